@@ -0,0 +1,165 @@
+package integrationtests
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/leodip/goiabada/internal/entities"
+	"github.com/leodip/goiabada/internal/lib"
+	"github.com/stretchr/testify/assert"
+)
+
+// addUserToGroupWithPermission creates a group that grants a permission, adds the user to it, and
+// returns a function to remove it
+func addUserToGroupWithPermission(t *testing.T, email string, groupIdentifier string,
+	resourceIdentifier string, permissionIdentifier string) func() {
+
+	user, err := database.GetUserByEmail(nil, email)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resource, err := database.GetResourceByResourceIdentifier(nil, resourceIdentifier)
+	if err != nil {
+		t.Fatal(err)
+	}
+	permissions, err := database.GetPermissionsByResourceId(nil, resource.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var permission *entities.Permission
+	for idx := range permissions {
+		if permissions[idx].PermissionIdentifier == permissionIdentifier {
+			permission = &permissions[idx]
+		}
+	}
+	if permission == nil {
+		t.Fatalf("permission %v:%v not found", resourceIdentifier, permissionIdentifier)
+	}
+
+	group := &entities.Group{
+		GroupIdentifier:      groupIdentifier,
+		Description:          "Access token profile test group",
+		IncludeInAccessToken: true,
+	}
+	err = database.CreateGroup(nil, group)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = database.CreateGroupPermission(nil, &entities.GroupPermission{
+		GroupId:      group.Id,
+		PermissionId: permission.Id,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = database.CreateUserGroup(nil, &entities.UserGroup{
+		UserId:  user.Id,
+		GroupId: group.Id,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return func() {
+		err := database.DeleteGroup(nil, group.Id)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestAccessTokenProfile_AuthCode(t *testing.T) {
+	setup()
+
+	removeGroup := addUserToGroupWithPermission(t, "mauro@outlook.com", "product-creators", "backend-svcA", "create-product")
+	defer removeGroup()
+
+	code, httpClient := createAuthCode(t, "openid groups backend-svcA:read-product backend-svcA:create-product")
+
+	formData := url.Values{
+		"client_id":     {"test-client-1"},
+		"client_secret": {getClientSecret(t, "test-client-1")},
+		"grant_type":    {"authorization_code"},
+		"redirect_uri":  {code.RedirectURI},
+		"code":          {code.Code},
+		"code_verifier": {"DdazqdVNuDmRLGGRGQKKehEaoFeatACtNsM2UYGwuHkhBhDsTSzaCqWttcBc0kGx"},
+	}
+	respData := postToTokenEndpoint(t, httpClient, lib.GetBaseUrl()+"/auth/token", formData)
+
+	token, claims := parseToken(t, respData["access_token"].(string))
+	assert.Equal(t, "at+jwt", token.Header["typ"])
+	assert.Equal(t, "test-client-1", claims["client_id"])
+	assert.Equal(t, code.User.Subject.String(), claims["sub"])
+	assert.ElementsMatch(t, []interface{}{"authserver", "backend-svcA"}, claims["aud"])
+	assert.Contains(t, claims["groups"], "product-creators")
+	assert.Equal(t, []interface{}{"backend-svcA:create-product"}, claims["roles"])
+	assert.Equal(t, []interface{}{"backend-svcA:read-product"}, claims["entitlements"])
+	assert.NotEmpty(t, claims["jti"])
+	assert.NotEmpty(t, claims["iat"])
+	assert.NotEmpty(t, claims["exp"])
+
+	// the id token is not affected
+	token, _ = parseToken(t, respData["id_token"].(string))
+	assert.Equal(t, "JWT", token.Header["typ"])
+}
+
+func TestAccessTokenProfile_Legacy(t *testing.T) {
+	setup()
+
+	restore := updateClient(t, "test-client-1", func(client *entities.Client) {
+		client.LegacyAccessTokenFormat = true
+	})
+	defer restore()
+
+	code, httpClient := createAuthCode(t, "openid backend-svcA:read-product")
+
+	clientSecret := getClientSecret(t, "test-client-1")
+	formData := url.Values{
+		"client_id":     {"test-client-1"},
+		"client_secret": {clientSecret},
+		"grant_type":    {"authorization_code"},
+		"redirect_uri":  {code.RedirectURI},
+		"code":          {code.Code},
+		"code_verifier": {"DdazqdVNuDmRLGGRGQKKehEaoFeatACtNsM2UYGwuHkhBhDsTSzaCqWttcBc0kGx"},
+	}
+	respData := postToTokenEndpoint(t, httpClient, lib.GetBaseUrl()+"/auth/token", formData)
+
+	token, claims := parseToken(t, respData["access_token"].(string))
+	assert.Equal(t, "JWT", token.Header["typ"])
+	assert.Equal(t, "test-client-1", claims["client_id"])
+	assert.Nil(t, claims["entitlements"])
+
+	formData = url.Values{
+		"client_id":     {"test-client-1"},
+		"client_secret": {clientSecret},
+		"grant_type":    {"client_credentials"},
+	}
+	respData = postToTokenEndpoint(t, httpClient, lib.GetBaseUrl()+"/auth/token", formData)
+
+	token, claims = parseToken(t, respData["access_token"].(string))
+	assert.Equal(t, "JWT", token.Header["typ"])
+	assert.Equal(t, "test-client-1", claims["client_id"])
+}
+
+func TestAccessTokenProfile_ClientCredentials(t *testing.T) {
+	setup()
+
+	httpClient := createHttpClient(&createHttpClientInput{
+		T: t,
+	})
+
+	formData := url.Values{
+		"client_id":     {"test-client-1"},
+		"client_secret": {getClientSecret(t, "test-client-1")},
+		"grant_type":    {"client_credentials"},
+	}
+	respData := postToTokenEndpoint(t, httpClient, lib.GetBaseUrl()+"/auth/token", formData)
+
+	token, claims := parseToken(t, respData["access_token"].(string))
+	assert.Equal(t, "at+jwt", token.Header["typ"])
+	assert.Equal(t, "test-client-1", claims["client_id"])
+	assert.Equal(t, "test-client-1", claims["sub"])
+}
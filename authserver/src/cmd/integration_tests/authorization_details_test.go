@@ -0,0 +1,309 @@
+package integrationtests
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/leodip/goiabada/internal/entities"
+	"github.com/leodip/goiabada/internal/lib"
+	"github.com/stretchr/testify/assert"
+)
+
+const paymentInitiationSchema = `{
+	"type": "object",
+	"required": ["instructedAmount"],
+	"properties": {
+		"type": {"const": "payment_initiation"},
+		"instructedAmount": {
+			"type": "object",
+			"required": ["currency", "amount"],
+			"properties": {
+				"currency": {"enum": ["EUR", "USD"]},
+				"amount": {"type": "string", "pattern": "^[0-9]+\\.[0-9]{2}$"}
+			}
+		},
+		"creditorAccount": {"type": "object"}
+	},
+	"additionalProperties": false
+}`
+
+const paymentInitiationDetails = `[{"type": "payment_initiation",
+	"instructedAmount": {"currency": "EUR", "amount": "500.00"},
+	"creditorAccount": {"iban": "DE02100100109307118603"}}]`
+
+func createAuthorizationDetailType(t *testing.T, resourceIdentifier string, detailType string,
+	jsonSchema string) *entities.AuthorizationDetailType {
+
+	resource, err := database.GetResourceByResourceIdentifier(nil, resourceIdentifier)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	authorizationDetailType := &entities.AuthorizationDetailType{
+		ResourceId:  resource.Id,
+		Type:        detailType,
+		Description: "Payment initiation",
+		JSONSchema:  jsonSchema,
+	}
+	err = database.CreateAuthorizationDetailType(nil, authorizationDetailType)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return authorizationDetailType
+}
+
+// authorizeWithAuthorizationDetails authenticates the user and opens the consent page of an authorization
+// request with the authorization_details parameter. It returns the consent page.
+func authorizeWithAuthorizationDetails(t *testing.T, scope string, authorizationDetails string) (*http.Client, *http.Response) {
+	deleteAllUserConsents(t)
+
+	httpClient := createHttpClient(&createHttpClientInput{
+		T: t,
+	})
+
+	resp, err := httpClient.Get(getAuthorizeUrl(scope, "&authorization_details="+url.QueryEscape(authorizationDetails)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	assertRedirect(t, resp, "/auth/pwd")
+
+	resp = getPage(t, httpClient, lib.GetBaseUrl()+"/auth/pwd")
+	defer resp.Body.Close()
+	csrf := getCsrfValue(t, resp)
+
+	resp = authenticateWithPassword(t, httpClient, "mauro@outlook.com", "abc123", csrf)
+	defer resp.Body.Close()
+	assertRedirect(t, resp, "/auth/consent")
+
+	return httpClient, getPage(t, httpClient, lib.GetBaseUrl()+"/auth/consent")
+}
+
+// postConsentWithAuthorizationDetails consents to the scopes and to the authorization details, and
+// exchanges the code for tokens
+func postConsentWithAuthorizationDetails(t *testing.T, httpClient *http.Client, csrf string, consents []int,
+	authorizationDetails []int) map[string]interface{} {
+
+	formData := url.Values{
+		"gorilla.csrf.Token": {csrf},
+		"btnSubmit":          {"submit"},
+	}
+	for _, consent := range consents {
+		formData.Add(fmt.Sprintf("consent%d", consent), "on")
+	}
+	for _, authorizationDetail := range authorizationDetails {
+		formData.Add(fmt.Sprintf("authorizationDetail%d", authorizationDetail), "on")
+	}
+
+	resp, err := httpClient.PostForm(lib.GetBaseUrl()+"/auth/consent", formData)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	assertRedirect(t, resp, "/callback.html")
+	codeVal, _ := getCodeAndStateFromUrl(t, resp)
+
+	tokenFormData := url.Values{
+		"client_id":     {"test-client-1"},
+		"client_secret": {getClientSecret(t, "test-client-1")},
+		"grant_type":    {"authorization_code"},
+		"redirect_uri":  {"https://goiabada-test-client:8090/callback.html"},
+		"code":          {codeVal},
+		"code_verifier": {"DdazqdVNuDmRLGGRGQKKehEaoFeatACtNsM2UYGwuHkhBhDsTSzaCqWttcBc0kGx"},
+	}
+	return postToTokenEndpoint(t, httpClient, lib.GetBaseUrl()+"/auth/token", tokenFormData)
+}
+
+func TestAuthorizationDetails_AuthCode(t *testing.T) {
+	setup()
+
+	authorizationDetailType := createAuthorizationDetailType(t, "backend-svcA", "payment_initiation", paymentInitiationSchema)
+	defer database.DeleteAuthorizationDetailType(nil, authorizationDetailType.Id)
+
+	httpClient, resp := authorizeWithAuthorizationDetails(t, "openid backend-svcA:read-product", paymentInitiationDetails)
+	defer resp.Body.Close()
+	csrf := getCsrfValue(t, resp)
+
+	// the consent page lists the authorization details
+	doc, err := goquery.NewDocumentFromReader(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	detailRow := doc.Find("input[name='authorizationDetail0']").ParentsFiltered("tr")
+	assert.Equal(t, 1, detailRow.Length())
+	assert.Equal(t, "payment_initiation", strings.TrimSpace(detailRow.Find("label").Text()))
+	assert.Contains(t, detailRow.Text(), "Payment initiation")
+	assert.Contains(t, detailRow.Text(), "instructedAmount")
+	assert.Contains(t, detailRow.Text(), "DE02100100109307118603")
+
+	respData := postConsentWithAuthorizationDetails(t, httpClient, csrf, []int{0, 1}, []int{0})
+
+	authorizationDetails := respData["authorization_details"].([]interface{})
+	assert.Len(t, authorizationDetails, 1)
+	assert.Equal(t, "payment_initiation", authorizationDetails[0].(map[string]interface{})["type"])
+
+	_, accessTokenClaims := parseToken(t, respData["access_token"].(string))
+	assert.Len(t, accessTokenClaims["authorization_details"], 1)
+	assert.Contains(t, accessTokenClaims["aud"], "backend-svcA")
+
+	// the refresh token keeps the authorization details
+	_, refreshTokenClaims := parseToken(t, respData["refresh_token"].(string))
+	refreshToken, err := database.GetRefreshTokenByJti(nil, refreshTokenClaims["jti"].(string))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Contains(t, refreshToken.AuthorizationDetails, "payment_initiation")
+
+	formData := url.Values{
+		"client_id":     {"test-client-1"},
+		"client_secret": {getClientSecret(t, "test-client-1")},
+		"grant_type":    {"refresh_token"},
+		"refresh_token": {respData["refresh_token"].(string)},
+	}
+	refreshRespData := postToTokenEndpoint(t, httpClient, lib.GetBaseUrl()+"/auth/token", formData)
+	assert.Len(t, refreshRespData["authorization_details"], 1)
+
+	// authorization details that were not granted can't be requested
+	formData.Set("refresh_token", refreshRespData["refresh_token"].(string))
+	formData.Set("authorization_details", `[{"type": "payment_initiation",
+		"instructedAmount": {"currency": "EUR", "amount": "900.00"}}]`)
+	refreshRespData = postToTokenEndpoint(t, httpClient, lib.GetBaseUrl()+"/auth/token", formData)
+	assert.Equal(t, "invalid_authorization_details", refreshRespData["error"])
+}
+
+func TestAuthorizationDetails_Declined(t *testing.T) {
+	setup()
+
+	authorizationDetailType := createAuthorizationDetailType(t, "backend-svcA", "payment_initiation", paymentInitiationSchema)
+	defer database.DeleteAuthorizationDetailType(nil, authorizationDetailType.Id)
+
+	httpClient, resp := authorizeWithAuthorizationDetails(t, "openid backend-svcA:read-product", paymentInitiationDetails)
+	defer resp.Body.Close()
+	csrf := getCsrfValue(t, resp)
+
+	respData := postConsentWithAuthorizationDetails(t, httpClient, csrf, []int{0, 1}, []int{})
+
+	assert.Nil(t, respData["authorization_details"])
+	_, accessTokenClaims := parseToken(t, respData["access_token"].(string))
+	assert.Nil(t, accessTokenClaims["authorization_details"])
+}
+
+func TestAuthorizationDetails_Invalid(t *testing.T) {
+	setup()
+
+	authorizationDetailType := createAuthorizationDetailType(t, "backend-svcA", "payment_initiation", paymentInitiationSchema)
+	defer database.DeleteAuthorizationDetailType(nil, authorizationDetailType.Id)
+
+	httpClient := createHttpClient(&createHttpClientInput{
+		T: t,
+	})
+
+	testCases := []string{
+		"not json",
+		`{"type": "payment_initiation"}`,
+		`[{"instructedAmount": {"currency": "EUR", "amount": "500.00"}}]`,
+		`[{"type": "unknown_type"}]`,
+		`[{"type": "payment_initiation", "instructedAmount": {"currency": "GBP", "amount": "500.00"}}]`,
+		`[{"type": "payment_initiation", "instructedAmount": {"currency": "EUR", "amount": "500"}}]`,
+		`[{"type": "payment_initiation", "instructedAmount": {"currency": "EUR", "amount": "500.00"}, "other": 1}]`,
+	}
+
+	for _, testCase := range testCases {
+		resp, err := httpClient.Get(getAuthorizeUrl("openid backend-svcA:read-product",
+			"&authorization_details="+url.QueryEscape(testCase)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		assertRedirectToClientWithError(t, resp, "invalid_authorization_details")
+	}
+}
+
+func TestAuthorizationDetails_PushedAuthRequest(t *testing.T) {
+	setup()
+
+	authorizationDetailType := createAuthorizationDetailType(t, "backend-svcA", "payment_initiation", paymentInitiationSchema)
+	defer database.DeleteAuthorizationDetailType(nil, authorizationDetailType.Id)
+
+	httpClient := createHttpClient(&createHttpClientInput{
+		T: t,
+	})
+
+	formData := getPushedAuthRequestFormData(t)
+	formData.Set("authorization_details", `[{"type": "payment_initiation"}]`)
+	resp, data := pushAuthRequest(t, httpClient, formData)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	assert.Equal(t, "invalid_authorization_details", data["error"])
+
+	formData.Set("authorization_details", paymentInitiationDetails)
+	resp, data = pushAuthRequest(t, httpClient, formData)
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+	assert.NotEmpty(t, data["request_uri"])
+}
+
+func TestAuthorizationDetails_ClientCredentials(t *testing.T) {
+	setup()
+
+	authorizationDetailType := createAuthorizationDetailType(t, "backend-svcA", "payment_initiation", paymentInitiationSchema)
+	defer database.DeleteAuthorizationDetailType(nil, authorizationDetailType.Id)
+
+	httpClient := createHttpClient(&createHttpClientInput{
+		T: t,
+	})
+
+	formData := url.Values{
+		"client_id":             {"test-client-1"},
+		"client_secret":         {getClientSecret(t, "test-client-1")},
+		"grant_type":            {"client_credentials"},
+		"scope":                 {"backend-svcB:read-info"},
+		"authorization_details": {paymentInitiationDetails},
+	}
+	respData := postToTokenEndpoint(t, httpClient, lib.GetBaseUrl()+"/auth/token", formData)
+	assert.Len(t, respData["authorization_details"], 1)
+
+	_, accessTokenClaims := parseToken(t, respData["access_token"].(string))
+	assert.Len(t, accessTokenClaims["authorization_details"], 1)
+	assert.ElementsMatch(t, []interface{}{"backend-svcA", "backend-svcB"}, accessTokenClaims["aud"])
+
+	// the client has no permissions on the resource of the type
+	resource := &entities.Resource{
+		ResourceIdentifier: "payments-api",
+		Description:        "Payments API",
+	}
+	err := database.CreateResource(nil, resource)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer database.DeleteResource(nil, resource.Id)
+
+	otherType := createAuthorizationDetailType(t, "payments-api", "account_information", "")
+	defer database.DeleteAuthorizationDetailType(nil, otherType.Id)
+
+	formData.Set("authorization_details", `[{"type": "account_information"}]`)
+	respData = postToTokenEndpoint(t, httpClient, lib.GetBaseUrl()+"/auth/token", formData)
+	assert.Equal(t, "invalid_authorization_details", respData["error"])
+}
+
+func TestAuthorizationDetails_Discovery(t *testing.T) {
+	setup()
+
+	authorizationDetailType := createAuthorizationDetailType(t, "backend-svcA", "payment_initiation", paymentInitiationSchema)
+	defer database.DeleteAuthorizationDetailType(nil, authorizationDetailType.Id)
+
+	httpClient := createHttpClient(&createHttpClientInput{
+		T: t,
+	})
+
+	resp, err := httpClient.Get(lib.GetBaseUrl() + "/.well-known/openid-configuration")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	data := unmarshalToMap(t, resp)
+	assert.Contains(t, data["authorization_details_types_supported"], "payment_initiation")
+}
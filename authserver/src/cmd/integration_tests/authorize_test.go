@@ -358,7 +358,7 @@ func TestAuthorize_InvalidResponseMode(t *testing.T) {
 	errorDescription := redirectLocation.Query().Get("error_description")
 
 	assert.Equal(t, "invalid_request", errorCode)
-	assert.Equal(t, "Please use 'query,' 'fragment,' or 'form_post' as the response_mode value.", errorDescription)
+	assert.Equal(t, "Please use 'query', 'fragment', 'form_post', 'query.jwt', 'fragment.jwt', 'form_post.jwt' or 'jwt' as the response_mode value.", errorDescription)
 }
 
 func TestAuthorize_AccetableResponseModes(t *testing.T) {
@@ -371,6 +371,10 @@ func TestAuthorize_AccetableResponseModes(t *testing.T) {
 		{responseMode: "query"},
 		{responseMode: "fragment"},
 		{responseMode: "form_post"},
+		{responseMode: "query.jwt"},
+		{responseMode: "fragment.jwt"},
+		{responseMode: "form_post.jwt"},
+		{responseMode: "jwt"},
 	}
 
 	for _, testCase := range testCases {
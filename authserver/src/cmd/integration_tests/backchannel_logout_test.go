@@ -0,0 +1,232 @@
+package integrationtests
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/leodip/goiabada/internal/entities"
+	"github.com/leodip/goiabada/internal/enums"
+	"github.com/leodip/goiabada/internal/lib"
+	"github.com/stretchr/testify/assert"
+)
+
+type logoutTokenReceiver struct {
+	mu           sync.Mutex
+	failFirst    int
+	requests     int
+	logoutTokens []string
+}
+
+func (l *logoutTokenReceiver) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.requests++
+	if l.requests <= l.failFirst {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	l.logoutTokens = append(l.logoutTokens, r.FormValue("logout_token"))
+	w.WriteHeader(http.StatusOK)
+}
+
+func (l *logoutTokenReceiver) received() (int, []string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.requests, append([]string{}, l.logoutTokens...)
+}
+
+func waitForLogoutTokens(t *testing.T, receiver *logoutTokenReceiver, count int) []string {
+	deadline := time.Now().Add(15 * time.Second)
+	for time.Now().Before(deadline) {
+		_, logoutTokens := receiver.received()
+		if len(logoutTokens) >= count {
+			return logoutTokens
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	t.Fatalf("timed out waiting for %v logout token(s)", count)
+	return nil
+}
+
+func getBackChannelLogoutDelivery(t *testing.T, clientId int64, sessionIdentifier string) *entities.BackChannelLogoutDelivery {
+	deliveries, err := database.GetBackChannelLogoutDeliveriesByClientId(nil, clientId, 20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for idx := range deliveries {
+		if deliveries[idx].SessionIdentifier == sessionIdentifier {
+			return &deliveries[idx]
+		}
+	}
+	return nil
+}
+
+func parseLogoutToken(t *testing.T, logoutToken string) (*jwt.Token, jwt.MapClaims) {
+	keyPair, err := database.GetCurrentSigningKey(nil, "RS256")
+	if err != nil {
+		t.Fatal(err)
+	}
+	pubKey, err := jwt.ParseRSAPublicKeyFromPEM(keyPair.PublicKeyPEM)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	claims := jwt.MapClaims{}
+	token, err := jwt.ParseWithClaims(logoutToken, claims, func(token *jwt.Token) (interface{}, error) {
+		return pubKey, nil
+	}, jwt.WithValidMethods([]string{"RS256"}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return token, claims
+}
+
+func TestBackChannelLogout_AccountLogout(t *testing.T) {
+	setup()
+
+	// the receiver fails the first attempt, so the delivery is retried
+	receiver := &logoutTokenReceiver{failFirst: 1}
+	srv := httptest.NewServer(receiver)
+	defer srv.Close()
+
+	restore := updateClient(t, "test-client-1", func(client *entities.Client) {
+		client.BackChannelLogoutURI = srv.URL + "/backchannel-logout"
+	})
+	defer restore()
+
+	code, httpClient := createAuthCode(t, "openid profile email")
+
+	destUrl := lib.GetBaseUrl() + "/auth/logout"
+	resp, err := httpClient.Get(destUrl)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	csrf := getCsrfValue(t, resp)
+
+	resp, err = httpClient.PostForm(destUrl, url.Values{
+		"gorilla.csrf.Token": {csrf},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusFound, resp.StatusCode)
+
+	// the session was deleted
+	userSession, err := database.GetUserSessionBySessionIdentifier(nil, code.SessionIdentifier)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Nil(t, userSession)
+
+	logoutTokens := waitForLogoutTokens(t, receiver, 1)
+	assert.Len(t, logoutTokens, 1)
+	requests, _ := receiver.received()
+	assert.Equal(t, 2, requests)
+
+	token, claims := parseLogoutToken(t, logoutTokens[0])
+	assert.Equal(t, "logout+jwt", token.Header["typ"])
+
+	settings, err := database.GetSettingsById(nil, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, settings.Issuer, claims["iss"])
+	assert.Equal(t, "test-client-1", claims["aud"])
+	assert.Equal(t, code.SessionIdentifier, claims["sid"])
+	assert.Equal(t, code.User.Subject.String(), claims["sub"])
+	assert.NotEmpty(t, claims["jti"])
+	assert.Nil(t, claims["nonce"])
+	events, ok := claims["events"].(map[string]interface{})
+	assert.True(t, ok)
+	assert.Contains(t, events, "http://schemas.openid.net/event/backchannel-logout")
+
+	// the delivery log is updated after the response is received, so poll for it
+	var delivery *entities.BackChannelLogoutDelivery
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		delivery = getBackChannelLogoutDelivery(t, code.ClientId, code.SessionIdentifier)
+		if delivery != nil && delivery.Status == enums.BackChannelLogoutStatusDelivered.String() {
+			break
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	if delivery == nil {
+		t.Fatal("the back-channel logout delivery was not found")
+	}
+	assert.Equal(t, enums.BackChannelLogoutStatusDelivered.String(), delivery.Status)
+	assert.Equal(t, 2, delivery.Attempts)
+	assert.Equal(t, srv.URL+"/backchannel-logout", delivery.LogoutURI)
+	assert.Equal(t, code.User.Subject.String(), delivery.Subject)
+	assert.True(t, delivery.LastAttemptAt.Valid)
+	assert.Empty(t, delivery.LastError)
+}
+
+func TestBackChannelLogout_AdminDeletesUserSession(t *testing.T) {
+	setup()
+
+	receiver := &logoutTokenReceiver{}
+	srv := httptest.NewServer(receiver)
+	defer srv.Close()
+
+	restore := updateClient(t, "test-client-1", func(client *entities.Client) {
+		client.BackChannelLogoutURI = srv.URL
+	})
+	defer restore()
+
+	code, _ := createAuthCode(t, "openid profile email")
+
+	userSession, err := database.GetUserSessionBySessionIdentifier(nil, code.SessionIdentifier)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if userSession == nil {
+		t.Fatal("the user session was not found")
+	}
+
+	httpClient := loginToAccountArea(t, "admin@example.com", "changeme")
+
+	destUrl := fmt.Sprintf("%v/admin/users/%v/sessions", lib.GetBaseUrl(), code.UserId)
+	resp, err := httpClient.Get(destUrl)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	csrf := getCsrfValue(t, resp)
+
+	req, err := http.NewRequest("POST", destUrl, strings.NewReader(fmt.Sprintf(`{"userSessionId": %d}`, userSession.Id)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-CSRF-Token", csrf)
+	resp, err = httpClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	result := unmarshalToMap(t, resp)
+	assert.True(t, result["Success"].(bool))
+
+	logoutTokens := waitForLogoutTokens(t, receiver, 1)
+	_, claims := parseLogoutToken(t, logoutTokens[0])
+	assert.Equal(t, "test-client-1", claims["aud"])
+	assert.Equal(t, code.SessionIdentifier, claims["sid"])
+	assert.Equal(t, code.User.Subject.String(), claims["sub"])
+
+	userSession, err = database.GetUserSessionBySessionIdentifier(nil, code.SessionIdentifier)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Nil(t, userSession)
+}
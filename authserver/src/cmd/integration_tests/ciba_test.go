@@ -0,0 +1,552 @@
+package integrationtests
+
+import (
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/leodip/goiabada/internal/entities"
+	"github.com/leodip/goiabada/internal/enums"
+	"github.com/leodip/goiabada/internal/lib"
+	"github.com/pquerna/otp/totp"
+	"github.com/stretchr/testify/assert"
+)
+
+func requestBackchannelAuthentication(t *testing.T, httpClient *http.Client, formData url.Values) map[string]interface{} {
+	destUrl := lib.GetBaseUrl() + "/auth/bc-authorize"
+
+	formData.Set("client_id", "test-client-1")
+	formData.Set("client_secret", getClientSecret(t, "test-client-1"))
+	return postToTokenEndpoint(t, httpClient, destUrl, formData)
+}
+
+func pollCIBAToken(t *testing.T, httpClient *http.Client, authReqId string) map[string]interface{} {
+	destUrl := lib.GetBaseUrl() + "/auth/token"
+
+	clientSecret := getClientSecret(t, "test-client-1")
+	formData := url.Values{
+		"grant_type":    {"urn:openid:params:grant-type:ciba"},
+		"client_id":     {"test-client-1"},
+		"client_secret": {clientSecret},
+		"auth_req_id":   {authReqId},
+	}
+	return postToTokenEndpoint(t, httpClient, destUrl, formData)
+}
+
+func getBackchannelAuthRequest(t *testing.T, authReqId string) *entities.BackchannelAuthRequest {
+	authReqIdHash, err := lib.HashString(authReqId)
+	if err != nil {
+		t.Fatal(err)
+	}
+	backchannelAuthRequest, err := database.GetBackchannelAuthRequestByAuthReqIdHash(nil, authReqIdHash)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if backchannelAuthRequest == nil {
+		t.Fatal("backchannel auth request not found")
+	}
+	return backchannelAuthRequest
+}
+
+// allowImmediatePoll moves the last poll to the past, so the client does not have to wait for the interval
+func allowImmediatePoll(t *testing.T, authReqId string) {
+	backchannelAuthRequest := getBackchannelAuthRequest(t, authReqId)
+	backchannelAuthRequest.LastPolledAt.Time = time.Now().UTC().Add(-time.Minute)
+	err := database.UpdateBackchannelAuthRequest(nil, backchannelAuthRequest)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func findAuthenticationRequestForm(t *testing.T, doc *goquery.Document, backchannelAuthRequestId int64) *goquery.Selection {
+	form := doc.Find("form").FilterFunction(func(i int, s *goquery.Selection) bool {
+		value, _ := s.Find("input[name='backchannelAuthRequestId']").Attr("value")
+		return value == strconv.FormatInt(backchannelAuthRequestId, 10)
+	})
+	if form.Length() != 1 {
+		t.Fatalf("expecting to find the form of authentication request %v", backchannelAuthRequestId)
+	}
+	return form
+}
+
+func postAuthenticationRequestDecision(t *testing.T, httpClient *http.Client, backchannelAuthRequestId int64,
+	formData url.Values) *http.Response {
+
+	resp := getPage(t, httpClient, lib.GetBaseUrl()+"/account/authentication-requests")
+	defer resp.Body.Close()
+
+	doc, err := goquery.NewDocumentFromReader(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// there is one form (and one csrf field) per pending request
+	csrf, exists := findAuthenticationRequestForm(t, doc, backchannelAuthRequestId).Find("input[name='gorilla.csrf.Token']").Attr("value")
+	if !exists {
+		t.Fatal("input 'gorilla.csrf.Token' was not found")
+	}
+
+	formData.Set("backchannelAuthRequestId", strconv.FormatInt(backchannelAuthRequestId, 10))
+	formData.Set("gorilla.csrf.Token", csrf)
+
+	request, err := http.NewRequest("POST", lib.GetBaseUrl()+"/account/authentication-requests", strings.NewReader(formData.Encode()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err = httpClient.Do(request)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return resp
+}
+
+func TestBackchannelAuthorize_FlowNotEnabled(t *testing.T) {
+	setup()
+
+	client, err := database.GetClientByClientIdentifier(nil, "test-client-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	client.CIBAEnabled = false
+	err = database.UpdateClient(nil, client)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		client.CIBAEnabled = true
+		err = database.UpdateClient(nil, client)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	httpClient := createHttpClient(&createHttpClientInput{
+		T: t,
+	})
+
+	data := requestBackchannelAuthentication(t, httpClient, url.Values{
+		"scope":           {"openid"},
+		"login_hint":      {"viviane@gmail.com"},
+		"binding_message": {"W4SCT"},
+	})
+
+	assert.Equal(t, "unauthorized_client", data["error"])
+	assert.Equal(t, "The client associated with the provided client_id does not support CIBA.", data["error_description"])
+}
+
+func TestBackchannelAuthorize_InvalidRequests(t *testing.T) {
+	setup()
+
+	httpClient := createHttpClient(&createHttpClientInput{
+		T: t,
+	})
+
+	testCases := []struct {
+		formData      url.Values
+		expectedError string
+	}{
+		{url.Values{"scope": {"profile"}, "login_hint": {"viviane@gmail.com"}, "binding_message": {"W4SCT"}}, "invalid_scope"},
+		{url.Values{"scope": {"openid"}, "binding_message": {"W4SCT"}}, "invalid_request"},
+		{url.Values{"scope": {"openid"}, "login_hint": {"viviane@gmail.com"}, "id_token_hint": {"abc"}, "binding_message": {"W4SCT"}}, "invalid_request"},
+		{url.Values{"scope": {"openid"}, "login_hint": {"nobody@example.com"}, "binding_message": {"W4SCT"}}, "unknown_user_id"},
+		{url.Values{"scope": {"openid"}, "login_hint": {"viviane@gmail.com"}}, "invalid_binding_message"},
+		{url.Values{"scope": {"openid"}, "login_hint": {"viviane@gmail.com"}, "binding_message": {strings.Repeat("a", 101)}}, "invalid_binding_message"},
+	}
+
+	for _, testCase := range testCases {
+		data := requestBackchannelAuthentication(t, httpClient, testCase.formData)
+		assert.Equal(t, testCase.expectedError, data["error"])
+	}
+}
+
+func TestBackchannelAuthorize_Success(t *testing.T) {
+	setup()
+
+	httpClient := createHttpClient(&createHttpClientInput{
+		T: t,
+	})
+
+	data := requestBackchannelAuthentication(t, httpClient, url.Values{
+		"scope":           {"openid   profile"},
+		"login_hint":      {"viviane@gmail.com"},
+		"binding_message": {"W4SCT"},
+		"acr_values":      {"urn:goiabada:pwd:otp_mandatory"},
+	})
+
+	assert.NotEmpty(t, data["auth_req_id"])
+	assert.Equal(t, float64(300), data["expires_in"])
+	assert.Equal(t, float64(5), data["interval"])
+
+	backchannelAuthRequest := getBackchannelAuthRequest(t, data["auth_req_id"].(string))
+	assert.Equal(t, "openid profile", backchannelAuthRequest.Scope)
+	assert.Equal(t, "W4SCT", backchannelAuthRequest.BindingMessage)
+	assert.Equal(t, enums.AcrLevel3.String(), backchannelAuthRequest.AcrLevel)
+	assert.Equal(t, enums.BackchannelAuthRequestStatePending.String(), backchannelAuthRequest.State)
+
+	user, err := database.GetUserByEmail(nil, "viviane@gmail.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, user.Id, backchannelAuthRequest.UserId)
+}
+
+func TestCIBAToken_AuthorizationPendingAndSlowDown(t *testing.T) {
+	setup()
+
+	httpClient := createHttpClient(&createHttpClientInput{
+		T: t,
+	})
+
+	data := requestBackchannelAuthentication(t, httpClient, url.Values{
+		"scope":           {"openid"},
+		"login_hint":      {"viviane@gmail.com"},
+		"binding_message": {"W4SCT"},
+	})
+	authReqId := data["auth_req_id"].(string)
+
+	data = pollCIBAToken(t, httpClient, authReqId)
+	assert.Equal(t, "authorization_pending", data["error"])
+
+	// polling again right away is faster than the allowed interval
+	data = pollCIBAToken(t, httpClient, authReqId)
+	assert.Equal(t, "slow_down", data["error"])
+
+	backchannelAuthRequest := getBackchannelAuthRequest(t, authReqId)
+	assert.Equal(t, 10, backchannelAuthRequest.IntervalInSeconds)
+}
+
+func TestCIBAToken_ExpiredToken(t *testing.T) {
+	setup()
+
+	httpClient := createHttpClient(&createHttpClientInput{
+		T: t,
+	})
+
+	data := requestBackchannelAuthentication(t, httpClient, url.Values{
+		"scope":           {"openid"},
+		"login_hint":      {"viviane@gmail.com"},
+		"binding_message": {"W4SCT"},
+	})
+	authReqId := data["auth_req_id"].(string)
+
+	backchannelAuthRequest := getBackchannelAuthRequest(t, authReqId)
+	backchannelAuthRequest.ExpiresAt = time.Now().UTC().Add(-time.Minute)
+	err := database.UpdateBackchannelAuthRequest(nil, backchannelAuthRequest)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data = pollCIBAToken(t, httpClient, authReqId)
+	assert.Equal(t, "expired_token", data["error"])
+}
+
+func TestCIBAToken_ClientAuthFailed(t *testing.T) {
+	setup()
+
+	httpClient := createHttpClient(&createHttpClientInput{
+		T: t,
+	})
+
+	data := requestBackchannelAuthentication(t, httpClient, url.Values{
+		"scope":           {"openid"},
+		"login_hint":      {"viviane@gmail.com"},
+		"binding_message": {"W4SCT"},
+	})
+	authReqId := data["auth_req_id"].(string)
+
+	destUrl := lib.GetBaseUrl() + "/auth/token"
+	formData := url.Values{
+		"grant_type":    {"urn:openid:params:grant-type:ciba"},
+		"client_id":     {"test-client-1"},
+		"client_secret": {"invalid"},
+		"auth_req_id":   {authReqId},
+	}
+	data = postToTokenEndpoint(t, httpClient, destUrl, formData)
+	assert.Equal(t, "invalid_grant", data["error"])
+	assert.Equal(t, "Client authentication failed. Please review your client_secret.", data["error_description"])
+}
+
+func TestCIBA_FullFlow(t *testing.T) {
+	setup()
+
+	httpClient := createHttpClient(&createHttpClientInput{
+		T: t,
+	})
+
+	data := requestBackchannelAuthentication(t, httpClient, url.Values{
+		"scope":           {"openid profile email"},
+		"login_hint":      {"viviane@gmail.com"},
+		"binding_message": {"Call 4587"},
+		"acr_values":      {"urn:goiabada:pwd"},
+	})
+	authReqId := data["auth_req_id"].(string)
+
+	data = pollCIBAToken(t, httpClient, authReqId)
+	assert.Equal(t, "authorization_pending", data["error"])
+
+	// the user sees the request on the account pages
+	accountClient := loginToAccountArea(t, "viviane@gmail.com", "asd123")
+	resp := getPage(t, accountClient, lib.GetBaseUrl()+"/account/authentication-requests")
+	defer resp.Body.Close()
+
+	doc, err := goquery.NewDocumentFromReader(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	backchannelAuthRequest := getBackchannelAuthRequest(t, authReqId)
+	form := findAuthenticationRequestForm(t, doc, backchannelAuthRequest.Id)
+	assert.Equal(t, "Call 4587", form.Find("span.text-accent").Text())
+	assert.Equal(t, 0, form.Find("input[name='otp']").Length())
+
+	resp = postAuthenticationRequestDecision(t, accountClient, backchannelAuthRequest.Id, url.Values{
+		"password":   {"asd123"},
+		"btnApprove": {"approve"},
+	})
+	defer resp.Body.Close()
+	assertRedirect(t, resp, "/account/authentication-requests")
+
+	allowImmediatePoll(t, authReqId)
+	data = pollCIBAToken(t, httpClient, authReqId)
+
+	assert.Equal(t, "Bearer", data["token_type"])
+	assert.Equal(t, "openid profile email authserver:userinfo", data["scope"])
+	assert.NotEmpty(t, data["access_token"])
+	assert.NotEmpty(t, data["refresh_token"])
+
+	_, idTokenClaims := parseToken(t, data["id_token"].(string))
+	assert.Equal(t, enums.AcrLevel1.String(), idTokenClaims["acr"])
+	assert.Equal(t, "pwd", idTokenClaims["amr"])
+	assert.Equal(t, "test-client-1", idTokenClaims["aud"])
+	assert.NotEmpty(t, idTokenClaims["sid"])
+
+	// the client is now tracked in the user session where the request was approved
+	userSession, err := database.GetUserSessionBySessionIdentifier(nil, idTokenClaims["sid"].(string))
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = database.UserSessionLoadClients(nil, userSession)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client, err := database.GetClientByClientIdentifier(nil, "test-client-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	clientFound := false
+	for _, userSessionClient := range userSession.Clients {
+		if userSessionClient.ClientId == client.Id {
+			clientFound = true
+		}
+	}
+	assert.True(t, clientFound)
+
+	// the auth_req_id can only be redeemed once
+	allowImmediatePoll(t, authReqId)
+	data = pollCIBAToken(t, httpClient, authReqId)
+	assert.Equal(t, "invalid_grant", data["error"])
+}
+
+func TestCIBA_OTPRequired(t *testing.T) {
+	setup()
+
+	httpClient := createHttpClient(&createHttpClientInput{
+		T: t,
+	})
+
+	user, err := database.GetUserByEmail(nil, "mauro@outlook.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !user.OTPEnabled {
+		t.Fatal("expecting the user to have OTP enabled")
+	}
+
+	data := requestBackchannelAuthentication(t, httpClient, url.Values{
+		"scope":           {"openid"},
+		"login_hint":      {"mauro@outlook.com"},
+		"binding_message": {"Call 9911"},
+		"acr_values":      {"urn:goiabada:pwd:otp_mandatory"},
+	})
+	authReqId := data["auth_req_id"].(string)
+	backchannelAuthRequest := getBackchannelAuthRequest(t, authReqId)
+
+	accountClient := loginToAccountArea(t, "mauro@outlook.com", "abc123")
+	resp := getPage(t, accountClient, lib.GetBaseUrl()+"/account/authentication-requests")
+	defer resp.Body.Close()
+
+	doc, err := goquery.NewDocumentFromReader(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, 1, findAuthenticationRequestForm(t, doc, backchannelAuthRequest.Id).Find("input[name='otp']").Length())
+
+	// the password alone is not enough
+	resp = postAuthenticationRequestDecision(t, accountClient, backchannelAuthRequest.Id, url.Values{
+		"password":   {"abc123"},
+		"btnApprove": {"approve"},
+	})
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	doc, err = goquery.NewDocumentFromReader(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "OTP code is required.", strings.TrimSpace(findAuthenticationRequestForm(t, doc, backchannelAuthRequest.Id).Find("div.text-error p").Text()))
+
+	otp, err := totp.GenerateCode(user.OTPSecret, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp = postAuthenticationRequestDecision(t, accountClient, backchannelAuthRequest.Id, url.Values{
+		"password":   {"abc123"},
+		"otp":        {otp},
+		"btnApprove": {"approve"},
+	})
+	defer resp.Body.Close()
+	assertRedirect(t, resp, "/account/authentication-requests")
+
+	data = pollCIBAToken(t, httpClient, authReqId)
+	assert.NotEmpty(t, data["access_token"])
+
+	_, idTokenClaims := parseToken(t, data["id_token"].(string))
+	assert.Equal(t, enums.AcrLevel3.String(), idTokenClaims["acr"])
+	assert.Equal(t, "pwd otp", idTokenClaims["amr"])
+}
+
+func TestCIBA_WrongPassword(t *testing.T) {
+	setup()
+
+	httpClient := createHttpClient(&createHttpClientInput{
+		T: t,
+	})
+
+	data := requestBackchannelAuthentication(t, httpClient, url.Values{
+		"scope":           {"openid"},
+		"login_hint":      {"viviane@gmail.com"},
+		"binding_message": {"W4SCT"},
+		"acr_values":      {"urn:goiabada:pwd"},
+	})
+	authReqId := data["auth_req_id"].(string)
+	backchannelAuthRequest := getBackchannelAuthRequest(t, authReqId)
+
+	accountClient := loginToAccountArea(t, "viviane@gmail.com", "asd123")
+	resp := postAuthenticationRequestDecision(t, accountClient, backchannelAuthRequest.Id, url.Values{
+		"password":   {"wrong"},
+		"btnApprove": {"approve"},
+	})
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	doc, err := goquery.NewDocumentFromReader(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "Authentication failed. Check your password and try again.", strings.TrimSpace(findAuthenticationRequestForm(t, doc, backchannelAuthRequest.Id).Find("div.text-error p").Text()))
+
+	backchannelAuthRequest = getBackchannelAuthRequest(t, authReqId)
+	assert.Equal(t, enums.BackchannelAuthRequestStatePending.String(), backchannelAuthRequest.State)
+}
+
+func TestCIBA_Denied(t *testing.T) {
+	setup()
+
+	httpClient := createHttpClient(&createHttpClientInput{
+		T: t,
+	})
+
+	data := requestBackchannelAuthentication(t, httpClient, url.Values{
+		"scope":           {"openid"},
+		"login_hint":      {"viviane@gmail.com"},
+		"binding_message": {"W4SCT"},
+	})
+	authReqId := data["auth_req_id"].(string)
+	backchannelAuthRequest := getBackchannelAuthRequest(t, authReqId)
+
+	accountClient := loginToAccountArea(t, "viviane@gmail.com", "asd123")
+	resp := postAuthenticationRequestDecision(t, accountClient, backchannelAuthRequest.Id, url.Values{
+		"btnDeny": {"deny"},
+	})
+	defer resp.Body.Close()
+	assertRedirect(t, resp, "/account/authentication-requests")
+
+	data = pollCIBAToken(t, httpClient, authReqId)
+	assert.Equal(t, "access_denied", data["error"])
+}
+
+func TestCIBA_DecisionOnUnknownRequest(t *testing.T) {
+	setup()
+
+	httpClient := createHttpClient(&createHttpClientInput{
+		T: t,
+	})
+
+	data := requestBackchannelAuthentication(t, httpClient, url.Values{
+		"scope":           {"openid"},
+		"login_hint":      {"viviane@gmail.com"},
+		"binding_message": {"W4SCT"},
+	})
+	backchannelAuthRequest := getBackchannelAuthRequest(t, data["auth_req_id"].(string))
+
+	// a request of another user
+	data = requestBackchannelAuthentication(t, httpClient, url.Values{
+		"scope":           {"openid"},
+		"login_hint":      {"mauro@outlook.com"},
+		"binding_message": {"W4SCT"},
+	})
+	otherUserBackchannelAuthRequest := getBackchannelAuthRequest(t, data["auth_req_id"].(string))
+
+	accountClient := loginToAccountArea(t, "viviane@gmail.com", "asd123")
+
+	resp := getPage(t, accountClient, lib.GetBaseUrl()+"/account/authentication-requests")
+	defer resp.Body.Close()
+	doc, err := goquery.NewDocumentFromReader(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	csrf, exists := findAuthenticationRequestForm(t, doc, backchannelAuthRequest.Id).Find("input[name='gorilla.csrf.Token']").Attr("value")
+	if !exists {
+		t.Fatal("input 'gorilla.csrf.Token' was not found")
+	}
+
+	backchannelAuthRequestIds := []string{
+		"abc",
+		"999999999",
+		strconv.FormatInt(otherUserBackchannelAuthRequest.Id, 10),
+	}
+	for _, backchannelAuthRequestId := range backchannelAuthRequestIds {
+		t.Run(backchannelAuthRequestId, func(t *testing.T) {
+			formData := url.Values{
+				"backchannelAuthRequestId": {backchannelAuthRequestId},
+				"btnDeny":                  {"deny"},
+				"gorilla.csrf.Token":       {csrf},
+			}
+			request, err := http.NewRequest("POST", lib.GetBaseUrl()+"/account/authentication-requests", strings.NewReader(formData.Encode()))
+			if err != nil {
+				t.Fatal(err)
+			}
+			request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+			resp, err := accountClient.Do(request)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer resp.Body.Close()
+
+			assert.Equal(t, http.StatusOK, resp.StatusCode)
+			doc, err := goquery.NewDocumentFromReader(resp.Body)
+			if err != nil {
+				t.Fatal(err)
+			}
+			assert.Equal(t, "The authentication request was not found.", strings.TrimSpace(doc.Find("p.text-error").Text()))
+		})
+	}
+
+	otherUserBackchannelAuthRequest = getBackchannelAuthRequest(t, data["auth_req_id"].(string))
+	assert.Equal(t, enums.BackchannelAuthRequestStatePending.String(), otherUserBackchannelAuthRequest.State)
+}
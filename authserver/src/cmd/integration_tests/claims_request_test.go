@@ -0,0 +1,189 @@
+package integrationtests
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/leodip/goiabada/internal/lib"
+	"github.com/stretchr/testify/assert"
+)
+
+// authorizeWithClaims authenticates the user and opens the consent page of an authorization request
+// with the claims parameter. It returns the consent page.
+func authorizeWithClaims(t *testing.T, scope string, claims string) (*http.Client, *http.Response) {
+	deleteAllUserConsents(t)
+
+	httpClient := createHttpClient(&createHttpClientInput{
+		T: t,
+	})
+
+	resp, err := httpClient.Get(getAuthorizeUrl(scope, "&claims="+url.QueryEscape(claims)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	assertRedirect(t, resp, "/auth/pwd")
+
+	resp = getPage(t, httpClient, lib.GetBaseUrl()+"/auth/pwd")
+	defer resp.Body.Close()
+	csrf := getCsrfValue(t, resp)
+
+	resp = authenticateWithPassword(t, httpClient, "mauro@outlook.com", "abc123", csrf)
+	defer resp.Body.Close()
+	assertRedirect(t, resp, "/auth/consent")
+
+	return httpClient, getPage(t, httpClient, lib.GetBaseUrl()+"/auth/consent")
+}
+
+// postConsentWithClaims consents to the scopes and to the individually requested claims, and
+// exchanges the code for tokens
+func postConsentWithClaims(t *testing.T, httpClient *http.Client, csrf string, consents []int, claims []int) map[string]interface{} {
+	formData := url.Values{
+		"gorilla.csrf.Token": {csrf},
+		"btnSubmit":          {"submit"},
+	}
+	for _, consent := range consents {
+		formData.Add(fmt.Sprintf("consent%d", consent), "on")
+	}
+	for _, claim := range claims {
+		formData.Add(fmt.Sprintf("claim%d", claim), "on")
+	}
+
+	resp, err := httpClient.PostForm(lib.GetBaseUrl()+"/auth/consent", formData)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	assertRedirect(t, resp, "/callback.html")
+	codeVal, _ := getCodeAndStateFromUrl(t, resp)
+
+	tokenFormData := url.Values{
+		"client_id":     {"test-client-1"},
+		"client_secret": {getClientSecret(t, "test-client-1")},
+		"grant_type":    {"authorization_code"},
+		"redirect_uri":  {"https://goiabada-test-client:8090/callback.html"},
+		"code":          {codeVal},
+		"code_verifier": {"DdazqdVNuDmRLGGRGQKKehEaoFeatACtNsM2UYGwuHkhBhDsTSzaCqWttcBc0kGx"},
+	}
+	return postToTokenEndpoint(t, httpClient, lib.GetBaseUrl()+"/auth/token", tokenFormData)
+}
+
+func TestClaimsRequest_IdTokenAndUserInfo(t *testing.T) {
+	setup()
+
+	claims := `{
+		"id_token": {"email": {"essential": true}, "locale": null},
+		"userinfo": {"phone_number": null, "given_name": {"value": "Mauro"}, "family_name": {"values": ["Smith", "Jones"]}}
+	}`
+	httpClient, resp := authorizeWithClaims(t, "openid backend-svcA:read-product", claims)
+	defer resp.Body.Close()
+	csrf := getCsrfValue(t, resp)
+
+	// the consent page lists the claims requested individually
+	doc, err := goquery.NewDocumentFromReader(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	claimRows := doc.Find("input[name^='claim']").ParentsFiltered("tr")
+	assert.Equal(t, 5, claimRows.Length())
+	expectedClaims := []string{"email", "family_name", "given_name", "locale", "phone_number"}
+	claimRows.Each(func(i int, row *goquery.Selection) {
+		assert.Equal(t, expectedClaims[i], strings.TrimSpace(row.Find("label").Text()))
+	})
+	assert.Contains(t, claimRows.Eq(0).Text(), "essential")
+	assert.NotContains(t, claimRows.Eq(3).Text(), "essential")
+
+	respData := postConsentWithClaims(t, httpClient, csrf, []int{0, 1}, []int{0, 1, 2, 3, 4})
+
+	_, idTokenClaims := parseToken(t, respData["id_token"].(string))
+	assert.Equal(t, "mauro@outlook.com", idTokenClaims["email"])
+	assert.Equal(t, "pt-BR", idTokenClaims["locale"])
+	assert.Nil(t, idTokenClaims["email_verified"])
+	assert.Nil(t, idTokenClaims["name"])
+	assert.Nil(t, idTokenClaims["phone_number"])
+
+	_, accessTokenClaims := parseToken(t, respData["access_token"].(string))
+	assert.NotNil(t, accessTokenClaims["userinfo_claims"])
+
+	userInfo := getUserInfo(t, httpClient, respData["access_token"].(string))
+	assert.Equal(t, idTokenClaims["sub"], userInfo["sub"])
+	assert.Equal(t, "+351 912156387", userInfo["phone_number"])
+	assert.Equal(t, "Mauro", userInfo["given_name"])
+	// the family name doesn't match the requested values
+	assert.Nil(t, userInfo["family_name"])
+	assert.Nil(t, userInfo["email"])
+	assert.Nil(t, userInfo["locale"])
+}
+
+func TestClaimsRequest_WithScopes(t *testing.T) {
+	setup()
+
+	// the claims of the requested scopes are not listed individually
+	claims := `{"id_token": {"email": null, "given_name": {"value": "Someone else"}}}`
+	httpClient, resp := authorizeWithClaims(t, "openid profile backend-svcA:read-product", claims)
+	defer resp.Body.Close()
+	csrf := getCsrfValue(t, resp)
+
+	doc, err := goquery.NewDocumentFromReader(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, 1, doc.Find("input[name^='claim']").Length())
+	assert.Equal(t, "email", strings.TrimSpace(doc.Find("label[for='claim0']").Text()))
+
+	// decline the email claim
+	respData := postConsentWithClaims(t, httpClient, csrf, []int{0, 1, 2}, []int{})
+
+	_, idTokenClaims := parseToken(t, respData["id_token"].(string))
+	assert.Nil(t, idTokenClaims["email"])
+	assert.Equal(t, "Golias", idTokenClaims["family_name"])
+	// the value constraint also applies to the claims of the scope
+	assert.Nil(t, idTokenClaims["given_name"])
+}
+
+func TestClaimsRequest_Invalid(t *testing.T) {
+	setup()
+
+	httpClient := createHttpClient(&createHttpClientInput{
+		T: t,
+	})
+
+	testCases := []struct {
+		scope  string
+		claims string
+	}{
+		{"openid profile", "not json"},
+		{"openid profile", `{"id_token": {"email": {"essential": "yes"}}}`},
+		{"backend-svcA:read-product", `{"id_token": {"email": null}}`},
+	}
+
+	for _, testCase := range testCases {
+		resp, err := httpClient.Get(getAuthorizeUrl(testCase.scope, "&claims="+url.QueryEscape(testCase.claims)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		assertRedirectToClientWithError(t, resp, "invalid_request")
+	}
+}
+
+func TestClaimsRequest_Discovery(t *testing.T) {
+	setup()
+
+	httpClient := createHttpClient(&createHttpClientInput{
+		T: t,
+	})
+
+	resp, err := httpClient.Get(lib.GetBaseUrl() + "/.well-known/openid-configuration")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	config := unmarshalToMap(t, resp)
+	assert.Equal(t, true, config["claims_parameter_supported"])
+}
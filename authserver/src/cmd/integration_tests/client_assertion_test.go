@@ -0,0 +1,227 @@
+package integrationtests
+
+import (
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/leodip/goiabada/internal/constants"
+	"github.com/leodip/goiabada/internal/entities"
+	"github.com/leodip/goiabada/internal/enums"
+	"github.com/leodip/goiabada/internal/lib"
+	"github.com/stretchr/testify/assert"
+)
+
+func getClientAssertionClaims(clientIdentifier string) jwt.MapClaims {
+	return jwt.MapClaims{
+		"iss": clientIdentifier,
+		"sub": clientIdentifier,
+		"aud": lib.GetBaseUrl() + "/auth/token",
+		"jti": uuid.New().String(),
+		"exp": time.Now().Add(time.Minute).Unix(),
+	}
+}
+
+func getClientCredentialsFormData(clientAssertion string) url.Values {
+	return url.Values{
+		"grant_type":            {"client_credentials"},
+		"client_id":             {"test-client-1"},
+		"client_assertion_type": {constants.ClientAssertionTypeJwtBearer},
+		"client_assertion":      {clientAssertion},
+		"scope":                 {"backend-svcA:create-product"},
+	}
+}
+
+func TestClientAssertion_PrivateKeyJwt(t *testing.T) {
+	setup()
+
+	privKey, err := lib.GeneratePrivateKey(2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer setClientJWKS(t, "test-client-1", privKey)()
+	defer updateClient(t, "test-client-1", func(client *entities.Client) {
+		client.TokenEndpointAuthMethod = enums.TokenEndpointAuthMethodPrivateKeyJwt.String()
+	})()
+
+	httpClient := createHttpClient(&createHttpClientInput{
+		T: t,
+	})
+	destUrl := lib.GetBaseUrl() + "/auth/token"
+
+	clientAssertion := createRequestObject(t, privKey, getClientAssertionClaims("test-client-1"))
+	data := postToTokenEndpoint(t, httpClient, destUrl, getClientCredentialsFormData(clientAssertion))
+
+	assert.Equal(t, "Bearer", data["token_type"])
+	assert.Equal(t, "backend-svcA:create-product", data["scope"])
+	assert.NotEmpty(t, data["access_token"])
+
+	// the same assertion can't be used twice
+	data = postToTokenEndpoint(t, httpClient, destUrl, getClientCredentialsFormData(clientAssertion))
+	assert.Equal(t, "invalid_client", data["error"])
+	assert.Equal(t, "Client authentication failed. The client_assertion has already been used.", data["error_description"])
+}
+
+func TestClientAssertion_PrivateKeyJwt_InvalidAssertion(t *testing.T) {
+	setup()
+
+	privKey, err := lib.GeneratePrivateKey(2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherPrivKey, err := lib.GeneratePrivateKey(2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer setClientJWKS(t, "test-client-1", privKey)()
+	defer updateClient(t, "test-client-1", func(client *entities.Client) {
+		client.TokenEndpointAuthMethod = enums.TokenEndpointAuthMethodPrivateKeyJwt.String()
+	})()
+
+	httpClient := createHttpClient(&createHttpClientInput{
+		T: t,
+	})
+	destUrl := lib.GetBaseUrl() + "/auth/token"
+
+	testCases := []struct {
+		privKeyOther     bool
+		claim            string
+		value            interface{}
+		errorDescription string
+	}{
+		{true, "", nil, "Client authentication failed. The client_assertion is invalid. Its signature could not be verified, or it has expired."},
+		{false, "exp", time.Now().Add(-time.Minute).Unix(), "Client authentication failed. The client_assertion is invalid. Its signature could not be verified, or it has expired."},
+		{false, "sub", "test-client-2", "Client authentication failed. The iss and sub claims of the client_assertion must be the client_id."},
+		{false, "aud", "https://example.com", "Client authentication failed. The aud claim of the client_assertion must be the issuer or the token endpoint URL."},
+		{false, "jti", "", "Client authentication failed. The client_assertion is missing the jti claim."},
+	}
+
+	for _, testCase := range testCases {
+		claims := getClientAssertionClaims("test-client-1")
+		if len(testCase.claim) > 0 {
+			claims[testCase.claim] = testCase.value
+		}
+		signingKey := privKey
+		if testCase.privKeyOther {
+			signingKey = otherPrivKey
+		}
+
+		data := postToTokenEndpoint(t, httpClient, destUrl,
+			getClientCredentialsFormData(createRequestObject(t, signingKey, claims)))
+		assert.Equal(t, "invalid_client", data["error"])
+		assert.Equal(t, testCase.errorDescription, data["error_description"])
+	}
+}
+
+func TestClientAssertion_ClientSecretNotAllowed(t *testing.T) {
+	setup()
+
+	privKey, err := lib.GeneratePrivateKey(2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer setClientJWKS(t, "test-client-1", privKey)()
+	defer updateClient(t, "test-client-1", func(client *entities.Client) {
+		client.TokenEndpointAuthMethod = enums.TokenEndpointAuthMethodPrivateKeyJwt.String()
+	})()
+
+	httpClient := createHttpClient(&createHttpClientInput{
+		T: t,
+	})
+	destUrl := lib.GetBaseUrl() + "/auth/token"
+
+	formData := url.Values{
+		"grant_type":    {"client_credentials"},
+		"client_id":     {"test-client-1"},
+		"client_secret": {getClientSecret(t, "test-client-1")},
+	}
+	data := postToTokenEndpoint(t, httpClient, destUrl, formData)
+	assert.Equal(t, "invalid_request", data["error"])
+	assert.Equal(t, "This client is configured to authenticate with private_key_jwt, which means the client_secret must not be sent. To proceed, please remove the client_secret from your request.", data["error_description"])
+
+	formData.Del("client_secret")
+	data = postToTokenEndpoint(t, httpClient, destUrl, formData)
+	assert.Equal(t, "invalid_request", data["error"])
+	assert.Equal(t, "This client is configured to authenticate with private_key_jwt, which means a client_assertion is required for authentication. Please provide a valid client_assertion to proceed.", data["error_description"])
+}
+
+func TestClientAssertion_ClientSecretJwt(t *testing.T) {
+	setup()
+
+	defer updateClient(t, "test-client-1", func(client *entities.Client) {
+		client.TokenEndpointAuthMethod = enums.TokenEndpointAuthMethodClientSecretJwt.String()
+	})()
+
+	httpClient := createHttpClient(&createHttpClientInput{
+		T: t,
+	})
+	destUrl := lib.GetBaseUrl() + "/auth/token"
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, getClientAssertionClaims("test-client-1"))
+	clientAssertion, err := token.SignedString([]byte(getClientSecret(t, "test-client-1")))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data := postToTokenEndpoint(t, httpClient, destUrl, getClientCredentialsFormData(clientAssertion))
+	assert.Equal(t, "Bearer", data["token_type"])
+	assert.NotEmpty(t, data["access_token"])
+
+	token = jwt.NewWithClaims(jwt.SigningMethodHS256, getClientAssertionClaims("test-client-1"))
+	clientAssertion, err = token.SignedString([]byte("not-the-client-secret"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data = postToTokenEndpoint(t, httpClient, destUrl, getClientCredentialsFormData(clientAssertion))
+	assert.Equal(t, "invalid_client", data["error"])
+	assert.Equal(t, "Client authentication failed. The client_assertion is invalid. Its signature could not be verified, or it has expired.", data["error_description"])
+}
+
+func TestClientAssertion_NotConfiguredForClient(t *testing.T) {
+	setup()
+
+	privKey, err := lib.GeneratePrivateKey(2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer setClientJWKS(t, "test-client-1", privKey)()
+
+	httpClient := createHttpClient(&createHttpClientInput{
+		T: t,
+	})
+	destUrl := lib.GetBaseUrl() + "/auth/token"
+
+	clientAssertion := createRequestObject(t, privKey, getClientAssertionClaims("test-client-1"))
+	data := postToTokenEndpoint(t, httpClient, destUrl, getClientCredentialsFormData(clientAssertion))
+	assert.Equal(t, "invalid_client", data["error"])
+	assert.Equal(t, "The client associated with the provided client_id is not configured to authenticate with a client_assertion.", data["error_description"])
+}
+
+func TestClientAssertion_TokenIntrospection(t *testing.T) {
+	setup()
+
+	privKey, err := lib.GeneratePrivateKey(2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer setClientJWKS(t, "test-client-1", privKey)()
+	defer updateClient(t, "test-client-1", func(client *entities.Client) {
+		client.TokenEndpointAuthMethod = enums.TokenEndpointAuthMethodPrivateKeyJwt.String()
+	})()
+
+	httpClient := createHttpClient(&createHttpClientInput{
+		T: t,
+	})
+
+	formData := url.Values{
+		"client_id":             {"test-client-1"},
+		"client_assertion_type": {constants.ClientAssertionTypeJwtBearer},
+		"client_assertion":      {createRequestObject(t, privKey, getClientAssertionClaims("test-client-1"))},
+		"token":                 {"not-a-token"},
+	}
+	data := postToTokenEndpoint(t, httpClient, lib.GetBaseUrl()+"/auth/introspect", formData)
+	assert.Equal(t, false, data["active"])
+}
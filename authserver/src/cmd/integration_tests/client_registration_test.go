@@ -0,0 +1,396 @@
+package integrationtests
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/x509/pkix"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	b64 "encoding/base64"
+
+	"github.com/leodip/goiabada/internal/entities"
+	"github.com/leodip/goiabada/internal/lib"
+	"github.com/stretchr/testify/assert"
+)
+
+// createInitialAccessToken creates an initial access token for dynamic client registration,
+// and returns the token and a func to delete it
+func createInitialAccessToken(t *testing.T, expiresAt sql.NullTime) (string, func()) {
+	token := lib.GenerateSecureRandomString(60)
+	tokenHash, err := lib.HashString(token)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	initialAccessToken := &entities.InitialAccessToken{
+		TokenHash:   tokenHash,
+		Description: "Integration tests",
+		ExpiresAt:   expiresAt,
+	}
+	err = database.CreateInitialAccessToken(nil, initialAccessToken)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return token, func() {
+		err = database.DeleteInitialAccessToken(nil, initialAccessToken.Id)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func sendClientRegistrationRequest(t *testing.T, client *http.Client, method string, destUrl string,
+	bearerToken string, body interface{}) (*http.Response, map[string]interface{}) {
+
+	var bodyReader *bytes.Reader
+	if body != nil {
+		bodyBytes, err := json.Marshal(body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		bodyReader = bytes.NewReader(bodyBytes)
+	} else {
+		bodyReader = bytes.NewReader(nil)
+	}
+
+	request, err := http.NewRequest(method, destUrl, bodyReader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	request.Header.Set("Content-Type", "application/json")
+	if len(bearerToken) > 0 {
+		request.Header.Set("Authorization", "Bearer "+bearerToken)
+	}
+
+	resp, err := client.Do(request)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNoContent {
+		return resp, nil
+	}
+	return resp, unmarshalToMap(t, resp)
+}
+
+func TestClientRegistration_RegisterAndManage(t *testing.T) {
+	setup()
+
+	initialAccessToken, deleteInitialAccessToken := createInitialAccessToken(t, sql.NullTime{})
+	defer deleteInitialAccessToken()
+
+	httpClient := createHttpClient(&createHttpClientInput{
+		T: t,
+	})
+	registrationUrl := lib.GetBaseUrl() + "/connect/register"
+
+	metadata := map[string]interface{}{
+		"client_name":                "Preview environment 42",
+		"redirect_uris":              []string{"https://pr-42.preview.example.com/callback"},
+		"web_origins":                []string{"https://pr-42.preview.example.com"},
+		"grant_types":                []string{"authorization_code", "client_credentials"},
+		"token_endpoint_auth_method": "client_secret_post",
+	}
+	resp, data := sendClientRegistrationRequest(t, httpClient, "POST", registrationUrl, initialAccessToken, metadata)
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+	assert.Equal(t, "no-store", resp.Header.Get("Cache-Control"))
+
+	clientId := data["client_id"].(string)
+	clientSecret := data["client_secret"].(string)
+	registrationAccessToken := data["registration_access_token"].(string)
+	assert.True(t, strings.HasPrefix(clientId, "dyn-"))
+	assert.NotEmpty(t, clientSecret)
+	assert.NotEmpty(t, registrationAccessToken)
+	assert.Equal(t, float64(0), data["client_secret_expires_at"])
+	assert.Equal(t, registrationUrl+"/"+clientId, data["registration_client_uri"])
+	assert.Equal(t, "Preview environment 42", data["client_name"])
+	assert.Equal(t, "client_secret_post", data["token_endpoint_auth_method"])
+	assert.Equal(t, []interface{}{"authorization_code", "refresh_token", "client_credentials"}, data["grant_types"])
+	assert.Equal(t, []interface{}{"code"}, data["response_types"])
+	assert.Equal(t, []interface{}{"https://pr-42.preview.example.com/callback"}, data["redirect_uris"])
+	assert.Equal(t, []interface{}{"https://pr-42.preview.example.com"}, data["web_origins"])
+
+	client, err := database.GetClientByClientIdentifier(nil, clientId)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.True(t, client.IsDynamicallyRegistered)
+	assert.True(t, client.ConsentRequired)
+	assert.True(t, client.AuthorizationCodeEnabled)
+	assert.True(t, client.ClientCredentialsEnabled)
+	assert.False(t, client.IsPublic)
+
+	// the client secret authenticates the client at the token endpoint
+	formData := url.Values{
+		"grant_type":    {"client_credentials"},
+		"client_id":     {clientId},
+		"client_secret": {clientSecret},
+		"scope":         {"backend-svcA:create-product"},
+	}
+	tokenData := postToTokenEndpoint(t, httpClient, lib.GetBaseUrl()+"/auth/token", formData)
+	assert.Equal(t, "invalid_scope", tokenData["error"])
+
+	formData.Set("client_secret", "invalid")
+	tokenData = postToTokenEndpoint(t, httpClient, lib.GetBaseUrl()+"/auth/token", formData)
+	assert.Equal(t, "invalid_client", tokenData["error"])
+
+	// read
+	registrationClientUri := data["registration_client_uri"].(string)
+	resp, data = sendClientRegistrationRequest(t, httpClient, "GET", registrationClientUri, registrationAccessToken, nil)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, clientId, data["client_id"])
+	assert.Equal(t, clientSecret, data["client_secret"])
+	assert.Nil(t, data["registration_access_token"])
+
+	// update
+	metadata = map[string]interface{}{
+		"client_id":                  clientId,
+		"client_name":                "Preview environment 42 (updated)",
+		"redirect_uris":              []string{"https://pr-42.preview.example.com/callback2", "http://localhost:3000/callback"},
+		"token_endpoint_auth_method": "client_secret_basic",
+	}
+	resp, data = sendClientRegistrationRequest(t, httpClient, "PUT", registrationClientUri, registrationAccessToken, metadata)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "Preview environment 42 (updated)", data["client_name"])
+	assert.Equal(t, "client_secret_basic", data["token_endpoint_auth_method"])
+	assert.Equal(t, clientSecret, data["client_secret"])
+	assert.Equal(t, []interface{}{"authorization_code", "refresh_token"}, data["grant_types"])
+	assert.Equal(t, []interface{}{"https://pr-42.preview.example.com/callback2", "http://localhost:3000/callback"}, data["redirect_uris"])
+	assert.Nil(t, data["web_origins"])
+
+	client, err = database.GetClientByClientIdentifier(nil, clientId)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.False(t, client.ClientCredentialsEnabled)
+	webOrigins, err := database.GetWebOriginsByClientId(nil, client.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Empty(t, webOrigins)
+
+	metadata["client_id"] = "other-client"
+	resp, data = sendClientRegistrationRequest(t, httpClient, "PUT", registrationClientUri, registrationAccessToken, metadata)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	assert.Equal(t, "invalid_client_metadata", data["error"])
+	assert.Equal(t, "The client_id in the request body must match the client being updated.", data["error_description"])
+
+	// delete
+	resp, _ = sendClientRegistrationRequest(t, httpClient, "DELETE", registrationClientUri, registrationAccessToken, nil)
+	assert.Equal(t, http.StatusNoContent, resp.StatusCode)
+
+	client, err = database.GetClientByClientIdentifier(nil, clientId)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Nil(t, client)
+
+	resp, data = sendClientRegistrationRequest(t, httpClient, "GET", registrationClientUri, registrationAccessToken, nil)
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	assert.Equal(t, "invalid_token", data["error"])
+}
+
+func TestClientRegistration_PublicClient(t *testing.T) {
+	setup()
+
+	initialAccessToken, deleteInitialAccessToken := createInitialAccessToken(t, sql.NullTime{})
+	defer deleteInitialAccessToken()
+
+	httpClient := createHttpClient(&createHttpClientInput{
+		T: t,
+	})
+
+	metadata := map[string]interface{}{
+		"redirect_uris":              []string{"http://localhost:5173/callback"},
+		"token_endpoint_auth_method": "none",
+	}
+	resp, data := sendClientRegistrationRequest(t, httpClient, "POST", lib.GetBaseUrl()+"/connect/register", initialAccessToken, metadata)
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+	assert.Equal(t, "none", data["token_endpoint_auth_method"])
+	assert.Nil(t, data["client_secret"])
+	assert.Nil(t, data["client_secret_expires_at"])
+
+	client, err := database.GetClientByClientIdentifier(nil, data["client_id"].(string))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer database.DeleteClient(nil, client.Id)
+	assert.True(t, client.IsPublic)
+	assert.Empty(t, client.ClientSecretEncrypted)
+
+	metadata["grant_types"] = []string{"authorization_code", "client_credentials"}
+	resp, data = sendClientRegistrationRequest(t, httpClient, "POST", lib.GetBaseUrl()+"/connect/register", initialAccessToken, metadata)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	assert.Equal(t, "invalid_client_metadata", data["error"])
+	assert.Equal(t, "A public client (token_endpoint_auth_method none) can't use the client_credentials grant type.", data["error_description"])
+}
+
+func TestClientRegistration_SelfSignedTLSClientAuth(t *testing.T) {
+	setup()
+	skipIfMTLSNotConfigured(t)
+
+	initialAccessToken, deleteInitialAccessToken := createInitialAccessToken(t, sql.NullTime{})
+	defer deleteInitialAccessToken()
+
+	httpClient := createHttpClient(&createHttpClientInput{
+		T: t,
+	})
+
+	certificate := createCertificate(t, pkix.Name{CommonName: "preview-42"}, false, nil)
+	metadata := map[string]interface{}{
+		"grant_types":                []string{"client_credentials"},
+		"token_endpoint_auth_method": "self_signed_tls_client_auth",
+		"jwks": map[string]interface{}{
+			"keys": []interface{}{createJWKWithCertificate(t, certificate)},
+		},
+	}
+	resp, data := sendClientRegistrationRequest(t, httpClient, "POST", lib.GetBaseUrl()+"/connect/register", initialAccessToken, metadata)
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+	assert.Equal(t, "self_signed_tls_client_auth", data["token_endpoint_auth_method"])
+	assert.Nil(t, data["client_secret"])
+
+	client, err := database.GetClientByClientIdentifier(nil, data["client_id"].(string))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer database.DeleteClient(nil, client.Id)
+	assert.Equal(t, certificate.PEM, client.TLSClientCertificate)
+
+	// a key without the certificate
+	metadata["jwks"] = map[string]interface{}{
+		"keys": []interface{}{createJWKWithCertificate(t, nil)},
+	}
+	resp, data = sendClientRegistrationRequest(t, httpClient, "POST", lib.GetBaseUrl()+"/connect/register", initialAccessToken, metadata)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	assert.Equal(t, "invalid_client_metadata", data["error"])
+	assert.Equal(t, "The self_signed_tls_client_auth method requires a jwks with the certificate of the client in the x5c parameter.", data["error_description"])
+}
+
+// createJWKWithCertificate returns a JWK with the public key of the certificate and,
+// when the certificate is not nil, the certificate in the x5c parameter
+func createJWKWithCertificate(t *testing.T, certificate *testCertificate) map[string]interface{} {
+	if certificate == nil {
+		certificate = createCertificate(t, pkix.Name{CommonName: "no-x5c"}, false, nil)
+		jwk := createJWKWithCertificate(t, certificate)
+		delete(jwk, "x5c")
+		return jwk
+	}
+
+	publicKey := certificate.Cert.PublicKey.(*ecdsa.PublicKey)
+	jwk := map[string]interface{}{
+		"kty": "EC",
+		"crv": "P-256",
+		"kid": "key-1",
+		"x":   b64.RawURLEncoding.EncodeToString(publicKey.X.FillBytes(make([]byte, 32))),
+		"y":   b64.RawURLEncoding.EncodeToString(publicKey.Y.FillBytes(make([]byte, 32))),
+	}
+	jwk["x5c"] = []string{b64.StdEncoding.EncodeToString(certificate.Cert.Raw)}
+	return jwk
+}
+
+func TestClientRegistration_InvalidInitialAccessToken(t *testing.T) {
+	setup()
+
+	expiredToken, deleteExpiredToken := createInitialAccessToken(t, sql.NullTime{
+		Time:  time.Now().UTC().Add(-time.Minute),
+		Valid: true,
+	})
+	defer deleteExpiredToken()
+
+	httpClient := createHttpClient(&createHttpClientInput{
+		T: t,
+	})
+	metadata := map[string]interface{}{
+		"redirect_uris": []string{"https://pr-43.preview.example.com/callback"},
+	}
+
+	testCases := []string{"", "invalid", expiredToken}
+	for _, token := range testCases {
+		resp, data := sendClientRegistrationRequest(t, httpClient, "POST", lib.GetBaseUrl()+"/connect/register", token, metadata)
+		assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+		assert.Equal(t, `Bearer error="invalid_token"`, resp.Header.Get("WWW-Authenticate"))
+		assert.Equal(t, "invalid_token", data["error"])
+		assert.Equal(t, "The initial access token is missing, invalid or expired. Please provide a valid initial access token in the Authorization header.", data["error_description"])
+	}
+}
+
+func TestClientRegistration_InvalidMetadata(t *testing.T) {
+	setup()
+
+	initialAccessToken, deleteInitialAccessToken := createInitialAccessToken(t, sql.NullTime{})
+	defer deleteInitialAccessToken()
+
+	httpClient := createHttpClient(&createHttpClientInput{
+		T: t,
+	})
+
+	testCases := []struct {
+		metadata            map[string]interface{}
+		expectedError       string
+		expectedDescription string
+	}{
+		{
+			metadata:            map[string]interface{}{},
+			expectedError:       "invalid_redirect_uri",
+			expectedDescription: "At least one redirect URI is required for the authorization_code grant type.",
+		},
+		{
+			metadata:            map[string]interface{}{"redirect_uris": []string{"https://example.com/callback#fragment"}},
+			expectedError:       "invalid_redirect_uri",
+			expectedDescription: "Invalid redirect URI 'https://example.com/callback#fragment'. It must be an absolute URI without a fragment, with up to 256 characters.",
+		},
+		{
+			metadata:            map[string]interface{}{"grant_types": []string{"implicit"}},
+			expectedError:       "invalid_client_metadata",
+			expectedDescription: "Unsupported grant type 'implicit'.",
+		},
+		{
+			metadata:            map[string]interface{}{"grant_types": []string{"client_credentials"}, "token_endpoint_auth_method": "private_key_jwt"},
+			expectedError:       "invalid_client_metadata",
+			expectedDescription: "The private_key_jwt method requires the jwks or the jwks_uri of the client.",
+		},
+		{
+			metadata:            map[string]interface{}{"grant_types": []string{"client_credentials"}, "web_origins": []string{"https://example.com/path"}},
+			expectedError:       "invalid_client_metadata",
+			expectedDescription: "Invalid web origin 'https://example.com/path'. It must be an http or https origin, without a path.",
+		},
+	}
+
+	for _, testCase := range testCases {
+		resp, data := sendClientRegistrationRequest(t, httpClient, "POST", lib.GetBaseUrl()+"/connect/register", initialAccessToken, testCase.metadata)
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+		assert.Equal(t, testCase.expectedError, data["error"])
+		assert.Equal(t, testCase.expectedDescription, data["error_description"])
+	}
+}
+
+func TestClientRegistration_InvalidRegistrationAccessToken(t *testing.T) {
+	setup()
+
+	httpClient := createHttpClient(&createHttpClientInput{
+		T: t,
+	})
+
+	// test-client-1 was not dynamically registered
+	resp, data := sendClientRegistrationRequest(t, httpClient, "GET", lib.GetBaseUrl()+"/connect/register/test-client-1",
+		getClientSecret(t, "test-client-1"), nil)
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	assert.Equal(t, "invalid_token", data["error"])
+
+	resp, _ = sendClientRegistrationRequest(t, httpClient, "DELETE", lib.GetBaseUrl()+"/connect/register/test-client-1", "invalid", nil)
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+
+	client, err := database.GetClientByClientIdentifier(nil, "test-client-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.NotNil(t, client)
+}
@@ -0,0 +1,165 @@
+package integrationtests
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+
+	b64 "encoding/base64"
+
+	"github.com/leodip/goiabada/internal/entities"
+	"github.com/leodip/goiabada/internal/enums"
+	"github.com/leodip/goiabada/internal/lib"
+	"github.com/stretchr/testify/assert"
+)
+
+func postToTokenEndpointWithBasicAuth(t *testing.T, client *http.Client, destUrl string, formData url.Values,
+	clientId string, clientSecret string) map[string]interface{} {
+
+	request, err := http.NewRequest("POST", destUrl, strings.NewReader(formData.Encode()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	credentials := url.QueryEscape(clientId) + ":" + url.QueryEscape(clientSecret)
+	request.Header.Set("Authorization", "Basic "+b64.StdEncoding.EncodeToString([]byte(credentials)))
+
+	resp, err := client.Do(request)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	return unmarshalToMap(t, resp)
+}
+
+// setClientSecret changes the secret of the client, and returns a func to restore it
+func setClientSecret(t *testing.T, clientIdentifier string, clientSecret string) func() {
+	settings, err := database.GetSettingsById(nil, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	clientSecretEncrypted, err := lib.EncryptText(clientSecret, settings.AESEncryptionKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return updateClient(t, clientIdentifier, func(client *entities.Client) {
+		client.ClientSecretEncrypted = clientSecretEncrypted
+	})
+}
+
+func TestClientSecretBasic_ClientCred(t *testing.T) {
+	setup()
+
+	// characters that must be form-urlencoded before being base64 encoded
+	const clientSecret = "a+b/c%d:e f&g"
+	defer setClientSecret(t, "test-client-1", clientSecret)()
+	defer updateClient(t, "test-client-1", func(client *entities.Client) {
+		client.TokenEndpointAuthMethod = enums.TokenEndpointAuthMethodClientSecretBasic.String()
+	})()
+
+	httpClient := createHttpClient(&createHttpClientInput{
+		T: t,
+	})
+	destUrl := lib.GetBaseUrl() + "/auth/token"
+
+	formData := url.Values{
+		"grant_type": {"client_credentials"},
+		"scope":      {"backend-svcA:create-product"},
+	}
+	data := postToTokenEndpointWithBasicAuth(t, httpClient, destUrl, formData, "test-client-1", clientSecret)
+	assert.Equal(t, "Bearer", data["token_type"])
+	assert.Equal(t, "backend-svcA:create-product", data["scope"])
+	assert.NotEmpty(t, data["access_token"])
+
+	data = postToTokenEndpointWithBasicAuth(t, httpClient, destUrl, formData, "test-client-1", "invalid")
+	assert.Equal(t, "invalid_client", data["error"])
+	assert.Equal(t, "Client authentication failed.", data["error_description"])
+
+	// the secret in the request body is not accepted for this client
+	formData.Set("client_id", "test-client-1")
+	formData.Set("client_secret", clientSecret)
+	data = postToTokenEndpoint(t, httpClient, destUrl, formData)
+	assert.Equal(t, "invalid_client", data["error"])
+	assert.Equal(t, "This client is configured to authenticate with client_secret_basic. Please send the client credentials in the Authorization header.", data["error_description"])
+}
+
+func TestClientSecretBasic_NotAllowedForClient(t *testing.T) {
+	setup()
+
+	httpClient := createHttpClient(&createHttpClientInput{
+		T: t,
+	})
+	destUrl := lib.GetBaseUrl() + "/auth/token"
+
+	formData := url.Values{
+		"grant_type": {"client_credentials"},
+		"scope":      {"backend-svcA:create-product"},
+	}
+	data := postToTokenEndpointWithBasicAuth(t, httpClient, destUrl, formData, "test-client-1", getClientSecret(t, "test-client-1"))
+	assert.Equal(t, "invalid_client", data["error"])
+	assert.Equal(t, "This client is configured to authenticate with client_secret_post. Please send the client_secret in the request body.", data["error_description"])
+}
+
+func TestClientSecretBasic_MixedMethods(t *testing.T) {
+	setup()
+
+	defer updateClient(t, "test-client-1", func(client *entities.Client) {
+		client.TokenEndpointAuthMethod = enums.TokenEndpointAuthMethodClientSecretBasic.String()
+	})()
+
+	httpClient := createHttpClient(&createHttpClientInput{
+		T: t,
+	})
+	destUrl := lib.GetBaseUrl() + "/auth/token"
+	clientSecret := getClientSecret(t, "test-client-1")
+
+	formData := url.Values{
+		"grant_type":    {"client_credentials"},
+		"client_secret": {clientSecret},
+	}
+	data := postToTokenEndpointWithBasicAuth(t, httpClient, destUrl, formData, "test-client-1", clientSecret)
+	assert.Equal(t, "invalid_request", data["error"])
+	assert.Equal(t, "The client must use only one authentication method. Please send the client credentials either in the Authorization header or in the request body, but not both.", data["error_description"])
+
+	formData = url.Values{
+		"grant_type": {"client_credentials"},
+		"client_id":  {"test-client-2"},
+	}
+	data = postToTokenEndpointWithBasicAuth(t, httpClient, destUrl, formData, "test-client-1", clientSecret)
+	assert.Equal(t, "invalid_request", data["error"])
+	assert.Equal(t, "The client_id parameter does not match the client identifier in the Authorization header.", data["error_description"])
+}
+
+func TestClientSecretBasic_AuthCodeAndRefresh(t *testing.T) {
+	setup()
+	scope := "openid profile email"
+	code, httpClient := createAuthCode(t, scope)
+
+	defer updateClient(t, "test-client-1", func(client *entities.Client) {
+		client.TokenEndpointAuthMethod = enums.TokenEndpointAuthMethodClientSecretBasic.String()
+	})()
+
+	destUrl := lib.GetBaseUrl() + "/auth/token"
+	clientSecret := getClientSecret(t, "test-client-1")
+
+	formData := url.Values{
+		"grant_type":    {"authorization_code"},
+		"redirect_uri":  {code.RedirectURI},
+		"code":          {code.Code},
+		"code_verifier": {"DdazqdVNuDmRLGGRGQKKehEaoFeatACtNsM2UYGwuHkhBhDsTSzaCqWttcBc0kGx"},
+	}
+	respData := postToTokenEndpointWithBasicAuth(t, httpClient, destUrl, formData, "test-client-1", clientSecret)
+	assert.Equal(t, "Bearer", respData["token_type"])
+	assert.NotEmpty(t, respData["refresh_token"])
+
+	formData = url.Values{
+		"grant_type":    {"refresh_token"},
+		"refresh_token": {respData["refresh_token"].(string)},
+	}
+	respData = postToTokenEndpointWithBasicAuth(t, httpClient, destUrl, formData, "test-client-1", clientSecret)
+	assert.Equal(t, "Bearer", respData["token_type"])
+	assert.NotEmpty(t, respData["access_token"])
+}
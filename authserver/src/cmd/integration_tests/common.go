@@ -6,11 +6,9 @@ import (
 	"crypto/cipher"
 	"crypto/rand"
 	"crypto/tls"
-	"crypto/x509"
 	"database/sql"
 	"encoding/base64"
 	"encoding/json"
-	"encoding/pem"
 	"fmt"
 	"io"
 	"log"
@@ -26,7 +24,9 @@ import (
 	"log/slog"
 
 	"github.com/PuerkitoBio/goquery"
+	"github.com/golang-jwt/jwt/v5"
 	"github.com/google/uuid"
+	"github.com/leodip/goiabada/internal/core"
 	"github.com/leodip/goiabada/internal/data"
 	"github.com/leodip/goiabada/internal/entities"
 	"github.com/leodip/goiabada/internal/enums"
@@ -724,26 +724,8 @@ func getCodeAndStateFromUrl(t *testing.T, resp *http.Response) (code string, sta
 }
 
 func createNewKeyPair(t *testing.T) *entities.KeyPair {
-	privateKey, err := lib.GeneratePrivateKey(4096)
-	if err != nil {
-		t.Fatal("unable to generate a private key")
-	}
-	privateKeyPEM := lib.EncodePrivateKeyToPEM(privateKey)
-
-	publicKeyASN1_DER, err := x509.MarshalPKIXPublicKey(&privateKey.PublicKey)
-	if err != nil {
-		t.Fatal("unable to marshal public key to PKIX")
-	}
-
-	publicKeyPEM := pem.EncodeToMemory(
-		&pem.Block{
-			Type:  "RSA PUBLIC KEY",
-			Bytes: publicKeyASN1_DER,
-		},
-	)
-
 	kid := uuid.New().String()
-	publicKeyJWK, err := lib.MarshalRSAPublicKeyToJWK(&privateKey.PublicKey, kid)
+	signingKeyPair, err := lib.GenerateSigningKeyPair("RS256", kid)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -751,12 +733,12 @@ func createNewKeyPair(t *testing.T) *entities.KeyPair {
 	keyPair := &entities.KeyPair{
 		State:             enums.KeyStateCurrent.String(),
 		KeyIdentifier:     kid,
-		Type:              "RSA",
+		Type:              signingKeyPair.Type,
 		Algorithm:         "RS256",
-		PrivateKeyPEM:     privateKeyPEM,
-		PublicKeyPEM:      publicKeyPEM,
-		PublicKeyASN1_DER: publicKeyASN1_DER,
-		PublicKeyJWK:      publicKeyJWK,
+		PrivateKeyPEM:     signingKeyPair.PrivateKeyPEM,
+		PublicKeyPEM:      signingKeyPair.PublicKeyPEM,
+		PublicKeyASN1_DER: signingKeyPair.PublicKeyASN1_DER,
+		PublicKeyJWK:      signingKeyPair.PublicKeyJWK,
 	}
 	return keyPair
 }
@@ -961,3 +943,84 @@ func aesGcmEncryption(t *testing.T, idTokenUnencrypted string, clientSecret stri
 
 	return base64.StdEncoding.EncodeToString(encrypted)
 }
+
+func authenticateWithPasswordAndOtp(t *testing.T, httpClient *http.Client, email string, password string) *http.Response {
+	resp := getPage(t, httpClient, lib.GetBaseUrl()+"/auth/pwd")
+	defer resp.Body.Close()
+	csrf := getCsrfValue(t, resp)
+
+	resp = authenticateWithPassword(t, httpClient, email, password, csrf)
+
+	user, err := database.GetUserByEmail(nil, email)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if user.OTPEnabled {
+		resp.Body.Close()
+		assertRedirect(t, resp, "/auth/otp")
+		resp = getPage(t, httpClient, lib.GetBaseUrl()+"/auth/otp")
+		defer resp.Body.Close()
+		csrf = getCsrfValue(t, resp)
+
+		otp, err := totp.GenerateCode(user.OTPSecret, time.Now())
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp = authenticateWithOtp(t, httpClient, otp, csrf)
+	}
+	return resp
+}
+
+// updateClient changes the client with the update func, and returns a func that restores the
+// previous settings of the client
+func updateClient(t *testing.T, clientIdentifier string, update func(client *entities.Client)) func() {
+	client, err := database.GetClientByClientIdentifier(nil, clientIdentifier)
+	if err != nil {
+		t.Fatal(err)
+	}
+	previous := *client
+	update(client)
+	err = database.UpdateClient(nil, client)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return func() {
+		err := database.UpdateClient(nil, &previous)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// parseToken parses a token issued by the server and verifies its signature. The claims are not
+// validated, so the tests can inspect them as issued.
+func parseToken(t *testing.T, tokenStr string) (*jwt.Token, jwt.MapClaims) {
+	claims := jwt.MapClaims{}
+	token, err := jwt.ParseWithClaims(tokenStr, claims, core.GetVerificationKeyFunc(database), jwt.WithoutClaimsValidation())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return token, claims
+}
+
+// requestUserInfo calls the userinfo endpoint and returns the raw response, which can be JSON or a JWT
+func requestUserInfo(t *testing.T, httpClient *http.Client, accessToken string) *http.Response {
+	request, err := http.NewRequest("GET", lib.GetBaseUrl()+"/userinfo", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	request.Header.Set("Authorization", "Bearer "+accessToken)
+	resp, err := httpClient.Do(request)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return resp
+}
+
+// getUserInfo calls the userinfo endpoint and returns its plain JSON response
+func getUserInfo(t *testing.T, httpClient *http.Client, accessToken string) map[string]interface{} {
+	resp := requestUserInfo(t, httpClient, accessToken)
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	return unmarshalToMap(t, resp)
+}
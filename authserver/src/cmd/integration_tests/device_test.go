@@ -0,0 +1,498 @@
+package integrationtests
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+	core_authorize "github.com/leodip/goiabada/internal/core/authorize"
+	"github.com/leodip/goiabada/internal/enums"
+	"github.com/leodip/goiabada/internal/lib"
+	"github.com/stretchr/testify/assert"
+)
+
+func requestDeviceCode(t *testing.T, httpClient *http.Client, scope string) map[string]interface{} {
+	destUrl := lib.GetBaseUrl() + "/auth/device_authorization"
+
+	clientSecret := getClientSecret(t, "test-client-1")
+	formData := url.Values{
+		"client_id":     {"test-client-1"},
+		"client_secret": {clientSecret},
+		"scope":         {scope},
+	}
+	return postToTokenEndpoint(t, httpClient, destUrl, formData)
+}
+
+func postDeviceUserCode(t *testing.T, httpClient *http.Client, userCode string) *http.Response {
+	resp := getPage(t, httpClient, lib.GetBaseUrl()+"/device")
+	defer resp.Body.Close()
+	csrf := getCsrfValue(t, resp)
+
+	formData := url.Values{
+		"userCode":           {userCode},
+		"gorilla.csrf.Token": {csrf},
+	}
+
+	request, err := http.NewRequest("POST", lib.GetBaseUrl()+"/device", strings.NewReader(formData.Encode()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err = httpClient.Do(request)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return resp
+}
+
+func pollDeviceToken(t *testing.T, httpClient *http.Client, deviceCode string) map[string]interface{} {
+	destUrl := lib.GetBaseUrl() + "/auth/token"
+
+	clientSecret := getClientSecret(t, "test-client-1")
+	formData := url.Values{
+		"grant_type":    {"urn:ietf:params:oauth:grant-type:device_code"},
+		"client_id":     {"test-client-1"},
+		"client_secret": {clientSecret},
+		"device_code":   {deviceCode},
+	}
+	return postToTokenEndpoint(t, httpClient, destUrl, formData)
+}
+
+func TestDeviceAuthorization_MissingClientId(t *testing.T) {
+	setup()
+
+	destUrl := lib.GetBaseUrl() + "/auth/device_authorization"
+
+	httpClient := createHttpClient(&createHttpClientInput{
+		T: t,
+	})
+
+	formData := url.Values{}
+	data := postToTokenEndpoint(t, httpClient, destUrl, formData)
+
+	assert.Equal(t, "invalid_request", data["error"])
+	assert.Equal(t, "Missing required client_id parameter.", data["error_description"])
+}
+
+func TestDeviceAuthorization_FlowNotEnabled(t *testing.T) {
+	setup()
+
+	client, err := database.GetClientByClientIdentifier(nil, "test-client-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	client.DeviceCodeEnabled = false
+	err = database.UpdateClient(nil, client)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		client.DeviceCodeEnabled = true
+		err = database.UpdateClient(nil, client)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	httpClient := createHttpClient(&createHttpClientInput{
+		T: t,
+	})
+
+	data := requestDeviceCode(t, httpClient, "openid")
+
+	assert.Equal(t, "unauthorized_client", data["error"])
+	assert.Equal(t, "The client associated with the provided client_id does not support device code flow.", data["error_description"])
+}
+
+func TestDeviceAuthorization_InvalidScope(t *testing.T) {
+	setup()
+
+	httpClient := createHttpClient(&createHttpClientInput{
+		T: t,
+	})
+
+	data := requestDeviceCode(t, httpClient, "openid invalid-scope")
+
+	assert.Equal(t, "invalid_scope", data["error"])
+}
+
+func TestDeviceAuthorization_Success(t *testing.T) {
+	setup()
+
+	httpClient := createHttpClient(&createHttpClientInput{
+		T: t,
+	})
+
+	data := requestDeviceCode(t, httpClient, "openid profile")
+
+	assert.NotEmpty(t, data["device_code"])
+	assert.Regexp(t, "^[A-Z]{4}-[A-Z]{4}$", data["user_code"])
+	assert.Equal(t, lib.GetBaseUrl()+"/device", data["verification_uri"])
+	assert.Equal(t, lib.GetBaseUrl()+"/device?user_code="+data["user_code"].(string), data["verification_uri_complete"])
+	assert.Equal(t, float64(600), data["expires_in"])
+	assert.Equal(t, float64(5), data["interval"])
+
+	deviceCodeHash, err := lib.HashString(data["device_code"].(string))
+	if err != nil {
+		t.Fatal(err)
+	}
+	deviceCode, err := database.GetDeviceCodeByDeviceCodeHash(nil, deviceCodeHash)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.NotNil(t, deviceCode)
+	assert.Equal(t, "openid profile", deviceCode.Scope)
+	assert.Equal(t, enums.DeviceCodeStatePending.String(), deviceCode.State)
+	assert.Equal(t, data["user_code"], deviceCode.UserCode)
+}
+
+func TestDeviceToken_AuthorizationPendingAndSlowDown(t *testing.T) {
+	setup()
+
+	httpClient := createHttpClient(&createHttpClientInput{
+		T: t,
+	})
+
+	data := requestDeviceCode(t, httpClient, "openid")
+	deviceCode := data["device_code"].(string)
+
+	data = pollDeviceToken(t, httpClient, deviceCode)
+	assert.Equal(t, "authorization_pending", data["error"])
+
+	// polling again right away is faster than the allowed interval
+	data = pollDeviceToken(t, httpClient, deviceCode)
+	assert.Equal(t, "slow_down", data["error"])
+
+	deviceCodeHash, err := lib.HashString(deviceCode)
+	if err != nil {
+		t.Fatal(err)
+	}
+	deviceCodeEntity, err := database.GetDeviceCodeByDeviceCodeHash(nil, deviceCodeHash)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, 10, deviceCodeEntity.IntervalInSeconds)
+}
+
+func TestDeviceToken_ExpiredToken(t *testing.T) {
+	setup()
+
+	httpClient := createHttpClient(&createHttpClientInput{
+		T: t,
+	})
+
+	data := requestDeviceCode(t, httpClient, "openid")
+	deviceCode := data["device_code"].(string)
+
+	deviceCodeHash, err := lib.HashString(deviceCode)
+	if err != nil {
+		t.Fatal(err)
+	}
+	deviceCodeEntity, err := database.GetDeviceCodeByDeviceCodeHash(nil, deviceCodeHash)
+	if err != nil {
+		t.Fatal(err)
+	}
+	deviceCodeEntity.ExpiresAt = time.Now().UTC().Add(-time.Minute)
+	err = database.UpdateDeviceCode(nil, deviceCodeEntity)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data = pollDeviceToken(t, httpClient, deviceCode)
+	assert.Equal(t, "expired_token", data["error"])
+}
+
+func TestDevice_InvalidUserCode(t *testing.T) {
+	setup()
+
+	httpClient := createHttpClient(&createHttpClientInput{
+		T: t,
+	})
+
+	resp := postDeviceUserCode(t, httpClient, "BBBB-BBBB")
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	doc, err := goquery.NewDocumentFromReader(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "The code is invalid or has expired.", strings.TrimSpace(doc.Find("p.text-error").Text()))
+}
+
+func TestDevice_DeniedConsent(t *testing.T) {
+	setup()
+
+	deleteAllUserConsents(t)
+
+	httpClient := createHttpClient(&createHttpClientInput{
+		T: t,
+	})
+
+	data := requestDeviceCode(t, httpClient, "openid profile")
+	deviceCode := data["device_code"].(string)
+
+	resp := postDeviceUserCode(t, httpClient, data["user_code"].(string))
+	defer resp.Body.Close()
+
+	assertRedirect(t, resp, "/auth/pwd")
+	resp = authenticateWithPasswordAndOtp(t, httpClient, "mauro@outlook.com", "abc123")
+	defer resp.Body.Close()
+
+	assertRedirect(t, resp, "/auth/consent")
+	resp = getPage(t, httpClient, lib.GetBaseUrl()+"/auth/consent")
+	defer resp.Body.Close()
+	csrf := getCsrfValue(t, resp)
+
+	resp = postConsent(t, httpClient, []int{}, csrf)
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	data = pollDeviceToken(t, httpClient, deviceCode)
+	assert.Equal(t, "access_denied", data["error"])
+}
+
+func TestDevice_ConsentAfterCodeExpired(t *testing.T) {
+	setup()
+
+	deleteAllUserConsents(t)
+
+	httpClient := createHttpClient(&createHttpClientInput{
+		T: t,
+	})
+
+	data := requestDeviceCode(t, httpClient, "openid profile")
+	deviceCode := data["device_code"].(string)
+
+	resp := postDeviceUserCode(t, httpClient, data["user_code"].(string))
+	defer resp.Body.Close()
+
+	assertRedirect(t, resp, "/auth/pwd")
+	resp = authenticateWithPasswordAndOtp(t, httpClient, "mauro@outlook.com", "abc123")
+	defer resp.Body.Close()
+
+	assertRedirect(t, resp, "/auth/consent")
+	resp = getPage(t, httpClient, lib.GetBaseUrl()+"/auth/consent")
+	defer resp.Body.Close()
+	csrf := getCsrfValue(t, resp)
+
+	// the device code expires while the user is on the consent page
+	deviceCodeHash, err := lib.HashString(deviceCode)
+	if err != nil {
+		t.Fatal(err)
+	}
+	deviceCodeEntity, err := database.GetDeviceCodeByDeviceCodeHash(nil, deviceCodeHash)
+	if err != nil {
+		t.Fatal(err)
+	}
+	deviceCodeEntity.ExpiresAt = time.Now().UTC().Add(-time.Minute)
+	err = database.UpdateDeviceCode(nil, deviceCodeEntity)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp = postConsent(t, httpClient, []int{0, 1}, csrf)
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	doc, err := goquery.NewDocumentFromReader(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "The code is invalid or has expired.", strings.TrimSpace(doc.Find("#resultMsg").Text()))
+
+	deviceCodeEntity, err = database.GetDeviceCodeByDeviceCodeHash(nil, deviceCodeHash)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, enums.DeviceCodeStatePending.String(), deviceCodeEntity.State)
+	assert.False(t, deviceCodeEntity.CodeId.Valid)
+}
+
+func TestDevice_DenyAfterCodeApproved(t *testing.T) {
+	setup()
+
+	deleteAllUserConsents(t)
+
+	httpClient := createHttpClient(&createHttpClientInput{
+		T: t,
+	})
+
+	data := requestDeviceCode(t, httpClient, "openid profile")
+	deviceCode := data["device_code"].(string)
+
+	resp := postDeviceUserCode(t, httpClient, data["user_code"].(string))
+	defer resp.Body.Close()
+
+	assertRedirect(t, resp, "/auth/pwd")
+	resp = authenticateWithPasswordAndOtp(t, httpClient, "mauro@outlook.com", "abc123")
+	defer resp.Body.Close()
+
+	assertRedirect(t, resp, "/auth/consent")
+	resp = getPage(t, httpClient, lib.GetBaseUrl()+"/auth/consent")
+	defer resp.Body.Close()
+	csrf := getCsrfValue(t, resp)
+
+	// the device code was already approved from another browser
+	deviceCodeHash, err := lib.HashString(deviceCode)
+	if err != nil {
+		t.Fatal(err)
+	}
+	deviceCodeEntity, err := database.GetDeviceCodeByDeviceCodeHash(nil, deviceCodeHash)
+	if err != nil {
+		t.Fatal(err)
+	}
+	deviceCodeEntity.State = enums.DeviceCodeStateApproved.String()
+	err = database.UpdateDeviceCode(nil, deviceCodeEntity)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp = postConsent(t, httpClient, []int{}, csrf)
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	doc, err := goquery.NewDocumentFromReader(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "The code is invalid or has expired.", strings.TrimSpace(doc.Find("#resultMsg").Text()))
+
+	deviceCodeEntity, err = database.GetDeviceCodeByDeviceCodeHash(nil, deviceCodeHash)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, enums.DeviceCodeStateApproved.String(), deviceCodeEntity.State)
+}
+
+func TestDevice_FullFlow(t *testing.T) {
+	setup()
+
+	deleteAllUserConsents(t)
+
+	httpClient := createHttpClient(&createHttpClientInput{
+		T: t,
+	})
+
+	data := requestDeviceCode(t, httpClient, "openid profile email")
+	deviceCode := data["device_code"].(string)
+
+	// the user opens the verification uri on another device
+	resp := postDeviceUserCode(t, httpClient, strings.ToLower(data["user_code"].(string)))
+	defer resp.Body.Close()
+
+	assertRedirect(t, resp, "/auth/pwd")
+	resp = authenticateWithPasswordAndOtp(t, httpClient, "mauro@outlook.com", "abc123")
+	defer resp.Body.Close()
+
+	assertRedirect(t, resp, "/auth/consent")
+	resp = getPage(t, httpClient, lib.GetBaseUrl()+"/auth/consent")
+	defer resp.Body.Close()
+	csrf := getCsrfValue(t, resp)
+
+	resp = postConsent(t, httpClient, []int{0, 1, 2, 3}, csrf)
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	doc, err := goquery.NewDocumentFromReader(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "You may now return to your device.", strings.TrimSpace(doc.Find("#resultMsg").Text()))
+
+	data = pollDeviceToken(t, httpClient, deviceCode)
+
+	assert.Equal(t, "Bearer", data["token_type"])
+	assert.Equal(t, "openid profile email authserver:userinfo", data["scope"])
+	assert.NotEmpty(t, data["access_token"])
+	assert.NotEmpty(t, data["id_token"])
+	assert.NotEmpty(t, data["refresh_token"])
+
+	// the device code can only be redeemed once
+	deviceCodeHash, err := lib.HashString(deviceCode)
+	if err != nil {
+		t.Fatal(err)
+	}
+	deviceCodeEntity, err := database.GetDeviceCodeByDeviceCodeHash(nil, deviceCodeHash)
+	if err != nil {
+		t.Fatal(err)
+	}
+	deviceCodeEntity.LastPolledAt.Time = time.Now().UTC().Add(-time.Minute)
+	err = database.UpdateDeviceCode(nil, deviceCodeEntity)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data = pollDeviceToken(t, httpClient, deviceCode)
+	assert.Equal(t, "invalid_grant", data["error"])
+}
+
+func TestDevice_ConfirmationRequiredWithoutClientConsent(t *testing.T) {
+	setup()
+
+	httpClient := createHttpClient(&createHttpClientInput{
+		T: t,
+	})
+
+	// test-client-2 does not require consent, but the user must still confirm the device
+	formData := url.Values{
+		"client_id": {"test-client-2"},
+		"scope":     {"openid profile"},
+	}
+	data := postToTokenEndpoint(t, httpClient, lib.GetBaseUrl()+"/auth/device_authorization", formData)
+	deviceCode := data["device_code"].(string)
+	userCode := data["user_code"].(string)
+
+	resp := postDeviceUserCode(t, httpClient, userCode)
+	defer resp.Body.Close()
+
+	assertRedirect(t, resp, "/auth/pwd")
+	resp = authenticateWithPasswordAndOtp(t, httpClient, "mauro@outlook.com", "abc123")
+	defer resp.Body.Close()
+
+	assertRedirect(t, resp, "/auth/consent")
+	resp = getPage(t, httpClient, lib.GetBaseUrl()+"/auth/consent")
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	csrf := getCsrfValue(t, resp)
+
+	// the device code stays pending until the user approves it
+	deviceCodeEntity, err := database.GetDeviceCodeByUserCode(nil, core_authorize.NormalizeUserCode(userCode))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, enums.DeviceCodeStatePending.String(), deviceCodeEntity.State)
+
+	pollFormData := url.Values{
+		"grant_type":  {"urn:ietf:params:oauth:grant-type:device_code"},
+		"client_id":   {"test-client-2"},
+		"device_code": {deviceCode},
+	}
+	data = postToTokenEndpoint(t, httpClient, lib.GetBaseUrl()+"/auth/token", pollFormData)
+	assert.Equal(t, "authorization_pending", data["error"])
+
+	resp = postConsent(t, httpClient, []int{0, 1, 2}, csrf)
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	deviceCodeEntity, err = database.GetDeviceCodeByUserCode(nil, core_authorize.NormalizeUserCode(userCode))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, enums.DeviceCodeStateApproved.String(), deviceCodeEntity.State)
+	deviceCodeEntity.LastPolledAt.Time = time.Now().UTC().Add(-time.Minute)
+	err = database.UpdateDeviceCode(nil, deviceCodeEntity)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data = postToTokenEndpoint(t, httpClient, lib.GetBaseUrl()+"/auth/token", pollFormData)
+	assert.NotEmpty(t, data["access_token"])
+}
@@ -0,0 +1,344 @@
+package integrationtests
+
+import (
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	b64 "encoding/base64"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/leodip/goiabada/internal/customerrors"
+	"github.com/leodip/goiabada/internal/entities"
+	"github.com/leodip/goiabada/internal/lib"
+	"github.com/stretchr/testify/assert"
+)
+
+func createDPoPProof(t *testing.T, privKey *rsa.PrivateKey, htm string, htu string, accessToken string) string {
+	jwkBytes, err := lib.MarshalPublicKeyToJWK(&privKey.PublicKey, "RS256", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var jwk map[string]interface{}
+	err = json.Unmarshal(jwkBytes, &jwk)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	claims := jwt.MapClaims{
+		"jti": uuid.New().String(),
+		"htm": htm,
+		"htu": htu,
+		"iat": time.Now().UTC().Unix(),
+	}
+	if len(accessToken) > 0 {
+		hash := sha256.Sum256([]byte(accessToken))
+		claims["ath"] = b64.RawURLEncoding.EncodeToString(hash[:])
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["typ"] = "dpop+jwt"
+	token.Header["jwk"] = jwk
+	tokenStr, err := token.SignedString(privKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tokenStr
+}
+
+func postToTokenEndpointWithDPoP(t *testing.T, client *http.Client, formData url.Values, dpopProof string) map[string]interface{} {
+	request, err := http.NewRequest("POST", lib.GetBaseUrl()+"/auth/token", strings.NewReader(formData.Encode()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	if len(dpopProof) > 0 {
+		request.Header.Set("DPoP", dpopProof)
+	}
+
+	resp, err := client.Do(request)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	return unmarshalToMap(t, resp)
+}
+
+func getConfirmationJkt(t *testing.T, accessToken string) string {
+	claims := jwt.MapClaims{}
+	_, _, err := jwt.NewParser().ParseUnverified(accessToken, claims)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cnf, ok := claims["cnf"].(map[string]interface{})
+	if !ok {
+		return ""
+	}
+	jkt, _ := cnf["jkt"].(string)
+	return jkt
+}
+
+// getDPoPKeyThumbprint returns the JWK thumbprint of the key (RFC 7638), as found in the jkt confirmation claim
+func getDPoPKeyThumbprint(t *testing.T, privKey *rsa.PrivateKey) string {
+	jwkBytes, err := lib.MarshalPublicKeyToJWK(&privKey.PublicKey, "RS256", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var jwk lib.JSONWebKey
+	err = json.Unmarshal(jwkBytes, &jwk)
+	if err != nil {
+		t.Fatal(err)
+	}
+	thumbprint, err := jwk.Thumbprint()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return thumbprint
+}
+
+func TestDPoP_ClientCred(t *testing.T) {
+	setup()
+
+	privKey, err := lib.GeneratePrivateKey(2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	httpClient := createHttpClient(&createHttpClientInput{
+		T: t,
+	})
+
+	formData := url.Values{
+		"grant_type":    {"client_credentials"},
+		"client_id":     {"test-client-1"},
+		"client_secret": {getClientSecret(t, "test-client-1")},
+		"scope":         {"backend-svcA:create-product"},
+	}
+	proof := createDPoPProof(t, privKey, "POST", lib.GetBaseUrl()+"/auth/token", "")
+	data := postToTokenEndpointWithDPoP(t, httpClient, formData, proof)
+
+	assert.Equal(t, "DPoP", data["token_type"])
+	assert.NotEmpty(t, data["access_token"])
+
+	assert.Equal(t, getDPoPKeyThumbprint(t, privKey), getConfirmationJkt(t, data["access_token"].(string)))
+}
+
+func TestDPoP_ProofReplay(t *testing.T) {
+	setup()
+
+	privKey, err := lib.GeneratePrivateKey(2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	httpClient := createHttpClient(&createHttpClientInput{
+		T: t,
+	})
+
+	formData := url.Values{
+		"grant_type":    {"client_credentials"},
+		"client_id":     {"test-client-1"},
+		"client_secret": {getClientSecret(t, "test-client-1")},
+		"scope":         {"backend-svcA:create-product"},
+	}
+	proof := createDPoPProof(t, privKey, "POST", lib.GetBaseUrl()+"/auth/token", "")
+	data := postToTokenEndpointWithDPoP(t, httpClient, formData, proof)
+	assert.Equal(t, "DPoP", data["token_type"])
+
+	data = postToTokenEndpointWithDPoP(t, httpClient, formData, proof)
+	assert.Equal(t, "invalid_dpop_proof", data["error"])
+	assert.Equal(t, "The DPoP proof has already been used.", data["error_description"])
+}
+
+func TestDPoP_UsedJtiStoredTwice(t *testing.T) {
+	setup()
+
+	// when the same proof is presented in concurrent requests, both can pass the replay check
+	// before the jti is stored, and only one of them can store it
+	jtiHash, err := lib.HashString("dpop:" + uuid.New().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = database.CreateUsedJti(nil, &entities.UsedJti{
+		JtiHash:   jtiHash,
+		ExpiresAt: time.Now().UTC().Add(time.Minute),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = database.CreateUsedJti(nil, &entities.UsedJti{
+		JtiHash:   jtiHash,
+		ExpiresAt: time.Now().UTC().Add(time.Minute),
+	})
+	assert.ErrorIs(t, err, customerrors.ErrJtiAlreadyUsed)
+}
+
+func TestDPoP_ProofMismatch(t *testing.T) {
+	setup()
+
+	privKey, err := lib.GeneratePrivateKey(2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	httpClient := createHttpClient(&createHttpClientInput{
+		T: t,
+	})
+
+	formData := url.Values{
+		"grant_type":    {"client_credentials"},
+		"client_id":     {"test-client-1"},
+		"client_secret": {getClientSecret(t, "test-client-1")},
+		"scope":         {"backend-svcA:create-product"},
+	}
+
+	proof := createDPoPProof(t, privKey, "GET", lib.GetBaseUrl()+"/auth/token", "")
+	data := postToTokenEndpointWithDPoP(t, httpClient, formData, proof)
+	assert.Equal(t, "invalid_dpop_proof", data["error"])
+	assert.Equal(t, "The htm claim of the DPoP proof does not match the HTTP method of the request.", data["error_description"])
+
+	proof = createDPoPProof(t, privKey, "POST", lib.GetBaseUrl()+"/userinfo", "")
+	data = postToTokenEndpointWithDPoP(t, httpClient, formData, proof)
+	assert.Equal(t, "invalid_dpop_proof", data["error"])
+	assert.Equal(t, "The htu claim of the DPoP proof does not match the HTTP URI of the request.", data["error_description"])
+}
+
+func TestDPoP_RefreshTokenBoundToKey(t *testing.T) {
+	setup()
+	scope := "openid profile email"
+	code, httpClient := createAuthCode(t, scope)
+
+	privKey, err := lib.GeneratePrivateKey(2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherPrivKey, err := lib.GeneratePrivateKey(2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	clientSecret := getClientSecret(t, "test-client-1")
+	tokenUrl := lib.GetBaseUrl() + "/auth/token"
+
+	formData := url.Values{
+		"client_id":     {"test-client-1"},
+		"client_secret": {clientSecret},
+		"grant_type":    {"authorization_code"},
+		"redirect_uri":  {code.RedirectURI},
+		"code":          {code.Code},
+		"code_verifier": {"DdazqdVNuDmRLGGRGQKKehEaoFeatACtNsM2UYGwuHkhBhDsTSzaCqWttcBc0kGx"},
+	}
+	respData := postToTokenEndpointWithDPoP(t, httpClient, formData, createDPoPProof(t, privKey, "POST", tokenUrl, ""))
+
+	assert.Equal(t, "DPoP", respData["token_type"])
+	assert.NotEmpty(t, respData["refresh_token"])
+
+	refreshTokenFormData := url.Values{
+		"client_id":     {"test-client-1"},
+		"client_secret": {clientSecret},
+		"grant_type":    {"refresh_token"},
+		"refresh_token": {respData["refresh_token"].(string)},
+	}
+
+	// without a DPoP proof
+	respData2 := postToTokenEndpointWithDPoP(t, httpClient, refreshTokenFormData, "")
+	assert.Equal(t, "invalid_grant", respData2["error"])
+	assert.Equal(t, "The refresh token is bound to a DPoP key. Please provide a DPoP proof signed with the same key.", respData2["error_description"])
+
+	// with a proof signed by another key
+	respData2 = postToTokenEndpointWithDPoP(t, httpClient, refreshTokenFormData, createDPoPProof(t, otherPrivKey, "POST", tokenUrl, ""))
+	assert.Equal(t, "invalid_grant", respData2["error"])
+	assert.Equal(t, "The refresh token is bound to a DPoP key. Please provide a DPoP proof signed with the same key.", respData2["error_description"])
+
+	// with a proof signed by the same key
+	respData2 = postToTokenEndpointWithDPoP(t, httpClient, refreshTokenFormData, createDPoPProof(t, privKey, "POST", tokenUrl, ""))
+	assert.Equal(t, "DPoP", respData2["token_type"])
+	assert.NotEmpty(t, respData2["access_token"])
+	assert.Equal(t, getConfirmationJkt(t, respData["access_token"].(string)), getConfirmationJkt(t, respData2["access_token"].(string)))
+}
+
+func TestDPoP_UserInfo(t *testing.T) {
+	setup()
+	scope := "openid profile email"
+	code, httpClient := createAuthCode(t, scope)
+
+	privKey, err := lib.GeneratePrivateKey(2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	formData := url.Values{
+		"client_id":     {"test-client-1"},
+		"client_secret": {getClientSecret(t, "test-client-1")},
+		"grant_type":    {"authorization_code"},
+		"redirect_uri":  {code.RedirectURI},
+		"code":          {code.Code},
+		"code_verifier": {"DdazqdVNuDmRLGGRGQKKehEaoFeatACtNsM2UYGwuHkhBhDsTSzaCqWttcBc0kGx"},
+	}
+	respData := postToTokenEndpointWithDPoP(t, httpClient, formData,
+		createDPoPProof(t, privKey, "POST", lib.GetBaseUrl()+"/auth/token", ""))
+	assert.Equal(t, "DPoP", respData["token_type"])
+	accessToken := respData["access_token"].(string)
+
+	userInfoUrl := lib.GetBaseUrl() + "/userinfo"
+
+	// a DPoP-bound access token can't be used as a bearer token
+	request, err := http.NewRequest("GET", userInfoUrl, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	request.Header.Set("Authorization", "Bearer "+accessToken)
+	resp, err := httpClient.Do(request)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+
+	// DPoP scheme without a proof
+	request, err = http.NewRequest("GET", userInfoUrl, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	request.Header.Set("Authorization", "DPoP "+accessToken)
+	resp, err = httpClient.Do(request)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+
+	// DPoP scheme with a valid proof
+	request, err = http.NewRequest("GET", userInfoUrl, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	request.Header.Set("Authorization", "DPoP "+accessToken)
+	request.Header.Set("DPoP", createDPoPProof(t, privKey, "GET", userInfoUrl, accessToken))
+	resp, err = httpClient.Do(request)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var userInfo map[string]interface{}
+	err = json.Unmarshal(body, &userInfo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "mauro@outlook.com", userInfo["email"])
+}
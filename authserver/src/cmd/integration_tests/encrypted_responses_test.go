@@ -0,0 +1,423 @@
+package integrationtests
+
+import (
+	"crypto"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"database/sql"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"hash"
+	"io"
+	"net/http"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/leodip/goiabada/internal/entities"
+	"github.com/leodip/goiabada/internal/lib"
+	"github.com/stretchr/testify/assert"
+)
+
+// createEncryptionKey creates a key pair of the type of the algorithm, and returns the private
+// key and the JSON Web Key Set with its public key, meant for encryption
+func createEncryptionKey(t *testing.T, alg string) (crypto.PrivateKey, string) {
+	var privateKey crypto.PrivateKey
+	var publicKey crypto.PublicKey
+	if lib.GetJWEKeyType(alg) == "RSA" {
+		rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+		if err != nil {
+			t.Fatal(err)
+		}
+		privateKey, publicKey = rsaKey, &rsaKey.PublicKey
+	} else {
+		ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		privateKey, publicKey = ecKey, &ecKey.PublicKey
+	}
+
+	jwk, err := lib.MarshalPublicKeyToJWK(publicKey, "", "enc-key-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	jwk = append(jwk[:len(jwk)-1], []byte(`,"use":"enc"}`)...)
+	return privateKey, fmt.Sprintf(`{"keys":[%v]}`, string(jwk))
+}
+
+// decryptJWE decrypts a JWE in the compact serialization with the private key of the recipient,
+// and returns the plaintext and the JWE header. It does the recipient side of RFC 7516 and RFC 7518
+// on its own, so the tests don't rely on the server code to check the server output
+func decryptJWE(t *testing.T, jwe string, privateKey crypto.PrivateKey) ([]byte, map[string]interface{}) {
+	parts := strings.Split(jwe, ".")
+	if len(parts) != 5 {
+		t.Fatalf("the JWE must have 5 parts, but it has %v", len(parts))
+	}
+	decoded := make([][]byte, 5)
+	for i, part := range parts {
+		var err error
+		decoded[i], err = base64.RawURLEncoding.DecodeString(part)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var header map[string]interface{}
+	err := json.Unmarshal(decoded[0], &header)
+	if err != nil {
+		t.Fatal(err)
+	}
+	alg, _ := header["alg"].(string)
+	enc, _ := header["enc"].(string)
+	cekSizes := map[string]int{"A128GCM": 16, "A192GCM": 24, "A256GCM": 32, "A128CBC-HS256": 32, "A192CBC-HS384": 48, "A256CBC-HS512": 64}
+	cekSize, ok := cekSizes[enc]
+	if !ok {
+		t.Fatalf("unexpected enc '%v'", enc)
+	}
+
+	var cek []byte
+	switch alg {
+	case "RSA-OAEP", "RSA-OAEP-256":
+		var h hash.Hash = sha1.New()
+		if alg == "RSA-OAEP-256" {
+			h = sha256.New()
+		}
+		cek, err = rsa.DecryptOAEP(h, nil, privateKey.(*rsa.PrivateKey), decoded[1], nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+	case "ECDH-ES", "ECDH-ES+A128KW", "ECDH-ES+A192KW", "ECDH-ES+A256KW":
+		recipientKey, err := privateKey.(*ecdsa.PrivateKey).ECDH()
+		if err != nil {
+			t.Fatal(err)
+		}
+		epk, ok := header["epk"].(map[string]interface{})
+		if !ok {
+			t.Fatal("the JWE header has no epk")
+		}
+		x, err := base64.RawURLEncoding.DecodeString(epk["x"].(string))
+		if err != nil {
+			t.Fatal(err)
+		}
+		y, err := base64.RawURLEncoding.DecodeString(epk["y"].(string))
+		if err != nil {
+			t.Fatal(err)
+		}
+		ephemeralKey, err := recipientKey.Curve().NewPublicKey(append(append([]byte{4}, x...), y...))
+		if err != nil {
+			t.Fatal(err)
+		}
+		sharedSecret, err := recipientKey.ECDH(ephemeralKey)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if alg == "ECDH-ES" {
+			cek = deriveJWEKey(sharedSecret, enc, cekSize)
+			assert.Empty(t, decoded[1])
+		} else {
+			kekSizes := map[string]int{"ECDH-ES+A128KW": 16, "ECDH-ES+A192KW": 24, "ECDH-ES+A256KW": 32}
+			cek = unwrapJWEKey(t, deriveJWEKey(sharedSecret, alg, kekSizes[alg]), decoded[1])
+		}
+	default:
+		t.Fatalf("unexpected alg '%v'", alg)
+	}
+	if len(cek) != cekSize {
+		t.Fatalf("the content encryption key has %v bytes, expected %v", len(cek), cekSize)
+	}
+
+	iv, ciphertext, tag, aad := decoded[2], decoded[3], decoded[4], []byte(parts[0])
+	if strings.HasSuffix(enc, "GCM") {
+		block, err := aes.NewCipher(cek)
+		if err != nil {
+			t.Fatal(err)
+		}
+		gcm, err := cipher.NewGCM(block)
+		if err != nil {
+			t.Fatal(err)
+		}
+		plaintext, err := gcm.Open(nil, iv, append(ciphertext, tag...), aad)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return plaintext, header
+	}
+
+	// AES_CBC_HMAC_SHA2 (RFC 7518, section 5.2)
+	macKey, encKey := cek[:cekSize/2], cek[cekSize/2:]
+	newHash := sha256.New
+	if enc == "A192CBC-HS384" {
+		newHash = sha512.New384
+	} else if enc == "A256CBC-HS512" {
+		newHash = sha512.New
+	}
+	mac := hmac.New(newHash, macKey)
+	mac.Write(aad)
+	mac.Write(iv)
+	mac.Write(ciphertext)
+	mac.Write(binary.BigEndian.AppendUint64(nil, uint64(len(aad))*8))
+	if !hmac.Equal(tag, mac.Sum(nil)[:cekSize/2]) {
+		t.Fatal("invalid JWE authentication tag")
+	}
+	block, err := aes.NewCipher(encKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	plaintext := make([]byte, len(ciphertext))
+	cipher.NewCBCDecrypter(block, iv).CryptBlocks(plaintext, ciphertext)
+	padding := int(plaintext[len(plaintext)-1])
+	if padding == 0 || padding > aes.BlockSize {
+		t.Fatal("invalid JWE padding")
+	}
+	return plaintext[:len(plaintext)-padding], header
+}
+
+// deriveJWEKey is the Concat KDF of ECDH-ES (RFC 7518, section 4.6.2), with empty apu and apv
+func deriveJWEKey(sharedSecret []byte, algorithmID string, keySize int) []byte {
+	otherInfo := binary.BigEndian.AppendUint32(nil, uint32(len(algorithmID)))
+	otherInfo = append(otherInfo, algorithmID...)
+	otherInfo = binary.BigEndian.AppendUint32(otherInfo, 0)
+	otherInfo = binary.BigEndian.AppendUint32(otherInfo, 0)
+	otherInfo = binary.BigEndian.AppendUint32(otherInfo, uint32(keySize*8))
+
+	key := []byte{}
+	for counter := uint32(1); len(key) < keySize; counter++ {
+		h := sha256.New()
+		h.Write(binary.BigEndian.AppendUint32(nil, counter))
+		h.Write(sharedSecret)
+		h.Write(otherInfo)
+		key = h.Sum(key)
+	}
+	return key[:keySize]
+}
+
+// unwrapJWEKey unwraps the content encryption key with AES key wrap (RFC 3394, section 2.2.2)
+func unwrapJWEKey(t *testing.T, kek []byte, wrapped []byte) []byte {
+	if len(wrapped) < 24 || len(wrapped)%8 != 0 {
+		t.Fatalf("invalid wrapped key size %v", len(wrapped))
+	}
+	block, err := aes.NewCipher(kek)
+	if err != nil {
+		t.Fatal(err)
+	}
+	n := len(wrapped)/8 - 1
+	a := binary.BigEndian.Uint64(wrapped[:8])
+	r := slices.Clone(wrapped[8:])
+	buf := make([]byte, 16)
+	for j := 5; j >= 0; j-- {
+		for i := n; i >= 1; i-- {
+			binary.BigEndian.PutUint64(buf, a^uint64(n*j+i))
+			copy(buf[8:], r[(i-1)*8:i*8])
+			block.Decrypt(buf, buf)
+			a = binary.BigEndian.Uint64(buf[:8])
+			copy(r[(i-1)*8:i*8], buf[8:])
+		}
+	}
+	if a != 0xA6A6A6A6A6A6A6A6 {
+		t.Fatal("unable to unwrap the key: integrity check failed")
+	}
+	return r
+}
+
+// decryptNestedJWT decrypts the JWE and verifies the signed JWT inside it
+func decryptNestedJWT(t *testing.T, httpClient *http.Client, jwe string, privateKey crypto.PrivateKey,
+	alg string, enc string) jwt.MapClaims {
+
+	plaintext, header := decryptJWE(t, jwe, privateKey)
+	assert.Equal(t, alg, header["alg"])
+	assert.Equal(t, enc, header["enc"])
+	assert.Equal(t, "JWT", header["cty"])
+	assert.Equal(t, "enc-key-1", header["kid"])
+
+	token := verifyWithCerts(t, getCerts(t, httpClient), string(plaintext))
+	return token.Claims.(jwt.MapClaims)
+}
+
+func TestEncryptedResponses_IdToken(t *testing.T) {
+	setup()
+
+	testCases := []struct {
+		alg         string
+		enc         string
+		expectedEnc string
+	}{
+		{"RSA-OAEP", "", "A128CBC-HS256"},
+		{"RSA-OAEP-256", "A256GCM", "A256GCM"},
+		{"ECDH-ES", "A128GCM", "A128GCM"},
+		{"ECDH-ES+A128KW", "A256CBC-HS512", "A256CBC-HS512"},
+		{"ECDH-ES+A256KW", "", "A128CBC-HS256"},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.alg, func(t *testing.T) {
+			privateKey, jwks := createEncryptionKey(t, testCase.alg)
+			restore := updateClient(t, "test-client-1", func(client *entities.Client) {
+				client.JWKS = jwks
+				client.IdTokenEncryptedResponseAlg = testCase.alg
+				client.IdTokenEncryptedResponseEnc = testCase.enc
+			})
+			defer restore()
+
+			respData, httpClient := getTokensWithAuthCode(t, "openid profile backend-svcA:read-product")
+
+			claims := decryptNestedJWT(t, httpClient, respData["id_token"].(string), privateKey, testCase.alg, testCase.expectedEnc)
+			assert.Equal(t, "test-client-1", claims["aud"])
+			assert.Equal(t, "ID", claims["typ"])
+			assert.NotEmpty(t, claims["sub"])
+
+			// only the id token is encrypted
+			_, accessTokenClaims := parseToken(t, respData["access_token"].(string))
+			assert.Equal(t, "test-client-1", accessTokenClaims["client_id"])
+		})
+	}
+}
+
+func TestEncryptedResponses_UserInfo(t *testing.T) {
+	setup()
+
+	privateKey, jwks := createEncryptionKey(t, "RSA-OAEP-256")
+	restore := updateClient(t, "test-client-1", func(client *entities.Client) {
+		client.JWKS = jwks
+		client.UserInfoEncryptedResponseAlg = "RSA-OAEP-256"
+		client.UserInfoEncryptedResponseEnc = "A256GCM"
+	})
+	defer restore()
+
+	respData, httpClient := getTokensWithAuthCode(t, "openid profile email backend-svcA:read-product")
+
+	// the id token is not encrypted
+	_, idTokenClaims := parseToken(t, respData["id_token"].(string))
+	sub := idTokenClaims["sub"].(string)
+
+	request, err := http.NewRequest("GET", lib.GetBaseUrl()+"/userinfo", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	request.Header.Set("Authorization", "Bearer "+respData["access_token"].(string))
+	resp, err := httpClient.Do(request)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "application/jwt", resp.Header.Get("Content-Type"))
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	claims := decryptNestedJWT(t, httpClient, string(body), privateKey, "RSA-OAEP-256", "A256GCM")
+	assert.Equal(t, sub, claims["sub"])
+	assert.Equal(t, "test-client-1", claims["aud"])
+	assert.Equal(t, idTokenClaims["iss"], claims["iss"])
+	assert.Equal(t, "mauro@outlook.com", claims["email"])
+	assert.NotEmpty(t, claims["name"])
+}
+
+func TestEncryptedResponses_ClientRegistration(t *testing.T) {
+	setup()
+
+	initialAccessToken, deleteInitialAccessToken := createInitialAccessToken(t, sql.NullTime{})
+	defer deleteInitialAccessToken()
+
+	httpClient := createHttpClient(&createHttpClientInput{
+		T: t,
+	})
+
+	_, jwks := createEncryptionKey(t, "ECDH-ES")
+
+	resp, data := sendClientRegistrationRequest(t, httpClient, "POST", lib.GetBaseUrl()+"/connect/register", initialAccessToken, map[string]interface{}{
+		"redirect_uris":                   []string{"https://app1.example.com/callback"},
+		"jwks":                            json.RawMessage(jwks),
+		"id_token_encrypted_response_alg": "ECDH-ES",
+		"userinfo_encrypted_response_alg": "ECDH-ES+A128KW",
+		"userinfo_encrypted_response_enc": "A128GCM",
+	})
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+	assert.Equal(t, "ECDH-ES", data["id_token_encrypted_response_alg"])
+	assert.Equal(t, "A128GCM", data["userinfo_encrypted_response_enc"])
+
+	client, err := database.GetClientByClientIdentifier(nil, data["client_id"].(string))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "ECDH-ES", client.IdTokenEncryptedResponseAlg)
+	assert.Equal(t, "", client.IdTokenEncryptedResponseEnc)
+	assert.Equal(t, "ECDH-ES+A128KW", client.UserInfoEncryptedResponseAlg)
+	assert.Equal(t, "A128GCM", client.UserInfoEncryptedResponseEnc)
+	err = database.DeleteClient(nil, client.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testCases := []struct {
+		metadata    map[string]interface{}
+		description string
+	}{
+		{
+			map[string]interface{}{"id_token_encrypted_response_alg": "RSA1_5", "jwks": json.RawMessage(jwks)},
+			"Unsupported id_token_encrypted_response_alg 'RSA1_5'.",
+		},
+		{
+			map[string]interface{}{"userinfo_encrypted_response_alg": "ECDH-ES", "userinfo_encrypted_response_enc": "A192GCM", "jwks": json.RawMessage(jwks)},
+			"Unsupported userinfo_encrypted_response_enc 'A192GCM'.",
+		},
+		{
+			map[string]interface{}{"id_token_encrypted_response_enc": "A128GCM"},
+			"The id_token_encrypted_response_enc requires the id_token_encrypted_response_alg.",
+		},
+		{
+			map[string]interface{}{"userinfo_encrypted_response_alg": "RSA-OAEP"},
+			"The userinfo_encrypted_response_alg requires the jwks or the jwks_uri of the client.",
+		},
+		{
+			map[string]interface{}{"id_token_encrypted_response_alg": "RSA-OAEP", "jwks": json.RawMessage(jwks)},
+			"The jwks of the client has no RSA key to encrypt with the id_token_encrypted_response_alg 'RSA-OAEP'.",
+		},
+	}
+
+	for _, testCase := range testCases {
+		testCase.metadata["redirect_uris"] = []string{"https://app1.example.com/callback"}
+		resp, data = sendClientRegistrationRequest(t, httpClient, "POST", lib.GetBaseUrl()+"/connect/register", initialAccessToken, testCase.metadata)
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+		assert.Equal(t, "invalid_client_metadata", data["error"])
+		assert.Contains(t, data["error_description"], testCase.description)
+	}
+}
+
+func TestEncryptedResponses_Discovery(t *testing.T) {
+	setup()
+
+	httpClient := createHttpClient(&createHttpClientInput{
+		T: t,
+	})
+
+	resp, err := httpClient.Get(lib.GetBaseUrl() + "/.well-known/openid-configuration")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	config := unmarshalToMap(t, resp)
+	for _, name := range []string{"id_token_encryption_alg_values_supported", "userinfo_encryption_alg_values_supported"} {
+		assert.Contains(t, config[name], "RSA-OAEP-256")
+		assert.Contains(t, config[name], "ECDH-ES+A256KW")
+	}
+	for _, name := range []string{"id_token_encryption_enc_values_supported", "userinfo_encryption_enc_values_supported"} {
+		assert.Contains(t, config[name], "A128CBC-HS256")
+		assert.Contains(t, config[name], "A256GCM")
+	}
+}
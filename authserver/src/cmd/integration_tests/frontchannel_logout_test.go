@@ -0,0 +1,212 @@
+package integrationtests
+
+import (
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/leodip/goiabada/internal/entities"
+	"github.com/leodip/goiabada/internal/lib"
+	"github.com/stretchr/testify/assert"
+)
+
+func assertFrontChannelLogoutIframe(t *testing.T, resp *http.Response, sessionIdentifier string) *goquery.Document {
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	doc, err := goquery.NewDocumentFromReader(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	iframes := doc.Find("iframe.frontchannel-logout")
+	assert.Equal(t, 1, iframes.Length())
+
+	src, _ := iframes.First().Attr("src")
+	iframeURL, err := url.Parse(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	settings, err := database.GetSettingsById(nil, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, "https", iframeURL.Scheme)
+	assert.Equal(t, "goiabada-test-client:8090", iframeURL.Host)
+	assert.Equal(t, "/frontchannel-logout", iframeURL.Path)
+	assert.Equal(t, "abc", iframeURL.Query().Get("tenant"))
+	assert.Equal(t, settings.Issuer, iframeURL.Query().Get("iss"))
+	assert.Equal(t, sessionIdentifier, iframeURL.Query().Get("sid"))
+	return doc
+}
+
+func TestFrontChannelLogout_AccountLogout(t *testing.T) {
+	setup()
+
+	restore := updateClient(t, "test-client-1", func(client *entities.Client) {
+		client.FrontChannelLogoutURI = "https://goiabada-test-client:8090/frontchannel-logout?tenant=abc"
+	})
+	defer restore()
+
+	code, httpClient := createAuthCode(t, "openid profile email")
+
+	destUrl := lib.GetBaseUrl() + "/auth/logout"
+	resp, err := httpClient.Get(destUrl)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	csrf := getCsrfValue(t, resp)
+
+	resp, err = httpClient.PostForm(destUrl, url.Values{
+		"gorilla.csrf.Token": {csrf},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	doc := assertFrontChannelLogoutIframe(t, resp, code.SessionIdentifier)
+	destination, _ := doc.Find("#frontChannelLogout").Attr("data-destination")
+	assert.Equal(t, lib.GetBaseUrl(), destination)
+
+	userSession, err := database.GetUserSessionBySessionIdentifier(nil, code.SessionIdentifier)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Nil(t, userSession)
+}
+
+func TestFrontChannelLogout_WithIdTokenHint(t *testing.T) {
+	setup()
+
+	restore := updateClient(t, "test-client-1", func(client *entities.Client) {
+		client.FrontChannelLogoutURI = "https://goiabada-test-client:8090/frontchannel-logout?tenant=abc"
+	})
+	defer restore()
+
+	code, httpClient := createAuthCode(t, "openid profile email")
+
+	clientSecret := getClientSecret(t, "test-client-1")
+
+	formData := url.Values{
+		"client_id":     {"test-client-1"},
+		"client_secret": {clientSecret},
+		"grant_type":    {"authorization_code"},
+		"redirect_uri":  {code.RedirectURI},
+		"code":          {code.Code},
+		"code_verifier": {"DdazqdVNuDmRLGGRGQKKehEaoFeatACtNsM2UYGwuHkhBhDsTSzaCqWttcBc0kGx"},
+	}
+	respData := postToTokenEndpoint(t, httpClient, lib.GetBaseUrl()+"/auth/token", formData)
+	idToken := respData["id_token"].(string)
+
+	destUrl := lib.GetBaseUrl() + "/auth/logout?id_token_hint=" + url.QueryEscape(idToken) +
+		"&post_logout_redirect_uri=https://oauthdebugger.com/debug" +
+		"&state=XYZ123"
+
+	resp, err := httpClient.Get(destUrl)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	doc := assertFrontChannelLogoutIframe(t, resp, code.SessionIdentifier)
+	destination, _ := doc.Find("#frontChannelLogout").Attr("data-destination")
+	assert.Contains(t, destination, "https://oauthdebugger.com/debug?sid="+code.SessionIdentifier)
+	assert.Contains(t, destination, "state=XYZ123")
+
+	userSession, err := database.GetUserSessionBySessionIdentifier(nil, code.SessionIdentifier)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Nil(t, userSession)
+}
+
+func TestFrontChannelLogout_WithIdTokenHint_OtherClientsStayInSession(t *testing.T) {
+	setup()
+
+	restore := updateClient(t, "test-client-1", func(client *entities.Client) {
+		client.FrontChannelLogoutURI = "https://goiabada-test-client:8090/frontchannel-logout?tenant=abc"
+	})
+	defer restore()
+	restore2 := updateClient(t, "test-client-2", func(client *entities.Client) {
+		client.FrontChannelLogoutURI = "https://goiabada-test-client:8090/frontchannel-logout?tenant=def"
+	})
+	defer restore2()
+
+	code, httpClient := createAuthCode(t, "openid profile email")
+
+	formData := url.Values{
+		"client_id":     {"test-client-1"},
+		"client_secret": {getClientSecret(t, "test-client-1")},
+		"grant_type":    {"authorization_code"},
+		"redirect_uri":  {code.RedirectURI},
+		"code":          {code.Code},
+		"code_verifier": {"DdazqdVNuDmRLGGRGQKKehEaoFeatACtNsM2UYGwuHkhBhDsTSzaCqWttcBc0kGx"},
+	}
+	respData := postToTokenEndpoint(t, httpClient, lib.GetBaseUrl()+"/auth/token", formData)
+	idToken := respData["id_token"].(string)
+
+	// test-client-2 also takes part in the session
+	userSession, err := database.GetUserSessionBySessionIdentifier(nil, code.SessionIdentifier)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client2, err := database.GetClientByClientIdentifier(nil, "test-client-2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = database.CreateUserSessionClient(nil, &entities.UserSessionClient{
+		UserSessionId: userSession.Id,
+		ClientId:      client2.Id,
+		Started:       time.Now().UTC(),
+		LastAccessed:  time.Now().UTC(),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	destUrl := lib.GetBaseUrl() + "/auth/logout?id_token_hint=" + url.QueryEscape(idToken) +
+		"&post_logout_redirect_uri=https://oauthdebugger.com/debug"
+
+	resp, err := httpClient.Get(destUrl)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	// the front-channel logout requests go to every client of the session
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	doc, err := goquery.NewDocumentFromReader(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tenants := []string{}
+	doc.Find("iframe.frontchannel-logout").Each(func(i int, iframe *goquery.Selection) {
+		iframeURL, err := url.Parse(iframe.AttrOr("src", ""))
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, code.SessionIdentifier, iframeURL.Query().Get("sid"))
+		tenants = append(tenants, iframeURL.Query().Get("tenant"))
+	})
+	assert.ElementsMatch(t, []string{"abc", "def"}, tenants)
+
+	// but only the calling client is removed from the user session, as before front-channel logout
+	userSession, err = database.GetUserSessionBySessionIdentifier(nil, code.SessionIdentifier)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if userSession == nil {
+		t.Fatal("the user session was deleted")
+	}
+	err = database.UserSessionLoadClients(nil, userSession)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, 1, len(userSession.Clients))
+	assert.Equal(t, client2.Id, userSession.Clients[0].ClientId)
+}
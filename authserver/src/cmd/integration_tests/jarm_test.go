@@ -0,0 +1,166 @@
+package integrationtests
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/leodip/goiabada/internal/lib"
+	"github.com/stretchr/testify/assert"
+)
+
+func getAuthorizeUrlWithResponseMode(scope string, responseMode string, extraParams string) string {
+	return strings.Replace(getAuthorizeUrl(scope, extraParams), "&response_mode=query", "&response_mode="+responseMode, 1)
+}
+
+// getJWTResponse extracts the response parameter of a JWT secured authorization response, and verifies its signature
+func getJWTResponse(t *testing.T, httpClient *http.Client, resp *http.Response, responseMode string) jwt.MapClaims {
+	var response string
+
+	switch responseMode {
+	case "form_post.jwt":
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		doc, err := goquery.NewDocumentFromReader(resp.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		form := doc.Find("form")
+		assert.Equal(t, "https://goiabada-test-client:8090/callback.html", form.AttrOr("action", ""))
+		assert.Equal(t, 1, form.Find("input").Length())
+		response = form.Find("input[name='response']").AttrOr("value", "")
+	case "fragment.jwt":
+		assertRedirect(t, resp, "/callback.html")
+		redirectLocation, err := url.Parse(resp.Header.Get("Location"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Empty(t, redirectLocation.RawQuery)
+		fragment, err := url.ParseQuery(redirectLocation.Fragment)
+		if err != nil {
+			t.Fatal(err)
+		}
+		response = fragment.Get("response")
+	default:
+		assertRedirect(t, resp, "/callback.html")
+		redirectLocation, err := url.Parse(resp.Header.Get("Location"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Empty(t, redirectLocation.Query().Get("code"))
+		assert.Empty(t, redirectLocation.Query().Get("state"))
+		response = redirectLocation.Query().Get("response")
+	}
+
+	if len(response) == 0 {
+		t.Fatal("the response parameter was not found")
+	}
+
+	token := verifyWithCerts(t, getCerts(t, httpClient), response)
+	assert.Equal(t, "RS256", token.Method.Alg())
+	return token.Claims.(jwt.MapClaims)
+}
+
+func TestJARM_AuthCode(t *testing.T) {
+	setup()
+
+	settings, err := database.GetSettingsById(nil, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, responseMode := range []string{"query.jwt", "fragment.jwt", "form_post.jwt", "jwt"} {
+		t.Run(responseMode, func(t *testing.T) {
+			// a session with consent to the scopes already exists, so the code is issued right away
+			_, httpClient := createAuthCode(t, "openid profile")
+
+			resp, err := httpClient.Get(getAuthorizeUrlWithResponseMode("openid profile", responseMode, "&prompt=none"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer resp.Body.Close()
+
+			assertRedirect(t, resp, "/auth/consent")
+			resp = getPage(t, httpClient, lib.GetBaseUrl()+"/auth/consent")
+			defer resp.Body.Close()
+
+			claims := getJWTResponse(t, httpClient, resp, responseMode)
+			assert.Equal(t, settings.Issuer, claims["iss"])
+			assert.Equal(t, "test-client-1", claims["aud"])
+			assert.Equal(t, "a1b2c3", claims["state"])
+			assert.NotEmpty(t, claims["session_state"])
+			assert.Nil(t, claims["error"])
+
+			exp, err := claims.GetExpirationTime()
+			if err != nil {
+				t.Fatal(err)
+			}
+			assertTimeWithinRange(t, time.Now().UTC().Add(10*time.Minute), exp.Time, 10)
+
+			// the code inside the response can be exchanged for tokens
+			code := claims["code"].(string)
+			assert.Equal(t, 128, len(code))
+
+			formData := url.Values{
+				"client_id":     {"test-client-1"},
+				"client_secret": {getClientSecret(t, "test-client-1")},
+				"grant_type":    {"authorization_code"},
+				"redirect_uri":  {"https://goiabada-test-client:8090/callback.html"},
+				"code":          {code},
+				"code_verifier": {"DdazqdVNuDmRLGGRGQKKehEaoFeatACtNsM2UYGwuHkhBhDsTSzaCqWttcBc0kGx"},
+			}
+			respData := postToTokenEndpoint(t, httpClient, lib.GetBaseUrl()+"/auth/token", formData)
+			assert.NotEmpty(t, respData["access_token"])
+			assert.NotEmpty(t, respData["id_token"])
+		})
+	}
+}
+
+func TestJARM_Error(t *testing.T) {
+	setup()
+
+	for _, responseMode := range []string{"query.jwt", "fragment.jwt", "form_post.jwt", "jwt"} {
+		t.Run(responseMode, func(t *testing.T) {
+			httpClient := createHttpClient(&createHttpClientInput{
+				T: t,
+			})
+
+			// without a session, prompt=none fails
+			resp, err := httpClient.Get(getAuthorizeUrlWithResponseMode("openid profile", responseMode, "&prompt=none"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer resp.Body.Close()
+
+			claims := getJWTResponse(t, httpClient, resp, responseMode)
+			assert.Equal(t, "test-client-1", claims["aud"])
+			assert.Equal(t, "login_required", claims["error"])
+			assert.NotEmpty(t, claims["error_description"])
+			assert.Equal(t, "a1b2c3", claims["state"])
+			assert.Nil(t, claims["code"])
+		})
+	}
+}
+
+func TestJARM_Discovery(t *testing.T) {
+	setup()
+
+	httpClient := createHttpClient(&createHttpClientInput{
+		T: t,
+	})
+
+	resp, err := httpClient.Get(lib.GetBaseUrl() + "/.well-known/openid-configuration")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	config := unmarshalToMap(t, resp)
+	for _, responseMode := range []string{"query", "fragment", "form_post", "query.jwt", "fragment.jwt", "form_post.jwt", "jwt"} {
+		assert.Contains(t, config["response_modes_supported"], responseMode)
+	}
+	assert.Equal(t, []interface{}{"RS256"}, config["authorization_signing_alg_values_supported"])
+}
@@ -0,0 +1,309 @@
+package integrationtests
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/leodip/goiabada/internal/entities"
+	"github.com/leodip/goiabada/internal/enums"
+	"github.com/leodip/goiabada/internal/lib"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+)
+
+type testCertificate struct {
+	Cert *x509.Certificate
+	Key  crypto.Signer
+	PEM  string
+}
+
+// createCertificate creates a client certificate with the given subject. It is signed by the
+// parent certificate, or self-signed when parent is nil.
+func createCertificate(t *testing.T, subject pkix.Name, isCA bool, parent *testCertificate) *testCertificate {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	serialNumber, err := rand.Int(rand.Reader, big.NewInt(1<<62))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber: serialNumber,
+		Subject:      subject,
+		NotBefore:    time.Now().Add(-time.Minute),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	if isCA {
+		template.IsCA = true
+		template.BasicConstraintsValid = true
+		template.KeyUsage |= x509.KeyUsageCertSign
+	}
+
+	parentCert, parentKey := template, crypto.Signer(key)
+	if parent != nil {
+		parentCert, parentKey = parent.Cert, parent.Key
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, parentCert, &key.PublicKey, parentKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return &testCertificate{
+		Cert: cert,
+		Key:  key,
+		PEM:  string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})),
+	}
+}
+
+// skipIfMTLSNotConfigured skips the test when the server does not read the client
+// certificate from the forwarded header (see the test-sqlite-mtls target in the Makefile)
+func skipIfMTLSNotConfigured(t *testing.T) {
+	if !viper.GetBool("MTLS.Enabled") || !viper.GetBool("IsBehindAReverseProxy") ||
+		len(viper.GetString("MTLS.ForwardedCertHeader")) == 0 {
+		t.Skip("mTLS with a forwarded certificate header is not configured")
+	}
+}
+
+func newRequestWithClientCertificate(t *testing.T, method string, destUrl string, body string,
+	certificate *testCertificate) *http.Request {
+
+	request, err := http.NewRequest(method, destUrl, strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if method == "POST" {
+		request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	}
+	if certificate != nil {
+		request.Header.Set(viper.GetString("MTLS.ForwardedCertHeader"), url.QueryEscape(certificate.PEM))
+	}
+	return request
+}
+
+func postToTokenEndpointWithClientCertificate(t *testing.T, client *http.Client, formData url.Values,
+	certificate *testCertificate) map[string]interface{} {
+
+	request := newRequestWithClientCertificate(t, "POST", lib.GetBaseUrl()+"/auth/token", formData.Encode(), certificate)
+	resp, err := client.Do(request)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	return unmarshalToMap(t, resp)
+}
+
+func getConfirmationX5t(t *testing.T, accessToken string) string {
+	claims := jwt.MapClaims{}
+	_, _, err := jwt.NewParser().ParseUnverified(accessToken, claims)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cnf, ok := claims["cnf"].(map[string]interface{})
+	if !ok {
+		return ""
+	}
+	x5t, _ := cnf["x5t#S256"].(string)
+	return x5t
+}
+
+func TestMTLS_SelfSignedTLSClientAuth(t *testing.T) {
+	setup()
+	skipIfMTLSNotConfigured(t)
+
+	certificate := createCertificate(t, pkix.Name{CommonName: "test-client-1"}, false, nil)
+	otherCertificate := createCertificate(t, pkix.Name{CommonName: "test-client-1"}, false, nil)
+	defer updateClient(t, "test-client-1", func(client *entities.Client) {
+		client.TokenEndpointAuthMethod = enums.TokenEndpointAuthMethodSelfSignedTLSClientAuth.String()
+		client.TLSClientCertificate = certificate.PEM
+	})()
+
+	httpClient := createHttpClient(&createHttpClientInput{
+		T: t,
+	})
+
+	formData := url.Values{
+		"grant_type": {"client_credentials"},
+		"client_id":  {"test-client-1"},
+		"scope":      {"backend-svcA:create-product"},
+	}
+	data := postToTokenEndpointWithClientCertificate(t, httpClient, formData, certificate)
+	assert.Equal(t, "Bearer", data["token_type"])
+	assert.Equal(t, "backend-svcA:create-product", data["scope"])
+	assert.NotEmpty(t, data["access_token"])
+	assert.Equal(t, lib.GetCertificateThumbprint(certificate.Cert), getConfirmationX5t(t, data["access_token"].(string)))
+
+	data = postToTokenEndpointWithClientCertificate(t, httpClient, formData, otherCertificate)
+	assert.Equal(t, "invalid_client", data["error"])
+	assert.Equal(t, "Client authentication failed. The client certificate does not match the one registered for the client.", data["error_description"])
+
+	data = postToTokenEndpointWithClientCertificate(t, httpClient, formData, nil)
+	assert.Equal(t, "invalid_client", data["error"])
+	assert.Equal(t, "This client is configured to authenticate with self_signed_tls_client_auth, which means a client certificate is required for authentication. Please present a valid client certificate in the TLS connection.", data["error_description"])
+
+	formData.Set("client_secret", getClientSecret(t, "test-client-1"))
+	data = postToTokenEndpointWithClientCertificate(t, httpClient, formData, certificate)
+	assert.Equal(t, "invalid_request", data["error"])
+	assert.Equal(t, "This client is configured to authenticate with self_signed_tls_client_auth, which means the client_secret must not be sent. To proceed, please remove the client_secret from your request.", data["error_description"])
+}
+
+func TestMTLS_TLSClientAuth(t *testing.T) {
+	setup()
+	skipIfMTLSNotConfigured(t)
+
+	caCertFile := viper.GetString("MTLS.CACertFile")
+	if len(caCertFile) == 0 {
+		t.Skip("the client certificate CA file is not configured")
+	}
+
+	ca := createCertificate(t, pkix.Name{CommonName: "Goiabada Test CA"}, true, nil)
+	err := os.WriteFile(caCertFile, []byte(ca.PEM), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(caCertFile)
+
+	subject := pkix.Name{CommonName: "partner.example.com", Organization: []string{"Example Partner"}, Country: []string{"US"}}
+	certificate := createCertificate(t, subject, false, ca)
+	defer updateClient(t, "test-client-1", func(client *entities.Client) {
+		client.TokenEndpointAuthMethod = enums.TokenEndpointAuthMethodTLSClientAuth.String()
+		client.TLSClientAuthSubjectDN = certificate.Cert.Subject.String()
+	})()
+
+	httpClient := createHttpClient(&createHttpClientInput{
+		T: t,
+	})
+
+	formData := url.Values{
+		"grant_type": {"client_credentials"},
+		"client_id":  {"test-client-1"},
+		"scope":      {"backend-svcA:create-product"},
+	}
+	data := postToTokenEndpointWithClientCertificate(t, httpClient, formData, certificate)
+	assert.Equal(t, "Bearer", data["token_type"])
+	assert.NotEmpty(t, data["access_token"])
+	assert.Equal(t, lib.GetCertificateThumbprint(certificate.Cert), getConfirmationX5t(t, data["access_token"].(string)))
+
+	// issued by the trusted CA, with another subject
+	otherSubjectCertificate := createCertificate(t, pkix.Name{CommonName: "other.example.com"}, false, ca)
+	data = postToTokenEndpointWithClientCertificate(t, httpClient, formData, otherSubjectCertificate)
+	assert.Equal(t, "invalid_client", data["error"])
+	assert.Equal(t, "Client authentication failed. The subject of the client certificate does not match the one registered for the client.", data["error_description"])
+
+	// same subject, but not issued by the trusted CA
+	selfSignedCertificate := createCertificate(t, subject, false, nil)
+	data = postToTokenEndpointWithClientCertificate(t, httpClient, formData, selfSignedCertificate)
+	assert.Equal(t, "invalid_client", data["error"])
+	assert.Equal(t, "Client authentication failed. The client certificate could not be verified.", data["error_description"])
+}
+
+func TestMTLS_TLSClientAuthWithoutTrustedCA(t *testing.T) {
+	setup()
+	skipIfMTLSNotConfigured(t)
+
+	if len(viper.GetString("MTLS.CACertFile")) > 0 {
+		t.Skip("the client certificate CA file is configured")
+	}
+
+	// without a trusted CA, a certificate with the registered subject is not enough (no fallback to the system roots)
+	subject := pkix.Name{CommonName: "partner.example.com"}
+	certificate := createCertificate(t, subject, false, nil)
+	defer updateClient(t, "test-client-1", func(client *entities.Client) {
+		client.TokenEndpointAuthMethod = enums.TokenEndpointAuthMethodTLSClientAuth.String()
+		client.TLSClientAuthSubjectDN = certificate.Cert.Subject.String()
+	})()
+
+	httpClient := createHttpClient(&createHttpClientInput{
+		T: t,
+	})
+
+	formData := url.Values{
+		"grant_type": {"client_credentials"},
+		"client_id":  {"test-client-1"},
+		"scope":      {"backend-svcA:create-product"},
+	}
+	data := postToTokenEndpointWithClientCertificate(t, httpClient, formData, certificate)
+	assert.Equal(t, "invalid_client", data["error"])
+	assert.Equal(t, "Client authentication failed. The tls_client_auth method is not available because no trusted certificate authority is configured on this server.", data["error_description"])
+
+	resp, err := httpClient.Get(lib.GetBaseUrl() + "/.well-known/openid-configuration")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	config := unmarshalToMap(t, resp)
+	assert.NotContains(t, config["token_endpoint_auth_methods_supported"], "tls_client_auth")
+	assert.Contains(t, config["token_endpoint_auth_methods_supported"], "self_signed_tls_client_auth")
+}
+
+func TestMTLS_CertificateBoundAccessToken(t *testing.T) {
+	setup()
+	skipIfMTLSNotConfigured(t)
+	scope := "openid profile email"
+	code, httpClient := createAuthCode(t, scope)
+
+	certificate := createCertificate(t, pkix.Name{CommonName: "test-client-1"}, false, nil)
+	otherCertificate := createCertificate(t, pkix.Name{CommonName: "test-client-1"}, false, nil)
+	defer updateClient(t, "test-client-1", func(client *entities.Client) {
+		client.TokenEndpointAuthMethod = enums.TokenEndpointAuthMethodSelfSignedTLSClientAuth.String()
+		client.TLSClientCertificate = certificate.PEM
+	})()
+
+	formData := url.Values{
+		"client_id":     {"test-client-1"},
+		"grant_type":    {"authorization_code"},
+		"redirect_uri":  {code.RedirectURI},
+		"code":          {code.Code},
+		"code_verifier": {"DdazqdVNuDmRLGGRGQKKehEaoFeatACtNsM2UYGwuHkhBhDsTSzaCqWttcBc0kGx"},
+	}
+	respData := postToTokenEndpointWithClientCertificate(t, httpClient, formData, certificate)
+	assert.Equal(t, "Bearer", respData["token_type"])
+	accessToken := respData["access_token"].(string)
+	assert.Equal(t, lib.GetCertificateThumbprint(certificate.Cert), getConfirmationX5t(t, accessToken))
+
+	userInfoUrl := lib.GetBaseUrl() + "/userinfo"
+
+	testCases := []struct {
+		certificate    *testCertificate
+		expectedStatus int
+	}{
+		{nil, http.StatusUnauthorized},
+		{otherCertificate, http.StatusUnauthorized},
+		{certificate, http.StatusOK},
+	}
+
+	for _, testCase := range testCases {
+		request := newRequestWithClientCertificate(t, "GET", userInfoUrl, "", testCase.certificate)
+		request.Header.Set("Authorization", "Bearer "+accessToken)
+		resp, err := httpClient.Do(request)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		assert.Equal(t, testCase.expectedStatus, resp.StatusCode)
+	}
+}
@@ -0,0 +1,234 @@
+package integrationtests
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/leodip/goiabada/internal/entities"
+	"github.com/leodip/goiabada/internal/lib"
+	"github.com/stretchr/testify/assert"
+)
+
+func getTokensWithAuthCode(t *testing.T, scope string) (map[string]interface{}, *http.Client) {
+	code, httpClient := createAuthCode(t, scope)
+
+	formData := url.Values{
+		"client_id":     {"test-client-1"},
+		"client_secret": {getClientSecret(t, "test-client-1")},
+		"grant_type":    {"authorization_code"},
+		"redirect_uri":  {code.RedirectURI},
+		"code":          {code.Code},
+		"code_verifier": {"DdazqdVNuDmRLGGRGQKKehEaoFeatACtNsM2UYGwuHkhBhDsTSzaCqWttcBc0kGx"},
+	}
+	return postToTokenEndpoint(t, httpClient, lib.GetBaseUrl()+"/auth/token", formData), httpClient
+}
+
+func TestPairwiseSubject_Tokens(t *testing.T) {
+	setup()
+
+	restore := updateClient(t, "test-client-1", func(client *entities.Client) {
+		client.SubjectType = "pairwise"
+		client.SectorIdentifierURI = "https://sector-a.example.com/redirect_uris.json"
+	})
+	defer restore()
+
+	user, err := database.GetUserByEmail(nil, "mauro@outlook.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	respData, httpClient := getTokensWithAuthCode(t, "openid profile backend-svcA:read-product")
+
+	_, idTokenClaims := parseToken(t, respData["id_token"].(string))
+	sub := idTokenClaims["sub"].(string)
+	assert.NotEmpty(t, sub)
+	assert.NotEqual(t, user.Subject.String(), sub)
+
+	pairwiseSubject, err := database.GetPairwiseSubjectBySubject(nil, sub)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, user.Id, pairwiseSubject.UserId)
+	assert.Equal(t, "sector-a.example.com", pairwiseSubject.SectorIdentifier)
+
+	_, accessTokenClaims := parseToken(t, respData["access_token"].(string))
+	assert.Equal(t, sub, accessTokenClaims["sub"])
+	_, refreshTokenClaims := parseToken(t, respData["refresh_token"].(string))
+	assert.Equal(t, sub, refreshTokenClaims["sub"])
+
+	// userinfo
+	request, err := http.NewRequest("GET", lib.GetBaseUrl()+"/userinfo", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	request.Header.Set("Authorization", "Bearer "+respData["access_token"].(string))
+	resp, err := httpClient.Do(request)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	userInfo := unmarshalToMap(t, resp)
+	assert.Equal(t, sub, userInfo["sub"])
+	assert.Equal(t, user.GetFullName(), userInfo["name"])
+
+	// the refresh token maps back to the user
+	formData := url.Values{
+		"client_id":     {"test-client-1"},
+		"client_secret": {getClientSecret(t, "test-client-1")},
+		"grant_type":    {"refresh_token"},
+		"refresh_token": {respData["refresh_token"].(string)},
+	}
+	respData = postToTokenEndpoint(t, httpClient, lib.GetBaseUrl()+"/auth/token", formData)
+	_, idTokenClaims = parseToken(t, respData["id_token"].(string))
+	assert.Equal(t, sub, idTokenClaims["sub"])
+
+	// the pairwise id token matches the user of the session
+	resp, err = httpClient.Get(getAuthorizeUrl("openid profile", "&prompt=none&id_token_hint="+url.QueryEscape(respData["id_token"].(string))))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	assertRedirect(t, resp, "/auth/consent")
+
+	// RP-initiated logout with the pairwise id token
+	resp, err = httpClient.Get(lib.GetBaseUrl() + "/auth/logout?id_token_hint=" + url.QueryEscape(respData["id_token"].(string)) +
+		"&post_logout_redirect_uri=https://oauthdebugger.com/debug&state=XYZ123")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	redirectLocation := resp.Header.Get("Location")
+	assert.Contains(t, redirectLocation, "https://oauthdebugger.com/debug")
+	assert.Contains(t, redirectLocation, "state=XYZ123")
+}
+
+func TestPairwiseSubject_Sectors(t *testing.T) {
+	setup()
+
+	restore := updateClient(t, "test-client-1", func(client *entities.Client) {
+		client.SubjectType = "pairwise"
+		client.SectorIdentifierURI = "https://sector-a.example.com/redirect_uris.json"
+	})
+	respData, _ := getTokensWithAuthCode(t, "openid profile")
+	_, claims := parseToken(t, respData["id_token"].(string))
+	subSectorA := claims["sub"].(string)
+
+	// the subject is stable within the sector
+	respData, _ = getTokensWithAuthCode(t, "openid profile")
+	_, claims = parseToken(t, respData["id_token"].(string))
+	assert.Equal(t, subSectorA, claims["sub"])
+	restore()
+
+	restore = updateClient(t, "test-client-1", func(client *entities.Client) {
+		client.SubjectType = "pairwise"
+		client.SectorIdentifierURI = "https://sector-b.example.com/redirect_uris.json"
+	})
+	respData, _ = getTokensWithAuthCode(t, "openid profile")
+	_, claims = parseToken(t, respData["id_token"].(string))
+	subSectorB := claims["sub"].(string)
+	restore()
+
+	assert.NotEqual(t, subSectorA, subSectorB)
+
+	// public
+	user, err := database.GetUserByEmail(nil, "mauro@outlook.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	respData, _ = getTokensWithAuthCode(t, "openid profile")
+	_, claims = parseToken(t, respData["id_token"].(string))
+	assert.Equal(t, user.Subject.String(), claims["sub"])
+}
+
+func TestPairwiseSubject_ClientRegistration(t *testing.T) {
+	setup()
+
+	initialAccessToken, deleteInitialAccessToken := createInitialAccessToken(t, sql.NullTime{})
+	defer deleteInitialAccessToken()
+
+	sectorServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode([]string{"https://app1.example.com/callback", "https://app2.example.com/callback"})
+	}))
+	defer sectorServer.Close()
+
+	httpClient := createHttpClient(&createHttpClientInput{
+		T: t,
+	})
+
+	resp, data := sendClientRegistrationRequest(t, httpClient, "POST", lib.GetBaseUrl()+"/connect/register", initialAccessToken, map[string]interface{}{
+		"redirect_uris":         []string{"https://app1.example.com/callback", "https://app2.example.com/callback"},
+		"subject_type":          "pairwise",
+		"sector_identifier_uri": sectorServer.URL,
+	})
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+	assert.Equal(t, "pairwise", data["subject_type"])
+	assert.Equal(t, sectorServer.URL, data["sector_identifier_uri"])
+
+	client, err := database.GetClientByClientIdentifier(nil, data["client_id"].(string))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "pairwise", client.SubjectType)
+	err = database.DeleteClient(nil, client.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testCases := []struct {
+		metadata            map[string]interface{}
+		expectedDescription string
+	}{
+		{
+			metadata: map[string]interface{}{
+				"redirect_uris": []string{"https://app1.example.com/callback"},
+				"subject_type":  "unknown",
+			},
+			expectedDescription: "Unsupported subject_type 'unknown'. It must be public or pairwise.",
+		},
+		{
+			metadata: map[string]interface{}{
+				"redirect_uris": []string{"https://app1.example.com/callback", "https://app2.example.com/callback"},
+				"subject_type":  "pairwise",
+			},
+			expectedDescription: "Pairwise subjects require a sector_identifier_uri when the redirect URIs have different hosts.",
+		},
+		{
+			metadata: map[string]interface{}{
+				"redirect_uris":         []string{"https://app3.example.com/callback"},
+				"subject_type":          "pairwise",
+				"sector_identifier_uri": sectorServer.URL,
+			},
+			expectedDescription: "The redirect URI 'https://app3.example.com/callback' is not included in the sector_identifier_uri document.",
+		},
+	}
+
+	for _, testCase := range testCases {
+		resp, data := sendClientRegistrationRequest(t, httpClient, "POST", lib.GetBaseUrl()+"/connect/register", initialAccessToken, testCase.metadata)
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+		assert.Equal(t, "invalid_client_metadata", data["error"])
+		assert.Equal(t, testCase.expectedDescription, data["error_description"])
+	}
+}
+
+func TestPairwiseSubject_Discovery(t *testing.T) {
+	setup()
+
+	httpClient := createHttpClient(&createHttpClientInput{
+		T: t,
+	})
+
+	resp, err := httpClient.Get(lib.GetBaseUrl() + "/.well-known/openid-configuration")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	config := unmarshalToMap(t, resp)
+	assert.Equal(t, []interface{}{"public", "pairwise"}, config["subject_types_supported"])
+}
@@ -0,0 +1,268 @@
+package integrationtests
+
+import (
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/leodip/goiabada/internal/enums"
+	"github.com/leodip/goiabada/internal/lib"
+	"github.com/stretchr/testify/assert"
+)
+
+func getAuthorizeUrl(scope string, extraParams string) string {
+	return lib.GetBaseUrl() +
+		"/auth/authorize/?client_id=test-client-1&redirect_uri=https://goiabada-test-client:8090/callback.html&response_type=code" +
+		"&code_challenge_method=S256&code_challenge=0BnoD4e6xPCPip8rqZ9Zc2RqWOFfvryu9vzXJN4egoY" +
+		"&response_mode=query&scope=" + url.QueryEscape(scope) + "&state=a1b2c3&nonce=m9n8b7" +
+		"&acr_values=" + enums.AcrLevel1.String() + extraParams
+}
+
+func assertRedirectToClientWithError(t *testing.T, resp *http.Response, expectedError string) {
+	assertRedirect(t, resp, "/callback.html")
+	redirectLocation, err := url.Parse(resp.Header.Get("Location"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, expectedError, redirectLocation.Query().Get("error"))
+	assert.Equal(t, "a1b2c3", redirectLocation.Query().Get("state"))
+}
+
+func getIdToken(t *testing.T, scope string) (string, *http.Client) {
+	code, httpClient := createAuthCode(t, scope)
+
+	formData := url.Values{
+		"client_id":     {"test-client-1"},
+		"client_secret": {getClientSecret(t, "test-client-1")},
+		"grant_type":    {"authorization_code"},
+		"redirect_uri":  {code.RedirectURI},
+		"code":          {code.Code},
+		"code_verifier": {"DdazqdVNuDmRLGGRGQKKehEaoFeatACtNsM2UYGwuHkhBhDsTSzaCqWttcBc0kGx"},
+	}
+	respData := postToTokenEndpoint(t, httpClient, lib.GetBaseUrl()+"/auth/token", formData)
+	return respData["id_token"].(string), httpClient
+}
+
+func TestPrompt_NoneWithoutSession(t *testing.T) {
+	setup()
+
+	httpClient := createHttpClient(&createHttpClientInput{
+		T: t,
+	})
+
+	resp, err := httpClient.Get(getAuthorizeUrl("openid profile", "&prompt=none"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	assertRedirectToClientWithError(t, resp, "login_required")
+}
+
+func TestPrompt_NoneWithSession(t *testing.T) {
+	setup()
+
+	_, httpClient := createAuthCode(t, "openid profile")
+
+	// the user already consented, so the code is issued without any UI
+	resp, err := httpClient.Get(getAuthorizeUrl("openid profile", "&prompt=none"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	assertRedirect(t, resp, "/auth/consent")
+	resp = getPage(t, httpClient, lib.GetBaseUrl()+"/auth/consent")
+	defer resp.Body.Close()
+
+	assertRedirect(t, resp, "/callback.html")
+	codeVal, stateVal := getCodeAndStateFromUrl(t, resp)
+	assert.NotEmpty(t, codeVal)
+	assert.Equal(t, "a1b2c3", stateVal)
+
+	// consent was not given for the email scope
+	resp, err = httpClient.Get(getAuthorizeUrl("openid profile email", "&prompt=none"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	assertRedirect(t, resp, "/auth/consent")
+	resp = getPage(t, httpClient, lib.GetBaseUrl()+"/auth/consent")
+	defer resp.Body.Close()
+
+	assertRedirectToClientWithError(t, resp, "consent_required")
+}
+
+func TestPrompt_Login(t *testing.T) {
+	setup()
+
+	_, httpClient := createAuthCode(t, "openid profile")
+
+	resp, err := httpClient.Get(getAuthorizeUrl("openid profile", "&prompt=login"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	assertRedirect(t, resp, "/auth/pwd")
+}
+
+func TestPrompt_Consent(t *testing.T) {
+	setup()
+
+	_, httpClient := createAuthCode(t, "openid profile")
+
+	resp, err := httpClient.Get(getAuthorizeUrl("openid profile", "&prompt=consent"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	assertRedirect(t, resp, "/auth/consent")
+	resp = getPage(t, httpClient, lib.GetBaseUrl()+"/auth/consent")
+	defer resp.Body.Close()
+
+	// the consent page is rendered even though all scopes were consented
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	doc, err := goquery.NewDocumentFromReader(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, 1, doc.Find("button[name='btnSubmit']").Length())
+}
+
+func TestPrompt_InvalidValues(t *testing.T) {
+	setup()
+
+	httpClient := createHttpClient(&createHttpClientInput{
+		T: t,
+	})
+
+	resp, err := httpClient.Get(getAuthorizeUrl("openid profile", "&prompt=invalid"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	assertRedirectToClientWithError(t, resp, "invalid_request")
+
+	resp, err = httpClient.Get(getAuthorizeUrl("openid profile", "&prompt="+url.QueryEscape("none login")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	assertRedirectToClientWithError(t, resp, "invalid_request")
+
+	resp, err = httpClient.Get(getAuthorizeUrl("openid profile", "&max_age=abc"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	assertRedirectToClientWithError(t, resp, "invalid_request")
+}
+
+func TestPrompt_LoginHint(t *testing.T) {
+	setup()
+
+	httpClient := createHttpClient(&createHttpClientInput{
+		T: t,
+	})
+
+	resp, err := httpClient.Get(getAuthorizeUrl("openid profile", "&login_hint="+url.QueryEscape("viviane@gmail.com")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	assertRedirect(t, resp, "/auth/pwd")
+	resp = getPage(t, httpClient, lib.GetBaseUrl()+"/auth/pwd")
+	defer resp.Body.Close()
+
+	doc, err := goquery.NewDocumentFromReader(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	email, _ := doc.Find("input[name='email']").Attr("value")
+	assert.Equal(t, "viviane@gmail.com", email)
+}
+
+func TestPrompt_IdTokenHint(t *testing.T) {
+	setup()
+
+	idToken, httpClient := getIdToken(t, "openid profile")
+
+	// the id token matches the session subject
+	resp, err := httpClient.Get(getAuthorizeUrl("openid profile", "&prompt=none&id_token_hint="+url.QueryEscape(idToken)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	assertRedirect(t, resp, "/auth/consent")
+
+	// the id token belongs to a different user
+	keyPair, err := database.GetCurrentSigningKey(nil, "RS256")
+	if err != nil {
+		t.Fatal(err)
+	}
+	privKey, err := jwt.ParseRSAPrivateKeyFromPEM(keyPair.PrivateKeyPEM)
+	if err != nil {
+		t.Fatal(err)
+	}
+	settings, err := database.GetSettingsById(nil, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{
+		"iss": settings.Issuer,
+		"aud": "test-client-1",
+		"sub": uuid.New().String(),
+		"typ": enums.TokenTypeId.String(),
+		"iat": time.Now().Add(-2 * time.Hour).Unix(),
+		"exp": time.Now().Add(-time.Hour).Unix(),
+	})
+	token.Header["kid"] = keyPair.KeyIdentifier
+	otherIdToken, err := token.SignedString(privKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err = httpClient.Get(getAuthorizeUrl("openid profile", "&prompt=none&id_token_hint="+url.QueryEscape(otherIdToken)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	assertRedirectToClientWithError(t, resp, "login_required")
+
+	resp, err = httpClient.Get(getAuthorizeUrl("openid profile", "&id_token_hint="+url.QueryEscape(otherIdToken)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	assertRedirect(t, resp, "/auth/pwd")
+
+	// a token of this server that is not an ID token
+	token.Claims.(jwt.MapClaims)["typ"] = enums.TokenTypeBearer.String()
+	accessToken, err := token.SignedString(privKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err = httpClient.Get(getAuthorizeUrl("openid profile", "&id_token_hint="+url.QueryEscape(accessToken)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	assertRedirectToClientWithError(t, resp, "invalid_request")
+
+	// a token that was not issued by this server
+	resp, err = httpClient.Get(getAuthorizeUrl("openid profile", "&id_token_hint=invalid"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	assertRedirectToClientWithError(t, resp, "invalid_request")
+}
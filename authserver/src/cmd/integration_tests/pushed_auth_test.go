@@ -0,0 +1,254 @@
+package integrationtests
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/leodip/goiabada/internal/lib"
+	"github.com/stretchr/testify/assert"
+)
+
+func pushAuthRequest(t *testing.T, httpClient *http.Client, formData url.Values) (*http.Response, map[string]interface{}) {
+	resp, err := httpClient.PostForm(lib.GetBaseUrl()+"/auth/par", formData)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	var data map[string]interface{}
+	err = json.NewDecoder(resp.Body).Decode(&data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return resp, data
+}
+
+func getPushedAuthRequestFormData(t *testing.T) url.Values {
+	return url.Values{
+		"client_id":             {"test-client-1"},
+		"client_secret":         {getClientSecret(t, "test-client-1")},
+		"redirect_uri":          {"https://goiabada-test-client:8090/callback.html"},
+		"response_type":         {"code"},
+		"code_challenge_method": {"S256"},
+		"code_challenge":        {"0BnoD4e6xPCPip8rqZ9Zc2RqWOFfvryu9vzXJN4egoY"},
+		"response_mode":         {"query"},
+		"scope":                 {"openid profile email"},
+		"state":                 {"p4r5t6"},
+		"nonce":                 {"z1x2c3"},
+	}
+}
+
+func getAuthorizeErrorMsg(t *testing.T, httpClient *http.Client, destUrl string) string {
+	resp, err := httpClient.Get(destUrl)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	doc, err := goquery.NewDocumentFromReader(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return doc.Find("p#errorMsg").Text()
+}
+
+func TestPushedAuth_MissingClientId(t *testing.T) {
+	setup()
+
+	httpClient := createHttpClient(&createHttpClientInput{
+		T: t,
+	})
+
+	resp, data := pushAuthRequest(t, httpClient, url.Values{})
+
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	assert.Equal(t, "invalid_request", data["error"])
+	assert.Equal(t, "Missing required client_id parameter.", data["error_description"])
+}
+
+func TestPushedAuth_ClientAuthFailed(t *testing.T) {
+	setup()
+
+	httpClient := createHttpClient(&createHttpClientInput{
+		T: t,
+	})
+
+	formData := getPushedAuthRequestFormData(t)
+	formData.Set("client_secret", "invalid")
+	resp, data := pushAuthRequest(t, httpClient, formData)
+
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	assert.Equal(t, "invalid_client", data["error"])
+	assert.Equal(t, "Client authentication failed.", data["error_description"])
+}
+
+func TestPushedAuth_InvalidRedirectURI(t *testing.T) {
+	setup()
+
+	httpClient := createHttpClient(&createHttpClientInput{
+		T: t,
+	})
+
+	formData := getPushedAuthRequestFormData(t)
+	formData.Set("redirect_uri", "https://example.com/callback")
+	resp, data := pushAuthRequest(t, httpClient, formData)
+
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	assert.Equal(t, "invalid_request", data["error"])
+	assert.Equal(t, "Invalid redirect_uri parameter. The client does not have this redirect uri configured.", data["error_description"])
+}
+
+func TestPushedAuth_RequestURINotAllowed(t *testing.T) {
+	setup()
+
+	httpClient := createHttpClient(&createHttpClientInput{
+		T: t,
+	})
+
+	formData := getPushedAuthRequestFormData(t)
+	formData.Set("request_uri", "urn:ietf:params:oauth:request_uri:abc")
+	resp, data := pushAuthRequest(t, httpClient, formData)
+
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	assert.Equal(t, "invalid_request", data["error"])
+	assert.Equal(t, "The request_uri parameter is not allowed in a pushed authorization request.", data["error_description"])
+}
+
+func TestPushedAuth_InvalidScope(t *testing.T) {
+	setup()
+
+	httpClient := createHttpClient(&createHttpClientInput{
+		T: t,
+	})
+
+	formData := getPushedAuthRequestFormData(t)
+	formData.Set("scope", "openid invalid-scope")
+	resp, data := pushAuthRequest(t, httpClient, formData)
+
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	assert.Equal(t, "invalid_scope", data["error"])
+}
+
+func TestPushedAuth_FullFlow(t *testing.T) {
+	setup()
+
+	deleteAllUserConsents(t)
+
+	httpClient := createHttpClient(&createHttpClientInput{
+		T: t,
+	})
+
+	resp, data := pushAuthRequest(t, httpClient, getPushedAuthRequestFormData(t))
+
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+	assert.Equal(t, "no-store", resp.Header.Get("Cache-Control"))
+	assert.Equal(t, float64(60), data["expires_in"])
+	requestURI := data["request_uri"].(string)
+	assert.True(t, strings.HasPrefix(requestURI, "urn:ietf:params:oauth:request_uri:"))
+
+	destUrl := lib.GetBaseUrl() + "/auth/authorize/?client_id=test-client-1&request_uri=" + url.QueryEscape(requestURI)
+
+	resp, err := httpClient.Get(destUrl)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	assertRedirect(t, resp, "/auth/pwd")
+	resp = authenticateWithPasswordAndOtp(t, httpClient, "mauro@outlook.com", "abc123")
+	defer resp.Body.Close()
+
+	assertRedirect(t, resp, "/auth/consent")
+	resp = getPage(t, httpClient, lib.GetBaseUrl()+"/auth/consent")
+	defer resp.Body.Close()
+	csrf := getCsrfValue(t, resp)
+
+	resp = postConsent(t, httpClient, []int{0, 1, 2, 3}, csrf)
+	defer resp.Body.Close()
+
+	assertRedirect(t, resp, "/callback.html")
+	codeVal, stateVal := getCodeAndStateFromUrl(t, resp)
+	assert.Equal(t, "p4r5t6", stateVal)
+
+	codeHash, err := lib.HashString(codeVal)
+	if err != nil {
+		t.Fatal(err)
+	}
+	code, err := database.GetCodeByCodeHash(nil, codeHash, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "openid profile email", code.Scope)
+	assert.Equal(t, "z1x2c3", code.Nonce)
+	assert.Equal(t, "https://goiabada-test-client:8090/callback.html", code.RedirectURI)
+
+	// a request_uri can only be used once
+	errorMsg := getAuthorizeErrorMsg(t, httpClient, destUrl)
+	assert.Equal(t, "The request_uri parameter is invalid or has expired.", errorMsg)
+}
+
+func TestPushedAuth_RequestURIFromAnotherClient(t *testing.T) {
+	setup()
+
+	httpClient := createHttpClient(&createHttpClientInput{
+		T: t,
+	})
+
+	resp, data := pushAuthRequest(t, httpClient, getPushedAuthRequestFormData(t))
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+
+	destUrl := lib.GetBaseUrl() + "/auth/authorize/?client_id=test-client-2&request_uri=" + url.QueryEscape(data["request_uri"].(string))
+
+	errorMsg := getAuthorizeErrorMsg(t, httpClient, destUrl)
+	assert.Equal(t, "The request_uri parameter is invalid or has expired.", errorMsg)
+}
+
+func TestPushedAuth_Required(t *testing.T) {
+	setup()
+
+	client, err := database.GetClientByClientIdentifier(nil, "test-client-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	client.PARRequired = true
+	err = database.UpdateClient(nil, client)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		client.PARRequired = false
+		err = database.UpdateClient(nil, client)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	httpClient := createHttpClient(&createHttpClientInput{
+		T: t,
+	})
+
+	destUrl := lib.GetBaseUrl() + "/auth/authorize/?client_id=test-client-1&redirect_uri=https://goiabada-test-client:8090/callback.html&response_type=code" +
+		"&code_challenge_method=S256&code_challenge=0BnoD4e6xPCPip8rqZ9Zc2RqWOFfvryu9vzXJN4egoY&scope=openid&state=a1b2c3"
+
+	errorMsg := getAuthorizeErrorMsg(t, httpClient, destUrl)
+	assert.Equal(t, "The client associated with the provided client_id requires pushed authorization requests (PAR). Please provide a request_uri obtained from the PAR endpoint.", errorMsg)
+
+	// with PAR, the request goes through
+	resp, data := pushAuthRequest(t, httpClient, getPushedAuthRequestFormData(t))
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+
+	destUrl = lib.GetBaseUrl() + "/auth/authorize/?client_id=test-client-1&request_uri=" + url.QueryEscape(data["request_uri"].(string))
+	resp, err = httpClient.Get(destUrl)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	assertRedirect(t, resp, "/auth/pwd")
+}
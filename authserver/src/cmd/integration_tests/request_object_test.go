@@ -0,0 +1,264 @@
+package integrationtests
+
+import (
+	"crypto/rsa"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/leodip/goiabada/internal/entities"
+	"github.com/leodip/goiabada/internal/lib"
+	"github.com/stretchr/testify/assert"
+)
+
+const requestObjectKid = "request-object-key"
+
+// setClientJWKS registers the public part of privKey on the client, and returns a func to remove it
+func setClientJWKS(t *testing.T, clientIdentifier string, privKey *rsa.PrivateKey) func() {
+	jwk, err := lib.MarshalPublicKeyToJWK(&privKey.PublicKey, "RS256", requestObjectKid)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return updateClient(t, clientIdentifier, func(client *entities.Client) {
+		client.JWKS = `{"keys": [` + string(jwk) + `]}`
+	})
+}
+
+func createRequestObject(t *testing.T, privKey *rsa.PrivateKey, claims jwt.MapClaims) string {
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = requestObjectKid
+	tokenStr, err := token.SignedString(privKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tokenStr
+}
+
+func getRequestObjectClaims(t *testing.T) jwt.MapClaims {
+	settings, err := database.GetSettingsById(nil, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return jwt.MapClaims{
+		"iss":                   "test-client-1",
+		"aud":                   settings.Issuer,
+		"exp":                   time.Now().Add(5 * time.Minute).Unix(),
+		"client_id":             "test-client-1",
+		"redirect_uri":          "https://goiabada-test-client:8090/callback.html",
+		"response_type":         "code",
+		"code_challenge_method": "S256",
+		"code_challenge":        "0BnoD4e6xPCPip8rqZ9Zc2RqWOFfvryu9vzXJN4egoY",
+		"response_mode":         "query",
+		"scope":                 "openid profile email",
+		"state":                 "j4r5t6",
+		"nonce":                 "m1n2b3",
+	}
+}
+
+func TestRequestObject_NoKeysRegistered(t *testing.T) {
+	setup()
+
+	privKey, err := lib.GeneratePrivateKey(2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	httpClient := createHttpClient(&createHttpClientInput{
+		T: t,
+	})
+
+	requestObject := createRequestObject(t, privKey, getRequestObjectClaims(t))
+	destUrl := lib.GetBaseUrl() + "/auth/authorize/?client_id=test-client-1&request=" + requestObject
+
+	errorMsg := getAuthorizeErrorMsg(t, httpClient, destUrl)
+	assert.Equal(t, "The client associated with the provided client_id has no keys registered to verify request objects.", errorMsg)
+}
+
+func TestRequestObject_InvalidSignature(t *testing.T) {
+	setup()
+
+	privKey, err := lib.GeneratePrivateKey(2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherPrivKey, err := lib.GeneratePrivateKey(2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer setClientJWKS(t, "test-client-1", privKey)()
+
+	httpClient := createHttpClient(&createHttpClientInput{
+		T: t,
+	})
+
+	requestObject := createRequestObject(t, otherPrivKey, getRequestObjectClaims(t))
+	destUrl := lib.GetBaseUrl() + "/auth/authorize/?client_id=test-client-1&request=" + requestObject
+
+	errorMsg := getAuthorizeErrorMsg(t, httpClient, destUrl)
+	assert.Equal(t, "The request object is invalid. Its signature could not be verified, or it has expired.", errorMsg)
+}
+
+func TestRequestObject_Expired(t *testing.T) {
+	setup()
+
+	privKey, err := lib.GeneratePrivateKey(2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer setClientJWKS(t, "test-client-1", privKey)()
+
+	httpClient := createHttpClient(&createHttpClientInput{
+		T: t,
+	})
+
+	claims := getRequestObjectClaims(t)
+	claims["exp"] = time.Now().Add(-time.Minute).Unix()
+	requestObject := createRequestObject(t, privKey, claims)
+	destUrl := lib.GetBaseUrl() + "/auth/authorize/?client_id=test-client-1&request=" + requestObject
+
+	errorMsg := getAuthorizeErrorMsg(t, httpClient, destUrl)
+	assert.Equal(t, "The request object is invalid. Its signature could not be verified, or it has expired.", errorMsg)
+}
+
+func TestRequestObject_IssuerMismatch(t *testing.T) {
+	setup()
+
+	privKey, err := lib.GeneratePrivateKey(2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer setClientJWKS(t, "test-client-1", privKey)()
+
+	httpClient := createHttpClient(&createHttpClientInput{
+		T: t,
+	})
+
+	claims := getRequestObjectClaims(t)
+	claims["iss"] = "test-client-2"
+	requestObject := createRequestObject(t, privKey, claims)
+	destUrl := lib.GetBaseUrl() + "/auth/authorize/?client_id=test-client-1&request=" + requestObject
+
+	errorMsg := getAuthorizeErrorMsg(t, httpClient, destUrl)
+	assert.Equal(t, "The request object is invalid. The iss claim must be the client_id.", errorMsg)
+}
+
+func TestRequestObject_ClientIdMismatch(t *testing.T) {
+	setup()
+
+	privKey, err := lib.GeneratePrivateKey(2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer setClientJWKS(t, "test-client-1", privKey)()
+
+	httpClient := createHttpClient(&createHttpClientInput{
+		T: t,
+	})
+
+	claims := getRequestObjectClaims(t)
+	delete(claims, "iss")
+	claims["client_id"] = "test-client-2"
+	requestObject := createRequestObject(t, privKey, claims)
+	destUrl := lib.GetBaseUrl() + "/auth/authorize/?client_id=test-client-1&request=" + requestObject
+
+	errorMsg := getAuthorizeErrorMsg(t, httpClient, destUrl)
+	assert.Equal(t, "The client_id in the request object does not match the client_id parameter.", errorMsg)
+}
+
+func TestRequestObject_FullFlow(t *testing.T) {
+	setup()
+
+	deleteAllUserConsents(t)
+
+	privKey, err := lib.GeneratePrivateKey(2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer setClientJWKS(t, "test-client-1", privKey)()
+
+	httpClient := createHttpClient(&createHttpClientInput{
+		T: t,
+	})
+
+	requestObject := createRequestObject(t, privKey, getRequestObjectClaims(t))
+
+	// the claims in the request object take precedence over the query parameters
+	destUrl := lib.GetBaseUrl() + "/auth/authorize/?client_id=test-client-1&scope=openid&state=overridden&request=" + requestObject
+
+	resp, err := httpClient.Get(destUrl)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	assertRedirect(t, resp, "/auth/pwd")
+	resp = authenticateWithPasswordAndOtp(t, httpClient, "mauro@outlook.com", "abc123")
+	defer resp.Body.Close()
+
+	assertRedirect(t, resp, "/auth/consent")
+	resp = getPage(t, httpClient, lib.GetBaseUrl()+"/auth/consent")
+	defer resp.Body.Close()
+	csrf := getCsrfValue(t, resp)
+
+	resp = postConsent(t, httpClient, []int{0, 1, 2, 3}, csrf)
+	defer resp.Body.Close()
+
+	assertRedirect(t, resp, "/callback.html")
+	codeVal, stateVal := getCodeAndStateFromUrl(t, resp)
+	assert.Equal(t, "j4r5t6", stateVal)
+
+	codeHash, err := lib.HashString(codeVal)
+	if err != nil {
+		t.Fatal(err)
+	}
+	code, err := database.GetCodeByCodeHash(nil, codeHash, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "openid profile email", code.Scope)
+	assert.Equal(t, "m1n2b3", code.Nonce)
+}
+
+func TestRequestObject_PushedAuthRequest(t *testing.T) {
+	setup()
+
+	privKey, err := lib.GeneratePrivateKey(2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherPrivKey, err := lib.GeneratePrivateKey(2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer setClientJWKS(t, "test-client-1", privKey)()
+
+	httpClient := createHttpClient(&createHttpClientInput{
+		T: t,
+	})
+
+	formData := url.Values{
+		"client_id":     {"test-client-1"},
+		"client_secret": {getClientSecret(t, "test-client-1")},
+		"request":       {createRequestObject(t, otherPrivKey, getRequestObjectClaims(t))},
+	}
+	resp, data := pushAuthRequest(t, httpClient, formData)
+
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	assert.Equal(t, "invalid_request_object", data["error"])
+
+	formData.Set("request", createRequestObject(t, privKey, getRequestObjectClaims(t)))
+	resp, data = pushAuthRequest(t, httpClient, formData)
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+
+	destUrl := lib.GetBaseUrl() + "/auth/authorize/?client_id=test-client-1&request_uri=" + url.QueryEscape(data["request_uri"].(string))
+	resp, err = httpClient.Get(destUrl)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	assertRedirect(t, resp, "/auth/pwd")
+}
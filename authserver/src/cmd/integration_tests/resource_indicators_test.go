@@ -0,0 +1,214 @@
+package integrationtests
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/leodip/goiabada/internal/lib"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResourceIndicators_AuthCodeAndRefresh(t *testing.T) {
+	setup()
+
+	scope := "openid profile backend-svcA:read-product backend-svcB:write-info"
+	code, httpClient := createAuthCode(t, scope)
+
+	clientSecret := getClientSecret(t, "test-client-1")
+	destUrl := lib.GetBaseUrl() + "/auth/token"
+
+	formData := url.Values{
+		"client_id":     {"test-client-1"},
+		"client_secret": {clientSecret},
+		"grant_type":    {"authorization_code"},
+		"redirect_uri":  {code.RedirectURI},
+		"code":          {code.Code},
+		"code_verifier": {"DdazqdVNuDmRLGGRGQKKehEaoFeatACtNsM2UYGwuHkhBhDsTSzaCqWttcBc0kGx"},
+		"resource":      {"backend-svcA"},
+	}
+	respData := postToTokenEndpoint(t, httpClient, destUrl, formData)
+
+	assert.Equal(t, "backend-svcA:read-product", respData["scope"])
+	assert.NotEmpty(t, respData["id_token"])
+	_, accessTokenClaims := parseToken(t, respData["access_token"].(string))
+	assert.Equal(t, "backend-svcA", accessTokenClaims["aud"])
+	assert.Equal(t, "backend-svcA:read-product", accessTokenClaims["scope"])
+
+	// one access token per resource on refresh
+	formData = url.Values{
+		"client_id":     {"test-client-1"},
+		"client_secret": {clientSecret},
+		"grant_type":    {"refresh_token"},
+		"refresh_token": {respData["refresh_token"].(string)},
+		"resource":      {"backend-svcB"},
+	}
+	respData = postToTokenEndpoint(t, httpClient, destUrl, formData)
+
+	assert.Equal(t, "backend-svcB:write-info", respData["scope"])
+	_, accessTokenClaims = parseToken(t, respData["access_token"].(string))
+	assert.Equal(t, "backend-svcB", accessTokenClaims["aud"])
+
+	// the refresh token keeps the full scope
+	_, refreshTokenClaims := parseToken(t, respData["refresh_token"].(string))
+	assert.Equal(t, scope, refreshTokenClaims["scope"])
+
+	formData = url.Values{
+		"client_id":     {"test-client-1"},
+		"client_secret": {clientSecret},
+		"grant_type":    {"refresh_token"},
+		"refresh_token": {respData["refresh_token"].(string)},
+		"resource":      {"authserver"},
+	}
+	respData = postToTokenEndpoint(t, httpClient, destUrl, formData)
+
+	assert.Equal(t, "openid profile authserver:userinfo", respData["scope"])
+	_, accessTokenClaims = parseToken(t, respData["access_token"].(string))
+	assert.Equal(t, "authserver", accessTokenClaims["aud"])
+
+	// without a resource, the access token is valid for all the resources
+	formData = url.Values{
+		"client_id":     {"test-client-1"},
+		"client_secret": {clientSecret},
+		"grant_type":    {"refresh_token"},
+		"refresh_token": {respData["refresh_token"].(string)},
+	}
+	respData = postToTokenEndpoint(t, httpClient, destUrl, formData)
+
+	_, accessTokenClaims = parseToken(t, respData["access_token"].(string))
+	assert.ElementsMatch(t, []interface{}{"authserver", "backend-svcA", "backend-svcB"}, accessTokenClaims["aud"])
+}
+
+func TestResourceIndicators_InvalidTarget(t *testing.T) {
+	setup()
+
+	code, httpClient := createAuthCode(t, "openid profile backend-svcA:read-product")
+
+	clientSecret := getClientSecret(t, "test-client-1")
+	destUrl := lib.GetBaseUrl() + "/auth/token"
+
+	testCases := []struct {
+		resources           []string
+		expectedDescription string
+	}{
+		{[]string{"does-not-exist"}, "The resource 'does-not-exist' is not recognized."},
+		{[]string{"backend-svcB"}, "None of the granted scopes belong to the resource 'backend-svcB'."},
+		{[]string{"backend-svcA", "authserver"}, "Only one resource parameter is supported per token request. Please request one access token per resource."},
+	}
+
+	for _, testCase := range testCases {
+		formData := url.Values{
+			"client_id":     {"test-client-1"},
+			"client_secret": {clientSecret},
+			"grant_type":    {"authorization_code"},
+			"redirect_uri":  {code.RedirectURI},
+			"code":          {code.Code},
+			"code_verifier": {"DdazqdVNuDmRLGGRGQKKehEaoFeatACtNsM2UYGwuHkhBhDsTSzaCqWttcBc0kGx"},
+			"resource":      testCase.resources,
+		}
+		respData := postToTokenEndpoint(t, httpClient, destUrl, formData)
+
+		assert.Equal(t, "invalid_target", respData["error"])
+		assert.Equal(t, testCase.expectedDescription, respData["error_description"])
+	}
+}
+
+func TestResourceIndicators_AuthorizeEndpoint(t *testing.T) {
+	setup()
+
+	deleteAllUserConsents(t)
+
+	httpClient := createHttpClient(&createHttpClientInput{
+		T: t,
+	})
+
+	// unknown resource
+	resp, err := httpClient.Get(getAuthorizeUrl("openid backend-svcA:read-product", "&resource=does-not-exist"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	assertRedirectToClientWithError(t, resp, "invalid_target")
+
+	// a scope that doesn't belong to the requested resource
+	resp, err = httpClient.Get(getAuthorizeUrl("openid backend-svcA:read-product backend-svcB:write-info", "&resource=backend-svcA"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	assertRedirectToClientWithError(t, resp, "invalid_scope")
+
+	resp, err = httpClient.Get(getAuthorizeUrl("openid backend-svcA:read-product", "&resource=backend-svcA"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	assertRedirect(t, resp, "/auth/pwd")
+	resp = getPage(t, httpClient, lib.GetBaseUrl()+"/auth/pwd")
+	defer resp.Body.Close()
+	csrf := getCsrfValue(t, resp)
+
+	resp = authenticateWithPassword(t, httpClient, "mauro@outlook.com", "abc123", csrf)
+	defer resp.Body.Close()
+
+	assertRedirect(t, resp, "/auth/consent")
+	resp = getPage(t, httpClient, lib.GetBaseUrl()+"/auth/consent")
+	defer resp.Body.Close()
+	csrf = getCsrfValue(t, resp)
+
+	resp = postConsent(t, httpClient, []int{0, 1}, csrf)
+	defer resp.Body.Close()
+
+	assertRedirect(t, resp, "/callback.html")
+	codeVal, _ := getCodeAndStateFromUrl(t, resp)
+
+	codeHash, err := lib.HashString(codeVal)
+	if err != nil {
+		t.Fatal(err)
+	}
+	code, err := database.GetCodeByCodeHash(nil, codeHash, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "backend-svcA", code.Resource)
+
+	// the auth server was not one of the requested resources
+	formData := url.Values{
+		"client_id":     {"test-client-1"},
+		"client_secret": {getClientSecret(t, "test-client-1")},
+		"grant_type":    {"authorization_code"},
+		"redirect_uri":  {code.RedirectURI},
+		"code":          {codeVal},
+		"code_verifier": {"DdazqdVNuDmRLGGRGQKKehEaoFeatACtNsM2UYGwuHkhBhDsTSzaCqWttcBc0kGx"},
+		"resource":      {"authserver"},
+	}
+	respData := postToTokenEndpoint(t, httpClient, lib.GetBaseUrl()+"/auth/token", formData)
+	assert.Equal(t, "invalid_target", respData["error"])
+	assert.Equal(t, "The resource 'authserver' was not requested in the authorization request.", respData["error_description"])
+}
+
+func TestResourceIndicators_ClientCredentials(t *testing.T) {
+	setup()
+
+	httpClient := createHttpClient(&createHttpClientInput{
+		T: t,
+	})
+
+	formData := url.Values{
+		"client_id":     {"test-client-1"},
+		"client_secret": {getClientSecret(t, "test-client-1")},
+		"grant_type":    {"client_credentials"},
+		"resource":      {"backend-svcA"},
+	}
+	respData := postToTokenEndpoint(t, httpClient, lib.GetBaseUrl()+"/auth/token", formData)
+
+	scopes := strings.Split(respData["scope"].(string), " ")
+	assert.NotEmpty(t, scopes)
+	for _, scope := range scopes {
+		assert.True(t, strings.HasPrefix(scope, "backend-svcA:"))
+	}
+
+	_, accessTokenClaims := parseToken(t, respData["access_token"].(string))
+	assert.Equal(t, "backend-svcA", accessTokenClaims["aud"])
+}
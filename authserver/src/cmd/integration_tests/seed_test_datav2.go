@@ -302,8 +302,12 @@ func seedTestData(db data.Database) error {
 		Permissions:                             []entities.Permission{*permission1, *permission3},
 		DefaultAcrLevel:                         enums.AcrLevel2,
 		IncludeOpenIDConnectClaimsInAccessToken: enums.ThreeStateSettingDefault.String(),
+		TokenEndpointAuthMethod:                 enums.TokenEndpointAuthMethodClientSecretPost.String(),
 		AuthorizationCodeEnabled:                true,
 		ClientCredentialsEnabled:                true,
+		DeviceCodeEnabled:                       true,
+		TokenExchangeEnabled:                    true,
+		CIBAEnabled:                             true,
 	}
 	err = db.CreateClient(nil, client)
 	if err != nil {
@@ -337,8 +341,10 @@ func seedTestData(db data.Database) error {
 		RedirectURIs:                            []entities.RedirectURI{{URI: "https://goiabada-test-client:8090/callback.html"}, {URI: "https://oauthdebugger.com/debug"}},
 		DefaultAcrLevel:                         enums.AcrLevel2,
 		IncludeOpenIDConnectClaimsInAccessToken: enums.ThreeStateSettingDefault.String(),
+		TokenEndpointAuthMethod:                 enums.TokenEndpointAuthMethodClientSecretPost.String(),
 		AuthorizationCodeEnabled:                true,
 		ClientCredentialsEnabled:                false,
+		DeviceCodeEnabled:                       true,
 	}
 	err = db.CreateClient(nil, client)
 	if err != nil {
@@ -362,6 +368,7 @@ func seedTestData(db data.Database) error {
 		RedirectURIs:                            []entities.RedirectURI{{URI: "https://goiabada-test-client:8090/callback.html"}, {URI: "https://oauthdebugger.com/debug"}},
 		DefaultAcrLevel:                         enums.AcrLevel2,
 		IncludeOpenIDConnectClaimsInAccessToken: enums.ThreeStateSettingDefault.String(),
+		TokenEndpointAuthMethod:                 enums.TokenEndpointAuthMethodClientSecretPost.String(),
 		AuthorizationCodeEnabled:                true,
 		ClientCredentialsEnabled:                false,
 	}
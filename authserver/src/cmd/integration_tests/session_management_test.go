@@ -0,0 +1,128 @@
+package integrationtests
+
+import (
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/leodip/goiabada/internal/common"
+	"github.com/leodip/goiabada/internal/enums"
+	"github.com/leodip/goiabada/internal/lib"
+	"github.com/stretchr/testify/assert"
+)
+
+func getBrowserStateCookie(t *testing.T, httpClient *http.Client) string {
+	baseUrl, err := url.Parse(lib.GetBaseUrl())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, cookie := range httpClient.Jar.Cookies(baseUrl) {
+		if cookie.Name == common.BrowserStateCookieName {
+			return cookie.Value
+		}
+	}
+	return ""
+}
+
+func TestSessionManagement_SessionStateInAuthorizationResponse(t *testing.T) {
+	setup()
+
+	code, httpClient := createAuthCode(t, "openid profile email")
+
+	browserState := getBrowserStateCookie(t, httpClient)
+	expectedBrowserState, err := lib.GetBrowserState(code.SessionIdentifier)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, expectedBrowserState, browserState)
+
+	// authorize again, with the existing session and consent
+	destUrl := lib.GetBaseUrl() +
+		"/auth/authorize/?client_id=test-client-1&redirect_uri=https://goiabada-test-client:8090/callback.html&response_type=code" +
+		"&code_challenge_method=S256&code_challenge=0BnoD4e6xPCPip8rqZ9Zc2RqWOFfvryu9vzXJN4egoY" +
+		"&response_mode=query&scope=" + url.QueryEscape("openid profile email") + "&state=a1b2c3&nonce=m9n8b7" +
+		"&acr_values=" + enums.AcrLevel1.String()
+
+	resp, err := httpClient.Get(destUrl)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	assertRedirect(t, resp, "/auth/consent")
+	resp = getPage(t, httpClient, lib.GetBaseUrl()+"/auth/consent")
+	defer resp.Body.Close()
+
+	assertRedirect(t, resp, "/callback.html")
+	redirectLocation, err := url.Parse(resp.Header.Get("Location"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sessionState := redirectLocation.Query().Get("session_state")
+	parts := strings.SplitN(sessionState, ".", 2)
+	if len(parts) != 2 {
+		t.Fatalf("unexpected session_state: %v", sessionState)
+	}
+	salt := parts[1]
+	assert.NotEmpty(t, salt)
+
+	expectedHash, err := lib.HashString("test-client-1 https://goiabada-test-client:8090 " + browserState + " " + salt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, expectedHash, parts[0])
+
+	// logout removes the browser state cookie
+	resp, err = httpClient.Get(lib.GetBaseUrl() + "/auth/logout")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	csrf := getCsrfValue(t, resp)
+
+	resp, err = httpClient.PostForm(lib.GetBaseUrl()+"/auth/logout", url.Values{
+		"gorilla.csrf.Token": {csrf},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	assert.Empty(t, getBrowserStateCookie(t, httpClient))
+}
+
+func TestSessionManagement_CheckSessionIframe(t *testing.T) {
+	setup()
+
+	httpClient := createHttpClient(&createHttpClientInput{
+		T: t,
+	})
+
+	resp, err := httpClient.Get(lib.GetBaseUrl() + "/auth/check-session-iframe")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Contains(t, resp.Header.Get("Content-Type"), "text/html")
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Contains(t, string(body), `"`+common.BrowserStateCookieName+`"`)
+	assert.Contains(t, string(body), "postMessage")
+
+	resp, err = httpClient.Get(lib.GetBaseUrl() + "/.well-known/openid-configuration")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	config := unmarshalToMap(t, resp)
+	assert.Equal(t, lib.GetBaseUrl()+"/auth/check-session-iframe", config["check_session_iframe"])
+}
@@ -0,0 +1,363 @@
+package integrationtests
+
+import (
+	"database/sql"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/leodip/goiabada/internal/entities"
+	"github.com/leodip/goiabada/internal/enums"
+	"github.com/leodip/goiabada/internal/lib"
+	"github.com/stretchr/testify/assert"
+)
+
+// createSigningKeys creates the current and next keys of the algorithm, unless it already
+// has keys, and returns a function to delete the keys it created
+func createSigningKeys(t *testing.T, algorithm string) func() {
+	allSigningKeys, err := database.GetAllSigningKeys(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, signingKey := range allSigningKeys {
+		if signingKey.Algorithm == algorithm {
+			return func() {}
+		}
+	}
+
+	createdKeys := []*entities.KeyPair{}
+	for _, keyState := range []enums.KeyState{enums.KeyStateCurrent, enums.KeyStateNext} {
+		kid := uuid.New().String()
+		signingKeyPair, err := lib.GenerateSigningKeyPair(algorithm, kid)
+		if err != nil {
+			t.Fatal(err)
+		}
+		keyPair := &entities.KeyPair{
+			State:             keyState.String(),
+			KeyIdentifier:     kid,
+			Type:              signingKeyPair.Type,
+			Algorithm:         algorithm,
+			PrivateKeyPEM:     signingKeyPair.PrivateKeyPEM,
+			PublicKeyPEM:      signingKeyPair.PublicKeyPEM,
+			PublicKeyASN1_DER: signingKeyPair.PublicKeyASN1_DER,
+			PublicKeyJWK:      signingKeyPair.PublicKeyJWK,
+		}
+		err = database.CreateKeyPair(nil, keyPair)
+		if err != nil {
+			t.Fatal(err)
+		}
+		createdKeys = append(createdKeys, keyPair)
+	}
+
+	return func() {
+		for _, keyPair := range createdKeys {
+			err := database.DeleteKeyPair(nil, keyPair.Id)
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+}
+
+func getCerts(t *testing.T, httpClient *http.Client) *lib.JSONWebKeySet {
+	resp, err := httpClient.Get(lib.GetBaseUrl() + "/certs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var jwks lib.JSONWebKeySet
+	err = json.NewDecoder(resp.Body).Decode(&jwks)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &jwks
+}
+
+// verifyWithCerts verifies the signature of the token with the key published at /certs
+func verifyWithCerts(t *testing.T, jwks *lib.JSONWebKeySet, tokenStr string) *jwt.Token {
+	token, err := jwt.Parse(tokenStr, func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		key := jwks.FindKey(kid)
+		if key == nil {
+			t.Fatalf("key %v not found in /certs", kid)
+		}
+		assert.Equal(t, key.Alg, token.Method.Alg())
+		return key.PublicKey()
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.True(t, token.Valid)
+	return token
+}
+
+func TestSigningKeys_IdTokenAlgorithms(t *testing.T) {
+	setup()
+
+	testCases := []struct {
+		algorithm string
+		kty       string
+		crv       string
+	}{
+		{"ES256", "EC", "P-256"},
+		{"ES384", "EC", "P-384"},
+		{"EdDSA", "OKP", "Ed25519"},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.algorithm, func(t *testing.T) {
+			deleteKeys := createSigningKeys(t, testCase.algorithm)
+			defer deleteKeys()
+
+			restore := updateClient(t, "test-client-1", func(client *entities.Client) {
+				client.IdTokenSignedResponseAlg = testCase.algorithm
+			})
+			defer restore()
+
+			respData, httpClient := getTokensWithAuthCode(t, "openid profile backend-svcA:read-product")
+			jwks := getCerts(t, httpClient)
+
+			idToken := verifyWithCerts(t, jwks, respData["id_token"].(string))
+			assert.Equal(t, testCase.algorithm, idToken.Method.Alg())
+			key := jwks.FindKey(idToken.Header["kid"].(string))
+			assert.Equal(t, testCase.kty, key.Kty)
+			assert.Equal(t, testCase.crv, key.Crv)
+
+			// the other tokens are signed with the default algorithm
+			accessToken := verifyWithCerts(t, jwks, respData["access_token"].(string))
+			assert.Equal(t, "RS256", accessToken.Method.Alg())
+			refreshToken := verifyWithCerts(t, jwks, respData["refresh_token"].(string))
+			assert.Equal(t, "RS256", refreshToken.Method.Alg())
+
+			// the id token is accepted as a hint
+			resp, err := httpClient.Get(getAuthorizeUrl("openid profile", "&prompt=none&id_token_hint="+url.QueryEscape(respData["id_token"].(string))))
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer resp.Body.Close()
+			assertRedirect(t, resp, "/auth/consent")
+
+			// refresh
+			formData := url.Values{
+				"client_id":     {"test-client-1"},
+				"client_secret": {getClientSecret(t, "test-client-1")},
+				"grant_type":    {"refresh_token"},
+				"refresh_token": {respData["refresh_token"].(string)},
+			}
+			respData = postToTokenEndpoint(t, httpClient, lib.GetBaseUrl()+"/auth/token", formData)
+			idToken = verifyWithCerts(t, jwks, respData["id_token"].(string))
+			assert.Equal(t, testCase.algorithm, idToken.Method.Alg())
+		})
+	}
+}
+
+func TestSigningKeys_SignedUserInfo(t *testing.T) {
+	setup()
+
+	for _, algorithm := range []string{"RS256", "ES256", "EdDSA"} {
+		t.Run(algorithm, func(t *testing.T) {
+			deleteKeys := createSigningKeys(t, algorithm)
+			defer deleteKeys()
+
+			restore := updateClient(t, "test-client-1", func(client *entities.Client) {
+				client.UserInfoSignedResponseAlg = algorithm
+			})
+			defer restore()
+
+			respData, httpClient := getTokensWithAuthCode(t, "openid profile email backend-svcA:read-product")
+			_, idTokenClaims := parseToken(t, respData["id_token"].(string))
+
+			resp := requestUserInfo(t, httpClient, respData["access_token"].(string))
+			defer resp.Body.Close()
+			assert.Equal(t, http.StatusOK, resp.StatusCode)
+			assert.Equal(t, "application/jwt", resp.Header.Get("Content-Type"))
+
+			body, err := io.ReadAll(resp.Body)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			token := verifyWithCerts(t, getCerts(t, httpClient), string(body))
+			assert.Equal(t, algorithm, token.Method.Alg())
+
+			claims := token.Claims.(jwt.MapClaims)
+			assert.Equal(t, idTokenClaims["sub"], claims["sub"])
+			assert.Equal(t, idTokenClaims["iss"], claims["iss"])
+			assert.Equal(t, "test-client-1", claims["aud"])
+			assert.Equal(t, "mauro@outlook.com", claims["email"])
+		})
+	}
+}
+
+func TestSigningKeys_SignedAndEncryptedUserInfo(t *testing.T) {
+	setup()
+
+	deleteKeys := createSigningKeys(t, "ES384")
+	defer deleteKeys()
+
+	restore := updateClient(t, "test-client-1", func(client *entities.Client) {
+		client.UserInfoSignedResponseAlg = "ES384"
+	})
+	defer restore()
+
+	privateKey, jwks := createEncryptionKey(t, "ECDH-ES")
+	restoreEncryption := updateClient(t, "test-client-1", func(client *entities.Client) {
+		client.JWKS = jwks
+		client.UserInfoEncryptedResponseAlg = "ECDH-ES"
+		client.UserInfoEncryptedResponseEnc = "A128GCM"
+	})
+	defer restoreEncryption()
+
+	respData, httpClient := getTokensWithAuthCode(t, "openid profile email backend-svcA:read-product")
+
+	resp := requestUserInfo(t, httpClient, respData["access_token"].(string))
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "application/jwt", resp.Header.Get("Content-Type"))
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the nested JWT is signed with the userinfo signing algorithm
+	plaintext, _ := decryptJWE(t, string(body), privateKey)
+	token := verifyWithCerts(t, getCerts(t, httpClient), string(plaintext))
+	assert.Equal(t, "ES384", token.Method.Alg())
+	assert.Equal(t, "mauro@outlook.com", token.Claims.(jwt.MapClaims)["email"])
+}
+
+func TestSigningKeys_SignedUserInfoWithLegacyAccessTokenFormat(t *testing.T) {
+	setup()
+
+	// the legacy access tokens also have the client_id claim, so the response is still signed
+	restore := updateClient(t, "test-client-1", func(client *entities.Client) {
+		client.LegacyAccessTokenFormat = true
+		client.UserInfoSignedResponseAlg = "RS256"
+	})
+	defer restore()
+
+	respData, httpClient := getTokensWithAuthCode(t, "openid profile email backend-svcA:read-product")
+
+	resp := requestUserInfo(t, httpClient, respData["access_token"].(string))
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "application/jwt", resp.Header.Get("Content-Type"))
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	token := verifyWithCerts(t, getCerts(t, httpClient), string(body))
+	assert.Equal(t, "test-client-1", token.Claims.(jwt.MapClaims)["aud"])
+	assert.Equal(t, "mauro@outlook.com", token.Claims.(jwt.MapClaims)["email"])
+}
+
+func TestSigningKeys_UserInfoNotSigned(t *testing.T) {
+	setup()
+
+	respData, httpClient := getTokensWithAuthCode(t, "openid profile email backend-svcA:read-product")
+
+	resp := requestUserInfo(t, httpClient, respData["access_token"].(string))
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "application/json", resp.Header.Get("Content-Type"))
+
+	data := unmarshalToMap(t, resp)
+	assert.Equal(t, "mauro@outlook.com", data["email"])
+	assert.Nil(t, data["aud"])
+}
+
+func TestSigningKeys_CertsAndDiscovery(t *testing.T) {
+	setup()
+
+	deleteKeys := createSigningKeys(t, "ES256")
+	defer deleteKeys()
+
+	httpClient := createHttpClient(&createHttpClientInput{
+		T: t,
+	})
+
+	jwks := getCerts(t, httpClient)
+	allSigningKeys, err := database.GetAllSigningKeys(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, len(allSigningKeys), len(jwks.Keys))
+	for _, signingKey := range allSigningKeys {
+		key := jwks.FindKey(signingKey.KeyIdentifier)
+		if assert.NotNil(t, key) {
+			assert.Equal(t, signingKey.Algorithm, key.Alg)
+			assert.Equal(t, signingKey.Type, key.Kty)
+			assert.Equal(t, "sig", key.Use)
+		}
+	}
+
+	resp, err := httpClient.Get(lib.GetBaseUrl() + "/.well-known/openid-configuration")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	config := unmarshalToMap(t, resp)
+	assert.Contains(t, config["id_token_signing_alg_values_supported"], "RS256")
+	assert.Contains(t, config["id_token_signing_alg_values_supported"], "ES256")
+	assert.Contains(t, config["userinfo_signing_alg_values_supported"], "RS256")
+	assert.Contains(t, config["userinfo_signing_alg_values_supported"], "ES256")
+}
+
+func TestSigningKeys_ClientRegistration(t *testing.T) {
+	setup()
+
+	deleteKeys := createSigningKeys(t, "ES256")
+	defer deleteKeys()
+
+	initialAccessToken, deleteInitialAccessToken := createInitialAccessToken(t, sql.NullTime{})
+	defer deleteInitialAccessToken()
+
+	httpClient := createHttpClient(&createHttpClientInput{
+		T: t,
+	})
+
+	resp, data := sendClientRegistrationRequest(t, httpClient, "POST", lib.GetBaseUrl()+"/connect/register", initialAccessToken, map[string]interface{}{
+		"redirect_uris":                []string{"https://app1.example.com/callback"},
+		"id_token_signed_response_alg": "ES256",
+		"userinfo_signed_response_alg": "ES256",
+	})
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+	assert.Equal(t, "ES256", data["id_token_signed_response_alg"])
+	assert.Equal(t, "ES256", data["userinfo_signed_response_alg"])
+
+	client, err := database.GetClientByClientIdentifier(nil, data["client_id"].(string))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "ES256", client.IdTokenSignedResponseAlg)
+	assert.Equal(t, "ES256", client.UserInfoSignedResponseAlg)
+	err = database.DeleteClient(nil, client.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp, data = sendClientRegistrationRequest(t, httpClient, "POST", lib.GetBaseUrl()+"/connect/register", initialAccessToken, map[string]interface{}{
+		"redirect_uris":                []string{"https://app1.example.com/callback"},
+		"id_token_signed_response_alg": "HS256",
+	})
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	assert.Equal(t, "invalid_client_metadata", data["error"])
+	assert.Contains(t, data["error_description"], "Unsupported id_token_signed_response_alg 'HS256'.")
+
+	resp, data = sendClientRegistrationRequest(t, httpClient, "POST", lib.GetBaseUrl()+"/connect/register", initialAccessToken, map[string]interface{}{
+		"redirect_uris":                []string{"https://app1.example.com/callback"},
+		"userinfo_signed_response_alg": "none",
+	})
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	assert.Equal(t, "invalid_client_metadata", data["error"])
+	assert.Contains(t, data["error_description"], "Unsupported userinfo_signed_response_alg 'none'.")
+}
@@ -0,0 +1,387 @@
+package integrationtests
+
+import (
+	"context"
+	"crypto/x509/pkix"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	core_token "github.com/leodip/goiabada/internal/core/token"
+	"github.com/leodip/goiabada/internal/enums"
+	"github.com/leodip/goiabada/internal/lib"
+	"github.com/stretchr/testify/assert"
+)
+
+const tokenExchangeGrantType = "urn:ietf:params:oauth:grant-type:token-exchange"
+const accessTokenType = "urn:ietf:params:oauth:token-type:access_token"
+
+// createSubjectToken signs an access token for the user, as if it was issued by the auth server
+func createSubjectToken(t *testing.T, email string, scope string, extraClaims map[string]interface{}) string {
+	user, err := database.GetUserByEmail(nil, email)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	settings, err := database.GetSettingsById(nil, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	keyPair, err := database.GetCurrentSigningKey(nil, "RS256")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	privKey, err := jwt.ParseRSAPrivateKeyFromPEM(keyPair.PrivateKeyPEM)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Now().UTC()
+	claims := jwt.MapClaims{
+		"iss":       settings.Issuer,
+		"sub":       user.Subject.String(),
+		"iat":       now.Unix(),
+		"auth_time": now.Unix(),
+		"jti":       uuid.New().String(),
+		"acr":       enums.AcrLevel1.String(),
+		"amr":       enums.AuthMethodPassword.String(),
+		"sid":       uuid.New().String(),
+		"aud":       "backend-svcA",
+		"typ":       enums.TokenTypeBearer.String(),
+		"exp":       now.Add(5 * time.Minute).Unix(),
+		"scope":     scope,
+	}
+	for k, v := range extraClaims {
+		claims[k] = v
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = keyPair.KeyIdentifier
+	tokenStr, err := token.SignedString(privKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tokenStr
+}
+
+func TestTokenExchange_FlowNotEnabled(t *testing.T) {
+	setup()
+
+	client, err := database.GetClientByClientIdentifier(nil, "test-client-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	client.TokenExchangeEnabled = false
+	err = database.UpdateClient(nil, client)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		client.TokenExchangeEnabled = true
+		err = database.UpdateClient(nil, client)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	destUrl := lib.GetBaseUrl() + "/auth/token"
+
+	httpClient := createHttpClient(&createHttpClientInput{
+		T: t,
+	})
+
+	formData := url.Values{
+		"grant_type":    {tokenExchangeGrantType},
+		"client_id":     {"test-client-1"},
+		"client_secret": {getClientSecret(t, "test-client-1")},
+	}
+	data := postToTokenEndpoint(t, httpClient, destUrl, formData)
+
+	assert.Equal(t, "unauthorized_client", data["error"])
+	assert.Equal(t, "The client associated with the provided client_id does not support token exchange.", data["error_description"])
+}
+
+func TestTokenExchange_MissingSubjectToken(t *testing.T) {
+	setup()
+
+	destUrl := lib.GetBaseUrl() + "/auth/token"
+
+	httpClient := createHttpClient(&createHttpClientInput{
+		T: t,
+	})
+
+	formData := url.Values{
+		"grant_type":         {tokenExchangeGrantType},
+		"client_id":          {"test-client-1"},
+		"client_secret":      {getClientSecret(t, "test-client-1")},
+		"subject_token_type": {accessTokenType},
+	}
+	data := postToTokenEndpoint(t, httpClient, destUrl, formData)
+
+	assert.Equal(t, "invalid_request", data["error"])
+	assert.Equal(t, "Missing required subject_token parameter.", data["error_description"])
+}
+
+func TestTokenExchange_UnsupportedSubjectTokenType(t *testing.T) {
+	setup()
+
+	destUrl := lib.GetBaseUrl() + "/auth/token"
+
+	httpClient := createHttpClient(&createHttpClientInput{
+		T: t,
+	})
+
+	formData := url.Values{
+		"grant_type":         {tokenExchangeGrantType},
+		"client_id":          {"test-client-1"},
+		"client_secret":      {getClientSecret(t, "test-client-1")},
+		"subject_token":      {"abc"},
+		"subject_token_type": {"urn:ietf:params:oauth:token-type:refresh_token"},
+	}
+	data := postToTokenEndpoint(t, httpClient, destUrl, formData)
+
+	assert.Equal(t, "invalid_request", data["error"])
+	assert.Equal(t, "Unsupported subject_token_type. Only access tokens (urn:ietf:params:oauth:token-type:access_token) can be exchanged.", data["error_description"])
+}
+
+func TestTokenExchange_InvalidSubjectToken(t *testing.T) {
+	setup()
+
+	destUrl := lib.GetBaseUrl() + "/auth/token"
+
+	httpClient := createHttpClient(&createHttpClientInput{
+		T: t,
+	})
+
+	formData := url.Values{
+		"grant_type":         {tokenExchangeGrantType},
+		"client_id":          {"test-client-1"},
+		"client_secret":      {getClientSecret(t, "test-client-1")},
+		"subject_token":      {"abc"},
+		"subject_token_type": {accessTokenType},
+	}
+	data := postToTokenEndpoint(t, httpClient, destUrl, formData)
+
+	assert.Equal(t, "invalid_grant", data["error"])
+}
+
+func TestTokenExchange_ScopeNotGrantedToClient(t *testing.T) {
+	setup()
+
+	destUrl := lib.GetBaseUrl() + "/auth/token"
+
+	httpClient := createHttpClient(&createHttpClientInput{
+		T: t,
+	})
+
+	subjectToken := createSubjectToken(t, "viviane@gmail.com", "backend-svcA:create-product backend-svcA:read-product", nil)
+
+	formData := url.Values{
+		"grant_type":         {tokenExchangeGrantType},
+		"client_id":          {"test-client-1"},
+		"client_secret":      {getClientSecret(t, "test-client-1")},
+		"subject_token":      {subjectToken},
+		"subject_token_type": {accessTokenType},
+		"scope":              {"backend-svcA:read-product"},
+	}
+	data := postToTokenEndpoint(t, httpClient, destUrl, formData)
+
+	assert.Equal(t, "invalid_scope", data["error"])
+	assert.Equal(t, "Permission to access scope 'backend-svcA:read-product' is not granted to the client.", data["error_description"])
+}
+
+func TestTokenExchange_ScopeNotHeldByUser(t *testing.T) {
+	setup()
+
+	destUrl := lib.GetBaseUrl() + "/auth/token"
+
+	httpClient := createHttpClient(&createHttpClientInput{
+		T: t,
+	})
+
+	// mauro does not have the create-product permission
+	subjectToken := createSubjectToken(t, "mauro@outlook.com", "backend-svcA:create-product", nil)
+
+	formData := url.Values{
+		"grant_type":         {tokenExchangeGrantType},
+		"client_id":          {"test-client-1"},
+		"client_secret":      {getClientSecret(t, "test-client-1")},
+		"subject_token":      {subjectToken},
+		"subject_token_type": {accessTokenType},
+		"scope":              {"backend-svcA:create-product"},
+	}
+	data := postToTokenEndpoint(t, httpClient, destUrl, formData)
+
+	assert.Equal(t, "invalid_scope", data["error"])
+	assert.Equal(t, "Scope 'backend-svcA:create-product' is not recognized. The user does not have the 'backend-svcA:create-product' permission.", data["error_description"])
+}
+
+func TestTokenExchange_ScopeNotInSubjectToken(t *testing.T) {
+	setup()
+
+	destUrl := lib.GetBaseUrl() + "/auth/token"
+
+	httpClient := createHttpClient(&createHttpClientInput{
+		T: t,
+	})
+
+	subjectToken := createSubjectToken(t, "viviane@gmail.com", "backend-svcA:read-product", nil)
+
+	formData := url.Values{
+		"grant_type":         {tokenExchangeGrantType},
+		"client_id":          {"test-client-1"},
+		"client_secret":      {getClientSecret(t, "test-client-1")},
+		"subject_token":      {subjectToken},
+		"subject_token_type": {accessTokenType},
+		"scope":              {"backend-svcA:create-product"},
+	}
+	data := postToTokenEndpoint(t, httpClient, destUrl, formData)
+
+	assert.Equal(t, "invalid_scope", data["error"])
+	assert.Equal(t, "Scope 'backend-svcA:create-product' is not recognized. The subject token does not grant the 'backend-svcA:create-product' permission.", data["error_description"])
+}
+
+func TestTokenExchange_Success(t *testing.T) {
+	setup()
+
+	destUrl := lib.GetBaseUrl() + "/auth/token"
+
+	httpClient := createHttpClient(&createHttpClientInput{
+		T: t,
+	})
+
+	subjectToken := createSubjectToken(t, "viviane@gmail.com",
+		"openid backend-svcA:create-product backend-svcA:read-product authserver:userinfo",
+		map[string]interface{}{
+			"act": map[string]interface{}{"sub": "api-gateway"},
+		})
+
+	// no scope: narrowed down to what both the user and the client hold
+	formData := url.Values{
+		"grant_type":         {tokenExchangeGrantType},
+		"client_id":          {"test-client-1"},
+		"client_secret":      {getClientSecret(t, "test-client-1")},
+		"subject_token":      {subjectToken},
+		"subject_token_type": {accessTokenType},
+	}
+	data := postToTokenEndpoint(t, httpClient, destUrl, formData)
+
+	assert.Equal(t, "Bearer", data["token_type"])
+	assert.Equal(t, accessTokenType, data["issued_token_type"])
+	assert.Equal(t, "backend-svcA:create-product", data["scope"])
+	assert.Nil(t, data["refresh_token"])
+	assert.Nil(t, data["id_token"])
+
+	tokenParser := core_token.NewTokenParser(database)
+	accessToken, err := tokenParser.ParseToken(context.Background(), data["access_token"].(string), true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	user, err := database.GetUserByEmail(nil, "viviane@gmail.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, user.Subject.String(), accessToken.GetStringClaim("sub"))
+	assert.Equal(t, "backend-svcA", accessToken.GetStringClaim("aud"))
+	assert.Equal(t, "backend-svcA:create-product", accessToken.GetStringClaim("scope"))
+
+	act := accessToken.Claims["act"].(map[string]interface{})
+	assert.Equal(t, "test-client-1", act["sub"])
+	assert.Equal(t, map[string]interface{}{"sub": "api-gateway"}, act["act"])
+}
+
+func TestTokenExchange_DPoPBoundSubjectToken(t *testing.T) {
+	setup()
+
+	privKey, err := lib.GeneratePrivateKey(2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherPrivKey, err := lib.GeneratePrivateKey(2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	httpClient := createHttpClient(&createHttpClientInput{
+		T: t,
+	})
+
+	thumbprint := getDPoPKeyThumbprint(t, privKey)
+	subjectToken := createSubjectToken(t, "viviane@gmail.com", "backend-svcA:create-product",
+		map[string]interface{}{
+			"cnf": map[string]interface{}{"jkt": thumbprint},
+		})
+
+	formData := url.Values{
+		"grant_type":         {tokenExchangeGrantType},
+		"client_id":          {"test-client-1"},
+		"client_secret":      {getClientSecret(t, "test-client-1")},
+		"subject_token":      {subjectToken},
+		"subject_token_type": {accessTokenType},
+	}
+
+	// without a DPoP proof
+	data := postToTokenEndpoint(t, httpClient, lib.GetBaseUrl()+"/auth/token", formData)
+	assert.Equal(t, "invalid_grant", data["error"])
+	assert.Equal(t, "The subject token is bound to a DPoP key. Please provide a DPoP proof signed with the same key.", data["error_description"])
+
+	// with a DPoP proof signed with another key
+	proof := createDPoPProof(t, otherPrivKey, "POST", lib.GetBaseUrl()+"/auth/token", "")
+	data = postToTokenEndpointWithDPoP(t, httpClient, formData, proof)
+	assert.Equal(t, "invalid_grant", data["error"])
+	assert.Equal(t, "The subject token is bound to a DPoP key. Please provide a DPoP proof signed with the same key.", data["error_description"])
+
+	// with a DPoP proof signed with the same key, the new token keeps the binding
+	proof = createDPoPProof(t, privKey, "POST", lib.GetBaseUrl()+"/auth/token", "")
+	data = postToTokenEndpointWithDPoP(t, httpClient, formData, proof)
+	assert.Equal(t, "DPoP", data["token_type"])
+	assert.Equal(t, thumbprint, getConfirmationJkt(t, data["access_token"].(string)))
+}
+
+func TestTokenExchange_CertificateBoundSubjectToken(t *testing.T) {
+	setup()
+
+	httpClient := createHttpClient(&createHttpClientInput{
+		T: t,
+	})
+
+	certificate := createCertificate(t, pkix.Name{CommonName: "test-client-1"}, false, nil)
+	otherCertificate := createCertificate(t, pkix.Name{CommonName: "test-client-1"}, false, nil)
+
+	thumbprint := lib.GetCertificateThumbprint(certificate.Cert)
+	subjectToken := createSubjectToken(t, "viviane@gmail.com", "backend-svcA:create-product",
+		map[string]interface{}{
+			"cnf": map[string]interface{}{"x5t#S256": thumbprint},
+		})
+
+	formData := url.Values{
+		"grant_type":         {tokenExchangeGrantType},
+		"client_id":          {"test-client-1"},
+		"client_secret":      {getClientSecret(t, "test-client-1")},
+		"subject_token":      {subjectToken},
+		"subject_token_type": {accessTokenType},
+	}
+
+	// without a client certificate
+	data := postToTokenEndpoint(t, httpClient, lib.GetBaseUrl()+"/auth/token", formData)
+	assert.Equal(t, "invalid_grant", data["error"])
+	assert.Equal(t, "The subject token is bound to a client certificate. Please present the same certificate in the TLS connection.", data["error_description"])
+
+	skipIfMTLSNotConfigured(t)
+
+	// with another client certificate
+	data = postToTokenEndpointWithClientCertificate(t, httpClient, formData, otherCertificate)
+	assert.Equal(t, "invalid_grant", data["error"])
+	assert.Equal(t, "The subject token is bound to a client certificate. Please present the same certificate in the TLS connection.", data["error_description"])
+
+	// with the same client certificate, the new token keeps the binding
+	data = postToTokenEndpointWithClientCertificate(t, httpClient, formData, certificate)
+	assert.NotEmpty(t, data["access_token"])
+	assert.Equal(t, thumbprint, getConfirmationX5t(t, data["access_token"].(string)))
+}
@@ -0,0 +1,216 @@
+package integrationtests
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/leodip/goiabada/internal/entities"
+	"github.com/leodip/goiabada/internal/lib"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTokenIntrospect_MissingClientId(t *testing.T) {
+	setup()
+
+	destUrl := lib.GetBaseUrl() + "/auth/introspect"
+
+	httpClient := createHttpClient(&createHttpClientInput{
+		T: t,
+	})
+
+	formData := url.Values{}
+	data := postToTokenEndpoint(t, httpClient, destUrl, formData)
+
+	assert.Equal(t, "invalid_request", data["error"])
+	assert.Equal(t, "Missing required client_id parameter.", data["error_description"])
+}
+
+func TestTokenIntrospect_ClientAuthFailed(t *testing.T) {
+	setup()
+
+	destUrl := lib.GetBaseUrl() + "/auth/introspect"
+
+	httpClient := createHttpClient(&createHttpClientInput{
+		T: t,
+	})
+
+	formData := url.Values{
+		"client_id":     {"test-client-1"},
+		"client_secret": {"invalid"},
+		"token":         {"abc"},
+	}
+	data := postToTokenEndpoint(t, httpClient, destUrl, formData)
+
+	assert.Equal(t, "invalid_client", data["error"])
+	assert.Equal(t, "Client authentication failed.", data["error_description"])
+}
+
+func TestTokenIntrospect_PublicClient(t *testing.T) {
+	setup()
+
+	destUrl := lib.GetBaseUrl() + "/auth/introspect"
+
+	httpClient := createHttpClient(&createHttpClientInput{
+		T: t,
+	})
+
+	formData := url.Values{
+		"client_id": {"test-client-2"},
+		"token":     {"abc"},
+	}
+	data := postToTokenEndpoint(t, httpClient, destUrl, formData)
+
+	assert.Equal(t, "unauthorized_client", data["error"])
+	assert.Equal(t, "A public client is not eligible for token introspection. Please review the client configuration.", data["error_description"])
+}
+
+func TestTokenIntrospect_MissingToken(t *testing.T) {
+	setup()
+
+	destUrl := lib.GetBaseUrl() + "/auth/introspect"
+
+	httpClient := createHttpClient(&createHttpClientInput{
+		T: t,
+	})
+
+	clientSecret := getClientSecret(t, "test-client-1")
+	formData := url.Values{
+		"client_id":     {"test-client-1"},
+		"client_secret": {clientSecret},
+	}
+	data := postToTokenEndpoint(t, httpClient, destUrl, formData)
+
+	assert.Equal(t, "invalid_request", data["error"])
+	assert.Equal(t, "Missing required token parameter.", data["error_description"])
+}
+
+func TestTokenIntrospect_InvalidToken(t *testing.T) {
+	setup()
+
+	destUrl := lib.GetBaseUrl() + "/auth/introspect"
+
+	httpClient := createHttpClient(&createHttpClientInput{
+		T: t,
+	})
+
+	clientSecret := getClientSecret(t, "test-client-1")
+	formData := url.Values{
+		"client_id":     {"test-client-1"},
+		"client_secret": {clientSecret},
+		"token":         {"invalid"},
+	}
+	data := postToTokenEndpoint(t, httpClient, destUrl, formData)
+
+	assert.Equal(t, false, data["active"])
+	assert.Equal(t, 1, len(data))
+}
+
+func TestTokenIntrospect_AccessToken(t *testing.T) {
+	setup()
+
+	destUrl := lib.GetBaseUrl() + "/auth/token"
+
+	httpClient := createHttpClient(&createHttpClientInput{
+		T: t,
+	})
+
+	clientSecret := getClientSecret(t, "test-client-1")
+	formData := url.Values{
+		"grant_type":    {"client_credentials"},
+		"client_id":     {"test-client-1"},
+		"client_secret": {clientSecret},
+		"scope":         {"backend-svcA:create-product"},
+	}
+	respData := postToTokenEndpoint(t, httpClient, destUrl, formData)
+	assert.NotEmpty(t, respData["access_token"])
+
+	destUrl = lib.GetBaseUrl() + "/auth/introspect"
+	formData = url.Values{
+		"client_id":       {"test-client-1"},
+		"client_secret":   {clientSecret},
+		"token":           {respData["access_token"].(string)},
+		"token_type_hint": {"access_token"},
+	}
+	data := postToTokenEndpoint(t, httpClient, destUrl, formData)
+
+	assert.Equal(t, true, data["active"])
+	assert.Equal(t, "backend-svcA:create-product", data["scope"])
+	assert.Equal(t, "test-client-1", data["client_id"])
+	assert.Equal(t, "test-client-1", data["sub"])
+	assert.Equal(t, "Bearer", data["token_type"])
+	assert.NotEmpty(t, data["exp"])
+	assert.NotEmpty(t, data["iat"])
+
+	// the access tokens in the legacy format also identify the client
+	restore := updateClient(t, "test-client-1", func(client *entities.Client) {
+		client.LegacyAccessTokenFormat = true
+	})
+	defer restore()
+
+	formData = url.Values{
+		"grant_type":    {"client_credentials"},
+		"client_id":     {"test-client-1"},
+		"client_secret": {clientSecret},
+		"scope":         {"backend-svcA:create-product"},
+	}
+	respData = postToTokenEndpoint(t, httpClient, lib.GetBaseUrl()+"/auth/token", formData)
+
+	formData = url.Values{
+		"client_id":     {"test-client-1"},
+		"client_secret": {clientSecret},
+		"token":         {respData["access_token"].(string)},
+	}
+	data = postToTokenEndpoint(t, httpClient, destUrl, formData)
+	assert.Equal(t, true, data["active"])
+	assert.Equal(t, "test-client-1", data["client_id"])
+}
+
+func TestTokenIntrospect_RefreshToken(t *testing.T) {
+	setup()
+	scope := "openid profile email backend-svcA:read-product"
+	code, httpClient := createAuthCode(t, scope)
+
+	destUrl := lib.GetBaseUrl() + "/auth/token"
+
+	clientSecret := getClientSecret(t, "test-client-1")
+
+	formData := url.Values{
+		"client_id":     {"test-client-1"},
+		"client_secret": {clientSecret},
+		"grant_type":    {"authorization_code"},
+		"redirect_uri":  {code.RedirectURI},
+		"code":          {code.Code},
+		"code_verifier": {"DdazqdVNuDmRLGGRGQKKehEaoFeatACtNsM2UYGwuHkhBhDsTSzaCqWttcBc0kGx"},
+	}
+	respData := postToTokenEndpoint(t, httpClient, destUrl, formData)
+	assert.NotEmpty(t, respData["refresh_token"])
+
+	introspectUrl := lib.GetBaseUrl() + "/auth/introspect"
+	introspectFormData := url.Values{
+		"client_id":       {"test-client-1"},
+		"client_secret":   {clientSecret},
+		"token":           {respData["refresh_token"].(string)},
+		"token_type_hint": {"refresh_token"},
+	}
+	data := postToTokenEndpoint(t, httpClient, introspectUrl, introspectFormData)
+
+	assert.Equal(t, true, data["active"])
+	assert.Equal(t, scope+" authserver:userinfo", data["scope"])
+	assert.Equal(t, "test-client-1", data["client_id"])
+	assert.Equal(t, code.User.Subject.String(), data["sub"])
+	assert.Equal(t, code.SessionIdentifier, data["sid"])
+	assert.Equal(t, "Refresh", data["token_type"])
+
+	// using the refresh token revokes it
+	formData = url.Values{
+		"client_id":     {"test-client-1"},
+		"client_secret": {clientSecret},
+		"grant_type":    {"refresh_token"},
+		"refresh_token": {respData["refresh_token"].(string)},
+	}
+	_ = postToTokenEndpoint(t, httpClient, destUrl, formData)
+
+	data = postToTokenEndpoint(t, httpClient, introspectUrl, introspectFormData)
+	assert.Equal(t, false, data["active"])
+	assert.Equal(t, 1, len(data))
+}
@@ -0,0 +1,255 @@
+package integrationtests
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+
+	core_token "github.com/leodip/goiabada/internal/core/token"
+	"github.com/leodip/goiabada/internal/lib"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTokenRevoke_MissingClientId(t *testing.T) {
+	setup()
+
+	destUrl := lib.GetBaseUrl() + "/auth/revoke"
+
+	httpClient := createHttpClient(&createHttpClientInput{
+		T: t,
+	})
+
+	formData := url.Values{}
+	data := postToTokenEndpoint(t, httpClient, destUrl, formData)
+
+	assert.Equal(t, "invalid_request", data["error"])
+	assert.Equal(t, "Missing required client_id parameter.", data["error_description"])
+}
+
+func TestTokenRevoke_ClientAuthFailed(t *testing.T) {
+	setup()
+
+	destUrl := lib.GetBaseUrl() + "/auth/revoke"
+
+	httpClient := createHttpClient(&createHttpClientInput{
+		T: t,
+	})
+
+	formData := url.Values{
+		"client_id":     {"test-client-1"},
+		"client_secret": {"invalid"},
+		"token":         {"abc"},
+	}
+	data := postToTokenEndpoint(t, httpClient, destUrl, formData)
+
+	assert.Equal(t, "invalid_client", data["error"])
+	assert.Equal(t, "Client authentication failed.", data["error_description"])
+}
+
+func TestTokenRevoke_AccessToken(t *testing.T) {
+	setup()
+	scope := "openid profile email backend-svcA:read-product"
+	code, httpClient := createAuthCode(t, scope)
+
+	clientSecret := getClientSecret(t, "test-client-1")
+
+	formData := url.Values{
+		"client_id":     {"test-client-1"},
+		"client_secret": {clientSecret},
+		"grant_type":    {"authorization_code"},
+		"redirect_uri":  {code.RedirectURI},
+		"code":          {code.Code},
+		"code_verifier": {"DdazqdVNuDmRLGGRGQKKehEaoFeatACtNsM2UYGwuHkhBhDsTSzaCqWttcBc0kGx"},
+	}
+	respData := postToTokenEndpoint(t, httpClient, lib.GetBaseUrl()+"/auth/token", formData)
+	assert.NotEmpty(t, respData["access_token"])
+
+	// the hint does not matter, the token type is determined from the token itself
+	for _, hint := range []string{"", "access_token", "refresh_token", "unknown"} {
+		formData = url.Values{
+			"client_id":       {"test-client-1"},
+			"client_secret":   {clientSecret},
+			"token":           {respData["access_token"].(string)},
+			"token_type_hint": {hint},
+		}
+		data := postToTokenEndpoint(t, httpClient, lib.GetBaseUrl()+"/auth/revoke", formData)
+
+		assert.Equal(t, "unsupported_token_type", data["error"])
+		assert.Equal(t, "Revocation of access tokens is not supported. Only refresh tokens can be revoked.", data["error_description"])
+	}
+}
+
+func TestTokenRevoke_InvalidTokenWithAccessTokenHint(t *testing.T) {
+	setup()
+
+	destUrl := lib.GetBaseUrl() + "/auth/revoke"
+
+	httpClient := createHttpClient(&createHttpClientInput{
+		T: t,
+	})
+
+	clientSecret := getClientSecret(t, "test-client-1")
+	formData := url.Values{
+		"client_id":       {"test-client-1"},
+		"client_secret":   {clientSecret},
+		"token":           {"abc"},
+		"token_type_hint": {"access_token"},
+	}
+	resp, err := httpClient.PostForm(destUrl, formData)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	// invalid tokens do not cause an error response, regardless of the hint
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+}
+
+func TestTokenRevoke_RefreshTokenWithWrongHint(t *testing.T) {
+	setup()
+	scope := "openid profile email backend-svcA:read-product"
+	code, httpClient := createAuthCode(t, scope)
+
+	clientSecret := getClientSecret(t, "test-client-1")
+
+	formData := url.Values{
+		"client_id":     {"test-client-1"},
+		"client_secret": {clientSecret},
+		"grant_type":    {"authorization_code"},
+		"redirect_uri":  {code.RedirectURI},
+		"code":          {code.Code},
+		"code_verifier": {"DdazqdVNuDmRLGGRGQKKehEaoFeatACtNsM2UYGwuHkhBhDsTSzaCqWttcBc0kGx"},
+	}
+	respData := postToTokenEndpoint(t, httpClient, lib.GetBaseUrl()+"/auth/token", formData)
+	assert.NotEmpty(t, respData["refresh_token"])
+
+	tokenParser := core_token.NewTokenParser(database)
+	refreshTokenJwt, err := tokenParser.ParseToken(context.Background(), respData["refresh_token"].(string), true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// a wrong hint does not prevent the refresh token from being revoked
+	formData = url.Values{
+		"client_id":       {"test-client-1"},
+		"client_secret":   {clientSecret},
+		"token":           {respData["refresh_token"].(string)},
+		"token_type_hint": {"access_token"},
+	}
+	resp, err := httpClient.PostForm(lib.GetBaseUrl()+"/auth/revoke", formData)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	refreshToken, err := database.GetRefreshTokenByJti(nil, refreshTokenJwt.GetStringClaim("jti"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.True(t, refreshToken.Revoked)
+}
+
+func TestTokenRevoke_InvalidToken(t *testing.T) {
+	setup()
+
+	destUrl := lib.GetBaseUrl() + "/auth/revoke"
+
+	httpClient := createHttpClient(&createHttpClientInput{
+		T: t,
+	})
+
+	clientSecret := getClientSecret(t, "test-client-1")
+	formData := url.Values{
+		"client_id":       {"test-client-1"},
+		"client_secret":   {clientSecret},
+		"token":           {"invalid"},
+		"token_type_hint": {"refresh_token"},
+	}
+	resp, err := httpClient.PostForm(destUrl, formData)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	// invalid tokens do not cause an error response
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+}
+
+func TestTokenRevoke_RefreshToken(t *testing.T) {
+	setup()
+	scope := "openid profile email backend-svcA:read-product"
+	code, httpClient := createAuthCode(t, scope)
+
+	destUrl := lib.GetBaseUrl() + "/auth/token"
+
+	clientSecret := getClientSecret(t, "test-client-1")
+
+	formData := url.Values{
+		"client_id":     {"test-client-1"},
+		"client_secret": {clientSecret},
+		"grant_type":    {"authorization_code"},
+		"redirect_uri":  {code.RedirectURI},
+		"code":          {code.Code},
+		"code_verifier": {"DdazqdVNuDmRLGGRGQKKehEaoFeatACtNsM2UYGwuHkhBhDsTSzaCqWttcBc0kGx"},
+	}
+	respData := postToTokenEndpoint(t, httpClient, destUrl, formData)
+	assert.NotEmpty(t, respData["refresh_token"])
+
+	// use the refresh token once, to get a chain of two refresh tokens
+	formData = url.Values{
+		"client_id":     {"test-client-1"},
+		"client_secret": {clientSecret},
+		"grant_type":    {"refresh_token"},
+		"refresh_token": {respData["refresh_token"].(string)},
+	}
+	respData2 := postToTokenEndpoint(t, httpClient, destUrl, formData)
+	assert.NotEmpty(t, respData2["refresh_token"])
+
+	tokenParser := core_token.NewTokenParser(database)
+	refreshTokenJwt, err := tokenParser.ParseToken(context.Background(), respData2["refresh_token"].(string), true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	refreshToken, err := database.GetRefreshTokenByJti(nil, refreshTokenJwt.GetStringClaim("jti"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.False(t, refreshToken.Revoked)
+
+	formData = url.Values{
+		"client_id":       {"test-client-1"},
+		"client_secret":   {clientSecret},
+		"token":           {respData2["refresh_token"].(string)},
+		"token_type_hint": {"refresh_token"},
+	}
+	resp, err := httpClient.Post(lib.GetBaseUrl()+"/auth/revoke", "application/x-www-form-urlencoded",
+		strings.NewReader(formData.Encode()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	refreshTokens, err := database.GetRefreshTokensByFirstRefreshTokenJti(nil, refreshToken.FirstRefreshTokenJti)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, 2, len(refreshTokens))
+	for _, rt := range refreshTokens {
+		assert.True(t, rt.Revoked)
+	}
+
+	formData = url.Values{
+		"client_id":     {"test-client-1"},
+		"client_secret": {clientSecret},
+		"grant_type":    {"refresh_token"},
+		"refresh_token": {respData2["refresh_token"].(string)},
+	}
+	respData3 := postToTokenEndpoint(t, httpClient, destUrl, formData)
+
+	assert.Equal(t, "invalid_grant", respData3["error"])
+	assert.Equal(t, "This refresh token has been revoked.", respData3["error_description"])
+}
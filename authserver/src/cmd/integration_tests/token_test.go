@@ -11,6 +11,7 @@ import (
 	"github.com/google/uuid"
 	core_token "github.com/leodip/goiabada/internal/core/token"
 	"github.com/leodip/goiabada/internal/dtos"
+	"github.com/leodip/goiabada/internal/entities"
 	"github.com/leodip/goiabada/internal/enums"
 	"github.com/leodip/goiabada/internal/lib"
 	"github.com/stretchr/testify/assert"
@@ -563,6 +564,31 @@ func TestToken_ClientCred_SpecificScope(t *testing.T) {
 	assert.Equal(t, "backend-svcA:create-product", parts[0])
 }
 
+func TestToken_Refresh_FlowIsNotEnabled(t *testing.T) {
+	setup()
+
+	defer updateClient(t, "test-client-1", func(client *entities.Client) {
+		client.AuthorizationCodeEnabled = false
+		client.DeviceCodeEnabled = false
+		client.CIBAEnabled = false
+	})()
+
+	destUrl := lib.GetBaseUrl() + "/auth/token"
+
+	httpClient := createHttpClient(&createHttpClientInput{
+		T: t,
+	})
+
+	formData := url.Values{
+		"grant_type": {"refresh_token"},
+		"client_id":  {"test-client-1"},
+	}
+	data := postToTokenEndpoint(t, httpClient, destUrl, formData)
+
+	assert.Equal(t, "unauthorized_client", data["error"])
+	assert.Equal(t, "The client associated with the provided client_id does not support the refresh_token grant. It requires the authorization code, device code or CIBA flow.", data["error_description"])
+}
+
 func TestToken_Refresh_ConfidentialClient_NoClientSecret(t *testing.T) {
 	setup()
 	destUrl := lib.GetBaseUrl() + "/auth/token"
@@ -699,7 +725,7 @@ func TestToken_Refresh_TokenExpired(t *testing.T) {
 	claims["aud"] = settings.Issuer
 	claims["typ"] = enums.TokenTypeRefresh.String()
 	claims["exp"] = exp.Unix()
-	keyPair, err := database.GetCurrentSigningKey(nil)
+	keyPair, err := database.GetCurrentSigningKey(nil, "RS256")
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -2,6 +2,9 @@ package common
 
 const SessionName string = "goiabada"
 
+// BrowserStateCookieName is the cookie with the OP browser state, for the check session iframe
+const BrowserStateCookieName string = "goiabada_browser_state"
+
 const SessionKeySessionIdentifier string = "SessionIdentifier"
 const SessionKeyOTPImage string = "OTPImage"
 const SessionKeyOTPSecret string = "OTPSecret"
@@ -8,6 +8,10 @@ const UserinfoPermissionIdentifier = "userinfo"
 const ManageAccountPermissionIdentifier = "manage-account"
 const AdminWebsitePermissionIdentifier = "admin-website"
 
+const TokenTypeAccessTokenUrn = "urn:ietf:params:oauth:token-type:access_token"
+
+const ClientAssertionTypeJwtBearer = "urn:ietf:params:oauth:client-assertion-type:jwt-bearer"
+
 const AuditAuthFailedPwd = "auth_failed_pwd"
 const AuditAuthFailedOtp = "auth_failed_otp"
 const AuditAuthSuccessPwd = "auth_success_pwd"
@@ -32,6 +36,9 @@ const AuditUpdatedResourcePermissions = "updated_resource_permissions"
 const AuditDeletedResource = "deleted_resource"
 const AuditUpdatedResource = "updated_resource"
 const AuditCreatedResource = "created_resource"
+const AuditAddedAuthorizationDetailType = "added_authorization_detail_type"
+const AuditUpdatedAuthorizationDetailType = "updated_authorization_detail_type"
+const AuditDeletedAuthorizationDetailType = "deleted_authorization_detail_type"
 const AuditUserAddedToGroup = "user_added_to_group"
 const AuditUserRemovedFromGroup = "user_removed_from_group"
 const AuditCreatedGroup = "created_group"
@@ -57,11 +64,24 @@ const AuditUpdatedUIThemeSettings = "updated_ui_theme_settings"
 const AuditTokenIssuedAuthorizationCodeResponse = "token_issued_authorization_code_response"
 const AuditTokenIssuedClientCredentialsResponse = "token_issued_client_credentials_response"
 const AuditTokenIssuedRefreshTokenResponse = "token_issued_refresh_token_response"
+const AuditTokenIntrospected = "token_introspected"
+const AuditRevokedRefreshToken = "revoked_refresh_token"
+const AuditCreatedDeviceCode = "created_device_code"
+const AuditApprovedDeviceCode = "approved_device_code"
+const AuditDeniedDeviceCode = "denied_device_code"
+const AuditTokenIssuedDeviceCodeResponse = "token_issued_device_code_response"
+const AuditTokenIssuedTokenExchangeResponse = "token_issued_token_exchange_response"
+const AuditCreatedBackchannelAuthRequest = "created_backchannel_auth_request"
+const AuditApprovedBackchannelAuthRequest = "approved_backchannel_auth_request"
+const AuditDeniedBackchannelAuthRequest = "denied_backchannel_auth_request"
+const AuditTokenIssuedCIBAResponse = "token_issued_ciba_response"
+const AuditCreatedPushedAuthRequest = "created_pushed_auth_request"
 const AuditUpdatedWebOrigins = "updated_web_origins"
 const AuditUpdatedClientSettings = "updated_client_settings"
 const AuditUpdatedClientTokens = "updated_client_tokens"
 const AuditUpdatedClientAuthentication = "updated_client_authentication"
 const AuditUpdatedClientOAuth2Flows = "updated_client_oauth2_flows"
+const AuditUpdatedClientKeys = "updated_client_keys"
 const AuditUpdatedUserDetails = "updated_user_details"
 const AuditUpdatedUserProfile = "updated_user_profile"
 const AuditUpdatedUserEmail = "updated_user_email"
@@ -75,3 +95,9 @@ const AuditSentPhoneVerificationMessage = "sent_phone_verification_message"
 const AuditChangedPassword = "changed_password"
 const AuditEnrolledOTP = "enrolled_otp"
 const AuditLogout = "logout"
+const AuditRegisteredClient = "registered_client"
+const AuditUpdatedClientRegistration = "updated_client_registration"
+const AuditDeletedClientRegistration = "deleted_client_registration"
+const AuditCreatedInitialAccessToken = "created_initial_access_token"
+const AuditDeletedInitialAccessToken = "deleted_initial_access_token"
+const AuditUpdatedClientLogout = "updated_client_logout"
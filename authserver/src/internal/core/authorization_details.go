@@ -0,0 +1,114 @@
+package core
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+
+	"github.com/leodip/goiabada/internal/customerrors"
+	"github.com/leodip/goiabada/internal/data"
+	"github.com/leodip/goiabada/internal/dtos"
+	"github.com/leodip/goiabada/internal/entities"
+	"github.com/leodip/goiabada/internal/lib"
+	"github.com/pkg/errors"
+)
+
+// ValidateAuthorizationDetails parses the authorization_details parameter (RFC 9396) and validates each
+// authorization detail against the JSON schema of its type. It returns the authorization details, and
+// their types by name.
+func ValidateAuthorizationDetails(database data.Database, authorizationDetails string) (dtos.AuthorizationDetails,
+	map[string]*entities.AuthorizationDetailType, error) {
+
+	details, err := dtos.ParseAuthorizationDetails(authorizationDetails)
+	if err != nil {
+		return nil, nil, customerrors.NewValidationError("invalid_authorization_details",
+			"The authorization_details parameter is invalid. It must be a JSON array of objects, each one with a type.")
+	}
+
+	detailTypes := map[string]*entities.AuthorizationDetailType{}
+	for _, detail := range details {
+		detailType := detailTypes[detail.GetType()]
+		if detailType == nil {
+			detailType, err = database.GetAuthorizationDetailTypeByType(nil, detail.GetType())
+			if err != nil {
+				return nil, nil, err
+			}
+			if detailType == nil {
+				return nil, nil, customerrors.NewValidationError("invalid_authorization_details",
+					fmt.Sprintf("The authorization details type '%v' is not supported.", detail.GetType()))
+			}
+			detailTypes[detailType.Type] = detailType
+		}
+
+		if len(detailType.JSONSchema) > 0 {
+			schema, err := lib.ParseJSONSchema(detailType.JSONSchema)
+			if err != nil {
+				return nil, nil, errors.Wrap(err, fmt.Sprintf("invalid JSON schema of the authorization details type %v", detailType.Type))
+			}
+
+			// validate the detail as a plain JSON document
+			var document interface{}
+			b, err := json.Marshal(detail)
+			if err != nil {
+				return nil, nil, errors.Wrap(err, "unable to marshal the authorization detail")
+			}
+			err = json.Unmarshal(b, &document)
+			if err != nil {
+				return nil, nil, errors.Wrap(err, "unable to unmarshal the authorization detail")
+			}
+
+			err = schema.Validate(document)
+			if err != nil {
+				return nil, nil, customerrors.NewValidationError("invalid_authorization_details",
+					fmt.Sprintf("The authorization details of type '%v' are invalid: %v.", detailType.Type, err.Error()))
+			}
+		}
+	}
+	return details, detailTypes, nil
+}
+
+// GetAuthorizationDetailsResources returns the identifiers of the resources that the types of the
+// authorization details belong to. These resources are part of the audience of the access token.
+func GetAuthorizationDetailsResources(database data.Database, authorizationDetails dtos.AuthorizationDetails) ([]string, error) {
+	resourceIdentifiers := []string{}
+	for _, detail := range authorizationDetails {
+		resource, err := getAuthorizationDetailResource(database, detail)
+		if err != nil {
+			return nil, err
+		}
+		if resource != nil && !slices.Contains(resourceIdentifiers, resource.ResourceIdentifier) {
+			resourceIdentifiers = append(resourceIdentifiers, resource.ResourceIdentifier)
+		}
+	}
+	return resourceIdentifiers, nil
+}
+
+// GetAuthorizationDetailsForResource narrows the authorization details down to the ones whose type
+// belongs to the resource (RFC 8707)
+func GetAuthorizationDetailsForResource(database data.Database, authorizationDetails dtos.AuthorizationDetails,
+	resourceIdentifier string) (dtos.AuthorizationDetails, error) {
+
+	var filtered dtos.AuthorizationDetails
+	for _, detail := range authorizationDetails {
+		resource, err := getAuthorizationDetailResource(database, detail)
+		if err != nil {
+			return nil, err
+		}
+		if resource != nil && resource.ResourceIdentifier == resourceIdentifier {
+			filtered = append(filtered, detail)
+		}
+	}
+	return filtered, nil
+}
+
+func getAuthorizationDetailResource(database data.Database, authorizationDetail dtos.AuthorizationDetail) (*entities.Resource, error) {
+	detailType, err := database.GetAuthorizationDetailTypeByType(nil, authorizationDetail.GetType())
+	if err != nil {
+		return nil, err
+	}
+	if detailType == nil {
+		// the type was removed after the authorization was granted
+		return nil, nil
+	}
+	return database.GetResourceById(nil, detailType.ResourceId)
+}
@@ -0,0 +1,76 @@
+package core
+
+import (
+	"context"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/leodip/goiabada/internal/constants"
+	"github.com/leodip/goiabada/internal/data"
+	"github.com/leodip/goiabada/internal/entities"
+	"github.com/leodip/goiabada/internal/enums"
+	"github.com/leodip/goiabada/internal/lib"
+)
+
+const backchannelAuthRequestExpirationInSeconds = 300
+const backchannelAuthRequestPollingIntervalInSeconds = 5
+
+type BackchannelAuthRequestIssuer struct {
+	database data.Database
+}
+
+type CreateBackchannelAuthRequestInput struct {
+	Client         *entities.Client
+	User           *entities.User
+	Scope          string
+	BindingMessage string
+	AcrLevel       enums.AcrLevel
+}
+
+func NewBackchannelAuthRequestIssuer(database data.Database) *BackchannelAuthRequestIssuer {
+	return &BackchannelAuthRequestIssuer{
+		database: database,
+	}
+}
+
+func (bari *BackchannelAuthRequestIssuer) CreateBackchannelAuthRequest(ctx context.Context,
+	input *CreateBackchannelAuthRequestInput) (*entities.BackchannelAuthRequest, error) {
+
+	space := regexp.MustCompile(`\s+`)
+	scope := strings.TrimSpace(space.ReplaceAllString(input.Scope, " "))
+
+	authReqId := strings.ReplaceAll(uuid.New().String(), "-", "") + lib.GenerateSecureRandomString(64)
+	authReqIdHash, err := lib.HashString(authReqId)
+	if err != nil {
+		return nil, err
+	}
+
+	backchannelAuthRequest := &entities.BackchannelAuthRequest{
+		AuthReqId:         authReqId,
+		AuthReqIdHash:     authReqIdHash,
+		ClientId:          input.Client.Id,
+		UserId:            input.User.Id,
+		Scope:             scope,
+		BindingMessage:    input.BindingMessage,
+		AcrLevel:          input.AcrLevel.String(),
+		State:             enums.BackchannelAuthRequestStatePending.String(),
+		ExpiresAt:         time.Now().UTC().Add(time.Second * time.Duration(backchannelAuthRequestExpirationInSeconds)),
+		IntervalInSeconds: backchannelAuthRequestPollingIntervalInSeconds,
+	}
+
+	err = bari.database.CreateBackchannelAuthRequest(nil, backchannelAuthRequest)
+	if err != nil {
+		return nil, err
+	}
+
+	lib.LogAudit(constants.AuditCreatedBackchannelAuthRequest, map[string]interface{}{
+		"clientId":                 input.Client.Id,
+		"userId":                   input.User.Id,
+		"backchannelAuthRequestId": backchannelAuthRequest.Id,
+	})
+
+	return backchannelAuthRequest, nil
+}
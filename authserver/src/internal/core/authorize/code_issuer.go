@@ -59,24 +59,27 @@ func (ci *CodeIssuer) CreateAuthCode(ctx context.Context, input *CreateCodeInput
 		return nil, err
 	}
 	code := &entities.Code{
-		Code:                authCode,
-		CodeHash:            authCodeHash,
-		ClientId:            client.Id,
-		AuthenticatedAt:     time.Now().UTC(),
-		UserId:              input.UserId,
-		CodeChallenge:       input.CodeChallenge,
-		CodeChallengeMethod: input.CodeChallengeMethod,
-		RedirectURI:         input.RedirectURI,
-		Scope:               scope,
-		State:               input.State,
-		Nonce:               input.Nonce,
-		UserAgent:           input.UserAgent,
-		ResponseMode:        responseMode,
-		IpAddress:           input.IpAddress,
-		AcrLevel:            input.AcrLevel,
-		AuthMethods:         input.AuthMethods,
-		SessionIdentifier:   input.SessionIdentifier,
-		Used:                false,
+		Code:                 authCode,
+		CodeHash:             authCodeHash,
+		ClientId:             client.Id,
+		AuthenticatedAt:      time.Now().UTC(),
+		UserId:               input.UserId,
+		CodeChallenge:        input.CodeChallenge,
+		CodeChallengeMethod:  input.CodeChallengeMethod,
+		RedirectURI:          input.RedirectURI,
+		Scope:                scope,
+		Resource:             input.Resource,
+		Claims:               input.Claims,
+		AuthorizationDetails: input.AuthorizationDetails,
+		State:                input.State,
+		Nonce:                input.Nonce,
+		UserAgent:            input.UserAgent,
+		ResponseMode:         responseMode,
+		IpAddress:            input.IpAddress,
+		AcrLevel:             input.AcrLevel,
+		AuthMethods:          input.AuthMethods,
+		SessionIdentifier:    input.SessionIdentifier,
+		Used:                 false,
 	}
 
 	err = ci.database.CreateCode(nil, code)
@@ -0,0 +1,103 @@
+package core
+
+import (
+	"context"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+
+	"github.com/leodip/goiabada/internal/constants"
+	"github.com/leodip/goiabada/internal/data"
+	"github.com/leodip/goiabada/internal/entities"
+	"github.com/leodip/goiabada/internal/enums"
+	"github.com/leodip/goiabada/internal/lib"
+)
+
+const deviceCodeExpirationInSeconds = 600
+const deviceCodePollingIntervalInSeconds = 5
+
+type DeviceCodeIssuer struct {
+	database data.Database
+}
+
+type CreateDeviceCodeInput struct {
+	Client *entities.Client
+	Scope  string
+}
+
+func NewDeviceCodeIssuer(database data.Database) *DeviceCodeIssuer {
+	return &DeviceCodeIssuer{
+		database: database,
+	}
+}
+
+func (dci *DeviceCodeIssuer) CreateDeviceCode(ctx context.Context, input *CreateDeviceCodeInput) (*entities.DeviceCode, error) {
+
+	space := regexp.MustCompile(`\s+`)
+	scope := strings.TrimSpace(space.ReplaceAllString(input.Scope, " "))
+
+	deviceCode := strings.ReplaceAll(uuid.New().String(), "-", "") + lib.GenerateSecureRandomString(64)
+	deviceCodeHash, err := lib.HashString(deviceCode)
+	if err != nil {
+		return nil, err
+	}
+
+	// user codes are short, so make sure we don't collide with an existing one
+	userCode := ""
+	for i := 0; i < 5; i++ {
+		candidate := lib.GenerateUserCode()
+		existing, err := dci.database.GetDeviceCodeByUserCode(nil, candidate)
+		if err != nil {
+			return nil, err
+		}
+		if existing == nil {
+			userCode = candidate
+			break
+		}
+	}
+	if len(userCode) == 0 {
+		return nil, errors.WithStack(errors.New("unable to generate a unique user code"))
+	}
+
+	deviceCodeEntity := &entities.DeviceCode{
+		DeviceCode:        deviceCode,
+		DeviceCodeHash:    deviceCodeHash,
+		UserCode:          userCode,
+		ClientId:          input.Client.Id,
+		Scope:             scope,
+		State:             enums.DeviceCodeStatePending.String(),
+		ExpiresAt:         time.Now().UTC().Add(time.Second * time.Duration(deviceCodeExpirationInSeconds)),
+		IntervalInSeconds: deviceCodePollingIntervalInSeconds,
+	}
+
+	err = dci.database.CreateDeviceCode(nil, deviceCodeEntity)
+	if err != nil {
+		return nil, err
+	}
+
+	lib.LogAudit(constants.AuditCreatedDeviceCode, map[string]interface{}{
+		"clientId":     input.Client.Id,
+		"deviceCodeId": deviceCodeEntity.Id,
+	})
+
+	return deviceCodeEntity, nil
+}
+
+// NormalizeUserCode converts a user code, as typed by the end-user, to the XXXX-XXXX format.
+func NormalizeUserCode(userCode string) string {
+	userCode = strings.ToUpper(userCode)
+	userCode = strings.Map(func(r rune) rune {
+		if r >= 'A' && r <= 'Z' {
+			return r
+		}
+		return -1
+	}, userCode)
+
+	if len(userCode) != 8 {
+		return userCode
+	}
+	return userCode[:4] + "-" + userCode[4:]
+}
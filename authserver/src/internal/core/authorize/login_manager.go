@@ -2,6 +2,8 @@ package core
 
 import (
 	"context"
+	"slices"
+	"strings"
 
 	"github.com/leodip/goiabada/internal/common"
 	"github.com/leodip/goiabada/internal/entities"
@@ -47,6 +49,34 @@ func (lm *LoginManager) HasValidUserSession(ctx context.Context, userSession *en
 	return isValid
 }
 
+type MustAuthenticateInput struct {
+	UserSession              *entities.UserSession
+	RequestedMaxAgeInSeconds *int
+	Prompt                   string
+	IdTokenHintSubject       string
+}
+
+// MustAuthenticate decides if the user must (re-)authenticate with a password, or if the
+// existing user session can be used
+func (lm *LoginManager) MustAuthenticate(ctx context.Context, input *MustAuthenticateInput) bool {
+
+	if !lm.HasValidUserSession(ctx, input.UserSession, input.RequestedMaxAgeInSeconds) {
+		return true
+	}
+
+	promptValues := strings.Fields(input.Prompt)
+	if slices.Contains(promptValues, "login") || slices.Contains(promptValues, "select_account") {
+		return true
+	}
+
+	if len(input.IdTokenHintSubject) > 0 && input.UserSession.User.Subject.String() != input.IdTokenHintSubject {
+		// the session belongs to a different user than the one the client expects
+		return true
+	}
+
+	return false
+}
+
 func (lm *LoginManager) MustPerformOTPAuth(ctx context.Context, client *entities.Client,
 	userSession *entities.UserSession, targetAcrLevel enums.AcrLevel) bool {
 
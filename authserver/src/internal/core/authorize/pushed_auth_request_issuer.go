@@ -0,0 +1,70 @@
+package core
+
+import (
+	"context"
+	"net/url"
+	"time"
+
+	"github.com/leodip/goiabada/internal/constants"
+	"github.com/leodip/goiabada/internal/data"
+	"github.com/leodip/goiabada/internal/entities"
+	"github.com/leodip/goiabada/internal/lib"
+)
+
+const PushedAuthRequestExpirationInSeconds = 60
+const RequestURIPrefix = "urn:ietf:params:oauth:request_uri:"
+
+type PushedAuthRequestIssuer struct {
+	database data.Database
+}
+
+type CreatePushedAuthRequestInput struct {
+	Client     *entities.Client
+	Parameters url.Values
+}
+
+func NewPushedAuthRequestIssuer(database data.Database) *PushedAuthRequestIssuer {
+	return &PushedAuthRequestIssuer{
+		database: database,
+	}
+}
+
+func (pi *PushedAuthRequestIssuer) CreatePushedAuthRequest(ctx context.Context,
+	input *CreatePushedAuthRequestInput) (*entities.PushedAuthRequest, error) {
+
+	// client credentials must never be persisted with the authorization parameters
+	parameters := url.Values{}
+	for key, values := range input.Parameters {
+		if key == "client_secret" || key == "client_assertion" || key == "client_assertion_type" {
+			continue
+		}
+		parameters[key] = values
+	}
+
+	requestURI := RequestURIPrefix + lib.GenerateSecureRandomString(48)
+	requestURIHash, err := lib.HashString(requestURI)
+	if err != nil {
+		return nil, err
+	}
+
+	pushedAuthRequest := &entities.PushedAuthRequest{
+		RequestURI:     requestURI,
+		RequestURIHash: requestURIHash,
+		ClientId:       input.Client.Id,
+		Parameters:     parameters.Encode(),
+		ExpiresAt:      time.Now().UTC().Add(time.Second * time.Duration(PushedAuthRequestExpirationInSeconds)),
+		Used:           false,
+	}
+
+	err = pi.database.CreatePushedAuthRequest(nil, pushedAuthRequest)
+	if err != nil {
+		return nil, err
+	}
+
+	lib.LogAudit(constants.AuditCreatedPushedAuthRequest, map[string]interface{}{
+		"clientId":            input.Client.Id,
+		"pushedAuthRequestId": pushedAuthRequest.Id,
+	})
+
+	return pushedAuthRequest, nil
+}
@@ -0,0 +1,58 @@
+package core
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/leodip/goiabada/internal/entities"
+	"github.com/leodip/goiabada/internal/lib"
+	"github.com/pkg/errors"
+)
+
+type ClientKeysResolver struct {
+	httpClient *http.Client
+}
+
+func NewClientKeysResolver() *ClientKeysResolver {
+	return &ClientKeysResolver{
+		httpClient: &http.Client{
+			Timeout: 10 * time.Second,
+		},
+	}
+}
+
+// GetClientKeys returns the public keys registered for the client, either inline
+// (JWKS) or by reference (jwks_uri). It returns nil when the client has no keys.
+func (ckr *ClientKeysResolver) GetClientKeys(ctx context.Context, client *entities.Client) (*lib.JSONWebKeySet, error) {
+	if len(client.JWKS) > 0 {
+		return lib.ParseJSONWebKeySet([]byte(client.JWKS))
+	}
+
+	if len(client.JWKSURI) == 0 {
+		return nil, nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, client.JWKSURI, nil)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := ckr.httpClient.Do(req)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to fetch the client's jwks_uri")
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.WithStack(errors.Errorf("the client's jwks_uri returned status code %v", resp.StatusCode))
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1024*1024))
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to read the client's jwks_uri response")
+	}
+	return lib.ParseJSONWebKeySet(body)
+}
@@ -0,0 +1,316 @@
+package core
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"encoding/pem"
+	"slices"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/leodip/goiabada/internal/common"
+	"github.com/leodip/goiabada/internal/data"
+	"github.com/leodip/goiabada/internal/dtos"
+	"github.com/leodip/goiabada/internal/entities"
+	"github.com/leodip/goiabada/internal/enums"
+	"github.com/leodip/goiabada/internal/lib"
+)
+
+type ClientRegistrar struct {
+	database       data.Database
+	inputSanitizer *InputSanitizer
+}
+
+func NewClientRegistrar(database data.Database, inputSanitizer *InputSanitizer) *ClientRegistrar {
+	return &ClientRegistrar{
+		database:       database,
+		inputSanitizer: inputSanitizer,
+	}
+}
+
+// RegisterClient creates a client from the (already validated) metadata of a dynamic
+// client registration request (RFC 7591). The response includes the registration access
+// token the client uses to manage its registration (RFC 7592); only its hash is stored.
+func (cr *ClientRegistrar) RegisterClient(ctx context.Context,
+	metadata *dtos.ClientMetadata) (*dtos.ClientRegistrationResponse, error) {
+
+	client := &entities.Client{
+		ClientIdentifier:        "dyn-" + strings.ReplaceAll(uuid.NewString(), "-", ""),
+		Enabled:                 true,
+		ConsentRequired:         true,
+		DefaultAcrLevel:         enums.AcrLevel2,
+		IsDynamicallyRegistered: true,
+	}
+
+	err := cr.applyMetadata(ctx, client, metadata)
+	if err != nil {
+		return nil, err
+	}
+
+	registrationAccessToken := lib.GenerateSecureRandomString(60)
+	client.RegistrationAccessTokenHash, err = lib.HashString(registrationAccessToken)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := cr.database.BeginTransaction()
+	if err != nil {
+		return nil, err
+	}
+	defer cr.database.RollbackTransaction(tx)
+
+	err = cr.database.CreateClient(tx, client)
+	if err != nil {
+		return nil, err
+	}
+
+	err = cr.createRedirectURIsAndWebOrigins(tx, client, metadata)
+	if err != nil {
+		return nil, err
+	}
+
+	err = cr.database.CommitTransaction(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	response, err := cr.GetClientRegistration(ctx, client)
+	if err != nil {
+		return nil, err
+	}
+	response.RegistrationAccessToken = registrationAccessToken
+	return response, nil
+}
+
+// UpdateClientRegistration replaces the metadata of a dynamically registered client (RFC 7592, section 2.2)
+func (cr *ClientRegistrar) UpdateClientRegistration(ctx context.Context, client *entities.Client,
+	metadata *dtos.ClientMetadata) (*dtos.ClientRegistrationResponse, error) {
+
+	err := cr.applyMetadata(ctx, client, metadata)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := cr.database.BeginTransaction()
+	if err != nil {
+		return nil, err
+	}
+	defer cr.database.RollbackTransaction(tx)
+
+	err = cr.database.UpdateClient(tx, client)
+	if err != nil {
+		return nil, err
+	}
+
+	redirectURIs, err := cr.database.GetRedirectURIsByClientId(tx, client.Id)
+	if err != nil {
+		return nil, err
+	}
+	for _, redirectURI := range redirectURIs {
+		err = cr.database.DeleteRedirectURI(tx, redirectURI.Id)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	webOrigins, err := cr.database.GetWebOriginsByClientId(tx, client.Id)
+	if err != nil {
+		return nil, err
+	}
+	for _, webOrigin := range webOrigins {
+		err = cr.database.DeleteWebOrigin(tx, webOrigin.Id)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	err = cr.createRedirectURIsAndWebOrigins(tx, client, metadata)
+	if err != nil {
+		return nil, err
+	}
+
+	err = cr.database.CommitTransaction(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	return cr.GetClientRegistration(ctx, client)
+}
+
+// GetClientRegistration returns the current registration of the client (RFC 7592, section 2.1)
+func (cr *ClientRegistrar) GetClientRegistration(ctx context.Context,
+	client *entities.Client) (*dtos.ClientRegistrationResponse, error) {
+
+	err := cr.database.ClientLoadRedirectURIs(nil, client)
+	if err != nil {
+		return nil, err
+	}
+	err = cr.database.ClientLoadWebOrigins(nil, client)
+	if err != nil {
+		return nil, err
+	}
+
+	response := &dtos.ClientRegistrationResponse{
+		ClientId:              client.ClientIdentifier,
+		ClientIdIssuedAt:      client.CreatedAt.Time.Unix(),
+		RegistrationClientURI: lib.GetBaseUrl() + "/connect/register/" + client.ClientIdentifier,
+		ClientMetadata: dtos.ClientMetadata{
+			TokenEndpointAuthMethod:      client.TokenEndpointAuthMethod,
+			ClientName:                   client.Description,
+			JWKSURI:                      client.JWKSURI,
+			TLSClientAuthSubjectDN:       client.TLSClientAuthSubjectDN,
+			BackChannelLogoutURI:         client.BackChannelLogoutURI,
+			FrontChannelLogoutURI:        client.FrontChannelLogoutURI,
+			SubjectType:                  client.SubjectType,
+			SectorIdentifierURI:          client.SectorIdentifierURI,
+			IdTokenSignedResponseAlg:     client.IdTokenSignedResponseAlg,
+			IdTokenEncryptedResponseAlg:  client.IdTokenEncryptedResponseAlg,
+			IdTokenEncryptedResponseEnc:  client.IdTokenEncryptedResponseEnc,
+			UserInfoSignedResponseAlg:    client.UserInfoSignedResponseAlg,
+			UserInfoEncryptedResponseAlg: client.UserInfoEncryptedResponseAlg,
+			UserInfoEncryptedResponseEnc: client.UserInfoEncryptedResponseEnc,
+		},
+	}
+
+	if client.IsPublic {
+		response.TokenEndpointAuthMethod = "none"
+	}
+
+	if len(client.JWKS) > 0 {
+		response.JWKS = json.RawMessage(client.JWKS)
+	}
+
+	for _, redirectURI := range client.RedirectURIs {
+		response.RedirectURIs = append(response.RedirectURIs, redirectURI.URI)
+	}
+	for _, webOrigin := range client.WebOrigins {
+		response.WebOrigins = append(response.WebOrigins, webOrigin.Origin)
+	}
+
+	if client.AuthorizationCodeEnabled {
+		response.GrantTypes = append(response.GrantTypes, "authorization_code", "refresh_token")
+		response.ResponseTypes = []string{"code"}
+	}
+	if client.ClientCredentialsEnabled {
+		response.GrantTypes = append(response.GrantTypes, "client_credentials")
+	}
+	if client.DeviceCodeEnabled {
+		response.GrantTypes = append(response.GrantTypes, "urn:ietf:params:oauth:grant-type:device_code")
+	}
+	if client.TokenExchangeEnabled {
+		response.GrantTypes = append(response.GrantTypes, "urn:ietf:params:oauth:grant-type:token-exchange")
+	}
+	if client.CIBAEnabled {
+		response.GrantTypes = append(response.GrantTypes, "urn:openid:params:grant-type:ciba")
+	}
+
+	if usesClientSecret(client) {
+		settings := ctx.Value(common.ContextKeySettings).(*entities.Settings)
+		clientSecret, err := lib.DecryptText(client.ClientSecretEncrypted, settings.AESEncryptionKey)
+		if err != nil {
+			return nil, err
+		}
+		response.ClientSecret = clientSecret
+		clientSecretExpiresAt := int64(0) // does not expire
+		response.ClientSecretExpiresAt = &clientSecretExpiresAt
+	}
+
+	return response, nil
+}
+
+func (cr *ClientRegistrar) applyMetadata(ctx context.Context, client *entities.Client, metadata *dtos.ClientMetadata) error {
+
+	client.Description = strings.TrimSpace(cr.inputSanitizer.Sanitize(metadata.ClientName))
+	client.IsPublic = metadata.TokenEndpointAuthMethod == "none"
+	client.AuthorizationCodeEnabled = slices.Contains(metadata.GrantTypes, "authorization_code")
+	client.DeviceCodeEnabled = slices.Contains(metadata.GrantTypes, "urn:ietf:params:oauth:grant-type:device_code")
+	client.ClientCredentialsEnabled = slices.Contains(metadata.GrantTypes, "client_credentials")
+	client.TokenExchangeEnabled = slices.Contains(metadata.GrantTypes, "urn:ietf:params:oauth:grant-type:token-exchange")
+	client.CIBAEnabled = slices.Contains(metadata.GrantTypes, "urn:openid:params:grant-type:ciba")
+	client.JWKS = string(metadata.JWKS)
+	client.JWKSURI = metadata.JWKSURI
+	client.BackChannelLogoutURI = metadata.BackChannelLogoutURI
+	client.FrontChannelLogoutURI = metadata.FrontChannelLogoutURI
+	client.SubjectType = metadata.SubjectType
+	client.SectorIdentifierURI = metadata.SectorIdentifierURI
+	client.IdTokenSignedResponseAlg = metadata.IdTokenSignedResponseAlg
+	client.IdTokenEncryptedResponseAlg = metadata.IdTokenEncryptedResponseAlg
+	client.IdTokenEncryptedResponseEnc = metadata.IdTokenEncryptedResponseEnc
+	client.UserInfoSignedResponseAlg = metadata.UserInfoSignedResponseAlg
+	client.UserInfoEncryptedResponseAlg = metadata.UserInfoEncryptedResponseAlg
+	client.UserInfoEncryptedResponseEnc = metadata.UserInfoEncryptedResponseEnc
+	client.TLSClientAuthSubjectDN = ""
+	client.TLSClientCertificate = ""
+
+	if client.IsPublic {
+		client.TokenEndpointAuthMethod = enums.TokenEndpointAuthMethodClientSecretPost.String()
+		client.ClientSecretEncrypted = nil
+		return nil
+	}
+
+	client.TokenEndpointAuthMethod = metadata.TokenEndpointAuthMethod
+
+	switch metadata.TokenEndpointAuthMethod {
+	case enums.TokenEndpointAuthMethodTLSClientAuth.String():
+		client.TLSClientAuthSubjectDN = metadata.TLSClientAuthSubjectDN
+	case enums.TokenEndpointAuthMethodSelfSignedTLSClientAuth.String():
+		jwks, err := lib.ParseJSONWebKeySet(metadata.JWKS)
+		if err != nil {
+			return err
+		}
+		cert, err := jwks.FindCertificate()
+		if err != nil {
+			return err
+		}
+		client.TLSClientCertificate = string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Raw}))
+	}
+
+	if len(client.ClientSecretEncrypted) == 0 {
+		settings := ctx.Value(common.ContextKeySettings).(*entities.Settings)
+		clientSecretEncrypted, err := lib.EncryptText(lib.GenerateSecureRandomString(60), settings.AESEncryptionKey)
+		if err != nil {
+			return err
+		}
+		client.ClientSecretEncrypted = clientSecretEncrypted
+	}
+
+	return nil
+}
+
+func (cr *ClientRegistrar) createRedirectURIsAndWebOrigins(tx *sql.Tx, client *entities.Client, metadata *dtos.ClientMetadata) error {
+	for _, redirectURI := range metadata.RedirectURIs {
+		err := cr.database.CreateRedirectURI(tx, &entities.RedirectURI{
+			ClientId: client.Id,
+			URI:      strings.TrimSpace(redirectURI),
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, webOrigin := range metadata.WebOrigins {
+		err := cr.database.CreateWebOrigin(tx, &entities.WebOrigin{
+			ClientId: client.Id,
+			Origin:   strings.TrimSuffix(strings.ToLower(strings.TrimSpace(webOrigin)), "/"),
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// usesClientSecret reports whether the client authenticates with its client secret
+func usesClientSecret(client *entities.Client) bool {
+	if client.IsPublic {
+		return false
+	}
+	switch client.TokenEndpointAuthMethod {
+	case enums.TokenEndpointAuthMethodClientSecretPost.String(),
+		enums.TokenEndpointAuthMethodClientSecretBasic.String(),
+		enums.TokenEndpointAuthMethodClientSecretJwt.String():
+		return true
+	}
+	return false
+}
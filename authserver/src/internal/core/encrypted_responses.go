@@ -0,0 +1,88 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/leodip/goiabada/internal/customerrors"
+	"github.com/leodip/goiabada/internal/entities"
+	"github.com/leodip/goiabada/internal/lib"
+	"github.com/pkg/errors"
+)
+
+// GetIdTokenEncryption returns the key management algorithm and the content encryption of the
+// id tokens of the client. An empty algorithm means the id tokens are only signed.
+func GetIdTokenEncryption(client *entities.Client) (string, string) {
+	return getEncryption(client.IdTokenEncryptedResponseAlg, client.IdTokenEncryptedResponseEnc)
+}
+
+// GetUserInfoEncryption returns the key management algorithm and the content encryption of the
+// userinfo responses of the client. An empty algorithm means the responses are plain JSON.
+func GetUserInfoEncryption(client *entities.Client) (string, string) {
+	return getEncryption(client.UserInfoEncryptedResponseAlg, client.UserInfoEncryptedResponseEnc)
+}
+
+func getEncryption(alg string, enc string) (string, string) {
+	if len(alg) == 0 {
+		return "", ""
+	}
+	if len(enc) == 0 {
+		return alg, lib.DefaultJWEEncryptionMethod
+	}
+	return alg, enc
+}
+
+// ValidateEncryptedResponse checks the encryption settings of a response of the client (id_token
+// or userinfo). Encryption requires the public keys of the client, in the jwks or the jwks_uri.
+func ValidateEncryptedResponse(response string, alg string, enc string, jwks string, jwksURI string) error {
+	if len(alg) == 0 {
+		if len(enc) > 0 {
+			return customerrors.NewValidationError("invalid_client_metadata", fmt.Sprintf("The %v_encrypted_response_enc requires the %v_encrypted_response_alg.",
+				response, response))
+		}
+		return nil
+	}
+
+	if !lib.IsJWEAlgorithmSupported(alg) {
+		return customerrors.NewValidationError("invalid_client_metadata", fmt.Sprintf("Unsupported %v_encrypted_response_alg '%v'. It must be one of: %v.",
+			response, alg, strings.Join(lib.JWEAlgorithms, ", ")))
+	}
+	if len(enc) > 0 && !lib.IsJWEEncryptionMethodSupported(enc) {
+		return customerrors.NewValidationError("invalid_client_metadata", fmt.Sprintf("Unsupported %v_encrypted_response_enc '%v'. It must be one of: %v.",
+			response, enc, strings.Join(lib.JWEEncryptionMethods, ", ")))
+	}
+
+	if len(jwks) == 0 && len(jwksURI) == 0 {
+		return customerrors.NewValidationError("invalid_client_metadata", fmt.Sprintf("The %v_encrypted_response_alg requires the jwks or the jwks_uri of the client.",
+			response))
+	}
+	if len(jwks) > 0 {
+		keySet, err := lib.ParseJSONWebKeySet([]byte(jwks))
+		if err != nil || keySet.FindEncryptionKey(alg) == nil {
+			return customerrors.NewValidationError("invalid_client_metadata", fmt.Sprintf("The jwks of the client has no %v key to encrypt with the %v_encrypted_response_alg '%v'.",
+				lib.GetJWEKeyType(alg), response, alg))
+		}
+	}
+	return nil
+}
+
+// EncryptToClient encrypts a signed JWT to a public key of the client, which results in a
+// nested JWT (OpenID Connect Core 1.0, section 16.14)
+func (ckr *ClientKeysResolver) EncryptToClient(ctx context.Context, client *entities.Client, signedJWT string,
+	alg string, enc string) (string, error) {
+
+	jwks, err := ckr.GetClientKeys(ctx, client)
+	if err != nil {
+		return "", err
+	}
+	if jwks == nil {
+		return "", errors.WithStack(errors.Errorf("client %v has no public keys to encrypt to", client.ClientIdentifier))
+	}
+
+	key := jwks.FindEncryptionKey(alg)
+	if key == nil {
+		return "", errors.WithStack(errors.Errorf("client %v has no public key to encrypt with the algorithm %v", client.ClientIdentifier, alg))
+	}
+	return lib.EncryptJWE([]byte(signedJWT), key, alg, enc, "JWT")
+}
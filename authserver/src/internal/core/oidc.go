@@ -1,12 +1,102 @@
 package core
 
-import "slices"
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/leodip/goiabada/internal/dtos"
+	"github.com/leodip/goiabada/internal/entities"
+	"github.com/leodip/goiabada/internal/lib"
+)
+
+// standardClaimScopes maps the standard claims about the user to the scope that releases them
+// (OpenID Connect Core 1.0, section 5.4)
+var standardClaimScopes = map[string]string{
+	"name":                  "profile",
+	"given_name":            "profile",
+	"middle_name":           "profile",
+	"family_name":           "profile",
+	"nickname":              "profile",
+	"preferred_username":    "profile",
+	"profile":               "profile",
+	"website":               "profile",
+	"gender":                "profile",
+	"birthdate":             "profile",
+	"zoneinfo":              "profile",
+	"locale":                "profile",
+	"updated_at":            "profile",
+	"email":                 "email",
+	"email_verified":        "email",
+	"address":               "address",
+	"phone_number":          "phone",
+	"phone_number_verified": "phone",
+}
 
 func IsIdTokenScope(scope string) bool {
 	oidcScopes := []string{"openid", "profile", "email", "address", "phone", "groups", "attributes", "offline_access"}
 	return slices.Contains(oidcScopes, scope)
 }
 
+// GetClaimScope returns the scope that releases a standard claim about the user, or an empty
+// string for the other claims
+func GetClaimScope(claim string) string {
+	return standardClaimScopes[claim]
+}
+
+// GetUserClaims returns the standard claims about the user released by the scope, and the claims
+// requested individually with the claims parameter. A requested claim is left out when its value
+// doesn't match the value or values constraints of the request.
+func GetUserClaims(user *entities.User, scope string, requestedClaims map[string]*dtos.ClaimRequest) map[string]interface{} {
+	values := map[string]interface{}{}
+
+	addClaimIfNotEmpty := func(claimName string, claimValue string) {
+		if len(strings.TrimSpace(claimValue)) > 0 {
+			values[claimName] = claimValue
+		}
+	}
+
+	addClaimIfNotEmpty("name", user.GetFullName())
+	addClaimIfNotEmpty("given_name", user.GivenName)
+	addClaimIfNotEmpty("middle_name", user.MiddleName)
+	addClaimIfNotEmpty("family_name", user.FamilyName)
+	addClaimIfNotEmpty("nickname", user.Nickname)
+	addClaimIfNotEmpty("preferred_username", user.Username)
+	values["profile"] = fmt.Sprintf("%v/account/profile", lib.GetBaseUrl())
+	addClaimIfNotEmpty("website", user.Website)
+	addClaimIfNotEmpty("gender", user.Gender)
+	if user.BirthDate.Valid {
+		values["birthdate"] = user.BirthDate.Time.Format("2006-01-02")
+	}
+	addClaimIfNotEmpty("zoneinfo", user.ZoneInfo)
+	addClaimIfNotEmpty("locale", user.Locale)
+	values["updated_at"] = user.UpdatedAt.Time.UTC().Unix()
+
+	addClaimIfNotEmpty("email", user.Email)
+	values["email_verified"] = user.EmailVerified
+
+	if user.HasAddress() {
+		values["address"] = user.GetAddressClaim()
+	}
+
+	addClaimIfNotEmpty("phone_number", user.PhoneNumber)
+	values["phone_number_verified"] = user.PhoneNumberVerified
+
+	scopes := strings.Split(scope, " ")
+	claims := map[string]interface{}{}
+	for claimName, claimValue := range values {
+		claimRequest, requested := requestedClaims[claimName]
+		if !requested && !slices.Contains(scopes, standardClaimScopes[claimName]) {
+			continue
+		}
+		if requested && !claimRequest.Matches(claimValue) {
+			continue
+		}
+		claims[claimName] = claimValue
+	}
+	return claims
+}
+
 func GetIdTokenScopeDescription(scope string) string {
 	switch scope {
 	case "openid":
@@ -0,0 +1,25 @@
+package core
+
+import (
+	"strings"
+
+	"github.com/leodip/goiabada/internal/constants"
+)
+
+// GetScopeForResource narrows a scope down to the permissions of a single resource (RFC 8707).
+// OpenID Connect scopes are kept only for the auth server, as they give access to the userinfo endpoint.
+func GetScopeForResource(scope string, resourceIdentifier string) string {
+	scopes := []string{}
+	for _, scopeStr := range strings.Fields(scope) {
+		if IsIdTokenScope(scopeStr) {
+			if resourceIdentifier == constants.AuthServerResourceIdentifier {
+				scopes = append(scopes, scopeStr)
+			}
+			continue
+		}
+		if strings.HasPrefix(scopeStr, resourceIdentifier+":") {
+			scopes = append(scopes, scopeStr)
+		}
+	}
+	return strings.Join(scopes, " ")
+}
@@ -0,0 +1,194 @@
+package core
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/leodip/goiabada/internal/common"
+	"github.com/leodip/goiabada/internal/core"
+	"github.com/leodip/goiabada/internal/data"
+	"github.com/leodip/goiabada/internal/entities"
+	"github.com/leodip/goiabada/internal/enums"
+	"github.com/leodip/goiabada/internal/lib"
+	"github.com/pkg/errors"
+)
+
+const backChannelLogoutEvent = "http://schemas.openid.net/event/backchannel-logout"
+
+type BackChannelLogoutSender struct {
+	database        data.Database
+	subjectResolver *core.SubjectResolver
+	httpClient      *http.Client
+	maxAttempts     int
+	retryDelay      time.Duration
+}
+
+func NewBackChannelLogoutSender(database data.Database, subjectResolver *core.SubjectResolver) *BackChannelLogoutSender {
+	return &BackChannelLogoutSender{
+		database:        database,
+		subjectResolver: subjectResolver,
+		httpClient: &http.Client{
+			Timeout: 5 * time.Second,
+		},
+		maxAttempts: 3,
+		retryDelay:  time.Second,
+	}
+}
+
+// SendLogoutTokens notifies every client of the user session that has a back-channel
+// logout URI (OpenID Connect Back-Channel Logout 1.0). It must be called before the
+// session is deleted. The deliveries are recorded and then sent in the background,
+// with retries, so the logout itself is never held up by a slow client.
+func (b *BackChannelLogoutSender) SendLogoutTokens(ctx context.Context, userSession *entities.UserSession) error {
+
+	settings := ctx.Value(common.ContextKeySettings).(*entities.Settings)
+
+	err := b.database.UserSessionLoadUser(nil, userSession)
+	if err != nil {
+		return err
+	}
+
+	err = b.database.UserSessionLoadClients(nil, userSession)
+	if err != nil {
+		return err
+	}
+
+	err = b.database.UserSessionClientsLoadClients(nil, userSession.Clients)
+	if err != nil {
+		return err
+	}
+
+	// logout tokens are signed like the id tokens of each client
+	signingKeys := map[string]*lib.SigningKey{}
+
+	for _, userSessionClient := range userSession.Clients {
+		client := userSessionClient.Client
+		if len(client.BackChannelLogoutURI) == 0 {
+			continue
+		}
+
+		// the sub claim is the one the client has seen, pairwise or public
+		subject, err := b.subjectResolver.GetSubject(&userSession.User, &client)
+		if err != nil {
+			return err
+		}
+
+		algorithm := core.GetIdTokenSigningAlgorithm(&client)
+		signingKey, ok := signingKeys[algorithm]
+		if !ok {
+			signingKey, err = core.GetCurrentSigningKey(b.database, algorithm)
+			if err != nil {
+				return err
+			}
+			signingKeys[algorithm] = signingKey
+		}
+
+		now := time.Now().UTC()
+		claims := jwt.MapClaims{
+			"iss":    settings.Issuer,
+			"aud":    client.ClientIdentifier,
+			"iat":    now.Unix(),
+			"exp":    now.Add(2 * time.Minute).Unix(),
+			"jti":    uuid.New().String(),
+			"sub":    subject,
+			"sid":    userSession.SessionIdentifier,
+			"events": map[string]interface{}{backChannelLogoutEvent: map[string]interface{}{}},
+		}
+		token := signingKey.NewToken(claims)
+		token.Header["typ"] = "logout+jwt"
+		logoutToken, err := token.SignedString(signingKey.PrivateKey)
+		if err != nil {
+			return errors.Wrap(err, "unable to sign logout token")
+		}
+
+		delivery := &entities.BackChannelLogoutDelivery{
+			ClientId:          client.Id,
+			SessionIdentifier: userSession.SessionIdentifier,
+			Subject:           subject,
+			LogoutURI:         client.BackChannelLogoutURI,
+			Status:            enums.BackChannelLogoutStatusPending.String(),
+		}
+		err = b.database.CreateBackChannelLogoutDelivery(nil, delivery)
+		if err != nil {
+			return err
+		}
+
+		go b.deliver(delivery, logoutToken)
+	}
+
+	return nil
+}
+
+func (b *BackChannelLogoutSender) deliver(delivery *entities.BackChannelLogoutDelivery, logoutToken string) {
+
+	for delivery.Attempts < b.maxAttempts {
+		if delivery.Attempts > 0 {
+			// exponential backoff: retryDelay, 2*retryDelay, 4*retryDelay...
+			time.Sleep(b.retryDelay * time.Duration(1<<(delivery.Attempts-1)))
+		}
+
+		err := b.post(delivery.LogoutURI, logoutToken)
+
+		delivery.Attempts++
+		delivery.LastAttemptAt = sql.NullTime{Time: time.Now().UTC(), Valid: true}
+		if err == nil {
+			delivery.Status = enums.BackChannelLogoutStatusDelivered.String()
+			delivery.LastError = ""
+		} else {
+			delivery.LastError = truncate(err.Error(), 512)
+			if delivery.Attempts >= b.maxAttempts {
+				delivery.Status = enums.BackChannelLogoutStatusFailed.String()
+			}
+		}
+
+		updateErr := b.database.UpdateBackChannelLogoutDelivery(nil, delivery)
+		if updateErr != nil {
+			slog.Error(fmt.Sprintf("unable to update back-channel logout delivery %v: %+v", delivery.Id, updateErr))
+		}
+
+		if err == nil {
+			return
+		}
+	}
+
+	slog.Warn(fmt.Sprintf("back-channel logout to %v failed after %v attempts: %v",
+		delivery.LogoutURI, delivery.Attempts, delivery.LastError))
+}
+
+func (b *BackChannelLogoutSender) post(logoutURI string, logoutToken string) error {
+	formData := url.Values{
+		"logout_token": {logoutToken},
+	}
+
+	req, err := http.NewRequest(http.MethodPost, logoutURI, strings.NewReader(formData.Encode()))
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := b.httpClient.Do(req)
+	if err != nil {
+		return errors.Wrap(err, "unable to send the logout token")
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return errors.WithStack(errors.Errorf("the back-channel logout URI returned status code %v", resp.StatusCode))
+	}
+	return nil
+}
+
+func truncate(str string, maxLength int) string {
+	if len(str) > maxLength {
+		return str[:maxLength]
+	}
+	return str
+}
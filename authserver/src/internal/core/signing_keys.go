@@ -0,0 +1,135 @@
+package core
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/leodip/goiabada/internal/customerrors"
+	"github.com/leodip/goiabada/internal/data"
+	"github.com/leodip/goiabada/internal/entities"
+	"github.com/leodip/goiabada/internal/enums"
+	"github.com/leodip/goiabada/internal/lib"
+	"github.com/pkg/errors"
+)
+
+// GetActiveSigningAlgorithms returns the signing algorithms that have a current key, which are
+// the algorithms that can sign id tokens and userinfo responses
+func GetActiveSigningAlgorithms(database data.Database) ([]string, error) {
+	allSigningKeys, err := database.GetAllSigningKeys(nil)
+	if err != nil {
+		return nil, err
+	}
+
+	algorithms := []string{}
+	for _, algorithm := range lib.SigningAlgorithms {
+		for _, signingKey := range allSigningKeys {
+			if signingKey.Algorithm == algorithm && signingKey.State == enums.KeyStateCurrent.String() &&
+				!slices.Contains(algorithms, algorithm) {
+				algorithms = append(algorithms, algorithm)
+			}
+		}
+	}
+	return algorithms, nil
+}
+
+// GetCurrentSigningKey returns the current signing key of the algorithm, ready to sign tokens
+func GetCurrentSigningKey(database data.Database, algorithm string) (*lib.SigningKey, error) {
+	keyPair, err := database.GetCurrentSigningKey(nil, algorithm)
+	if err != nil {
+		return nil, err
+	}
+	if keyPair == nil {
+		return nil, errors.WithStack(errors.Errorf("no current signing key found for the algorithm %v", algorithm))
+	}
+	return lib.ParseSigningKey(keyPair.Algorithm, keyPair.KeyIdentifier, keyPair.PrivateKeyPEM)
+}
+
+// GetIdTokenSigningAlgorithm returns the algorithm of the id tokens of the client, which also
+// applies to its logout tokens. Access and refresh tokens are always signed with the default algorithm.
+func GetIdTokenSigningAlgorithm(client *entities.Client) string {
+	if len(client.IdTokenSignedResponseAlg) > 0 {
+		return client.IdTokenSignedResponseAlg
+	}
+	return lib.DefaultSigningAlgorithm
+}
+
+// GetUserInfoSigningAlgorithm returns the algorithm of the signed userinfo responses of the client.
+// An empty algorithm means the responses are plain JSON, unless they're encrypted, in which case
+// they're signed with the default algorithm before the encryption.
+func GetUserInfoSigningAlgorithm(client *entities.Client) string {
+	if len(client.UserInfoSignedResponseAlg) > 0 {
+		return client.UserInfoSignedResponseAlg
+	}
+	alg, _ := GetUserInfoEncryption(client)
+	if len(alg) > 0 {
+		return lib.DefaultSigningAlgorithm
+	}
+	return ""
+}
+
+// ValidateIdTokenSigningAlgorithm checks if the id tokens of a client can be signed with the
+// algorithm. An empty algorithm means the default one.
+func ValidateIdTokenSigningAlgorithm(database data.Database, algorithm string) error {
+	return validateSigningAlgorithm(database, "id_token", algorithm)
+}
+
+// ValidateUserInfoSigningAlgorithm checks if the userinfo responses of a client can be signed with
+// the algorithm. An empty algorithm means the responses are not signed.
+func ValidateUserInfoSigningAlgorithm(database data.Database, algorithm string) error {
+	return validateSigningAlgorithm(database, "userinfo", algorithm)
+}
+
+func validateSigningAlgorithm(database data.Database, response string, algorithm string) error {
+	if len(algorithm) == 0 {
+		return nil
+	}
+
+	activeAlgorithms, err := GetActiveSigningAlgorithms(database)
+	if err != nil {
+		return err
+	}
+	if !slices.Contains(activeAlgorithms, algorithm) {
+		return customerrors.NewValidationError("invalid_client_metadata", fmt.Sprintf("Unsupported %v_signed_response_alg '%v'. "+
+			"It must be one of: %v.", response, algorithm, strings.Join(activeAlgorithms, ", ")))
+	}
+	return nil
+}
+
+// GetVerificationKeyFunc returns a jwt.Keyfunc that selects the signing key (next, current or
+// previous) identified by the kid header of the token, and checks the token uses its algorithm.
+// Tokens without a kid are verified with the current key of their algorithm.
+func GetVerificationKeyFunc(database data.Database) jwt.Keyfunc {
+	return func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		if len(kid) == 0 {
+			// without a kid, the token can only be verified with the current key of its algorithm
+			keyPair, err := database.GetCurrentSigningKey(nil, token.Method.Alg())
+			if err != nil {
+				return nil, err
+			}
+			if keyPair == nil {
+				return nil, errors.WithStack(errors.Errorf("no current signing key found for the algorithm %v", token.Method.Alg()))
+			}
+			return lib.ParseVerificationKey(keyPair.Algorithm, keyPair.PublicKeyPEM)
+		}
+
+		signingKeys, err := database.GetAllSigningKeys(nil)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, signingKey := range signingKeys {
+			if signingKey.KeyIdentifier != kid {
+				continue
+			}
+			if token.Method.Alg() != signingKey.Algorithm {
+				return nil, errors.WithStack(errors.Errorf("the token algorithm '%v' does not match the algorithm of the key '%v'",
+					token.Method.Alg(), signingKey.Algorithm))
+			}
+			return lib.ParseVerificationKey(signingKey.Algorithm, signingKey.PublicKeyPEM)
+		}
+		return nil, errors.WithStack(errors.Errorf("no signing key found with kid '%v'", kid))
+	}
+}
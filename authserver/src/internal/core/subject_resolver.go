@@ -0,0 +1,207 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/leodip/goiabada/internal/customerrors"
+	"github.com/leodip/goiabada/internal/data"
+	"github.com/leodip/goiabada/internal/entities"
+	"github.com/leodip/goiabada/internal/enums"
+	"github.com/pkg/errors"
+)
+
+// SubjectResolver maps users to the subject identifiers (sub claim) seen by each client
+// (OpenID Connect Core 1.0, section 8). Clients with the public subject type see the subject
+// of the user. Clients with the pairwise subject type see a pseudonymous subject, shared only
+// by the clients of the same sector identifier, so that unrelated clients can't correlate users.
+type SubjectResolver struct {
+	database   data.Database
+	httpClient *http.Client
+}
+
+func NewSubjectResolver(database data.Database) *SubjectResolver {
+	return &SubjectResolver{
+		database: database,
+		httpClient: &http.Client{
+			Timeout: 10 * time.Second,
+		},
+	}
+}
+
+// GetSubject returns the subject identifier of the user for the client. The pairwise subject
+// is created the first time the user signs in to a client of the sector, and reused afterwards.
+func (sr *SubjectResolver) GetSubject(user *entities.User, client *entities.Client) (string, error) {
+	if client.SubjectType != enums.SubjectTypePairwise.String() {
+		return user.Subject.String(), nil
+	}
+
+	sectorIdentifier, err := sr.GetSectorIdentifier(client)
+	if err != nil {
+		return "", err
+	}
+
+	pairwiseSubject, err := sr.database.GetPairwiseSubjectByUserIdAndSectorIdentifier(nil, user.Id, sectorIdentifier)
+	if err != nil {
+		return "", err
+	}
+	if pairwiseSubject != nil {
+		return pairwiseSubject.Subject, nil
+	}
+
+	pairwiseSubject = &entities.PairwiseSubject{
+		UserId:           user.Id,
+		SectorIdentifier: sectorIdentifier,
+		Subject:          uuid.New().String(),
+	}
+	err = sr.database.CreatePairwiseSubject(nil, pairwiseSubject)
+	if err != nil {
+		// a concurrent request may have created it in the meantime
+		existing, errGet := sr.database.GetPairwiseSubjectByUserIdAndSectorIdentifier(nil, user.Id, sectorIdentifier)
+		if errGet == nil && existing != nil {
+			return existing.Subject, nil
+		}
+		return "", err
+	}
+	return pairwiseSubject.Subject, nil
+}
+
+// GetUserBySubject returns the user of a subject identifier, either public or pairwise.
+// It returns nil when the subject is unknown.
+func (sr *SubjectResolver) GetUserBySubject(subject string) (*entities.User, error) {
+	user, err := sr.database.GetUserBySubject(nil, subject)
+	if err != nil {
+		return nil, err
+	}
+	if user != nil {
+		return user, nil
+	}
+
+	pairwiseSubject, err := sr.database.GetPairwiseSubjectBySubject(nil, subject)
+	if err != nil {
+		return nil, err
+	}
+	if pairwiseSubject == nil {
+		return nil, nil
+	}
+	return sr.database.GetUserById(nil, pairwiseSubject.UserId)
+}
+
+// GetSectorIdentifier returns the sector identifier of the client: the host of its
+// sector_identifier_uri or, when it has none, the host of its redirect URIs
+func (sr *SubjectResolver) GetSectorIdentifier(client *entities.Client) (string, error) {
+	if len(client.SectorIdentifierURI) > 0 {
+		parsedURI, err := url.Parse(client.SectorIdentifierURI)
+		if err != nil {
+			return "", errors.Wrap(err, "unable to parse the sector_identifier_uri")
+		}
+		return parsedURI.Host, nil
+	}
+
+	if client.RedirectURIs == nil {
+		err := sr.database.ClientLoadRedirectURIs(nil, client)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	redirectURIs := make([]string, 0, len(client.RedirectURIs))
+	for _, redirectURI := range client.RedirectURIs {
+		redirectURIs = append(redirectURIs, redirectURI.URI)
+	}
+
+	hosts := getRedirectURIHosts(redirectURIs)
+	if len(hosts) != 1 {
+		return "", errors.WithStack(fmt.Errorf("the client %v uses pairwise subjects, but it has no sector_identifier_uri "+
+			"and its redirect URIs don't share a single host", client.ClientIdentifier))
+	}
+	return hosts[0], nil
+}
+
+// ValidatePairwiseSettings checks if a client with the given sector_identifier_uri and redirect
+// URIs can use pairwise subjects. Without a sector_identifier_uri, the redirect URIs must share a
+// single host. With it, the document must be a JSON array that includes every redirect URI.
+func (sr *SubjectResolver) ValidatePairwiseSettings(ctx context.Context, sectorIdentifierURI string,
+	redirectURIs []string) error {
+
+	if len(sectorIdentifierURI) == 0 {
+		hosts := getRedirectURIHosts(redirectURIs)
+		if len(hosts) == 0 {
+			return customerrors.NewValidationError("invalid_client_metadata", "Pairwise subjects require a sector_identifier_uri or at least one redirect URI.")
+		}
+		if len(hosts) > 1 {
+			return customerrors.NewValidationError("invalid_client_metadata", "Pairwise subjects require a sector_identifier_uri when the redirect URIs have different hosts.")
+		}
+		return nil
+	}
+
+	const maxLengthSectorIdentifierURI = 512
+	parsedURI, err := url.ParseRequestURI(sectorIdentifierURI)
+	if err != nil || (parsedURI.Scheme != "https" && parsedURI.Scheme != "http") || len(parsedURI.Host) == 0 ||
+		len(sectorIdentifierURI) > maxLengthSectorIdentifierURI {
+		return customerrors.NewValidationError("invalid_client_metadata", fmt.Sprintf("Invalid sector_identifier_uri. It must be an absolute http or https URL, with up to %v characters.", maxLengthSectorIdentifierURI))
+	}
+
+	sectorRedirectURIs, err := sr.fetchSectorIdentifierDocument(ctx, sectorIdentifierURI)
+	if err != nil {
+		return customerrors.NewValidationError("invalid_client_metadata", "Unable to retrieve the sector_identifier_uri document. It must return a JSON array of redirect URIs.")
+	}
+
+	for _, redirectURI := range redirectURIs {
+		if !slices.Contains(sectorRedirectURIs, redirectURI) {
+			return customerrors.NewValidationError("invalid_client_metadata", fmt.Sprintf("The redirect URI '%v' is not included in the sector_identifier_uri document.", redirectURI))
+		}
+	}
+	return nil
+}
+
+func (sr *SubjectResolver) fetchSectorIdentifierDocument(ctx context.Context, sectorIdentifierURI string) ([]string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, sectorIdentifierURI, nil)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := sr.httpClient.Do(req)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to fetch the sector_identifier_uri")
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.WithStack(errors.Errorf("the sector_identifier_uri returned status code %v", resp.StatusCode))
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1024*1024))
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to read the sector_identifier_uri response")
+	}
+
+	var redirectURIs []string
+	err = json.Unmarshal(body, &redirectURIs)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to parse the sector_identifier_uri document")
+	}
+	return redirectURIs, nil
+}
+
+func getRedirectURIHosts(redirectURIs []string) []string {
+	hosts := []string{}
+	for _, redirectURI := range redirectURIs {
+		parsedURI, err := url.Parse(redirectURI)
+		if err != nil || len(parsedURI.Host) == 0 {
+			continue
+		}
+		if !slices.Contains(hosts, parsedURI.Host) {
+			hosts = append(hosts, parsedURI.Host)
+		}
+	}
+	return hosts
+}
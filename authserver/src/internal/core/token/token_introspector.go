@@ -0,0 +1,128 @@
+package core
+
+import (
+	"context"
+	"time"
+
+	"github.com/leodip/goiabada/internal/common"
+	"github.com/leodip/goiabada/internal/data"
+	"github.com/leodip/goiabada/internal/dtos"
+	"github.com/leodip/goiabada/internal/entities"
+	"github.com/leodip/goiabada/internal/enums"
+)
+
+type TokenIntrospector struct {
+	database    data.Database
+	tokenParser *TokenParser
+}
+
+func NewTokenIntrospector(database data.Database, tokenParser *TokenParser) *TokenIntrospector {
+	return &TokenIntrospector{
+		database:    database,
+		tokenParser: tokenParser,
+	}
+}
+
+type IntrospectTokenInput struct {
+	Client *entities.Client
+	Token  string
+}
+
+// IntrospectToken implements RFC 7662. The token_type_hint parameter is not needed
+// because our tokens are self-describing (typ claim).
+func (ti *TokenIntrospector) IntrospectToken(ctx context.Context, input *IntrospectTokenInput) (*dtos.TokenIntrospectionResponse, error) {
+
+	settings := ctx.Value(common.ContextKeySettings).(*entities.Settings)
+
+	inactive := &dtos.TokenIntrospectionResponse{
+		Active: false,
+	}
+
+	jwtToken, err := ti.tokenParser.ParseToken(ctx, input.Token, true)
+	if err != nil || !jwtToken.SignatureIsValid || jwtToken.IsExpired {
+		return inactive, nil
+	}
+
+	if jwtToken.GetStringClaim("iss") != settings.Issuer {
+		return inactive, nil
+	}
+
+	result := &dtos.TokenIntrospectionResponse{
+		Active:    true,
+		Scope:     jwtToken.GetStringClaim("scope"),
+		Sub:       jwtToken.GetStringClaim("sub"),
+		Exp:       jwtToken.GetTimeClaim("exp").Unix(),
+		Iat:       jwtToken.GetTimeClaim("iat").Unix(),
+		Sid:       jwtToken.GetStringClaim("sid"),
+		TokenType: jwtToken.GetStringClaim("typ"),
+	}
+
+	switch result.TokenType {
+	case enums.TokenTypeBearer.String():
+		result.ClientId = jwtToken.GetStringClaim("client_id")
+		cnf := map[string]string{}
+		if jkt := jwtToken.GetConfirmationClaim("jkt"); len(jkt) > 0 {
+			cnf["jkt"] = jkt
+		}
+		if certificateThumbprint := jwtToken.GetConfirmationClaim("x5t#S256"); len(certificateThumbprint) > 0 {
+			cnf["x5t#S256"] = certificateThumbprint
+		}
+		if len(cnf) > 0 {
+			result.Cnf = cnf
+		}
+		result.AuthorizationDetails = jwtToken.GetAuthorizationDetailsClaim()
+		return result, nil
+	case "Refresh", "Offline":
+		refreshToken, err := ti.database.GetRefreshTokenByJti(nil, jwtToken.GetStringClaim("jti"))
+		if err != nil {
+			return nil, err
+		}
+		if refreshToken == nil || refreshToken.Revoked {
+			return inactive, nil
+		}
+
+		now := time.Now().UTC()
+		if refreshToken.ExpiresAt.Valid && now.After(refreshToken.ExpiresAt.Time) {
+			return inactive, nil
+		}
+		if refreshToken.MaxLifetime.Valid && now.After(refreshToken.MaxLifetime.Time) {
+			return inactive, nil
+		}
+
+		if refreshToken.RefreshTokenType == "Refresh" {
+			// a normal refresh token is only active while its user session is valid
+			userSession, err := ti.database.GetUserSessionBySessionIdentifier(nil, refreshToken.SessionIdentifier)
+			if err != nil {
+				return nil, err
+			}
+			if userSession == nil ||
+				!userSession.IsValid(settings.UserSessionIdleTimeoutInSeconds, settings.UserSessionMaxLifetimeInSeconds, nil) {
+				return inactive, nil
+			}
+		}
+
+		err = ti.database.RefreshTokenLoadCode(nil, refreshToken)
+		if err != nil {
+			return nil, err
+		}
+		err = ti.database.CodeLoadClient(nil, &refreshToken.Code)
+		if err != nil {
+			return nil, err
+		}
+
+		// a client can only introspect its own refresh tokens
+		if refreshToken.Code.ClientId != input.Client.Id {
+			return inactive, nil
+		}
+
+		result.ClientId = refreshToken.Code.Client.ClientIdentifier
+		result.AuthorizationDetails, err = dtos.ParseAuthorizationDetails(refreshToken.AuthorizationDetails)
+		if err != nil {
+			return nil, err
+		}
+		return result, nil
+	default:
+		// id tokens are not meant to be introspected
+		return inactive, nil
+	}
+}
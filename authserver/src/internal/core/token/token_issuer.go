@@ -2,7 +2,6 @@ package core
 
 import (
 	"context"
-	"crypto/rsa"
 	"database/sql"
 	"fmt"
 	"strings"
@@ -24,26 +23,39 @@ import (
 )
 
 type TokenIssuer struct {
-	database    data.Database
-	tokenParser *TokenParser
+	database           data.Database
+	tokenParser        *TokenParser
+	subjectResolver    *core.SubjectResolver
+	clientKeysResolver *core.ClientKeysResolver
 }
 
-func NewTokenIssuer(database data.Database, tokenParser *TokenParser) *TokenIssuer {
+func NewTokenIssuer(database data.Database, tokenParser *TokenParser, subjectResolver *core.SubjectResolver,
+	clientKeysResolver *core.ClientKeysResolver) *TokenIssuer {
 	return &TokenIssuer{
-		database:    database,
-		tokenParser: tokenParser,
+		database:           database,
+		tokenParser:        tokenParser,
+		subjectResolver:    subjectResolver,
+		clientKeysResolver: clientKeysResolver,
 	}
 }
 
 type GenerateTokenForRefreshInput struct {
-	Code             *entities.Code
-	ScopeRequested   string
-	RefreshToken     *entities.RefreshToken
-	RefreshTokenInfo *dtos.JwtToken
+	Code                  *entities.Code
+	ScopeRequested        string
+	Resource              string
+	RefreshToken          *entities.RefreshToken
+	RefreshTokenInfo      *dtos.JwtToken
+	AuthorizationDetails  dtos.AuthorizationDetails
+	DPoPJkt               string
+	CertificateThumbprint string
 }
 
 type GenerateTokenResponseForAuthCodeInput struct {
-	Code *entities.Code
+	Code                  *entities.Code
+	Resource              string
+	AuthorizationDetails  dtos.AuthorizationDetails
+	DPoPJkt               string
+	CertificateThumbprint string
 }
 
 func (t *TokenIssuer) GenerateTokenResponseForAuthCode(ctx context.Context,
@@ -62,20 +74,15 @@ func (t *TokenIssuer) GenerateTokenResponseForAuthCode(ctx context.Context,
 	}
 
 	var tokenResponse = dtos.TokenResponse{
-		TokenType: enums.TokenTypeBearer.String(),
+		TokenType: t.getTokenType(input.DPoPJkt),
 		ExpiresIn: int64(tokenExpirationInSeconds),
 	}
 
-	keyPair, err := t.database.GetCurrentSigningKey(nil)
+	signingKey, err := core.GetCurrentSigningKey(t.database, lib.DefaultSigningAlgorithm)
 	if err != nil {
 		return nil, err
 	}
 
-	privKey, err := jwt.ParseRSAPrivateKeyFromPEM(keyPair.PrivateKeyPEM)
-	if err != nil {
-		return nil, errors.Wrap(err, "unable to parse private key from PEM")
-	}
-
 	now := time.Now().UTC()
 
 	// access_token -----------------------------------------------------------------------
@@ -100,18 +107,44 @@ func (t *TokenIssuer) GenerateTokenResponseForAuthCode(ctx context.Context,
 		return nil, err
 	}
 
-	accessTokenStr, scopeFromAccessToken, err := t.generateAccessToken(settings, input.Code, input.Code.Scope, now, privKey, keyPair.KeyIdentifier)
+	if !input.Code.Client.LegacyAccessTokenFormat {
+		err = t.loadUserPermissions(&input.Code.User)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	subject, err := t.subjectResolver.GetSubject(&input.Code.User, &input.Code.Client)
+	if err != nil {
+		return nil, err
+	}
+
+	accessTokenScope := input.Code.Scope
+	accessTokenAuthorizationDetails := input.AuthorizationDetails
+	if len(input.Resource) > 0 {
+		// the access token is restricted to the requested resource (RFC 8707)
+		accessTokenScope = core.GetScopeForResource(accessTokenScope, input.Resource)
+		accessTokenAuthorizationDetails, err = core.GetAuthorizationDetailsForResource(t.database,
+			accessTokenAuthorizationDetails, input.Resource)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	accessTokenStr, scopeFromAccessToken, err := t.generateAccessToken(settings, input.Code, subject, accessTokenScope,
+		accessTokenAuthorizationDetails, now, signingKey, input.DPoPJkt, input.CertificateThumbprint)
 	if err != nil {
 		return nil, err
 	}
 	tokenResponse.AccessToken = accessTokenStr
 	tokenResponse.Scope = scopeFromAccessToken
+	tokenResponse.AuthorizationDetails = accessTokenAuthorizationDetails
 
 	// id_token ---------------------------------------------------------------------------
 
 	scopes := strings.Split(input.Code.Scope, " ")
 	if slices.Contains(scopes, "openid") {
-		idTokenStr, err := t.generateIdToken(settings, input.Code, input.Code.Scope, now, privKey, keyPair.KeyIdentifier)
+		idTokenStr, err := t.generateIdToken(ctx, settings, input.Code, subject, input.Code.Scope, now, signingKey)
 		if err != nil {
 			return nil, err
 		}
@@ -120,7 +153,13 @@ func (t *TokenIssuer) GenerateTokenResponseForAuthCode(ctx context.Context,
 
 	// refresh_token ----------------------------------------------------------------------
 
-	refreshToken, refreshExpiresIn, err := t.generateRefreshToken(settings, input.Code, scopeFromAccessToken, now, privKey, keyPair.KeyIdentifier, nil)
+	// the refresh token keeps the full scope, so it can be used for other resources
+	refreshTokenScope := scopeFromAccessToken
+	if len(input.Resource) > 0 {
+		refreshTokenScope = input.Code.Scope
+	}
+
+	refreshToken, refreshExpiresIn, err := t.generateRefreshToken(settings, input.Code, subject, refreshTokenScope, now, signingKey, nil, input.DPoPJkt)
 	if err != nil {
 		return nil, err
 	}
@@ -130,13 +169,13 @@ func (t *TokenIssuer) GenerateTokenResponseForAuthCode(ctx context.Context,
 	return &tokenResponse, nil
 }
 
-func (t *TokenIssuer) generateAccessToken(settings *entities.Settings, code *entities.Code, scope string,
-	now time.Time, signingKey *rsa.PrivateKey, keyIdentifier string) (string, string, error) {
+func (t *TokenIssuer) generateAccessToken(settings *entities.Settings, code *entities.Code, subject string, scope string,
+	authorizationDetails dtos.AuthorizationDetails, now time.Time, signingKey *lib.SigningKey, dpopJkt string, certificateThumbprint string) (string, string, error) {
 
 	claims := make(jwt.MapClaims)
 
 	claims["iss"] = settings.Issuer
-	claims["sub"] = code.User.Subject
+	claims["sub"] = subject
 	claims["iat"] = now.Unix()
 	claims["auth_time"] = code.AuthenticatedAt.Unix()
 	claims["jti"] = uuid.New().String()
@@ -166,6 +205,10 @@ func (t *TokenIssuer) generateAccessToken(settings *entities.Settings, code *ent
 			audCollection = append(audCollection, parts[0])
 		}
 	}
+	audCollection, err := t.addAuthorizationDetailsResources(audCollection, authorizationDetails)
+	if err != nil {
+		return "", "", err
+	}
 	switch {
 	case len(audCollection) == 0:
 		return "", "", errors.WithStack(fmt.Errorf("unable to generate an access token without an audience. scope: '%v'", scope))
@@ -196,14 +239,27 @@ func (t *TokenIssuer) generateAccessToken(settings *entities.Settings, code *ent
 	if len(code.Nonce) > 0 {
 		claims["nonce"] = code.Nonce
 	}
+	if len(authorizationDetails) > 0 {
+		claims["authorization_details"] = authorizationDetails
+	}
 
 	includeOpenIDConnectClaimsInAccessToken := settings.IncludeOpenIDConnectClaimsInAccessToken
 	if code.Client.IncludeOpenIDConnectClaimsInAccessToken != enums.ThreeStateSettingDefault.String() {
 		includeOpenIDConnectClaimsInAccessToken = code.Client.IncludeOpenIDConnectClaimsInAccessToken == enums.ThreeStateSettingOn.String()
 	}
 
+	claimsRequest, err := dtos.ParseClaimsRequest(code.Claims)
+	if err != nil {
+		return "", "", err
+	}
+
 	if slices.Contains(scopes, "openid") && includeOpenIDConnectClaimsInAccessToken {
-		t.addOpenIdConnectClaims(claims, code)
+		t.addOpenIdConnectClaims(claims, code, claimsRequest)
+	}
+
+	// the claims requested for the userinfo response travel with the access token
+	if len(claimsRequest.UserInfo) > 0 {
+		claims["userinfo_claims"] = claimsRequest.UserInfo
 	}
 
 	// groups
@@ -240,22 +296,27 @@ func (t *TokenIssuer) generateAccessToken(settings *entities.Settings, code *ent
 		}
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
-	token.Header["kid"] = keyIdentifier
-	accessToken, err := token.SignedString(signingKey)
+	if !code.Client.LegacyAccessTokenFormat {
+		t.addRolesAndEntitlements(claims, &code.User, scopes)
+	}
+
+	t.addConfirmationClaim(claims, dpopJkt, certificateThumbprint)
+
+	token := t.newAccessToken(claims, &code.Client, signingKey)
+	accessToken, err := token.SignedString(signingKey.PrivateKey)
 	if err != nil {
 		return "", "", errors.Wrap(err, "unable to sign access_token")
 	}
 	return accessToken, scope, nil
 }
 
-func (t *TokenIssuer) generateIdToken(settings *entities.Settings, code *entities.Code, scope string,
-	now time.Time, signingKey *rsa.PrivateKey, keyIdentifier string) (string, error) {
+func (t *TokenIssuer) generateIdToken(ctx context.Context, settings *entities.Settings, code *entities.Code, subject string, scope string,
+	now time.Time, signingKey *lib.SigningKey) (string, error) {
 
 	claims := make(jwt.MapClaims)
 
 	claims["iss"] = settings.Issuer
-	claims["sub"] = code.User.Subject
+	claims["sub"] = subject
 	claims["iat"] = now.Unix()
 	claims["auth_time"] = code.AuthenticatedAt.Unix()
 	claims["jti"] = uuid.New().String()
@@ -277,7 +338,11 @@ func (t *TokenIssuer) generateIdToken(settings *entities.Settings, code *entitie
 	if len(code.Nonce) > 0 {
 		claims["nonce"] = code.Nonce
 	}
-	t.addOpenIdConnectClaims(claims, code)
+	claimsRequest, err := dtos.ParseClaimsRequest(code.Claims)
+	if err != nil {
+		return "", err
+	}
+	t.addOpenIdConnectClaims(claims, code, claimsRequest)
 
 	// groups
 	if slices.Contains(scopes, "groups") {
@@ -313,17 +378,30 @@ func (t *TokenIssuer) generateIdToken(settings *entities.Settings, code *entitie
 		}
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
-	token.Header["kid"] = keyIdentifier
-	idToken, err := token.SignedString(signingKey)
+	idTokenSigningKey, err := t.getIdTokenSigningKey(&code.Client, signingKey)
+	if err != nil {
+		return "", err
+	}
+
+	token := idTokenSigningKey.NewToken(claims)
+	idToken, err := token.SignedString(idTokenSigningKey.PrivateKey)
 	if err != nil {
 		return "", errors.Wrap(err, "unable to sign id_token")
 	}
+
+	// the client can require the id token to be encrypted (signed, then encrypted)
+	alg, enc := core.GetIdTokenEncryption(&code.Client)
+	if len(alg) > 0 {
+		idToken, err = t.clientKeysResolver.EncryptToClient(ctx, &code.Client, idToken, alg, enc)
+		if err != nil {
+			return "", errors.Wrap(err, "unable to encrypt id_token")
+		}
+	}
 	return idToken, nil
 }
 
-func (t *TokenIssuer) generateRefreshToken(settings *entities.Settings, code *entities.Code, scope string,
-	now time.Time, signingKey *rsa.PrivateKey, keyIdentifier string, refreshToken *entities.RefreshToken) (string, int64, error) {
+func (t *TokenIssuer) generateRefreshToken(settings *entities.Settings, code *entities.Code, subject string, scope string,
+	now time.Time, signingKey *lib.SigningKey, refreshToken *entities.RefreshToken, dpopJkt string) (string, int64, error) {
 
 	claims := make(jwt.MapClaims)
 
@@ -332,7 +410,7 @@ func (t *TokenIssuer) generateRefreshToken(settings *entities.Settings, code *en
 	claims["iat"] = now.Unix()
 	claims["jti"] = jti
 	claims["aud"] = settings.Issuer
-	claims["sub"] = code.User.Subject
+	claims["sub"] = subject
 
 	scopes := strings.Split(scope, " ")
 
@@ -389,14 +467,18 @@ func (t *TokenIssuer) generateRefreshToken(settings *entities.Settings, code *en
 		RefreshTokenType: claims["typ"].(string),
 		Scope:            claims["scope"].(string),
 		Revoked:          false,
+		DPoPJkt:          dpopJkt,
 	}
 
+	// the refresh token keeps all the authorization details of the grant
 	if refreshToken != nil {
 		refreshTokenEntity.PreviousRefreshTokenJti = refreshToken.RefreshTokenJti
 		refreshTokenEntity.FirstRefreshTokenJti = refreshToken.FirstRefreshTokenJti
+		refreshTokenEntity.AuthorizationDetails = refreshToken.AuthorizationDetails
 	} else {
 		// first refresh token issued
 		refreshTokenEntity.FirstRefreshTokenJti = jti
+		refreshTokenEntity.AuthorizationDetails = code.AuthorizationDetails
 	}
 
 	if !slices.Contains(scopes, "offline_access") {
@@ -410,9 +492,8 @@ func (t *TokenIssuer) generateRefreshToken(settings *entities.Settings, code *en
 		return "", 0, err
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
-	token.Header["kid"] = keyIdentifier
-	rt, err := token.SignedString(signingKey)
+	token := signingKey.NewToken(claims)
+	rt, err := token.SignedString(signingKey.PrivateKey)
 	if err != nil {
 		return "", 0, errors.Wrap(err, "unable to sign refresh_token")
 	}
@@ -460,26 +541,22 @@ func (t *TokenIssuer) getRefreshTokenMaxLifetime(refreshTokenType string, now ti
 }
 
 func (t *TokenIssuer) GenerateTokenResponseForClientCred(ctx context.Context, client *entities.Client,
-	scope string) (*dtos.TokenResponse, error) {
+	scope string, authorizationDetails dtos.AuthorizationDetails, dpopJkt string, certificateThumbprint string) (*dtos.TokenResponse, error) {
 
 	settings := ctx.Value(common.ContextKeySettings).(*entities.Settings)
 
 	var tokenResponse = dtos.TokenResponse{
-		TokenType: "Bearer",
-		ExpiresIn: int64(settings.TokenExpirationInSeconds),
-		Scope:     scope,
+		TokenType:            t.getTokenType(dpopJkt),
+		ExpiresIn:            int64(settings.TokenExpirationInSeconds),
+		Scope:                scope,
+		AuthorizationDetails: authorizationDetails,
 	}
 
-	keyPair, err := t.database.GetCurrentSigningKey(nil)
+	signingKey, err := core.GetCurrentSigningKey(t.database, lib.DefaultSigningAlgorithm)
 	if err != nil {
 		return nil, err
 	}
 
-	privKey, err := jwt.ParseRSAPrivateKeyFromPEM(keyPair.PrivateKeyPEM)
-	if err != nil {
-		return nil, errors.Wrap(err, "unable to parse private key from PEM")
-	}
-
 	now := time.Now().UTC()
 	claims := make(jwt.MapClaims)
 	scopes := strings.Split(scope, " ")
@@ -501,6 +578,10 @@ func (t *TokenIssuer) GenerateTokenResponseForClientCred(ctx context.Context, cl
 			audCollection = append(audCollection, parts[0])
 		}
 	}
+	audCollection, err = t.addAuthorizationDetailsResources(audCollection, authorizationDetails)
+	if err != nil {
+		return nil, err
+	}
 	switch {
 	case len(audCollection) == 0:
 		return nil, errors.WithStack(fmt.Errorf("unable to generate an access token without an audience. scope: '%v'", scope))
@@ -512,10 +593,113 @@ func (t *TokenIssuer) GenerateTokenResponseForClientCred(ctx context.Context, cl
 	claims["typ"] = enums.TokenTypeBearer.String()
 	claims["exp"] = now.Add(time.Duration(time.Second * time.Duration(settings.TokenExpirationInSeconds))).Unix()
 	claims["scope"] = scope
+	if len(authorizationDetails) > 0 {
+		claims["authorization_details"] = authorizationDetails
+	}
+
+	t.addConfirmationClaim(claims, dpopJkt, certificateThumbprint)
 
-	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
-	token.Header["kid"] = keyPair.KeyIdentifier
-	accessToken, err := token.SignedString(privKey)
+	token := t.newAccessToken(claims, client, signingKey)
+	accessToken, err := token.SignedString(signingKey.PrivateKey)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to sign access_token")
+	}
+	tokenResponse.AccessToken = accessToken
+	return &tokenResponse, nil
+}
+
+type GenerateTokenResponseForTokenExchangeInput struct {
+	Client                *entities.Client
+	User                  *entities.User
+	Scope                 string
+	SubjectTokenInfo      *dtos.JwtToken
+	DPoPJkt               string
+	CertificateThumbprint string
+}
+
+// GenerateTokenResponseForTokenExchange issues an access token on behalf of the user from the
+// subject token. The requesting client is recorded as the actor, in the act claim (RFC 8693).
+func (t *TokenIssuer) GenerateTokenResponseForTokenExchange(ctx context.Context,
+	input *GenerateTokenResponseForTokenExchangeInput) (*dtos.TokenResponse, error) {
+
+	settings := ctx.Value(common.ContextKeySettings).(*entities.Settings)
+
+	tokenExpirationInSeconds := settings.TokenExpirationInSeconds
+	if input.Client.TokenExpirationInSeconds > 0 {
+		tokenExpirationInSeconds = input.Client.TokenExpirationInSeconds
+	}
+
+	// the new token can't outlive the subject token
+	now := time.Now().UTC()
+	exp := now.Add(time.Duration(time.Second * time.Duration(tokenExpirationInSeconds)))
+	subjectTokenExp := input.SubjectTokenInfo.GetTimeClaim("exp")
+	if !subjectTokenExp.IsZero() && subjectTokenExp.Before(exp) {
+		exp = subjectTokenExp
+	}
+
+	var tokenResponse = dtos.TokenResponse{
+		TokenType:       t.getTokenType(input.DPoPJkt),
+		ExpiresIn:       exp.Unix() - now.Unix(),
+		Scope:           input.Scope,
+		IssuedTokenType: constants.TokenTypeAccessTokenUrn,
+	}
+
+	signingKey, err := core.GetCurrentSigningKey(t.database, lib.DefaultSigningAlgorithm)
+	if err != nil {
+		return nil, err
+	}
+
+	subject, err := t.subjectResolver.GetSubject(input.User, input.Client)
+	if err != nil {
+		return nil, err
+	}
+
+	claims := make(jwt.MapClaims)
+
+	claims["iss"] = settings.Issuer
+	claims["sub"] = subject
+	claims["iat"] = now.Unix()
+	claims["jti"] = uuid.New().String()
+
+	// keep the authentication context of the original token
+	for _, claimName := range []string{"auth_time", "acr", "amr", "sid"} {
+		if input.SubjectTokenInfo.Claims[claimName] != nil {
+			claims[claimName] = input.SubjectTokenInfo.Claims[claimName]
+		}
+	}
+
+	audCollection := []string{}
+	for _, scope := range strings.Split(input.Scope, " ") {
+		parts := strings.Split(scope, ":")
+		if !slices.Contains(audCollection, parts[0]) {
+			audCollection = append(audCollection, parts[0])
+		}
+	}
+	switch {
+	case len(audCollection) == 0:
+		return nil, errors.WithStack(fmt.Errorf("unable to generate an access token without an audience. scope: '%v'", input.Scope))
+	case len(audCollection) == 1:
+		claims["aud"] = audCollection[0]
+	default:
+		claims["aud"] = audCollection
+	}
+	claims["typ"] = enums.TokenTypeBearer.String()
+	claims["exp"] = exp.Unix()
+	claims["scope"] = input.Scope
+
+	// if the subject token was itself obtained through token exchange, nest the previous actor
+	act := map[string]interface{}{
+		"sub": input.Client.ClientIdentifier,
+	}
+	if input.SubjectTokenInfo.Claims["act"] != nil {
+		act["act"] = input.SubjectTokenInfo.Claims["act"]
+	}
+	claims["act"] = act
+
+	t.addConfirmationClaim(claims, input.DPoPJkt, input.CertificateThumbprint)
+
+	token := t.newAccessToken(claims, input.Client, signingKey)
+	accessToken, err := token.SignedString(signingKey.PrivateKey)
 	if err != nil {
 		return nil, errors.Wrap(err, "unable to sign access_token")
 	}
@@ -543,20 +727,15 @@ func (t *TokenIssuer) GenerateTokenResponseForRefresh(ctx context.Context, input
 	}
 
 	var tokenResponse = dtos.TokenResponse{
-		TokenType: enums.TokenTypeBearer.String(),
+		TokenType: t.getTokenType(input.DPoPJkt),
 		ExpiresIn: int64(tokenExpirationInSeconds),
 	}
 
-	keyPair, err := t.database.GetCurrentSigningKey(nil)
+	signingKey, err := core.GetCurrentSigningKey(t.database, lib.DefaultSigningAlgorithm)
 	if err != nil {
 		return nil, err
 	}
 
-	privKey, err := jwt.ParseRSAPrivateKeyFromPEM(keyPair.PrivateKeyPEM)
-	if err != nil {
-		return nil, errors.Wrap(err, "unable to parse private key from PEM")
-	}
-
 	now := time.Now().UTC()
 
 	// access_token -----------------------------------------------------------------------
@@ -581,18 +760,44 @@ func (t *TokenIssuer) GenerateTokenResponseForRefresh(ctx context.Context, input
 		return nil, err
 	}
 
-	accessTokenStr, scopeFromAccessToken, err := t.generateAccessToken(settings, input.Code, scopeToUse, now, privKey, keyPair.KeyIdentifier)
+	if !input.Code.Client.LegacyAccessTokenFormat {
+		err = t.loadUserPermissions(&input.Code.User)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	subject, err := t.subjectResolver.GetSubject(&input.Code.User, &input.Code.Client)
+	if err != nil {
+		return nil, err
+	}
+
+	accessTokenScope := scopeToUse
+	accessTokenAuthorizationDetails := input.AuthorizationDetails
+	if len(input.Resource) > 0 {
+		// one access token per resource (RFC 8707)
+		accessTokenScope = core.GetScopeForResource(accessTokenScope, input.Resource)
+		accessTokenAuthorizationDetails, err = core.GetAuthorizationDetailsForResource(t.database,
+			accessTokenAuthorizationDetails, input.Resource)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	accessTokenStr, scopeFromAccessToken, err := t.generateAccessToken(settings, input.Code, subject, accessTokenScope,
+		accessTokenAuthorizationDetails, now, signingKey, input.DPoPJkt, input.CertificateThumbprint)
 	if err != nil {
 		return nil, err
 	}
 	tokenResponse.AccessToken = accessTokenStr
 	tokenResponse.Scope = scopeFromAccessToken
+	tokenResponse.AuthorizationDetails = accessTokenAuthorizationDetails
 
 	// id_token ---------------------------------------------------------------------------
 
 	scopes := strings.Split(scopeToUse, " ")
 	if slices.Contains(scopes, "openid") {
-		idTokenStr, err := t.generateIdToken(settings, input.Code, scopeToUse, now, privKey, keyPair.KeyIdentifier)
+		idTokenStr, err := t.generateIdToken(ctx, settings, input.Code, subject, scopeToUse, now, signingKey)
 		if err != nil {
 			return nil, err
 		}
@@ -601,7 +806,12 @@ func (t *TokenIssuer) GenerateTokenResponseForRefresh(ctx context.Context, input
 
 	// refresh_token ----------------------------------------------------------------------
 
-	refreshToken, refreshExpiresIn, err := t.generateRefreshToken(settings, input.Code, scopeFromAccessToken, now, privKey, keyPair.KeyIdentifier, input.RefreshToken)
+	refreshTokenScope := scopeFromAccessToken
+	if len(input.Resource) > 0 {
+		refreshTokenScope = scopeToUse
+	}
+
+	refreshToken, refreshExpiresIn, err := t.generateRefreshToken(settings, input.Code, subject, refreshTokenScope, now, signingKey, input.RefreshToken, input.DPoPJkt)
 	if err != nil {
 		return nil, err
 	}
@@ -611,45 +821,129 @@ func (t *TokenIssuer) GenerateTokenResponseForRefresh(ctx context.Context, input
 	return &tokenResponse, nil
 }
 
-func (tm *TokenIssuer) addOpenIdConnectClaims(claims jwt.MapClaims, code *entities.Code) {
+// getIdTokenSigningKey returns the key that signs the id tokens of the client, according to
+// its id_token_signed_response_alg
+func (t *TokenIssuer) getIdTokenSigningKey(client *entities.Client, defaultSigningKey *lib.SigningKey) (*lib.SigningKey, error) {
+	algorithm := core.GetIdTokenSigningAlgorithm(client)
+	if algorithm == defaultSigningKey.Method.Alg() {
+		return defaultSigningKey, nil
+	}
+	return core.GetCurrentSigningKey(t.database, algorithm)
+}
+
+// newAccessToken creates the access token. It always has the client_id claim, for the introspection
+// and userinfo endpoints. Unless the client keeps the legacy format, it follows the JWT profile for
+// OAuth 2.0 access tokens (RFC 9068), with the at+jwt type.
+func (t *TokenIssuer) newAccessToken(claims jwt.MapClaims, client *entities.Client, signingKey *lib.SigningKey) *jwt.Token {
+	claims["client_id"] = client.ClientIdentifier
+	token := signingKey.NewToken(claims)
+	if !client.LegacyAccessTokenFormat {
+		token.Header["typ"] = "at+jwt"
+	}
+	return token
+}
+
+// loadUserPermissions loads the permissions of the user, both assigned directly and through
+// group memberships, along with their resources
+func (t *TokenIssuer) loadUserPermissions(user *entities.User) error {
+	err := t.database.UserLoadPermissions(nil, user)
+	if err != nil {
+		return err
+	}
+
+	err = t.database.PermissionsLoadResources(nil, user.Permissions)
+	if err != nil {
+		return err
+	}
 
-	scopes := strings.Split(code.Scope, " ")
+	err = t.database.GroupsLoadPermissions(nil, user.Groups)
+	if err != nil {
+		return err
+	}
 
-	if slices.Contains(scopes, "profile") {
-		tm.addClaimIfNotEmpty(claims, "name", code.User.GetFullName())
-		tm.addClaimIfNotEmpty(claims, "given_name", code.User.GivenName)
-		tm.addClaimIfNotEmpty(claims, "middle_name", code.User.MiddleName)
-		tm.addClaimIfNotEmpty(claims, "family_name", code.User.FamilyName)
-		tm.addClaimIfNotEmpty(claims, "nickname", code.User.Nickname)
-		tm.addClaimIfNotEmpty(claims, "preferred_username", code.User.Username)
-		claims["profile"] = fmt.Sprintf("%v/account/profile", lib.GetBaseUrl())
-		tm.addClaimIfNotEmpty(claims, "website", code.User.Website)
-		tm.addClaimIfNotEmpty(claims, "gender", code.User.Gender)
-		if code.User.BirthDate.Valid {
-			claims["birthdate"] = code.User.BirthDate.Time.Format("2006-01-02")
+	for idx := range user.Groups {
+		err = t.database.PermissionsLoadResources(nil, user.Groups[idx].Permissions)
+		if err != nil {
+			return err
 		}
-		tm.addClaimIfNotEmpty(claims, "zoneinfo", code.User.ZoneInfo)
-		tm.addClaimIfNotEmpty(claims, "locale", code.User.Locale)
-		claims["updated_at"] = code.User.UpdatedAt.Time.UTC().Unix()
 	}
+	return nil
+}
 
-	if slices.Contains(scopes, "email") {
-		tm.addClaimIfNotEmpty(claims, "email", code.User.Email)
-		claims["email_verified"] = code.User.EmailVerified
+// addRolesAndEntitlements adds the roles (permissions held through group memberships) and the
+// entitlements (permissions assigned directly to the user) claims of RFC 9068. Only the
+// permissions granted in the scope are included.
+func (t *TokenIssuer) addRolesAndEntitlements(claims jwt.MapClaims, user *entities.User, scopes []string) {
+	roles := []string{}
+	for _, group := range user.Groups {
+		for _, permission := range group.Permissions {
+			scope := permission.Resource.ResourceIdentifier + ":" + permission.PermissionIdentifier
+			if slices.Contains(scopes, scope) && !slices.Contains(roles, scope) {
+				roles = append(roles, scope)
+			}
+		}
+	}
+	if len(roles) > 0 {
+		claims["roles"] = roles
 	}
 
-	if slices.Contains(scopes, "address") && code.User.HasAddress() {
-		claims["address"] = code.User.GetAddressClaim()
+	entitlements := []string{}
+	for _, permission := range user.Permissions {
+		scope := permission.Resource.ResourceIdentifier + ":" + permission.PermissionIdentifier
+		if slices.Contains(scopes, scope) && !slices.Contains(entitlements, scope) {
+			entitlements = append(entitlements, scope)
+		}
+	}
+	if len(entitlements) > 0 {
+		claims["entitlements"] = entitlements
 	}
+}
+
+// addAuthorizationDetailsResources adds the resources of the authorization details types to the
+// audience of the access token
+func (t *TokenIssuer) addAuthorizationDetailsResources(audCollection []string,
+	authorizationDetails dtos.AuthorizationDetails) ([]string, error) {
 
-	if slices.Contains(scopes, "phone") {
-		tm.addClaimIfNotEmpty(claims, "phone_number", code.User.PhoneNumber)
-		claims["phone_number_verified"] = code.User.PhoneNumberVerified
+	resourceIdentifiers, err := core.GetAuthorizationDetailsResources(t.database, authorizationDetails)
+	if err != nil {
+		return nil, err
+	}
+	for _, resourceIdentifier := range resourceIdentifiers {
+		if !slices.Contains(audCollection, resourceIdentifier) {
+			audCollection = append(audCollection, resourceIdentifier)
+		}
+	}
+	return audCollection, nil
+}
+
+// getTokenType returns the token_type of the token response. Access tokens
+// bound to a DPoP key (RFC 9449) are of type DPoP.
+func (t *TokenIssuer) getTokenType(dpopJkt string) string {
+	if len(dpopJkt) > 0 {
+		return enums.TokenTypeDPoP.String()
+	}
+	return enums.TokenTypeBearer.String()
+}
+
+// addConfirmationClaim binds the access token to the thumbprint of the DPoP key (RFC 9449)
+// and/or to the thumbprint of the client certificate (RFC 8705)
+func (t *TokenIssuer) addConfirmationClaim(claims jwt.MapClaims, dpopJkt string, certificateThumbprint string) {
+	cnf := map[string]string{}
+	if len(dpopJkt) > 0 {
+		cnf["jkt"] = dpopJkt
+	}
+	if len(certificateThumbprint) > 0 {
+		cnf["x5t#S256"] = certificateThumbprint
+	}
+	if len(cnf) > 0 {
+		claims["cnf"] = cnf
 	}
 }
 
-func (tm *TokenIssuer) addClaimIfNotEmpty(claims jwt.MapClaims, claimName string, claimValue string) {
-	if len(strings.TrimSpace(claimValue)) > 0 {
+// addOpenIdConnectClaims adds the claims about the user released by the scope of the code, and
+// the claims requested individually for the id token with the claims parameter
+func (tm *TokenIssuer) addOpenIdConnectClaims(claims jwt.MapClaims, code *entities.Code, claimsRequest *dtos.ClaimsRequest) {
+	for claimName, claimValue := range core.GetUserClaims(&code.User, code.Scope, claimsRequest.IdToken) {
 		claims[claimName] = claimValue
 	}
 }
@@ -5,6 +5,7 @@ import (
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/leodip/goiabada/internal/core"
 	"github.com/leodip/goiabada/internal/data"
 	"github.com/leodip/goiabada/internal/dtos"
 )
@@ -21,15 +22,7 @@ func NewTokenParser(database data.Database) *TokenParser {
 
 func (tp *TokenParser) ParseTokenResponse(ctx context.Context, tokenResponse *dtos.TokenResponse) (*dtos.JwtInfo, error) {
 
-	keyPair, err := tp.database.GetCurrentSigningKey(nil)
-	if err != nil {
-		return nil, err
-	}
-
-	pubKey, err := jwt.ParseRSAPublicKeyFromPEM(keyPair.PublicKeyPEM)
-	if err != nil {
-		return nil, err
-	}
+	keyFunc := core.GetVerificationKeyFunc(tp.database)
 
 	result := &dtos.JwtInfo{
 		TokenResponse: *tokenResponse,
@@ -41,9 +34,7 @@ func (tp *TokenParser) ParseTokenResponse(ctx context.Context, tokenResponse *dt
 			TokenBase64: tokenResponse.AccessToken,
 		}
 
-		token, err := jwt.ParseWithClaims(tokenResponse.AccessToken, claimsAccessToken, func(token *jwt.Token) (interface{}, error) {
-			return pubKey, nil
-		})
+		token, err := jwt.ParseWithClaims(tokenResponse.AccessToken, claimsAccessToken, keyFunc)
 		if err != nil {
 			return nil, err
 		}
@@ -65,9 +56,7 @@ func (tp *TokenParser) ParseTokenResponse(ctx context.Context, tokenResponse *dt
 			TokenBase64: tokenResponse.IdToken,
 		}
 
-		token, err := jwt.ParseWithClaims(tokenResponse.IdToken, claimsIdToken, func(token *jwt.Token) (interface{}, error) {
-			return pubKey, nil
-		})
+		token, err := jwt.ParseWithClaims(tokenResponse.IdToken, claimsIdToken, keyFunc)
 		if err != nil {
 			return nil, err
 		}
@@ -89,9 +78,7 @@ func (tp *TokenParser) ParseTokenResponse(ctx context.Context, tokenResponse *dt
 			TokenBase64: tokenResponse.RefreshToken,
 		}
 
-		token, err := jwt.ParseWithClaims(tokenResponse.RefreshToken, claimsRefreshToken, func(token *jwt.Token) (interface{}, error) {
-			return pubKey, nil
-		})
+		token, err := jwt.ParseWithClaims(tokenResponse.RefreshToken, claimsRefreshToken, keyFunc)
 		if err != nil {
 			return nil, err
 		}
@@ -111,15 +98,7 @@ func (tp *TokenParser) ParseTokenResponse(ctx context.Context, tokenResponse *dt
 }
 
 func (tp *TokenParser) ParseToken(ctx context.Context, token string, validateClaims bool) (*dtos.JwtToken, error) {
-	keyPair, err := tp.database.GetCurrentSigningKey(nil)
-	if err != nil {
-		return nil, err
-	}
-
-	pubKey, err := jwt.ParseRSAPublicKeyFromPEM(keyPair.PublicKeyPEM)
-	if err != nil {
-		return nil, err
-	}
+	keyFunc := core.GetVerificationKeyFunc(tp.database)
 
 	result := &dtos.JwtToken{
 		TokenBase64: token,
@@ -128,9 +107,7 @@ func (tp *TokenParser) ParseToken(ctx context.Context, token string, validateCla
 	if len(token) > 0 {
 		claims := jwt.MapClaims{}
 
-		token, err := jwt.ParseWithClaims(token, claims, func(token *jwt.Token) (interface{}, error) {
-			return pubKey, nil
-		})
+		token, err := jwt.ParseWithClaims(token, claims, keyFunc)
 		if err != nil {
 			return nil, err
 		}
@@ -2,26 +2,61 @@ package core
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"log/slog"
+	"net/url"
 	"regexp"
+	"strconv"
 	"strings"
+	"time"
 
 	"slices"
 
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/leodip/goiabada/internal/common"
 	"github.com/leodip/goiabada/internal/constants"
 	"github.com/leodip/goiabada/internal/core"
 	"github.com/leodip/goiabada/internal/customerrors"
 	"github.com/leodip/goiabada/internal/data"
+	"github.com/leodip/goiabada/internal/dtos"
+	"github.com/leodip/goiabada/internal/entities"
+	"github.com/leodip/goiabada/internal/enums"
+	"github.com/leodip/goiabada/internal/lib"
+	"github.com/pkg/errors"
 )
 
+// PromptValuesSupported are the values accepted in the prompt parameter of the authorize endpoint
+var PromptValuesSupported = []string{"none", "login", "consent", "select_account"}
+
+// ResponseModesSupported are the values accepted in the response_mode parameter of the authorize endpoint.
+// The ones ending in jwt wrap the response parameters in a signed JWT (JARM).
+var ResponseModesSupported = []string{"query", "fragment", "form_post", "query.jwt", "fragment.jwt", "form_post.jwt", "jwt"}
+
+// RequestObjectSigningAlgValuesSupported are the algorithms accepted for signed request objects
+var RequestObjectSigningAlgValuesSupported = []string{"RS256", "RS384", "RS512", "PS256", "PS384", "PS512", "ES256", "ES384", "ES512"}
+
 type AuthorizeValidator struct {
-	database data.Database
+	database           data.Database
+	clientKeysResolver *core.ClientKeysResolver
+	subjectResolver    *core.SubjectResolver
 }
 
 type ValidateClientAndRedirectURIInput struct {
-	RequestId   string
-	ClientId    string
-	RedirectURI string
+	RequestId           string
+	ClientId            string
+	RedirectURI         string
+	IsPushedAuthRequest bool
+}
+
+type ValidateRequestURIInput struct {
+	ClientId   string
+	RequestURI string
+}
+
+type ValidateRequestObjectInput struct {
+	ClientId      string
+	RequestObject string
 }
 
 type ValidateRequestInput struct {
@@ -29,11 +64,31 @@ type ValidateRequestInput struct {
 	CodeChallengeMethod string
 	CodeChallenge       string
 	ResponseMode        string
+	Prompt              string
+	MaxAge              string
 }
 
-func NewAuthorizeValidator(database data.Database) *AuthorizeValidator {
+type ValidateResourcesInput struct {
+	Resources []string
+	Scope     string
+}
+
+type ValidateClaimsRequestInput struct {
+	Claims string
+	Scope  string
+}
+
+type ValidateIdTokenHintInput struct {
+	ClientId    string
+	IdTokenHint string
+}
+
+func NewAuthorizeValidator(database data.Database, clientKeysResolver *core.ClientKeysResolver,
+	subjectResolver *core.SubjectResolver) *AuthorizeValidator {
 	return &AuthorizeValidator{
-		database: database,
+		database:           database,
+		clientKeysResolver: clientKeysResolver,
+		subjectResolver:    subjectResolver,
 	}
 }
 
@@ -94,6 +149,36 @@ func (val *AuthorizeValidator) ValidateScopes(ctx context.Context, scope string)
 	return nil
 }
 
+// ValidateResources checks the resource parameters of the authorization request (RFC 8707).
+// Every resource:permission scope must belong to one of the requested resources.
+func (val *AuthorizeValidator) ValidateResources(ctx context.Context, input *ValidateResourcesInput) error {
+
+	for _, resourceIdentifier := range input.Resources {
+		res, err := val.database.GetResourceByResourceIdentifier(nil, resourceIdentifier)
+		if err != nil {
+			return err
+		}
+		if res == nil {
+			return customerrors.NewValidationError("invalid_target", fmt.Sprintf("The resource '%v' is not recognized.", resourceIdentifier))
+		}
+	}
+
+	if len(input.Resources) == 0 {
+		return nil
+	}
+
+	for _, scopeStr := range strings.Fields(input.Scope) {
+		if core.IsIdTokenScope(scopeStr) {
+			continue
+		}
+		parts := strings.Split(scopeStr, ":")
+		if !slices.Contains(input.Resources, parts[0]) {
+			return customerrors.NewValidationError("invalid_scope", fmt.Sprintf("Scope '%v' does not belong to any of the requested resources.", scopeStr))
+		}
+	}
+	return nil
+}
+
 func (val *AuthorizeValidator) ValidateClientAndRedirectURI(ctx context.Context, input *ValidateClientAndRedirectURIInput) error {
 	if len(input.ClientId) == 0 {
 		return customerrors.NewValidationError("", "The client_id parameter is missing.")
@@ -112,6 +197,9 @@ func (val *AuthorizeValidator) ValidateClientAndRedirectURI(ctx context.Context,
 	if !client.AuthorizationCodeEnabled {
 		return customerrors.NewValidationError("", "The client associated with the provided client_id does not support authorization code flow.")
 	}
+	if client.PARRequired && !input.IsPushedAuthRequest {
+		return customerrors.NewValidationError("", "The client associated with the provided client_id requires pushed authorization requests (PAR). Please provide a request_uri obtained from the PAR endpoint.")
+	}
 
 	if len(input.RedirectURI) == 0 {
 		return customerrors.NewValidationError("", "The redirect_uri parameter is missing.")
@@ -149,9 +237,216 @@ func (val *AuthorizeValidator) ValidateRequest(ctx context.Context, input *Valid
 	}
 
 	if len(input.ResponseMode) > 0 {
-		if !slices.Contains([]string{"query", "fragment", "form_post"}, input.ResponseMode) {
-			return customerrors.NewValidationError("invalid_request", "Please use 'query,' 'fragment,' or 'form_post' as the response_mode value.")
+		if !slices.Contains(ResponseModesSupported, input.ResponseMode) {
+			return customerrors.NewValidationError("invalid_request", "Please use 'query', 'fragment', 'form_post', 'query.jwt', 'fragment.jwt', "+
+				"'form_post.jwt' or 'jwt' as the response_mode value.")
+		}
+	}
+
+	if len(input.Prompt) > 0 {
+		promptValues := strings.Fields(input.Prompt)
+		for _, prompt := range promptValues {
+			if !slices.Contains(PromptValuesSupported, prompt) {
+				return customerrors.NewValidationError("invalid_request", fmt.Sprintf("Invalid prompt value: '%v'. Supported values are 'none', 'login', 'consent' and 'select_account'.", prompt))
+			}
+		}
+		if slices.Contains(promptValues, "none") && len(promptValues) > 1 {
+			return customerrors.NewValidationError("invalid_request", "The prompt value 'none' can't be combined with other values.")
+		}
+	}
+
+	if len(input.MaxAge) > 0 {
+		maxAge, err := strconv.Atoi(input.MaxAge)
+		if err != nil || maxAge < 0 {
+			return customerrors.NewValidationError("invalid_request", "The max_age parameter must be a non-negative integer, in seconds.")
 		}
 	}
 	return nil
 }
+
+// ValidateClaimsRequest checks the claims parameter of the authorization request (OpenID Connect
+// Core 1.0, section 5.5), and returns the parsed request. Claims that the server doesn't know are
+// kept, and later ignored.
+func (val *AuthorizeValidator) ValidateClaimsRequest(ctx context.Context, input *ValidateClaimsRequestInput) (*dtos.ClaimsRequest, error) {
+	claimsRequest, err := dtos.ParseClaimsRequest(input.Claims)
+	if err != nil {
+		return nil, customerrors.NewValidationError("invalid_request", "The claims parameter is invalid. It must be a JSON object with the userinfo and id_token members, "+
+			"each one with the requested claims, and their essential, value or values constraints.")
+	}
+
+	if !claimsRequest.IsEmpty() && !slices.Contains(strings.Split(input.Scope, " "), "openid") {
+		return nil, customerrors.NewValidationError("invalid_request", "The claims parameter requires the openid scope.")
+	}
+	return claimsRequest, nil
+}
+
+// ValidateAuthorizationDetails checks the authorization_details parameter (RFC 9396) against
+// the authorization details types registered in the resources
+func (val *AuthorizeValidator) ValidateAuthorizationDetails(ctx context.Context, authorizationDetails string) (dtos.AuthorizationDetails, error) {
+	details, _, err := core.ValidateAuthorizationDetails(val.database, authorizationDetails)
+	if err != nil {
+		return nil, err
+	}
+	return details, nil
+}
+
+// ValidateIdTokenHint checks that the id_token_hint was issued by this server to the client, and
+// returns the subject of its user. The id token can be expired - it's just a hint of who the user is.
+// A pairwise sub is mapped back to the user, so the result can be compared with the user session.
+func (val *AuthorizeValidator) ValidateIdTokenHint(ctx context.Context, input *ValidateIdTokenHintInput) (string, error) {
+
+	settings := ctx.Value(common.ContextKeySettings).(*entities.Settings)
+
+	claims := jwt.MapClaims{}
+	_, err := jwt.ParseWithClaims(input.IdTokenHint, claims, core.GetVerificationKeyFunc(val.database),
+		jwt.WithValidMethods(lib.SigningAlgorithms), jwt.WithoutClaimsValidation())
+	if err != nil {
+		return "", customerrors.NewValidationError("invalid_request", "The id_token_hint parameter is invalid: the token could not be verified.")
+	}
+
+	// access and refresh tokens are signed with the same keys
+	if typ, _ := claims["typ"].(string); typ != enums.TokenTypeId.String() {
+		return "", customerrors.NewValidationError("invalid_request", "The id_token_hint parameter is invalid: the token is not an ID token.")
+	}
+
+	if iss, _ := claims.GetIssuer(); iss != settings.Issuer {
+		return "", customerrors.NewValidationError("invalid_request", "The id_token_hint parameter is invalid: the iss claim does not match the issuer of this server.")
+	}
+
+	aud, _ := claims.GetAudience()
+	if !slices.Contains(aud, input.ClientId) {
+		return "", customerrors.NewValidationError("invalid_request", "The id_token_hint parameter is invalid: the token was not issued to this client.")
+	}
+
+	sub, _ := claims.GetSubject()
+	if len(sub) == 0 {
+		return "", customerrors.NewValidationError("invalid_request", "The id_token_hint parameter is invalid: the sub claim is missing.")
+	}
+
+	user, err := val.subjectResolver.GetUserBySubject(sub)
+	if err != nil {
+		return "", err
+	}
+	if user != nil {
+		return user.Subject.String(), nil
+	}
+	return sub, nil
+}
+
+// ValidateRequestURI looks up the pushed authorization request referenced by request_uri.
+// A request_uri is bound to the client that pushed it and can only be used once.
+func (val *AuthorizeValidator) ValidateRequestURI(ctx context.Context, input *ValidateRequestURIInput) (*entities.PushedAuthRequest, error) {
+	if len(input.ClientId) == 0 {
+		return nil, customerrors.NewValidationError("", "The client_id parameter is missing.")
+	}
+
+	requestURIHash, err := lib.HashString(input.RequestURI)
+	if err != nil {
+		return nil, err
+	}
+
+	pushedAuthRequest, err := val.database.GetPushedAuthRequestByRequestURIHash(nil, requestURIHash)
+	if err != nil {
+		return nil, err
+	}
+
+	const invalidRequestURIMessage = "The request_uri parameter is invalid or has expired."
+	if pushedAuthRequest == nil || pushedAuthRequest.Used || time.Now().UTC().After(pushedAuthRequest.ExpiresAt) {
+		return nil, customerrors.NewValidationError("", invalidRequestURIMessage)
+	}
+
+	err = val.database.PushedAuthRequestLoadClient(nil, pushedAuthRequest)
+	if err != nil {
+		return nil, err
+	}
+
+	if pushedAuthRequest.Client.ClientIdentifier != input.ClientId {
+		return nil, customerrors.NewValidationError("", invalidRequestURIMessage)
+	}
+
+	return pushedAuthRequest, nil
+}
+
+// ValidateRequestObject verifies the signature of a request object (RFC 9101) against
+// the keys registered for the client, and returns the authorization parameters it carries.
+func (val *AuthorizeValidator) ValidateRequestObject(ctx context.Context, input *ValidateRequestObjectInput) (url.Values, error) {
+	if len(input.ClientId) == 0 {
+		return nil, customerrors.NewValidationError("", "The client_id parameter is missing.")
+	}
+
+	client, err := val.database.GetClientByClientIdentifier(nil, input.ClientId)
+	if err != nil {
+		return nil, err
+	}
+	if client == nil {
+		return nil, customerrors.NewValidationError("", "We couldn't find a client associated with the provided client_id.")
+	}
+
+	jwks, err := val.clientKeysResolver.GetClientKeys(ctx, client)
+	if err != nil {
+		slog.Warn(fmt.Sprintf("unable to get the keys of client %v: %+v", client.ClientIdentifier, err))
+		return nil, customerrors.NewValidationError("", "Unable to retrieve the keys of the client to verify the request object.")
+	}
+	if jwks == nil {
+		return nil, customerrors.NewValidationError("", "The client associated with the provided client_id has no keys registered to verify request objects.")
+	}
+
+	claims := jwt.MapClaims{}
+	_, err = jwt.ParseWithClaims(input.RequestObject, claims, func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		key := jwks.FindKey(kid)
+		if key == nil {
+			return nil, errors.New("no matching key found")
+		}
+		if len(key.Alg) > 0 && key.Alg != token.Method.Alg() {
+			return nil, errors.New("the key is not meant to be used with this algorithm")
+		}
+		return key.PublicKey()
+	}, jwt.WithValidMethods(RequestObjectSigningAlgValuesSupported))
+	if err != nil {
+		return nil, customerrors.NewValidationError("", "The request object is invalid. Its signature could not be verified, or it has expired.")
+	}
+
+	if iss, ok := claims["iss"]; ok && iss != input.ClientId {
+		return nil, customerrors.NewValidationError("", "The request object is invalid. The iss claim must be the client_id.")
+	}
+
+	if _, ok := claims["aud"]; ok {
+		settings := ctx.Value(common.ContextKeySettings).(*entities.Settings)
+		aud, err := claims.GetAudience()
+		if err != nil || !slices.Contains(aud, settings.Issuer) {
+			return nil, customerrors.NewValidationError("", "The request object is invalid. The aud claim must be the issuer of the authorization server.")
+		}
+	}
+
+	if clientId, ok := claims["client_id"]; ok && clientId != input.ClientId {
+		return nil, customerrors.NewValidationError("", "The client_id in the request object does not match the client_id parameter.")
+	}
+
+	params := url.Values{}
+	for name, value := range claims {
+		switch name {
+		case "iss", "aud", "exp", "iat", "nbf", "jti":
+			continue
+		case "request", "request_uri":
+			return nil, customerrors.NewValidationError("", "The request object must not contain the request or request_uri parameters.")
+		}
+
+		switch v := value.(type) {
+		case string:
+			params.Set(name, v)
+		case float64:
+			params.Set(name, strconv.FormatFloat(v, 'f', -1, 64))
+		case bool:
+			params.Set(name, strconv.FormatBool(v))
+		default:
+			// structured parameters (e.g. claims) are carried as JSON, like in the query string
+			b, err := json.Marshal(v)
+			if err != nil {
+				return nil, errors.WithStack(err)
+			}
+			params.Set(name, string(b))
+		}
+	}
+	return params, nil
+}
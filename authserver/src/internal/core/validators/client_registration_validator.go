@@ -0,0 +1,178 @@
+package core
+
+import (
+	"crypto/x509"
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+
+	"github.com/leodip/goiabada/internal/customerrors"
+	"github.com/leodip/goiabada/internal/dtos"
+	"github.com/leodip/goiabada/internal/enums"
+	"github.com/leodip/goiabada/internal/lib"
+)
+
+// ClientRegistrationGrantTypesSupported are the grant types a client can register with (RFC 7591)
+var ClientRegistrationGrantTypesSupported = []string{"authorization_code", "refresh_token", "client_credentials",
+	"urn:ietf:params:oauth:grant-type:device_code", "urn:ietf:params:oauth:grant-type:token-exchange",
+	"urn:openid:params:grant-type:ciba"}
+
+type ClientRegistrationValidator struct {
+}
+
+func NewClientRegistrationValidator() *ClientRegistrationValidator {
+	return &ClientRegistrationValidator{}
+}
+
+// ValidateClientMetadata validates the metadata of a dynamic client registration request and sets
+// the default values of the fields that were omitted (RFC 7591, section 2)
+func (val *ClientRegistrationValidator) ValidateClientMetadata(metadata *dtos.ClientMetadata) error {
+
+	if len(metadata.TokenEndpointAuthMethod) == 0 {
+		metadata.TokenEndpointAuthMethod = enums.TokenEndpointAuthMethodClientSecretBasic.String()
+	}
+	if len(metadata.GrantTypes) == 0 {
+		metadata.GrantTypes = []string{"authorization_code"}
+	}
+	if len(metadata.ResponseTypes) == 0 && slices.Contains(metadata.GrantTypes, "authorization_code") {
+		metadata.ResponseTypes = []string{"code"}
+	}
+	if len(metadata.SubjectType) == 0 {
+		metadata.SubjectType = enums.SubjectTypePublic.String()
+	}
+
+	isPublic := metadata.TokenEndpointAuthMethod == "none"
+	var authMethod enums.TokenEndpointAuthMethod
+	if !isPublic {
+		var err error
+		authMethod, err = enums.TokenEndpointAuthMethodFromString(metadata.TokenEndpointAuthMethod)
+		if err != nil {
+			return customerrors.NewValidationError("invalid_client_metadata", fmt.Sprintf("Unsupported token_endpoint_auth_method '%v'.", metadata.TokenEndpointAuthMethod))
+		}
+	}
+
+	for _, grantType := range metadata.GrantTypes {
+		if !slices.Contains(ClientRegistrationGrantTypesSupported, grantType) {
+			return customerrors.NewValidationError("invalid_client_metadata", fmt.Sprintf("Unsupported grant type '%v'.", grantType))
+		}
+		if isPublic && (grantType == "client_credentials" || grantType == "urn:ietf:params:oauth:grant-type:token-exchange" ||
+			grantType == "urn:openid:params:grant-type:ciba") {
+			return customerrors.NewValidationError("invalid_client_metadata", fmt.Sprintf("A public client (token_endpoint_auth_method none) can't use the %v grant type.", grantType))
+		}
+	}
+
+	for _, responseType := range metadata.ResponseTypes {
+		if responseType != "code" {
+			return customerrors.NewValidationError("invalid_client_metadata", fmt.Sprintf("Unsupported response type '%v'. Only code is supported.", responseType))
+		}
+	}
+	if slices.Contains(metadata.GrantTypes, "authorization_code") != slices.Contains(metadata.ResponseTypes, "code") {
+		return customerrors.NewValidationError("invalid_client_metadata", "The authorization_code grant type and the code response type must be registered together.")
+	}
+
+	if slices.Contains(metadata.GrantTypes, "authorization_code") && len(metadata.RedirectURIs) == 0 {
+		return customerrors.NewValidationError("invalid_redirect_uri", "At least one redirect URI is required for the authorization_code grant type.")
+	}
+	for _, redirectURI := range metadata.RedirectURIs {
+		const maxLengthRedirectURI = 256
+		parsedURI, err := url.Parse(redirectURI)
+		if err != nil || !parsedURI.IsAbs() || strings.Contains(redirectURI, "#") || len(redirectURI) > maxLengthRedirectURI {
+			return customerrors.NewValidationError("invalid_redirect_uri", fmt.Sprintf("Invalid redirect URI '%v'. It must be an absolute URI without a fragment, with up to %v characters.", redirectURI, maxLengthRedirectURI))
+		}
+	}
+
+	for _, webOrigin := range metadata.WebOrigins {
+		const maxLengthWebOrigin = 256
+		parsedOrigin, err := url.ParseRequestURI(webOrigin)
+		if err != nil || (parsedOrigin.Scheme != "https" && parsedOrigin.Scheme != "http") || len(parsedOrigin.Host) == 0 ||
+			strings.TrimSuffix(parsedOrigin.Path, "/") != "" || len(webOrigin) > maxLengthWebOrigin {
+			return customerrors.NewValidationError("invalid_client_metadata", fmt.Sprintf("Invalid web origin '%v'. It must be an http or https origin, without a path.", webOrigin))
+		}
+	}
+
+	const maxLengthClientName = 100
+	if len(metadata.ClientName) > maxLengthClientName {
+		return customerrors.NewValidationError("invalid_client_metadata", fmt.Sprintf("The client_name cannot exceed a maximum length of %v characters.", maxLengthClientName))
+	}
+
+	if len(metadata.JWKS) > 0 && len(metadata.JWKSURI) > 0 {
+		return customerrors.NewValidationError("invalid_client_metadata", "The jwks and jwks_uri parameters can't be used together.")
+	}
+
+	var jwks *lib.JSONWebKeySet
+	if len(metadata.JWKS) > 0 {
+		var err error
+		jwks, err = lib.ParseJSONWebKeySet(metadata.JWKS)
+		if err != nil {
+			return customerrors.NewValidationError("invalid_client_metadata", "The jwks is invalid. It must be a JSON object with a non-empty 'keys' array of RSA or EC public keys.")
+		}
+	}
+
+	if len(metadata.JWKSURI) > 0 {
+		const maxLengthJWKSURI = 512
+		parsedURI, err := url.ParseRequestURI(metadata.JWKSURI)
+		if err != nil || (parsedURI.Scheme != "https" && parsedURI.Scheme != "http") || len(parsedURI.Host) == 0 ||
+			len(metadata.JWKSURI) > maxLengthJWKSURI {
+			return customerrors.NewValidationError("invalid_client_metadata", fmt.Sprintf("Invalid jwks_uri. It must be an absolute http or https URL, with up to %v characters.", maxLengthJWKSURI))
+		}
+	}
+
+	if len(metadata.BackChannelLogoutURI) > 0 {
+		const maxLengthBackChannelLogoutURI = 512
+		parsedURI, err := url.ParseRequestURI(metadata.BackChannelLogoutURI)
+		if err != nil || (parsedURI.Scheme != "https" && parsedURI.Scheme != "http") || len(parsedURI.Host) == 0 ||
+			strings.Contains(metadata.BackChannelLogoutURI, "#") || len(metadata.BackChannelLogoutURI) > maxLengthBackChannelLogoutURI {
+			return customerrors.NewValidationError("invalid_client_metadata", fmt.Sprintf("Invalid backchannel_logout_uri. It must be an absolute http or https URL without a fragment, with up to %v characters.", maxLengthBackChannelLogoutURI))
+		}
+	}
+
+	if len(metadata.FrontChannelLogoutURI) > 0 {
+		const maxLengthFrontChannelLogoutURI = 512
+		parsedURI, err := url.ParseRequestURI(metadata.FrontChannelLogoutURI)
+		if err != nil || (parsedURI.Scheme != "https" && parsedURI.Scheme != "http") || len(parsedURI.Host) == 0 ||
+			strings.Contains(metadata.FrontChannelLogoutURI, "#") || len(metadata.FrontChannelLogoutURI) > maxLengthFrontChannelLogoutURI {
+			return customerrors.NewValidationError("invalid_client_metadata", fmt.Sprintf("Invalid frontchannel_logout_uri. It must be an absolute http or https URL without a fragment, with up to %v characters.", maxLengthFrontChannelLogoutURI))
+		}
+	}
+
+	if _, err := enums.SubjectTypeFromString(metadata.SubjectType); err != nil {
+		return customerrors.NewValidationError("invalid_client_metadata", fmt.Sprintf("Unsupported subject_type '%v'. It must be public or pairwise.", metadata.SubjectType))
+	}
+
+	if isPublic {
+		return nil
+	}
+
+	if (authMethod == enums.TokenEndpointAuthMethodTLSClientAuth || authMethod == enums.TokenEndpointAuthMethodSelfSignedTLSClientAuth) &&
+		!lib.IsMTLSEnabled() {
+		return customerrors.NewValidationError("invalid_client_metadata", fmt.Sprintf("The %v method is not available because mutual TLS is not enabled on this server.", authMethod.String()))
+	}
+
+	if authMethod == enums.TokenEndpointAuthMethodTLSClientAuth && !lib.IsClientCertificateCAConfigured() {
+		return customerrors.NewValidationError("invalid_client_metadata", "The tls_client_auth method is not available because no trusted certificate authority is configured on this server.")
+	}
+
+	switch authMethod {
+	case enums.TokenEndpointAuthMethodPrivateKeyJwt:
+		if jwks == nil && len(metadata.JWKSURI) == 0 {
+			return customerrors.NewValidationError("invalid_client_metadata", "The private_key_jwt method requires the jwks or the jwks_uri of the client.")
+		}
+	case enums.TokenEndpointAuthMethodTLSClientAuth:
+		const maxLengthSubjectDN = 512
+		if len(metadata.TLSClientAuthSubjectDN) == 0 || len(metadata.TLSClientAuthSubjectDN) > maxLengthSubjectDN {
+			return customerrors.NewValidationError("invalid_client_metadata", fmt.Sprintf("The tls_client_auth method requires the tls_client_auth_subject_dn of the client, with up to %v characters.", maxLengthSubjectDN))
+		}
+	case enums.TokenEndpointAuthMethodSelfSignedTLSClientAuth:
+		var cert *x509.Certificate
+		var err error
+		if jwks != nil {
+			cert, err = jwks.FindCertificate()
+		}
+		if err != nil || cert == nil {
+			return customerrors.NewValidationError("invalid_client_metadata", "The self_signed_tls_client_auth method requires a jwks with the certificate of the client in the x5c parameter.")
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,157 @@
+package core
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"net/url"
+	"strings"
+	"time"
+
+	b64 "encoding/base64"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/leodip/goiabada/internal/customerrors"
+	"github.com/leodip/goiabada/internal/data"
+	"github.com/leodip/goiabada/internal/entities"
+	"github.com/leodip/goiabada/internal/lib"
+	"github.com/pkg/errors"
+)
+
+// DPoPSigningAlgValuesSupported are the algorithms accepted for DPoP proofs
+var DPoPSigningAlgValuesSupported = []string{"RS256", "RS384", "RS512", "PS256", "PS384", "PS512", "ES256", "ES384", "ES512"}
+
+// DPoPProofMaxAgeInSeconds is how far the iat of a DPoP proof can be from the current time
+const DPoPProofMaxAgeInSeconds = 60
+
+type DPoPValidator struct {
+	database data.Database
+}
+
+func NewDPoPValidator(database data.Database) *DPoPValidator {
+	return &DPoPValidator{
+		database: database,
+	}
+}
+
+type ValidateDPoPProofInput struct {
+	Proof       string
+	HttpMethod  string
+	HttpURI     string
+	AccessToken string
+}
+
+// ValidateDPoPProof validates a DPoP proof JWT (RFC 9449) and returns the thumbprint
+// of the public key that signed it. When an access token is provided, the proof
+// must also carry its hash (ath).
+func (val *DPoPValidator) ValidateDPoPProof(ctx context.Context, input *ValidateDPoPProofInput) (string, error) {
+
+	var jwk lib.JSONWebKey
+	claims := jwt.MapClaims{}
+	_, err := jwt.ParseWithClaims(input.Proof, claims, func(token *jwt.Token) (interface{}, error) {
+		if token.Header["typ"] != "dpop+jwt" {
+			return nil, errors.New("invalid typ header")
+		}
+		jwkHeader, ok := token.Header["jwk"].(map[string]interface{})
+		if !ok {
+			return nil, errors.New("missing jwk header")
+		}
+		if _, ok := jwkHeader["d"]; ok {
+			return nil, errors.New("the jwk header must not contain a private key")
+		}
+		jwkBytes, err := json.Marshal(jwkHeader)
+		if err != nil {
+			return nil, err
+		}
+		err = json.Unmarshal(jwkBytes, &jwk)
+		if err != nil {
+			return nil, err
+		}
+		return jwk.PublicKey()
+	}, jwt.WithValidMethods(DPoPSigningAlgValuesSupported))
+	if err != nil {
+		return "", customerrors.NewValidationError("invalid_dpop_proof", "The DPoP proof is invalid. Its signature could not be verified.")
+	}
+
+	jti, _ := claims["jti"].(string)
+	if len(jti) == 0 {
+		return "", customerrors.NewValidationError("invalid_dpop_proof", "The DPoP proof is missing the jti claim.")
+	}
+
+	if claims["htm"] != input.HttpMethod {
+		return "", customerrors.NewValidationError("invalid_dpop_proof", "The htm claim of the DPoP proof does not match the HTTP method of the request.")
+	}
+
+	htu, _ := claims["htu"].(string)
+	if !val.sameHttpURI(htu, input.HttpURI) {
+		return "", customerrors.NewValidationError("invalid_dpop_proof", "The htu claim of the DPoP proof does not match the HTTP URI of the request.")
+	}
+
+	iat, err := claims.GetIssuedAt()
+	if err != nil || iat == nil {
+		return "", customerrors.NewValidationError("invalid_dpop_proof", "The DPoP proof is missing the iat claim.")
+	}
+	now := time.Now().UTC()
+	maxAge := time.Duration(DPoPProofMaxAgeInSeconds) * time.Second
+	if iat.Time.Before(now.Add(-maxAge)) || iat.Time.After(now.Add(maxAge)) {
+		return "", customerrors.NewValidationError("invalid_dpop_proof", "The DPoP proof has expired or was issued in the future.")
+	}
+
+	if len(input.AccessToken) > 0 {
+		hash := sha256.Sum256([]byte(input.AccessToken))
+		if claims["ath"] != b64.RawURLEncoding.EncodeToString(hash[:]) {
+			return "", customerrors.NewValidationError("invalid_dpop_proof", "The ath claim of the DPoP proof does not match the access token.")
+		}
+	}
+
+	jkt, err := jwk.Thumbprint()
+	if err != nil {
+		return "", err
+	}
+
+	// a DPoP proof can only be used once
+	jtiHash, err := lib.HashString("dpop:" + jkt + ":" + jti)
+	if err != nil {
+		return "", err
+	}
+	usedJti, err := val.database.GetUsedJtiByJtiHash(nil, jtiHash)
+	if err != nil {
+		return "", err
+	}
+	if usedJti != nil {
+		return "", customerrors.NewValidationError("invalid_dpop_proof", "The DPoP proof has already been used.")
+	}
+
+	err = val.database.DeleteUsedJtisExpired(nil)
+	if err != nil {
+		return "", err
+	}
+	err = val.database.CreateUsedJti(nil, &entities.UsedJti{
+		JtiHash:   jtiHash,
+		ExpiresAt: iat.Time.UTC().Add(2 * maxAge),
+	})
+	if err != nil {
+		// the same proof was used in a concurrent request
+		if errors.Is(err, customerrors.ErrJtiAlreadyUsed) {
+			return "", customerrors.NewValidationError("invalid_dpop_proof", "The DPoP proof has already been used.")
+		}
+		return "", err
+	}
+
+	return jkt, nil
+}
+
+// sameHttpURI compares two URIs ignoring the query and fragment parts
+func (val *DPoPValidator) sameHttpURI(uri1 string, uri2 string) bool {
+	u1, err := url.Parse(uri1)
+	if err != nil {
+		return false
+	}
+	u2, err := url.Parse(uri2)
+	if err != nil {
+		return false
+	}
+	return strings.EqualFold(u1.Scheme, u2.Scheme) &&
+		strings.EqualFold(u1.Host, u2.Host) &&
+		u1.Path == u2.Path
+}
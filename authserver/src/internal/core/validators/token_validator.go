@@ -2,11 +2,17 @@ package core
 
 import (
 	"context"
+	"crypto/x509"
+	"database/sql"
 	"fmt"
+	"log/slog"
 	"regexp"
+	"slices"
 	"strings"
 	"time"
+	"unicode/utf8"
 
+	"github.com/golang-jwt/jwt/v5"
 	"github.com/pkg/errors"
 
 	"github.com/leodip/goiabada/internal/common"
@@ -17,41 +23,68 @@ import (
 	"github.com/leodip/goiabada/internal/data"
 	"github.com/leodip/goiabada/internal/dtos"
 	"github.com/leodip/goiabada/internal/entities"
+	"github.com/leodip/goiabada/internal/enums"
 	"github.com/leodip/goiabada/internal/lib"
 )
 
+// TokenEndpointAuthSigningAlgValuesSupported are the algorithms accepted for client assertions
+var TokenEndpointAuthSigningAlgValuesSupported = []string{"HS256", "HS384", "HS512", "RS256", "RS384", "RS512", "PS256", "PS384", "PS512", "ES256", "ES384", "ES512"}
+
 type TokenValidator struct {
-	database          data.Database
-	tokenParser       *core_token.TokenParser
-	permissionChecker *core.PermissionChecker
+	database           data.Database
+	tokenParser        *core_token.TokenParser
+	permissionChecker  *core.PermissionChecker
+	clientKeysResolver *core.ClientKeysResolver
+	subjectResolver    *core.SubjectResolver
 }
 
 func NewTokenValidator(database data.Database, tokenParser *core_token.TokenParser,
-	permissionChecker *core.PermissionChecker) *TokenValidator {
+	permissionChecker *core.PermissionChecker, clientKeysResolver *core.ClientKeysResolver,
+	subjectResolver *core.SubjectResolver) *TokenValidator {
 	return &TokenValidator{
-		database:          database,
-		tokenParser:       tokenParser,
-		permissionChecker: permissionChecker,
+		database:           database,
+		tokenParser:        tokenParser,
+		permissionChecker:  permissionChecker,
+		clientKeysResolver: clientKeysResolver,
+		subjectResolver:    subjectResolver,
 	}
 }
 
 type ValidateTokenRequestInput struct {
-	GrantType    string
-	Code         string
-	RedirectURI  string
-	CodeVerifier string
-	ClientId     string
-	ClientSecret string
-	Scope        string
-	RefreshToken string
+	GrantType            string
+	Code                 string
+	RedirectURI          string
+	CodeVerifier         string
+	ClientId             string
+	ClientSecret         string
+	ClientSecretBasic    bool
+	ClientAssertionType  string
+	ClientAssertion      string
+	ClientCertificate    *x509.Certificate
+	Scope                string
+	RefreshToken         string
+	DeviceCode           string
+	AuthReqId            string
+	SubjectToken         string
+	SubjectTokenType     string
+	RequestedTokenType   string
+	Resources            []string
+	AuthorizationDetails string
+	DPoPJkt              string
 }
 
 type ValidateTokenRequestResult struct {
-	CodeEntity       *entities.Code
-	Client           *entities.Client
-	Scope            string
-	RefreshToken     *entities.RefreshToken
-	RefreshTokenInfo *dtos.JwtToken
+	CodeEntity             *entities.Code
+	Client                 *entities.Client
+	Scope                  string
+	RefreshToken           *entities.RefreshToken
+	RefreshTokenInfo       *dtos.JwtToken
+	DeviceCode             *entities.DeviceCode
+	BackchannelAuthRequest *entities.BackchannelAuthRequest
+	SubjectTokenInfo       *dtos.JwtToken
+	User                   *entities.User
+	Resource               string
+	AuthorizationDetails   dtos.AuthorizationDetails
 }
 
 func (val *TokenValidator) ValidateTokenRequest(ctx context.Context, input *ValidateTokenRequestInput) (*ValidateTokenRequestResult, error) {
@@ -73,7 +106,17 @@ func (val *TokenValidator) ValidateTokenRequest(ctx context.Context, input *Vali
 		return nil, customerrors.NewValidationError("invalid_grant", "Client is disabled.")
 	}
 
-	clientSecretRequiredErrorMsg := "This client is configured as confidential (not public), which means a client_secret is required for authentication. Please provide a valid client_secret to proceed."
+	clientAuthInput := &clientAuthenticationInput{
+		ClientSecret:        input.ClientSecret,
+		ClientSecretBasic:   input.ClientSecretBasic,
+		ClientAssertionType: input.ClientAssertionType,
+		ClientAssertion:     input.ClientAssertion,
+		ClientCertificate:   input.ClientCertificate,
+	}
+
+	// the authorization code, device code, CIBA and refresh token grants answer a wrong client secret with invalid_grant
+	invalidClientSecretGrantErr := customerrors.NewValidationError("invalid_grant", "Client authentication failed. Please review your client_secret.")
+	invalidClientSecretClientErr := customerrors.NewValidationError("invalid_client", "Client authentication failed.")
 
 	switch input.GrantType {
 	case "authorization_code":
@@ -141,20 +184,9 @@ func (val *TokenValidator) ValidateTokenRequest(ctx context.Context, input *Vali
 			return nil, customerrors.NewValidationError("invalid_grant", "Code has expired.")
 		}
 
-		if !client.IsPublic {
-			if len(input.ClientSecret) == 0 {
-				return nil, customerrors.NewValidationError("invalid_request", clientSecretRequiredErrorMsg)
-			}
-
-			clientSecretDecrypted, err := lib.DecryptText(client.ClientSecretEncrypted, settings.AESEncryptionKey)
-			if err != nil {
-				return nil, err
-			}
-			if clientSecretDecrypted != input.ClientSecret {
-				return nil, customerrors.NewValidationError("invalid_grant", "Client authentication failed. Please review your client_secret.")
-			}
-		} else if len(input.ClientSecret) > 0 {
-			return nil, customerrors.NewValidationError("invalid_request", "This client is configured as public, which means a client_secret is not required. To proceed, please remove the client_secret from your request.")
+		err = val.verifyClient(ctx, client, clientAuthInput, invalidClientSecretGrantErr)
+		if err != nil {
+			return nil, err
 		}
 
 		codeChallenge := lib.GeneratePKCECodeChallenge(input.CodeVerifier)
@@ -162,8 +194,20 @@ func (val *TokenValidator) ValidateTokenRequest(ctx context.Context, input *Vali
 			return nil, customerrors.NewValidationError("invalid_grant", "Invalid code_verifier (PKCE).")
 		}
 
+		resource, err := val.validateResource(input.Resources, codeEntity.Scope, codeEntity.Resource)
+		if err != nil {
+			return nil, err
+		}
+
+		authorizationDetails, err := val.validateRequestedAuthorizationDetails(input.AuthorizationDetails, codeEntity.AuthorizationDetails)
+		if err != nil {
+			return nil, err
+		}
+
 		return &ValidateTokenRequestResult{
-			CodeEntity: codeEntity,
+			CodeEntity:           codeEntity,
+			Resource:             resource,
+			AuthorizationDetails: authorizationDetails,
 		}, nil
 	case "client_credentials":
 		if !client.ClientCredentialsEnabled {
@@ -174,17 +218,10 @@ func (val *TokenValidator) ValidateTokenRequest(ctx context.Context, input *Vali
 			return nil, customerrors.NewValidationError("unauthorized_client", "A public client is not eligible for the client credentials flow. Please review the client configuration.")
 		}
 
-		if len(input.ClientSecret) == 0 {
-			return nil, customerrors.NewValidationError("invalid_request", clientSecretRequiredErrorMsg)
-		}
-
-		clientSecretDescrypted, err := lib.DecryptText(client.ClientSecretEncrypted, settings.AESEncryptionKey)
+		err = val.verifyClient(ctx, client, clientAuthInput, invalidClientSecretClientErr)
 		if err != nil {
 			return nil, err
 		}
-		if clientSecretDescrypted != input.ClientSecret {
-			return nil, customerrors.NewValidationError("invalid_client", "Client authentication failed.")
-		}
 
 		err = val.database.ClientLoadPermissions(nil, client)
 		if err != nil {
@@ -213,27 +250,249 @@ func (val *TokenValidator) ValidateTokenRequest(ctx context.Context, input *Vali
 			return nil, err
 		}
 
+		resource, err := val.validateResource(input.Resources, input.Scope, "")
+		if err != nil {
+			return nil, err
+		}
+		if len(resource) > 0 {
+			input.Scope = core.GetScopeForResource(input.Scope, resource)
+		}
+
+		authorizationDetails, err := val.validateClientCredentialsAuthorizationDetails(input.AuthorizationDetails, client)
+		if err != nil {
+			return nil, err
+		}
+
 		return &ValidateTokenRequestResult{
-			Client: client,
-			Scope:  input.Scope,
+			Client:               client,
+			Scope:                input.Scope,
+			Resource:             resource,
+			AuthorizationDetails: authorizationDetails,
 		}, nil
-	case "refresh_token":
-		if !client.AuthorizationCodeEnabled {
-			return nil, customerrors.NewValidationError("unauthorized_client", "The client associated with the provided client_id does not support authorization code flow.")
+	case "urn:ietf:params:oauth:grant-type:device_code":
+		if !client.DeviceCodeEnabled {
+			return nil, customerrors.NewValidationError("unauthorized_client", "The client associated with the provided client_id does not support device code flow.")
 		}
 
-		if !client.IsPublic {
-			if len(input.ClientSecret) == 0 {
-				return nil, customerrors.NewValidationError("invalid_request", clientSecretRequiredErrorMsg)
-			}
+		err = val.verifyClient(ctx, client, clientAuthInput, invalidClientSecretGrantErr)
+		if err != nil {
+			return nil, err
+		}
 
-			clientSecretDecrypted, err := lib.DecryptText(client.ClientSecretEncrypted, settings.AESEncryptionKey)
-			if err != nil {
-				return nil, err
-			}
-			if clientSecretDecrypted != input.ClientSecret {
-				return nil, customerrors.NewValidationError("invalid_grant", "Client authentication failed. Please review your client_secret.")
-			}
+		if len(input.DeviceCode) == 0 {
+			return nil, customerrors.NewValidationError("invalid_request", "Missing required device_code parameter.")
+		}
+
+		deviceCodeHash, err := lib.HashString(input.DeviceCode)
+		if err != nil {
+			return nil, err
+		}
+		deviceCode, err := val.database.GetDeviceCodeByDeviceCodeHash(nil, deviceCodeHash)
+		if err != nil {
+			return nil, err
+		}
+		if deviceCode == nil {
+			return nil, customerrors.NewValidationError("invalid_grant", "Device code is invalid.")
+		}
+
+		if deviceCode.ClientId != client.Id {
+			return nil, customerrors.NewValidationError("invalid_grant", "The client_id provided does not match the client_id from device code.")
+		}
+
+		now := time.Now().UTC()
+		if now.After(deviceCode.ExpiresAt) {
+			return nil, customerrors.NewValidationError("expired_token", "The device code has expired. Please start a new device authorization request.")
+		}
+
+		// polling too fast - increase the interval by 5 seconds (RFC 8628, section 3.5)
+		pollingTooFast := deviceCode.LastPolledAt.Valid &&
+			now.Before(deviceCode.LastPolledAt.Time.Add(time.Second*time.Duration(deviceCode.IntervalInSeconds)))
+		if pollingTooFast {
+			deviceCode.IntervalInSeconds = deviceCode.IntervalInSeconds + 5
+		}
+		deviceCode.LastPolledAt = sql.NullTime{Time: now, Valid: true}
+		err = val.database.UpdateDeviceCode(nil, deviceCode)
+		if err != nil {
+			return nil, err
+		}
+		if pollingTooFast {
+			return nil, customerrors.NewValidationError("slow_down",
+				fmt.Sprintf("The client is polling too frequently. Please wait at least %v seconds between requests.", deviceCode.IntervalInSeconds))
+		}
+
+		switch deviceCode.State {
+		case enums.DeviceCodeStatePending.String():
+			return nil, customerrors.NewValidationError("authorization_pending", "The user has not yet completed the authorization.")
+		case enums.DeviceCodeStateDenied.String():
+			return nil, customerrors.NewValidationError("access_denied", "The user has denied the authorization request.")
+		case enums.DeviceCodeStateUsed.String():
+			return nil, customerrors.NewValidationError("invalid_grant", "The device code has already been used.")
+		}
+
+		if !deviceCode.CodeId.Valid {
+			return nil, errors.WithStack(errors.New("the device code is approved but has no code associated with it"))
+		}
+
+		codeEntity, err := val.database.GetCodeById(nil, deviceCode.CodeId.Int64)
+		if err != nil {
+			return nil, err
+		}
+		if codeEntity == nil || codeEntity.Used {
+			return nil, customerrors.NewValidationError("invalid_grant", "The device code has already been used.")
+		}
+
+		err = val.database.CodeLoadClient(nil, codeEntity)
+		if err != nil {
+			return nil, err
+		}
+
+		err = val.database.CodeLoadUser(nil, codeEntity)
+		if err != nil {
+			return nil, err
+		}
+
+		if !codeEntity.User.Enabled {
+			lib.LogAudit(constants.AuditUserDisabled, map[string]interface{}{
+				"userId": codeEntity.User.Id,
+			})
+			return nil, customerrors.NewValidationError("invalid_grant", "The user account is disabled.")
+		}
+
+		resource, err := val.validateResource(input.Resources, codeEntity.Scope, codeEntity.Resource)
+		if err != nil {
+			return nil, err
+		}
+
+		authorizationDetails, err := val.validateRequestedAuthorizationDetails(input.AuthorizationDetails, codeEntity.AuthorizationDetails)
+		if err != nil {
+			return nil, err
+		}
+
+		return &ValidateTokenRequestResult{
+			CodeEntity:           codeEntity,
+			Client:               client,
+			DeviceCode:           deviceCode,
+			Resource:             resource,
+			AuthorizationDetails: authorizationDetails,
+		}, nil
+	case "urn:openid:params:grant-type:ciba":
+		if !client.CIBAEnabled {
+			return nil, customerrors.NewValidationError("unauthorized_client", "The client associated with the provided client_id does not support CIBA.")
+		}
+
+		if client.IsPublic {
+			return nil, customerrors.NewValidationError("unauthorized_client", "A public client is not eligible for CIBA. Please review the client configuration.")
+		}
+
+		err = val.verifyClient(ctx, client, clientAuthInput, invalidClientSecretGrantErr)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(input.AuthReqId) == 0 {
+			return nil, customerrors.NewValidationError("invalid_request", "Missing required auth_req_id parameter.")
+		}
+
+		authReqIdHash, err := lib.HashString(input.AuthReqId)
+		if err != nil {
+			return nil, err
+		}
+		backchannelAuthRequest, err := val.database.GetBackchannelAuthRequestByAuthReqIdHash(nil, authReqIdHash)
+		if err != nil {
+			return nil, err
+		}
+		if backchannelAuthRequest == nil {
+			return nil, customerrors.NewValidationError("invalid_grant", "The auth_req_id is invalid.")
+		}
+
+		if backchannelAuthRequest.ClientId != client.Id {
+			return nil, customerrors.NewValidationError("invalid_grant", "The client_id provided does not match the client_id from the authentication request.")
+		}
+
+		now := time.Now().UTC()
+		if now.After(backchannelAuthRequest.ExpiresAt) {
+			return nil, customerrors.NewValidationError("expired_token", "The authentication request has expired. Please start a new backchannel authentication request.")
+		}
+
+		// polling too fast - increase the interval by 5 seconds (CIBA, section 11)
+		pollingTooFast := backchannelAuthRequest.LastPolledAt.Valid &&
+			now.Before(backchannelAuthRequest.LastPolledAt.Time.Add(time.Second*time.Duration(backchannelAuthRequest.IntervalInSeconds)))
+		if pollingTooFast {
+			backchannelAuthRequest.IntervalInSeconds = backchannelAuthRequest.IntervalInSeconds + 5
+		}
+		backchannelAuthRequest.LastPolledAt = sql.NullTime{Time: now, Valid: true}
+		err = val.database.UpdateBackchannelAuthRequest(nil, backchannelAuthRequest)
+		if err != nil {
+			return nil, err
+		}
+		if pollingTooFast {
+			return nil, customerrors.NewValidationError("slow_down",
+				fmt.Sprintf("The client is polling too frequently. Please wait at least %v seconds between requests.", backchannelAuthRequest.IntervalInSeconds))
+		}
+
+		switch backchannelAuthRequest.State {
+		case enums.BackchannelAuthRequestStatePending.String():
+			return nil, customerrors.NewValidationError("authorization_pending", "The user has not yet been authenticated.")
+		case enums.BackchannelAuthRequestStateDenied.String():
+			return nil, customerrors.NewValidationError("access_denied", "The user has denied the authentication request.")
+		case enums.BackchannelAuthRequestStateUsed.String():
+			return nil, customerrors.NewValidationError("invalid_grant", "The auth_req_id has already been used.")
+		}
+
+		if !backchannelAuthRequest.CodeId.Valid {
+			return nil, errors.WithStack(errors.New("the backchannel auth request is approved but has no code associated with it"))
+		}
+
+		codeEntity, err := val.database.GetCodeById(nil, backchannelAuthRequest.CodeId.Int64)
+		if err != nil {
+			return nil, err
+		}
+		if codeEntity == nil || codeEntity.Used {
+			return nil, customerrors.NewValidationError("invalid_grant", "The auth_req_id has already been used.")
+		}
+
+		err = val.database.CodeLoadClient(nil, codeEntity)
+		if err != nil {
+			return nil, err
+		}
+
+		err = val.database.CodeLoadUser(nil, codeEntity)
+		if err != nil {
+			return nil, err
+		}
+
+		if !codeEntity.User.Enabled {
+			lib.LogAudit(constants.AuditUserDisabled, map[string]interface{}{
+				"userId": codeEntity.User.Id,
+			})
+			return nil, customerrors.NewValidationError("invalid_grant", "The user account is disabled.")
+		}
+
+		resource, err := val.validateResource(input.Resources, codeEntity.Scope, codeEntity.Resource)
+		if err != nil {
+			return nil, err
+		}
+
+		authorizationDetails, err := val.validateRequestedAuthorizationDetails(input.AuthorizationDetails, codeEntity.AuthorizationDetails)
+		if err != nil {
+			return nil, err
+		}
+
+		return &ValidateTokenRequestResult{
+			CodeEntity:             codeEntity,
+			Client:                 client,
+			BackchannelAuthRequest: backchannelAuthRequest,
+			Resource:               resource,
+			AuthorizationDetails:   authorizationDetails,
+		}, nil
+	case "refresh_token":
+		if !client.AuthorizationCodeEnabled && !client.DeviceCodeEnabled && !client.CIBAEnabled {
+			return nil, customerrors.NewValidationError("unauthorized_client", "The client associated with the provided client_id does not support the refresh_token grant. It requires the authorization code, device code or CIBA flow.")
+		}
+
+		err = val.verifyClient(ctx, client, clientAuthInput, invalidClientSecretGrantErr)
+		if err != nil {
+			return nil, err
 		}
 
 		if len(input.RefreshToken) == 0 {
@@ -276,6 +535,10 @@ func (val *TokenValidator) ValidateTokenRequest(ctx context.Context, input *Vali
 			return nil, customerrors.NewValidationError("invalid_grant", "The user account is disabled.")
 		}
 
+		if len(refreshToken.DPoPJkt) > 0 && refreshToken.DPoPJkt != input.DPoPJkt {
+			return nil, customerrors.NewValidationError("invalid_grant", "The refresh token is bound to a DPoP key. Please provide a DPoP proof signed with the same key.")
+		}
+
 		refreshTokenType := refreshTokenInfo.GetStringClaim("typ")
 		switch refreshTokenType {
 		case "Refresh":
@@ -342,7 +605,7 @@ func (val *TokenValidator) ValidateTokenRequest(ctx context.Context, input *Vali
 		inputScopes := strings.Split(scopes, " ")
 
 		sub := refreshTokenInfo.GetStringClaim("sub")
-		user, err := val.database.GetUserBySubject(nil, sub)
+		user, err := val.subjectResolver.GetUserBySubject(sub)
 		if err != nil {
 			return nil, err
 		}
@@ -389,75 +652,864 @@ func (val *TokenValidator) ValidateTokenRequest(ctx context.Context, input *Vali
 			}
 		}
 
+		resource, err := val.validateResource(input.Resources, scopes, refreshToken.Code.Resource)
+		if err != nil {
+			return nil, err
+		}
+
+		authorizationDetails, err := val.validateRequestedAuthorizationDetails(input.AuthorizationDetails, refreshToken.AuthorizationDetails)
+		if err != nil {
+			return nil, err
+		}
+
 		return &ValidateTokenRequestResult{
-			CodeEntity:       &refreshToken.Code,
-			Client:           client,
-			RefreshToken:     refreshToken,
-			RefreshTokenInfo: refreshTokenInfo,
+			CodeEntity:           &refreshToken.Code,
+			Client:               client,
+			RefreshToken:         refreshToken,
+			RefreshTokenInfo:     refreshTokenInfo,
+			Resource:             resource,
+			AuthorizationDetails: authorizationDetails,
 		}, nil
-	default:
-		return nil, customerrors.NewValidationError("unsupported_grant_type", "Unsupported grant_type.")
-	}
-}
-
-func (val *TokenValidator) validateClientCredentialsScopes(ctx context.Context, scope string, client *entities.Client) error {
+	case "urn:ietf:params:oauth:grant-type:token-exchange":
+		if !client.TokenExchangeEnabled {
+			return nil, customerrors.NewValidationError("unauthorized_client", "The client associated with the provided client_id does not support token exchange.")
+		}
 
-	if len(scope) == 0 {
-		return nil
-	}
+		if client.IsPublic {
+			return nil, customerrors.NewValidationError("unauthorized_client", "A public client is not eligible for token exchange. Please review the client configuration.")
+		}
 
-	space := regexp.MustCompile(`\s+`)
-	scope = space.ReplaceAllString(scope, " ")
+		err = val.verifyClient(ctx, client, clientAuthInput, invalidClientSecretClientErr)
+		if err != nil {
+			return nil, err
+		}
 
-	scopes := strings.Split(scope, " ")
+		if len(input.SubjectToken) == 0 {
+			return nil, customerrors.NewValidationError("invalid_request", "Missing required subject_token parameter.")
+		}
 
-	for _, scopeStr := range scopes {
+		if len(input.SubjectTokenType) == 0 {
+			return nil, customerrors.NewValidationError("invalid_request", "Missing required subject_token_type parameter.")
+		}
 
-		if core.IsIdTokenScope(scopeStr) {
-			return customerrors.NewValidationError("invalid_request", fmt.Sprintf("Id token scopes (such as '%v') are not supported in the client credentials flow. Please use scopes in the format 'resource:permission' (e.g., 'backendA:read'). Multiple scopes can be specified, separated by spaces.", scopeStr))
+		if input.SubjectTokenType != constants.TokenTypeAccessTokenUrn {
+			return nil, customerrors.NewValidationError("invalid_request", "Unsupported subject_token_type. Only access tokens ("+constants.TokenTypeAccessTokenUrn+") can be exchanged.")
 		}
 
-		parts := strings.Split(scopeStr, ":")
-		if len(parts) != 2 {
-			return customerrors.NewValidationError("invalid_scope", fmt.Sprintf("Invalid scope format: '%v'. Scopes must adhere to the resource-identifier:permission-identifier format. For instance: backend-service:create-product.", scopeStr))
+		if len(input.RequestedTokenType) > 0 && input.RequestedTokenType != constants.TokenTypeAccessTokenUrn {
+			return nil, customerrors.NewValidationError("invalid_request", "Unsupported requested_token_type. Only access tokens ("+constants.TokenTypeAccessTokenUrn+") can be issued.")
 		}
 
-		res, err := val.database.GetResourceByResourceIdentifier(nil, parts[0])
+		subjectTokenInfo, err := val.tokenParser.ParseToken(ctx, input.SubjectToken, true)
 		if err != nil {
-			return err
+			return nil, customerrors.NewValidationError("invalid_grant", "The subject token is invalid ("+err.Error()+").")
 		}
-		if res == nil {
-			return customerrors.NewValidationError("invalid_scope", fmt.Sprintf("Invalid scope: '%v'. Could not find a resource with identifier '%v'.", scopeStr, parts[0]))
+
+		if subjectTokenInfo.GetStringClaim("iss") != settings.Issuer ||
+			subjectTokenInfo.GetStringClaim("typ") != enums.TokenTypeBearer.String() {
+			return nil, customerrors.NewValidationError("invalid_grant", "The subject token is invalid. It must be an access token issued by this authorization server.")
 		}
 
-		permissions, err := val.database.GetPermissionsByResourceId(nil, res.Id)
+		// a sender-constrained subject token can only be exchanged by the holder of its key or certificate.
+		// The new token is bound to the same key or certificate, as the request proves possession of it.
+		if jkt := subjectTokenInfo.GetConfirmationClaim("jkt"); len(jkt) > 0 && jkt != input.DPoPJkt {
+			return nil, customerrors.NewValidationError("invalid_grant", "The subject token is bound to a DPoP key. Please provide a DPoP proof signed with the same key.")
+		}
+		if certificateThumbprint := subjectTokenInfo.GetConfirmationClaim("x5t#S256"); len(certificateThumbprint) > 0 &&
+			(input.ClientCertificate == nil || lib.GetCertificateThumbprint(input.ClientCertificate) != certificateThumbprint) {
+			return nil, customerrors.NewValidationError("invalid_grant", "The subject token is bound to a client certificate. Please present the same certificate in the TLS connection.")
+		}
+
+		user, err := val.subjectResolver.GetUserBySubject(subjectTokenInfo.GetStringClaim("sub"))
 		if err != nil {
-			return err
+			return nil, err
+		}
+		if user == nil {
+			return nil, customerrors.NewValidationError("invalid_grant", "The subject token is invalid. It does not represent a user.")
 		}
 
-		permissionExists := false
-		for _, perm := range permissions {
-			if perm.PermissionIdentifier == parts[1] {
-				permissionExists = true
-				break
-			}
+		if !user.Enabled {
+			lib.LogAudit(constants.AuditUserDisabled, map[string]interface{}{
+				"userId": user.Id,
+			})
+			return nil, customerrors.NewValidationError("invalid_grant", "The user account is disabled.")
 		}
 
-		if !permissionExists {
-			return customerrors.NewValidationError("invalid_scope", fmt.Sprintf("Scope '%v' is not recognized. The resource identified by '%v' doesn't grant the '%v' permission.", scopeStr, parts[0], parts[1]))
+		err = val.database.ClientLoadPermissions(nil, client)
+		if err != nil {
+			return nil, err
 		}
 
-		clientHasPermission := false
-		for _, perm := range client.Permissions {
-			if perm.PermissionIdentifier == parts[1] {
-				clientHasPermission = true
-				break
-			}
+		err = val.database.PermissionsLoadResources(nil, client.Permissions)
+		if err != nil {
+			return nil, err
 		}
 
-		if !clientHasPermission {
-			return customerrors.NewValidationError("invalid_scope", fmt.Sprintf("Permission to access scope '%v' is not granted to the client.", scopeStr))
+		scope, err := val.validateTokenExchangeScopes(input.Scope, subjectTokenInfo, client, user)
+		if err != nil {
+			return nil, err
 		}
+
+		return &ValidateTokenRequestResult{
+			Client:           client,
+			Scope:            scope,
+			SubjectTokenInfo: subjectTokenInfo,
+			User:             user,
+		}, nil
+	default:
+		return nil, customerrors.NewValidationError("unsupported_grant_type", "Unsupported grant_type.")
 	}
-	return nil
+}
+
+// validateResource checks the resource parameter of the token request (RFC 8707). A single resource
+// is accepted per request, and it must be the resource of at least one of the granted scopes.
+// When the authorization request carried resources, the resource must be one of them.
+func (val *TokenValidator) validateResource(resources []string, scope string, authorizedResources string) (string, error) {
+	if len(resources) == 0 {
+		return "", nil
+	}
+	if len(resources) > 1 {
+		return "", customerrors.NewValidationError("invalid_target", "Only one resource parameter is supported per token request. Please request one access token per resource.")
+	}
+
+	resource := resources[0]
+	res, err := val.database.GetResourceByResourceIdentifier(nil, resource)
+	if err != nil {
+		return "", err
+	}
+	if res == nil {
+		return "", customerrors.NewValidationError("invalid_target", fmt.Sprintf("The resource '%v' is not recognized.", resource))
+	}
+
+	if len(authorizedResources) > 0 && !slices.Contains(strings.Fields(authorizedResources), resource) {
+		return "", customerrors.NewValidationError("invalid_target", fmt.Sprintf("The resource '%v' was not requested in the authorization request.", resource))
+	}
+
+	if len(core.GetScopeForResource(scope, resource)) == 0 {
+		return "", customerrors.NewValidationError("invalid_target", fmt.Sprintf("None of the granted scopes belong to the resource '%v'.", resource))
+	}
+	return resource, nil
+}
+
+// validateRequestedAuthorizationDetails checks the authorization_details parameter of the token request,
+// which narrows down the authorization details of the grant (RFC 9396, section 6). Each requested
+// authorization detail must be identical to one of the granted ones.
+func (val *TokenValidator) validateRequestedAuthorizationDetails(requested string, granted string) (dtos.AuthorizationDetails, error) {
+	grantedDetails, err := dtos.ParseAuthorizationDetails(granted)
+	if err != nil {
+		return nil, err
+	}
+	if len(requested) == 0 {
+		return grantedDetails, nil
+	}
+
+	requestedDetails, err := dtos.ParseAuthorizationDetails(requested)
+	if err != nil {
+		return nil, customerrors.NewValidationError("invalid_authorization_details",
+			"The authorization_details parameter is invalid. It must be a JSON array of objects, each one with a type.")
+	}
+	for _, detail := range requestedDetails {
+		if !grantedDetails.Contains(detail) {
+			return nil, customerrors.NewValidationError("invalid_authorization_details",
+				fmt.Sprintf("The authorization details of type '%v' were not granted to the client.", detail.GetType()))
+		}
+	}
+	return requestedDetails, nil
+}
+
+// validateClientCredentialsAuthorizationDetails checks the authorization_details parameter in the client
+// credentials flow. The client must hold at least one permission of the resource of each type.
+func (val *TokenValidator) validateClientCredentialsAuthorizationDetails(authorizationDetails string,
+	client *entities.Client) (dtos.AuthorizationDetails, error) {
+
+	details, detailTypes, err := core.ValidateAuthorizationDetails(val.database, authorizationDetails)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, detailType := range detailTypes {
+		clientHasPermission := slices.ContainsFunc(client.Permissions, func(permission entities.Permission) bool {
+			return permission.ResourceId == detailType.ResourceId
+		})
+		if !clientHasPermission {
+			return nil, customerrors.NewValidationError("invalid_authorization_details",
+				fmt.Sprintf("The client is not authorized to request authorization details of type '%v'.", detailType.Type))
+		}
+	}
+	return details, nil
+}
+
+// validateTokenExchangeScopes narrows the scope of the new token down to the permissions
+// that were granted to the subject token, and that both the user and the client still hold.
+// When no scope is requested, every permission that satisfies these conditions is included.
+func (val *TokenValidator) validateTokenExchangeScopes(scope string, subjectTokenInfo *dtos.JwtToken,
+	client *entities.Client, user *entities.User) (string, error) {
+
+	scopeRequested := len(strings.TrimSpace(scope)) > 0
+
+	space := regexp.MustCompile(`\s+`)
+	if scopeRequested {
+		scope = space.ReplaceAllString(strings.TrimSpace(scope), " ")
+	} else {
+		scope = space.ReplaceAllString(strings.TrimSpace(subjectTokenInfo.GetStringClaim("scope")), " ")
+	}
+
+	grantedScopes := []string{}
+	for _, scopeStr := range strings.Split(scope, " ") {
+
+		if core.IsIdTokenScope(scopeStr) {
+			if scopeRequested {
+				return "", customerrors.NewValidationError("invalid_scope", fmt.Sprintf("Id token scopes (such as '%v') are not supported in token exchange. Please use scopes in the format 'resource:permission' (e.g., 'backendA:read').", scopeStr))
+			}
+			continue
+		}
+
+		parts := strings.Split(scopeStr, ":")
+		if len(parts) != 2 {
+			return "", customerrors.NewValidationError("invalid_scope", fmt.Sprintf("Invalid scope format: '%v'. Scopes must adhere to the resource-identifier:permission-identifier format. For instance: backend-service:create-product.", scopeStr))
+		}
+
+		if !subjectTokenInfo.HasScope(scopeStr) {
+			if scopeRequested {
+				return "", customerrors.NewValidationError("invalid_scope", fmt.Sprintf("Scope '%v' is not recognized. The subject token does not grant the '%v' permission.", scopeStr, scopeStr))
+			}
+			continue
+		}
+
+		clientHasPermission := false
+		for _, perm := range client.Permissions {
+			if perm.Resource.ResourceIdentifier == parts[0] && perm.PermissionIdentifier == parts[1] {
+				clientHasPermission = true
+				break
+			}
+		}
+		if !clientHasPermission {
+			if scopeRequested {
+				return "", customerrors.NewValidationError("invalid_scope", fmt.Sprintf("Permission to access scope '%v' is not granted to the client.", scopeStr))
+			}
+			continue
+		}
+
+		userHasPermission, err := val.permissionChecker.UserHasScopePermission(user.Id, scopeStr)
+		if err != nil {
+			return "", err
+		}
+		if !userHasPermission {
+			if scopeRequested {
+				return "", customerrors.NewValidationError("invalid_scope", fmt.Sprintf("Scope '%v' is not recognized. The user does not have the '%v' permission.", scopeStr, scopeStr))
+			}
+			continue
+		}
+
+		if !slices.Contains(grantedScopes, scopeStr) {
+			grantedScopes = append(grantedScopes, scopeStr)
+		}
+	}
+
+	if len(grantedScopes) == 0 {
+		return "", customerrors.NewValidationError("invalid_scope", "None of the permissions of the subject token are held by both the user and the client.")
+	}
+
+	return strings.Join(grantedScopes, " "), nil
+}
+
+func (val *TokenValidator) validateClientCredentialsScopes(ctx context.Context, scope string, client *entities.Client) error {
+
+	if len(scope) == 0 {
+		return nil
+	}
+
+	space := regexp.MustCompile(`\s+`)
+	scope = space.ReplaceAllString(scope, " ")
+
+	scopes := strings.Split(scope, " ")
+
+	for _, scopeStr := range scopes {
+
+		if core.IsIdTokenScope(scopeStr) {
+			return customerrors.NewValidationError("invalid_request", fmt.Sprintf("Id token scopes (such as '%v') are not supported in the client credentials flow. Please use scopes in the format 'resource:permission' (e.g., 'backendA:read'). Multiple scopes can be specified, separated by spaces.", scopeStr))
+		}
+
+		parts := strings.Split(scopeStr, ":")
+		if len(parts) != 2 {
+			return customerrors.NewValidationError("invalid_scope", fmt.Sprintf("Invalid scope format: '%v'. Scopes must adhere to the resource-identifier:permission-identifier format. For instance: backend-service:create-product.", scopeStr))
+		}
+
+		res, err := val.database.GetResourceByResourceIdentifier(nil, parts[0])
+		if err != nil {
+			return err
+		}
+		if res == nil {
+			return customerrors.NewValidationError("invalid_scope", fmt.Sprintf("Invalid scope: '%v'. Could not find a resource with identifier '%v'.", scopeStr, parts[0]))
+		}
+
+		permissions, err := val.database.GetPermissionsByResourceId(nil, res.Id)
+		if err != nil {
+			return err
+		}
+
+		permissionExists := false
+		for _, perm := range permissions {
+			if perm.PermissionIdentifier == parts[1] {
+				permissionExists = true
+				break
+			}
+		}
+
+		if !permissionExists {
+			return customerrors.NewValidationError("invalid_scope", fmt.Sprintf("Scope '%v' is not recognized. The resource identified by '%v' doesn't grant the '%v' permission.", scopeStr, parts[0], parts[1]))
+		}
+
+		clientHasPermission := false
+		for _, perm := range client.Permissions {
+			if perm.PermissionIdentifier == parts[1] {
+				clientHasPermission = true
+				break
+			}
+		}
+
+		if !clientHasPermission {
+			return customerrors.NewValidationError("invalid_scope", fmt.Sprintf("Permission to access scope '%v' is not granted to the client.", scopeStr))
+		}
+	}
+	return nil
+}
+
+type ValidateDeviceAuthorizationRequestInput struct {
+	ClientId            string
+	ClientSecret        string
+	ClientSecretBasic   bool
+	ClientAssertionType string
+	ClientAssertion     string
+	ClientCertificate   *x509.Certificate
+}
+
+func (val *TokenValidator) ValidateDeviceAuthorizationRequest(ctx context.Context,
+	input *ValidateDeviceAuthorizationRequestInput) (*entities.Client, error) {
+
+	client, err := val.authenticateClient(ctx, input.ClientId, &clientAuthenticationInput{
+		ClientSecret:        input.ClientSecret,
+		ClientSecretBasic:   input.ClientSecretBasic,
+		ClientAssertionType: input.ClientAssertionType,
+		ClientAssertion:     input.ClientAssertion,
+		ClientCertificate:   input.ClientCertificate,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if !client.DeviceCodeEnabled {
+		return nil, customerrors.NewValidationError("unauthorized_client", "The client associated with the provided client_id does not support device code flow.")
+	}
+
+	return client, nil
+}
+
+type ValidateBackchannelAuthRequestInput struct {
+	ClientId            string
+	ClientSecret        string
+	ClientSecretBasic   bool
+	ClientAssertionType string
+	ClientAssertion     string
+	ClientCertificate   *x509.Certificate
+	Scope               string
+	LoginHint           string
+	LoginHintToken      string
+	IdTokenHint         string
+	BindingMessage      string
+	UserCode            string
+	AcrValues           string
+}
+
+type ValidateBackchannelAuthRequestResult struct {
+	Client   *entities.Client
+	User     *entities.User
+	AcrLevel enums.AcrLevel
+}
+
+// ValidateBackchannelAuthRequest validates a request to the backchannel authentication endpoint (CIBA, section 7.1)
+func (val *TokenValidator) ValidateBackchannelAuthRequest(ctx context.Context,
+	input *ValidateBackchannelAuthRequestInput) (*ValidateBackchannelAuthRequestResult, error) {
+
+	client, err := val.authenticateClient(ctx, input.ClientId, &clientAuthenticationInput{
+		ClientSecret:        input.ClientSecret,
+		ClientSecretBasic:   input.ClientSecretBasic,
+		ClientAssertionType: input.ClientAssertionType,
+		ClientAssertion:     input.ClientAssertion,
+		ClientCertificate:   input.ClientCertificate,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if client.IsPublic {
+		return nil, customerrors.NewValidationError("unauthorized_client", "A public client is not eligible for CIBA. Please review the client configuration.")
+	}
+
+	if !client.CIBAEnabled {
+		return nil, customerrors.NewValidationError("unauthorized_client", "The client associated with the provided client_id does not support CIBA.")
+	}
+
+	if !slices.Contains(strings.Fields(input.Scope), "openid") {
+		return nil, customerrors.NewValidationError("invalid_scope", "The openid scope is required for a backchannel authentication request.")
+	}
+
+	if len(input.LoginHintToken) > 0 || len(input.IdTokenHint) > 0 {
+		return nil, customerrors.NewValidationError("invalid_request", "Only the login_hint parameter is supported to identify the user.")
+	}
+	if len(input.LoginHint) == 0 {
+		return nil, customerrors.NewValidationError("invalid_request", "Missing required login_hint parameter.")
+	}
+
+	if len(input.UserCode) > 0 {
+		return nil, customerrors.NewValidationError("invalid_request", "The user_code parameter is not supported.")
+	}
+
+	const maxLengthBindingMessage = 100
+	if len(strings.TrimSpace(input.BindingMessage)) == 0 {
+		return nil, customerrors.NewValidationError("invalid_binding_message", "Missing required binding_message parameter.")
+	}
+	if utf8.RuneCountInString(input.BindingMessage) > maxLengthBindingMessage {
+		return nil, customerrors.NewValidationError("invalid_binding_message",
+			fmt.Sprintf("The binding_message cannot exceed a maximum length of %v characters.", maxLengthBindingMessage))
+	}
+
+	acrLevel := client.DefaultAcrLevel
+	for _, acrValue := range strings.Fields(input.AcrValues) {
+		requestedAcrLevel, err := enums.AcrLevelFromString(acrValue)
+		if err == nil {
+			acrLevel = requestedAcrLevel
+			break
+		}
+	}
+
+	user, err := val.database.GetUserByEmail(nil, input.LoginHint)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, customerrors.NewValidationError("unknown_user_id", "The login_hint does not identify a known user.")
+	}
+	if !user.Enabled {
+		lib.LogAudit(constants.AuditUserDisabled, map[string]interface{}{
+			"userId": user.Id,
+		})
+		return nil, customerrors.NewValidationError("access_denied", "The user account is disabled.")
+	}
+
+	return &ValidateBackchannelAuthRequestResult{
+		Client:   client,
+		User:     user,
+		AcrLevel: acrLevel,
+	}, nil
+}
+
+type ValidatePushedAuthRequestInput struct {
+	ClientId            string
+	ClientSecret        string
+	ClientSecretBasic   bool
+	ClientAssertionType string
+	ClientAssertion     string
+	ClientCertificate   *x509.Certificate
+}
+
+func (val *TokenValidator) ValidatePushedAuthRequest(ctx context.Context,
+	input *ValidatePushedAuthRequestInput) (*entities.Client, error) {
+
+	client, err := val.authenticateClient(ctx, input.ClientId, &clientAuthenticationInput{
+		ClientSecret:        input.ClientSecret,
+		ClientSecretBasic:   input.ClientSecretBasic,
+		ClientAssertionType: input.ClientAssertionType,
+		ClientAssertion:     input.ClientAssertion,
+		ClientCertificate:   input.ClientCertificate,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if !client.AuthorizationCodeEnabled {
+		return nil, customerrors.NewValidationError("unauthorized_client", "The client associated with the provided client_id does not support authorization code flow.")
+	}
+
+	return client, nil
+}
+
+type ValidateTokenIntrospectionRequestInput struct {
+	ClientId            string
+	ClientSecret        string
+	ClientSecretBasic   bool
+	ClientAssertionType string
+	ClientAssertion     string
+	ClientCertificate   *x509.Certificate
+	Token               string
+}
+
+func (val *TokenValidator) ValidateTokenIntrospectionRequest(ctx context.Context,
+	input *ValidateTokenIntrospectionRequestInput) (*entities.Client, error) {
+
+	client, err := val.authenticateClient(ctx, input.ClientId, &clientAuthenticationInput{
+		ClientSecret:        input.ClientSecret,
+		ClientSecretBasic:   input.ClientSecretBasic,
+		ClientAssertionType: input.ClientAssertionType,
+		ClientAssertion:     input.ClientAssertion,
+		ClientCertificate:   input.ClientCertificate,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if client.IsPublic {
+		return nil, customerrors.NewValidationError("unauthorized_client", "A public client is not eligible for token introspection. Please review the client configuration.")
+	}
+
+	if len(input.Token) == 0 {
+		return nil, customerrors.NewValidationError("invalid_request", "Missing required token parameter.")
+	}
+
+	return client, nil
+}
+
+type ValidateTokenRevocationRequestInput struct {
+	ClientId            string
+	ClientSecret        string
+	ClientSecretBasic   bool
+	ClientAssertionType string
+	ClientAssertion     string
+	ClientCertificate   *x509.Certificate
+	Token               string
+	TokenTypeHint       string
+}
+
+type ValidateTokenRevocationRequestResult struct {
+	Client       *entities.Client
+	RefreshToken *entities.RefreshToken
+}
+
+func (val *TokenValidator) ValidateTokenRevocationRequest(ctx context.Context,
+	input *ValidateTokenRevocationRequestInput) (*ValidateTokenRevocationRequestResult, error) {
+
+	client, err := val.authenticateClient(ctx, input.ClientId, &clientAuthenticationInput{
+		ClientSecret:        input.ClientSecret,
+		ClientSecretBasic:   input.ClientSecretBasic,
+		ClientAssertionType: input.ClientAssertionType,
+		ClientAssertion:     input.ClientAssertion,
+		ClientCertificate:   input.ClientCertificate,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if len(input.Token) == 0 {
+		return nil, customerrors.NewValidationError("invalid_request", "Missing required token parameter.")
+	}
+
+	const accessTokenNotSupportedMsg = "Revocation of access tokens is not supported. Only refresh tokens can be revoked."
+
+	// token_type_hint is only a hint (RFC 7009, section 2.1), the token type is determined from the token itself
+	tokenInfo, err := val.tokenParser.ParseToken(ctx, input.Token, true)
+	if err != nil {
+		// invalid tokens do not cause an error response (RFC 7009, section 2.2)
+		return &ValidateTokenRevocationRequestResult{
+			Client: client,
+		}, nil
+	}
+
+	tokenType := tokenInfo.GetStringClaim("typ")
+	if tokenType != "Refresh" && tokenType != "Offline" {
+		return nil, customerrors.NewValidationError("unsupported_token_type", accessTokenNotSupportedMsg)
+	}
+
+	refreshToken, err := val.database.GetRefreshTokenByJti(nil, tokenInfo.GetStringClaim("jti"))
+	if err != nil {
+		return nil, err
+	}
+	if refreshToken == nil {
+		return &ValidateTokenRevocationRequestResult{
+			Client: client,
+		}, nil
+	}
+
+	err = val.database.RefreshTokenLoadCode(nil, refreshToken)
+	if err != nil {
+		return nil, err
+	}
+
+	if refreshToken.Code.ClientId != client.Id {
+		return nil, customerrors.NewValidationError("invalid_request", "The refresh token is invalid because it does not belong to the client.")
+	}
+
+	return &ValidateTokenRevocationRequestResult{
+		Client:       client,
+		RefreshToken: refreshToken,
+	}, nil
+}
+
+type clientAuthenticationInput struct {
+	ClientSecret        string
+	ClientSecretBasic   bool
+	ClientAssertionType string
+	ClientAssertion     string
+	ClientCertificate   *x509.Certificate
+}
+
+func (val *TokenValidator) authenticateClient(ctx context.Context, clientId string,
+	authInput *clientAuthenticationInput) (*entities.Client, error) {
+
+	if len(clientId) == 0 {
+		return nil, customerrors.NewValidationError("invalid_request", "Missing required client_id parameter.")
+	}
+
+	client, err := val.database.GetClientByClientIdentifier(nil, clientId)
+	if err != nil {
+		return nil, err
+	}
+	if client == nil {
+		return nil, customerrors.NewValidationError("invalid_client", "Client does not exist.")
+	}
+	if !client.Enabled {
+		return nil, customerrors.NewValidationError("invalid_client", "Client is disabled.")
+	}
+
+	err = val.verifyClient(ctx, client, authInput,
+		customerrors.NewValidationError("invalid_client", "Client authentication failed."))
+	if err != nil {
+		return nil, err
+	}
+	return client, nil
+}
+
+// verifyClient authenticates a client that was already loaded, with the method it is configured for.
+// Public clients are not authenticated, but must not send a client secret. A wrong client secret
+// is answered with invalidClientSecretErr.
+func (val *TokenValidator) verifyClient(ctx context.Context, client *entities.Client,
+	authInput *clientAuthenticationInput, invalidClientSecretErr error) error {
+
+	settings := ctx.Value(common.ContextKeySettings).(*entities.Settings)
+
+	clientAuthenticated, err := val.validateClientAuthentication(ctx, client, authInput)
+	if err != nil {
+		return err
+	}
+	if clientAuthenticated {
+		return nil
+	}
+
+	clientSecret := authInput.ClientSecret
+
+	if client.IsPublic {
+		if len(clientSecret) > 0 {
+			return customerrors.NewValidationError("invalid_request", "This client is configured as public, which means a client_secret is not required. To proceed, please remove the client_secret from your request.")
+		}
+		return nil
+	}
+
+	if len(clientSecret) == 0 {
+		return customerrors.NewValidationError("invalid_request", "This client is configured as confidential (not public), which means a client_secret is required for authentication. Please provide a valid client_secret to proceed.")
+	}
+
+	clientSecretDecrypted, err := lib.DecryptText(client.ClientSecretEncrypted, settings.AESEncryptionKey)
+	if err != nil {
+		return err
+	}
+	if clientSecretDecrypted != clientSecret {
+		return invalidClientSecretErr
+	}
+	return nil
+}
+
+// validateClientAuthentication checks that the client authenticates with the method it is configured
+// for, and authenticates it when that is a JWT assertion (RFC 7523) or a TLS client certificate (RFC 8705).
+// It returns false when the client is public or authenticates with a client secret, so the caller checks the secret.
+func (val *TokenValidator) validateClientAuthentication(ctx context.Context, client *entities.Client,
+	authInput *clientAuthenticationInput) (bool, error) {
+
+	settings := ctx.Value(common.ContextKeySettings).(*entities.Settings)
+
+	clientSecret := authInput.ClientSecret
+	clientSecretBasic := authInput.ClientSecretBasic
+	clientAssertionType := authInput.ClientAssertionType
+	clientAssertion := authInput.ClientAssertion
+
+	authMethod, err := enums.TokenEndpointAuthMethodFromString(client.TokenEndpointAuthMethod)
+	if err != nil {
+		return false, err
+	}
+
+	isClientSecretMethod := authMethod == enums.TokenEndpointAuthMethodClientSecretPost ||
+		authMethod == enums.TokenEndpointAuthMethodClientSecretBasic
+
+	isTLSClientAuthMethod := authMethod == enums.TokenEndpointAuthMethodTLSClientAuth ||
+		authMethod == enums.TokenEndpointAuthMethodSelfSignedTLSClientAuth
+
+	if client.IsPublic || isClientSecretMethod || isTLSClientAuthMethod {
+		if len(clientAssertion) > 0 || len(clientAssertionType) > 0 {
+			return false, customerrors.NewValidationError("invalid_client", "The client associated with the provided client_id is not configured to authenticate with a client_assertion.")
+		}
+	}
+	if client.IsPublic {
+		return false, nil
+	}
+
+	if isClientSecretMethod {
+		if len(clientSecret) > 0 && !clientSecretBasic && authMethod == enums.TokenEndpointAuthMethodClientSecretBasic {
+			return false, customerrors.NewValidationError("invalid_client", "This client is configured to authenticate with client_secret_basic. Please send the client credentials in the Authorization header.")
+		}
+		if clientSecretBasic && authMethod == enums.TokenEndpointAuthMethodClientSecretPost {
+			return false, customerrors.NewValidationError("invalid_client", "This client is configured to authenticate with client_secret_post. Please send the client_secret in the request body.")
+		}
+		return false, nil
+	}
+
+	if len(clientSecret) > 0 {
+		return false, customerrors.NewValidationError("invalid_request", fmt.Sprintf("This client is configured to authenticate with %v, which means the client_secret must not be sent. To proceed, please remove the client_secret from your request.", authMethod.String()))
+	}
+
+	if isTLSClientAuthMethod {
+		return val.validateClientCertificate(client, authMethod, authInput.ClientCertificate)
+	}
+
+	if len(clientAssertion) == 0 {
+		return false, customerrors.NewValidationError("invalid_request", fmt.Sprintf("This client is configured to authenticate with %v, which means a client_assertion is required for authentication. Please provide a valid client_assertion to proceed.", authMethod.String()))
+	}
+
+	if clientAssertionType != constants.ClientAssertionTypeJwtBearer {
+		return false, customerrors.NewValidationError("invalid_request", "Unsupported client_assertion_type. Only "+constants.ClientAssertionTypeJwtBearer+" is supported.")
+	}
+
+	var keyFunc jwt.Keyfunc
+	var validMethods []string
+	switch authMethod {
+	case enums.TokenEndpointAuthMethodClientSecretJwt:
+		clientSecretDecrypted, err := lib.DecryptText(client.ClientSecretEncrypted, settings.AESEncryptionKey)
+		if err != nil {
+			return false, err
+		}
+		keyFunc = func(token *jwt.Token) (interface{}, error) {
+			return []byte(clientSecretDecrypted), nil
+		}
+		validMethods = []string{"HS256", "HS384", "HS512"}
+	case enums.TokenEndpointAuthMethodPrivateKeyJwt:
+		jwks, err := val.clientKeysResolver.GetClientKeys(ctx, client)
+		if err != nil {
+			slog.Warn(fmt.Sprintf("unable to get the keys of client %v: %+v", client.ClientIdentifier, err))
+			return false, customerrors.NewValidationError("invalid_client", "Unable to retrieve the keys of the client to verify the client_assertion.")
+		}
+		if jwks == nil {
+			return false, customerrors.NewValidationError("invalid_client", "The client associated with the provided client_id has no keys registered to verify client assertions.")
+		}
+		keyFunc = func(token *jwt.Token) (interface{}, error) {
+			kid, _ := token.Header["kid"].(string)
+			key := jwks.FindKey(kid)
+			if key == nil {
+				return nil, errors.New("no matching key found")
+			}
+			if len(key.Alg) > 0 && key.Alg != token.Method.Alg() {
+				return nil, errors.New("the key is not meant to be used with this algorithm")
+			}
+			return key.PublicKey()
+		}
+		validMethods = RequestObjectSigningAlgValuesSupported
+	}
+
+	claims := jwt.MapClaims{}
+	_, err = jwt.ParseWithClaims(clientAssertion, claims, keyFunc,
+		jwt.WithValidMethods(validMethods), jwt.WithExpirationRequired())
+	if err != nil {
+		return false, customerrors.NewValidationError("invalid_client", "Client authentication failed. The client_assertion is invalid. Its signature could not be verified, or it has expired.")
+	}
+
+	if claims["iss"] != client.ClientIdentifier || claims["sub"] != client.ClientIdentifier {
+		return false, customerrors.NewValidationError("invalid_client", "Client authentication failed. The iss and sub claims of the client_assertion must be the client_id.")
+	}
+
+	aud, err := claims.GetAudience()
+	if err != nil || (!slices.Contains(aud, settings.Issuer) && !slices.Contains(aud, lib.GetBaseUrl()+"/auth/token")) {
+		return false, customerrors.NewValidationError("invalid_client", "Client authentication failed. The aud claim of the client_assertion must be the issuer or the token endpoint URL.")
+	}
+
+	jti, _ := claims["jti"].(string)
+	if len(jti) == 0 {
+		return false, customerrors.NewValidationError("invalid_client", "Client authentication failed. The client_assertion is missing the jti claim.")
+	}
+
+	// a client assertion can only be used once
+	jtiHash, err := lib.HashString("client_assertion:" + client.ClientIdentifier + ":" + jti)
+	if err != nil {
+		return false, err
+	}
+	usedJti, err := val.database.GetUsedJtiByJtiHash(nil, jtiHash)
+	if err != nil {
+		return false, err
+	}
+	if usedJti != nil {
+		return false, customerrors.NewValidationError("invalid_client", "Client authentication failed. The client_assertion has already been used.")
+	}
+
+	exp, err := claims.GetExpirationTime()
+	if err != nil {
+		return false, err
+	}
+	err = val.database.DeleteUsedJtisExpired(nil)
+	if err != nil {
+		return false, err
+	}
+	err = val.database.CreateUsedJti(nil, &entities.UsedJti{
+		JtiHash:   jtiHash,
+		ExpiresAt: exp.Time.UTC(),
+	})
+	if err != nil {
+		// the same assertion was used in a concurrent request
+		if errors.Is(err, customerrors.ErrJtiAlreadyUsed) {
+			return false, customerrors.NewValidationError("invalid_client", "Client authentication failed. The client_assertion has already been used.")
+		}
+		return false, err
+	}
+
+	return true, nil
+}
+
+// validateClientCertificate authenticates the client with the certificate it presented in the TLS
+// connection (RFC 8705). With tls_client_auth the certificate must chain to a trusted CA and its subject
+// must match the one registered for the client; with self_signed_tls_client_auth it must be the certificate
+// registered for the client.
+func (val *TokenValidator) validateClientCertificate(client *entities.Client, authMethod enums.TokenEndpointAuthMethod,
+	clientCertificate *x509.Certificate) (bool, error) {
+
+	if clientCertificate == nil {
+		return false, customerrors.NewValidationError("invalid_client", fmt.Sprintf("This client is configured to authenticate with %v, which means a client certificate is required for authentication. Please present a valid client certificate in the TLS connection.", authMethod.String()))
+	}
+
+	switch authMethod {
+	case enums.TokenEndpointAuthMethodTLSClientAuth:
+		if !lib.IsClientCertificateCAConfigured() {
+			return false, customerrors.NewValidationError("invalid_client", "Client authentication failed. The tls_client_auth method is not available because no trusted certificate authority is configured on this server.")
+		}
+		roots, err := lib.GetClientCertificateCAPool()
+		if err != nil {
+			return false, err
+		}
+		_, err = clientCertificate.Verify(x509.VerifyOptions{
+			Roots:     roots,
+			KeyUsages: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+		})
+		if err != nil {
+			return false, customerrors.NewValidationError("invalid_client", "Client authentication failed. The client certificate could not be verified.")
+		}
+		if len(client.TLSClientAuthSubjectDN) == 0 || clientCertificate.Subject.String() != client.TLSClientAuthSubjectDN {
+			return false, customerrors.NewValidationError("invalid_client", "Client authentication failed. The subject of the client certificate does not match the one registered for the client.")
+		}
+	case enums.TokenEndpointAuthMethodSelfSignedTLSClientAuth:
+		if len(client.TLSClientCertificate) == 0 {
+			return false, customerrors.NewValidationError("invalid_client", "The client associated with the provided client_id has no certificate registered.")
+		}
+		registeredCertificate, err := lib.ParseCertificatePEM(client.TLSClientCertificate)
+		if err != nil {
+			return false, err
+		}
+		if lib.GetCertificateThumbprint(clientCertificate) != lib.GetCertificateThumbprint(registeredCertificate) {
+			return false, customerrors.NewValidationError("invalid_client", "Client authentication failed. The client certificate does not match the one registered for the client.")
+		}
+	}
+
+	return true, nil
 }
@@ -3,3 +3,7 @@ package customerrors
 import "github.com/pkg/errors"
 
 var ErrNoAuthContext = errors.New("unable to find auth context in session")
+
+// ErrJtiAlreadyUsed is returned when a used jti is stored twice, which happens when
+// the same token is presented in concurrent requests
+var ErrJtiAlreadyUsed = errors.New("the jti has already been used")
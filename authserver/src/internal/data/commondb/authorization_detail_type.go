@@ -0,0 +1,180 @@
+package commondb
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/huandu/go-sqlbuilder"
+	"github.com/leodip/goiabada/internal/entities"
+	"github.com/pkg/errors"
+)
+
+func (d *CommonDatabase) CreateAuthorizationDetailType(tx *sql.Tx, authorizationDetailType *entities.AuthorizationDetailType) error {
+
+	if authorizationDetailType.ResourceId == 0 {
+		return errors.WithStack(errors.New("can't create authorization detail type with resource_id 0"))
+	}
+
+	now := time.Now().UTC()
+
+	originalCreatedAt := authorizationDetailType.CreatedAt
+	originalUpdatedAt := authorizationDetailType.UpdatedAt
+	authorizationDetailType.CreatedAt = sql.NullTime{Time: now, Valid: true}
+	authorizationDetailType.UpdatedAt = sql.NullTime{Time: now, Valid: true}
+
+	authorizationDetailTypeStruct := sqlbuilder.NewStruct(new(entities.AuthorizationDetailType)).
+		For(d.Flavor)
+
+	insertBuilder := authorizationDetailTypeStruct.WithoutTag("pk").InsertInto("authorization_detail_types", authorizationDetailType)
+
+	sql, args := insertBuilder.Build()
+	result, err := d.ExecSql(tx, sql, args...)
+	if err != nil {
+		authorizationDetailType.CreatedAt = originalCreatedAt
+		authorizationDetailType.UpdatedAt = originalUpdatedAt
+		return errors.Wrap(err, "unable to insert authorization detail type")
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		authorizationDetailType.CreatedAt = originalCreatedAt
+		authorizationDetailType.UpdatedAt = originalUpdatedAt
+		return errors.Wrap(err, "unable to get last insert id")
+	}
+
+	authorizationDetailType.Id = id
+	return nil
+}
+
+func (d *CommonDatabase) UpdateAuthorizationDetailType(tx *sql.Tx, authorizationDetailType *entities.AuthorizationDetailType) error {
+
+	if authorizationDetailType.Id == 0 {
+		return errors.WithStack(errors.New("can't update authorization detail type with id 0"))
+	}
+
+	originalUpdatedAt := authorizationDetailType.UpdatedAt
+	authorizationDetailType.UpdatedAt = sql.NullTime{Time: time.Now().UTC(), Valid: true}
+
+	authorizationDetailTypeStruct := sqlbuilder.NewStruct(new(entities.AuthorizationDetailType)).
+		For(d.Flavor)
+
+	updateBuilder := authorizationDetailTypeStruct.WithoutTag("pk").Update("authorization_detail_types", authorizationDetailType)
+	updateBuilder.Where(updateBuilder.Equal("id", authorizationDetailType.Id))
+
+	sql, args := updateBuilder.Build()
+	_, err := d.ExecSql(tx, sql, args...)
+	if err != nil {
+		authorizationDetailType.UpdatedAt = originalUpdatedAt
+		return errors.Wrap(err, "unable to update authorization detail type")
+	}
+
+	return nil
+}
+
+func (d *CommonDatabase) getAuthorizationDetailTypeCommon(tx *sql.Tx, selectBuilder *sqlbuilder.SelectBuilder,
+	authorizationDetailTypeStruct *sqlbuilder.Struct) (*entities.AuthorizationDetailType, error) {
+
+	sql, args := selectBuilder.Build()
+	rows, err := d.QuerySql(tx, sql, args...)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to query database")
+	}
+	defer rows.Close()
+
+	var authorizationDetailType entities.AuthorizationDetailType
+	if rows.Next() {
+		addr := authorizationDetailTypeStruct.Addr(&authorizationDetailType)
+		err = rows.Scan(addr...)
+		if err != nil {
+			return nil, errors.Wrap(err, "unable to scan authorization detail type")
+		}
+		return &authorizationDetailType, nil
+	}
+	return nil, nil
+}
+
+func (d *CommonDatabase) getAuthorizationDetailTypesCommon(tx *sql.Tx, selectBuilder *sqlbuilder.SelectBuilder,
+	authorizationDetailTypeStruct *sqlbuilder.Struct) ([]entities.AuthorizationDetailType, error) {
+
+	sql, args := selectBuilder.Build()
+	rows, err := d.QuerySql(tx, sql, args...)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to query database")
+	}
+	defer rows.Close()
+
+	var authorizationDetailTypes []entities.AuthorizationDetailType
+	for rows.Next() {
+		var authorizationDetailType entities.AuthorizationDetailType
+		addr := authorizationDetailTypeStruct.Addr(&authorizationDetailType)
+		err = rows.Scan(addr...)
+		if err != nil {
+			return nil, errors.Wrap(err, "unable to scan authorization detail type")
+		}
+		authorizationDetailTypes = append(authorizationDetailTypes, authorizationDetailType)
+	}
+
+	return authorizationDetailTypes, nil
+}
+
+func (d *CommonDatabase) GetAuthorizationDetailTypeById(tx *sql.Tx, authorizationDetailTypeId int64) (*entities.AuthorizationDetailType, error) {
+
+	authorizationDetailTypeStruct := sqlbuilder.NewStruct(new(entities.AuthorizationDetailType)).
+		For(d.Flavor)
+
+	selectBuilder := authorizationDetailTypeStruct.SelectFrom("authorization_detail_types")
+	selectBuilder.Where(selectBuilder.Equal("id", authorizationDetailTypeId))
+
+	return d.getAuthorizationDetailTypeCommon(tx, selectBuilder, authorizationDetailTypeStruct)
+}
+
+func (d *CommonDatabase) GetAuthorizationDetailTypeByType(tx *sql.Tx, authorizationDetailType string) (*entities.AuthorizationDetailType, error) {
+
+	authorizationDetailTypeStruct := sqlbuilder.NewStruct(new(entities.AuthorizationDetailType)).
+		For(d.Flavor)
+
+	selectBuilder := authorizationDetailTypeStruct.SelectFrom("authorization_detail_types")
+	selectBuilder.Where(selectBuilder.Equal(d.Flavor.Quote("type"), authorizationDetailType))
+
+	return d.getAuthorizationDetailTypeCommon(tx, selectBuilder, authorizationDetailTypeStruct)
+}
+
+func (d *CommonDatabase) GetAuthorizationDetailTypesByResourceId(tx *sql.Tx, resourceId int64) ([]entities.AuthorizationDetailType, error) {
+
+	authorizationDetailTypeStruct := sqlbuilder.NewStruct(new(entities.AuthorizationDetailType)).
+		For(d.Flavor)
+
+	selectBuilder := authorizationDetailTypeStruct.SelectFrom("authorization_detail_types")
+	selectBuilder.Where(selectBuilder.Equal("resource_id", resourceId))
+	selectBuilder.OrderBy(d.Flavor.Quote("type")).Asc()
+
+	return d.getAuthorizationDetailTypesCommon(tx, selectBuilder, authorizationDetailTypeStruct)
+}
+
+func (d *CommonDatabase) GetAllAuthorizationDetailTypes(tx *sql.Tx) ([]entities.AuthorizationDetailType, error) {
+
+	authorizationDetailTypeStruct := sqlbuilder.NewStruct(new(entities.AuthorizationDetailType)).
+		For(d.Flavor)
+
+	selectBuilder := authorizationDetailTypeStruct.SelectFrom("authorization_detail_types")
+	selectBuilder.OrderBy(d.Flavor.Quote("type")).Asc()
+
+	return d.getAuthorizationDetailTypesCommon(tx, selectBuilder, authorizationDetailTypeStruct)
+}
+
+func (d *CommonDatabase) DeleteAuthorizationDetailType(tx *sql.Tx, authorizationDetailTypeId int64) error {
+
+	authorizationDetailTypeStruct := sqlbuilder.NewStruct(new(entities.AuthorizationDetailType)).
+		For(d.Flavor)
+
+	deleteBuilder := authorizationDetailTypeStruct.DeleteFrom("authorization_detail_types")
+	deleteBuilder.Where(deleteBuilder.Equal("id", authorizationDetailTypeId))
+
+	sql, args := deleteBuilder.Build()
+	_, err := d.ExecSql(tx, sql, args...)
+	if err != nil {
+		return errors.Wrap(err, "unable to delete authorization detail type")
+	}
+
+	return nil
+}
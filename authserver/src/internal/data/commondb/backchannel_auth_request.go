@@ -0,0 +1,195 @@
+package commondb
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/huandu/go-sqlbuilder"
+	"github.com/leodip/goiabada/internal/entities"
+	"github.com/leodip/goiabada/internal/enums"
+	"github.com/pkg/errors"
+)
+
+func (d *CommonDatabase) CreateBackchannelAuthRequest(tx *sql.Tx, backchannelAuthRequest *entities.BackchannelAuthRequest) error {
+
+	if backchannelAuthRequest.ClientId == 0 {
+		return errors.WithStack(errors.New("client id must be greater than 0"))
+	}
+
+	now := time.Now().UTC()
+
+	originalCreatedAt := backchannelAuthRequest.CreatedAt
+	originalUpdatedAt := backchannelAuthRequest.UpdatedAt
+	backchannelAuthRequest.CreatedAt = sql.NullTime{Time: now, Valid: true}
+	backchannelAuthRequest.UpdatedAt = sql.NullTime{Time: now, Valid: true}
+
+	backchannelAuthRequestStruct := sqlbuilder.NewStruct(new(entities.BackchannelAuthRequest)).
+		For(d.Flavor)
+
+	insertBuilder := backchannelAuthRequestStruct.WithoutTag("pk").InsertInto("backchannel_auth_requests", backchannelAuthRequest)
+
+	sql, args := insertBuilder.Build()
+	result, err := d.ExecSql(tx, sql, args...)
+	if err != nil {
+		backchannelAuthRequest.CreatedAt = originalCreatedAt
+		backchannelAuthRequest.UpdatedAt = originalUpdatedAt
+		return errors.Wrap(err, "unable to insert backchannelAuthRequest")
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		backchannelAuthRequest.CreatedAt = originalCreatedAt
+		backchannelAuthRequest.UpdatedAt = originalUpdatedAt
+		return errors.Wrap(err, "unable to get last insert id")
+	}
+
+	backchannelAuthRequest.Id = id
+	return nil
+}
+
+func (d *CommonDatabase) UpdateBackchannelAuthRequest(tx *sql.Tx, backchannelAuthRequest *entities.BackchannelAuthRequest) error {
+
+	if backchannelAuthRequest.Id == 0 {
+		return errors.WithStack(errors.New("can't update backchannelAuthRequest with id 0"))
+	}
+
+	originalUpdatedAt := backchannelAuthRequest.UpdatedAt
+	backchannelAuthRequest.UpdatedAt = sql.NullTime{Time: time.Now().UTC(), Valid: true}
+
+	backchannelAuthRequestStruct := sqlbuilder.NewStruct(new(entities.BackchannelAuthRequest)).
+		For(d.Flavor)
+
+	updateBuilder := backchannelAuthRequestStruct.WithoutTag("pk").Update("backchannel_auth_requests", backchannelAuthRequest)
+	updateBuilder.Where(updateBuilder.Equal("id", backchannelAuthRequest.Id))
+
+	sql, args := updateBuilder.Build()
+	_, err := d.ExecSql(tx, sql, args...)
+	if err != nil {
+		backchannelAuthRequest.UpdatedAt = originalUpdatedAt
+		return errors.Wrap(err, "unable to update backchannelAuthRequest")
+	}
+
+	return nil
+}
+
+func (d *CommonDatabase) getBackchannelAuthRequestCommon(tx *sql.Tx, selectBuilder *sqlbuilder.SelectBuilder,
+	backchannelAuthRequestStruct *sqlbuilder.Struct) (*entities.BackchannelAuthRequest, error) {
+
+	sql, args := selectBuilder.Build()
+	rows, err := d.QuerySql(tx, sql, args...)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to query database")
+	}
+	defer rows.Close()
+
+	var backchannelAuthRequest entities.BackchannelAuthRequest
+	if rows.Next() {
+		addr := backchannelAuthRequestStruct.Addr(&backchannelAuthRequest)
+		err = rows.Scan(addr...)
+		if err != nil {
+			return nil, errors.Wrap(err, "unable to scan backchannelAuthRequest")
+		}
+		return &backchannelAuthRequest, nil
+	}
+	return nil, nil
+}
+
+func (d *CommonDatabase) GetBackchannelAuthRequestById(tx *sql.Tx, backchannelAuthRequestId int64) (*entities.BackchannelAuthRequest, error) {
+
+	backchannelAuthRequestStruct := sqlbuilder.NewStruct(new(entities.BackchannelAuthRequest)).
+		For(d.Flavor)
+
+	selectBuilder := backchannelAuthRequestStruct.SelectFrom("backchannel_auth_requests")
+	selectBuilder.Where(selectBuilder.Equal("id", backchannelAuthRequestId))
+
+	backchannelAuthRequest, err := d.getBackchannelAuthRequestCommon(tx, selectBuilder, backchannelAuthRequestStruct)
+	if err != nil {
+		return nil, err
+	}
+
+	return backchannelAuthRequest, nil
+}
+
+func (d *CommonDatabase) GetBackchannelAuthRequestByAuthReqIdHash(tx *sql.Tx, authReqIdHash string) (*entities.BackchannelAuthRequest, error) {
+
+	backchannelAuthRequestStruct := sqlbuilder.NewStruct(new(entities.BackchannelAuthRequest)).
+		For(d.Flavor)
+
+	selectBuilder := backchannelAuthRequestStruct.SelectFrom("backchannel_auth_requests")
+	selectBuilder.Where(selectBuilder.Equal("auth_req_id_hash", authReqIdHash))
+
+	backchannelAuthRequest, err := d.getBackchannelAuthRequestCommon(tx, selectBuilder, backchannelAuthRequestStruct)
+	if err != nil {
+		return nil, err
+	}
+
+	return backchannelAuthRequest, nil
+}
+
+func (d *CommonDatabase) GetPendingBackchannelAuthRequestsByUserId(tx *sql.Tx, userId int64) ([]entities.BackchannelAuthRequest, error) {
+
+	backchannelAuthRequestStruct := sqlbuilder.NewStruct(new(entities.BackchannelAuthRequest)).
+		For(d.Flavor)
+
+	selectBuilder := backchannelAuthRequestStruct.SelectFrom("backchannel_auth_requests")
+	selectBuilder.Where(
+		selectBuilder.Equal("user_id", userId),
+		selectBuilder.Equal(d.Flavor.Quote("state"), enums.BackchannelAuthRequestStatePending.String()),
+		selectBuilder.GreaterThan("expires_at", time.Now().UTC()),
+	)
+	selectBuilder.OrderBy("id").Asc()
+
+	sql, args := selectBuilder.Build()
+	rows, err := d.QuerySql(tx, sql, args...)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to query database")
+	}
+	defer rows.Close()
+
+	var backchannelAuthRequests []entities.BackchannelAuthRequest
+	for rows.Next() {
+		var backchannelAuthRequest entities.BackchannelAuthRequest
+		addr := backchannelAuthRequestStruct.Addr(&backchannelAuthRequest)
+		err = rows.Scan(addr...)
+		if err != nil {
+			return nil, errors.Wrap(err, "unable to scan backchannelAuthRequest")
+		}
+		backchannelAuthRequests = append(backchannelAuthRequests, backchannelAuthRequest)
+	}
+
+	return backchannelAuthRequests, nil
+}
+
+func (d *CommonDatabase) BackchannelAuthRequestLoadClient(tx *sql.Tx, backchannelAuthRequest *entities.BackchannelAuthRequest) error {
+
+	if backchannelAuthRequest == nil {
+		return nil
+	}
+
+	client, err := d.GetClientById(tx, backchannelAuthRequest.ClientId)
+	if err != nil {
+		return errors.Wrap(err, "unable to load client")
+	}
+
+	if client != nil {
+		backchannelAuthRequest.Client = *client
+	}
+	return nil
+}
+
+func (d *CommonDatabase) DeleteBackchannelAuthRequest(tx *sql.Tx, backchannelAuthRequestId int64) error {
+
+	backchannelAuthRequestStruct := sqlbuilder.NewStruct(new(entities.BackchannelAuthRequest)).
+		For(d.Flavor)
+
+	deleteBuilder := backchannelAuthRequestStruct.DeleteFrom("backchannel_auth_requests")
+	deleteBuilder.Where(deleteBuilder.Equal("id", backchannelAuthRequestId))
+
+	sql, args := deleteBuilder.Build()
+	_, err := d.ExecSql(tx, sql, args...)
+	if err != nil {
+		return errors.Wrap(err, "unable to delete backchannelAuthRequest")
+	}
+
+	return nil
+}
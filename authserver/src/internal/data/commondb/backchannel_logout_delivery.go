@@ -0,0 +1,103 @@
+package commondb
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/huandu/go-sqlbuilder"
+	"github.com/leodip/goiabada/internal/entities"
+	"github.com/pkg/errors"
+)
+
+func (d *CommonDatabase) CreateBackChannelLogoutDelivery(tx *sql.Tx, delivery *entities.BackChannelLogoutDelivery) error {
+
+	if delivery.ClientId == 0 {
+		return errors.WithStack(errors.New("client id must be greater than 0"))
+	}
+
+	now := time.Now().UTC()
+
+	originalCreatedAt := delivery.CreatedAt
+	originalUpdatedAt := delivery.UpdatedAt
+	delivery.CreatedAt = sql.NullTime{Time: now, Valid: true}
+	delivery.UpdatedAt = sql.NullTime{Time: now, Valid: true}
+
+	deliveryStruct := sqlbuilder.NewStruct(new(entities.BackChannelLogoutDelivery)).
+		For(d.Flavor)
+
+	insertBuilder := deliveryStruct.WithoutTag("pk").InsertInto("backchannel_logout_deliveries", delivery)
+
+	sql, args := insertBuilder.Build()
+	result, err := d.ExecSql(tx, sql, args...)
+	if err != nil {
+		delivery.CreatedAt = originalCreatedAt
+		delivery.UpdatedAt = originalUpdatedAt
+		return errors.Wrap(err, "unable to insert backChannelLogoutDelivery")
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		delivery.CreatedAt = originalCreatedAt
+		delivery.UpdatedAt = originalUpdatedAt
+		return errors.Wrap(err, "unable to get last insert id")
+	}
+
+	delivery.Id = id
+	return nil
+}
+
+func (d *CommonDatabase) UpdateBackChannelLogoutDelivery(tx *sql.Tx, delivery *entities.BackChannelLogoutDelivery) error {
+
+	if delivery.Id == 0 {
+		return errors.WithStack(errors.New("can't update backChannelLogoutDelivery with id 0"))
+	}
+
+	originalUpdatedAt := delivery.UpdatedAt
+	delivery.UpdatedAt = sql.NullTime{Time: time.Now().UTC(), Valid: true}
+
+	deliveryStruct := sqlbuilder.NewStruct(new(entities.BackChannelLogoutDelivery)).
+		For(d.Flavor)
+
+	updateBuilder := deliveryStruct.WithoutTag("pk").Update("backchannel_logout_deliveries", delivery)
+	updateBuilder.Where(updateBuilder.Equal("id", delivery.Id))
+
+	sql, args := updateBuilder.Build()
+	_, err := d.ExecSql(tx, sql, args...)
+	if err != nil {
+		delivery.UpdatedAt = originalUpdatedAt
+		return errors.Wrap(err, "unable to update backChannelLogoutDelivery")
+	}
+
+	return nil
+}
+
+func (d *CommonDatabase) GetBackChannelLogoutDeliveriesByClientId(tx *sql.Tx, clientId int64, limit int) ([]entities.BackChannelLogoutDelivery, error) {
+
+	deliveryStruct := sqlbuilder.NewStruct(new(entities.BackChannelLogoutDelivery)).
+		For(d.Flavor)
+
+	selectBuilder := deliveryStruct.SelectFrom("backchannel_logout_deliveries")
+	selectBuilder.Where(selectBuilder.Equal("client_id", clientId))
+	selectBuilder.OrderBy("id").Desc()
+	selectBuilder.Limit(limit)
+
+	sql, args := selectBuilder.Build()
+	rows, err := d.QuerySql(tx, sql, args...)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to query database")
+	}
+	defer rows.Close()
+
+	var deliveries []entities.BackChannelLogoutDelivery
+	for rows.Next() {
+		var delivery entities.BackChannelLogoutDelivery
+		addr := deliveryStruct.Addr(&delivery)
+		err = rows.Scan(addr...)
+		if err != nil {
+			return nil, errors.Wrap(err, "unable to scan backChannelLogoutDelivery")
+		}
+		deliveries = append(deliveries, delivery)
+	}
+
+	return deliveries, nil
+}
@@ -0,0 +1,176 @@
+package commondb
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/huandu/go-sqlbuilder"
+	"github.com/leodip/goiabada/internal/entities"
+	"github.com/pkg/errors"
+)
+
+func (d *CommonDatabase) CreateDeviceCode(tx *sql.Tx, deviceCode *entities.DeviceCode) error {
+
+	if deviceCode.ClientId == 0 {
+		return errors.WithStack(errors.New("client id must be greater than 0"))
+	}
+
+	now := time.Now().UTC()
+
+	originalCreatedAt := deviceCode.CreatedAt
+	originalUpdatedAt := deviceCode.UpdatedAt
+	deviceCode.CreatedAt = sql.NullTime{Time: now, Valid: true}
+	deviceCode.UpdatedAt = sql.NullTime{Time: now, Valid: true}
+
+	deviceCodeStruct := sqlbuilder.NewStruct(new(entities.DeviceCode)).
+		For(d.Flavor)
+
+	insertBuilder := deviceCodeStruct.WithoutTag("pk").InsertInto("device_codes", deviceCode)
+
+	sql, args := insertBuilder.Build()
+	result, err := d.ExecSql(tx, sql, args...)
+	if err != nil {
+		deviceCode.CreatedAt = originalCreatedAt
+		deviceCode.UpdatedAt = originalUpdatedAt
+		return errors.Wrap(err, "unable to insert deviceCode")
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		deviceCode.CreatedAt = originalCreatedAt
+		deviceCode.UpdatedAt = originalUpdatedAt
+		return errors.Wrap(err, "unable to get last insert id")
+	}
+
+	deviceCode.Id = id
+	return nil
+}
+
+func (d *CommonDatabase) UpdateDeviceCode(tx *sql.Tx, deviceCode *entities.DeviceCode) error {
+
+	if deviceCode.Id == 0 {
+		return errors.WithStack(errors.New("can't update deviceCode with id 0"))
+	}
+
+	originalUpdatedAt := deviceCode.UpdatedAt
+	deviceCode.UpdatedAt = sql.NullTime{Time: time.Now().UTC(), Valid: true}
+
+	deviceCodeStruct := sqlbuilder.NewStruct(new(entities.DeviceCode)).
+		For(d.Flavor)
+
+	updateBuilder := deviceCodeStruct.WithoutTag("pk").Update("device_codes", deviceCode)
+	updateBuilder.Where(updateBuilder.Equal("id", deviceCode.Id))
+
+	sql, args := updateBuilder.Build()
+	_, err := d.ExecSql(tx, sql, args...)
+	if err != nil {
+		deviceCode.UpdatedAt = originalUpdatedAt
+		return errors.Wrap(err, "unable to update deviceCode")
+	}
+
+	return nil
+}
+
+func (d *CommonDatabase) getDeviceCodeCommon(tx *sql.Tx, selectBuilder *sqlbuilder.SelectBuilder,
+	deviceCodeStruct *sqlbuilder.Struct) (*entities.DeviceCode, error) {
+
+	sql, args := selectBuilder.Build()
+	rows, err := d.QuerySql(tx, sql, args...)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to query database")
+	}
+	defer rows.Close()
+
+	var deviceCode entities.DeviceCode
+	if rows.Next() {
+		addr := deviceCodeStruct.Addr(&deviceCode)
+		err = rows.Scan(addr...)
+		if err != nil {
+			return nil, errors.Wrap(err, "unable to scan deviceCode")
+		}
+		return &deviceCode, nil
+	}
+	return nil, nil
+}
+
+func (d *CommonDatabase) GetDeviceCodeById(tx *sql.Tx, deviceCodeId int64) (*entities.DeviceCode, error) {
+
+	deviceCodeStruct := sqlbuilder.NewStruct(new(entities.DeviceCode)).
+		For(d.Flavor)
+
+	selectBuilder := deviceCodeStruct.SelectFrom("device_codes")
+	selectBuilder.Where(selectBuilder.Equal("id", deviceCodeId))
+
+	deviceCode, err := d.getDeviceCodeCommon(tx, selectBuilder, deviceCodeStruct)
+	if err != nil {
+		return nil, err
+	}
+
+	return deviceCode, nil
+}
+
+func (d *CommonDatabase) GetDeviceCodeByDeviceCodeHash(tx *sql.Tx, deviceCodeHash string) (*entities.DeviceCode, error) {
+
+	deviceCodeStruct := sqlbuilder.NewStruct(new(entities.DeviceCode)).
+		For(d.Flavor)
+
+	selectBuilder := deviceCodeStruct.SelectFrom("device_codes")
+	selectBuilder.Where(selectBuilder.Equal("device_code_hash", deviceCodeHash))
+
+	deviceCode, err := d.getDeviceCodeCommon(tx, selectBuilder, deviceCodeStruct)
+	if err != nil {
+		return nil, err
+	}
+
+	return deviceCode, nil
+}
+
+func (d *CommonDatabase) GetDeviceCodeByUserCode(tx *sql.Tx, userCode string) (*entities.DeviceCode, error) {
+
+	deviceCodeStruct := sqlbuilder.NewStruct(new(entities.DeviceCode)).
+		For(d.Flavor)
+
+	selectBuilder := deviceCodeStruct.SelectFrom("device_codes")
+	selectBuilder.Where(selectBuilder.Equal("user_code", userCode))
+
+	deviceCode, err := d.getDeviceCodeCommon(tx, selectBuilder, deviceCodeStruct)
+	if err != nil {
+		return nil, err
+	}
+
+	return deviceCode, nil
+}
+
+func (d *CommonDatabase) DeviceCodeLoadClient(tx *sql.Tx, deviceCode *entities.DeviceCode) error {
+
+	if deviceCode == nil {
+		return nil
+	}
+
+	client, err := d.GetClientById(tx, deviceCode.ClientId)
+	if err != nil {
+		return errors.Wrap(err, "unable to load client")
+	}
+
+	if client != nil {
+		deviceCode.Client = *client
+	}
+	return nil
+}
+
+func (d *CommonDatabase) DeleteDeviceCode(tx *sql.Tx, deviceCodeId int64) error {
+
+	deviceCodeStruct := sqlbuilder.NewStruct(new(entities.DeviceCode)).
+		For(d.Flavor)
+
+	deleteBuilder := deviceCodeStruct.DeleteFrom("device_codes")
+	deleteBuilder.Where(deleteBuilder.Equal("id", deviceCodeId))
+
+	sql, args := deleteBuilder.Build()
+	_, err := d.ExecSql(tx, sql, args...)
+	if err != nil {
+		return errors.Wrap(err, "unable to delete deviceCode")
+	}
+
+	return nil
+}
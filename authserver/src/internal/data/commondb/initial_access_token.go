@@ -0,0 +1,120 @@
+package commondb
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/huandu/go-sqlbuilder"
+	"github.com/leodip/goiabada/internal/entities"
+	"github.com/pkg/errors"
+)
+
+func (d *CommonDatabase) CreateInitialAccessToken(tx *sql.Tx, initialAccessToken *entities.InitialAccessToken) error {
+
+	if len(initialAccessToken.TokenHash) == 0 {
+		return errors.WithStack(errors.New("token hash must not be empty"))
+	}
+
+	now := time.Now().UTC()
+
+	originalCreatedAt := initialAccessToken.CreatedAt
+	originalUpdatedAt := initialAccessToken.UpdatedAt
+	initialAccessToken.CreatedAt = sql.NullTime{Time: now, Valid: true}
+	initialAccessToken.UpdatedAt = sql.NullTime{Time: now, Valid: true}
+
+	initialAccessTokenStruct := sqlbuilder.NewStruct(new(entities.InitialAccessToken)).
+		For(d.Flavor)
+
+	insertBuilder := initialAccessTokenStruct.WithoutTag("pk").InsertInto("initial_access_tokens", initialAccessToken)
+
+	sql, args := insertBuilder.Build()
+	result, err := d.ExecSql(tx, sql, args...)
+	if err != nil {
+		initialAccessToken.CreatedAt = originalCreatedAt
+		initialAccessToken.UpdatedAt = originalUpdatedAt
+		return errors.Wrap(err, "unable to insert initialAccessToken")
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		initialAccessToken.CreatedAt = originalCreatedAt
+		initialAccessToken.UpdatedAt = originalUpdatedAt
+		return errors.Wrap(err, "unable to get last insert id")
+	}
+
+	initialAccessToken.Id = id
+	return nil
+}
+
+func (d *CommonDatabase) GetInitialAccessTokenByTokenHash(tx *sql.Tx, tokenHash string) (*entities.InitialAccessToken, error) {
+
+	initialAccessTokenStruct := sqlbuilder.NewStruct(new(entities.InitialAccessToken)).
+		For(d.Flavor)
+
+	selectBuilder := initialAccessTokenStruct.SelectFrom("initial_access_tokens")
+	selectBuilder.Where(selectBuilder.Equal("token_hash", tokenHash))
+
+	sql, args := selectBuilder.Build()
+	rows, err := d.QuerySql(tx, sql, args...)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to query database")
+	}
+	defer rows.Close()
+
+	var initialAccessToken entities.InitialAccessToken
+	if rows.Next() {
+		addr := initialAccessTokenStruct.Addr(&initialAccessToken)
+		err = rows.Scan(addr...)
+		if err != nil {
+			return nil, errors.Wrap(err, "unable to scan initialAccessToken")
+		}
+		return &initialAccessToken, nil
+	}
+	return nil, nil
+}
+
+func (d *CommonDatabase) GetAllInitialAccessTokens(tx *sql.Tx) ([]entities.InitialAccessToken, error) {
+
+	initialAccessTokenStruct := sqlbuilder.NewStruct(new(entities.InitialAccessToken)).
+		For(d.Flavor)
+
+	selectBuilder := initialAccessTokenStruct.SelectFrom("initial_access_tokens")
+	selectBuilder.OrderBy("created_at").Desc()
+
+	sql, args := selectBuilder.Build()
+	rows, err := d.QuerySql(tx, sql, args...)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to query database")
+	}
+	defer rows.Close()
+
+	var initialAccessTokens []entities.InitialAccessToken
+	for rows.Next() {
+		var initialAccessToken entities.InitialAccessToken
+		addr := initialAccessTokenStruct.Addr(&initialAccessToken)
+		err = rows.Scan(addr...)
+		if err != nil {
+			return nil, errors.Wrap(err, "unable to scan initialAccessToken")
+		}
+		initialAccessTokens = append(initialAccessTokens, initialAccessToken)
+	}
+
+	return initialAccessTokens, nil
+}
+
+func (d *CommonDatabase) DeleteInitialAccessToken(tx *sql.Tx, initialAccessTokenId int64) error {
+
+	initialAccessTokenStruct := sqlbuilder.NewStruct(new(entities.InitialAccessToken)).
+		For(d.Flavor)
+
+	deleteBuilder := initialAccessTokenStruct.DeleteFrom("initial_access_tokens")
+	deleteBuilder.Where(deleteBuilder.Equal("id", initialAccessTokenId))
+
+	sql, args := deleteBuilder.Build()
+	_, err := d.ExecSql(tx, sql, args...)
+	if err != nil {
+		return errors.Wrap(err, "unable to delete initialAccessToken")
+	}
+
+	return nil
+}
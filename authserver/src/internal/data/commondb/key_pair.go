@@ -133,12 +133,15 @@ func (d *CommonDatabase) GetAllSigningKeys(tx *sql.Tx) ([]entities.KeyPair, erro
 	return keyPairs, nil
 }
 
-func (d *CommonDatabase) GetCurrentSigningKey(tx *sql.Tx) (*entities.KeyPair, error) {
+func (d *CommonDatabase) GetCurrentSigningKey(tx *sql.Tx, algorithm string) (*entities.KeyPair, error) {
 	keyPairStruct := sqlbuilder.NewStruct(new(entities.KeyPair)).
 		For(d.Flavor)
 
 	selectBuilder := keyPairStruct.SelectFrom("key_pairs")
-	selectBuilder.Where(selectBuilder.Equal("state", enums.KeyStateCurrent.String()))
+	selectBuilder.Where(
+		selectBuilder.Equal("state", enums.KeyStateCurrent.String()),
+		selectBuilder.Equal(d.Flavor.Quote("algorithm"), algorithm),
+	)
 
 	keyPair, err := d.getKeyPairCommon(tx, selectBuilder, keyPairStruct)
 	if err != nil {
@@ -0,0 +1,99 @@
+package commondb
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/huandu/go-sqlbuilder"
+	"github.com/leodip/goiabada/internal/entities"
+	"github.com/pkg/errors"
+)
+
+func (d *CommonDatabase) CreatePairwiseSubject(tx *sql.Tx, pairwiseSubject *entities.PairwiseSubject) error {
+
+	if pairwiseSubject.UserId == 0 {
+		return errors.WithStack(errors.New("user id must be greater than 0"))
+	}
+
+	if len(pairwiseSubject.SectorIdentifier) == 0 {
+		return errors.WithStack(errors.New("sector identifier must not be empty"))
+	}
+
+	now := time.Now().UTC()
+
+	originalCreatedAt := pairwiseSubject.CreatedAt
+	originalUpdatedAt := pairwiseSubject.UpdatedAt
+	pairwiseSubject.CreatedAt = sql.NullTime{Time: now, Valid: true}
+	pairwiseSubject.UpdatedAt = sql.NullTime{Time: now, Valid: true}
+
+	pairwiseSubjectStruct := sqlbuilder.NewStruct(new(entities.PairwiseSubject)).
+		For(d.Flavor)
+
+	insertBuilder := pairwiseSubjectStruct.WithoutTag("pk").InsertInto("pairwise_subjects", pairwiseSubject)
+
+	sql, args := insertBuilder.Build()
+	result, err := d.ExecSql(tx, sql, args...)
+	if err != nil {
+		pairwiseSubject.CreatedAt = originalCreatedAt
+		pairwiseSubject.UpdatedAt = originalUpdatedAt
+		return errors.Wrap(err, "unable to insert pairwiseSubject")
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		pairwiseSubject.CreatedAt = originalCreatedAt
+		pairwiseSubject.UpdatedAt = originalUpdatedAt
+		return errors.Wrap(err, "unable to get last insert id")
+	}
+
+	pairwiseSubject.Id = id
+	return nil
+}
+
+func (d *CommonDatabase) GetPairwiseSubjectByUserIdAndSectorIdentifier(tx *sql.Tx, userId int64,
+	sectorIdentifier string) (*entities.PairwiseSubject, error) {
+
+	pairwiseSubjectStruct := sqlbuilder.NewStruct(new(entities.PairwiseSubject)).
+		For(d.Flavor)
+
+	selectBuilder := pairwiseSubjectStruct.SelectFrom("pairwise_subjects")
+	selectBuilder.Where(
+		selectBuilder.Equal("user_id", userId),
+		selectBuilder.Equal("sector_identifier", sectorIdentifier),
+	)
+
+	return d.getPairwiseSubjectCommon(tx, selectBuilder, pairwiseSubjectStruct)
+}
+
+func (d *CommonDatabase) GetPairwiseSubjectBySubject(tx *sql.Tx, subject string) (*entities.PairwiseSubject, error) {
+
+	pairwiseSubjectStruct := sqlbuilder.NewStruct(new(entities.PairwiseSubject)).
+		For(d.Flavor)
+
+	selectBuilder := pairwiseSubjectStruct.SelectFrom("pairwise_subjects")
+	selectBuilder.Where(selectBuilder.Equal("subject", subject))
+
+	return d.getPairwiseSubjectCommon(tx, selectBuilder, pairwiseSubjectStruct)
+}
+
+func (d *CommonDatabase) getPairwiseSubjectCommon(tx *sql.Tx, selectBuilder *sqlbuilder.SelectBuilder,
+	pairwiseSubjectStruct *sqlbuilder.Struct) (*entities.PairwiseSubject, error) {
+
+	sql, args := selectBuilder.Build()
+	rows, err := d.QuerySql(tx, sql, args...)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to query database")
+	}
+	defer rows.Close()
+
+	var pairwiseSubject entities.PairwiseSubject
+	if rows.Next() {
+		addr := pairwiseSubjectStruct.Addr(&pairwiseSubject)
+		err = rows.Scan(addr...)
+		if err != nil {
+			return nil, errors.Wrap(err, "unable to scan pairwiseSubject")
+		}
+		return &pairwiseSubject, nil
+	}
+	return nil, nil
+}
@@ -0,0 +1,160 @@
+package commondb
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/huandu/go-sqlbuilder"
+	"github.com/leodip/goiabada/internal/entities"
+	"github.com/pkg/errors"
+)
+
+func (d *CommonDatabase) CreatePushedAuthRequest(tx *sql.Tx, pushedAuthRequest *entities.PushedAuthRequest) error {
+
+	if pushedAuthRequest.ClientId == 0 {
+		return errors.WithStack(errors.New("client id must be greater than 0"))
+	}
+
+	now := time.Now().UTC()
+
+	originalCreatedAt := pushedAuthRequest.CreatedAt
+	originalUpdatedAt := pushedAuthRequest.UpdatedAt
+	pushedAuthRequest.CreatedAt = sql.NullTime{Time: now, Valid: true}
+	pushedAuthRequest.UpdatedAt = sql.NullTime{Time: now, Valid: true}
+
+	pushedAuthRequestStruct := sqlbuilder.NewStruct(new(entities.PushedAuthRequest)).
+		For(d.Flavor)
+
+	insertBuilder := pushedAuthRequestStruct.WithoutTag("pk").InsertInto("pushed_auth_requests", pushedAuthRequest)
+
+	sql, args := insertBuilder.Build()
+	result, err := d.ExecSql(tx, sql, args...)
+	if err != nil {
+		pushedAuthRequest.CreatedAt = originalCreatedAt
+		pushedAuthRequest.UpdatedAt = originalUpdatedAt
+		return errors.Wrap(err, "unable to insert pushedAuthRequest")
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		pushedAuthRequest.CreatedAt = originalCreatedAt
+		pushedAuthRequest.UpdatedAt = originalUpdatedAt
+		return errors.Wrap(err, "unable to get last insert id")
+	}
+
+	pushedAuthRequest.Id = id
+	return nil
+}
+
+func (d *CommonDatabase) UpdatePushedAuthRequest(tx *sql.Tx, pushedAuthRequest *entities.PushedAuthRequest) error {
+
+	if pushedAuthRequest.Id == 0 {
+		return errors.WithStack(errors.New("can't update pushedAuthRequest with id 0"))
+	}
+
+	originalUpdatedAt := pushedAuthRequest.UpdatedAt
+	pushedAuthRequest.UpdatedAt = sql.NullTime{Time: time.Now().UTC(), Valid: true}
+
+	pushedAuthRequestStruct := sqlbuilder.NewStruct(new(entities.PushedAuthRequest)).
+		For(d.Flavor)
+
+	updateBuilder := pushedAuthRequestStruct.WithoutTag("pk").Update("pushed_auth_requests", pushedAuthRequest)
+	updateBuilder.Where(updateBuilder.Equal("id", pushedAuthRequest.Id))
+
+	sql, args := updateBuilder.Build()
+	_, err := d.ExecSql(tx, sql, args...)
+	if err != nil {
+		pushedAuthRequest.UpdatedAt = originalUpdatedAt
+		return errors.Wrap(err, "unable to update pushedAuthRequest")
+	}
+
+	return nil
+}
+
+func (d *CommonDatabase) getPushedAuthRequestCommon(tx *sql.Tx, selectBuilder *sqlbuilder.SelectBuilder,
+	pushedAuthRequestStruct *sqlbuilder.Struct) (*entities.PushedAuthRequest, error) {
+
+	sql, args := selectBuilder.Build()
+	rows, err := d.QuerySql(tx, sql, args...)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to query database")
+	}
+	defer rows.Close()
+
+	var pushedAuthRequest entities.PushedAuthRequest
+	if rows.Next() {
+		addr := pushedAuthRequestStruct.Addr(&pushedAuthRequest)
+		err = rows.Scan(addr...)
+		if err != nil {
+			return nil, errors.Wrap(err, "unable to scan pushedAuthRequest")
+		}
+		return &pushedAuthRequest, nil
+	}
+	return nil, nil
+}
+
+func (d *CommonDatabase) GetPushedAuthRequestById(tx *sql.Tx, pushedAuthRequestId int64) (*entities.PushedAuthRequest, error) {
+
+	pushedAuthRequestStruct := sqlbuilder.NewStruct(new(entities.PushedAuthRequest)).
+		For(d.Flavor)
+
+	selectBuilder := pushedAuthRequestStruct.SelectFrom("pushed_auth_requests")
+	selectBuilder.Where(selectBuilder.Equal("id", pushedAuthRequestId))
+
+	pushedAuthRequest, err := d.getPushedAuthRequestCommon(tx, selectBuilder, pushedAuthRequestStruct)
+	if err != nil {
+		return nil, err
+	}
+
+	return pushedAuthRequest, nil
+}
+
+func (d *CommonDatabase) GetPushedAuthRequestByRequestURIHash(tx *sql.Tx, requestURIHash string) (*entities.PushedAuthRequest, error) {
+
+	pushedAuthRequestStruct := sqlbuilder.NewStruct(new(entities.PushedAuthRequest)).
+		For(d.Flavor)
+
+	selectBuilder := pushedAuthRequestStruct.SelectFrom("pushed_auth_requests")
+	selectBuilder.Where(selectBuilder.Equal("request_uri_hash", requestURIHash))
+
+	pushedAuthRequest, err := d.getPushedAuthRequestCommon(tx, selectBuilder, pushedAuthRequestStruct)
+	if err != nil {
+		return nil, err
+	}
+
+	return pushedAuthRequest, nil
+}
+
+func (d *CommonDatabase) PushedAuthRequestLoadClient(tx *sql.Tx, pushedAuthRequest *entities.PushedAuthRequest) error {
+
+	if pushedAuthRequest == nil {
+		return nil
+	}
+
+	client, err := d.GetClientById(tx, pushedAuthRequest.ClientId)
+	if err != nil {
+		return errors.Wrap(err, "unable to load client")
+	}
+
+	if client != nil {
+		pushedAuthRequest.Client = *client
+	}
+	return nil
+}
+
+func (d *CommonDatabase) DeletePushedAuthRequest(tx *sql.Tx, pushedAuthRequestId int64) error {
+
+	pushedAuthRequestStruct := sqlbuilder.NewStruct(new(entities.PushedAuthRequest)).
+		For(d.Flavor)
+
+	deleteBuilder := pushedAuthRequestStruct.DeleteFrom("pushed_auth_requests")
+	deleteBuilder.Where(deleteBuilder.Equal("id", pushedAuthRequestId))
+
+	sql, args := deleteBuilder.Build()
+	_, err := d.ExecSql(tx, sql, args...)
+	if err != nil {
+		return errors.Wrap(err, "unable to delete pushedAuthRequest")
+	}
+
+	return nil
+}
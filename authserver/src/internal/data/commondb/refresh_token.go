@@ -138,6 +138,35 @@ func (d *CommonDatabase) GetRefreshTokenByJti(tx *sql.Tx, jti string) (*entities
 	return refreshToken, nil
 }
 
+func (d *CommonDatabase) GetRefreshTokensByFirstRefreshTokenJti(tx *sql.Tx, firstRefreshTokenJti string) ([]entities.RefreshToken, error) {
+
+	refreshTokenStruct := sqlbuilder.NewStruct(new(entities.RefreshToken)).
+		For(d.Flavor)
+
+	selectBuilder := refreshTokenStruct.SelectFrom("refresh_tokens")
+	selectBuilder.Where(selectBuilder.Equal("first_refresh_token_jti", firstRefreshTokenJti))
+
+	sql, args := selectBuilder.Build()
+	rows, err := d.QuerySql(tx, sql, args...)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to query database")
+	}
+	defer rows.Close()
+
+	var refreshTokens []entities.RefreshToken
+	for rows.Next() {
+		var refreshToken entities.RefreshToken
+		addr := refreshTokenStruct.Addr(&refreshToken)
+		err = rows.Scan(addr...)
+		if err != nil {
+			return nil, errors.Wrap(err, "unable to scan refreshToken")
+		}
+		refreshTokens = append(refreshTokens, refreshToken)
+	}
+
+	return refreshTokens, nil
+}
+
 func (d *CommonDatabase) DeleteRefreshToken(tx *sql.Tx, refreshTokenId int64) error {
 
 	userConsentStruct := sqlbuilder.NewStruct(new(entities.RefreshToken)).
@@ -0,0 +1,91 @@
+package commondb
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/huandu/go-sqlbuilder"
+	"github.com/leodip/goiabada/internal/entities"
+	"github.com/pkg/errors"
+)
+
+func (d *CommonDatabase) CreateUsedJti(tx *sql.Tx, usedJti *entities.UsedJti) error {
+
+	if len(usedJti.JtiHash) == 0 {
+		return errors.WithStack(errors.New("jti hash must not be empty"))
+	}
+
+	now := time.Now().UTC()
+
+	originalCreatedAt := usedJti.CreatedAt
+	originalUpdatedAt := usedJti.UpdatedAt
+	usedJti.CreatedAt = sql.NullTime{Time: now, Valid: true}
+	usedJti.UpdatedAt = sql.NullTime{Time: now, Valid: true}
+
+	usedJtiStruct := sqlbuilder.NewStruct(new(entities.UsedJti)).
+		For(d.Flavor)
+
+	insertBuilder := usedJtiStruct.WithoutTag("pk").InsertInto("used_jtis", usedJti)
+
+	sql, args := insertBuilder.Build()
+	result, err := d.ExecSql(tx, sql, args...)
+	if err != nil {
+		usedJti.CreatedAt = originalCreatedAt
+		usedJti.UpdatedAt = originalUpdatedAt
+		return errors.Wrap(err, "unable to insert usedJti")
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		usedJti.CreatedAt = originalCreatedAt
+		usedJti.UpdatedAt = originalUpdatedAt
+		return errors.Wrap(err, "unable to get last insert id")
+	}
+
+	usedJti.Id = id
+	return nil
+}
+
+func (d *CommonDatabase) GetUsedJtiByJtiHash(tx *sql.Tx, jtiHash string) (*entities.UsedJti, error) {
+
+	usedJtiStruct := sqlbuilder.NewStruct(new(entities.UsedJti)).
+		For(d.Flavor)
+
+	selectBuilder := usedJtiStruct.SelectFrom("used_jtis")
+	selectBuilder.Where(selectBuilder.Equal("jti_hash", jtiHash))
+
+	sql, args := selectBuilder.Build()
+	rows, err := d.QuerySql(tx, sql, args...)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to query database")
+	}
+	defer rows.Close()
+
+	var usedJti entities.UsedJti
+	if rows.Next() {
+		addr := usedJtiStruct.Addr(&usedJti)
+		err = rows.Scan(addr...)
+		if err != nil {
+			return nil, errors.Wrap(err, "unable to scan usedJti")
+		}
+		return &usedJti, nil
+	}
+	return nil, nil
+}
+
+func (d *CommonDatabase) DeleteUsedJtisExpired(tx *sql.Tx) error {
+
+	usedJtiStruct := sqlbuilder.NewStruct(new(entities.UsedJti)).
+		For(d.Flavor)
+
+	deleteBuilder := usedJtiStruct.DeleteFrom("used_jtis")
+	deleteBuilder.Where(deleteBuilder.LessThan("expires_at", time.Now().UTC()))
+
+	sql, args := deleteBuilder.Build()
+	_, err := d.ExecSql(tx, sql, args...)
+	if err != nil {
+		return errors.Wrap(err, "unable to delete expired used jtis")
+	}
+
+	return nil
+}
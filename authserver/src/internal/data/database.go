@@ -53,6 +53,46 @@ type Database interface {
 	CodeLoadClient(tx *sql.Tx, code *entities.Code) error
 	CodeLoadUser(tx *sql.Tx, code *entities.Code) error
 
+	CreateDeviceCode(tx *sql.Tx, deviceCode *entities.DeviceCode) error
+	UpdateDeviceCode(tx *sql.Tx, deviceCode *entities.DeviceCode) error
+	GetDeviceCodeById(tx *sql.Tx, deviceCodeId int64) (*entities.DeviceCode, error)
+	GetDeviceCodeByDeviceCodeHash(tx *sql.Tx, deviceCodeHash string) (*entities.DeviceCode, error)
+	GetDeviceCodeByUserCode(tx *sql.Tx, userCode string) (*entities.DeviceCode, error)
+	DeviceCodeLoadClient(tx *sql.Tx, deviceCode *entities.DeviceCode) error
+	DeleteDeviceCode(tx *sql.Tx, deviceCodeId int64) error
+
+	CreateBackchannelAuthRequest(tx *sql.Tx, backchannelAuthRequest *entities.BackchannelAuthRequest) error
+	UpdateBackchannelAuthRequest(tx *sql.Tx, backchannelAuthRequest *entities.BackchannelAuthRequest) error
+	GetBackchannelAuthRequestById(tx *sql.Tx, backchannelAuthRequestId int64) (*entities.BackchannelAuthRequest, error)
+	GetBackchannelAuthRequestByAuthReqIdHash(tx *sql.Tx, authReqIdHash string) (*entities.BackchannelAuthRequest, error)
+	GetPendingBackchannelAuthRequestsByUserId(tx *sql.Tx, userId int64) ([]entities.BackchannelAuthRequest, error)
+	BackchannelAuthRequestLoadClient(tx *sql.Tx, backchannelAuthRequest *entities.BackchannelAuthRequest) error
+	DeleteBackchannelAuthRequest(tx *sql.Tx, backchannelAuthRequestId int64) error
+
+	CreatePushedAuthRequest(tx *sql.Tx, pushedAuthRequest *entities.PushedAuthRequest) error
+	UpdatePushedAuthRequest(tx *sql.Tx, pushedAuthRequest *entities.PushedAuthRequest) error
+	GetPushedAuthRequestById(tx *sql.Tx, pushedAuthRequestId int64) (*entities.PushedAuthRequest, error)
+	GetPushedAuthRequestByRequestURIHash(tx *sql.Tx, requestURIHash string) (*entities.PushedAuthRequest, error)
+	PushedAuthRequestLoadClient(tx *sql.Tx, pushedAuthRequest *entities.PushedAuthRequest) error
+	DeletePushedAuthRequest(tx *sql.Tx, pushedAuthRequestId int64) error
+
+	CreateUsedJti(tx *sql.Tx, usedJti *entities.UsedJti) error
+	GetUsedJtiByJtiHash(tx *sql.Tx, jtiHash string) (*entities.UsedJti, error)
+	DeleteUsedJtisExpired(tx *sql.Tx) error
+
+	CreateInitialAccessToken(tx *sql.Tx, initialAccessToken *entities.InitialAccessToken) error
+	GetInitialAccessTokenByTokenHash(tx *sql.Tx, tokenHash string) (*entities.InitialAccessToken, error)
+	GetAllInitialAccessTokens(tx *sql.Tx) ([]entities.InitialAccessToken, error)
+	DeleteInitialAccessToken(tx *sql.Tx, initialAccessTokenId int64) error
+
+	CreatePairwiseSubject(tx *sql.Tx, pairwiseSubject *entities.PairwiseSubject) error
+	GetPairwiseSubjectByUserIdAndSectorIdentifier(tx *sql.Tx, userId int64, sectorIdentifier string) (*entities.PairwiseSubject, error)
+	GetPairwiseSubjectBySubject(tx *sql.Tx, subject string) (*entities.PairwiseSubject, error)
+
+	CreateBackChannelLogoutDelivery(tx *sql.Tx, delivery *entities.BackChannelLogoutDelivery) error
+	UpdateBackChannelLogoutDelivery(tx *sql.Tx, delivery *entities.BackChannelLogoutDelivery) error
+	GetBackChannelLogoutDeliveriesByClientId(tx *sql.Tx, clientId int64, limit int) ([]entities.BackChannelLogoutDelivery, error)
+
 	CreateResource(tx *sql.Tx, resource *entities.Resource) error
 	UpdateResource(tx *sql.Tx, resource *entities.Resource) error
 	GetResourceById(tx *sql.Tx, resourceId int64) (*entities.Resource, error)
@@ -61,6 +101,14 @@ type Database interface {
 	GetAllResources(tx *sql.Tx) ([]entities.Resource, error)
 	DeleteResource(tx *sql.Tx, resourceId int64) error
 
+	CreateAuthorizationDetailType(tx *sql.Tx, authorizationDetailType *entities.AuthorizationDetailType) error
+	UpdateAuthorizationDetailType(tx *sql.Tx, authorizationDetailType *entities.AuthorizationDetailType) error
+	GetAuthorizationDetailTypeById(tx *sql.Tx, authorizationDetailTypeId int64) (*entities.AuthorizationDetailType, error)
+	GetAuthorizationDetailTypeByType(tx *sql.Tx, authorizationDetailType string) (*entities.AuthorizationDetailType, error)
+	GetAuthorizationDetailTypesByResourceId(tx *sql.Tx, resourceId int64) ([]entities.AuthorizationDetailType, error)
+	GetAllAuthorizationDetailTypes(tx *sql.Tx) ([]entities.AuthorizationDetailType, error)
+	DeleteAuthorizationDetailType(tx *sql.Tx, authorizationDetailTypeId int64) error
+
 	CreatePermission(tx *sql.Tx, permission *entities.Permission) error
 	UpdatePermission(tx *sql.Tx, permission *entities.Permission) error
 	GetPermissionById(tx *sql.Tx, permissionId int64) (*entities.Permission, error)
@@ -74,7 +122,7 @@ type Database interface {
 	UpdateKeyPair(tx *sql.Tx, keyPair *entities.KeyPair) error
 	GetKeyPairById(tx *sql.Tx, keyPairId int64) (*entities.KeyPair, error)
 	GetAllSigningKeys(tx *sql.Tx) ([]entities.KeyPair, error)
-	GetCurrentSigningKey(tx *sql.Tx) (*entities.KeyPair, error)
+	GetCurrentSigningKey(tx *sql.Tx, algorithm string) (*entities.KeyPair, error)
 	DeleteKeyPair(tx *sql.Tx, keyPairId int64) error
 
 	CreateRedirectURI(tx *sql.Tx, redirectURI *entities.RedirectURI) error
@@ -182,6 +230,7 @@ type Database interface {
 	UpdateRefreshToken(tx *sql.Tx, refreshToken *entities.RefreshToken) error
 	GetRefreshTokenById(tx *sql.Tx, refreshTokenId int64) (*entities.RefreshToken, error)
 	GetRefreshTokenByJti(tx *sql.Tx, jti string) (*entities.RefreshToken, error)
+	GetRefreshTokensByFirstRefreshTokenJti(tx *sql.Tx, firstRefreshTokenJti string) ([]entities.RefreshToken, error)
 	DeleteRefreshToken(tx *sql.Tx, refreshTokenId int64) error
 	RefreshTokenLoadCode(tx *sql.Tx, refreshToken *entities.RefreshToken) error
 
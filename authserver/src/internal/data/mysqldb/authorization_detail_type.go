@@ -0,0 +1,35 @@
+package mysqldb
+
+import (
+	"database/sql"
+
+	"github.com/leodip/goiabada/internal/entities"
+)
+
+func (d *MySQLDatabase) CreateAuthorizationDetailType(tx *sql.Tx, authorizationDetailType *entities.AuthorizationDetailType) error {
+	return d.CommonDB.CreateAuthorizationDetailType(tx, authorizationDetailType)
+}
+
+func (d *MySQLDatabase) UpdateAuthorizationDetailType(tx *sql.Tx, authorizationDetailType *entities.AuthorizationDetailType) error {
+	return d.CommonDB.UpdateAuthorizationDetailType(tx, authorizationDetailType)
+}
+
+func (d *MySQLDatabase) GetAuthorizationDetailTypeById(tx *sql.Tx, authorizationDetailTypeId int64) (*entities.AuthorizationDetailType, error) {
+	return d.CommonDB.GetAuthorizationDetailTypeById(tx, authorizationDetailTypeId)
+}
+
+func (d *MySQLDatabase) GetAuthorizationDetailTypeByType(tx *sql.Tx, authorizationDetailType string) (*entities.AuthorizationDetailType, error) {
+	return d.CommonDB.GetAuthorizationDetailTypeByType(tx, authorizationDetailType)
+}
+
+func (d *MySQLDatabase) GetAuthorizationDetailTypesByResourceId(tx *sql.Tx, resourceId int64) ([]entities.AuthorizationDetailType, error) {
+	return d.CommonDB.GetAuthorizationDetailTypesByResourceId(tx, resourceId)
+}
+
+func (d *MySQLDatabase) GetAllAuthorizationDetailTypes(tx *sql.Tx) ([]entities.AuthorizationDetailType, error) {
+	return d.CommonDB.GetAllAuthorizationDetailTypes(tx)
+}
+
+func (d *MySQLDatabase) DeleteAuthorizationDetailType(tx *sql.Tx, authorizationDetailTypeId int64) error {
+	return d.CommonDB.DeleteAuthorizationDetailType(tx, authorizationDetailTypeId)
+}
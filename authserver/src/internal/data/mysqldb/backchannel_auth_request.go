@@ -0,0 +1,35 @@
+package mysqldb
+
+import (
+	"database/sql"
+
+	"github.com/leodip/goiabada/internal/entities"
+)
+
+func (d *MySQLDatabase) CreateBackchannelAuthRequest(tx *sql.Tx, backchannelAuthRequest *entities.BackchannelAuthRequest) error {
+	return d.CommonDB.CreateBackchannelAuthRequest(tx, backchannelAuthRequest)
+}
+
+func (d *MySQLDatabase) UpdateBackchannelAuthRequest(tx *sql.Tx, backchannelAuthRequest *entities.BackchannelAuthRequest) error {
+	return d.CommonDB.UpdateBackchannelAuthRequest(tx, backchannelAuthRequest)
+}
+
+func (d *MySQLDatabase) GetBackchannelAuthRequestById(tx *sql.Tx, backchannelAuthRequestId int64) (*entities.BackchannelAuthRequest, error) {
+	return d.CommonDB.GetBackchannelAuthRequestById(tx, backchannelAuthRequestId)
+}
+
+func (d *MySQLDatabase) GetBackchannelAuthRequestByAuthReqIdHash(tx *sql.Tx, authReqIdHash string) (*entities.BackchannelAuthRequest, error) {
+	return d.CommonDB.GetBackchannelAuthRequestByAuthReqIdHash(tx, authReqIdHash)
+}
+
+func (d *MySQLDatabase) GetPendingBackchannelAuthRequestsByUserId(tx *sql.Tx, userId int64) ([]entities.BackchannelAuthRequest, error) {
+	return d.CommonDB.GetPendingBackchannelAuthRequestsByUserId(tx, userId)
+}
+
+func (d *MySQLDatabase) BackchannelAuthRequestLoadClient(tx *sql.Tx, backchannelAuthRequest *entities.BackchannelAuthRequest) error {
+	return d.CommonDB.BackchannelAuthRequestLoadClient(tx, backchannelAuthRequest)
+}
+
+func (d *MySQLDatabase) DeleteBackchannelAuthRequest(tx *sql.Tx, backchannelAuthRequestId int64) error {
+	return d.CommonDB.DeleteBackchannelAuthRequest(tx, backchannelAuthRequestId)
+}
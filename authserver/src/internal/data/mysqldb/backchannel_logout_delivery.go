@@ -0,0 +1,19 @@
+package mysqldb
+
+import (
+	"database/sql"
+
+	"github.com/leodip/goiabada/internal/entities"
+)
+
+func (d *MySQLDatabase) CreateBackChannelLogoutDelivery(tx *sql.Tx, delivery *entities.BackChannelLogoutDelivery) error {
+	return d.CommonDB.CreateBackChannelLogoutDelivery(tx, delivery)
+}
+
+func (d *MySQLDatabase) UpdateBackChannelLogoutDelivery(tx *sql.Tx, delivery *entities.BackChannelLogoutDelivery) error {
+	return d.CommonDB.UpdateBackChannelLogoutDelivery(tx, delivery)
+}
+
+func (d *MySQLDatabase) GetBackChannelLogoutDeliveriesByClientId(tx *sql.Tx, clientId int64, limit int) ([]entities.BackChannelLogoutDelivery, error) {
+	return d.CommonDB.GetBackChannelLogoutDeliveriesByClientId(tx, clientId, limit)
+}
@@ -0,0 +1,35 @@
+package mysqldb
+
+import (
+	"database/sql"
+
+	"github.com/leodip/goiabada/internal/entities"
+)
+
+func (d *MySQLDatabase) CreateDeviceCode(tx *sql.Tx, deviceCode *entities.DeviceCode) error {
+	return d.CommonDB.CreateDeviceCode(tx, deviceCode)
+}
+
+func (d *MySQLDatabase) UpdateDeviceCode(tx *sql.Tx, deviceCode *entities.DeviceCode) error {
+	return d.CommonDB.UpdateDeviceCode(tx, deviceCode)
+}
+
+func (d *MySQLDatabase) GetDeviceCodeById(tx *sql.Tx, deviceCodeId int64) (*entities.DeviceCode, error) {
+	return d.CommonDB.GetDeviceCodeById(tx, deviceCodeId)
+}
+
+func (d *MySQLDatabase) GetDeviceCodeByDeviceCodeHash(tx *sql.Tx, deviceCodeHash string) (*entities.DeviceCode, error) {
+	return d.CommonDB.GetDeviceCodeByDeviceCodeHash(tx, deviceCodeHash)
+}
+
+func (d *MySQLDatabase) GetDeviceCodeByUserCode(tx *sql.Tx, userCode string) (*entities.DeviceCode, error) {
+	return d.CommonDB.GetDeviceCodeByUserCode(tx, userCode)
+}
+
+func (d *MySQLDatabase) DeviceCodeLoadClient(tx *sql.Tx, deviceCode *entities.DeviceCode) error {
+	return d.CommonDB.DeviceCodeLoadClient(tx, deviceCode)
+}
+
+func (d *MySQLDatabase) DeleteDeviceCode(tx *sql.Tx, deviceCodeId int64) error {
+	return d.CommonDB.DeleteDeviceCode(tx, deviceCodeId)
+}
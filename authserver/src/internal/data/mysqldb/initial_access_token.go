@@ -0,0 +1,23 @@
+package mysqldb
+
+import (
+	"database/sql"
+
+	"github.com/leodip/goiabada/internal/entities"
+)
+
+func (d *MySQLDatabase) CreateInitialAccessToken(tx *sql.Tx, initialAccessToken *entities.InitialAccessToken) error {
+	return d.CommonDB.CreateInitialAccessToken(tx, initialAccessToken)
+}
+
+func (d *MySQLDatabase) GetInitialAccessTokenByTokenHash(tx *sql.Tx, tokenHash string) (*entities.InitialAccessToken, error) {
+	return d.CommonDB.GetInitialAccessTokenByTokenHash(tx, tokenHash)
+}
+
+func (d *MySQLDatabase) GetAllInitialAccessTokens(tx *sql.Tx) ([]entities.InitialAccessToken, error) {
+	return d.CommonDB.GetAllInitialAccessTokens(tx)
+}
+
+func (d *MySQLDatabase) DeleteInitialAccessToken(tx *sql.Tx, initialAccessTokenId int64) error {
+	return d.CommonDB.DeleteInitialAccessToken(tx, initialAccessTokenId)
+}
@@ -22,8 +22,8 @@ func (d *MySQLDatabase) GetAllSigningKeys(tx *sql.Tx) ([]entities.KeyPair, error
 	return d.CommonDB.GetAllSigningKeys(tx)
 }
 
-func (d *MySQLDatabase) GetCurrentSigningKey(tx *sql.Tx) (*entities.KeyPair, error) {
-	return d.CommonDB.GetCurrentSigningKey(tx)
+func (d *MySQLDatabase) GetCurrentSigningKey(tx *sql.Tx, algorithm string) (*entities.KeyPair, error) {
+	return d.CommonDB.GetCurrentSigningKey(tx, algorithm)
 }
 
 func (d *MySQLDatabase) DeleteKeyPair(tx *sql.Tx, keyPairId int64) error {
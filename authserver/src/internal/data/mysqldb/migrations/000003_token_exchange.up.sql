@@ -0,0 +1,5 @@
+-- BEGIN
+
+ALTER TABLE `clients` ADD COLUMN `token_exchange_enabled` tinyint(1) NOT NULL DEFAULT 0 AFTER `device_code_enabled`;
+
+-- END
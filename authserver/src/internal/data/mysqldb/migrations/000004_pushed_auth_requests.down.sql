@@ -0,0 +1,6 @@
+-- BEGIN
+
+DROP TABLE IF EXISTS `pushed_auth_requests`;
+ALTER TABLE `clients` DROP COLUMN `par_required`;
+
+-- END
@@ -0,0 +1,6 @@
+-- BEGIN
+
+ALTER TABLE `clients` DROP COLUMN `jwks_uri`;
+ALTER TABLE `clients` DROP COLUMN `jwks`;
+
+-- END
@@ -0,0 +1,6 @@
+-- BEGIN
+
+ALTER TABLE `clients` ADD COLUMN `jwks` text NOT NULL AFTER `par_required`;
+ALTER TABLE `clients` ADD COLUMN `jwks_uri` varchar(512) NOT NULL DEFAULT '' AFTER `jwks`;
+
+-- END
@@ -0,0 +1,17 @@
+-- BEGIN
+
+ALTER TABLE `refresh_tokens` ADD COLUMN `dpop_jkt` varchar(64) NOT NULL DEFAULT '' AFTER `revoked`;
+
+
+CREATE TABLE `used_jtis` (
+  `id` bigint unsigned NOT NULL AUTO_INCREMENT,
+  `created_at` datetime(6) DEFAULT NULL,
+  `updated_at` datetime(6) DEFAULT NULL,
+  `jti_hash` varchar(64) NOT NULL,
+  `expires_at` datetime(6) NOT NULL,
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `idx_jti_hash` (`jti_hash`),
+  KEY `idx_used_jtis_expires_at` (`expires_at`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
+
+-- END
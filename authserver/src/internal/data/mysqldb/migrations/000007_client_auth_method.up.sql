@@ -0,0 +1,5 @@
+-- BEGIN
+
+ALTER TABLE `clients` ADD COLUMN `token_endpoint_auth_method` varchar(40) NOT NULL DEFAULT 'client_secret_post' AFTER `is_public`;
+
+-- END
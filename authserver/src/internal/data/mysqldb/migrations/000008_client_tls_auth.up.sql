@@ -0,0 +1,6 @@
+-- BEGIN
+
+ALTER TABLE `clients` ADD COLUMN `tls_client_auth_subject_dn` varchar(512) NOT NULL DEFAULT '' AFTER `token_endpoint_auth_method`;
+ALTER TABLE `clients` ADD COLUMN `tls_client_certificate` text NOT NULL AFTER `tls_client_auth_subject_dn`;
+
+-- END
@@ -0,0 +1,7 @@
+-- BEGIN
+
+DROP TABLE IF EXISTS `initial_access_tokens`;
+ALTER TABLE `clients` DROP COLUMN `registration_access_token_hash`;
+ALTER TABLE `clients` DROP COLUMN `is_dynamically_registered`;
+
+-- END
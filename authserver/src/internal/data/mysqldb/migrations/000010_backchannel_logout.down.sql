@@ -0,0 +1,6 @@
+-- BEGIN
+
+DROP TABLE IF EXISTS `backchannel_logout_deliveries`;
+ALTER TABLE `clients` DROP COLUMN `backchannel_logout_uri`;
+
+-- END
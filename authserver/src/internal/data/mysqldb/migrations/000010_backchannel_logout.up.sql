@@ -0,0 +1,23 @@
+-- BEGIN
+
+ALTER TABLE `clients` ADD COLUMN `backchannel_logout_uri` varchar(512) NOT NULL DEFAULT '' AFTER `jwks_uri`;
+
+
+CREATE TABLE `backchannel_logout_deliveries` (
+  `id` bigint unsigned NOT NULL AUTO_INCREMENT,
+  `created_at` datetime(6) DEFAULT NULL,
+  `updated_at` datetime(6) DEFAULT NULL,
+  `client_id` bigint unsigned NOT NULL,
+  `session_identifier` varchar(64) NOT NULL,
+  `subject` varchar(64) NOT NULL,
+  `logout_uri` varchar(512) NOT NULL,
+  `status` varchar(20) NOT NULL,
+  `attempts` int NOT NULL DEFAULT 0,
+  `last_attempt_at` datetime(6) DEFAULT NULL,
+  `last_error` varchar(512) NOT NULL DEFAULT '',
+  PRIMARY KEY (`id`),
+  KEY `fk_backchannel_logout_deliveries_client` (`client_id`),
+  CONSTRAINT `fk_backchannel_logout_deliveries_client` FOREIGN KEY (`client_id`) REFERENCES `clients` (`id`) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
+
+-- END
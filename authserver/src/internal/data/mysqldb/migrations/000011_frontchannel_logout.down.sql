@@ -0,0 +1,5 @@
+-- BEGIN
+
+ALTER TABLE `clients` DROP COLUMN `frontchannel_logout_uri`;
+
+-- END
@@ -0,0 +1,5 @@
+-- BEGIN
+
+ALTER TABLE `clients` ADD COLUMN `frontchannel_logout_uri` varchar(512) NOT NULL DEFAULT '' AFTER `backchannel_logout_uri`;
+
+-- END
@@ -0,0 +1,5 @@
+-- BEGIN
+
+ALTER TABLE `codes` ADD COLUMN `resource` varchar(512) NOT NULL DEFAULT '' AFTER `scope`;
+
+-- END
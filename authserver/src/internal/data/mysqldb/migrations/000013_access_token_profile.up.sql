@@ -0,0 +1,5 @@
+-- BEGIN
+
+ALTER TABLE `clients` ADD COLUMN `legacy_access_token_format` tinyint(1) NOT NULL DEFAULT 0 AFTER `include_open_id_connect_claims_in_access_token`;
+
+-- END
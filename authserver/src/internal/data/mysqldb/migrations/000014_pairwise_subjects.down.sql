@@ -0,0 +1,7 @@
+-- BEGIN
+
+DROP TABLE IF EXISTS `pairwise_subjects`;
+ALTER TABLE `clients` DROP COLUMN `sector_identifier_uri`;
+ALTER TABLE `clients` DROP COLUMN `subject_type`;
+
+-- END
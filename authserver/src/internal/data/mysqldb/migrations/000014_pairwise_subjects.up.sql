@@ -0,0 +1,20 @@
+-- BEGIN
+
+ALTER TABLE `clients` ADD COLUMN `subject_type` varchar(16) NOT NULL DEFAULT 'public' AFTER `legacy_access_token_format`;
+ALTER TABLE `clients` ADD COLUMN `sector_identifier_uri` varchar(512) NOT NULL DEFAULT '' AFTER `subject_type`;
+
+
+CREATE TABLE `pairwise_subjects` (
+  `id` bigint unsigned NOT NULL AUTO_INCREMENT,
+  `created_at` datetime(6) DEFAULT NULL,
+  `updated_at` datetime(6) DEFAULT NULL,
+  `user_id` bigint unsigned NOT NULL,
+  `sector_identifier` varchar(256) NOT NULL,
+  `subject` varchar(64) NOT NULL,
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `idx_pairwise_subjects_user_sector` (`user_id`, `sector_identifier`),
+  UNIQUE KEY `idx_pairwise_subjects_subject` (`subject`),
+  CONSTRAINT `fk_pairwise_subjects_user` FOREIGN KEY (`user_id`) REFERENCES `users` (`id`) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
+
+-- END
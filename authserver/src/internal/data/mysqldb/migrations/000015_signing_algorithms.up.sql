@@ -0,0 +1,5 @@
+-- BEGIN
+
+ALTER TABLE `clients` ADD COLUMN `id_token_signed_response_alg` varchar(16) NOT NULL DEFAULT '' AFTER `sector_identifier_uri`;
+
+-- END
@@ -0,0 +1,8 @@
+-- BEGIN
+
+ALTER TABLE `clients` ADD COLUMN `id_token_encrypted_response_alg` varchar(32) NOT NULL DEFAULT '' AFTER `id_token_signed_response_alg`;
+ALTER TABLE `clients` ADD COLUMN `id_token_encrypted_response_enc` varchar(32) NOT NULL DEFAULT '' AFTER `id_token_encrypted_response_alg`;
+ALTER TABLE `clients` ADD COLUMN `userinfo_encrypted_response_alg` varchar(32) NOT NULL DEFAULT '' AFTER `id_token_encrypted_response_enc`;
+ALTER TABLE `clients` ADD COLUMN `userinfo_encrypted_response_enc` varchar(32) NOT NULL DEFAULT '' AFTER `userinfo_encrypted_response_alg`;
+
+-- END
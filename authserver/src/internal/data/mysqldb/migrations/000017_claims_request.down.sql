@@ -0,0 +1,5 @@
+-- BEGIN
+
+ALTER TABLE `codes` DROP COLUMN `claims`;
+
+-- END
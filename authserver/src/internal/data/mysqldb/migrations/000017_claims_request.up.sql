@@ -0,0 +1,5 @@
+-- BEGIN
+
+ALTER TABLE `codes` ADD COLUMN `claims` text NOT NULL AFTER `resource`;
+
+-- END
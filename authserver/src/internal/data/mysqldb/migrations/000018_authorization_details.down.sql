@@ -0,0 +1,7 @@
+-- BEGIN
+
+DROP TABLE IF EXISTS `authorization_detail_types`;
+ALTER TABLE `refresh_tokens` DROP COLUMN `authorization_details`;
+ALTER TABLE `codes` DROP COLUMN `authorization_details`;
+
+-- END
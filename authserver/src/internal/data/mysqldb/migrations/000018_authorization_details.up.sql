@@ -0,0 +1,21 @@
+-- BEGIN
+
+ALTER TABLE `codes` ADD COLUMN `authorization_details` text NOT NULL AFTER `claims`;
+ALTER TABLE `refresh_tokens` ADD COLUMN `authorization_details` text NOT NULL AFTER `scope`;
+
+
+CREATE TABLE `authorization_detail_types` (
+  `id` bigint unsigned NOT NULL AUTO_INCREMENT,
+  `created_at` datetime(6) DEFAULT NULL,
+  `updated_at` datetime(6) DEFAULT NULL,
+  `resource_id` bigint unsigned NOT NULL,
+  `type` varchar(128) NOT NULL,
+  `description` varchar(128) NOT NULL,
+  `json_schema` text NOT NULL,
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `idx_authorization_detail_types_type` (`type`),
+  KEY `fk_authorization_detail_types_resource` (`resource_id`),
+  CONSTRAINT `fk_authorization_detail_types_resource` FOREIGN KEY (`resource_id`) REFERENCES `resources` (`id`) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
+
+-- END
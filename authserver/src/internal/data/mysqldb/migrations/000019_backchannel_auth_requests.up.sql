@@ -0,0 +1,31 @@
+-- BEGIN
+
+ALTER TABLE `clients` ADD COLUMN `ciba_enabled` tinyint(1) NOT NULL DEFAULT 0 AFTER `token_exchange_enabled`;
+
+
+CREATE TABLE `backchannel_auth_requests` (
+  `id` bigint unsigned NOT NULL AUTO_INCREMENT,
+  `created_at` datetime(6) DEFAULT NULL,
+  `updated_at` datetime(6) DEFAULT NULL,
+  `auth_req_id_hash` varchar(64) NOT NULL,
+  `client_id` bigint unsigned NOT NULL,
+  `user_id` bigint unsigned NOT NULL,
+  `scope` varchar(512) NOT NULL,
+  `binding_message` varchar(128) NOT NULL,
+  `acr_level` varchar(128) NOT NULL,
+  `state` varchar(16) NOT NULL,
+  `code_id` bigint unsigned DEFAULT NULL,
+  `expires_at` datetime(6) NOT NULL,
+  `interval_in_seconds` int NOT NULL,
+  `last_polled_at` datetime(6) DEFAULT NULL,
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `idx_auth_req_id_hash` (`auth_req_id_hash`),
+  KEY `idx_backchannel_auth_requests_user_state` (`user_id`, `state`),
+  KEY `fk_backchannel_auth_requests_client` (`client_id`),
+  KEY `fk_backchannel_auth_requests_code` (`code_id`),
+  CONSTRAINT `fk_backchannel_auth_requests_client` FOREIGN KEY (`client_id`) REFERENCES `clients` (`id`) ON DELETE CASCADE,
+  CONSTRAINT `fk_backchannel_auth_requests_user` FOREIGN KEY (`user_id`) REFERENCES `users` (`id`) ON DELETE CASCADE,
+  CONSTRAINT `fk_backchannel_auth_requests_code` FOREIGN KEY (`code_id`) REFERENCES `codes` (`id`) ON DELETE SET NULL
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
+
+-- END
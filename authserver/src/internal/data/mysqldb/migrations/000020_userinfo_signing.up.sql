@@ -0,0 +1,5 @@
+-- BEGIN
+
+ALTER TABLE `clients` ADD COLUMN `userinfo_signed_response_alg` varchar(16) NOT NULL DEFAULT '' AFTER `id_token_encrypted_response_enc`;
+
+-- END
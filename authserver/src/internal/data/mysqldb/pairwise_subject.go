@@ -0,0 +1,19 @@
+package mysqldb
+
+import (
+	"database/sql"
+
+	"github.com/leodip/goiabada/internal/entities"
+)
+
+func (d *MySQLDatabase) CreatePairwiseSubject(tx *sql.Tx, pairwiseSubject *entities.PairwiseSubject) error {
+	return d.CommonDB.CreatePairwiseSubject(tx, pairwiseSubject)
+}
+
+func (d *MySQLDatabase) GetPairwiseSubjectByUserIdAndSectorIdentifier(tx *sql.Tx, userId int64, sectorIdentifier string) (*entities.PairwiseSubject, error) {
+	return d.CommonDB.GetPairwiseSubjectByUserIdAndSectorIdentifier(tx, userId, sectorIdentifier)
+}
+
+func (d *MySQLDatabase) GetPairwiseSubjectBySubject(tx *sql.Tx, subject string) (*entities.PairwiseSubject, error) {
+	return d.CommonDB.GetPairwiseSubjectBySubject(tx, subject)
+}
@@ -0,0 +1,31 @@
+package mysqldb
+
+import (
+	"database/sql"
+
+	"github.com/leodip/goiabada/internal/entities"
+)
+
+func (d *MySQLDatabase) CreatePushedAuthRequest(tx *sql.Tx, pushedAuthRequest *entities.PushedAuthRequest) error {
+	return d.CommonDB.CreatePushedAuthRequest(tx, pushedAuthRequest)
+}
+
+func (d *MySQLDatabase) UpdatePushedAuthRequest(tx *sql.Tx, pushedAuthRequest *entities.PushedAuthRequest) error {
+	return d.CommonDB.UpdatePushedAuthRequest(tx, pushedAuthRequest)
+}
+
+func (d *MySQLDatabase) GetPushedAuthRequestById(tx *sql.Tx, pushedAuthRequestId int64) (*entities.PushedAuthRequest, error) {
+	return d.CommonDB.GetPushedAuthRequestById(tx, pushedAuthRequestId)
+}
+
+func (d *MySQLDatabase) GetPushedAuthRequestByRequestURIHash(tx *sql.Tx, requestURIHash string) (*entities.PushedAuthRequest, error) {
+	return d.CommonDB.GetPushedAuthRequestByRequestURIHash(tx, requestURIHash)
+}
+
+func (d *MySQLDatabase) PushedAuthRequestLoadClient(tx *sql.Tx, pushedAuthRequest *entities.PushedAuthRequest) error {
+	return d.CommonDB.PushedAuthRequestLoadClient(tx, pushedAuthRequest)
+}
+
+func (d *MySQLDatabase) DeletePushedAuthRequest(tx *sql.Tx, pushedAuthRequestId int64) error {
+	return d.CommonDB.DeletePushedAuthRequest(tx, pushedAuthRequestId)
+}
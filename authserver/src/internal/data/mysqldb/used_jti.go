@@ -0,0 +1,31 @@
+package mysqldb
+
+import (
+	"database/sql"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/leodip/goiabada/internal/customerrors"
+	"github.com/leodip/goiabada/internal/entities"
+	"github.com/pkg/errors"
+)
+
+// mysqlErrDuplicateEntry is the error number of a unique key violation
+const mysqlErrDuplicateEntry = 1062
+
+func (d *MySQLDatabase) CreateUsedJti(tx *sql.Tx, usedJti *entities.UsedJti) error {
+	err := d.CommonDB.CreateUsedJti(tx, usedJti)
+
+	var mysqlErr *mysql.MySQLError
+	if errors.As(err, &mysqlErr) && mysqlErr.Number == mysqlErrDuplicateEntry {
+		return customerrors.ErrJtiAlreadyUsed
+	}
+	return err
+}
+
+func (d *MySQLDatabase) GetUsedJtiByJtiHash(tx *sql.Tx, jtiHash string) (*entities.UsedJti, error) {
+	return d.CommonDB.GetUsedJtiByJtiHash(tx, jtiHash)
+}
+
+func (d *MySQLDatabase) DeleteUsedJtisExpired(tx *sql.Tx) error {
+	return d.CommonDB.DeleteUsedJtisExpired(tx)
+}
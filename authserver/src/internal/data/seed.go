@@ -1,8 +1,6 @@
 package data
 
 import (
-	"crypto/x509"
-	"encoding/pem"
 	"fmt"
 	"log/slog"
 
@@ -12,7 +10,6 @@ import (
 	"github.com/leodip/goiabada/internal/entities"
 	"github.com/leodip/goiabada/internal/enums"
 	"github.com/leodip/goiabada/internal/lib"
-	"github.com/pkg/errors"
 	"github.com/spf13/viper"
 )
 
@@ -34,6 +31,8 @@ func seed(database Database) error {
 		ClientCredentialsEnabled:                false,
 		ClientSecretEncrypted:                   clientSecretEncrypted,
 		IncludeOpenIDConnectClaimsInAccessToken: enums.ThreeStateSettingDefault.String(),
+		TokenEndpointAuthMethod:                 enums.TokenEndpointAuthMethodClientSecretPost.String(),
+		SubjectType:                             enums.SubjectTypePublic.String(),
 	}
 
 	err := database.CreateClient(nil, client1)
@@ -133,85 +132,29 @@ func seed(database Database) error {
 		return err
 	}
 
-	// key pair (current)
+	// key pairs (current and next)
 
-	privateKey, err := lib.GeneratePrivateKey(4096)
-	if err != nil {
-		return errors.Wrap(err, "unable to generate a private key")
-	}
-	privateKeyPEM := lib.EncodePrivateKeyToPEM(privateKey)
-
-	publicKeyASN1_DER, err := x509.MarshalPKIXPublicKey(&privateKey.PublicKey)
-	if err != nil {
-		return errors.Wrap(err, "unable to marshal public key to PKIX")
-	}
-
-	publicKeyPEM := pem.EncodeToMemory(
-		&pem.Block{
-			Type:  "RSA PUBLIC KEY",
-			Bytes: publicKeyASN1_DER,
-		},
-	)
-
-	kid := uuid.New().String()
-	publicKeyJWK, err := lib.MarshalRSAPublicKeyToJWK(&privateKey.PublicKey, kid)
-	if err != nil {
-		return err
-	}
-
-	keyPair := &entities.KeyPair{
-		State:             enums.KeyStateCurrent.String(),
-		KeyIdentifier:     kid,
-		Type:              "RSA",
-		Algorithm:         "RS256",
-		PrivateKeyPEM:     privateKeyPEM,
-		PublicKeyPEM:      publicKeyPEM,
-		PublicKeyASN1_DER: publicKeyASN1_DER,
-		PublicKeyJWK:      publicKeyJWK,
-	}
-	err = database.CreateKeyPair(nil, keyPair)
-	if err != nil {
-		return err
-	}
-
-	// key pair (next)
-	privateKey, err = lib.GeneratePrivateKey(4096)
-	if err != nil {
-		return errors.Wrap(err, "unable to generate a private key")
-	}
-	privateKeyPEM = lib.EncodePrivateKeyToPEM(privateKey)
-
-	publicKeyASN1_DER, err = x509.MarshalPKIXPublicKey(&privateKey.PublicKey)
-	if err != nil {
-		return errors.Wrap(err, "unable to marshal public key to PKIX")
-	}
-
-	publicKeyPEM = pem.EncodeToMemory(
-		&pem.Block{
-			Type:  "RSA PUBLIC KEY",
-			Bytes: publicKeyASN1_DER,
-		},
-	)
-
-	kid = uuid.New().String()
-	publicKeyJWK, err = lib.MarshalRSAPublicKeyToJWK(&privateKey.PublicKey, kid)
-	if err != nil {
-		return err
-	}
+	for _, keyState := range []enums.KeyState{enums.KeyStateCurrent, enums.KeyStateNext} {
+		kid := uuid.New().String()
+		signingKeyPair, err := lib.GenerateSigningKeyPair(lib.DefaultSigningAlgorithm, kid)
+		if err != nil {
+			return err
+		}
 
-	keyPair = &entities.KeyPair{
-		State:             enums.KeyStateNext.String(),
-		KeyIdentifier:     kid,
-		Type:              "RSA",
-		Algorithm:         "RS256",
-		PrivateKeyPEM:     privateKeyPEM,
-		PublicKeyPEM:      publicKeyPEM,
-		PublicKeyASN1_DER: publicKeyASN1_DER,
-		PublicKeyJWK:      publicKeyJWK,
-	}
-	err = database.CreateKeyPair(nil, keyPair)
-	if err != nil {
-		return err
+		keyPair := &entities.KeyPair{
+			State:             keyState.String(),
+			KeyIdentifier:     kid,
+			Type:              signingKeyPair.Type,
+			Algorithm:         lib.DefaultSigningAlgorithm,
+			PrivateKeyPEM:     signingKeyPair.PrivateKeyPEM,
+			PublicKeyPEM:      signingKeyPair.PublicKeyPEM,
+			PublicKeyASN1_DER: signingKeyPair.PublicKeyASN1_DER,
+			PublicKeyJWK:      signingKeyPair.PublicKeyJWK,
+		}
+		err = database.CreateKeyPair(nil, keyPair)
+		if err != nil {
+			return err
+		}
 	}
 
 	appName := viper.GetString("AppName")
@@ -0,0 +1,35 @@
+package sqlitedb
+
+import (
+	"database/sql"
+
+	"github.com/leodip/goiabada/internal/entities"
+)
+
+func (d *SQLiteDatabase) CreateAuthorizationDetailType(tx *sql.Tx, authorizationDetailType *entities.AuthorizationDetailType) error {
+	return d.CommonDB.CreateAuthorizationDetailType(tx, authorizationDetailType)
+}
+
+func (d *SQLiteDatabase) UpdateAuthorizationDetailType(tx *sql.Tx, authorizationDetailType *entities.AuthorizationDetailType) error {
+	return d.CommonDB.UpdateAuthorizationDetailType(tx, authorizationDetailType)
+}
+
+func (d *SQLiteDatabase) GetAuthorizationDetailTypeById(tx *sql.Tx, authorizationDetailTypeId int64) (*entities.AuthorizationDetailType, error) {
+	return d.CommonDB.GetAuthorizationDetailTypeById(tx, authorizationDetailTypeId)
+}
+
+func (d *SQLiteDatabase) GetAuthorizationDetailTypeByType(tx *sql.Tx, authorizationDetailType string) (*entities.AuthorizationDetailType, error) {
+	return d.CommonDB.GetAuthorizationDetailTypeByType(tx, authorizationDetailType)
+}
+
+func (d *SQLiteDatabase) GetAuthorizationDetailTypesByResourceId(tx *sql.Tx, resourceId int64) ([]entities.AuthorizationDetailType, error) {
+	return d.CommonDB.GetAuthorizationDetailTypesByResourceId(tx, resourceId)
+}
+
+func (d *SQLiteDatabase) GetAllAuthorizationDetailTypes(tx *sql.Tx) ([]entities.AuthorizationDetailType, error) {
+	return d.CommonDB.GetAllAuthorizationDetailTypes(tx)
+}
+
+func (d *SQLiteDatabase) DeleteAuthorizationDetailType(tx *sql.Tx, authorizationDetailTypeId int64) error {
+	return d.CommonDB.DeleteAuthorizationDetailType(tx, authorizationDetailTypeId)
+}
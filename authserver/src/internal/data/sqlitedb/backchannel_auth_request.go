@@ -0,0 +1,35 @@
+package sqlitedb
+
+import (
+	"database/sql"
+
+	"github.com/leodip/goiabada/internal/entities"
+)
+
+func (d *SQLiteDatabase) CreateBackchannelAuthRequest(tx *sql.Tx, backchannelAuthRequest *entities.BackchannelAuthRequest) error {
+	return d.CommonDB.CreateBackchannelAuthRequest(tx, backchannelAuthRequest)
+}
+
+func (d *SQLiteDatabase) UpdateBackchannelAuthRequest(tx *sql.Tx, backchannelAuthRequest *entities.BackchannelAuthRequest) error {
+	return d.CommonDB.UpdateBackchannelAuthRequest(tx, backchannelAuthRequest)
+}
+
+func (d *SQLiteDatabase) GetBackchannelAuthRequestById(tx *sql.Tx, backchannelAuthRequestId int64) (*entities.BackchannelAuthRequest, error) {
+	return d.CommonDB.GetBackchannelAuthRequestById(tx, backchannelAuthRequestId)
+}
+
+func (d *SQLiteDatabase) GetBackchannelAuthRequestByAuthReqIdHash(tx *sql.Tx, authReqIdHash string) (*entities.BackchannelAuthRequest, error) {
+	return d.CommonDB.GetBackchannelAuthRequestByAuthReqIdHash(tx, authReqIdHash)
+}
+
+func (d *SQLiteDatabase) GetPendingBackchannelAuthRequestsByUserId(tx *sql.Tx, userId int64) ([]entities.BackchannelAuthRequest, error) {
+	return d.CommonDB.GetPendingBackchannelAuthRequestsByUserId(tx, userId)
+}
+
+func (d *SQLiteDatabase) BackchannelAuthRequestLoadClient(tx *sql.Tx, backchannelAuthRequest *entities.BackchannelAuthRequest) error {
+	return d.CommonDB.BackchannelAuthRequestLoadClient(tx, backchannelAuthRequest)
+}
+
+func (d *SQLiteDatabase) DeleteBackchannelAuthRequest(tx *sql.Tx, backchannelAuthRequestId int64) error {
+	return d.CommonDB.DeleteBackchannelAuthRequest(tx, backchannelAuthRequestId)
+}
@@ -0,0 +1,19 @@
+package sqlitedb
+
+import (
+	"database/sql"
+
+	"github.com/leodip/goiabada/internal/entities"
+)
+
+func (d *SQLiteDatabase) CreateBackChannelLogoutDelivery(tx *sql.Tx, delivery *entities.BackChannelLogoutDelivery) error {
+	return d.CommonDB.CreateBackChannelLogoutDelivery(tx, delivery)
+}
+
+func (d *SQLiteDatabase) UpdateBackChannelLogoutDelivery(tx *sql.Tx, delivery *entities.BackChannelLogoutDelivery) error {
+	return d.CommonDB.UpdateBackChannelLogoutDelivery(tx, delivery)
+}
+
+func (d *SQLiteDatabase) GetBackChannelLogoutDeliveriesByClientId(tx *sql.Tx, clientId int64, limit int) ([]entities.BackChannelLogoutDelivery, error) {
+	return d.CommonDB.GetBackChannelLogoutDeliveriesByClientId(tx, clientId, limit)
+}
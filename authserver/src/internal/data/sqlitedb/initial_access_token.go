@@ -0,0 +1,23 @@
+package sqlitedb
+
+import (
+	"database/sql"
+
+	"github.com/leodip/goiabada/internal/entities"
+)
+
+func (d *SQLiteDatabase) CreateInitialAccessToken(tx *sql.Tx, initialAccessToken *entities.InitialAccessToken) error {
+	return d.CommonDB.CreateInitialAccessToken(tx, initialAccessToken)
+}
+
+func (d *SQLiteDatabase) GetInitialAccessTokenByTokenHash(tx *sql.Tx, tokenHash string) (*entities.InitialAccessToken, error) {
+	return d.CommonDB.GetInitialAccessTokenByTokenHash(tx, tokenHash)
+}
+
+func (d *SQLiteDatabase) GetAllInitialAccessTokens(tx *sql.Tx) ([]entities.InitialAccessToken, error) {
+	return d.CommonDB.GetAllInitialAccessTokens(tx)
+}
+
+func (d *SQLiteDatabase) DeleteInitialAccessToken(tx *sql.Tx, initialAccessTokenId int64) error {
+	return d.CommonDB.DeleteInitialAccessToken(tx, initialAccessTokenId)
+}
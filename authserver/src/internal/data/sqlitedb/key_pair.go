@@ -22,8 +22,8 @@ func (d *SQLiteDatabase) GetAllSigningKeys(tx *sql.Tx) ([]entities.KeyPair, erro
 	return d.CommonDB.GetAllSigningKeys(tx)
 }
 
-func (d *SQLiteDatabase) GetCurrentSigningKey(tx *sql.Tx) (*entities.KeyPair, error) {
-	return d.CommonDB.GetCurrentSigningKey(tx)
+func (d *SQLiteDatabase) GetCurrentSigningKey(tx *sql.Tx, algorithm string) (*entities.KeyPair, error) {
+	return d.CommonDB.GetCurrentSigningKey(tx, algorithm)
 }
 
 func (d *SQLiteDatabase) DeleteKeyPair(tx *sql.Tx, keyPairId int64) error {
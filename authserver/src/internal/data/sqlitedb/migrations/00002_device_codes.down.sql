@@ -0,0 +1,6 @@
+-- BEGIN
+
+DROP TABLE IF EXISTS `device_codes`;
+ALTER TABLE clients DROP COLUMN device_code_enabled;
+
+-- END
@@ -0,0 +1,22 @@
+ALTER TABLE clients ADD COLUMN device_code_enabled numeric NOT NULL DEFAULT 0;
+
+
+CREATE TABLE device_codes (
+  `id` integer PRIMARY KEY AUTOINCREMENT,
+  created_at DATETIME,
+  updated_at DATETIME,
+  device_code_hash TEXT NOT NULL,
+  user_code TEXT NOT NULL,
+  client_id INTEGER NOT NULL,
+  scope TEXT NOT NULL,
+  `state` TEXT NOT NULL,
+  code_id INTEGER,
+  expires_at DATETIME NOT NULL,
+  interval_in_seconds int NOT NULL,
+  last_polled_at DATETIME,
+  CONSTRAINT fk_device_codes_client FOREIGN KEY (client_id) REFERENCES clients (id) ON DELETE CASCADE,
+  CONSTRAINT fk_device_codes_code FOREIGN KEY (code_id) REFERENCES codes (id) ON DELETE SET NULL
+);
+
+CREATE UNIQUE INDEX `idx_device_code_hash` ON `device_codes`(`device_code_hash`);
+CREATE UNIQUE INDEX `idx_user_code` ON `device_codes`(`user_code`);
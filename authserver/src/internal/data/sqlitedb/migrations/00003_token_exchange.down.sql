@@ -0,0 +1,5 @@
+-- BEGIN
+
+ALTER TABLE clients DROP COLUMN token_exchange_enabled;
+
+-- END
@@ -0,0 +1 @@
+ALTER TABLE clients ADD COLUMN token_exchange_enabled numeric NOT NULL DEFAULT 0;
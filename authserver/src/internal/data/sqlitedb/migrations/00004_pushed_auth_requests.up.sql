@@ -0,0 +1,16 @@
+ALTER TABLE clients ADD COLUMN par_required numeric NOT NULL DEFAULT 0;
+
+
+CREATE TABLE pushed_auth_requests (
+  `id` integer PRIMARY KEY AUTOINCREMENT,
+  created_at DATETIME,
+  updated_at DATETIME,
+  request_uri_hash TEXT NOT NULL,
+  client_id INTEGER NOT NULL,
+  parameters TEXT NOT NULL,
+  expires_at DATETIME NOT NULL,
+  used numeric NOT NULL,
+  CONSTRAINT fk_pushed_auth_requests_client FOREIGN KEY (client_id) REFERENCES clients (id) ON DELETE CASCADE
+);
+
+CREATE UNIQUE INDEX `idx_request_uri_hash` ON `pushed_auth_requests`(`request_uri_hash`);
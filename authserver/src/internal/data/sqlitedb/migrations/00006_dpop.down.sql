@@ -0,0 +1,6 @@
+-- BEGIN
+
+DROP TABLE IF EXISTS `used_jtis`;
+ALTER TABLE refresh_tokens DROP COLUMN dpop_jkt;
+
+-- END
\ No newline at end of file
@@ -0,0 +1,13 @@
+ALTER TABLE refresh_tokens ADD COLUMN dpop_jkt TEXT NOT NULL DEFAULT '';
+
+
+CREATE TABLE used_jtis (
+  `id` integer PRIMARY KEY AUTOINCREMENT,
+  created_at DATETIME,
+  updated_at DATETIME,
+  jti_hash TEXT NOT NULL,
+  expires_at DATETIME NOT NULL
+);
+
+CREATE UNIQUE INDEX `idx_jti_hash` ON `used_jtis`(`jti_hash`);
+CREATE INDEX `idx_used_jtis_expires_at` ON `used_jtis`(`expires_at`);
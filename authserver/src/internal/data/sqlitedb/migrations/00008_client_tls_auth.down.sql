@@ -0,0 +1,6 @@
+-- BEGIN
+
+ALTER TABLE clients DROP COLUMN tls_client_certificate;
+ALTER TABLE clients DROP COLUMN tls_client_auth_subject_dn;
+
+-- END
\ No newline at end of file
@@ -0,0 +1,2 @@
+ALTER TABLE clients ADD COLUMN tls_client_auth_subject_dn TEXT NOT NULL DEFAULT '';
+ALTER TABLE clients ADD COLUMN tls_client_certificate TEXT NOT NULL DEFAULT '';
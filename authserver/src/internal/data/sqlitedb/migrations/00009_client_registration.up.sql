@@ -0,0 +1,14 @@
+ALTER TABLE clients ADD COLUMN is_dynamically_registered numeric NOT NULL DEFAULT 0;
+ALTER TABLE clients ADD COLUMN registration_access_token_hash TEXT NOT NULL DEFAULT '';
+
+
+CREATE TABLE initial_access_tokens (
+  `id` integer PRIMARY KEY AUTOINCREMENT,
+  created_at DATETIME,
+  updated_at DATETIME,
+  token_hash TEXT NOT NULL,
+  description TEXT NOT NULL,
+  expires_at DATETIME NULL
+);
+
+CREATE UNIQUE INDEX `idx_initial_access_tokens_token_hash` ON `initial_access_tokens`(`token_hash`);
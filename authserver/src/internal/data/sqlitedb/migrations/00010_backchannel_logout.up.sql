@@ -0,0 +1,19 @@
+ALTER TABLE clients ADD COLUMN backchannel_logout_uri TEXT NOT NULL DEFAULT '';
+
+
+CREATE TABLE backchannel_logout_deliveries (
+  `id` integer PRIMARY KEY AUTOINCREMENT,
+  created_at DATETIME,
+  updated_at DATETIME,
+  client_id INTEGER NOT NULL,
+  session_identifier TEXT NOT NULL,
+  subject TEXT NOT NULL,
+  logout_uri TEXT NOT NULL,
+  `status` TEXT NOT NULL,
+  attempts INTEGER NOT NULL DEFAULT 0,
+  last_attempt_at DATETIME NULL,
+  last_error TEXT NOT NULL DEFAULT '',
+  CONSTRAINT fk_backchannel_logout_deliveries_client FOREIGN KEY (client_id) REFERENCES clients (id) ON DELETE CASCADE
+);
+
+CREATE INDEX `idx_backchannel_logout_deliveries_client_id` ON `backchannel_logout_deliveries`(`client_id`);
@@ -0,0 +1,5 @@
+-- BEGIN
+
+ALTER TABLE codes DROP COLUMN resource;
+
+-- END
\ No newline at end of file
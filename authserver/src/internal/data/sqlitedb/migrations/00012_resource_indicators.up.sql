@@ -0,0 +1 @@
+ALTER TABLE codes ADD COLUMN resource TEXT NOT NULL DEFAULT '';
@@ -0,0 +1,5 @@
+-- BEGIN
+
+ALTER TABLE clients DROP COLUMN legacy_access_token_format;
+
+-- END
\ No newline at end of file
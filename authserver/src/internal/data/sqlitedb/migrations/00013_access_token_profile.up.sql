@@ -0,0 +1 @@
+ALTER TABLE clients ADD COLUMN legacy_access_token_format numeric NOT NULL DEFAULT 0;
@@ -0,0 +1,16 @@
+ALTER TABLE clients ADD COLUMN subject_type TEXT NOT NULL DEFAULT 'public';
+ALTER TABLE clients ADD COLUMN sector_identifier_uri TEXT NOT NULL DEFAULT '';
+
+
+CREATE TABLE pairwise_subjects (
+  `id` integer PRIMARY KEY AUTOINCREMENT,
+  created_at DATETIME,
+  updated_at DATETIME,
+  user_id INTEGER NOT NULL,
+  sector_identifier TEXT NOT NULL,
+  subject TEXT NOT NULL,
+  CONSTRAINT fk_pairwise_subjects_user FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE
+);
+
+CREATE UNIQUE INDEX `idx_pairwise_subjects_user_sector` ON `pairwise_subjects`(`user_id`, `sector_identifier`);
+CREATE UNIQUE INDEX `idx_pairwise_subjects_subject` ON `pairwise_subjects`(`subject`);
@@ -0,0 +1,5 @@
+-- BEGIN
+
+ALTER TABLE clients DROP COLUMN id_token_signed_response_alg;
+
+-- END
\ No newline at end of file
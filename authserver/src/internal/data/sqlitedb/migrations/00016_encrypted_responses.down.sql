@@ -0,0 +1,8 @@
+-- BEGIN
+
+ALTER TABLE clients DROP COLUMN id_token_encrypted_response_alg;
+ALTER TABLE clients DROP COLUMN id_token_encrypted_response_enc;
+ALTER TABLE clients DROP COLUMN userinfo_encrypted_response_alg;
+ALTER TABLE clients DROP COLUMN userinfo_encrypted_response_enc;
+
+-- END
\ No newline at end of file
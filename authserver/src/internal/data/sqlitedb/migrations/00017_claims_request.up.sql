@@ -0,0 +1 @@
+ALTER TABLE codes ADD COLUMN claims TEXT NOT NULL DEFAULT '';
@@ -0,0 +1,16 @@
+ALTER TABLE codes ADD COLUMN authorization_details TEXT NOT NULL DEFAULT '';
+ALTER TABLE refresh_tokens ADD COLUMN authorization_details TEXT NOT NULL DEFAULT '';
+
+
+CREATE TABLE authorization_detail_types (
+  `id` integer PRIMARY KEY AUTOINCREMENT,
+  created_at DATETIME,
+  updated_at DATETIME,
+  resource_id INTEGER NOT NULL,
+  `type` TEXT NOT NULL,
+  `description` TEXT NOT NULL,
+  json_schema TEXT NOT NULL,
+  CONSTRAINT fk_authorization_detail_types_resource FOREIGN KEY (resource_id) REFERENCES resources (id) ON DELETE CASCADE
+);
+
+CREATE UNIQUE INDEX `idx_authorization_detail_types_type` ON `authorization_detail_types`(`type`);
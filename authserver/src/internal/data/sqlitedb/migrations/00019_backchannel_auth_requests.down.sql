@@ -0,0 +1,6 @@
+-- BEGIN
+
+DROP TABLE IF EXISTS `backchannel_auth_requests`;
+ALTER TABLE clients DROP COLUMN ciba_enabled;
+
+-- END
\ No newline at end of file
@@ -0,0 +1,25 @@
+ALTER TABLE clients ADD COLUMN ciba_enabled numeric NOT NULL DEFAULT 0;
+
+
+CREATE TABLE backchannel_auth_requests (
+  `id` integer PRIMARY KEY AUTOINCREMENT,
+  created_at DATETIME,
+  updated_at DATETIME,
+  auth_req_id_hash TEXT NOT NULL,
+  client_id INTEGER NOT NULL,
+  user_id INTEGER NOT NULL,
+  scope TEXT NOT NULL,
+  binding_message TEXT NOT NULL,
+  acr_level TEXT NOT NULL,
+  `state` TEXT NOT NULL,
+  code_id INTEGER,
+  expires_at DATETIME NOT NULL,
+  interval_in_seconds int NOT NULL,
+  last_polled_at DATETIME,
+  CONSTRAINT fk_backchannel_auth_requests_client FOREIGN KEY (client_id) REFERENCES clients (id) ON DELETE CASCADE,
+  CONSTRAINT fk_backchannel_auth_requests_user FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE,
+  CONSTRAINT fk_backchannel_auth_requests_code FOREIGN KEY (code_id) REFERENCES codes (id) ON DELETE SET NULL
+);
+
+CREATE UNIQUE INDEX `idx_auth_req_id_hash` ON `backchannel_auth_requests`(`auth_req_id_hash`);
+CREATE INDEX `idx_backchannel_auth_requests_user_state` ON `backchannel_auth_requests`(`user_id`, `state`);
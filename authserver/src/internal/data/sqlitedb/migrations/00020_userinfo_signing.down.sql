@@ -0,0 +1,5 @@
+-- BEGIN
+
+ALTER TABLE clients DROP COLUMN userinfo_signed_response_alg;
+
+-- END
\ No newline at end of file
@@ -0,0 +1,19 @@
+package sqlitedb
+
+import (
+	"database/sql"
+
+	"github.com/leodip/goiabada/internal/entities"
+)
+
+func (d *SQLiteDatabase) CreatePairwiseSubject(tx *sql.Tx, pairwiseSubject *entities.PairwiseSubject) error {
+	return d.CommonDB.CreatePairwiseSubject(tx, pairwiseSubject)
+}
+
+func (d *SQLiteDatabase) GetPairwiseSubjectByUserIdAndSectorIdentifier(tx *sql.Tx, userId int64, sectorIdentifier string) (*entities.PairwiseSubject, error) {
+	return d.CommonDB.GetPairwiseSubjectByUserIdAndSectorIdentifier(tx, userId, sectorIdentifier)
+}
+
+func (d *SQLiteDatabase) GetPairwiseSubjectBySubject(tx *sql.Tx, subject string) (*entities.PairwiseSubject, error) {
+	return d.CommonDB.GetPairwiseSubjectBySubject(tx, subject)
+}
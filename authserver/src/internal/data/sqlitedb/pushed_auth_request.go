@@ -0,0 +1,31 @@
+package sqlitedb
+
+import (
+	"database/sql"
+
+	"github.com/leodip/goiabada/internal/entities"
+)
+
+func (d *SQLiteDatabase) CreatePushedAuthRequest(tx *sql.Tx, pushedAuthRequest *entities.PushedAuthRequest) error {
+	return d.CommonDB.CreatePushedAuthRequest(tx, pushedAuthRequest)
+}
+
+func (d *SQLiteDatabase) UpdatePushedAuthRequest(tx *sql.Tx, pushedAuthRequest *entities.PushedAuthRequest) error {
+	return d.CommonDB.UpdatePushedAuthRequest(tx, pushedAuthRequest)
+}
+
+func (d *SQLiteDatabase) GetPushedAuthRequestById(tx *sql.Tx, pushedAuthRequestId int64) (*entities.PushedAuthRequest, error) {
+	return d.CommonDB.GetPushedAuthRequestById(tx, pushedAuthRequestId)
+}
+
+func (d *SQLiteDatabase) GetPushedAuthRequestByRequestURIHash(tx *sql.Tx, requestURIHash string) (*entities.PushedAuthRequest, error) {
+	return d.CommonDB.GetPushedAuthRequestByRequestURIHash(tx, requestURIHash)
+}
+
+func (d *SQLiteDatabase) PushedAuthRequestLoadClient(tx *sql.Tx, pushedAuthRequest *entities.PushedAuthRequest) error {
+	return d.CommonDB.PushedAuthRequestLoadClient(tx, pushedAuthRequest)
+}
+
+func (d *SQLiteDatabase) DeletePushedAuthRequest(tx *sql.Tx, pushedAuthRequestId int64) error {
+	return d.CommonDB.DeletePushedAuthRequest(tx, pushedAuthRequestId)
+}
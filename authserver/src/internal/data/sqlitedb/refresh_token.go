@@ -29,3 +29,7 @@ func (d *SQLiteDatabase) GetRefreshTokenByJti(tx *sql.Tx, jti string) (*entities
 func (d *SQLiteDatabase) DeleteRefreshToken(tx *sql.Tx, refreshTokenId int64) error {
 	return d.CommonDB.DeleteRefreshToken(tx, refreshTokenId)
 }
+
+func (d *SQLiteDatabase) GetRefreshTokensByFirstRefreshTokenJti(tx *sql.Tx, firstRefreshTokenJti string) ([]entities.RefreshToken, error) {
+	return d.CommonDB.GetRefreshTokensByFirstRefreshTokenJti(tx, firstRefreshTokenJti)
+}
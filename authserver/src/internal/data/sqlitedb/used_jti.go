@@ -0,0 +1,29 @@
+package sqlitedb
+
+import (
+	"database/sql"
+
+	"github.com/leodip/goiabada/internal/customerrors"
+	"github.com/leodip/goiabada/internal/entities"
+	"github.com/pkg/errors"
+	sqlitedriver "modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
+)
+
+func (d *SQLiteDatabase) CreateUsedJti(tx *sql.Tx, usedJti *entities.UsedJti) error {
+	err := d.CommonDB.CreateUsedJti(tx, usedJti)
+
+	var sqliteErr *sqlitedriver.Error
+	if errors.As(err, &sqliteErr) && sqliteErr.Code() == sqlite3.SQLITE_CONSTRAINT_UNIQUE {
+		return customerrors.ErrJtiAlreadyUsed
+	}
+	return err
+}
+
+func (d *SQLiteDatabase) GetUsedJtiByJtiHash(tx *sql.Tx, jtiHash string) (*entities.UsedJti, error) {
+	return d.CommonDB.GetUsedJtiByJtiHash(tx, jtiHash)
+}
+
+func (d *SQLiteDatabase) DeleteUsedJtisExpired(tx *sql.Tx) error {
+	return d.CommonDB.DeleteUsedJtisExpired(tx)
+}
@@ -12,25 +12,32 @@ import (
 )
 
 type AuthContext struct {
-	ClientId            string
-	RedirectURI         string
-	ResponseType        string
-	CodeChallengeMethod string
-	CodeChallenge       string
-	ResponseMode        string
-	Scope               string
-	ConsentedScope      string
-	MaxAge              string
-	RequestedAcrValues  string
-	State               string
-	Nonce               string
-	UserAgent           string
-	IpAddress           string
-	AcrLevel            string
-	AuthMethods         string
-	AuthTime            time.Time
-	UserId              int64
-	AuthCompleted       bool
+	ClientId             string
+	RedirectURI          string
+	ResponseType         string
+	CodeChallengeMethod  string
+	CodeChallenge        string
+	ResponseMode         string
+	Scope                string
+	ConsentedScope       string
+	Resource             string
+	Claims               string
+	AuthorizationDetails string
+	MaxAge               string
+	RequestedAcrValues   string
+	State                string
+	Nonce                string
+	Prompt               string
+	LoginHint            string
+	IdTokenHintSubject   string
+	UserAgent            string
+	IpAddress            string
+	AcrLevel             string
+	AuthMethods          string
+	AuthTime             time.Time
+	UserId               int64
+	AuthCompleted        bool
+	DeviceCodeId         int64
 }
 
 func (ac *AuthContext) SetScope(scope string) {
@@ -57,6 +64,10 @@ func (ac *AuthContext) HasScope(scope string) bool {
 	return slices.Contains(strings.Split(ac.Scope, " "), scope)
 }
 
+func (ac *AuthContext) HasPrompt(prompt string) bool {
+	return slices.Contains(strings.Fields(ac.Prompt), prompt)
+}
+
 func (ac *AuthContext) ParseRequestedMaxAge() *int {
 	var requestedMaxAge *int
 	if len(ac.MaxAge) > 0 {
@@ -0,0 +1,93 @@
+package dtos
+
+import (
+	"bytes"
+	"encoding/json"
+	"slices"
+
+	"github.com/pkg/errors"
+)
+
+// AuthorizationDetail is an element of the authorization_details parameter (RFC 9396). Besides the type,
+// it holds the fields defined by the type, as sent by the client.
+type AuthorizationDetail map[string]interface{}
+
+func (ad AuthorizationDetail) GetType() string {
+	detailType, _ := ad["type"].(string)
+	return detailType
+}
+
+// GetFields returns the fields of the authorization detail other than the type, sorted by name. Values
+// that are not strings are formatted as JSON.
+func (ad AuthorizationDetail) GetFields() []AuthorizationDetailField {
+	names := []string{}
+	for name := range ad {
+		if name != "type" {
+			names = append(names, name)
+		}
+	}
+	slices.Sort(names)
+
+	fields := []AuthorizationDetailField{}
+	for _, name := range names {
+		value, ok := ad[name].(string)
+		if !ok {
+			b, err := json.Marshal(ad[name])
+			if err != nil {
+				continue
+			}
+			value = string(b)
+		}
+		fields = append(fields, AuthorizationDetailField{Name: name, Value: value})
+	}
+	return fields
+}
+
+type AuthorizationDetails []AuthorizationDetail
+
+// ParseAuthorizationDetails parses the JSON of an authorization_details parameter. It must be an array
+// of objects, each one with a type. An empty parameter results in no authorization details.
+func ParseAuthorizationDetails(authorizationDetails string) (AuthorizationDetails, error) {
+	if len(authorizationDetails) == 0 {
+		return nil, nil
+	}
+
+	var details AuthorizationDetails
+	err := json.Unmarshal([]byte(authorizationDetails), &details)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to parse the authorization details")
+	}
+	for _, detail := range details {
+		if detail == nil || len(detail.GetType()) == 0 {
+			return nil, errors.New("each authorization detail must be an object with a type")
+		}
+	}
+	return details, nil
+}
+
+// String returns the JSON of the authorization details, or an empty string when there are none
+func (ads AuthorizationDetails) String() string {
+	if len(ads) == 0 {
+		return ""
+	}
+	b, err := json.Marshal(ads)
+	if err != nil {
+		return ""
+	}
+	return string(b)
+}
+
+// Contains checks if one of the authorization details is identical to the given one
+func (ads AuthorizationDetails) Contains(authorizationDetail AuthorizationDetail) bool {
+	expected, err := json.Marshal(authorizationDetail)
+	if err != nil {
+		return false
+	}
+	for _, detail := range ads {
+		actual, err := json.Marshal(detail)
+		if err == nil && bytes.Equal(expected, actual) {
+			return true
+		}
+	}
+	return false
+}
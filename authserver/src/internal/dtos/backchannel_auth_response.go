@@ -0,0 +1,7 @@
+package dtos
+
+type BackchannelAuthResponse struct {
+	AuthReqId string `json:"auth_req_id"`
+	ExpiresIn int64  `json:"expires_in"`
+	Interval  int    `json:"interval"`
+}
@@ -0,0 +1,101 @@
+package dtos
+
+import (
+	"bytes"
+	"encoding/json"
+	"slices"
+
+	"github.com/pkg/errors"
+)
+
+// ClaimsRequest is the claims parameter of the authorization request, with the claims requested
+// individually for the id token and for the userinfo response (OpenID Connect Core 1.0, section 5.5)
+type ClaimsRequest struct {
+	UserInfo map[string]*ClaimRequest `json:"userinfo,omitempty"`
+	IdToken  map[string]*ClaimRequest `json:"id_token,omitempty"`
+}
+
+// ClaimRequest holds the constraints of a requested claim. A claim requested as null has no constraints.
+type ClaimRequest struct {
+	Essential bool          `json:"essential,omitempty"`
+	Value     interface{}   `json:"value,omitempty"`
+	Values    []interface{} `json:"values,omitempty"`
+}
+
+// ParseClaimsRequest parses the JSON of a claims parameter. An empty parameter results in an empty request.
+func ParseClaimsRequest(claims string) (*ClaimsRequest, error) {
+	claimsRequest := &ClaimsRequest{}
+	if len(claims) == 0 {
+		return claimsRequest, nil
+	}
+	err := json.Unmarshal([]byte(claims), claimsRequest)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to parse the claims request")
+	}
+	return claimsRequest, nil
+}
+
+func (cr *ClaimsRequest) IsEmpty() bool {
+	return len(cr.UserInfo) == 0 && len(cr.IdToken) == 0
+}
+
+// String returns the JSON of the claims request, or an empty string when it's empty
+func (cr *ClaimsRequest) String() string {
+	if cr.IsEmpty() {
+		return ""
+	}
+	b, err := json.Marshal(cr)
+	if err != nil {
+		return ""
+	}
+	return string(b)
+}
+
+// GetClaimNames returns the names of the claims requested for the id token or for the userinfo response, sorted
+func (cr *ClaimsRequest) GetClaimNames() []string {
+	names := []string{}
+	for _, requested := range []map[string]*ClaimRequest{cr.IdToken, cr.UserInfo} {
+		for name := range requested {
+			if !slices.Contains(names, name) {
+				names = append(names, name)
+			}
+		}
+	}
+	slices.Sort(names)
+	return names
+}
+
+func (cr *ClaimsRequest) IsEssential(name string) bool {
+	return (cr.IdToken[name] != nil && cr.IdToken[name].Essential) ||
+		(cr.UserInfo[name] != nil && cr.UserInfo[name].Essential)
+}
+
+// Remove removes the claim from the id token and from the userinfo requests
+func (cr *ClaimsRequest) Remove(name string) {
+	delete(cr.IdToken, name)
+	delete(cr.UserInfo, name)
+}
+
+// Matches checks if a claim value satisfies the value or values constraints of the request
+func (c *ClaimRequest) Matches(value interface{}) bool {
+	if c == nil || (c.Value == nil && len(c.Values) == 0) {
+		return true
+	}
+
+	actual, err := json.Marshal(value)
+	if err != nil {
+		return false
+	}
+
+	expectedValues := slices.Clone(c.Values)
+	if c.Value != nil {
+		expectedValues = append(expectedValues, c.Value)
+	}
+	for _, expectedValue := range expectedValues {
+		expected, err := json.Marshal(expectedValue)
+		if err == nil && bytes.Equal(expected, actual) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,38 @@
+package dtos
+
+import "encoding/json"
+
+// ClientMetadata is the client metadata of dynamic client registration (RFC 7591, section 2).
+// WebOrigins is an extension, for the origins allowed to call the token endpoint from the browser.
+type ClientMetadata struct {
+	RedirectURIs                 []string        `json:"redirect_uris,omitempty"`
+	TokenEndpointAuthMethod      string          `json:"token_endpoint_auth_method,omitempty"`
+	GrantTypes                   []string        `json:"grant_types,omitempty"`
+	ResponseTypes                []string        `json:"response_types,omitempty"`
+	ClientName                   string          `json:"client_name,omitempty"`
+	JWKS                         json.RawMessage `json:"jwks,omitempty"`
+	JWKSURI                      string          `json:"jwks_uri,omitempty"`
+	TLSClientAuthSubjectDN       string          `json:"tls_client_auth_subject_dn,omitempty"`
+	BackChannelLogoutURI         string          `json:"backchannel_logout_uri,omitempty"`
+	FrontChannelLogoutURI        string          `json:"frontchannel_logout_uri,omitempty"`
+	SubjectType                  string          `json:"subject_type,omitempty"`
+	SectorIdentifierURI          string          `json:"sector_identifier_uri,omitempty"`
+	IdTokenSignedResponseAlg     string          `json:"id_token_signed_response_alg,omitempty"`
+	IdTokenEncryptedResponseAlg  string          `json:"id_token_encrypted_response_alg,omitempty"`
+	IdTokenEncryptedResponseEnc  string          `json:"id_token_encrypted_response_enc,omitempty"`
+	UserInfoSignedResponseAlg    string          `json:"userinfo_signed_response_alg,omitempty"`
+	UserInfoEncryptedResponseAlg string          `json:"userinfo_encrypted_response_alg,omitempty"`
+	UserInfoEncryptedResponseEnc string          `json:"userinfo_encrypted_response_enc,omitempty"`
+	WebOrigins                   []string        `json:"web_origins,omitempty"`
+}
+
+// ClientRegistrationResponse is the client information response (RFC 7591, section 3.2.1)
+type ClientRegistrationResponse struct {
+	ClientId                string `json:"client_id"`
+	ClientSecret            string `json:"client_secret,omitempty"`
+	ClientIdIssuedAt        int64  `json:"client_id_issued_at"`
+	ClientSecretExpiresAt   *int64 `json:"client_secret_expires_at,omitempty"`
+	RegistrationAccessToken string `json:"registration_access_token,omitempty"`
+	RegistrationClientURI   string `json:"registration_client_uri"`
+	ClientMetadata
+}
@@ -87,6 +87,35 @@ func (jwt JwtToken) GetAddressClaim() map[string]string {
 	return map[string]string{}
 }
 
+// GetConfirmationClaim returns a member of the cnf claim, such as the DPoP key thumbprint (jkt)
+func (jwt JwtToken) GetConfirmationClaim(memberName string) string {
+	if jwt.Claims["cnf"] != nil {
+		cnfMap, ok := jwt.Claims["cnf"].(map[string]interface{})
+		if ok {
+			member, ok := cnfMap[memberName].(string)
+			if ok {
+				return member
+			}
+		}
+	}
+	return ""
+}
+
+// GetAuthorizationDetailsClaim returns the authorization details (RFC 9396) of an access token
+func (jwt JwtToken) GetAuthorizationDetailsClaim() AuthorizationDetails {
+	claim, ok := jwt.Claims["authorization_details"].([]interface{})
+	if !ok {
+		return nil
+	}
+	details := AuthorizationDetails{}
+	for _, element := range claim {
+		if detail, ok := element.(map[string]interface{}); ok {
+			details = append(details, detail)
+		}
+	}
+	return details
+}
+
 func (jwt JwtToken) HasScope(scope string) bool {
 	if jwt.Claims["scope"] != nil {
 		scopesStr, ok := jwt.Claims["scope"].(string)
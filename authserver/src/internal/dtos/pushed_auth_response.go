@@ -0,0 +1,6 @@
+package dtos
+
+type PushedAuthResponse struct {
+	RequestURI string `json:"request_uri"`
+	ExpiresIn  int64  `json:"expires_in"`
+}
@@ -5,3 +5,23 @@ type ScopeInfo struct {
 	Description      string
 	AlreadyConsented bool
 }
+
+// ClaimInfo is a claim requested individually with the claims parameter, outside of the requested scopes
+type ClaimInfo struct {
+	Claim            string
+	Scope            string
+	Essential        bool
+	AlreadyConsented bool
+}
+
+// AuthorizationDetailInfo is an element of the authorization_details parameter, as shown on the consent page
+type AuthorizationDetailInfo struct {
+	Type        string
+	Description string
+	Fields      []AuthorizationDetailField
+}
+
+type AuthorizationDetailField struct {
+	Name  string
+	Value string
+}
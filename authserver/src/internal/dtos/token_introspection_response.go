@@ -0,0 +1,14 @@
+package dtos
+
+type TokenIntrospectionResponse struct {
+	Active               bool                 `json:"active"`
+	Scope                string               `json:"scope,omitempty"`
+	ClientId             string               `json:"client_id,omitempty"`
+	Sub                  string               `json:"sub,omitempty"`
+	Exp                  int64                `json:"exp,omitempty"`
+	Iat                  int64                `json:"iat,omitempty"`
+	Sid                  string               `json:"sid,omitempty"`
+	TokenType            string               `json:"token_type,omitempty"`
+	Cnf                  map[string]string    `json:"cnf,omitempty"`
+	AuthorizationDetails AuthorizationDetails `json:"authorization_details,omitempty"`
+}
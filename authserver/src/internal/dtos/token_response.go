@@ -1,11 +1,13 @@
 package dtos
 
 type TokenResponse struct {
-	AccessToken      string `json:"access_token,omitempty"`
-	IdToken          string `json:"id_token,omitempty"`
-	TokenType        string `json:"token_type,omitempty"`
-	ExpiresIn        int64  `json:"expires_in,omitempty"`
-	RefreshToken     string `json:"refresh_token,omitempty"`
-	RefreshExpiresIn int64  `json:"refresh_expires_in,omitempty"`
-	Scope            string `json:"scope,omitempty"`
+	AccessToken          string               `json:"access_token,omitempty"`
+	IdToken              string               `json:"id_token,omitempty"`
+	TokenType            string               `json:"token_type,omitempty"`
+	ExpiresIn            int64                `json:"expires_in,omitempty"`
+	RefreshToken         string               `json:"refresh_token,omitempty"`
+	RefreshExpiresIn     int64                `json:"refresh_expires_in,omitempty"`
+	Scope                string               `json:"scope,omitempty"`
+	IssuedTokenType      string               `json:"issued_token_type,omitempty"`
+	AuthorizationDetails AuthorizationDetails `json:"authorization_details,omitempty"`
 }
@@ -22,12 +22,34 @@ type Client struct {
 	Enabled                                 bool           `db:"enabled"`
 	ConsentRequired                         bool           `db:"consent_required"`
 	IsPublic                                bool           `db:"is_public"`
+	TokenEndpointAuthMethod                 string         `db:"token_endpoint_auth_method"`
+	TLSClientAuthSubjectDN                  string         `db:"tls_client_auth_subject_dn"`
+	TLSClientCertificate                    string         `db:"tls_client_certificate"`
+	IsDynamicallyRegistered                 bool           `db:"is_dynamically_registered"`
+	RegistrationAccessTokenHash             string         `db:"registration_access_token_hash"`
 	AuthorizationCodeEnabled                bool           `db:"authorization_code_enabled"`
 	ClientCredentialsEnabled                bool           `db:"client_credentials_enabled"`
+	DeviceCodeEnabled                       bool           `db:"device_code_enabled"`
+	TokenExchangeEnabled                    bool           `db:"token_exchange_enabled"`
+	CIBAEnabled                             bool           `db:"ciba_enabled"`
+	PARRequired                             bool           `db:"par_required"`
+	JWKS                                    string         `db:"jwks"`
+	JWKSURI                                 string         `db:"jwks_uri"`
+	BackChannelLogoutURI                    string         `db:"backchannel_logout_uri"`
+	FrontChannelLogoutURI                   string         `db:"frontchannel_logout_uri"`
 	TokenExpirationInSeconds                int            `db:"token_expiration_in_seconds"`
 	RefreshTokenOfflineIdleTimeoutInSeconds int            `db:"refresh_token_offline_idle_timeout_in_seconds"`
 	RefreshTokenOfflineMaxLifetimeInSeconds int            `db:"refresh_token_offline_max_lifetime_in_seconds"`
 	IncludeOpenIDConnectClaimsInAccessToken string         `db:"include_open_id_connect_claims_in_access_token"`
+	LegacyAccessTokenFormat                 bool           `db:"legacy_access_token_format"`
+	SubjectType                             string         `db:"subject_type"`
+	SectorIdentifierURI                     string         `db:"sector_identifier_uri"`
+	IdTokenSignedResponseAlg                string         `db:"id_token_signed_response_alg"`
+	IdTokenEncryptedResponseAlg             string         `db:"id_token_encrypted_response_alg"`
+	IdTokenEncryptedResponseEnc             string         `db:"id_token_encrypted_response_enc"`
+	UserInfoSignedResponseAlg               string         `db:"userinfo_signed_response_alg"`
+	UserInfoEncryptedResponseAlg            string         `db:"userinfo_encrypted_response_alg"`
+	UserInfoEncryptedResponseEnc            string         `db:"userinfo_encrypted_response_enc"`
 	DefaultAcrLevel                         enums.AcrLevel `db:"default_acr_level"`
 	Permissions                             []Permission   `db:"-"`
 	RedirectURIs                            []RedirectURI  `db:"-"`
@@ -73,6 +95,17 @@ func (r *Resource) IsSystemLevelResource() bool {
 	return false
 }
 
+type AuthorizationDetailType struct {
+	Id          int64        `db:"id" fieldtag:"pk"`
+	CreatedAt   sql.NullTime `db:"created_at"`
+	UpdatedAt   sql.NullTime `db:"updated_at"`
+	ResourceId  int64        `db:"resource_id"`
+	Resource    Resource     `db:"-"`
+	Type        string       `db:"type" fieldopt:"withquote"`
+	Description string       `db:"description"`
+	JSONSchema  string       `db:"json_schema"`
+}
+
 type Permission struct {
 	Id                   int64        `db:"id" fieldtag:"pk"`
 	CreatedAt            sql.NullTime `db:"created_at"`
@@ -283,29 +316,82 @@ type UserSessionClient struct {
 }
 
 type Code struct {
-	Id                  int64        `db:"id" fieldtag:"pk"`
-	CreatedAt           sql.NullTime `db:"created_at"`
-	UpdatedAt           sql.NullTime `db:"updated_at"`
-	Code                string       `db:"-"`
-	CodeHash            string       `db:"code_hash"`
-	ClientId            int64        `db:"client_id"`
-	Client              Client       `db:"-"`
-	CodeChallenge       string       `db:"code_challenge"`
-	CodeChallengeMethod string       `db:"code_challenge_method"`
-	Scope               string       `db:"scope"`
-	State               string       `db:"state"`
-	Nonce               string       `db:"nonce"`
-	RedirectURI         string       `db:"redirect_uri"`
-	UserId              int64        `db:"user_id"`
-	User                User         `db:"-"`
-	IpAddress           string       `db:"ip_address"`
-	UserAgent           string       `db:"user_agent"`
-	ResponseMode        string       `db:"response_mode"`
-	AuthenticatedAt     time.Time    `db:"authenticated_at"`
-	SessionIdentifier   string       `db:"session_identifier"`
-	AcrLevel            string       `db:"acr_level"`
-	AuthMethods         string       `db:"auth_methods"`
-	Used                bool         `db:"used"`
+	Id                   int64        `db:"id" fieldtag:"pk"`
+	CreatedAt            sql.NullTime `db:"created_at"`
+	UpdatedAt            sql.NullTime `db:"updated_at"`
+	Code                 string       `db:"-"`
+	CodeHash             string       `db:"code_hash"`
+	ClientId             int64        `db:"client_id"`
+	Client               Client       `db:"-"`
+	CodeChallenge        string       `db:"code_challenge"`
+	CodeChallengeMethod  string       `db:"code_challenge_method"`
+	Scope                string       `db:"scope"`
+	Resource             string       `db:"resource"`
+	Claims               string       `db:"claims"`
+	AuthorizationDetails string       `db:"authorization_details"`
+	State                string       `db:"state"`
+	Nonce                string       `db:"nonce"`
+	RedirectURI          string       `db:"redirect_uri"`
+	UserId               int64        `db:"user_id"`
+	User                 User         `db:"-"`
+	IpAddress            string       `db:"ip_address"`
+	UserAgent            string       `db:"user_agent"`
+	ResponseMode         string       `db:"response_mode"`
+	AuthenticatedAt      time.Time    `db:"authenticated_at"`
+	SessionIdentifier    string       `db:"session_identifier"`
+	AcrLevel             string       `db:"acr_level"`
+	AuthMethods          string       `db:"auth_methods"`
+	Used                 bool         `db:"used"`
+}
+
+type DeviceCode struct {
+	Id                int64         `db:"id" fieldtag:"pk"`
+	CreatedAt         sql.NullTime  `db:"created_at"`
+	UpdatedAt         sql.NullTime  `db:"updated_at"`
+	DeviceCode        string        `db:"-"`
+	DeviceCodeHash    string        `db:"device_code_hash"`
+	UserCode          string        `db:"user_code"`
+	ClientId          int64         `db:"client_id"`
+	Client            Client        `db:"-"`
+	Scope             string        `db:"scope"`
+	State             string        `db:"state"`
+	CodeId            sql.NullInt64 `db:"code_id"`
+	ExpiresAt         time.Time     `db:"expires_at"`
+	IntervalInSeconds int           `db:"interval_in_seconds"`
+	LastPolledAt      sql.NullTime  `db:"last_polled_at"`
+}
+
+type BackchannelAuthRequest struct {
+	Id                int64         `db:"id" fieldtag:"pk"`
+	CreatedAt         sql.NullTime  `db:"created_at"`
+	UpdatedAt         sql.NullTime  `db:"updated_at"`
+	AuthReqId         string        `db:"-"`
+	AuthReqIdHash     string        `db:"auth_req_id_hash"`
+	ClientId          int64         `db:"client_id"`
+	Client            Client        `db:"-"`
+	UserId            int64         `db:"user_id"`
+	User              User          `db:"-"`
+	Scope             string        `db:"scope"`
+	BindingMessage    string        `db:"binding_message"`
+	AcrLevel          string        `db:"acr_level"`
+	State             string        `db:"state"`
+	CodeId            sql.NullInt64 `db:"code_id"`
+	ExpiresAt         time.Time     `db:"expires_at"`
+	IntervalInSeconds int           `db:"interval_in_seconds"`
+	LastPolledAt      sql.NullTime  `db:"last_polled_at"`
+}
+
+type PushedAuthRequest struct {
+	Id             int64        `db:"id" fieldtag:"pk"`
+	CreatedAt      sql.NullTime `db:"created_at"`
+	UpdatedAt      sql.NullTime `db:"updated_at"`
+	RequestURI     string       `db:"-"`
+	RequestURIHash string       `db:"request_uri_hash"`
+	ClientId       int64        `db:"client_id"`
+	Client         Client       `db:"-"`
+	Parameters     string       `db:"parameters"`
+	ExpiresAt      time.Time    `db:"expires_at"`
+	Used           bool         `db:"used"`
 }
 
 type RefreshToken struct {
@@ -320,10 +406,29 @@ type RefreshToken struct {
 	SessionIdentifier       string       `db:"session_identifier"`
 	RefreshTokenType        string       `db:"refresh_token_type"`
 	Scope                   string       `db:"scope"`
+	AuthorizationDetails    string       `db:"authorization_details"`
 	IssuedAt                sql.NullTime `db:"issued_at"`
 	ExpiresAt               sql.NullTime `db:"expires_at"`
 	MaxLifetime             sql.NullTime `db:"max_lifetime"`
 	Revoked                 bool         `db:"revoked"`
+	DPoPJkt                 string       `db:"dpop_jkt"`
+}
+
+type UsedJti struct {
+	Id        int64        `db:"id" fieldtag:"pk"`
+	CreatedAt sql.NullTime `db:"created_at"`
+	UpdatedAt sql.NullTime `db:"updated_at"`
+	JtiHash   string       `db:"jti_hash"`
+	ExpiresAt time.Time    `db:"expires_at"`
+}
+
+type InitialAccessToken struct {
+	Id          int64        `db:"id" fieldtag:"pk"`
+	CreatedAt   sql.NullTime `db:"created_at"`
+	UpdatedAt   sql.NullTime `db:"updated_at"`
+	TokenHash   string       `db:"token_hash"`
+	Description string       `db:"description"`
+	ExpiresAt   sql.NullTime `db:"expires_at"`
 }
 
 type KeyPair struct {
@@ -454,3 +559,26 @@ type GroupPermission struct {
 	GroupId      int64        `db:"group_id"`
 	PermissionId int64        `db:"permission_id"`
 }
+
+type BackChannelLogoutDelivery struct {
+	Id                int64        `db:"id" fieldtag:"pk"`
+	CreatedAt         sql.NullTime `db:"created_at"`
+	UpdatedAt         sql.NullTime `db:"updated_at"`
+	ClientId          int64        `db:"client_id"`
+	SessionIdentifier string       `db:"session_identifier"`
+	Subject           string       `db:"subject"`
+	LogoutURI         string       `db:"logout_uri"`
+	Status            string       `db:"status"`
+	Attempts          int          `db:"attempts"`
+	LastAttemptAt     sql.NullTime `db:"last_attempt_at"`
+	LastError         string       `db:"last_error"`
+}
+
+type PairwiseSubject struct {
+	Id               int64        `db:"id" fieldtag:"pk"`
+	CreatedAt        sql.NullTime `db:"created_at"`
+	UpdatedAt        sql.NullTime `db:"updated_at"`
+	UserId           int64        `db:"user_id"`
+	SectorIdentifier string       `db:"sector_identifier"`
+	Subject          string       `db:"subject"`
+}
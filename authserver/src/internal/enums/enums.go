@@ -14,10 +14,11 @@ const (
 	TokenTypeId TokenType = iota
 	TokenTypeBearer
 	TokenTypeRefresh
+	TokenTypeDPoP
 )
 
 func (tt TokenType) String() string {
-	return []string{"ID", "Bearer", "Refresh"}[tt]
+	return []string{"ID", "Bearer", "Refresh", "DPoP"}[tt]
 }
 
 type AcrLevel string
@@ -122,6 +123,60 @@ func KeyStateFromString(s string) (KeyState, error) {
 	return KeyStateCurrent, errors.WithStack(errors.New("invalid key state " + s))
 }
 
+type DeviceCodeState int
+
+const (
+	DeviceCodeStatePending DeviceCodeState = iota
+	DeviceCodeStateApproved
+	DeviceCodeStateDenied
+	DeviceCodeStateUsed
+)
+
+func (dcs DeviceCodeState) String() string {
+	return []string{"pending", "approved", "denied", "used"}[dcs]
+}
+
+func DeviceCodeStateFromString(s string) (DeviceCodeState, error) {
+	switch s {
+	case DeviceCodeStatePending.String():
+		return DeviceCodeStatePending, nil
+	case DeviceCodeStateApproved.String():
+		return DeviceCodeStateApproved, nil
+	case DeviceCodeStateDenied.String():
+		return DeviceCodeStateDenied, nil
+	case DeviceCodeStateUsed.String():
+		return DeviceCodeStateUsed, nil
+	}
+	return DeviceCodeStatePending, errors.WithStack(errors.New("invalid device code state " + s))
+}
+
+type BackchannelAuthRequestState int
+
+const (
+	BackchannelAuthRequestStatePending BackchannelAuthRequestState = iota
+	BackchannelAuthRequestStateApproved
+	BackchannelAuthRequestStateDenied
+	BackchannelAuthRequestStateUsed
+)
+
+func (bars BackchannelAuthRequestState) String() string {
+	return []string{"pending", "approved", "denied", "used"}[bars]
+}
+
+func BackchannelAuthRequestStateFromString(s string) (BackchannelAuthRequestState, error) {
+	switch s {
+	case BackchannelAuthRequestStatePending.String():
+		return BackchannelAuthRequestStatePending, nil
+	case BackchannelAuthRequestStateApproved.String():
+		return BackchannelAuthRequestStateApproved, nil
+	case BackchannelAuthRequestStateDenied.String():
+		return BackchannelAuthRequestStateDenied, nil
+	case BackchannelAuthRequestStateUsed.String():
+		return BackchannelAuthRequestStateUsed, nil
+	}
+	return BackchannelAuthRequestStatePending, errors.WithStack(errors.New("invalid backchannel auth request state " + s))
+}
+
 type SMTPEncryption int
 
 const (
@@ -169,3 +224,82 @@ func ThreeStateSettingFromString(s string) (ThreeStateSetting, error) {
 	}
 	return ThreeStateSettingOn, errors.WithStack(errors.New("invalid three state setting " + s))
 }
+
+type TokenEndpointAuthMethod int
+
+const (
+	TokenEndpointAuthMethodClientSecretPost TokenEndpointAuthMethod = iota
+	TokenEndpointAuthMethodClientSecretJwt
+	TokenEndpointAuthMethodPrivateKeyJwt
+	TokenEndpointAuthMethodClientSecretBasic
+	TokenEndpointAuthMethodTLSClientAuth
+	TokenEndpointAuthMethodSelfSignedTLSClientAuth
+)
+
+func (m TokenEndpointAuthMethod) String() string {
+	return []string{"client_secret_post", "client_secret_jwt", "private_key_jwt", "client_secret_basic",
+		"tls_client_auth", "self_signed_tls_client_auth"}[m]
+}
+
+func TokenEndpointAuthMethodFromString(s string) (TokenEndpointAuthMethod, error) {
+	switch s {
+	case TokenEndpointAuthMethodClientSecretPost.String():
+		return TokenEndpointAuthMethodClientSecretPost, nil
+	case TokenEndpointAuthMethodClientSecretJwt.String():
+		return TokenEndpointAuthMethodClientSecretJwt, nil
+	case TokenEndpointAuthMethodPrivateKeyJwt.String():
+		return TokenEndpointAuthMethodPrivateKeyJwt, nil
+	case TokenEndpointAuthMethodClientSecretBasic.String():
+		return TokenEndpointAuthMethodClientSecretBasic, nil
+	case TokenEndpointAuthMethodTLSClientAuth.String():
+		return TokenEndpointAuthMethodTLSClientAuth, nil
+	case TokenEndpointAuthMethodSelfSignedTLSClientAuth.String():
+		return TokenEndpointAuthMethodSelfSignedTLSClientAuth, nil
+	}
+	return TokenEndpointAuthMethodClientSecretPost, errors.WithStack(errors.New("invalid token endpoint auth method " + s))
+}
+
+type BackChannelLogoutStatus int
+
+const (
+	BackChannelLogoutStatusPending BackChannelLogoutStatus = iota
+	BackChannelLogoutStatusDelivered
+	BackChannelLogoutStatusFailed
+)
+
+func (s BackChannelLogoutStatus) String() string {
+	return []string{"pending", "delivered", "failed"}[s]
+}
+
+func BackChannelLogoutStatusFromString(s string) (BackChannelLogoutStatus, error) {
+	switch s {
+	case BackChannelLogoutStatusPending.String():
+		return BackChannelLogoutStatusPending, nil
+	case BackChannelLogoutStatusDelivered.String():
+		return BackChannelLogoutStatusDelivered, nil
+	case BackChannelLogoutStatusFailed.String():
+		return BackChannelLogoutStatusFailed, nil
+	}
+	return BackChannelLogoutStatusPending, errors.WithStack(errors.New("invalid back-channel logout status " + s))
+}
+
+type SubjectType int
+
+const (
+	SubjectTypePublic SubjectType = iota
+	SubjectTypePairwise
+)
+
+func (t SubjectType) String() string {
+	return []string{"public", "pairwise"}[t]
+}
+
+func SubjectTypeFromString(s string) (SubjectType, error) {
+	switch s {
+	case SubjectTypePublic.String():
+		return SubjectTypePublic, nil
+	case SubjectTypePairwise.String():
+		return SubjectTypePairwise, nil
+	}
+	return SubjectTypePublic, errors.WithStack(errors.New("invalid subject type " + s))
+}
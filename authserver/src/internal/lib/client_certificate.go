@@ -0,0 +1,113 @@
+package lib
+
+import (
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/pem"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+
+	b64 "encoding/base64"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/viper"
+)
+
+func IsMTLSEnabled() bool {
+	return viper.GetBool("MTLS.Enabled")
+}
+
+// GetClientCertificate returns the certificate the client presented in the TLS handshake or, when
+// behind a reverse proxy that terminates TLS, the certificate forwarded in the configured header.
+// It returns nil when mTLS is disabled or the client did not present a certificate.
+func GetClientCertificate(r *http.Request) (*x509.Certificate, error) {
+	if !IsMTLSEnabled() {
+		return nil, nil
+	}
+
+	forwardedCertHeader := viper.GetString("MTLS.ForwardedCertHeader")
+	if viper.GetBool("IsBehindAReverseProxy") && len(forwardedCertHeader) > 0 {
+		headerValue := strings.TrimSpace(r.Header.Get(forwardedCertHeader))
+		if len(headerValue) == 0 {
+			return nil, nil
+		}
+		return ParseForwardedCertificate(headerValue)
+	}
+
+	if r.TLS != nil && len(r.TLS.PeerCertificates) > 0 {
+		return r.TLS.PeerCertificates[0], nil
+	}
+	return nil, nil
+}
+
+// ParseForwardedCertificate parses a certificate forwarded by a reverse proxy, either as a
+// URL-encoded PEM (e.g. nginx's $ssl_client_escaped_cert) or as base64-encoded DER
+func ParseForwardedCertificate(value string) (*x509.Certificate, error) {
+	unescaped, err := url.QueryUnescape(value)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to unescape the forwarded client certificate")
+	}
+
+	if block, _ := pem.Decode([]byte(unescaped)); block != nil {
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, errors.Wrap(err, "unable to parse the forwarded client certificate")
+		}
+		return cert, nil
+	}
+
+	der, err := b64.StdEncoding.DecodeString(value)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to decode the forwarded client certificate")
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to parse the forwarded client certificate")
+	}
+	return cert, nil
+}
+
+func ParseCertificatePEM(certificatePEM string) (*x509.Certificate, error) {
+	block, _ := pem.Decode([]byte(certificatePEM))
+	if block == nil || block.Type != "CERTIFICATE" {
+		return nil, errors.WithStack(errors.New("unable to decode the PEM certificate"))
+	}
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to parse the certificate")
+	}
+	return cert, nil
+}
+
+// GetCertificateThumbprint returns the SHA-256 thumbprint of the certificate, base64url encoded (x5t#S256)
+func GetCertificateThumbprint(cert *x509.Certificate) string {
+	hash := sha256.Sum256(cert.Raw)
+	return b64.RawURLEncoding.EncodeToString(hash[:])
+}
+
+// IsClientCertificateCAConfigured reports whether MTLS.CACertFile is set. Without it, tls_client_auth
+// is not available, as any certificate issued by a public CA would be trusted.
+func IsClientCertificateCAConfigured() bool {
+	return len(viper.GetString("MTLS.CACertFile")) > 0
+}
+
+// GetClientCertificateCAPool returns the certificate authorities trusted to issue client
+// certificates for tls_client_auth, read from MTLS.CACertFile. It never falls back to the system roots.
+func GetClientCertificateCAPool() (*x509.CertPool, error) {
+	caCertFile := viper.GetString("MTLS.CACertFile")
+	if len(caCertFile) == 0 {
+		return nil, errors.WithStack(errors.New("the client certificate CA file is not configured"))
+	}
+
+	caCertPEM, err := os.ReadFile(caCertFile)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to read the client certificate CA file")
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(caCertPEM) {
+		return nil, errors.WithStack(errors.New("the client certificate CA file does not contain any certificates"))
+	}
+	return pool, nil
+}
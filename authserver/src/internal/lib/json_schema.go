@@ -0,0 +1,315 @@
+package lib
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"regexp"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/pkg/errors"
+)
+
+// JSONSchemaTypes are the types of JSON values that a schema can require
+var JSONSchemaTypes = []string{"object", "array", "string", "number", "integer", "boolean", "null"}
+
+// JSONSchema validates JSON documents against a subset of JSON Schema (draft 2020-12). The supported
+// keywords are type, enum, const, properties, required, additionalProperties, items, minItems, maxItems,
+// minLength, maxLength, pattern, minimum, maximum, exclusiveMinimum, exclusiveMaximum, allOf, anyOf
+// and oneOf. The annotations in jsonSchemaAnnotations are ignored, and any other keyword is rejected,
+// so that a schema never looks stricter than what is actually enforced.
+type JSONSchema struct {
+	Type                 jsonSchemaTypes        `json:"type,omitempty"`
+	Enum                 []json.RawMessage      `json:"enum,omitempty"`
+	Const                json.RawMessage        `json:"const,omitempty"`
+	Properties           map[string]*JSONSchema `json:"properties,omitempty"`
+	Required             []string               `json:"required,omitempty"`
+	AdditionalProperties json.RawMessage        `json:"additionalProperties,omitempty"`
+	Items                *JSONSchema            `json:"items,omitempty"`
+	MinItems             *int                   `json:"minItems,omitempty"`
+	MaxItems             *int                   `json:"maxItems,omitempty"`
+	MinLength            *int                   `json:"minLength,omitempty"`
+	MaxLength            *int                   `json:"maxLength,omitempty"`
+	Pattern              string                 `json:"pattern,omitempty"`
+	Minimum              *float64               `json:"minimum,omitempty"`
+	Maximum              *float64               `json:"maximum,omitempty"`
+	ExclusiveMinimum     *float64               `json:"exclusiveMinimum,omitempty"`
+	ExclusiveMaximum     *float64               `json:"exclusiveMaximum,omitempty"`
+	AllOf                []*JSONSchema          `json:"allOf,omitempty"`
+	AnyOf                []*JSONSchema          `json:"anyOf,omitempty"`
+	OneOf                []*JSONSchema          `json:"oneOf,omitempty"`
+
+	pattern                    *regexp.Regexp
+	additionalPropertiesSchema *JSONSchema
+	noAdditionalProperties     bool
+}
+
+// jsonSchemaKeywords are the keywords that JSONSchema enforces
+var jsonSchemaKeywords = []string{"type", "enum", "const", "properties", "required", "additionalProperties",
+	"items", "minItems", "maxItems", "minLength", "maxLength", "pattern", "minimum", "maximum",
+	"exclusiveMinimum", "exclusiveMaximum", "allOf", "anyOf", "oneOf"}
+
+// jsonSchemaAnnotations are the keywords that don't affect validation
+var jsonSchemaAnnotations = []string{"$schema", "$id", "$comment", "title", "description", "default",
+	"examples", "deprecated", "readOnly", "writeOnly"}
+
+func (s *JSONSchema) UnmarshalJSON(data []byte) error {
+	var keywords map[string]json.RawMessage
+	if err := json.Unmarshal(data, &keywords); err != nil {
+		return errors.New("a JSON schema must be a JSON object")
+	}
+	names := make([]string, 0, len(keywords))
+	for name := range keywords {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	for _, name := range names {
+		if !slices.Contains(jsonSchemaKeywords, name) && !slices.Contains(jsonSchemaAnnotations, name) {
+			return errors.Errorf("the keyword '%v' is not supported", name)
+		}
+	}
+
+	// the alias type has the same fields but not this method, so it's decoded the usual way
+	type jsonSchema JSONSchema
+	return json.Unmarshal(data, (*jsonSchema)(s))
+}
+
+// jsonSchemaTypes is the type keyword, which is either a single type or a list of types
+type jsonSchemaTypes []string
+
+func (t *jsonSchemaTypes) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*t = []string{single}
+		return nil
+	}
+	var multiple []string
+	if err := json.Unmarshal(data, &multiple); err != nil {
+		return errors.New("the type keyword must be a string or an array of strings")
+	}
+	*t = multiple
+	return nil
+}
+
+// ParseJSONSchema parses a JSON schema and checks that its keywords are well-formed
+func ParseJSONSchema(schema string) (*JSONSchema, error) {
+	if !strings.HasPrefix(strings.TrimSpace(schema), "{") {
+		return nil, errors.New("the JSON schema must be a JSON object")
+	}
+
+	var jsonSchema JSONSchema
+	err := json.Unmarshal([]byte(schema), &jsonSchema)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to parse the JSON schema")
+	}
+
+	err = jsonSchema.compile()
+	if err != nil {
+		return nil, err
+	}
+	return &jsonSchema, nil
+}
+
+func (s *JSONSchema) compile() error {
+	for _, schemaType := range s.Type {
+		if !slices.Contains(JSONSchemaTypes, schemaType) {
+			return errors.Errorf("unknown type '%v' in the JSON schema", schemaType)
+		}
+	}
+
+	if len(s.Pattern) > 0 {
+		pattern, err := regexp.Compile(s.Pattern)
+		if err != nil {
+			return errors.Errorf("invalid pattern '%v' in the JSON schema", s.Pattern)
+		}
+		s.pattern = pattern
+	}
+
+	if len(s.AdditionalProperties) > 0 {
+		var allowed bool
+		if err := json.Unmarshal(s.AdditionalProperties, &allowed); err == nil {
+			s.noAdditionalProperties = !allowed
+		} else {
+			s.additionalPropertiesSchema = &JSONSchema{}
+			if !bytes.HasPrefix(bytes.TrimSpace(s.AdditionalProperties), []byte("{")) {
+				return errors.New("the additionalProperties keyword must be a boolean or a JSON schema")
+			}
+			if err := json.Unmarshal(s.AdditionalProperties, s.additionalPropertiesSchema); err != nil {
+				return errors.Wrap(err, "invalid additionalProperties schema")
+			}
+		}
+	}
+
+	subschemas := []*JSONSchema{s.Items, s.additionalPropertiesSchema}
+	for _, property := range s.Properties {
+		subschemas = append(subschemas, property)
+	}
+	subschemas = append(subschemas, s.AllOf...)
+	subschemas = append(subschemas, s.AnyOf...)
+	subschemas = append(subschemas, s.OneOf...)
+	for _, subschema := range subschemas {
+		if subschema == nil {
+			continue
+		}
+		if err := subschema.compile(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Validate checks a JSON document, as decoded by encoding/json, against the schema. The error
+// describes the first violation found, with the location of the offending value.
+func (s *JSONSchema) Validate(document interface{}) error {
+	return s.validate(document, "")
+}
+
+func (s *JSONSchema) validate(value interface{}, path string) error {
+	location := path
+	if len(location) == 0 {
+		location = "/"
+	}
+
+	if len(s.Type) > 0 && !slices.ContainsFunc(s.Type, func(schemaType string) bool { return isJSONType(value, schemaType) }) {
+		return errors.Errorf("%v must be of type %v", location, strings.Join(s.Type, " or "))
+	}
+
+	if len(s.Enum) > 0 && !slices.ContainsFunc(s.Enum, func(allowed json.RawMessage) bool { return jsonEquals(allowed, value) }) {
+		allowedValues := []string{}
+		for _, allowed := range s.Enum {
+			allowedValues = append(allowedValues, string(allowed))
+		}
+		return errors.Errorf("%v must be one of: %v", location, strings.Join(allowedValues, ", "))
+	}
+
+	if len(s.Const) > 0 && !jsonEquals(s.Const, value) {
+		return errors.Errorf("%v must be %v", location, string(s.Const))
+	}
+
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for _, name := range s.Required {
+			if _, ok := v[name]; !ok {
+				return errors.Errorf("%v is missing the required property '%v'", location, name)
+			}
+		}
+		names := make([]string, 0, len(v))
+		for name := range v {
+			names = append(names, name)
+		}
+		slices.Sort(names)
+		for _, name := range names {
+			propertySchema := s.Properties[name]
+			if propertySchema == nil {
+				if s.noAdditionalProperties {
+					return errors.Errorf("%v has the property '%v', which is not allowed", location, name)
+				}
+				propertySchema = s.additionalPropertiesSchema
+			}
+			if propertySchema != nil {
+				if err := propertySchema.validate(v[name], path+"/"+name); err != nil {
+					return err
+				}
+			}
+		}
+	case []interface{}:
+		if s.MinItems != nil && len(v) < *s.MinItems {
+			return errors.Errorf("%v must have at least %v items", location, *s.MinItems)
+		}
+		if s.MaxItems != nil && len(v) > *s.MaxItems {
+			return errors.Errorf("%v must have at most %v items", location, *s.MaxItems)
+		}
+		if s.Items != nil {
+			for idx, item := range v {
+				if err := s.Items.validate(item, fmt.Sprintf("%v/%v", path, idx)); err != nil {
+					return err
+				}
+			}
+		}
+	case string:
+		length := utf8.RuneCountInString(v)
+		if s.MinLength != nil && length < *s.MinLength {
+			return errors.Errorf("%v must be at least %v characters long", location, *s.MinLength)
+		}
+		if s.MaxLength != nil && length > *s.MaxLength {
+			return errors.Errorf("%v must be at most %v characters long", location, *s.MaxLength)
+		}
+		if s.pattern != nil && !s.pattern.MatchString(v) {
+			return errors.Errorf("%v must match the pattern %v", location, s.Pattern)
+		}
+	case float64:
+		if s.Minimum != nil && v < *s.Minimum {
+			return errors.Errorf("%v must be greater than or equal to %v", location, *s.Minimum)
+		}
+		if s.Maximum != nil && v > *s.Maximum {
+			return errors.Errorf("%v must be less than or equal to %v", location, *s.Maximum)
+		}
+		if s.ExclusiveMinimum != nil && v <= *s.ExclusiveMinimum {
+			return errors.Errorf("%v must be greater than %v", location, *s.ExclusiveMinimum)
+		}
+		if s.ExclusiveMaximum != nil && v >= *s.ExclusiveMaximum {
+			return errors.Errorf("%v must be less than %v", location, *s.ExclusiveMaximum)
+		}
+	}
+
+	for _, subschema := range s.AllOf {
+		if err := subschema.validate(value, path); err != nil {
+			return err
+		}
+	}
+
+	if len(s.AnyOf) > 0 && !slices.ContainsFunc(s.AnyOf, func(subschema *JSONSchema) bool { return subschema.validate(value, path) == nil }) {
+		return errors.Errorf("%v does not match any of the allowed schemas", location)
+	}
+
+	if len(s.OneOf) > 0 {
+		matches := 0
+		for _, subschema := range s.OneOf {
+			if subschema.validate(value, path) == nil {
+				matches++
+			}
+		}
+		if matches != 1 {
+			return errors.Errorf("%v must match exactly one of the allowed schemas", location)
+		}
+	}
+	return nil
+}
+
+func isJSONType(value interface{}, schemaType string) bool {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		return schemaType == "object"
+	case []interface{}:
+		return schemaType == "array"
+	case string:
+		return schemaType == "string"
+	case float64:
+		return schemaType == "number" || (schemaType == "integer" && v == math.Trunc(v))
+	case bool:
+		return schemaType == "boolean"
+	case nil:
+		return schemaType == "null"
+	}
+	return false
+}
+
+// jsonEquals compares a JSON value of the schema with a decoded value of the document
+func jsonEquals(expected json.RawMessage, value interface{}) bool {
+	var decoded interface{}
+	if err := json.Unmarshal(expected, &decoded); err != nil {
+		return false
+	}
+	expectedBytes, err := json.Marshal(decoded)
+	if err != nil {
+		return false
+	}
+	actualBytes, err := json.Marshal(value)
+	if err != nil {
+		return false
+	}
+	return bytes.Equal(expectedBytes, actualBytes)
+}
@@ -0,0 +1,82 @@
+package lib
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseJSONSchema_UnsupportedKeywords(t *testing.T) {
+	testCases := []struct {
+		name   string
+		schema string
+		errMsg string
+	}{
+		{
+			name:   "at the root",
+			schema: `{"type": "object", "$ref": "#/$defs/payment"}`,
+			errMsg: "the keyword '$ref' is not supported",
+		},
+		{
+			name:   "in a property",
+			schema: `{"properties": {"email": {"type": "string", "format": "email"}}}`,
+			errMsg: "the keyword 'format' is not supported",
+		},
+		{
+			name:   "in items",
+			schema: `{"type": "array", "items": {"type": "array", "uniqueItems": true}}`,
+			errMsg: "the keyword 'uniqueItems' is not supported",
+		},
+		{
+			name:   "in additionalProperties",
+			schema: `{"additionalProperties": {"type": "number", "multipleOf": 5}}`,
+			errMsg: "the keyword 'multipleOf' is not supported",
+		},
+		{
+			name:   "in oneOf",
+			schema: `{"oneOf": [{"type": "string"}, {"type": "object", "dependentRequired": {"a": ["b"]}}]}`,
+			errMsg: "the keyword 'dependentRequired' is not supported",
+		},
+		{
+			name:   "misspelled",
+			schema: `{"type": "object", "require": ["amount"]}`,
+			errMsg: "the keyword 'require' is not supported",
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			schema, err := ParseJSONSchema(testCase.schema)
+			assert.Nil(t, schema)
+			if assert.Error(t, err) {
+				assert.Contains(t, err.Error(), testCase.errMsg)
+			}
+		})
+	}
+}
+
+func TestParseJSONSchema_Annotations(t *testing.T) {
+	schema, err := ParseJSONSchema(`{
+		"$schema": "https://json-schema.org/draft/2020-12/schema",
+		"$id": "https://example.com/payment.json",
+		"title": "Payment",
+		"description": "A payment initiation",
+		"type": "object",
+		"properties": {
+			"amount": {"type": "string", "description": "The amount", "examples": ["10.00"], "default": "0.00"}
+		},
+		"additionalProperties": {"$comment": "anything else is a string", "type": "string"}
+	}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.NoError(t, schema.Validate(map[string]interface{}{"amount": "10.00", "note": "rent"}))
+	assert.EqualError(t, schema.Validate(map[string]interface{}{"amount": 10.0}), "/amount must be of type string")
+	assert.EqualError(t, schema.Validate(map[string]interface{}{"note": true}), "/note must be of type string")
+}
+
+func TestParseJSONSchema_InvalidAdditionalProperties(t *testing.T) {
+	_, err := ParseJSONSchema(`{"additionalProperties": 1}`)
+	assert.EqualError(t, err, "the additionalProperties keyword must be a boolean or a JSON schema")
+}
@@ -0,0 +1,340 @@
+package lib
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/binary"
+	"encoding/json"
+	"hash"
+	"slices"
+	"strings"
+
+	b64 "encoding/base64"
+
+	"github.com/pkg/errors"
+)
+
+// JWEAlgorithms are the supported key management algorithms of JSON Web Encryption (RFC 7516)
+var JWEAlgorithms = []string{"RSA-OAEP", "RSA-OAEP-256", "ECDH-ES", "ECDH-ES+A128KW", "ECDH-ES+A256KW"}
+
+// JWEEncryptionMethods are the supported content encryption algorithms of JSON Web Encryption
+var JWEEncryptionMethods = []string{"A128CBC-HS256", "A256CBC-HS512", "A128GCM", "A256GCM"}
+
+// DefaultJWEEncryptionMethod is the content encryption when a client sets the algorithm
+// but not the encryption method (OpenID Connect Dynamic Client Registration 1.0, section 2)
+const DefaultJWEEncryptionMethod = "A128CBC-HS256"
+
+func IsJWEAlgorithmSupported(alg string) bool {
+	return slices.Contains(JWEAlgorithms, alg)
+}
+
+func IsJWEEncryptionMethodSupported(enc string) bool {
+	return slices.Contains(JWEEncryptionMethods, enc)
+}
+
+// GetJWEKeyType returns the JWK key type (kty) of the recipient keys of the algorithm
+func GetJWEKeyType(alg string) string {
+	if strings.HasPrefix(alg, "RSA-") {
+		return "RSA"
+	}
+	return "EC"
+}
+
+// FindEncryptionKey returns the key of the set to encrypt to with the algorithm, preferring
+// keys explicitly meant for encryption. It returns nil when the set has no suitable key.
+func (jwks *JSONWebKeySet) FindEncryptionKey(alg string) *JSONWebKey {
+	var candidate *JSONWebKey
+	for i := range jwks.Keys {
+		key := &jwks.Keys[i]
+		if key.Kty != GetJWEKeyType(alg) || (key.Alg != "" && key.Alg != alg) {
+			continue
+		}
+		if key.Use == "enc" {
+			return key
+		}
+		if key.Use == "" && candidate == nil {
+			candidate = key
+		}
+	}
+	return candidate
+}
+
+// EncryptJWE encrypts the plaintext to the public key, in the JWE compact serialization. For a
+// nested JWT (a signed JWT that is then encrypted), cty must be "JWT".
+func EncryptJWE(plaintext []byte, key *JSONWebKey, alg string, enc string, cty string) (string, error) {
+	if !IsJWEAlgorithmSupported(alg) {
+		return "", errors.WithStack(errors.Errorf("unsupported JWE algorithm '%v'", alg))
+	}
+	cekSize, err := getCEKSize(enc)
+	if err != nil {
+		return "", err
+	}
+
+	publicKey, err := key.PublicKey()
+	if err != nil {
+		return "", err
+	}
+
+	header := map[string]interface{}{
+		"alg": alg,
+		"enc": enc,
+	}
+	if len(key.Kid) > 0 {
+		header["kid"] = key.Kid
+	}
+	if len(cty) > 0 {
+		header["cty"] = cty
+	}
+
+	var cek []byte
+	var encryptedKey []byte
+
+	switch alg {
+	case "RSA-OAEP", "RSA-OAEP-256":
+		rsaPublicKey, ok := publicKey.(*rsa.PublicKey)
+		if !ok {
+			return "", errors.WithStack(errors.Errorf("the algorithm %v requires an RSA key", alg))
+		}
+		cek = make([]byte, cekSize)
+		_, err = rand.Read(cek)
+		if err != nil {
+			return "", errors.WithStack(err)
+		}
+		encryptedKey, err = rsa.EncryptOAEP(getOAEPHash(alg), rand.Reader, rsaPublicKey, cek, nil)
+		if err != nil {
+			return "", errors.Wrap(err, "unable to encrypt the content encryption key")
+		}
+	default:
+		ecPublicKey, ok := publicKey.(*ecdsa.PublicKey)
+		if !ok {
+			return "", errors.WithStack(errors.Errorf("the algorithm %v requires an EC key", alg))
+		}
+		recipientKey, err := ecPublicKey.ECDH()
+		if err != nil {
+			return "", errors.Wrap(err, "unable to use the EC key for key agreement")
+		}
+		ephemeralKey, err := recipientKey.Curve().GenerateKey(rand.Reader)
+		if err != nil {
+			return "", errors.Wrap(err, "unable to generate the ephemeral key")
+		}
+		sharedSecret, err := ephemeralKey.ECDH(recipientKey)
+		if err != nil {
+			return "", errors.Wrap(err, "unable to compute the shared secret")
+		}
+		header["epk"] = marshalEphemeralPublicKey(ephemeralKey.PublicKey(), key.Crv)
+
+		if alg == "ECDH-ES" {
+			// direct key agreement - the derived key is the content encryption key
+			cek = concatKDF(sharedSecret, enc, nil, nil, cekSize)
+		} else {
+			kek := concatKDF(sharedSecret, alg, nil, nil, getKeyWrapSize(alg))
+			cek = make([]byte, cekSize)
+			_, err = rand.Read(cek)
+			if err != nil {
+				return "", errors.WithStack(err)
+			}
+			encryptedKey, err = aesKeyWrap(kek, cek)
+			if err != nil {
+				return "", err
+			}
+		}
+	}
+
+	headerJSON, err := json.Marshal(header)
+	if err != nil {
+		return "", errors.Wrap(err, "unable to marshal the JWE header")
+	}
+	protectedHeader := b64.RawURLEncoding.EncodeToString(headerJSON)
+
+	iv, ciphertext, tag, err := encryptContent(enc, cek, plaintext, []byte(protectedHeader))
+	if err != nil {
+		return "", err
+	}
+
+	return strings.Join([]string{
+		protectedHeader,
+		b64.RawURLEncoding.EncodeToString(encryptedKey),
+		b64.RawURLEncoding.EncodeToString(iv),
+		b64.RawURLEncoding.EncodeToString(ciphertext),
+		b64.RawURLEncoding.EncodeToString(tag),
+	}, "."), nil
+}
+
+func getCEKSize(enc string) (int, error) {
+	switch enc {
+	case "A128CBC-HS256":
+		return 32, nil
+	case "A256CBC-HS512":
+		return 64, nil
+	case "A128GCM":
+		return 16, nil
+	case "A256GCM":
+		return 32, nil
+	default:
+		return 0, errors.WithStack(errors.Errorf("unsupported JWE encryption method '%v'", enc))
+	}
+}
+
+func getKeyWrapSize(alg string) int {
+	if alg == "ECDH-ES+A256KW" {
+		return 32
+	}
+	return 16
+}
+
+func getOAEPHash(alg string) hash.Hash {
+	if alg == "RSA-OAEP-256" {
+		return sha256.New()
+	}
+	return sha1.New()
+}
+
+func marshalEphemeralPublicKey(publicKey *ecdh.PublicKey, crv string) map[string]string {
+	// uncompressed point: 0x04 || x || y
+	point := publicKey.Bytes()
+	size := (len(point) - 1) / 2
+	return map[string]string{
+		"kty": "EC",
+		"crv": crv,
+		"x":   b64.RawURLEncoding.EncodeToString(point[1 : 1+size]),
+		"y":   b64.RawURLEncoding.EncodeToString(point[1+size:]),
+	}
+}
+
+// concatKDF derives a key from the ECDH shared secret (RFC 7518, section 4.6.2). The JWEs issued
+// here don't set the apu and apv header parameters, so PartyUInfo and PartyVInfo are empty.
+func concatKDF(sharedSecret []byte, algorithmID string, partyUInfo []byte, partyVInfo []byte, keySize int) []byte {
+	otherInfo := []byte{}
+	otherInfo = binary.BigEndian.AppendUint32(otherInfo, uint32(len(algorithmID)))
+	otherInfo = append(otherInfo, []byte(algorithmID)...)
+	otherInfo = binary.BigEndian.AppendUint32(otherInfo, uint32(len(partyUInfo)))
+	otherInfo = append(otherInfo, partyUInfo...)
+	otherInfo = binary.BigEndian.AppendUint32(otherInfo, uint32(len(partyVInfo)))
+	otherInfo = append(otherInfo, partyVInfo...)
+	otherInfo = binary.BigEndian.AppendUint32(otherInfo, uint32(keySize*8))
+
+	key := []byte{}
+	for counter := uint32(1); len(key) < keySize; counter++ {
+		h := sha256.New()
+		h.Write(binary.BigEndian.AppendUint32(nil, counter))
+		h.Write(sharedSecret)
+		h.Write(otherInfo)
+		key = h.Sum(key)
+	}
+	return key[:keySize]
+}
+
+var keyWrapDefaultIV = []byte{0xa6, 0xa6, 0xa6, 0xa6, 0xa6, 0xa6, 0xa6, 0xa6}
+
+// aesKeyWrap wraps the key with the key encryption key (RFC 3394)
+func aesKeyWrap(kek []byte, key []byte) ([]byte, error) {
+	block, err := aes.NewCipher(kek)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	n := len(key) / 8
+	r := make([][]byte, n)
+	for i := range r {
+		r[i] = slices.Clone(key[i*8 : (i+1)*8])
+	}
+	a := slices.Clone(keyWrapDefaultIV)
+	buf := make([]byte, 16)
+	for j := 0; j < 6; j++ {
+		for i := 0; i < n; i++ {
+			copy(buf, a)
+			copy(buf[8:], r[i])
+			block.Encrypt(buf, buf)
+			t := uint64(n*j + i + 1)
+			binary.BigEndian.PutUint64(a, binary.BigEndian.Uint64(buf[:8])^t)
+			copy(r[i], buf[8:])
+		}
+	}
+	result := slices.Clone(a)
+	for i := range r {
+		result = append(result, r[i]...)
+	}
+	return result, nil
+}
+
+func encryptContent(enc string, cek []byte, plaintext []byte, aad []byte) (iv, ciphertext, tag []byte, err error) {
+	iv = make([]byte, aes.BlockSize)
+	if strings.HasSuffix(enc, "GCM") {
+		iv = make([]byte, 12)
+	}
+	_, err = rand.Read(iv)
+	if err != nil {
+		return nil, nil, nil, errors.WithStack(err)
+	}
+	ciphertext, tag, err = sealContent(enc, cek, iv, plaintext, aad)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	return iv, ciphertext, tag, nil
+}
+
+// sealContent encrypts the plaintext with the content encryption key and the initialization vector,
+// and returns the ciphertext and the authentication tag
+func sealContent(enc string, cek []byte, iv []byte, plaintext []byte, aad []byte) (ciphertext, tag []byte, err error) {
+	if strings.HasSuffix(enc, "GCM") {
+		gcm, err := newGCM(cek)
+		if err != nil {
+			return nil, nil, err
+		}
+		if len(iv) != gcm.NonceSize() {
+			return nil, nil, errors.WithStack(errors.New("invalid JWE initialization vector"))
+		}
+		sealed := gcm.Seal(nil, iv, plaintext, aad)
+		tagStart := len(sealed) - gcm.Overhead()
+		return sealed[:tagStart], sealed[tagStart:], nil
+	}
+
+	// AES-CBC with HMAC-SHA2 (RFC 7518, section 5.2)
+	macKey, encKey := cek[:len(cek)/2], cek[len(cek)/2:]
+	block, err := aes.NewCipher(encKey)
+	if err != nil {
+		return nil, nil, errors.WithStack(err)
+	}
+	if len(iv) != aes.BlockSize {
+		return nil, nil, errors.WithStack(errors.New("invalid JWE initialization vector"))
+	}
+	padding := aes.BlockSize - len(plaintext)%aes.BlockSize
+	padded := append(slices.Clone(plaintext), bytes.Repeat([]byte{byte(padding)}, padding)...)
+	ciphertext = make([]byte, len(padded))
+	cipher.NewCBCEncrypter(block, iv).CryptBlocks(ciphertext, padded)
+	tag = computeCBCHMACTag(enc, macKey, aad, iv, ciphertext)
+	return ciphertext, tag, nil
+}
+
+func newGCM(cek []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(cek)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return gcm, nil
+}
+
+func computeCBCHMACTag(enc string, macKey, aad, iv, ciphertext []byte) []byte {
+	hashFunc := sha256.New
+	if enc == "A256CBC-HS512" {
+		hashFunc = sha512.New
+	}
+	mac := hmac.New(hashFunc, macKey)
+	mac.Write(aad)
+	mac.Write(iv)
+	mac.Write(ciphertext)
+	mac.Write(binary.BigEndian.AppendUint64(nil, uint64(len(aad))*8))
+	return mac.Sum(nil)[:len(macKey)]
+}
@@ -0,0 +1,162 @@
+package lib
+
+import (
+	"crypto/ecdh"
+	"encoding/hex"
+	"testing"
+
+	b64 "encoding/base64"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func decodeHex(t *testing.T, s string) []byte {
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+func decodeBase64URL(t *testing.T, s string) []byte {
+	b, err := b64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+// RFC 7516, appendix A.1 (the content encryption with A256GCM)
+func TestSealContent_RFC7516AppendixA1(t *testing.T) {
+	cek := []byte{177, 161, 244, 128, 84, 143, 225, 115, 63, 180, 3, 255, 107, 154, 212, 246,
+		138, 7, 110, 91, 112, 46, 34, 105, 47, 130, 203, 46, 122, 234, 64, 252}
+	iv := decodeBase64URL(t, "48V1_ALb6US04U3b")
+	aad := []byte("eyJhbGciOiJSU0EtT0FFUCIsImVuYyI6IkEyNTZHQ00ifQ")
+	plaintext := []byte("The true sign of intelligence is not knowledge but imagination.")
+
+	ciphertext, tag, err := sealContent("A256GCM", cek, iv, plaintext, aad)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "5eym8TW_c8SuK0ltJ3rpYIzOeDQz7TALvtu6UG9oMo4vpzs9tX_EFShS8iB7j6jiSdiwkIr3ajwQzaBtQD_A",
+		b64.RawURLEncoding.EncodeToString(ciphertext))
+	assert.Equal(t, "XFBoMYUZodetZdvTiFvSkQ", b64.RawURLEncoding.EncodeToString(tag))
+}
+
+// RFC 7516, appendix A.3 (A128KW key wrapping, and the content encryption with A128CBC-HS256)
+func TestEncrypt_RFC7516AppendixA3(t *testing.T) {
+	kek := decodeBase64URL(t, "GawgguFyGrWKav7AX4VKUg")
+	cek := []byte{4, 211, 31, 197, 84, 157, 252, 254, 11, 100, 157, 250, 63, 170, 106, 206,
+		107, 124, 212, 45, 111, 107, 9, 219, 200, 177, 0, 240, 143, 156, 44, 207}
+
+	encryptedKey, err := aesKeyWrap(kek, cek)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "6KB707dM9YTIgHtLvtgWQ8mKwboJW3of9locizkDTHzBC2IlrT1oOQ", b64.RawURLEncoding.EncodeToString(encryptedKey))
+
+	iv := decodeBase64URL(t, "AxY8DCtDaGlsbGljb3RoZQ")
+	aad := []byte("eyJhbGciOiJBMTI4S1ciLCJlbmMiOiJBMTI4Q0JDLUhTMjU2In0")
+
+	ciphertext, tag, err := sealContent("A128CBC-HS256", cek, iv, []byte("Live long and prosper."), aad)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "KDlTtXchhZTGufMYmOYGS4HffxPSUrfmqCHXaI9wOGY", b64.RawURLEncoding.EncodeToString(ciphertext))
+	assert.Equal(t, "U0m_YmjN04DJvceFICbCVQ", b64.RawURLEncoding.EncodeToString(tag))
+}
+
+// RFC 7518, appendix B.1 and B.3 (AES_CBC_HMAC_SHA2 test cases)
+func TestSealContent_RFC7518AppendixB(t *testing.T) {
+	plaintext := []byte("A cipher system must not be required to be secret, and it must be able to fall into the hands of the enemy without inconvenience")
+	iv := decodeHex(t, "1af38c2dc2b96ffdd86694092341bc04")
+	aad := []byte("The second principle of Auguste Kerckhoffs")
+
+	testCases := []struct {
+		enc        string
+		key        string
+		ciphertext string
+		tag        string
+	}{
+		{
+			enc:        "A128CBC-HS256",
+			key:        "000102030405060708090a0b0c0d0e0f101112131415161718191a1b1c1d1e1f",
+			ciphertext: "c80edfa32ddf39d5ef00c0b468834279a2e46a1b8049f792f76bfe54b903a9c9a94ac9b47ad2655c5f10f9aef71427e2fc6f9b3f399a221489f16362c703233609d45ac69864e3321cf82935ac4096c86e133314c54019e8ca7980dfa4b9cf1b384c486f3a54c51078158ee5d79de59fbd34d848b3d69550a67646344427ade54b8851ffb598f7f80074b9473c82e2db",
+			tag:        "652c3fa36b0a7c5b3219fab3a30bc1c4",
+		},
+		{
+			enc:        "A256CBC-HS512",
+			key:        "000102030405060708090a0b0c0d0e0f101112131415161718191a1b1c1d1e1f202122232425262728292a2b2c2d2e2f303132333435363738393a3b3c3d3e3f",
+			ciphertext: "4affaaadb78c31c5da4b1b590d10ffbd3dd8d5d302423526912da037ecbcc7bd822c301dd67c373bccb584ad3e9279c2e6d12a1374b77f077553df829410446b36ebd97066296ae6427ea75c2e0846a11a09ccf5370dc80bfecbad28c73f09b3a3b75e662a2594410ae496b2e2e6609e31e6e02cc837f053d21f37ff4f51950bbe2638d09dd7a4930930806d0703b1f6",
+			tag:        "4dd3b4c088a7f45c216839645b2012bf2e6269a8c56a816dbc1b267761955bc5",
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.enc, func(t *testing.T) {
+			ciphertext, tag, err := sealContent(testCase.enc, decodeHex(t, testCase.key), iv, plaintext, aad)
+			if err != nil {
+				t.Fatal(err)
+			}
+			assert.Equal(t, testCase.ciphertext, hex.EncodeToString(ciphertext))
+			assert.Equal(t, testCase.tag, hex.EncodeToString(tag))
+		})
+	}
+}
+
+// RFC 7518, appendix C (ECDH-ES key agreement, with apu and apv)
+func TestConcatKDF_RFC7518AppendixC(t *testing.T) {
+	ephemeralKey, err := ecdh.P256().NewPrivateKey(decodeBase64URL(t, "0_NxaRPUMQoAJt50Gz8YiTr8gRTwyEaCumd-MToTmIo"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	point := append([]byte{4}, decodeBase64URL(t, "weNJy2HscCSM6AEDTDg04biOvhFhyyWvOHQfeF_PxMQ")...)
+	point = append(point, decodeBase64URL(t, "e8lnCO-AlStT-NJVX-crhB7QRYhiix03illJOVAOyck")...)
+	recipientKey, err := ecdh.P256().NewPublicKey(point)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sharedSecret, err := ephemeralKey.ECDH(recipientKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, []byte{158, 86, 217, 29, 129, 113, 53, 211, 114, 131, 66, 131, 191, 132, 38, 156,
+		251, 49, 110, 163, 218, 128, 106, 72, 246, 218, 167, 121, 140, 254, 144, 196}, sharedSecret)
+
+	key := concatKDF(sharedSecret, "A128GCM", []byte("Alice"), []byte("Bob"), 16)
+	assert.Equal(t, "VqqN6vgjbSBcIijNcacQGg", b64.RawURLEncoding.EncodeToString(key))
+}
+
+// RFC 3394, sections 4.1 and 4.6
+func TestAESKeyWrap_RFC3394(t *testing.T) {
+	testCases := []struct {
+		name    string
+		kek     string
+		key     string
+		wrapped string
+	}{
+		{
+			name:    "128 bits of key data with a 128-bit KEK",
+			kek:     "000102030405060708090a0b0c0d0e0f",
+			key:     "00112233445566778899aabbccddeeff",
+			wrapped: "1fa68b0a8112b447aef34bd8fb5a7b829d3e862371d2cfe5",
+		},
+		{
+			name:    "256 bits of key data with a 256-bit KEK",
+			kek:     "000102030405060708090a0b0c0d0e0f101112131415161718191a1b1c1d1e1f",
+			key:     "00112233445566778899aabbccddeeff000102030405060708090a0b0c0d0e0f",
+			wrapped: "28c9f404c4b810f4cbccb35cfb87f8263f5786e2d80ed326cbc7f0e71a99f43bfb988b9b7a02dd21",
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			wrapped, err := aesKeyWrap(decodeHex(t, testCase.kek), decodeHex(t, testCase.key))
+			if err != nil {
+				t.Fatal(err)
+			}
+			assert.Equal(t, testCase.wrapped, hex.EncodeToString(wrapped))
+		})
+	}
+}
@@ -0,0 +1,215 @@
+package lib
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"math/big"
+
+	b64 "encoding/base64"
+
+	"github.com/pkg/errors"
+)
+
+type JSONWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid,omitempty"`
+	Use string `json:"use,omitempty"`
+	Alg string `json:"alg,omitempty"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+	Y   string `json:"y,omitempty"`
+	// X5c is the certificate chain of the key, base64 (not base64url) encoded DER
+	X5c []string `json:"x5c,omitempty"`
+}
+
+type JSONWebKeySet struct {
+	Keys []JSONWebKey `json:"keys"`
+}
+
+func ParseJSONWebKeySet(data []byte) (*JSONWebKeySet, error) {
+	var jwks JSONWebKeySet
+	err := json.Unmarshal(data, &jwks)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to parse JSON web key set")
+	}
+	if len(jwks.Keys) == 0 {
+		return nil, errors.WithStack(errors.New("the JSON web key set does not contain any keys"))
+	}
+	for i := range jwks.Keys {
+		_, err := jwks.Keys[i].PublicKey()
+		if err != nil {
+			return nil, err
+		}
+	}
+	return &jwks, nil
+}
+
+// FindKey returns the signing key identified by kid. When kid is empty, the set
+// must contain exactly one signing key.
+func (jwks *JSONWebKeySet) FindKey(kid string) *JSONWebKey {
+	var candidates []*JSONWebKey
+	for i := range jwks.Keys {
+		key := &jwks.Keys[i]
+		if key.Use != "" && key.Use != "sig" {
+			continue
+		}
+		if len(kid) > 0 && key.Kid == kid {
+			return key
+		}
+		candidates = append(candidates, key)
+	}
+	if len(kid) == 0 && len(candidates) == 1 {
+		return candidates[0]
+	}
+	return nil
+}
+
+// FindCertificate returns the certificate in the x5c parameter of the first key that has one,
+// or nil when no key has a certificate
+func (jwks *JSONWebKeySet) FindCertificate() (*x509.Certificate, error) {
+	for i := range jwks.Keys {
+		cert, err := jwks.Keys[i].Certificate()
+		if err != nil {
+			return nil, err
+		}
+		if cert != nil {
+			return cert, nil
+		}
+	}
+	return nil, nil
+}
+
+func (k *JSONWebKey) PublicKey() (crypto.PublicKey, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := b64.RawURLEncoding.DecodeString(k.N)
+		if err != nil || len(n) == 0 {
+			return nil, errors.WithStack(errors.New("invalid modulus (n) in RSA JSON web key"))
+		}
+		e, err := b64.RawURLEncoding.DecodeString(k.E)
+		if err != nil || len(e) == 0 || len(e) > 4 {
+			return nil, errors.WithStack(errors.New("invalid exponent (e) in RSA JSON web key"))
+		}
+		return &rsa.PublicKey{
+			N: new(big.Int).SetBytes(n),
+			E: int(new(big.Int).SetBytes(e).Int64()),
+		}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, errors.WithStack(errors.Errorf("unsupported curve '%v' in EC JSON web key", k.Crv))
+		}
+		x, err := b64.RawURLEncoding.DecodeString(k.X)
+		if err != nil || len(x) == 0 {
+			return nil, errors.WithStack(errors.New("invalid x coordinate in EC JSON web key"))
+		}
+		y, err := b64.RawURLEncoding.DecodeString(k.Y)
+		if err != nil || len(y) == 0 {
+			return nil, errors.WithStack(errors.New("invalid y coordinate in EC JSON web key"))
+		}
+		publicKey := &ecdsa.PublicKey{
+			Curve: curve,
+			X:     new(big.Int).SetBytes(x),
+			Y:     new(big.Int).SetBytes(y),
+		}
+		if !curve.IsOnCurve(publicKey.X, publicKey.Y) {
+			return nil, errors.WithStack(errors.New("the point in EC JSON web key is not on the curve"))
+		}
+		return publicKey, nil
+	case "OKP":
+		if k.Crv != "Ed25519" {
+			return nil, errors.WithStack(errors.Errorf("unsupported curve '%v' in OKP JSON web key", k.Crv))
+		}
+		x, err := b64.RawURLEncoding.DecodeString(k.X)
+		if err != nil || len(x) != ed25519.PublicKeySize {
+			return nil, errors.WithStack(errors.New("invalid public key (x) in OKP JSON web key"))
+		}
+		return ed25519.PublicKey(x), nil
+	default:
+		return nil, errors.WithStack(errors.Errorf("unsupported key type '%v' in JSON web key", k.Kty))
+	}
+}
+
+// Certificate returns the first certificate of the x5c chain of the key, or nil when the key has no x5c
+func (k *JSONWebKey) Certificate() (*x509.Certificate, error) {
+	if len(k.X5c) == 0 {
+		return nil, nil
+	}
+	der, err := b64.StdEncoding.DecodeString(k.X5c[0])
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to decode the x5c certificate of the JSON web key")
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to parse the x5c certificate of the JSON web key")
+	}
+	return cert, nil
+}
+
+// Thumbprint computes the JWK SHA-256 thumbprint (RFC 7638), base64url encoded
+func (k *JSONWebKey) Thumbprint() (string, error) {
+	var members string
+	switch k.Kty {
+	case "RSA":
+		members = fmt.Sprintf(`{"e":%q,"kty":%q,"n":%q}`, k.E, k.Kty, k.N)
+	case "EC":
+		members = fmt.Sprintf(`{"crv":%q,"kty":%q,"x":%q,"y":%q}`, k.Crv, k.Kty, k.X, k.Y)
+	case "OKP":
+		members = fmt.Sprintf(`{"crv":%q,"kty":%q,"x":%q}`, k.Crv, k.Kty, k.X)
+	default:
+		return "", errors.WithStack(errors.Errorf("unsupported key type '%v' in JSON web key", k.Kty))
+	}
+	hash := sha256.Sum256([]byte(members))
+	return b64.RawURLEncoding.EncodeToString(hash[:]), nil
+}
+
+// MarshalPublicKeyToJWK marshals an RSA, EC or Ed25519 public key to a JSON web key used for signing
+func MarshalPublicKeyToJWK(publicKey crypto.PublicKey, alg string, kid string) ([]byte, error) {
+	jwk := JSONWebKey{
+		Kid: kid,
+		Use: "sig",
+		Alg: alg,
+	}
+
+	switch key := publicKey.(type) {
+	case *rsa.PublicKey:
+		jwk.Kty = "RSA"
+		jwk.N = b64.RawURLEncoding.EncodeToString(key.N.Bytes())
+		jwk.E = b64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes())
+	case *ecdsa.PublicKey:
+		jwk.Kty = "EC"
+		jwk.Crv = key.Curve.Params().Name
+		// the coordinates are padded to the size of the curve (RFC 7518, section 6.2.1.2)
+		size := (key.Curve.Params().BitSize + 7) / 8
+		jwk.X = b64.RawURLEncoding.EncodeToString(key.X.FillBytes(make([]byte, size)))
+		jwk.Y = b64.RawURLEncoding.EncodeToString(key.Y.FillBytes(make([]byte, size)))
+	case ed25519.PublicKey:
+		jwk.Kty = "OKP"
+		jwk.Crv = "Ed25519"
+		jwk.X = b64.RawURLEncoding.EncodeToString(key)
+	default:
+		return nil, errors.WithStack(errors.Errorf("unsupported public key type %T", publicKey))
+	}
+
+	publicKeyJWK, err := json.MarshalIndent(jwk, "", "  ")
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to marshal public key to JSON")
+	}
+	return publicKeyJWK, nil
+}
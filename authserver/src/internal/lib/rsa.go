@@ -4,13 +4,7 @@ import (
 	"crypto/rand"
 	"crypto/rsa"
 	"crypto/x509"
-	"encoding/json"
 	"encoding/pem"
-	"math/big"
-
-	b64 "encoding/base64"
-
-	"github.com/pkg/errors"
 )
 
 func GeneratePrivateKey(bitSize int) (*rsa.PrivateKey, error) {
@@ -55,27 +49,3 @@ func EncodePublicKeyToPEM(publicKey *rsa.PublicKey) ([]byte, error) {
 
 	return pubkey_pem, nil
 }
-
-func MarshalRSAPublicKeyToJWK(publicKey *rsa.PublicKey, kid string) ([]byte, error) {
-	jwt := struct {
-		Alg string `json:"alg"`
-		Kid string `json:"kid"`
-		Kty string `json:"kty"`
-		Use string `json:"use"`
-		N   string `json:"n"`
-		E   string `json:"e"`
-	}{
-		Alg: "RS256",
-		Kid: kid,
-		Kty: "RSA",
-		Use: "sig",
-		N:   b64.RawURLEncoding.EncodeToString(publicKey.N.Bytes()),
-		E:   b64.RawURLEncoding.EncodeToString(big.NewInt(int64(publicKey.E)).Bytes()),
-	}
-
-	publicKeyJWK, err := json.MarshalIndent(jwt, "", "  ")
-	if err != nil {
-		return nil, errors.Wrap(err, "unable to marshal public key to JSON")
-	}
-	return publicKeyJWK, nil
-}
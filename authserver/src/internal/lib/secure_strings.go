@@ -36,6 +36,23 @@ func GenerateRandomNumbers(length int) string {
 	return string(bytes)
 }
 
+// GenerateUserCode returns a code in the format XXXX-XXXX, to be typed by the end-user.
+// Vowels are excluded to avoid forming words, as recommended by RFC 8628.
+func GenerateUserCode() string {
+	const chars = "BCDFGHJKLMNPQRSTVWXZ"
+	bytes := make([]byte, 8)
+
+	if _, err := rand.Read(bytes); err != nil {
+		return ""
+	}
+
+	for i, b := range bytes {
+		bytes[i] = chars[b%byte(len(chars))]
+	}
+
+	return string(bytes[:4]) + "-" + string(bytes[4:])
+}
+
 func GeneratePKCECodeChallenge(codeVerifier string) string {
 	bytes := sha256.Sum256([]byte(codeVerifier))
 	return base64.RawURLEncoding.EncodeToString(bytes[:])
@@ -0,0 +1,38 @@
+package lib
+
+import (
+	"net/url"
+
+	"github.com/pkg/errors"
+)
+
+// GetBrowserState returns the OP browser state (OpenID Connect Session Management 1.0),
+// derived from the session identifier. It's stored in a cookie readable by the check session iframe.
+func GetBrowserState(sessionIdentifier string) (string, error) {
+	return HashString(sessionIdentifier)
+}
+
+// CalculateSessionState returns the session_state of the authorization response, in the
+// format hash.salt. The check session iframe calculates it again to detect changes.
+func CalculateSessionState(clientIdentifier string, redirectURI string, browserState string) (string, error) {
+	origin, err := GetOrigin(redirectURI)
+	if err != nil {
+		return "", err
+	}
+
+	salt := GenerateRandomNumbers(16)
+	hash, err := HashString(clientIdentifier + " " + origin + " " + browserState + " " + salt)
+	if err != nil {
+		return "", err
+	}
+	return hash + "." + salt, nil
+}
+
+// GetOrigin returns the origin (scheme://host[:port]) of an URL
+func GetOrigin(uri string) (string, error) {
+	parsedURI, err := url.Parse(uri)
+	if err != nil {
+		return "", errors.Wrap(err, "unable to parse the URI")
+	}
+	return parsedURI.Scheme + "://" + parsedURI.Host, nil
+}
@@ -0,0 +1,221 @@
+package lib
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"slices"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/pkg/errors"
+)
+
+// DefaultSigningAlgorithm is the algorithm of the keys that sign tokens, unless the
+// client requests another algorithm for its id tokens
+const DefaultSigningAlgorithm = "RS256"
+
+// SigningAlgorithms are the algorithms supported for the key pairs that sign tokens
+var SigningAlgorithms = []string{"RS256", "ES256", "ES384", "EdDSA"}
+
+// SigningKeyPair holds a new key pair in the formats stored in the database
+type SigningKeyPair struct {
+	Type              string
+	PrivateKeyPEM     []byte
+	PublicKeyPEM      []byte
+	PublicKeyASN1_DER []byte
+	PublicKeyJWK      []byte
+}
+
+// SigningKey is a private key ready to sign tokens
+type SigningKey struct {
+	KeyIdentifier string
+	Method        jwt.SigningMethod
+	PrivateKey    crypto.PrivateKey
+}
+
+func IsSigningAlgorithmSupported(algorithm string) bool {
+	return slices.Contains(SigningAlgorithms, algorithm)
+}
+
+// GetKeyType returns the JWK key type (kty) of the keys of the algorithm
+func GetKeyType(algorithm string) (string, error) {
+	switch algorithm {
+	case "RS256":
+		return "RSA", nil
+	case "ES256", "ES384":
+		return "EC", nil
+	case "EdDSA":
+		return "OKP", nil
+	default:
+		return "", errors.WithStack(errors.Errorf("unsupported signing algorithm '%v'", algorithm))
+	}
+}
+
+// GenerateSigningKeyPair generates a key pair for the algorithm: RSA 4096 for RS256, the P-256 and
+// P-384 curves for ES256 and ES384, and Ed25519 for EdDSA
+func GenerateSigningKeyPair(algorithm string, kid string) (*SigningKeyPair, error) {
+	keyType, err := GetKeyType(algorithm)
+	if err != nil {
+		return nil, err
+	}
+
+	var privateKeyPEM []byte
+	var publicKey crypto.PublicKey
+
+	switch algorithm {
+	case "RS256":
+		privateKey, err := GeneratePrivateKey(4096)
+		if err != nil {
+			return nil, errors.Wrap(err, "unable to generate a private key")
+		}
+		privateKeyPEM = EncodePrivateKeyToPEM(privateKey)
+		publicKey = &privateKey.PublicKey
+	case "ES256", "ES384":
+		curve := elliptic.P256()
+		if algorithm == "ES384" {
+			curve = elliptic.P384()
+		}
+		privateKey, err := ecdsa.GenerateKey(curve, rand.Reader)
+		if err != nil {
+			return nil, errors.Wrap(err, "unable to generate a private key")
+		}
+		privateKeyPEM, err = encodePKCS8PrivateKeyToPEM(privateKey)
+		if err != nil {
+			return nil, err
+		}
+		publicKey = &privateKey.PublicKey
+	case "EdDSA":
+		edPublicKey, privateKey, err := ed25519.GenerateKey(rand.Reader)
+		if err != nil {
+			return nil, errors.Wrap(err, "unable to generate a private key")
+		}
+		privateKeyPEM, err = encodePKCS8PrivateKeyToPEM(privateKey)
+		if err != nil {
+			return nil, err
+		}
+		publicKey = edPublicKey
+	}
+
+	publicKeyASN1_DER, err := x509.MarshalPKIXPublicKey(publicKey)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to marshal public key to PKIX")
+	}
+
+	pemType := "PUBLIC KEY"
+	if keyType == "RSA" {
+		pemType = "RSA PUBLIC KEY"
+	}
+	publicKeyPEM := pem.EncodeToMemory(
+		&pem.Block{
+			Type:  pemType,
+			Bytes: publicKeyASN1_DER,
+		},
+	)
+
+	publicKeyJWK, err := MarshalPublicKeyToJWK(publicKey, algorithm, kid)
+	if err != nil {
+		return nil, err
+	}
+
+	return &SigningKeyPair{
+		Type:              keyType,
+		PrivateKeyPEM:     privateKeyPEM,
+		PublicKeyPEM:      publicKeyPEM,
+		PublicKeyASN1_DER: publicKeyASN1_DER,
+		PublicKeyJWK:      publicKeyJWK,
+	}, nil
+}
+
+// ParseSigningKey parses the private key of a key pair, and checks that it matches the algorithm
+func ParseSigningKey(algorithm string, kid string, privateKeyPEM []byte) (*SigningKey, error) {
+	block, _ := pem.Decode(privateKeyPEM)
+	if block == nil {
+		return nil, errors.WithStack(errors.New("unable to decode the private key PEM"))
+	}
+
+	var privateKey crypto.PrivateKey
+	var err error
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		privateKey, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "EC PRIVATE KEY":
+		privateKey, err = x509.ParseECPrivateKey(block.Bytes)
+	default:
+		privateKey, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to parse private key from PEM")
+	}
+
+	signer, ok := privateKey.(crypto.Signer)
+	if !ok {
+		return nil, errors.WithStack(errors.New("the private key can't be used for signing"))
+	}
+	err = checkKeyAlgorithm(algorithm, signer.Public())
+	if err != nil {
+		return nil, err
+	}
+
+	return &SigningKey{
+		KeyIdentifier: kid,
+		Method:        jwt.GetSigningMethod(algorithm),
+		PrivateKey:    privateKey,
+	}, nil
+}
+
+// ParseVerificationKey parses the public key of a key pair, and checks that it matches the algorithm
+func ParseVerificationKey(algorithm string, publicKeyPEM []byte) (crypto.PublicKey, error) {
+	block, _ := pem.Decode(publicKeyPEM)
+	if block == nil {
+		return nil, errors.WithStack(errors.New("unable to decode the public key PEM"))
+	}
+	publicKey, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to parse public key from PEM")
+	}
+	err = checkKeyAlgorithm(algorithm, publicKey)
+	if err != nil {
+		return nil, err
+	}
+	return publicKey, nil
+}
+
+// NewToken creates a token signed by the key, identified by the kid header
+func (k *SigningKey) NewToken(claims jwt.Claims) *jwt.Token {
+	token := jwt.NewWithClaims(k.Method, claims)
+	token.Header["kid"] = k.KeyIdentifier
+	return token
+}
+
+func checkKeyAlgorithm(algorithm string, publicKey crypto.PublicKey) error {
+	matches := false
+	switch key := publicKey.(type) {
+	case *rsa.PublicKey:
+		matches = algorithm == "RS256"
+	case *ecdsa.PublicKey:
+		matches = (algorithm == "ES256" && key.Curve == elliptic.P256()) ||
+			(algorithm == "ES384" && key.Curve == elliptic.P384())
+	case ed25519.PublicKey:
+		matches = algorithm == "EdDSA"
+	}
+	if !matches {
+		return errors.WithStack(errors.Errorf("the key does not match the signing algorithm '%v'", algorithm))
+	}
+	return nil
+}
+
+func encodePKCS8PrivateKeyToPEM(privateKey crypto.PrivateKey) ([]byte, error) {
+	privDER, err := x509.MarshalPKCS8PrivateKey(privateKey)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to marshal private key to PKCS8")
+	}
+	return pem.EncodeToMemory(&pem.Block{
+		Type:  "PRIVATE KEY",
+		Bytes: privDER,
+	}), nil
+}
@@ -0,0 +1,353 @@
+package server
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pquerna/otp/totp"
+
+	"github.com/gorilla/csrf"
+	"github.com/leodip/goiabada/internal/common"
+	"github.com/leodip/goiabada/internal/constants"
+	core_authorize "github.com/leodip/goiabada/internal/core/authorize"
+	"github.com/leodip/goiabada/internal/dtos"
+	"github.com/leodip/goiabada/internal/entities"
+	"github.com/leodip/goiabada/internal/enums"
+	"github.com/leodip/goiabada/internal/lib"
+)
+
+type authenticationRequestInfo struct {
+	BackchannelAuthRequestId int64
+	Client                   string
+	ClientDescription        string
+	BindingMessage           string
+	Scope                    string
+	RequestedAt              string
+	OTPRequired              bool
+}
+
+// mustPerformOTPForBackchannelAuthRequest tells if the user must also enter an OTP code to approve
+// the request. Approving with the password is an acr level 1 authentication, so the login manager
+// decides if the acr level requested by the client needs the OTP step on top of it.
+func (s *Server) mustPerformOTPForBackchannelAuthRequest(ctx context.Context, loginManager loginManager,
+	user *entities.User, backchannelAuthRequest *entities.BackchannelAuthRequest) (bool, error) {
+
+	targetAcrLevel, err := enums.AcrLevelFromString(backchannelAuthRequest.AcrLevel)
+	if err != nil {
+		return false, err
+	}
+
+	passwordSession := &entities.UserSession{
+		AcrLevel: enums.AcrLevel1.String(),
+		User:     *user,
+	}
+	return loginManager.MustPerformOTPAuth(ctx, &backchannelAuthRequest.Client, passwordSession, targetAcrLevel), nil
+}
+
+func (s *Server) buildAuthenticationRequestInfoArray(ctx context.Context, loginManager loginManager,
+	user *entities.User) ([]authenticationRequestInfo, error) {
+
+	backchannelAuthRequests, err := s.database.GetPendingBackchannelAuthRequestsByUserId(nil, user.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	authenticationRequestInfoArr := []authenticationRequestInfo{}
+	for _, backchannelAuthRequest := range backchannelAuthRequests {
+		err = s.database.BackchannelAuthRequestLoadClient(nil, &backchannelAuthRequest)
+		if err != nil {
+			return nil, err
+		}
+
+		otpRequired, err := s.mustPerformOTPForBackchannelAuthRequest(ctx, loginManager, user, &backchannelAuthRequest)
+		if err != nil {
+			return nil, err
+		}
+
+		authenticationRequestInfoArr = append(authenticationRequestInfoArr, authenticationRequestInfo{
+			BackchannelAuthRequestId: backchannelAuthRequest.Id,
+			Client:                   backchannelAuthRequest.Client.ClientIdentifier,
+			ClientDescription:        backchannelAuthRequest.Client.Description,
+			BindingMessage:           backchannelAuthRequest.BindingMessage,
+			Scope:                    backchannelAuthRequest.Scope,
+			RequestedAt:              backchannelAuthRequest.CreatedAt.Time.Format(time.RFC1123),
+			OTPRequired:              otpRequired,
+		})
+	}
+	return authenticationRequestInfoArr, nil
+}
+
+func (s *Server) handleAccountAuthenticationRequestsGet(loginManager loginManager) http.HandlerFunc {
+
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		var jwtInfo dtos.JwtInfo
+		if r.Context().Value(common.ContextKeyJwtInfo) != nil {
+			jwtInfo = r.Context().Value(common.ContextKeyJwtInfo).(dtos.JwtInfo)
+		}
+
+		sub, err := jwtInfo.IdToken.Claims.GetSubject()
+		if err != nil {
+			s.internalServerError(w, r, err)
+			return
+		}
+		user, err := s.database.GetUserBySubject(nil, sub)
+		if err != nil {
+			s.internalServerError(w, r, err)
+			return
+		}
+
+		authenticationRequestInfoArr, err := s.buildAuthenticationRequestInfoArray(r.Context(), loginManager, user)
+		if err != nil {
+			s.internalServerError(w, r, err)
+			return
+		}
+
+		sess, err := s.sessionStore.Get(r, common.SessionName)
+		if err != nil {
+			s.internalServerError(w, r, err)
+			return
+		}
+
+		requestResult := sess.Flashes("authenticationRequestResult")
+		if requestResult != nil {
+			err = sess.Save(r, w)
+			if err != nil {
+				s.internalServerError(w, r, err)
+				return
+			}
+		}
+
+		bind := map[string]interface{}{
+			"authenticationRequests": authenticationRequestInfoArr,
+			"csrfField":              csrf.TemplateField(r),
+		}
+		if len(requestResult) > 0 {
+			bind["requestResult"] = requestResult[0]
+		}
+
+		err = s.renderTemplate(w, r, "/layouts/menu_layout.html", "/account_authentication_requests.html", bind)
+		if err != nil {
+			s.internalServerError(w, r, err)
+			return
+		}
+	}
+}
+
+func (s *Server) handleAccountAuthenticationRequestsPost(codeIssuer codeIssuer, loginManager loginManager) http.HandlerFunc {
+
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		var jwtInfo dtos.JwtInfo
+		if r.Context().Value(common.ContextKeyJwtInfo) != nil {
+			jwtInfo = r.Context().Value(common.ContextKeyJwtInfo).(dtos.JwtInfo)
+		}
+
+		sub, err := jwtInfo.IdToken.Claims.GetSubject()
+		if err != nil {
+			s.internalServerError(w, r, err)
+			return
+		}
+		user, err := s.database.GetUserBySubject(nil, sub)
+		if err != nil {
+			s.internalServerError(w, r, err)
+			return
+		}
+
+		var backchannelAuthRequestId int64
+
+		renderError := func(message string) {
+			authenticationRequestInfoArr, err := s.buildAuthenticationRequestInfoArray(r.Context(), loginManager, user)
+			if err != nil {
+				s.internalServerError(w, r, err)
+				return
+			}
+
+			bind := map[string]interface{}{
+				"authenticationRequests": authenticationRequestInfoArr,
+				"csrfField":              csrf.TemplateField(r),
+			}
+
+			// the error goes next to the form of the request, unless that request is no longer listed
+			if slices.ContainsFunc(authenticationRequestInfoArr, func(info authenticationRequestInfo) bool {
+				return info.BackchannelAuthRequestId == backchannelAuthRequestId
+			}) {
+				bind["error"] = message
+				bind["errorRequestId"] = backchannelAuthRequestId
+			} else {
+				bind["requestError"] = message
+			}
+
+			err = s.renderTemplate(w, r, "/layouts/menu_layout.html", "/account_authentication_requests.html", bind)
+			if err != nil {
+				s.internalServerError(w, r, err)
+			}
+		}
+
+		const notFoundMessage = "The authentication request was not found."
+
+		backchannelAuthRequestId, err = strconv.ParseInt(r.FormValue("backchannelAuthRequestId"), 10, 64)
+		if err != nil {
+			renderError(notFoundMessage)
+			return
+		}
+
+		backchannelAuthRequest, err := s.database.GetBackchannelAuthRequestById(nil, backchannelAuthRequestId)
+		if err != nil {
+			s.internalServerError(w, r, err)
+			return
+		}
+		if backchannelAuthRequest == nil || backchannelAuthRequest.UserId != user.Id {
+			renderError(notFoundMessage)
+			return
+		}
+		if backchannelAuthRequest.State != enums.BackchannelAuthRequestStatePending.String() ||
+			time.Now().UTC().After(backchannelAuthRequest.ExpiresAt) {
+			renderError("The authentication request is no longer pending. It may have expired.")
+			return
+		}
+
+		err = s.database.BackchannelAuthRequestLoadClient(nil, backchannelAuthRequest)
+		if err != nil {
+			s.internalServerError(w, r, err)
+			return
+		}
+		client := &backchannelAuthRequest.Client
+
+		sess, err := s.sessionStore.Get(r, common.SessionName)
+		if err != nil {
+			s.internalServerError(w, r, err)
+			return
+		}
+
+		if len(r.FormValue("btnDeny")) > 0 {
+			backchannelAuthRequest.State = enums.BackchannelAuthRequestStateDenied.String()
+			err = s.database.UpdateBackchannelAuthRequest(nil, backchannelAuthRequest)
+			if err != nil {
+				s.internalServerError(w, r, err)
+				return
+			}
+
+			lib.LogAudit(constants.AuditDeniedBackchannelAuthRequest, map[string]interface{}{
+				"userId":                   user.Id,
+				"clientId":                 client.Id,
+				"backchannelAuthRequestId": backchannelAuthRequest.Id,
+				"loggedInUser":             s.getLoggedInSubject(r),
+			})
+
+			sess.AddFlash("The authentication request from "+client.ClientIdentifier+" was denied.", "authenticationRequestResult")
+			err = sess.Save(r, w)
+			if err != nil {
+				s.internalServerError(w, r, err)
+				return
+			}
+
+			http.Redirect(w, r, lib.GetBaseUrl()+"/account/authentication-requests", http.StatusFound)
+			return
+		}
+
+		if !user.Enabled {
+			lib.LogAudit(constants.AuditUserDisabled, map[string]interface{}{
+				"userId": user.Id,
+			})
+			renderError("Your account is disabled.")
+			return
+		}
+
+		if !lib.VerifyPasswordHash(user.PasswordHash, r.FormValue("password")) {
+			lib.LogAudit(constants.AuditAuthFailedPwd, map[string]interface{}{
+				"email": user.Email,
+			})
+			renderError("Authentication failed. Check your password and try again.")
+			return
+		}
+
+		authMethods := enums.AuthMethodPassword.String()
+
+		mustPerformOTPAuth, err := s.mustPerformOTPForBackchannelAuthRequest(r.Context(), loginManager, user, backchannelAuthRequest)
+		if err != nil {
+			s.internalServerError(w, r, err)
+			return
+		}
+		if mustPerformOTPAuth {
+			if !user.OTPEnabled {
+				renderError("This request requires 2-factor authentication. Please enable OTP for your account before approving it.")
+				return
+			}
+
+			otpCode := strings.TrimSpace(r.FormValue("otp"))
+			if len(otpCode) == 0 {
+				renderError("OTP code is required.")
+				return
+			}
+			if !totp.Validate(otpCode, user.OTPSecret) {
+				lib.LogAudit(constants.AuditAuthFailedOtp, map[string]interface{}{
+					"userId": user.Id,
+				})
+				renderError("Incorrect OTP Code. OTP codes are time-sensitive and change every 30 seconds. Make sure you're using the most recent code generated by your authenticator app.")
+				return
+			}
+			authMethods = authMethods + " " + enums.AuthMethodOTP.String()
+		}
+
+		// the client is now part of the user session where the request was approved
+		sessionIdentifier := ""
+		if r.Context().Value(common.ContextKeySessionIdentifier) != nil {
+			sessionIdentifier = r.Context().Value(common.ContextKeySessionIdentifier).(string)
+		}
+		if len(sessionIdentifier) > 0 {
+			_, err = s.bumpUserSession(w, r, sessionIdentifier, client.Id)
+			if err != nil {
+				s.internalServerError(w, r, err)
+				return
+			}
+		}
+
+		code, err := codeIssuer.CreateAuthCode(r.Context(), &core_authorize.CreateCodeInput{
+			AuthContext: dtos.AuthContext{
+				ClientId:    client.ClientIdentifier,
+				Scope:       backchannelAuthRequest.Scope,
+				UserId:      user.Id,
+				AcrLevel:    backchannelAuthRequest.AcrLevel,
+				AuthMethods: authMethods,
+				UserAgent:   r.UserAgent(),
+				IpAddress:   r.RemoteAddr,
+			},
+			SessionIdentifier: sessionIdentifier,
+		})
+		if err != nil {
+			s.internalServerError(w, r, err)
+			return
+		}
+
+		backchannelAuthRequest.State = enums.BackchannelAuthRequestStateApproved.String()
+		backchannelAuthRequest.CodeId = sql.NullInt64{Int64: code.Id, Valid: true}
+		err = s.database.UpdateBackchannelAuthRequest(nil, backchannelAuthRequest)
+		if err != nil {
+			s.internalServerError(w, r, err)
+			return
+		}
+
+		lib.LogAudit(constants.AuditApprovedBackchannelAuthRequest, map[string]interface{}{
+			"userId":                   user.Id,
+			"clientId":                 client.Id,
+			"backchannelAuthRequestId": backchannelAuthRequest.Id,
+			"codeId":                   code.Id,
+			"loggedInUser":             s.getLoggedInSubject(r),
+		})
+
+		sess.AddFlash("The authentication request from "+client.ClientIdentifier+" was approved.", "authenticationRequestResult")
+		err = sess.Save(r, w)
+		if err != nil {
+			s.internalServerError(w, r, err)
+			return
+		}
+
+		http.Redirect(w, r, lib.GetBaseUrl()+"/account/authentication-requests", http.StatusFound)
+	}
+}
@@ -3,6 +3,8 @@ package server
 import (
 	"encoding/base64"
 	"net/http"
+	"net/url"
+	"strings"
 
 	"github.com/gorilla/csrf"
 	"github.com/leodip/goiabada/internal/common"
@@ -12,7 +14,7 @@ import (
 	"github.com/pkg/errors"
 )
 
-func (s *Server) handleAccountLogoutGet() http.HandlerFunc {
+func (s *Server) handleAccountLogoutGet(subjectResolver subjectResolver) http.HandlerFunc {
 
 	return func(w http.ResponseWriter, r *http.Request) {
 
@@ -172,6 +174,7 @@ func (s *Server) handleAccountLogoutGet() http.HandlerFunc {
 			sessionIdentifier = r.Context().Value(common.ContextKeySessionIdentifier).(string)
 		}
 
+		var frontChannelLogoutURLs []string
 		if len(sessionIdentifier) > 0 {
 
 			sid := idToken.GetStringClaim("sid")
@@ -192,14 +195,21 @@ func (s *Server) handleAccountLogoutGet() http.HandlerFunc {
 			}
 
 			if userSession != nil {
-
-				err = s.database.UserSessionLoadClients(nil, userSession)
+				// the sub of the id token (public or pairwise) must belong to the user of the session
+				hintUser, err := subjectResolver.GetUserBySubject(idToken.GetStringClaim("sub"))
 				if err != nil {
 					s.internalServerError(w, r, err)
 					return
 				}
 
-				err = s.database.UserSessionClientsLoadClients(nil, userSession.Clients)
+				if hintUser == nil || hintUser.Id != userSession.UserId {
+					renderErrorUi("The id_token_hint parameter is invalid: the sub claim does not match the user of the current session.")
+					return
+				}
+
+				// every client of the session gets the front-channel logout request, as the browser
+				// session is cleared below, but only the calling client is removed from the user session
+				frontChannelLogoutURLs, err = s.getFrontChannelLogoutURLs(r, userSession)
 				if err != nil {
 					s.internalServerError(w, r, err)
 					return
@@ -253,6 +263,7 @@ func (s *Server) handleAccountLogoutGet() http.HandlerFunc {
 			s.internalServerError(w, r, err)
 			return
 		}
+		s.clearBrowserStateCookie(w)
 
 		state := getFromUrlQueryOrFormPost("state")
 		sid := sessionIdentifier
@@ -262,11 +273,11 @@ func (s *Server) handleAccountLogoutGet() http.HandlerFunc {
 			logoutUri += "&state=" + state
 		}
 
-		http.Redirect(w, r, logoutUri, http.StatusFound)
+		s.redirectAfterLogout(w, r, frontChannelLogoutURLs, logoutUri)
 	}
 }
 
-func (s *Server) handleAccountLogoutPost() http.HandlerFunc {
+func (s *Server) handleAccountLogoutPost(backChannelLogoutSender backChannelLogoutSender) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 
 		sess, err := s.sessionStore.Get(r, common.SessionName)
@@ -281,6 +292,7 @@ func (s *Server) handleAccountLogoutPost() http.HandlerFunc {
 		}
 
 		userId := int64(0)
+		var frontChannelLogoutURLs []string
 
 		if len(sessionIdentifier) > 0 {
 			userSession, err := s.database.GetUserSessionBySessionIdentifier(nil, sessionIdentifier)
@@ -291,6 +303,12 @@ func (s *Server) handleAccountLogoutPost() http.HandlerFunc {
 
 			if userSession != nil {
 				userId = userSession.UserId
+
+				frontChannelLogoutURLs, err = s.endUserSession(r, userSession, backChannelLogoutSender)
+				if err != nil {
+					s.internalServerError(w, r, err)
+					return
+				}
 			}
 		}
 
@@ -301,6 +319,7 @@ func (s *Server) handleAccountLogoutPost() http.HandlerFunc {
 			s.internalServerError(w, r, err)
 			return
 		}
+		s.clearBrowserStateCookie(w)
 
 		lib.LogAudit(constants.AuditLogout, map[string]interface{}{
 			"userId":            userId,
@@ -308,6 +327,79 @@ func (s *Server) handleAccountLogoutPost() http.HandlerFunc {
 			"loggedInUser":      s.getLoggedInSubject(r),
 		})
 
-		http.Redirect(w, r, lib.GetBaseUrl(), http.StatusFound)
+		s.redirectAfterLogout(w, r, frontChannelLogoutURLs, lib.GetBaseUrl())
+	}
+}
+
+// endUserSession sends the back-channel logout tokens and deletes the user session. It returns the
+// front-channel logout URLs of the clients that took part in the session.
+func (s *Server) endUserSession(r *http.Request, userSession *entities.UserSession,
+	backChannelLogoutSender backChannelLogoutSender) ([]string, error) {
+
+	frontChannelLogoutURLs, err := s.getFrontChannelLogoutURLs(r, userSession)
+	if err != nil {
+		return nil, err
+	}
+
+	err = backChannelLogoutSender.SendLogoutTokens(r.Context(), userSession)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.database.DeleteUserSession(nil, userSession.Id)
+	if err != nil {
+		return nil, err
+	}
+	return frontChannelLogoutURLs, nil
+}
+
+// getFrontChannelLogoutURLs loads the clients of the user session and returns their front-channel
+// logout URLs, with the iss and sid parameters.
+func (s *Server) getFrontChannelLogoutURLs(r *http.Request, userSession *entities.UserSession) ([]string, error) {
+
+	settings := r.Context().Value(common.ContextKeySettings).(*entities.Settings)
+
+	err := s.database.UserSessionLoadClients(nil, userSession)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.database.UserSessionClientsLoadClients(nil, userSession.Clients)
+	if err != nil {
+		return nil, err
+	}
+
+	frontChannelLogoutURLs := []string{}
+	for _, userSessionClient := range userSession.Clients {
+		if len(userSessionClient.Client.FrontChannelLogoutURI) == 0 {
+			continue
+		}
+
+		separator := "?"
+		if strings.Contains(userSessionClient.Client.FrontChannelLogoutURI, "?") {
+			separator = "&"
+		}
+		frontChannelLogoutURLs = append(frontChannelLogoutURLs, userSessionClient.Client.FrontChannelLogoutURI+separator+
+			"iss="+url.QueryEscape(settings.Issuer)+"&sid="+url.QueryEscape(userSession.SessionIdentifier))
+	}
+	return frontChannelLogoutURLs, nil
+}
+
+// redirectAfterLogout redirects to the destination. When there are front-channel logout URLs, it renders
+// a page that loads them in hidden iframes first, so browser-based clients can clear their own state.
+func (s *Server) redirectAfterLogout(w http.ResponseWriter, r *http.Request, frontChannelLogoutURLs []string, redirectURL string) {
+	if len(frontChannelLogoutURLs) == 0 {
+		http.Redirect(w, r, redirectURL, http.StatusFound)
+		return
+	}
+
+	bind := map[string]interface{}{
+		"frontChannelLogoutURLs": frontChannelLogoutURLs,
+		"redirectURL":            redirectURL,
+	}
+
+	err := s.renderTemplate(w, r, "/layouts/auth_layout.html", "/logout_frontchannel.html", bind)
+	if err != nil {
+		s.internalServerError(w, r, err)
 	}
 }
@@ -120,7 +120,7 @@ func (s *Server) handleAccountSessionsGet() http.HandlerFunc {
 	}
 }
 
-func (s *Server) handleAccountSessionsEndSesssionPost() http.HandlerFunc {
+func (s *Server) handleAccountSessionsEndSesssionPost(backChannelLogoutSender backChannelLogoutSender) http.HandlerFunc {
 
 	return func(w http.ResponseWriter, r *http.Request) {
 
@@ -161,7 +161,13 @@ func (s *Server) handleAccountSessionsEndSesssionPost() http.HandlerFunc {
 
 		for _, us := range allUserSessions {
 			if us.Id == int64(userSessionId) {
-				err := s.database.DeleteUserSession(nil, us.Id)
+				err := backChannelLogoutSender.SendLogoutTokens(r.Context(), &us)
+				if err != nil {
+					s.jsonError(w, r, err)
+					return
+				}
+
+				err = s.database.DeleteUserSession(nil, us.Id)
 				if err != nil {
 					s.jsonError(w, r, err)
 					return
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"net/http"
 	"strconv"
+	"strings"
 
 	"github.com/pkg/errors"
 
@@ -13,6 +14,7 @@ import (
 	"github.com/leodip/goiabada/internal/common"
 	"github.com/leodip/goiabada/internal/constants"
 	"github.com/leodip/goiabada/internal/entities"
+	"github.com/leodip/goiabada/internal/enums"
 	"github.com/leodip/goiabada/internal/lib"
 )
 
@@ -53,17 +55,23 @@ func (s *Server) handleAdminClientAuthenticationGet() http.HandlerFunc {
 		}
 
 		adminClientAuthentication := struct {
-			ClientId            int64
-			ClientIdentifier    string
-			IsPublic            bool
-			ClientSecret        string
-			IsSystemLevelClient bool
+			ClientId                int64
+			ClientIdentifier        string
+			IsPublic                bool
+			ClientSecret            string
+			TokenEndpointAuthMethod string
+			TLSClientAuthSubjectDN  string
+			TLSClientCertificate    string
+			IsSystemLevelClient     bool
 		}{
-			ClientId:            client.Id,
-			ClientIdentifier:    client.ClientIdentifier,
-			IsPublic:            client.IsPublic,
-			ClientSecret:        clientSecretDecrypted,
-			IsSystemLevelClient: client.IsSystemLevelClient(),
+			ClientId:                client.Id,
+			ClientIdentifier:        client.ClientIdentifier,
+			IsPublic:                client.IsPublic,
+			ClientSecret:            clientSecretDecrypted,
+			TokenEndpointAuthMethod: client.TokenEndpointAuthMethod,
+			TLSClientAuthSubjectDN:  client.TLSClientAuthSubjectDN,
+			TLSClientCertificate:    client.TLSClientCertificate,
+			IsSystemLevelClient:     client.IsSystemLevelClient(),
 		}
 
 		sess, err := s.sessionStore.Get(r, common.SessionName)
@@ -84,6 +92,8 @@ func (s *Server) handleAdminClientAuthenticationGet() http.HandlerFunc {
 		bind := map[string]interface{}{
 			"client":            adminClientAuthentication,
 			"savedSuccessfully": len(savedSuccessfully) > 0,
+			"mtlsEnabled":       lib.IsMTLSEnabled(),
+			"mtlsCAConfigured":  lib.IsClientCertificateCAConfigured(),
 			"csrfField":         csrf.TemplateField(r),
 		}
 
@@ -139,25 +149,42 @@ func (s *Server) handleAdminClientAuthenticationPost() http.HandlerFunc {
 			return
 		}
 
+		tokenEndpointAuthMethod := enums.TokenEndpointAuthMethodClientSecretPost
+		if !isPublic {
+			tokenEndpointAuthMethod, err = enums.TokenEndpointAuthMethodFromString(r.FormValue("tokenEndpointAuthMethod"))
+			if err != nil {
+				s.internalServerError(w, r, err)
+				return
+			}
+		}
+
 		adminClientAuthentication := struct {
-			ClientId            int64
-			ClientIdentifier    string
-			IsPublic            bool
-			ClientSecret        string
-			IsSystemLevelClient bool
+			ClientId                int64
+			ClientIdentifier        string
+			IsPublic                bool
+			ClientSecret            string
+			TokenEndpointAuthMethod string
+			TLSClientAuthSubjectDN  string
+			TLSClientCertificate    string
+			IsSystemLevelClient     bool
 		}{
-			ClientId:            client.Id,
-			ClientIdentifier:    client.ClientIdentifier,
-			IsPublic:            isPublic,
-			ClientSecret:        r.FormValue("clientSecret"),
-			IsSystemLevelClient: isSystemLevelClient,
+			ClientId:                client.Id,
+			ClientIdentifier:        client.ClientIdentifier,
+			IsPublic:                isPublic,
+			ClientSecret:            r.FormValue("clientSecret"),
+			TokenEndpointAuthMethod: tokenEndpointAuthMethod.String(),
+			TLSClientAuthSubjectDN:  strings.TrimSpace(r.FormValue("tlsClientAuthSubjectDN")),
+			TLSClientCertificate:    strings.TrimSpace(r.FormValue("tlsClientCertificate")),
+			IsSystemLevelClient:     isSystemLevelClient,
 		}
 
 		renderError := func(message string) {
 			bind := map[string]interface{}{
-				"client":    adminClientAuthentication,
-				"error":     message,
-				"csrfField": csrf.TemplateField(r),
+				"client":           adminClientAuthentication,
+				"error":            message,
+				"mtlsEnabled":      lib.IsMTLSEnabled(),
+				"mtlsCAConfigured": lib.IsClientCertificateCAConfigured(),
+				"csrfField":        csrf.TemplateField(r),
 			}
 
 			err := s.renderTemplate(w, r, "/layouts/menu_layout.html", "/admin_clients_authentication.html", bind)
@@ -171,8 +198,39 @@ func (s *Server) handleAdminClientAuthenticationPost() http.HandlerFunc {
 			return
 		}
 
+		if tokenEndpointAuthMethod == enums.TokenEndpointAuthMethodPrivateKeyJwt && len(client.JWKS) == 0 && len(client.JWKSURI) == 0 {
+			renderError("To use private_key_jwt, please register the keys of the client first (Keys tab).")
+			return
+		}
+
+		if tokenEndpointAuthMethod == enums.TokenEndpointAuthMethodTLSClientAuth && len(adminClientAuthentication.TLSClientAuthSubjectDN) == 0 {
+			renderError("To use tls_client_auth, please enter the subject of the client certificate.")
+			return
+		}
+
+		if len(adminClientAuthentication.TLSClientAuthSubjectDN) > 512 {
+			renderError("The certificate subject cannot exceed a maximum length of 512 characters.")
+			return
+		}
+
+		if tokenEndpointAuthMethod == enums.TokenEndpointAuthMethodSelfSignedTLSClientAuth && len(adminClientAuthentication.TLSClientCertificate) == 0 {
+			renderError("To use self_signed_tls_client_auth, please enter the client certificate.")
+			return
+		}
+
+		if len(adminClientAuthentication.TLSClientCertificate) > 0 {
+			_, err := lib.ParseCertificatePEM(adminClientAuthentication.TLSClientCertificate)
+			if err != nil {
+				renderError("The client certificate is invalid. Please enter a certificate in PEM format.")
+				return
+			}
+		}
+
 		settings := r.Context().Value(common.ContextKeySettings).(*entities.Settings)
 
+		client.TokenEndpointAuthMethod = tokenEndpointAuthMethod.String()
+		client.TLSClientAuthSubjectDN = adminClientAuthentication.TLSClientAuthSubjectDN
+		client.TLSClientCertificate = adminClientAuthentication.TLSClientCertificate
 		if adminClientAuthentication.IsPublic {
 			client.IsPublic = true
 			client.ClientSecretEncrypted = nil
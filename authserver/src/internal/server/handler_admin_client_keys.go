@@ -0,0 +1,202 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/gorilla/csrf"
+	"github.com/leodip/goiabada/internal/common"
+	"github.com/leodip/goiabada/internal/constants"
+	"github.com/leodip/goiabada/internal/lib"
+)
+
+func (s *Server) handleAdminClientKeysGet() http.HandlerFunc {
+
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		idStr := chi.URLParam(r, "clientId")
+		if len(idStr) == 0 {
+			s.internalServerError(w, r, errors.WithStack(errors.New("clientId is required")))
+			return
+		}
+
+		id, err := strconv.ParseInt(idStr, 10, 64)
+		if err != nil {
+			s.internalServerError(w, r, err)
+			return
+		}
+		client, err := s.database.GetClientById(nil, id)
+		if err != nil {
+			s.internalServerError(w, r, err)
+			return
+		}
+		if client == nil {
+			s.internalServerError(w, r, errors.WithStack(errors.New("client not found")))
+			return
+		}
+
+		adminClientKeys := struct {
+			ClientId            int64
+			ClientIdentifier    string
+			JWKS                string
+			JWKSURI             string
+			IsSystemLevelClient bool
+		}{
+			ClientId:            client.Id,
+			ClientIdentifier:    client.ClientIdentifier,
+			JWKS:                client.JWKS,
+			JWKSURI:             client.JWKSURI,
+			IsSystemLevelClient: client.IsSystemLevelClient(),
+		}
+
+		sess, err := s.sessionStore.Get(r, common.SessionName)
+		if err != nil {
+			s.internalServerError(w, r, err)
+			return
+		}
+
+		savedSuccessfully := sess.Flashes("savedSuccessfully")
+		if savedSuccessfully != nil {
+			err = sess.Save(r, w)
+			if err != nil {
+				s.internalServerError(w, r, err)
+				return
+			}
+		}
+
+		bind := map[string]interface{}{
+			"client":            adminClientKeys,
+			"savedSuccessfully": len(savedSuccessfully) > 0,
+			"csrfField":         csrf.TemplateField(r),
+		}
+
+		err = s.renderTemplate(w, r, "/layouts/menu_layout.html", "/admin_clients_keys.html", bind)
+		if err != nil {
+			s.internalServerError(w, r, err)
+			return
+		}
+	}
+}
+
+func (s *Server) handleAdminClientKeysPost() http.HandlerFunc {
+
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		idStr := chi.URLParam(r, "clientId")
+		if len(idStr) == 0 {
+			s.internalServerError(w, r, errors.WithStack(errors.New("clientId is required")))
+			return
+		}
+
+		id, err := strconv.ParseInt(idStr, 10, 64)
+		if err != nil {
+			s.internalServerError(w, r, err)
+			return
+		}
+
+		client, err := s.database.GetClientById(nil, id)
+		if err != nil {
+			s.internalServerError(w, r, err)
+			return
+		}
+		if client == nil {
+			s.internalServerError(w, r, errors.WithStack(errors.New("client not found")))
+			return
+		}
+
+		isSystemLevelClient := client.IsSystemLevelClient()
+		if isSystemLevelClient {
+			s.internalServerError(w, r, errors.WithStack(errors.New("trying to edit a system level client")))
+			return
+		}
+
+		adminClientKeys := struct {
+			ClientId            int64
+			ClientIdentifier    string
+			JWKS                string
+			JWKSURI             string
+			IsSystemLevelClient bool
+		}{
+			ClientId:            client.Id,
+			ClientIdentifier:    client.ClientIdentifier,
+			JWKS:                strings.TrimSpace(r.FormValue("jwks")),
+			JWKSURI:             strings.TrimSpace(r.FormValue("jwksURI")),
+			IsSystemLevelClient: isSystemLevelClient,
+		}
+
+		renderError := func(message string) {
+			bind := map[string]interface{}{
+				"client":    adminClientKeys,
+				"error":     message,
+				"csrfField": csrf.TemplateField(r),
+			}
+
+			err := s.renderTemplate(w, r, "/layouts/menu_layout.html", "/admin_clients_keys.html", bind)
+			if err != nil {
+				s.internalServerError(w, r, err)
+			}
+		}
+
+		if len(adminClientKeys.JWKS) > 0 && len(adminClientKeys.JWKSURI) > 0 {
+			renderError("Please provide either a JSON Web Key Set or a JWKS URI, but not both.")
+			return
+		}
+
+		if len(adminClientKeys.JWKS) > 0 {
+			_, err := lib.ParseJSONWebKeySet([]byte(adminClientKeys.JWKS))
+			if err != nil {
+				renderError("The JSON Web Key Set is invalid. It must be a JSON object with a non-empty 'keys' array of RSA or EC public keys.")
+				return
+			}
+		}
+
+		if len(adminClientKeys.JWKSURI) > 0 {
+			const maxLengthJWKSURI = 512
+			if len(adminClientKeys.JWKSURI) > maxLengthJWKSURI {
+				renderError(fmt.Sprintf("The JWKS URI cannot exceed a maximum length of %v characters.", maxLengthJWKSURI))
+				return
+			}
+
+			parsedURI, err := url.ParseRequestURI(adminClientKeys.JWKSURI)
+			if err != nil || (parsedURI.Scheme != "https" && parsedURI.Scheme != "http") || len(parsedURI.Host) == 0 {
+				renderError("Invalid JWKS URI. It must be an absolute http or https URL.")
+				return
+			}
+		}
+
+		client.JWKS = adminClientKeys.JWKS
+		client.JWKSURI = adminClientKeys.JWKSURI
+
+		err = s.database.UpdateClient(nil, client)
+		if err != nil {
+			s.internalServerError(w, r, err)
+			return
+		}
+
+		sess, err := s.sessionStore.Get(r, common.SessionName)
+		if err != nil {
+			s.internalServerError(w, r, err)
+			return
+		}
+
+		sess.AddFlash("true", "savedSuccessfully")
+		err = sess.Save(r, w)
+		if err != nil {
+			s.internalServerError(w, r, err)
+			return
+		}
+
+		lib.LogAudit(constants.AuditUpdatedClientKeys, map[string]interface{}{
+			"clientId":     client.Id,
+			"loggedInUser": s.getLoggedInSubject(r),
+		})
+
+		http.Redirect(w, r, fmt.Sprintf("%v/admin/clients/%v/keys", lib.GetBaseUrl(), client.Id), http.StatusFound)
+	}
+}
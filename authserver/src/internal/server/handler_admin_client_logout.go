@@ -0,0 +1,254 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/gorilla/csrf"
+	"github.com/leodip/goiabada/internal/common"
+	"github.com/leodip/goiabada/internal/constants"
+	"github.com/leodip/goiabada/internal/lib"
+)
+
+type backChannelLogoutDeliveryInfo struct {
+	CreatedAt         string
+	SessionIdentifier string
+	Subject           string
+	Status            string
+	Attempts          int
+	LastAttemptAt     string
+	LastError         string
+}
+
+func (s *Server) getBackChannelLogoutDeliveryInfos(clientId int64) ([]backChannelLogoutDeliveryInfo, error) {
+	const maxDeliveries = 20
+	deliveries, err := s.database.GetBackChannelLogoutDeliveriesByClientId(nil, clientId, maxDeliveries)
+	if err != nil {
+		return nil, err
+	}
+
+	deliveryInfos := make([]backChannelLogoutDeliveryInfo, 0, len(deliveries))
+	for _, delivery := range deliveries {
+		deliveryInfo := backChannelLogoutDeliveryInfo{
+			CreatedAt:         delivery.CreatedAt.Time.Format("02 Jan 2006 15:04:05 MST"),
+			SessionIdentifier: delivery.SessionIdentifier,
+			Subject:           delivery.Subject,
+			Status:            delivery.Status,
+			Attempts:          delivery.Attempts,
+			LastError:         delivery.LastError,
+		}
+		if delivery.LastAttemptAt.Valid {
+			deliveryInfo.LastAttemptAt = delivery.LastAttemptAt.Time.Format("02 Jan 2006 15:04:05 MST")
+		}
+		deliveryInfos = append(deliveryInfos, deliveryInfo)
+	}
+	return deliveryInfos, nil
+}
+
+func (s *Server) handleAdminClientLogoutGet() http.HandlerFunc {
+
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		idStr := chi.URLParam(r, "clientId")
+		if len(idStr) == 0 {
+			s.internalServerError(w, r, errors.WithStack(errors.New("clientId is required")))
+			return
+		}
+
+		id, err := strconv.ParseInt(idStr, 10, 64)
+		if err != nil {
+			s.internalServerError(w, r, err)
+			return
+		}
+		client, err := s.database.GetClientById(nil, id)
+		if err != nil {
+			s.internalServerError(w, r, err)
+			return
+		}
+		if client == nil {
+			s.internalServerError(w, r, errors.WithStack(errors.New("client not found")))
+			return
+		}
+
+		deliveries, err := s.getBackChannelLogoutDeliveryInfos(client.Id)
+		if err != nil {
+			s.internalServerError(w, r, err)
+			return
+		}
+
+		adminClientLogout := struct {
+			ClientId              int64
+			ClientIdentifier      string
+			BackChannelLogoutURI  string
+			FrontChannelLogoutURI string
+			IsSystemLevelClient   bool
+		}{
+			ClientId:              client.Id,
+			ClientIdentifier:      client.ClientIdentifier,
+			BackChannelLogoutURI:  client.BackChannelLogoutURI,
+			FrontChannelLogoutURI: client.FrontChannelLogoutURI,
+			IsSystemLevelClient:   client.IsSystemLevelClient(),
+		}
+
+		sess, err := s.sessionStore.Get(r, common.SessionName)
+		if err != nil {
+			s.internalServerError(w, r, err)
+			return
+		}
+
+		savedSuccessfully := sess.Flashes("savedSuccessfully")
+		if savedSuccessfully != nil {
+			err = sess.Save(r, w)
+			if err != nil {
+				s.internalServerError(w, r, err)
+				return
+			}
+		}
+
+		bind := map[string]interface{}{
+			"client":            adminClientLogout,
+			"deliveries":        deliveries,
+			"savedSuccessfully": len(savedSuccessfully) > 0,
+			"csrfField":         csrf.TemplateField(r),
+		}
+
+		err = s.renderTemplate(w, r, "/layouts/menu_layout.html", "/admin_clients_logout.html", bind)
+		if err != nil {
+			s.internalServerError(w, r, err)
+			return
+		}
+	}
+}
+
+func (s *Server) handleAdminClientLogoutPost() http.HandlerFunc {
+
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		idStr := chi.URLParam(r, "clientId")
+		if len(idStr) == 0 {
+			s.internalServerError(w, r, errors.WithStack(errors.New("clientId is required")))
+			return
+		}
+
+		id, err := strconv.ParseInt(idStr, 10, 64)
+		if err != nil {
+			s.internalServerError(w, r, err)
+			return
+		}
+
+		client, err := s.database.GetClientById(nil, id)
+		if err != nil {
+			s.internalServerError(w, r, err)
+			return
+		}
+		if client == nil {
+			s.internalServerError(w, r, errors.WithStack(errors.New("client not found")))
+			return
+		}
+
+		isSystemLevelClient := client.IsSystemLevelClient()
+		if isSystemLevelClient {
+			s.internalServerError(w, r, errors.WithStack(errors.New("trying to edit a system level client")))
+			return
+		}
+
+		adminClientLogout := struct {
+			ClientId              int64
+			ClientIdentifier      string
+			BackChannelLogoutURI  string
+			FrontChannelLogoutURI string
+			IsSystemLevelClient   bool
+		}{
+			ClientId:              client.Id,
+			ClientIdentifier:      client.ClientIdentifier,
+			BackChannelLogoutURI:  strings.TrimSpace(r.FormValue("backChannelLogoutURI")),
+			FrontChannelLogoutURI: strings.TrimSpace(r.FormValue("frontChannelLogoutURI")),
+			IsSystemLevelClient:   isSystemLevelClient,
+		}
+
+		renderError := func(message string) {
+			deliveries, err := s.getBackChannelLogoutDeliveryInfos(client.Id)
+			if err != nil {
+				s.internalServerError(w, r, err)
+				return
+			}
+
+			bind := map[string]interface{}{
+				"client":     adminClientLogout,
+				"deliveries": deliveries,
+				"error":      message,
+				"csrfField":  csrf.TemplateField(r),
+			}
+
+			err = s.renderTemplate(w, r, "/layouts/menu_layout.html", "/admin_clients_logout.html", bind)
+			if err != nil {
+				s.internalServerError(w, r, err)
+			}
+		}
+
+		if len(adminClientLogout.BackChannelLogoutURI) > 0 {
+			const maxLengthBackChannelLogoutURI = 512
+			if len(adminClientLogout.BackChannelLogoutURI) > maxLengthBackChannelLogoutURI {
+				renderError(fmt.Sprintf("The back-channel logout URI cannot exceed a maximum length of %v characters.", maxLengthBackChannelLogoutURI))
+				return
+			}
+
+			parsedURI, err := url.ParseRequestURI(adminClientLogout.BackChannelLogoutURI)
+			if err != nil || (parsedURI.Scheme != "https" && parsedURI.Scheme != "http") || len(parsedURI.Host) == 0 ||
+				strings.Contains(adminClientLogout.BackChannelLogoutURI, "#") {
+				renderError("Invalid back-channel logout URI. It must be an absolute http or https URL, without a fragment.")
+				return
+			}
+		}
+
+		if len(adminClientLogout.FrontChannelLogoutURI) > 0 {
+			const maxLengthFrontChannelLogoutURI = 512
+			if len(adminClientLogout.FrontChannelLogoutURI) > maxLengthFrontChannelLogoutURI {
+				renderError(fmt.Sprintf("The front-channel logout URI cannot exceed a maximum length of %v characters.", maxLengthFrontChannelLogoutURI))
+				return
+			}
+
+			parsedURI, err := url.ParseRequestURI(adminClientLogout.FrontChannelLogoutURI)
+			if err != nil || (parsedURI.Scheme != "https" && parsedURI.Scheme != "http") || len(parsedURI.Host) == 0 ||
+				strings.Contains(adminClientLogout.FrontChannelLogoutURI, "#") {
+				renderError("Invalid front-channel logout URI. It must be an absolute http or https URL, without a fragment.")
+				return
+			}
+		}
+
+		client.BackChannelLogoutURI = adminClientLogout.BackChannelLogoutURI
+		client.FrontChannelLogoutURI = adminClientLogout.FrontChannelLogoutURI
+
+		err = s.database.UpdateClient(nil, client)
+		if err != nil {
+			s.internalServerError(w, r, err)
+			return
+		}
+
+		sess, err := s.sessionStore.Get(r, common.SessionName)
+		if err != nil {
+			s.internalServerError(w, r, err)
+			return
+		}
+
+		sess.AddFlash("true", "savedSuccessfully")
+		err = sess.Save(r, w)
+		if err != nil {
+			s.internalServerError(w, r, err)
+			return
+		}
+
+		lib.LogAudit(constants.AuditUpdatedClientLogout, map[string]interface{}{
+			"clientId":     client.Id,
+			"loggedInUser": s.getLoggedInSubject(r),
+		})
+
+		http.Redirect(w, r, fmt.Sprintf("%v/admin/clients/%v/logout", lib.GetBaseUrl(), client.Id), http.StatusFound)
+	}
+}
@@ -102,6 +102,8 @@ func (s *Server) handleAdminClientNewPost(identifierValidator identifierValidato
 			Description:              strings.TrimSpace(inputSanitizer.Sanitize(description)),
 			ClientSecretEncrypted:    clientSecretEncrypted,
 			IsPublic:                 false,
+			TokenEndpointAuthMethod:  enums.TokenEndpointAuthMethodClientSecretPost.String(),
+			SubjectType:              enums.SubjectTypePublic.String(),
 			ConsentRequired:          false,
 			Enabled:                  true,
 			DefaultAcrLevel:          enums.AcrLevel2,
@@ -44,14 +44,22 @@ func (s *Server) handleAdminClientOAuth2Get() http.HandlerFunc {
 			ClientIdentifier         string
 			IsPublic                 bool
 			AuthorizationCodeEnabled bool
+			PARRequired              bool
 			ClientCredentialsEnabled bool
+			DeviceCodeEnabled        bool
+			TokenExchangeEnabled     bool
+			CIBAEnabled              bool
 			IsSystemLevelClient      bool
 		}{
 			ClientId:                 client.Id,
 			ClientIdentifier:         client.ClientIdentifier,
 			IsPublic:                 client.IsPublic,
 			AuthorizationCodeEnabled: client.AuthorizationCodeEnabled,
+			PARRequired:              client.PARRequired,
 			ClientCredentialsEnabled: client.ClientCredentialsEnabled,
+			DeviceCodeEnabled:        client.DeviceCodeEnabled,
+			TokenExchangeEnabled:     client.TokenExchangeEnabled,
+			CIBAEnabled:              client.CIBAEnabled,
 			IsSystemLevelClient:      client.IsSystemLevelClient(),
 		}
 
@@ -120,15 +128,37 @@ func (s *Server) handleAdminClientOAuth2Post() http.HandlerFunc {
 		if r.FormValue("authCodeEnabled") == "on" {
 			authCodeEnabled = true
 		}
+		parRequired := false
+		if r.FormValue("parRequired") == "on" {
+			parRequired = true
+		}
 		clientCredentialsEnabled := false
 		if r.FormValue("clientCredentialsEnabled") == "on" {
 			clientCredentialsEnabled = true
 		}
+		deviceCodeEnabled := false
+		if r.FormValue("deviceCodeEnabled") == "on" {
+			deviceCodeEnabled = true
+		}
+		tokenExchangeEnabled := false
+		if r.FormValue("tokenExchangeEnabled") == "on" {
+			tokenExchangeEnabled = true
+		}
+		cibaEnabled := false
+		if r.FormValue("cibaEnabled") == "on" {
+			cibaEnabled = true
+		}
 
 		client.AuthorizationCodeEnabled = authCodeEnabled
+		client.PARRequired = parRequired
 		client.ClientCredentialsEnabled = clientCredentialsEnabled
+		client.DeviceCodeEnabled = deviceCodeEnabled
+		client.TokenExchangeEnabled = tokenExchangeEnabled
+		client.CIBAEnabled = cibaEnabled
 		if client.IsPublic {
 			client.ClientCredentialsEnabled = false
+			client.TokenExchangeEnabled = false
+			client.CIBAEnabled = false
 		}
 
 		err = s.database.UpdateClient(nil, client)
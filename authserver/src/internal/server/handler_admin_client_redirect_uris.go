@@ -17,6 +17,7 @@ import (
 	"github.com/leodip/goiabada/internal/common"
 	"github.com/leodip/goiabada/internal/constants"
 	"github.com/leodip/goiabada/internal/entities"
+	"github.com/leodip/goiabada/internal/enums"
 	"github.com/leodip/goiabada/internal/lib"
 )
 
@@ -102,7 +103,7 @@ func (s *Server) handleAdminClientRedirectURIsGet() http.HandlerFunc {
 	}
 }
 
-func (s *Server) handleAdminClientRedirectURIsPost() http.HandlerFunc {
+func (s *Server) handleAdminClientRedirectURIsPost(subjectResolver subjectResolver) http.HandlerFunc {
 
 	type redirectURIsPostInput struct {
 		ClientId     int64    `json:"clientId"`
@@ -146,6 +147,15 @@ func (s *Server) handleAdminClientRedirectURIsPost() http.HandlerFunc {
 			return
 		}
 
+		if client.SubjectType == enums.SubjectTypePairwise.String() && len(client.SectorIdentifierURI) == 0 {
+			// without a sector identifier URI, the pairwise subjects are derived from the redirect URIs host
+			err = subjectResolver.ValidatePairwiseSettings(r.Context(), "", data.RedirectURIs)
+			if err != nil {
+				s.jsonError(w, r, err)
+				return
+			}
+		}
+
 		for idx, redirURI := range data.RedirectURIs {
 			_, err := url.ParseRequestURI(redirURI)
 			if err != nil {
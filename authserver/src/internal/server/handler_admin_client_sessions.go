@@ -165,7 +165,7 @@ func (s *Server) handleAdminClientUserSessionsGet() http.HandlerFunc {
 	}
 }
 
-func (s *Server) handleAdminClientUserSessionsPost() http.HandlerFunc {
+func (s *Server) handleAdminClientUserSessionsPost(backChannelLogoutSender backChannelLogoutSender) http.HandlerFunc {
 
 	return func(w http.ResponseWriter, r *http.Request) {
 
@@ -203,6 +203,19 @@ func (s *Server) handleAdminClientUserSessionsPost() http.HandlerFunc {
 			return
 		}
 
+		userSession, err := s.database.GetUserSessionById(nil, int64(userSessionId))
+		if err != nil {
+			s.jsonError(w, r, err)
+			return
+		}
+		if userSession != nil {
+			err = backChannelLogoutSender.SendLogoutTokens(r.Context(), userSession)
+			if err != nil {
+				s.jsonError(w, r, err)
+				return
+			}
+		}
+
 		err = s.database.DeleteUserSession(nil, int64(userSessionId))
 		if err != nil {
 			s.jsonError(w, r, err)
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"net/http"
 	"strconv"
+	"strings"
 
 	"github.com/pkg/errors"
 
@@ -11,6 +12,8 @@ import (
 	"github.com/gorilla/csrf"
 	"github.com/leodip/goiabada/internal/common"
 	"github.com/leodip/goiabada/internal/constants"
+	"github.com/leodip/goiabada/internal/core"
+	"github.com/leodip/goiabada/internal/customerrors"
 	"github.com/leodip/goiabada/internal/enums"
 	"github.com/leodip/goiabada/internal/lib"
 )
@@ -45,11 +48,33 @@ func (s *Server) handleAdminClientTokensGet() http.HandlerFunc {
 			RefreshTokenOfflineIdleTimeoutInSeconds int
 			RefreshTokenOfflineMaxLifetimeInSeconds int
 			IncludeOpenIDConnectClaimsInAccessToken string
+			SubjectType                             string
+			SectorIdentifierURI                     string
+			IdTokenSignedResponseAlg                string
+			IdTokenEncryptedResponseAlg             string
+			IdTokenEncryptedResponseEnc             string
+			UserInfoSignedResponseAlg               string
+			UserInfoEncryptedResponseAlg            string
+			UserInfoEncryptedResponseEnc            string
 		}{
 			TokenExpirationInSeconds:                client.TokenExpirationInSeconds,
 			RefreshTokenOfflineIdleTimeoutInSeconds: client.RefreshTokenOfflineIdleTimeoutInSeconds,
 			RefreshTokenOfflineMaxLifetimeInSeconds: client.RefreshTokenOfflineMaxLifetimeInSeconds,
 			IncludeOpenIDConnectClaimsInAccessToken: client.IncludeOpenIDConnectClaimsInAccessToken,
+			SubjectType:                             client.SubjectType,
+			SectorIdentifierURI:                     client.SectorIdentifierURI,
+			IdTokenSignedResponseAlg:                client.IdTokenSignedResponseAlg,
+			IdTokenEncryptedResponseAlg:             client.IdTokenEncryptedResponseAlg,
+			IdTokenEncryptedResponseEnc:             client.IdTokenEncryptedResponseEnc,
+			UserInfoSignedResponseAlg:               client.UserInfoSignedResponseAlg,
+			UserInfoEncryptedResponseAlg:            client.UserInfoEncryptedResponseAlg,
+			UserInfoEncryptedResponseEnc:            client.UserInfoEncryptedResponseEnc,
+		}
+
+		signingAlgorithms, err := core.GetActiveSigningAlgorithms(s.database)
+		if err != nil {
+			s.internalServerError(w, r, err)
+			return
 		}
 
 		sess, err := s.sessionStore.Get(r, common.SessionName)
@@ -68,10 +93,13 @@ func (s *Server) handleAdminClientTokensGet() http.HandlerFunc {
 		}
 
 		bind := map[string]interface{}{
-			"settings":          settingsInfo,
-			"client":            client,
-			"savedSuccessfully": len(savedSuccessfully) > 0,
-			"csrfField":         csrf.TemplateField(r),
+			"settings":             settingsInfo,
+			"client":               client,
+			"signingAlgorithms":    signingAlgorithms,
+			"encryptionAlgorithms": lib.JWEAlgorithms,
+			"encryptionMethods":    lib.JWEEncryptionMethods,
+			"savedSuccessfully":    len(savedSuccessfully) > 0,
+			"csrfField":            csrf.TemplateField(r),
 		}
 
 		err = s.renderTemplate(w, r, "/layouts/menu_layout.html", "/admin_clients_tokens.html", bind)
@@ -82,7 +110,7 @@ func (s *Server) handleAdminClientTokensGet() http.HandlerFunc {
 	}
 }
 
-func (s *Server) handleAdminClientTokensPost() http.HandlerFunc {
+func (s *Server) handleAdminClientTokensPost(subjectResolver subjectResolver) http.HandlerFunc {
 
 	return func(w http.ResponseWriter, r *http.Request) {
 
@@ -118,20 +146,45 @@ func (s *Server) handleAdminClientTokensPost() http.HandlerFunc {
 			RefreshTokenOfflineIdleTimeoutInSeconds string
 			RefreshTokenOfflineMaxLifetimeInSeconds string
 			IncludeOpenIDConnectClaimsInAccessToken string
+			SubjectType                             string
+			SectorIdentifierURI                     string
+			IdTokenSignedResponseAlg                string
+			IdTokenEncryptedResponseAlg             string
+			IdTokenEncryptedResponseEnc             string
+			UserInfoSignedResponseAlg               string
+			UserInfoEncryptedResponseAlg            string
+			UserInfoEncryptedResponseEnc            string
 		}{
 			TokenExpirationInSeconds:                r.FormValue("tokenExpirationInSeconds"),
 			RefreshTokenOfflineIdleTimeoutInSeconds: r.FormValue("refreshTokenOfflineIdleTimeoutInSeconds"),
 			RefreshTokenOfflineMaxLifetimeInSeconds: r.FormValue("refreshTokenOfflineMaxLifetimeInSeconds"),
 			IncludeOpenIDConnectClaimsInAccessToken: r.FormValue("includeOpenIDConnectClaimsInAccessToken"),
+			SubjectType:                             r.FormValue("subjectType"),
+			SectorIdentifierURI:                     strings.TrimSpace(r.FormValue("sectorIdentifierURI")),
+			IdTokenSignedResponseAlg:                r.FormValue("idTokenSignedResponseAlg"),
+			IdTokenEncryptedResponseAlg:             r.FormValue("idTokenEncryptedResponseAlg"),
+			IdTokenEncryptedResponseEnc:             r.FormValue("idTokenEncryptedResponseEnc"),
+			UserInfoSignedResponseAlg:               r.FormValue("userInfoSignedResponseAlg"),
+			UserInfoEncryptedResponseAlg:            r.FormValue("userInfoEncryptedResponseAlg"),
+			UserInfoEncryptedResponseEnc:            r.FormValue("userInfoEncryptedResponseEnc"),
+		}
+
+		signingAlgorithms, err := core.GetActiveSigningAlgorithms(s.database)
+		if err != nil {
+			s.internalServerError(w, r, err)
+			return
 		}
 
 		renderError := func(message string) {
 
 			bind := map[string]interface{}{
-				"settings":  settingsInfo,
-				"client":    client,
-				"csrfField": csrf.TemplateField(r),
-				"error":     message,
+				"settings":             settingsInfo,
+				"client":               client,
+				"signingAlgorithms":    signingAlgorithms,
+				"encryptionAlgorithms": lib.JWEAlgorithms,
+				"encryptionMethods":    lib.JWEEncryptionMethods,
+				"csrfField":            csrf.TemplateField(r),
+				"error":                message,
 			}
 
 			err := s.renderTemplate(w, r, "/layouts/menu_layout.html", "/admin_clients_tokens.html", bind)
@@ -188,10 +241,76 @@ func (s *Server) handleAdminClientTokensPost() http.HandlerFunc {
 			return
 		}
 
+		subjectType, err := enums.SubjectTypeFromString(settingsInfo.SubjectType)
+		if err != nil {
+			s.internalServerError(w, r, err)
+			return
+		}
+
+		if subjectType == enums.SubjectTypePairwise || len(settingsInfo.SectorIdentifierURI) > 0 {
+			err = s.database.ClientLoadRedirectURIs(nil, client)
+			if err != nil {
+				s.internalServerError(w, r, err)
+				return
+			}
+
+			redirectURIs := []string{}
+			for _, redirectURI := range client.RedirectURIs {
+				redirectURIs = append(redirectURIs, redirectURI.URI)
+			}
+
+			err = subjectResolver.ValidatePairwiseSettings(r.Context(), settingsInfo.SectorIdentifierURI, redirectURIs)
+			if err != nil {
+				if valError, ok := err.(*customerrors.ValidationError); ok {
+					renderError(valError.Description)
+				} else {
+					s.internalServerError(w, r, err)
+				}
+				return
+			}
+		}
+
+		err = core.ValidateIdTokenSigningAlgorithm(s.database, settingsInfo.IdTokenSignedResponseAlg)
+		if err == nil {
+			err = core.ValidateUserInfoSigningAlgorithm(s.database, settingsInfo.UserInfoSignedResponseAlg)
+		}
+		if err != nil {
+			if valError, ok := err.(*customerrors.ValidationError); ok {
+				renderError(valError.Description)
+			} else {
+				s.internalServerError(w, r, err)
+			}
+			return
+		}
+
+		err = core.ValidateEncryptedResponse("id_token", settingsInfo.IdTokenEncryptedResponseAlg, settingsInfo.IdTokenEncryptedResponseEnc,
+			client.JWKS, client.JWKSURI)
+		if err == nil {
+			err = core.ValidateEncryptedResponse("userinfo", settingsInfo.UserInfoEncryptedResponseAlg, settingsInfo.UserInfoEncryptedResponseEnc,
+				client.JWKS, client.JWKSURI)
+		}
+		if err != nil {
+			if valError, ok := err.(*customerrors.ValidationError); ok {
+				renderError(valError.Description)
+			} else {
+				s.internalServerError(w, r, err)
+			}
+			return
+		}
+
 		client.TokenExpirationInSeconds = tokenExpirationInSeconds
 		client.RefreshTokenOfflineIdleTimeoutInSeconds = refreshTokenOfflineIdleTimeoutInSeconds
 		client.RefreshTokenOfflineMaxLifetimeInSeconds = refreshTokenOfflineMaxLifetimeInSeconds
 		client.IncludeOpenIDConnectClaimsInAccessToken = threeStateSetting.String()
+		client.LegacyAccessTokenFormat = r.FormValue("legacyAccessTokenFormat") == "on"
+		client.SubjectType = subjectType.String()
+		client.SectorIdentifierURI = settingsInfo.SectorIdentifierURI
+		client.IdTokenSignedResponseAlg = settingsInfo.IdTokenSignedResponseAlg
+		client.IdTokenEncryptedResponseAlg = settingsInfo.IdTokenEncryptedResponseAlg
+		client.IdTokenEncryptedResponseEnc = settingsInfo.IdTokenEncryptedResponseEnc
+		client.UserInfoSignedResponseAlg = settingsInfo.UserInfoSignedResponseAlg
+		client.UserInfoEncryptedResponseAlg = settingsInfo.UserInfoEncryptedResponseAlg
+		client.UserInfoEncryptedResponseEnc = settingsInfo.UserInfoEncryptedResponseEnc
 
 		err = s.database.UpdateClient(nil, client)
 		if err != nil {
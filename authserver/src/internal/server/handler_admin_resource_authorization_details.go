@@ -0,0 +1,132 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/pkg/errors"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/gorilla/csrf"
+	"github.com/leodip/goiabada/internal/constants"
+	"github.com/leodip/goiabada/internal/lib"
+)
+
+func (s *Server) handleAdminResourceAuthorizationDetailsGet() http.HandlerFunc {
+
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		idStr := chi.URLParam(r, "resourceId")
+		if len(idStr) == 0 {
+			s.internalServerError(w, r, errors.WithStack(errors.New("resourceId is required")))
+			return
+		}
+
+		id, err := strconv.ParseInt(idStr, 10, 64)
+		if err != nil {
+			s.internalServerError(w, r, err)
+			return
+		}
+		resource, err := s.database.GetResourceById(nil, id)
+		if err != nil {
+			s.internalServerError(w, r, err)
+			return
+		}
+		if resource == nil {
+			s.internalServerError(w, r, errors.WithStack(errors.New("resource not found")))
+			return
+		}
+
+		authorizationDetailTypes, err := s.database.GetAuthorizationDetailTypesByResourceId(nil, resource.Id)
+		if err != nil {
+			s.internalServerError(w, r, err)
+			return
+		}
+
+		bind := map[string]interface{}{
+			"resourceId":               resource.Id,
+			"resourceIdentifier":       resource.ResourceIdentifier,
+			"isSystemLevelResource":    resource.IsSystemLevelResource(),
+			"authorizationDetailTypes": authorizationDetailTypes,
+			"csrfField":                csrf.TemplateField(r),
+		}
+
+		err = s.renderTemplate(w, r, "/layouts/menu_layout.html", "/admin_resources_authorization_details.html", bind)
+		if err != nil {
+			s.internalServerError(w, r, err)
+			return
+		}
+	}
+}
+
+func (s *Server) handleAdminResourceAuthorizationDetailsRemovePost() http.HandlerFunc {
+
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		idStr := chi.URLParam(r, "resourceId")
+		if len(idStr) == 0 {
+			s.jsonError(w, r, errors.WithStack(errors.New("resourceId is required")))
+			return
+		}
+
+		id, err := strconv.ParseInt(idStr, 10, 64)
+		if err != nil {
+			s.jsonError(w, r, err)
+			return
+		}
+		resource, err := s.database.GetResourceById(nil, id)
+		if err != nil {
+			s.jsonError(w, r, err)
+			return
+		}
+		if resource == nil {
+			s.jsonError(w, r, errors.WithStack(errors.New("resource not found")))
+			return
+		}
+
+		authorizationDetailTypeIdStr := chi.URLParam(r, "authorizationDetailTypeId")
+		if len(authorizationDetailTypeIdStr) == 0 {
+			s.jsonError(w, r, errors.WithStack(errors.New("authorizationDetailTypeId is required")))
+			return
+		}
+
+		authorizationDetailTypeId, err := strconv.ParseInt(authorizationDetailTypeIdStr, 10, 64)
+		if err != nil {
+			s.jsonError(w, r, err)
+			return
+		}
+
+		authorizationDetailType, err := s.database.GetAuthorizationDetailTypeById(nil, authorizationDetailTypeId)
+		if err != nil {
+			s.jsonError(w, r, err)
+			return
+		}
+		if authorizationDetailType == nil || authorizationDetailType.ResourceId != resource.Id {
+			s.jsonError(w, r, errors.WithStack(errors.New("authorization detail type not found")))
+			return
+		}
+
+		err = s.database.DeleteAuthorizationDetailType(nil, authorizationDetailType.Id)
+		if err != nil {
+			s.jsonError(w, r, err)
+			return
+		}
+
+		lib.LogAudit(constants.AuditDeletedAuthorizationDetailType, map[string]interface{}{
+			"authorizationDetailTypeId": authorizationDetailType.Id,
+			"type":                      authorizationDetailType.Type,
+			"resourceId":                resource.Id,
+			"resourceIdentifier":        resource.ResourceIdentifier,
+			"loggedInUser":              s.getLoggedInSubject(r),
+		})
+
+		result := struct {
+			Success bool
+		}{
+			Success: true,
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(result)
+	}
+}
@@ -0,0 +1,196 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/gorilla/csrf"
+	"github.com/leodip/goiabada/internal/constants"
+	"github.com/leodip/goiabada/internal/lib"
+)
+
+func (s *Server) handleAdminResourceAuthorizationDetailsEditGet() http.HandlerFunc {
+
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		idStr := chi.URLParam(r, "resourceId")
+		if len(idStr) == 0 {
+			s.internalServerError(w, r, errors.WithStack(errors.New("resourceId is required")))
+			return
+		}
+
+		id, err := strconv.ParseInt(idStr, 10, 64)
+		if err != nil {
+			s.internalServerError(w, r, err)
+			return
+		}
+		resource, err := s.database.GetResourceById(nil, id)
+		if err != nil {
+			s.internalServerError(w, r, err)
+			return
+		}
+		if resource == nil {
+			s.internalServerError(w, r, errors.WithStack(errors.New("resource not found")))
+			return
+		}
+
+		idStr = chi.URLParam(r, "authorizationDetailTypeId")
+		if len(idStr) == 0 {
+			s.internalServerError(w, r, errors.WithStack(errors.New("authorizationDetailTypeId is required")))
+			return
+		}
+
+		id, err = strconv.ParseInt(idStr, 10, 64)
+		if err != nil {
+			s.internalServerError(w, r, err)
+			return
+		}
+
+		authorizationDetailType, err := s.database.GetAuthorizationDetailTypeById(nil, id)
+		if err != nil {
+			s.internalServerError(w, r, err)
+			return
+		}
+		if authorizationDetailType == nil || authorizationDetailType.ResourceId != resource.Id {
+			s.internalServerError(w, r, errors.WithStack(errors.New("authorization detail type not found")))
+			return
+		}
+
+		bind := map[string]interface{}{
+			"resource":                resource,
+			"authorizationDetailType": authorizationDetailType,
+			"csrfField":               csrf.TemplateField(r),
+		}
+
+		err = s.renderTemplate(w, r, "/layouts/menu_layout.html", "/admin_resources_authorization_details_edit.html", bind)
+		if err != nil {
+			s.internalServerError(w, r, err)
+			return
+		}
+	}
+}
+
+func (s *Server) handleAdminResourceAuthorizationDetailsEditPost(identifierValidator identifierValidator,
+	inputSanitizer inputSanitizer) http.HandlerFunc {
+
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		idStr := chi.URLParam(r, "resourceId")
+		if len(idStr) == 0 {
+			s.internalServerError(w, r, errors.WithStack(errors.New("resourceId is required")))
+			return
+		}
+
+		id, err := strconv.ParseInt(idStr, 10, 64)
+		if err != nil {
+			s.internalServerError(w, r, err)
+			return
+		}
+		resource, err := s.database.GetResourceById(nil, id)
+		if err != nil {
+			s.internalServerError(w, r, err)
+			return
+		}
+		if resource == nil {
+			s.internalServerError(w, r, errors.WithStack(errors.New("resource not found")))
+			return
+		}
+
+		idStr = chi.URLParam(r, "authorizationDetailTypeId")
+		if len(idStr) == 0 {
+			s.internalServerError(w, r, errors.WithStack(errors.New("authorizationDetailTypeId is required")))
+			return
+		}
+
+		id, err = strconv.ParseInt(idStr, 10, 64)
+		if err != nil {
+			s.internalServerError(w, r, err)
+			return
+		}
+
+		authorizationDetailType, err := s.database.GetAuthorizationDetailTypeById(nil, id)
+		if err != nil {
+			s.internalServerError(w, r, err)
+			return
+		}
+		if authorizationDetailType == nil || authorizationDetailType.ResourceId != resource.Id {
+			s.internalServerError(w, r, errors.WithStack(errors.New("authorization detail type not found")))
+			return
+		}
+
+		authorizationDetailType.Type = strings.TrimSpace(r.FormValue("type"))
+		authorizationDetailType.Description = strings.TrimSpace(r.FormValue("description"))
+		authorizationDetailType.JSONSchema = strings.TrimSpace(r.FormValue("jsonSchema"))
+
+		renderError := func(message string) {
+			bind := map[string]interface{}{
+				"resource":                resource,
+				"authorizationDetailType": authorizationDetailType,
+				"error":                   message,
+				"csrfField":               csrf.TemplateField(r),
+			}
+
+			err := s.renderTemplate(w, r, "/layouts/menu_layout.html", "/admin_resources_authorization_details_edit.html", bind)
+			if err != nil {
+				s.internalServerError(w, r, err)
+			}
+		}
+
+		if len(authorizationDetailType.Type) == 0 {
+			renderError("Type is required.")
+			return
+		}
+
+		err = identifierValidator.ValidateIdentifier(authorizationDetailType.Type, true)
+		if err != nil {
+			renderError(err.Error())
+			return
+		}
+
+		existingType, err := s.database.GetAuthorizationDetailTypeByType(nil, authorizationDetailType.Type)
+		if err != nil {
+			s.internalServerError(w, r, err)
+			return
+		}
+		if existingType != nil && existingType.Id != authorizationDetailType.Id {
+			renderError("The type is already in use.")
+			return
+		}
+
+		const maxLengthDescription = 100
+		if len(authorizationDetailType.Description) > maxLengthDescription {
+			renderError("The description cannot exceed a maximum length of " + strconv.Itoa(maxLengthDescription) + " characters.")
+			return
+		}
+
+		if len(authorizationDetailType.JSONSchema) > 0 {
+			_, err = lib.ParseJSONSchema(authorizationDetailType.JSONSchema)
+			if err != nil {
+				renderError("The JSON schema is invalid: " + err.Error())
+				return
+			}
+		}
+
+		authorizationDetailType.Description = inputSanitizer.Sanitize(authorizationDetailType.Description)
+		err = s.database.UpdateAuthorizationDetailType(nil, authorizationDetailType)
+		if err != nil {
+			s.internalServerError(w, r, err)
+			return
+		}
+
+		lib.LogAudit(constants.AuditUpdatedAuthorizationDetailType, map[string]interface{}{
+			"authorizationDetailTypeId": authorizationDetailType.Id,
+			"type":                      authorizationDetailType.Type,
+			"resourceId":                resource.Id,
+			"resourceIdentifier":        resource.ResourceIdentifier,
+			"loggedInUser":              s.getLoggedInSubject(r),
+		})
+
+		http.Redirect(w, r, fmt.Sprintf("/admin/resources/%v/authorization-details", resource.Id), http.StatusFound)
+	}
+}
@@ -0,0 +1,230 @@
+package server
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/csrf"
+	"github.com/leodip/goiabada/internal/common"
+	"github.com/leodip/goiabada/internal/constants"
+	"github.com/leodip/goiabada/internal/entities"
+	"github.com/leodip/goiabada/internal/lib"
+	"github.com/pkg/errors"
+)
+
+type initialAccessTokenInfo struct {
+	Id          int64
+	Description string
+	CreatedAt   string
+	ExpiresAt   string
+	Expired     bool
+}
+
+func (s *Server) getInitialAccessTokenInfos() ([]initialAccessTokenInfo, error) {
+	initialAccessTokens, err := s.database.GetAllInitialAccessTokens(nil)
+	if err != nil {
+		return nil, err
+	}
+
+	tokens := make([]initialAccessTokenInfo, 0, len(initialAccessTokens))
+	for _, initialAccessToken := range initialAccessTokens {
+		tokenInfo := initialAccessTokenInfo{
+			Id:          initialAccessToken.Id,
+			Description: initialAccessToken.Description,
+			CreatedAt:   initialAccessToken.CreatedAt.Time.Format("02 Jan 2006 15:04:05 MST"),
+			ExpiresAt:   "Never",
+		}
+		if initialAccessToken.ExpiresAt.Valid {
+			tokenInfo.ExpiresAt = initialAccessToken.ExpiresAt.Time.Format("02 Jan 2006 15:04:05 MST")
+			tokenInfo.Expired = time.Now().UTC().After(initialAccessToken.ExpiresAt.Time)
+		}
+		tokens = append(tokens, tokenInfo)
+	}
+	return tokens, nil
+}
+
+func (s *Server) handleAdminSettingsClientRegistrationGet() http.HandlerFunc {
+
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		tokens, err := s.getInitialAccessTokenInfos()
+		if err != nil {
+			s.internalServerError(w, r, err)
+			return
+		}
+
+		sess, err := s.sessionStore.Get(r, common.SessionName)
+		if err != nil {
+			s.internalServerError(w, r, err)
+			return
+		}
+
+		createdToken := sess.Flashes("createdInitialAccessToken")
+		if createdToken != nil {
+			err = sess.Save(r, w)
+			if err != nil {
+				s.internalServerError(w, r, err)
+				return
+			}
+		}
+
+		bind := map[string]interface{}{
+			"tokens":             tokens,
+			"registrationUrl":    lib.GetBaseUrl() + "/connect/register",
+			"expiresInDays":      "30",
+			"csrfField":          csrf.TemplateField(r),
+			"initialAccessToken": "",
+		}
+		if len(createdToken) > 0 {
+			bind["initialAccessToken"] = createdToken[0]
+		}
+
+		err = s.renderTemplate(w, r, "/layouts/menu_layout.html", "/admin_settings_client_registration.html", bind)
+		if err != nil {
+			s.internalServerError(w, r, err)
+			return
+		}
+	}
+}
+
+func (s *Server) handleAdminSettingsClientRegistrationPost(inputSanitizer inputSanitizer) http.HandlerFunc {
+
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		description := strings.TrimSpace(r.FormValue("description"))
+		expiresInDays := strings.TrimSpace(r.FormValue("expiresInDays"))
+
+		renderError := func(message string) {
+
+			tokens, err := s.getInitialAccessTokenInfos()
+			if err != nil {
+				s.internalServerError(w, r, err)
+				return
+			}
+
+			bind := map[string]interface{}{
+				"tokens":          tokens,
+				"registrationUrl": lib.GetBaseUrl() + "/connect/register",
+				"description":     description,
+				"expiresInDays":   expiresInDays,
+				"csrfField":       csrf.TemplateField(r),
+				"error":           message,
+			}
+
+			err = s.renderTemplate(w, r, "/layouts/menu_layout.html", "/admin_settings_client_registration.html", bind)
+			if err != nil {
+				s.internalServerError(w, r, err)
+			}
+		}
+
+		const maxLengthDescription = 128
+		if len(description) == 0 {
+			renderError("The description is required.")
+			return
+		}
+		if len(description) > maxLengthDescription {
+			renderError("The description cannot exceed a maximum length of " + strconv.Itoa(maxLengthDescription) + " characters.")
+			return
+		}
+
+		expiresInDaysInt, err := strconv.Atoi(expiresInDays)
+		if err != nil || expiresInDaysInt < 0 {
+			renderError("Invalid value for expires in days. Please use zero (never expires) or a positive number.")
+			return
+		}
+
+		const maxExpiresInDays = 3650
+		if expiresInDaysInt > maxExpiresInDays {
+			renderError(fmt.Sprintf("Expires in days cannot be greater than %v.", maxExpiresInDays))
+			return
+		}
+
+		token := lib.GenerateSecureRandomString(60)
+		tokenHash, err := lib.HashString(token)
+		if err != nil {
+			s.internalServerError(w, r, err)
+			return
+		}
+
+		initialAccessToken := &entities.InitialAccessToken{
+			TokenHash:   tokenHash,
+			Description: inputSanitizer.Sanitize(description),
+		}
+		if expiresInDaysInt > 0 {
+			initialAccessToken.ExpiresAt = sql.NullTime{
+				Time:  time.Now().UTC().AddDate(0, 0, expiresInDaysInt),
+				Valid: true,
+			}
+		}
+
+		err = s.database.CreateInitialAccessToken(nil, initialAccessToken)
+		if err != nil {
+			s.internalServerError(w, r, err)
+			return
+		}
+
+		lib.LogAudit(constants.AuditCreatedInitialAccessToken, map[string]interface{}{
+			"initialAccessTokenId": initialAccessToken.Id,
+			"loggedInUser":         s.getLoggedInSubject(r),
+		})
+
+		sess, err := s.sessionStore.Get(r, common.SessionName)
+		if err != nil {
+			s.internalServerError(w, r, err)
+			return
+		}
+
+		// the token is only stored as a hash, so this is the only time it can be displayed
+		sess.AddFlash(token, "createdInitialAccessToken")
+		err = sess.Save(r, w)
+		if err != nil {
+			s.internalServerError(w, r, err)
+			return
+		}
+
+		http.Redirect(w, r, fmt.Sprintf("%v/admin/settings/client-registration", lib.GetBaseUrl()), http.StatusFound)
+	}
+}
+
+func (s *Server) handleAdminSettingsClientRegistrationDeletePost() http.HandlerFunc {
+
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		var data map[string]interface{}
+		decoder := json.NewDecoder(r.Body)
+		if err := decoder.Decode(&data); err != nil {
+			s.jsonError(w, r, err)
+			return
+		}
+
+		id, ok := data["id"].(float64)
+		if !ok {
+			s.jsonError(w, r, errors.WithStack(fmt.Errorf("unable to cast id to float64")))
+			return
+		}
+
+		err := s.database.DeleteInitialAccessToken(nil, int64(id))
+		if err != nil {
+			s.jsonError(w, r, err)
+			return
+		}
+
+		lib.LogAudit(constants.AuditDeletedInitialAccessToken, map[string]interface{}{
+			"initialAccessTokenId": int64(id),
+			"loggedInUser":         s.getLoggedInSubject(r),
+		})
+
+		result := struct {
+			Success bool
+		}{
+			Success: true,
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(result)
+	}
+}
@@ -1,16 +1,15 @@
 package server
 
 import (
-	"crypto/x509"
 	"encoding/base64"
 	"encoding/json"
-	"encoding/pem"
 	"fmt"
 	"net/http"
 
 	"github.com/google/uuid"
 	"github.com/gorilla/csrf"
 	"github.com/leodip/goiabada/internal/constants"
+	"github.com/leodip/goiabada/internal/customerrors"
 	"github.com/leodip/goiabada/internal/entities"
 	"github.com/leodip/goiabada/internal/enums"
 	"github.com/leodip/goiabada/internal/lib"
@@ -65,27 +64,21 @@ func (s *Server) handleAdminSettingsKeysGet() http.HandlerFunc {
 		}
 
 		orderedKeys := make([]keyInfo, 0, len(keys))
-		for _, ki := range keys {
-			if ki.State == enums.KeyStateNext.String() {
-				orderedKeys = append(orderedKeys, ki)
-				break
-			}
-		}
-		for _, ki := range keys {
-			if ki.State == enums.KeyStateCurrent.String() {
-				orderedKeys = append(orderedKeys, ki)
-				break
-			}
-		}
-		for _, ki := range keys {
-			if ki.State == enums.KeyStatePrevious.String() {
-				orderedKeys = append(orderedKeys, ki)
+		for _, algorithm := range lib.SigningAlgorithms {
+			for _, keyState := range []enums.KeyState{enums.KeyStateNext, enums.KeyStateCurrent, enums.KeyStatePrevious} {
+				for _, ki := range keys {
+					if ki.Algorithm == algorithm && ki.State == keyState.String() {
+						orderedKeys = append(orderedKeys, ki)
+					}
+				}
 			}
 		}
 
 		bind := map[string]interface{}{
-			"keys":      orderedKeys,
-			"csrfField": csrf.TemplateField(r),
+			"keys":                    orderedKeys,
+			"signingAlgorithms":       lib.SigningAlgorithms,
+			"defaultSigningAlgorithm": lib.DefaultSigningAlgorithm,
+			"csrfField":               csrf.TemplateField(r),
 		}
 
 		err = s.renderTemplate(w, r, "/layouts/menu_layout.html", "/admin_settings_keys.html", bind)
@@ -100,16 +93,36 @@ func (s *Server) handleAdminSettingsKeysRotatePost() http.HandlerFunc {
 
 	return func(w http.ResponseWriter, r *http.Request) {
 
+		var data map[string]interface{}
+		decoder := json.NewDecoder(r.Body)
+		if err := decoder.Decode(&data); err != nil {
+			s.jsonError(w, r, err)
+			return
+		}
+
+		algorithm, _ := data["algorithm"].(string)
+		if len(algorithm) == 0 {
+			algorithm = lib.DefaultSigningAlgorithm
+		}
+		if !lib.IsSigningAlgorithmSupported(algorithm) {
+			s.jsonError(w, r, customerrors.NewValidationError("", fmt.Sprintf("The algorithm %v is not supported.", algorithm)))
+			return
+		}
+
 		allSigningKeys, err := s.database.GetAllSigningKeys(nil)
 		if err != nil {
 			s.jsonError(w, r, err)
 			return
 		}
 
+		// each algorithm has its own set of next, current and previous keys
 		var currentKey *entities.KeyPair
 		var nextKey *entities.KeyPair
 		var previousKey *entities.KeyPair
 		for i, signingKey := range allSigningKeys {
+			if signingKey.Algorithm != algorithm {
+				continue
+			}
 			keyState, err := enums.KeyStateFromString(signingKey.State)
 			if err != nil {
 				s.jsonError(w, r, err)
@@ -125,79 +138,51 @@ func (s *Server) handleAdminSettingsKeysRotatePost() http.HandlerFunc {
 			}
 		}
 
-		if previousKey != nil {
-			err = s.database.DeleteKeyPair(nil, previousKey.Id)
+		if currentKey == nil && nextKey == nil && previousKey == nil {
+			// first keys of the algorithm - the current key can be used right away
+			err = s.createSigningKey(algorithm, enums.KeyStateCurrent)
 			if err != nil {
 				s.jsonError(w, r, err)
 				return
 			}
-		}
+		} else {
+			if previousKey != nil {
+				err = s.database.DeleteKeyPair(nil, previousKey.Id)
+				if err != nil {
+					s.jsonError(w, r, err)
+					return
+				}
+			}
 
-		if currentKey == nil {
-			s.jsonError(w, r, errors.WithStack(fmt.Errorf("no current key found")))
-			return
-		}
+			if currentKey == nil {
+				s.jsonError(w, r, errors.WithStack(fmt.Errorf("no current key found")))
+				return
+			}
 
-		if nextKey == nil {
-			s.jsonError(w, r, errors.WithStack(fmt.Errorf("no next key found")))
-			return
-		}
+			if nextKey == nil {
+				s.jsonError(w, r, errors.WithStack(fmt.Errorf("no next key found")))
+				return
+			}
 
-		// current key becomes previous
-		currentKey.State = enums.KeyStatePrevious.String()
-		err = s.database.UpdateKeyPair(nil, currentKey)
-		if err != nil {
-			s.jsonError(w, r, err)
-			return
-		}
+			// current key becomes previous
+			currentKey.State = enums.KeyStatePrevious.String()
+			err = s.database.UpdateKeyPair(nil, currentKey)
+			if err != nil {
+				s.jsonError(w, r, err)
+				return
+			}
 
-		// next key becomes current
-		nextKey.State = enums.KeyStateCurrent.String()
-		err = s.database.UpdateKeyPair(nil, nextKey)
-		if err != nil {
-			s.jsonError(w, r, err)
-			return
+			// next key becomes current
+			nextKey.State = enums.KeyStateCurrent.String()
+			err = s.database.UpdateKeyPair(nil, nextKey)
+			if err != nil {
+				s.jsonError(w, r, err)
+				return
+			}
 		}
 
 		// create a new next key
-		privateKey, err := lib.GeneratePrivateKey(4096)
-		if err != nil {
-			s.jsonError(w, r, errors.Wrap(err, "unable to generate a private key"))
-			return
-		}
-		privateKeyPEM := lib.EncodePrivateKeyToPEM(privateKey)
-
-		publicKeyASN1_DER, err := x509.MarshalPKIXPublicKey(&privateKey.PublicKey)
-		if err != nil {
-			s.jsonError(w, r, errors.Wrap(err, "unable to marshal public key to PKIX"))
-			return
-		}
-
-		publicKeyPEM := pem.EncodeToMemory(
-			&pem.Block{
-				Type:  "RSA PUBLIC KEY",
-				Bytes: publicKeyASN1_DER,
-			},
-		)
-
-		kid := uuid.New().String()
-		publicKeyJWK, err := lib.MarshalRSAPublicKeyToJWK(&privateKey.PublicKey, kid)
-		if err != nil {
-			s.jsonError(w, r, err)
-			return
-		}
-
-		keyPair := &entities.KeyPair{
-			State:             enums.KeyStateNext.String(),
-			KeyIdentifier:     kid,
-			Type:              "RSA",
-			Algorithm:         "RS256",
-			PrivateKeyPEM:     privateKeyPEM,
-			PublicKeyPEM:      publicKeyPEM,
-			PublicKeyASN1_DER: publicKeyASN1_DER,
-			PublicKeyJWK:      publicKeyJWK,
-		}
-		err = s.database.CreateKeyPair(nil, keyPair)
+		err = s.createSigningKey(algorithm, enums.KeyStateNext)
 		if err != nil {
 			s.jsonError(w, r, err)
 			return
@@ -205,6 +190,7 @@ func (s *Server) handleAdminSettingsKeysRotatePost() http.HandlerFunc {
 
 		lib.LogAudit(constants.AuditRotatedKeys, map[string]interface{}{
 			"loggedInUser": s.getLoggedInSubject(r),
+			"algorithm":    algorithm,
 		})
 
 		result := struct {
@@ -217,6 +203,26 @@ func (s *Server) handleAdminSettingsKeysRotatePost() http.HandlerFunc {
 	}
 }
 
+func (s *Server) createSigningKey(algorithm string, keyState enums.KeyState) error {
+	kid := uuid.New().String()
+	signingKeyPair, err := lib.GenerateSigningKeyPair(algorithm, kid)
+	if err != nil {
+		return err
+	}
+
+	keyPair := &entities.KeyPair{
+		State:             keyState.String(),
+		KeyIdentifier:     kid,
+		Type:              signingKeyPair.Type,
+		Algorithm:         algorithm,
+		PrivateKeyPEM:     signingKeyPair.PrivateKeyPEM,
+		PublicKeyPEM:      signingKeyPair.PublicKeyPEM,
+		PublicKeyASN1_DER: signingKeyPair.PublicKeyASN1_DER,
+		PublicKeyJWK:      signingKeyPair.PublicKeyJWK,
+	}
+	return s.database.CreateKeyPair(nil, keyPair)
+}
+
 func (s *Server) handleAdminSettingsKeysRevokePost() http.HandlerFunc {
 
 	return func(w http.ResponseWriter, r *http.Request) {
@@ -128,7 +128,7 @@ func (s *Server) handleAdminUserSessionsGet() http.HandlerFunc {
 	}
 }
 
-func (s *Server) handleAdminUserSessionsPost() http.HandlerFunc {
+func (s *Server) handleAdminUserSessionsPost(backChannelLogoutSender backChannelLogoutSender) http.HandlerFunc {
 
 	return func(w http.ResponseWriter, r *http.Request) {
 
@@ -174,7 +174,13 @@ func (s *Server) handleAdminUserSessionsPost() http.HandlerFunc {
 
 		for _, us := range allUserSessions {
 			if us.Id == int64(userSessionId) {
-				err := s.database.DeleteUserSession(nil, us.Id)
+				err := backChannelLogoutSender.SendLogoutTokens(r.Context(), &us)
+				if err != nil {
+					s.jsonError(w, r, err)
+					return
+				}
+
+				err = s.database.DeleteUserSession(nil, us.Id)
 				if err != nil {
 					s.jsonError(w, r, err)
 					return
@@ -21,7 +21,7 @@ func (s *Server) handleAuthPwdGet() http.HandlerFunc {
 
 	return func(w http.ResponseWriter, r *http.Request) {
 
-		_, err := s.getAuthContext(r)
+		authContext, err := s.getAuthContext(r)
 		if err != nil {
 			if errors.Is(err, customerrors.ErrNoAuthContext) {
 				slog.Warn("no auth context, redirecting to " + lib.GetBaseUrl() + "/account/profile")
@@ -50,6 +50,11 @@ func (s *Server) handleAuthPwdGet() http.HandlerFunc {
 			}
 		}
 
+		// the login_hint sent by the client takes precedence
+		if len(authContext.LoginHint) > 0 {
+			email = authContext.LoginHint
+		}
+
 		settings := r.Context().Value(common.ContextKeySettings).(*entities.Settings)
 
 		bind := map[string]interface{}{
@@ -156,6 +161,11 @@ func (s *Server) handleAuthPwdPost(authorizeValidator authorizeValidator, loginM
 			return
 		}
 
+		if userSession != nil && userSession.UserId != user.Id {
+			// the existing session belongs to another user (e.g. prompt=select_account)
+			userSession = nil
+		}
+
 		err = s.database.UserSessionLoadUser(nil, userSession)
 		if err != nil {
 			s.internalServerError(w, r, err)
@@ -4,19 +4,28 @@ import (
 	"html/template"
 	"net/http"
 	"net/url"
+	"slices"
 	"strings"
+	"time"
 
 	"github.com/pkg/errors"
 
 	"github.com/go-chi/chi/v5/middleware"
+	"github.com/golang-jwt/jwt/v5"
 	"github.com/leodip/goiabada/internal/common"
 	"github.com/leodip/goiabada/internal/constants"
+	"github.com/leodip/goiabada/internal/core"
+	core_authorize "github.com/leodip/goiabada/internal/core/authorize"
 	core_validators "github.com/leodip/goiabada/internal/core/validators"
 	"github.com/leodip/goiabada/internal/customerrors"
 	"github.com/leodip/goiabada/internal/dtos"
+	"github.com/leodip/goiabada/internal/entities"
 	"github.com/leodip/goiabada/internal/lib"
 )
 
+// jwtResponseExpirationInSeconds is the lifetime of the JWT secured authorization responses
+const jwtResponseExpirationInSeconds = 600
+
 func (s *Server) handleAuthorizeGet(authorizeValidator authorizeValidator,
 	codeIssuer codeIssuer, loginManager loginManager) http.HandlerFunc {
 
@@ -24,28 +33,6 @@ func (s *Server) handleAuthorizeGet(authorizeValidator authorizeValidator,
 
 		requestId := middleware.GetReqID(r.Context())
 
-		authContext := dtos.AuthContext{
-			ClientId:            r.URL.Query().Get("client_id"),
-			RedirectURI:         r.URL.Query().Get("redirect_uri"),
-			ResponseType:        r.URL.Query().Get("response_type"),
-			CodeChallengeMethod: r.URL.Query().Get("code_challenge_method"),
-			CodeChallenge:       r.URL.Query().Get("code_challenge"),
-			ResponseMode:        r.URL.Query().Get("response_mode"),
-			MaxAge:              r.URL.Query().Get("max_age"),
-			RequestedAcrValues:  r.URL.Query().Get("acr_values"),
-			State:               r.URL.Query().Get("state"),
-			Nonce:               r.URL.Query().Get("nonce"),
-			UserAgent:           r.UserAgent(),
-			IpAddress:           r.RemoteAddr,
-		}
-		authContext.SetScope(r.URL.Query().Get("scope"))
-
-		err := s.saveAuthContext(w, r, &authContext)
-		if err != nil {
-			s.internalServerError(w, r, err)
-			return
-		}
-
 		renderErrorUi := func(message string) {
 			bind := map[string]interface{}{
 				"title": "Unable to authorize",
@@ -58,10 +45,100 @@ func (s *Server) handleAuthorizeGet(authorizeValidator authorizeValidator,
 			}
 		}
 
+		query := r.URL.Query()
+
+		if len(query.Get("request")) > 0 && len(query.Get("request_uri")) > 0 {
+			renderErrorUi("The request and request_uri parameters cannot be used together.")
+			return
+		}
+
+		isPushedAuthRequest := false
+		if len(query.Get("request_uri")) > 0 {
+			// the authorization parameters were pushed beforehand (PAR)
+			pushedAuthRequest, err := authorizeValidator.ValidateRequestURI(r.Context(), &core_validators.ValidateRequestURIInput{
+				ClientId:   query.Get("client_id"),
+				RequestURI: query.Get("request_uri"),
+			})
+			if err != nil {
+				valError, ok := err.(*customerrors.ValidationError)
+				if ok {
+					renderErrorUi(valError.Description)
+					return
+				} else {
+					s.internalServerError(w, r, err)
+					return
+				}
+			}
+
+			pushedAuthRequest.Used = true
+			err = s.database.UpdatePushedAuthRequest(nil, pushedAuthRequest)
+			if err != nil {
+				s.internalServerError(w, r, err)
+				return
+			}
+
+			query, err = url.ParseQuery(pushedAuthRequest.Parameters)
+			if err != nil {
+				s.internalServerError(w, r, err)
+				return
+			}
+			isPushedAuthRequest = true
+		}
+
+		if len(query.Get("request")) > 0 {
+			// the authorization parameters were sent as a signed request object (JAR)
+			requestObjectParams, err := authorizeValidator.ValidateRequestObject(r.Context(), &core_validators.ValidateRequestObjectInput{
+				ClientId:      query.Get("client_id"),
+				RequestObject: query.Get("request"),
+			})
+			if err != nil {
+				valError, ok := err.(*customerrors.ValidationError)
+				if ok {
+					renderErrorUi(valError.Description)
+					return
+				} else {
+					s.internalServerError(w, r, err)
+					return
+				}
+			}
+
+			// parameters inside the request object take precedence over the query string
+			for name, values := range requestObjectParams {
+				query[name] = values
+			}
+			query.Del("request")
+		}
+
+		authContext := dtos.AuthContext{
+			ClientId:            query.Get("client_id"),
+			RedirectURI:         query.Get("redirect_uri"),
+			ResponseType:        query.Get("response_type"),
+			CodeChallengeMethod: query.Get("code_challenge_method"),
+			CodeChallenge:       query.Get("code_challenge"),
+			ResponseMode:        query.Get("response_mode"),
+			MaxAge:              query.Get("max_age"),
+			RequestedAcrValues:  query.Get("acr_values"),
+			State:               query.Get("state"),
+			Nonce:               query.Get("nonce"),
+			Resource:            strings.Join(query["resource"], " "),
+			Prompt:              query.Get("prompt"),
+			LoginHint:           query.Get("login_hint"),
+			UserAgent:           r.UserAgent(),
+			IpAddress:           r.RemoteAddr,
+		}
+		authContext.SetScope(query.Get("scope"))
+
+		err := s.saveAuthContext(w, r, &authContext)
+		if err != nil {
+			s.internalServerError(w, r, err)
+			return
+		}
+
 		err = authorizeValidator.ValidateClientAndRedirectURI(r.Context(), &core_validators.ValidateClientAndRedirectURIInput{
-			RequestId:   requestId,
-			ClientId:    authContext.ClientId,
-			RedirectURI: authContext.RedirectURI,
+			RequestId:           requestId,
+			ClientId:            authContext.ClientId,
+			RedirectURI:         authContext.RedirectURI,
+			IsPushedAuthRequest: isPushedAuthRequest,
 		})
 
 		if err != nil {
@@ -77,7 +154,7 @@ func (s *Server) handleAuthorizeGet(authorizeValidator authorizeValidator,
 
 		redirToClientWithError := func(validationError *customerrors.ValidationError) {
 			err := s.redirToClientWithError(w, r, validationError.Code, validationError.Description,
-				r.URL.Query().Get("response_mode"), r.URL.Query().Get("redirect_uri"), r.URL.Query().Get("state"))
+				authContext.ClientId, authContext.ResponseMode, authContext.RedirectURI, authContext.State)
 			if err != nil {
 				s.internalServerError(w, r, err)
 			}
@@ -88,6 +165,8 @@ func (s *Server) handleAuthorizeGet(authorizeValidator authorizeValidator,
 			CodeChallengeMethod: authContext.CodeChallengeMethod,
 			CodeChallenge:       authContext.CodeChallenge,
 			ResponseMode:        authContext.ResponseMode,
+			Prompt:              authContext.Prompt,
+			MaxAge:              authContext.MaxAge,
 		})
 
 		if err != nil {
@@ -114,6 +193,79 @@ func (s *Server) handleAuthorizeGet(authorizeValidator authorizeValidator,
 			}
 		}
 
+		err = authorizeValidator.ValidateResources(r.Context(), &core_validators.ValidateResourcesInput{
+			Resources: query["resource"],
+			Scope:     authContext.Scope,
+		})
+
+		if err != nil {
+			valError, ok := err.(*customerrors.ValidationError)
+			if ok {
+				redirToClientWithError(valError)
+				return
+			} else {
+				s.internalServerError(w, r, err)
+				return
+			}
+		}
+
+		claimsRequest, err := authorizeValidator.ValidateClaimsRequest(r.Context(), &core_validators.ValidateClaimsRequestInput{
+			Claims: query.Get("claims"),
+			Scope:  authContext.Scope,
+		})
+		if err != nil {
+			valError, ok := err.(*customerrors.ValidationError)
+			if ok {
+				redirToClientWithError(valError)
+				return
+			} else {
+				s.internalServerError(w, r, err)
+				return
+			}
+		}
+		authContext.Claims = claimsRequest.String()
+
+		// the acr claim requested with values works like the acr_values parameter
+		if acrRequest := claimsRequest.IdToken["acr"]; acrRequest != nil && len(authContext.RequestedAcrValues) == 0 {
+			acrValues := []string{}
+			for _, value := range append(slices.Clone(acrRequest.Values), acrRequest.Value) {
+				if acrValue, ok := value.(string); ok {
+					acrValues = append(acrValues, acrValue)
+				}
+			}
+			authContext.RequestedAcrValues = strings.Join(acrValues, " ")
+		}
+
+		authorizationDetails, err := authorizeValidator.ValidateAuthorizationDetails(r.Context(), query.Get("authorization_details"))
+		if err != nil {
+			valError, ok := err.(*customerrors.ValidationError)
+			if ok {
+				redirToClientWithError(valError)
+				return
+			} else {
+				s.internalServerError(w, r, err)
+				return
+			}
+		}
+		authContext.AuthorizationDetails = authorizationDetails.String()
+
+		if len(query.Get("id_token_hint")) > 0 {
+			authContext.IdTokenHintSubject, err = authorizeValidator.ValidateIdTokenHint(r.Context(), &core_validators.ValidateIdTokenHintInput{
+				ClientId:    authContext.ClientId,
+				IdTokenHint: query.Get("id_token_hint"),
+			})
+			if err != nil {
+				valError, ok := err.(*customerrors.ValidationError)
+				if ok {
+					redirToClientWithError(valError)
+					return
+				} else {
+					s.internalServerError(w, r, err)
+					return
+				}
+			}
+		}
+
 		sessionIdentifier := ""
 		if r.Context().Value(common.ContextKeySessionIdentifier) != nil {
 			sessionIdentifier = r.Context().Value(common.ContextKeySessionIdentifier).(string)
@@ -144,8 +296,13 @@ func (s *Server) handleAuthorizeGet(authorizeValidator authorizeValidator,
 		requestedAcrValues := authContext.ParseRequestedAcrValues()
 		targetAcrLevel := client.DefaultAcrLevel
 
-		hasValidUserSession := loginManager.HasValidUserSession(r.Context(), userSession, authContext.ParseRequestedMaxAge())
-		if hasValidUserSession {
+		mustAuthenticate := loginManager.MustAuthenticate(r.Context(), &core_authorize.MustAuthenticateInput{
+			UserSession:              userSession,
+			RequestedMaxAgeInSeconds: authContext.ParseRequestedMaxAge(),
+			Prompt:                   authContext.Prompt,
+			IdTokenHintSubject:       authContext.IdTokenHintSubject,
+		})
+		if !mustAuthenticate {
 			// valid user session
 
 			if !userSession.User.Enabled {
@@ -167,6 +324,14 @@ func (s *Server) handleAuthorizeGet(authorizeValidator authorizeValidator,
 
 			mustPerformOTPAuth := loginManager.MustPerformOTPAuth(r.Context(), client, userSession, targetAcrLevel)
 			if mustPerformOTPAuth {
+				if authContext.HasPrompt("none") {
+					redirToClientWithError(&customerrors.ValidationError{
+						Code:        "interaction_required",
+						Description: "The requested authentication level requires user interaction.",
+					})
+					return
+				}
+
 				authContext.UserId = userSession.User.Id
 				err = s.saveAuthContext(w, r, &authContext)
 				if err != nil {
@@ -178,7 +343,15 @@ func (s *Server) handleAuthorizeGet(authorizeValidator authorizeValidator,
 			}
 
 		} else {
-			// no valid session
+			// no valid session, or the client asked for a new authentication
+			if authContext.HasPrompt("none") {
+				redirToClientWithError(&customerrors.ValidationError{
+					Code:        "login_required",
+					Description: "The user is not authenticated.",
+				})
+				return
+			}
+
 			err = s.saveAuthContext(w, r, &authContext)
 			if err != nil {
 				s.internalServerError(w, r, err)
@@ -220,7 +393,17 @@ func (s *Server) handleAuthorizeGet(authorizeValidator authorizeValidator,
 }
 
 func (s *Server) redirToClientWithError(w http.ResponseWriter, r *http.Request, code string,
-	description string, responseMode string, redirectURI string, state string) error {
+	description string, clientIdentifier string, responseMode string, redirectURI string, state string) error {
+
+	if isJWTResponseMode(responseMode) {
+		values := url.Values{}
+		values.Add("error", code)
+		values.Add("error_description", description)
+		if len(strings.TrimSpace(state)) > 0 {
+			values.Add("state", state)
+		}
+		return s.redirToClientWithJWTResponse(w, r, clientIdentifier, responseMode, redirectURI, values)
+	}
 
 	if responseMode == "fragment" {
 		values := url.Values{}
@@ -266,3 +449,65 @@ func (s *Server) redirToClientWithError(w http.ResponseWriter, r *http.Request,
 	http.Redirect(w, r, redirUrl.String(), http.StatusFound)
 	return nil
 }
+
+// isJWTResponseMode tells if the response mode is one of the JWT secured authorization response modes (JARM)
+func isJWTResponseMode(responseMode string) bool {
+	return slices.Contains(core_validators.ResponseModesSupported, responseMode) && strings.HasSuffix(responseMode, "jwt")
+}
+
+// redirToClientWithJWTResponse sends the authorization response parameters to the client inside a JWT
+// signed with the current key, as defined by JARM. The jwt response mode means query.jwt, the default
+// response mode of the code response type.
+func (s *Server) redirToClientWithJWTResponse(w http.ResponseWriter, r *http.Request, clientIdentifier string,
+	responseMode string, redirectURI string, values url.Values) error {
+
+	settings := r.Context().Value(common.ContextKeySettings).(*entities.Settings)
+
+	claims := make(jwt.MapClaims)
+	claims["iss"] = settings.Issuer
+	claims["aud"] = clientIdentifier
+	claims["exp"] = time.Now().UTC().Add(time.Duration(jwtResponseExpirationInSeconds) * time.Second).Unix()
+	for name := range values {
+		claims[name] = values.Get(name)
+	}
+
+	signingKey, err := core.GetCurrentSigningKey(s.database, lib.DefaultSigningAlgorithm)
+	if err != nil {
+		return err
+	}
+	response, err := signingKey.NewToken(claims).SignedString(signingKey.PrivateKey)
+	if err != nil {
+		return errors.Wrap(err, "unable to sign the authorization response")
+	}
+
+	if responseMode == "fragment.jwt" {
+		values := url.Values{}
+		values.Add("response", response)
+		http.Redirect(w, r, redirectURI+"#"+values.Encode(), http.StatusFound)
+		return nil
+	}
+
+	if responseMode == "form_post.jwt" {
+		m := make(map[string]interface{})
+		m["redirectURI"] = redirectURI
+		m["response"] = response
+
+		t, err := template.ParseFS(s.templateFS, "form_post.html")
+		if err != nil {
+			return errors.Wrap(err, "unable to parse template")
+		}
+		err = t.Execute(w, m)
+		if err != nil {
+			return errors.Wrap(err, "unable to execute template")
+		}
+		return nil
+	}
+
+	// query.jwt and jwt
+	redirUrl, _ := url.ParseRequestURI(redirectURI)
+	query := redirUrl.Query()
+	query.Add("response", response)
+	redirUrl.RawQuery = query.Encode()
+	http.Redirect(w, r, redirUrl.String(), http.StatusFound)
+	return nil
+}
@@ -0,0 +1,79 @@
+package server
+
+import (
+	"encoding/json"
+	"math"
+	"net/http"
+	"strings"
+	"time"
+
+	core_authorize "github.com/leodip/goiabada/internal/core/authorize"
+	core_validators "github.com/leodip/goiabada/internal/core/validators"
+	"github.com/leodip/goiabada/internal/dtos"
+)
+
+func (s *Server) handleBackchannelAuthorizePost(backchannelAuthRequestIssuer backchannelAuthRequestIssuer,
+	tokenValidator tokenValidator, authorizeValidator authorizeValidator) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		r.ParseForm()
+
+		clientCredentials, err := s.getClientCredentials(r)
+		if err != nil {
+			s.jsonError(w, r, err)
+			return
+		}
+
+		input := core_validators.ValidateBackchannelAuthRequestInput{
+			ClientId:            clientCredentials.ClientId,
+			ClientSecret:        clientCredentials.ClientSecret,
+			ClientSecretBasic:   clientCredentials.ClientSecretBasic,
+			ClientAssertionType: clientCredentials.ClientAssertionType,
+			ClientAssertion:     clientCredentials.ClientAssertion,
+			ClientCertificate:   clientCredentials.ClientCertificate,
+			Scope:               r.PostForm.Get("scope"),
+			LoginHint:           strings.TrimSpace(r.PostForm.Get("login_hint")),
+			LoginHintToken:      r.PostForm.Get("login_hint_token"),
+			IdTokenHint:         r.PostForm.Get("id_token_hint"),
+			BindingMessage:      strings.TrimSpace(r.PostForm.Get("binding_message")),
+			UserCode:            r.PostForm.Get("user_code"),
+			AcrValues:           r.PostForm.Get("acr_values"),
+		}
+
+		result, err := tokenValidator.ValidateBackchannelAuthRequest(r.Context(), &input)
+		if err != nil {
+			s.jsonError(w, r, err)
+			return
+		}
+
+		err = authorizeValidator.ValidateScopes(r.Context(), input.Scope)
+		if err != nil {
+			s.jsonError(w, r, err)
+			return
+		}
+
+		backchannelAuthRequest, err := backchannelAuthRequestIssuer.CreateBackchannelAuthRequest(r.Context(),
+			&core_authorize.CreateBackchannelAuthRequestInput{
+				Client:         result.Client,
+				User:           result.User,
+				Scope:          input.Scope,
+				BindingMessage: input.BindingMessage,
+				AcrLevel:       result.AcrLevel,
+			})
+		if err != nil {
+			s.internalServerError(w, r, err)
+			return
+		}
+
+		resp := dtos.BackchannelAuthResponse{
+			AuthReqId: backchannelAuthRequest.AuthReqId,
+			ExpiresIn: int64(math.Round(time.Until(backchannelAuthRequest.ExpiresAt).Seconds())),
+			Interval:  backchannelAuthRequest.IntervalInSeconds,
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "no-store")
+		w.Header().Set("Pragma", "no-cache")
+		json.NewEncoder(w).Encode(resp)
+	}
+}
@@ -4,25 +4,12 @@ import (
 	"encoding/json"
 	"net/http"
 
-	"github.com/leodip/goiabada/internal/entities"
 	"github.com/leodip/goiabada/internal/enums"
+	"github.com/leodip/goiabada/internal/lib"
 )
 
 func (s *Server) handleCertsGet() http.HandlerFunc {
 
-	type jwk struct {
-		Alg string `json:"alg"`
-		Kid string `json:"kid"`
-		Kty string `json:"kty"`
-		Use string `json:"use"`
-		N   string `json:"n"`
-		E   string `json:"e"`
-	}
-
-	type jwks struct {
-		Keys []jwk `json:"keys"`
-	}
-
 	return func(w http.ResponseWriter, r *http.Request) {
 
 		allSigningKeys, err := s.database.GetAllSigningKeys(nil)
@@ -31,58 +18,27 @@ func (s *Server) handleCertsGet() http.HandlerFunc {
 			return
 		}
 
-		result := jwks{}
-
-		var nextKey *entities.KeyPair
-		var currentKey *entities.KeyPair
-		var previousKey *entities.KeyPair
-
-		for idx, signingKey := range allSigningKeys {
-
-			keyState, err := enums.KeyStateFromString(signingKey.State)
-			if err != nil {
-				s.internalServerError(w, r, err)
-				return
-			}
-
-			switch keyState {
-			case enums.KeyStateNext:
-				nextKey = &allSigningKeys[idx]
-			case enums.KeyStateCurrent:
-				currentKey = &allSigningKeys[idx]
-			case enums.KeyStatePrevious:
-				previousKey = &allSigningKeys[idx]
-			}
-		}
-
-		if nextKey != nil {
-			var publicKeyJwk jwk
-			err := json.Unmarshal(nextKey.PublicKeyJWK, &publicKeyJwk)
-			if err != nil {
-				s.internalServerError(w, r, err)
-				return
-			}
-			result.Keys = append(result.Keys, publicKeyJwk)
-		}
-
-		if currentKey != nil {
-			var publicKeyJwk jwk
-			err := json.Unmarshal(currentKey.PublicKeyJWK, &publicKeyJwk)
-			if err != nil {
-				s.internalServerError(w, r, err)
-				return
-			}
-			result.Keys = append(result.Keys, publicKeyJwk)
+		result := lib.JSONWebKeySet{
+			Keys: []lib.JSONWebKey{},
 		}
 
-		if previousKey != nil {
-			var publicKeyJwk jwk
-			err := json.Unmarshal(previousKey.PublicKeyJWK, &publicKeyJwk)
-			if err != nil {
-				s.internalServerError(w, r, err)
-				return
+		// the next, current and previous keys of each algorithm
+		for _, algorithm := range lib.SigningAlgorithms {
+			for _, keyState := range []enums.KeyState{enums.KeyStateNext, enums.KeyStateCurrent, enums.KeyStatePrevious} {
+				for _, signingKey := range allSigningKeys {
+					if signingKey.Algorithm != algorithm || signingKey.State != keyState.String() {
+						continue
+					}
+
+					var publicKeyJwk lib.JSONWebKey
+					err := json.Unmarshal(signingKey.PublicKeyJWK, &publicKeyJwk)
+					if err != nil {
+						s.internalServerError(w, r, err)
+						return
+					}
+					result.Keys = append(result.Keys, publicKeyJwk)
+				}
 			}
-			result.Keys = append(result.Keys, publicKeyJwk)
 		}
 
 		w.Header().Set("Content-Type", "application/json")
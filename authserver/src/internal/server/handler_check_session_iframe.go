@@ -0,0 +1,36 @@
+package server
+
+import (
+	"html/template"
+	"net/http"
+
+	"github.com/leodip/goiabada/internal/common"
+	"github.com/pkg/errors"
+)
+
+// handleCheckSessionIframeGet serves the check session iframe of OpenID Connect Session Management 1.0.
+// The client embeds it, and sends "client_id session_state" with postMessage. The iframe answers
+// "changed", "unchanged" or "error", by comparing the session_state with the browser state cookie.
+func (s *Server) handleCheckSessionIframeGet() http.HandlerFunc {
+
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		t, err := template.ParseFS(s.templateFS, "check_session_iframe.html")
+		if err != nil {
+			s.internalServerError(w, r, errors.Wrap(err, "unable to parse template"))
+			return
+		}
+
+		bind := map[string]interface{}{
+			"cookieName": common.BrowserStateCookieName,
+		}
+
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Header().Set("Cache-Control", "no-store")
+		err = t.Execute(w, bind)
+		if err != nil {
+			s.internalServerError(w, r, errors.Wrap(err, "unable to execute template"))
+			return
+		}
+	}
+}
@@ -0,0 +1,321 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/leodip/goiabada/internal/constants"
+	"github.com/leodip/goiabada/internal/core"
+	"github.com/leodip/goiabada/internal/customerrors"
+	"github.com/leodip/goiabada/internal/dtos"
+	"github.com/leodip/goiabada/internal/entities"
+	"github.com/leodip/goiabada/internal/enums"
+	"github.com/leodip/goiabada/internal/lib"
+)
+
+func (s *Server) handleClientRegistrationPost(clientRegistrationValidator clientRegistrationValidator,
+	clientRegistrar clientRegistrar, subjectResolver subjectResolver) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		initialAccessToken, err := s.getInitialAccessToken(r)
+		if err != nil {
+			s.jsonError(w, r, err)
+			return
+		}
+		if initialAccessToken == nil {
+			s.invalidTokenError(w, "The initial access token is missing, invalid or expired. Please provide a valid initial access token in the Authorization header.")
+			return
+		}
+
+		var metadata dtos.ClientMetadata
+		err = json.NewDecoder(r.Body).Decode(&metadata)
+		if err != nil {
+			s.jsonError(w, r, customerrors.NewValidationError("invalid_client_metadata", "The request body must be a JSON object with the client metadata."))
+			return
+		}
+
+		err = clientRegistrationValidator.ValidateClientMetadata(&metadata)
+		if err != nil {
+			s.jsonError(w, r, err)
+			return
+		}
+
+		err = s.validateClientMetadataSubjectType(r, subjectResolver, &metadata)
+		if err != nil {
+			s.jsonError(w, r, err)
+			return
+		}
+
+		err = core.ValidateIdTokenSigningAlgorithm(s.database, metadata.IdTokenSignedResponseAlg)
+		if err == nil {
+			err = core.ValidateUserInfoSigningAlgorithm(s.database, metadata.UserInfoSignedResponseAlg)
+		}
+		if err != nil {
+			s.jsonError(w, r, err)
+			return
+		}
+
+		err = validateClientMetadataEncryption(&metadata)
+		if err != nil {
+			s.jsonError(w, r, err)
+			return
+		}
+
+		resp, err := clientRegistrar.RegisterClient(r.Context(), &metadata)
+		if err != nil {
+			s.jsonError(w, r, err)
+			return
+		}
+
+		lib.LogAudit(constants.AuditRegisteredClient, map[string]interface{}{
+			"clientIdentifier":     resp.ClientId,
+			"initialAccessTokenId": initialAccessToken.Id,
+		})
+
+		s.clientRegistrationResponse(w, http.StatusCreated, resp)
+	}
+}
+
+func (s *Server) handleClientRegistrationGet(clientRegistrar clientRegistrar) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		client, err := s.getRegisteredClient(r)
+		if err != nil {
+			s.jsonError(w, r, err)
+			return
+		}
+		if client == nil {
+			s.invalidTokenError(w, "The registration access token is missing or invalid for this client.")
+			return
+		}
+
+		resp, err := clientRegistrar.GetClientRegistration(r.Context(), client)
+		if err != nil {
+			s.jsonError(w, r, err)
+			return
+		}
+
+		s.clientRegistrationResponse(w, http.StatusOK, resp)
+	}
+}
+
+func (s *Server) handleClientRegistrationPut(clientRegistrationValidator clientRegistrationValidator,
+	clientRegistrar clientRegistrar, subjectResolver subjectResolver) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		client, err := s.getRegisteredClient(r)
+		if err != nil {
+			s.jsonError(w, r, err)
+			return
+		}
+		if client == nil {
+			s.invalidTokenError(w, "The registration access token is missing or invalid for this client.")
+			return
+		}
+
+		var req dtos.ClientRegistrationResponse
+		err = json.NewDecoder(r.Body).Decode(&req)
+		if err != nil {
+			s.jsonError(w, r, customerrors.NewValidationError("invalid_client_metadata", "The request body must be a JSON object with the client metadata."))
+			return
+		}
+
+		if req.ClientId != client.ClientIdentifier {
+			s.jsonError(w, r, customerrors.NewValidationError("invalid_client_metadata", "The client_id in the request body must match the client being updated."))
+			return
+		}
+
+		if len(req.RegistrationAccessToken) > 0 || req.ClientSecretExpiresAt != nil {
+			s.jsonError(w, r, customerrors.NewValidationError("invalid_client_metadata", "The registration_access_token and client_secret_expires_at fields can't be updated."))
+			return
+		}
+
+		if len(req.ClientSecret) > 0 {
+			resp, err := clientRegistrar.GetClientRegistration(r.Context(), client)
+			if err != nil {
+				s.jsonError(w, r, err)
+				return
+			}
+			if req.ClientSecret != resp.ClientSecret {
+				s.jsonError(w, r, customerrors.NewValidationError("invalid_client_metadata", "The client_secret in the request body does not match the secret of the client."))
+				return
+			}
+		}
+
+		err = clientRegistrationValidator.ValidateClientMetadata(&req.ClientMetadata)
+		if err != nil {
+			s.jsonError(w, r, err)
+			return
+		}
+
+		err = s.validateClientMetadataSubjectType(r, subjectResolver, &req.ClientMetadata)
+		if err != nil {
+			s.jsonError(w, r, err)
+			return
+		}
+
+		err = core.ValidateIdTokenSigningAlgorithm(s.database, req.ClientMetadata.IdTokenSignedResponseAlg)
+		if err == nil {
+			err = core.ValidateUserInfoSigningAlgorithm(s.database, req.ClientMetadata.UserInfoSignedResponseAlg)
+		}
+		if err != nil {
+			s.jsonError(w, r, err)
+			return
+		}
+
+		err = validateClientMetadataEncryption(&req.ClientMetadata)
+		if err != nil {
+			s.jsonError(w, r, err)
+			return
+		}
+
+		resp, err := clientRegistrar.UpdateClientRegistration(r.Context(), client, &req.ClientMetadata)
+		if err != nil {
+			s.jsonError(w, r, err)
+			return
+		}
+
+		lib.LogAudit(constants.AuditUpdatedClientRegistration, map[string]interface{}{
+			"clientId":         client.Id,
+			"clientIdentifier": client.ClientIdentifier,
+		})
+
+		s.clientRegistrationResponse(w, http.StatusOK, resp)
+	}
+}
+
+func (s *Server) handleClientRegistrationDelete() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		client, err := s.getRegisteredClient(r)
+		if err != nil {
+			s.jsonError(w, r, err)
+			return
+		}
+		if client == nil {
+			s.invalidTokenError(w, "The registration access token is missing or invalid for this client.")
+			return
+		}
+
+		err = s.database.DeleteClient(nil, client.Id)
+		if err != nil {
+			s.jsonError(w, r, err)
+			return
+		}
+
+		lib.LogAudit(constants.AuditDeletedClientRegistration, map[string]interface{}{
+			"clientId":         client.Id,
+			"clientIdentifier": client.ClientIdentifier,
+		})
+
+		w.Header().Set("Cache-Control", "no-store")
+		w.Header().Set("Pragma", "no-cache")
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// getInitialAccessToken returns the initial access token sent as a bearer token,
+// or nil when it is missing, unknown or expired
+func (s *Server) getInitialAccessToken(r *http.Request) (*entities.InitialAccessToken, error) {
+	tokenStr := getBearerToken(r)
+	if len(tokenStr) == 0 {
+		return nil, nil
+	}
+
+	tokenHash, err := lib.HashString(tokenStr)
+	if err != nil {
+		return nil, err
+	}
+
+	initialAccessToken, err := s.database.GetInitialAccessTokenByTokenHash(nil, tokenHash)
+	if err != nil {
+		return nil, err
+	}
+	if initialAccessToken == nil {
+		return nil, nil
+	}
+	if initialAccessToken.ExpiresAt.Valid && time.Now().UTC().After(initialAccessToken.ExpiresAt.Time) {
+		return nil, nil
+	}
+	return initialAccessToken, nil
+}
+
+// getRegisteredClient returns the dynamically registered client in the URL, or nil when the
+// client does not exist or the registration access token sent as a bearer token does not match
+func (s *Server) getRegisteredClient(r *http.Request) (*entities.Client, error) {
+	tokenStr := getBearerToken(r)
+	if len(tokenStr) == 0 {
+		return nil, nil
+	}
+
+	client, err := s.database.GetClientByClientIdentifier(nil, chi.URLParam(r, "clientId"))
+	if err != nil {
+		return nil, err
+	}
+	if client == nil || !client.IsDynamicallyRegistered || len(client.RegistrationAccessTokenHash) == 0 {
+		return nil, nil
+	}
+
+	tokenHash, err := lib.HashString(tokenStr)
+	if err != nil {
+		return nil, err
+	}
+	if tokenHash != client.RegistrationAccessTokenHash {
+		return nil, nil
+	}
+	return client, nil
+}
+
+func getBearerToken(r *http.Request) string {
+	const BEARER_SCHEMA = "Bearer "
+	authHeader := r.Header.Get("Authorization")
+	if !strings.HasPrefix(authHeader, BEARER_SCHEMA) {
+		return ""
+	}
+	return strings.TrimSpace(authHeader[len(BEARER_SCHEMA):])
+}
+
+func (s *Server) invalidTokenError(w http.ResponseWriter, message string) {
+	values := map[string]string{
+		"error":             "invalid_token",
+		"error_description": message,
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("WWW-Authenticate", `Bearer error="invalid_token"`)
+	w.WriteHeader(http.StatusUnauthorized)
+	json.NewEncoder(w).Encode(values)
+}
+
+func (s *Server) clientRegistrationResponse(w http.ResponseWriter, statusCode int, resp *dtos.ClientRegistrationResponse) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("Pragma", "no-cache")
+	w.WriteHeader(statusCode)
+	json.NewEncoder(w).Encode(resp)
+}
+
+// validateClientMetadataSubjectType checks the sector identifier of a client that registers
+// with pairwise subjects, or with a sector_identifier_uri (OpenID Connect Core 1.0, section 8.1)
+func (s *Server) validateClientMetadataSubjectType(r *http.Request, subjectResolver subjectResolver,
+	metadata *dtos.ClientMetadata) error {
+
+	if metadata.SubjectType != enums.SubjectTypePairwise.String() && len(metadata.SectorIdentifierURI) == 0 {
+		return nil
+	}
+	return subjectResolver.ValidatePairwiseSettings(r.Context(), metadata.SectorIdentifierURI, metadata.RedirectURIs)
+}
+
+// validateClientMetadataEncryption checks the encryption of the id tokens and of the userinfo
+// responses of a client that registers (OpenID Connect Dynamic Client Registration 1.0, section 2)
+func validateClientMetadataEncryption(metadata *dtos.ClientMetadata) error {
+	err := core.ValidateEncryptedResponse("id_token", metadata.IdTokenEncryptedResponseAlg, metadata.IdTokenEncryptedResponseEnc,
+		string(metadata.JWKS), metadata.JWKSURI)
+	if err != nil {
+		return err
+	}
+	return core.ValidateEncryptedResponse("userinfo", metadata.UserInfoEncryptedResponseAlg, metadata.UserInfoEncryptedResponseEnc,
+		string(metadata.JWKS), metadata.JWKSURI)
+}
@@ -49,6 +49,59 @@ func (s *Server) buildScopeInfoArray(scope string, consent *entities.UserConsent
 	return scopeInfoArr
 }
 
+// buildClaimInfoArray returns the claims requested individually with the claims parameter that
+// are not released by the requested scopes. They're consented like the scope that releases them.
+func (s *Server) buildClaimInfoArray(authContext *dtos.AuthContext, consent *entities.UserConsent) ([]dtos.ClaimInfo, error) {
+	claimInfoArr := []dtos.ClaimInfo{}
+
+	claimsRequest, err := dtos.ParseClaimsRequest(authContext.Claims)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, claim := range claimsRequest.GetClaimNames() {
+		scope := core.GetClaimScope(claim)
+		if len(scope) == 0 || authContext.HasScope(scope) {
+			continue
+		}
+		claimInfoArr = append(claimInfoArr, dtos.ClaimInfo{
+			Claim:            claim,
+			Scope:            scope,
+			Essential:        claimsRequest.IsEssential(claim),
+			AlreadyConsented: consent != nil && consent.HasScope(scope),
+		})
+	}
+	return claimInfoArr, nil
+}
+
+// buildAuthorizationDetailInfoArray returns the authorization details of the request, along with
+// the descriptions of their types
+func (s *Server) buildAuthorizationDetailInfoArray(authContext *dtos.AuthContext) ([]dtos.AuthorizationDetailInfo, error) {
+	authorizationDetailInfoArr := []dtos.AuthorizationDetailInfo{}
+
+	authorizationDetails, err := dtos.ParseAuthorizationDetails(authContext.AuthorizationDetails)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, authorizationDetail := range authorizationDetails {
+		authorizationDetailType, err := s.database.GetAuthorizationDetailTypeByType(nil, authorizationDetail.GetType())
+		if err != nil {
+			return nil, err
+		}
+		description := ""
+		if authorizationDetailType != nil {
+			description = authorizationDetailType.Description
+		}
+		authorizationDetailInfoArr = append(authorizationDetailInfoArr, dtos.AuthorizationDetailInfo{
+			Type:        authorizationDetail.GetType(),
+			Description: description,
+			Fields:      authorizationDetail.GetFields(),
+		})
+	}
+	return authorizationDetailInfoArr, nil
+}
+
 func (s *Server) filterOutScopesWhereUserIsNotAuthorized(scope string, user *entities.User,
 	permissionChecker *core.PermissionChecker) (string, error) {
 
@@ -110,8 +163,10 @@ func (s *Server) handleConsentGet(codeIssuer codeIssuer, permissionChecker *core
 				"userId": user.Id,
 			})
 
-			s.redirToClientWithError(w, r, "access_denied", "The user is not enabled", authContext.ResponseMode,
-				authContext.RedirectURI, authContext.State)
+			err = s.denyAuthorization(w, r, authContext, "The user is not enabled")
+			if err != nil {
+				s.internalServerError(w, r, err)
+			}
 			return
 		}
 
@@ -122,8 +177,10 @@ func (s *Server) handleConsentGet(codeIssuer codeIssuer, permissionChecker *core
 		}
 		authContext.SetScope(newScope)
 		if len(authContext.Scope) == 0 {
-			s.redirToClientWithError(w, r, "access_denied", "The user is not authorized to access any of the requested scopes", authContext.ResponseMode,
-				authContext.RedirectURI, authContext.State)
+			err = s.denyAuthorization(w, r, authContext, "The user is not authorized to access any of the requested scopes")
+			if err != nil {
+				s.internalServerError(w, r, err)
+			}
 			return
 		}
 		err = s.saveAuthContext(w, r, authContext)
@@ -147,8 +204,17 @@ func (s *Server) handleConsentGet(codeIssuer codeIssuer, permissionChecker *core
 			sessionIdentifier = r.Context().Value(common.ContextKeySessionIdentifier).(string)
 		}
 
-		// if the client requested an offline refresh token, consent is mandatory
-		if client.ConsentRequired || authContext.HasScope("offline_access") {
+		authorizationDetailInfoArr, err := s.buildAuthorizationDetailInfoArray(authContext)
+		if err != nil {
+			s.internalServerError(w, r, err)
+			return
+		}
+
+		// if the client requested an offline refresh token, consent is mandatory. The same goes for
+		// authorization details, which are specific to this request and never remembered, and for the
+		// device flow, where the user must confirm the device that is being authorized.
+		if client.ConsentRequired || authContext.HasScope("offline_access") || authContext.HasPrompt("consent") ||
+			len(authorizationDetailInfoArr) > 0 || authContext.DeviceCodeId > 0 {
 
 			consent, err := s.database.GetConsentByUserIdAndClientId(nil, user.Id, client.Id)
 			if err != nil {
@@ -157,18 +223,43 @@ func (s *Server) handleConsentGet(codeIssuer codeIssuer, permissionChecker *core
 			}
 
 			scopeInfoArr := s.buildScopeInfoArray(authContext.Scope, consent)
+			claimInfoArr, err := s.buildClaimInfoArray(authContext, consent)
+			if err != nil {
+				s.internalServerError(w, r, err)
+				return
+			}
 
 			scopesFullyConsented := true
 			for _, scopeInfo := range scopeInfoArr {
 				scopesFullyConsented = scopesFullyConsented && scopeInfo.AlreadyConsented
 			}
+			for _, claimInfo := range claimInfoArr {
+				scopesFullyConsented = scopesFullyConsented && claimInfo.AlreadyConsented
+			}
+
+			if !scopesFullyConsented || authContext.HasScope("offline_access") || authContext.HasPrompt("consent") ||
+				len(authorizationDetailInfoArr) > 0 || authContext.DeviceCodeId > 0 {
+				if authContext.HasPrompt("none") {
+					err = s.clearAuthContext(w, r)
+					if err != nil {
+						s.internalServerError(w, r, err)
+						return
+					}
+					err = s.redirToClientWithError(w, r, "consent_required", "The user must give consent to the client.",
+						authContext.ClientId, authContext.ResponseMode, authContext.RedirectURI, authContext.State)
+					if err != nil {
+						s.internalServerError(w, r, err)
+					}
+					return
+				}
 
-			if !scopesFullyConsented || authContext.HasScope("offline_access") {
 				bind := map[string]interface{}{
-					"csrfField":         csrf.TemplateField(r),
-					"clientIdentifier":  client.ClientIdentifier,
-					"clientDescription": client.Description,
-					"scopes":            scopeInfoArr,
+					"csrfField":            csrf.TemplateField(r),
+					"clientIdentifier":     client.ClientIdentifier,
+					"clientDescription":    client.Description,
+					"scopes":               scopeInfoArr,
+					"claims":               claimInfoArr,
+					"authorizationDetails": authorizationDetailInfoArr,
 				}
 
 				err = s.renderTemplate(w, r, "/layouts/auth_layout.html", "/consent.html", bind)
@@ -196,7 +287,7 @@ func (s *Server) handleConsentGet(codeIssuer codeIssuer, permissionChecker *core
 			s.internalServerError(w, r, err)
 			return
 		}
-		err = s.issueAuthCode(w, r, code, authContext.ResponseMode)
+		err = s.completeAuthorization(w, r, authContext, code)
 		if err != nil {
 			s.internalServerError(w, r, err)
 		}
@@ -232,8 +323,10 @@ func (s *Server) handleConsentPost(codeIssuer codeIssuer) http.HandlerFunc {
 			consented = strings.TrimSpace(consented)
 
 			if len(consented) == 0 {
-				s.redirToClientWithError(w, r, "access_denied", "The user did not provide consent", authContext.ResponseMode,
-					authContext.RedirectURI, authContext.State)
+				err = s.denyAuthorization(w, r, authContext, "The user did not provide consent")
+				if err != nil {
+					s.internalServerError(w, r, err)
+				}
 			} else {
 
 				client, err := s.database.GetClientByClientIdentifier(nil, authContext.ClientId)
@@ -263,6 +356,11 @@ func (s *Server) handleConsentPost(codeIssuer codeIssuer) http.HandlerFunc {
 				}
 
 				scopeInfoArr := s.buildScopeInfoArray(authContext.Scope, consent)
+				claimInfoArr, err := s.buildClaimInfoArray(authContext, consent)
+				if err != nil {
+					s.internalServerError(w, r, err)
+					return
+				}
 
 				if consent == nil {
 					consent = &entities.UserConsent{
@@ -296,6 +394,39 @@ func (s *Server) handleConsentPost(codeIssuer codeIssuer) http.HandlerFunc {
 				}
 				authContext.ConsentedScope = consent.Scope
 
+				// the claims requested individually are only released with the consent of the user
+				claimsRequest, err := dtos.ParseClaimsRequest(authContext.Claims)
+				if err != nil {
+					s.internalServerError(w, r, err)
+					return
+				}
+				for _, claim := range claimsRequest.GetClaimNames() {
+					scope := core.GetClaimScope(claim)
+					if len(scope) > 0 && authContext.HasScope(scope) && !consent.HasScope(scope) {
+						claimsRequest.Remove(claim)
+					}
+				}
+				for idx, claimInfo := range claimInfoArr {
+					if len(r.FormValue(fmt.Sprintf("claim%v", idx))) == 0 {
+						claimsRequest.Remove(claimInfo.Claim)
+					}
+				}
+				authContext.Claims = claimsRequest.String()
+
+				// the user can also decline some of the authorization details
+				authorizationDetails, err := dtos.ParseAuthorizationDetails(authContext.AuthorizationDetails)
+				if err != nil {
+					s.internalServerError(w, r, err)
+					return
+				}
+				var grantedAuthorizationDetails dtos.AuthorizationDetails
+				for idx, authorizationDetail := range authorizationDetails {
+					if len(r.FormValue(fmt.Sprintf("authorizationDetail%v", idx))) > 0 {
+						grantedAuthorizationDetails = append(grantedAuthorizationDetails, authorizationDetail)
+					}
+				}
+				authContext.AuthorizationDetails = grantedAuthorizationDetails.String()
+
 				lib.LogAudit(constants.AuditSavedConsent, map[string]interface{}{
 					"userId":   consent.UserId,
 					"clientId": consent.ClientId,
@@ -321,7 +452,7 @@ func (s *Server) handleConsentPost(codeIssuer codeIssuer) http.HandlerFunc {
 					s.internalServerError(w, r, err)
 					return
 				}
-				err = s.issueAuthCode(w, r, code, authContext.ResponseMode)
+				err = s.completeAuthorization(w, r, authContext, code)
 				if err != nil {
 					s.internalServerError(w, r, err)
 				}
@@ -329,22 +460,81 @@ func (s *Server) handleConsentPost(codeIssuer codeIssuer) http.HandlerFunc {
 			}
 
 		} else if btn == "cancel" {
-			s.redirToClientWithError(w, r, "access_denied", "The user did not provide consent", authContext.ResponseMode,
-				authContext.RedirectURI, authContext.State)
+			err = s.denyAuthorization(w, r, authContext, "The user did not provide consent")
+			if err != nil {
+				s.internalServerError(w, r, err)
+			}
 		}
 	}
 }
 
+// completeAuthorization hands the auth code over to the client. In the device code flow
+// there's no redirect_uri, so the code is linked to the device code instead.
+func (s *Server) completeAuthorization(w http.ResponseWriter, r *http.Request, authContext *dtos.AuthContext,
+	code *entities.Code) error {
+
+	if authContext.DeviceCodeId > 0 {
+		return s.approveDeviceCode(w, r, authContext.DeviceCodeId, code)
+	}
+	return s.issueAuthCode(w, r, code, authContext.ResponseMode)
+}
+
+// denyAuthorization tells the client that the authorization was denied.
+func (s *Server) denyAuthorization(w http.ResponseWriter, r *http.Request, authContext *dtos.AuthContext,
+	description string) error {
+
+	if authContext.DeviceCodeId > 0 {
+		return s.denyDeviceCode(w, r, authContext.DeviceCodeId, description)
+	}
+	return s.redirToClientWithError(w, r, "access_denied", description, authContext.ClientId,
+		authContext.ResponseMode, authContext.RedirectURI, authContext.State)
+}
+
 func (s *Server) issueAuthCode(w http.ResponseWriter, r *http.Request, code *entities.Code, responseMode string) error {
 
 	if responseMode == "" {
 		responseMode = "query"
 	}
 
+	// OpenID Connect Session Management
+	client, err := s.database.GetClientById(nil, code.ClientId)
+	if err != nil {
+		return err
+	}
+	if client == nil {
+		return errors.WithStack(errors.New("client not found"))
+	}
+
+	err = s.setBrowserStateCookie(w, code.SessionIdentifier)
+	if err != nil {
+		return err
+	}
+
+	browserState, err := lib.GetBrowserState(code.SessionIdentifier)
+	if err != nil {
+		return err
+	}
+
+	sessionState, err := lib.CalculateSessionState(client.ClientIdentifier, code.RedirectURI, browserState)
+	if err != nil {
+		return err
+	}
+
+	if isJWTResponseMode(responseMode) {
+		values := url.Values{}
+		values.Add("code", code.Code)
+		if len(strings.TrimSpace(code.State)) > 0 {
+			values.Add("state", code.State)
+		}
+		values.Add("session_state", sessionState)
+		return s.redirToClientWithJWTResponse(w, r, client.ClientIdentifier, responseMode, code.RedirectURI, values)
+	}
+
 	if responseMode == "fragment" {
 		values := url.Values{}
 		values.Add("code", code.Code)
 		values.Add("state", code.State)
+		values.Add("session_state", sessionState)
 		http.Redirect(w, r, code.RedirectURI+"#"+values.Encode(), http.StatusFound)
 		return nil
 	}
@@ -352,6 +542,7 @@ func (s *Server) issueAuthCode(w http.ResponseWriter, r *http.Request, code *ent
 		m := make(map[string]interface{})
 		m["redirectURI"] = code.RedirectURI
 		m["code"] = code.Code
+		m["sessionState"] = sessionState
 		if len(strings.TrimSpace(code.State)) > 0 {
 			m["state"] = code.State
 		}
@@ -372,6 +563,7 @@ func (s *Server) issueAuthCode(w http.ResponseWriter, r *http.Request, code *ent
 	values := redirUrl.Query()
 	values.Add("code", code.Code)
 	values.Add("state", code.State)
+	values.Add("session_state", sessionState)
 	redirUrl.RawQuery = values.Encode()
 	http.Redirect(w, r, redirUrl.String(), http.StatusFound)
 	return nil
@@ -0,0 +1,261 @@
+package server
+
+import (
+	"database/sql"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/csrf"
+	"github.com/pkg/errors"
+
+	"github.com/leodip/goiabada/internal/common"
+	"github.com/leodip/goiabada/internal/constants"
+	core_authorize "github.com/leodip/goiabada/internal/core/authorize"
+	"github.com/leodip/goiabada/internal/dtos"
+	"github.com/leodip/goiabada/internal/entities"
+	"github.com/leodip/goiabada/internal/enums"
+	"github.com/leodip/goiabada/internal/lib"
+)
+
+func (s *Server) handleDeviceGet() http.HandlerFunc {
+
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		bind := map[string]interface{}{
+			"error":     nil,
+			"userCode":  r.URL.Query().Get("user_code"),
+			"csrfField": csrf.TemplateField(r),
+		}
+
+		err := s.renderTemplate(w, r, "/layouts/auth_layout.html", "/device.html", bind)
+		if err != nil {
+			s.internalServerError(w, r, err)
+			return
+		}
+	}
+}
+
+func (s *Server) handleDevicePost(loginManager loginManager) http.HandlerFunc {
+
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		userCode := core_authorize.NormalizeUserCode(r.FormValue("userCode"))
+
+		renderError := func(message string) {
+			bind := map[string]interface{}{
+				"error":     message,
+				"userCode":  userCode,
+				"csrfField": csrf.TemplateField(r),
+			}
+
+			err := s.renderTemplate(w, r, "/layouts/auth_layout.html", "/device.html", bind)
+			if err != nil {
+				s.internalServerError(w, r, err)
+			}
+		}
+
+		if len(userCode) == 0 {
+			renderError("The code is required.")
+			return
+		}
+
+		deviceCode, err := s.database.GetDeviceCodeByUserCode(nil, userCode)
+		if err != nil {
+			s.internalServerError(w, r, err)
+			return
+		}
+
+		const invalidCodeMessage = "The code is invalid or has expired."
+		if deviceCode == nil || !isDeviceCodePending(deviceCode) {
+			renderError(invalidCodeMessage)
+			return
+		}
+
+		err = s.database.DeviceCodeLoadClient(nil, deviceCode)
+		if err != nil {
+			s.internalServerError(w, r, err)
+			return
+		}
+		client := &deviceCode.Client
+
+		if !client.Enabled || !client.DeviceCodeEnabled {
+			renderError(invalidCodeMessage)
+			return
+		}
+
+		authContext := dtos.AuthContext{
+			ClientId:     client.ClientIdentifier,
+			UserAgent:    r.UserAgent(),
+			IpAddress:    r.RemoteAddr,
+			DeviceCodeId: deviceCode.Id,
+		}
+		authContext.SetScope(deviceCode.Scope)
+
+		sessionIdentifier := ""
+		if r.Context().Value(common.ContextKeySessionIdentifier) != nil {
+			sessionIdentifier = r.Context().Value(common.ContextKeySessionIdentifier).(string)
+		}
+
+		userSession, err := s.database.GetUserSessionBySessionIdentifier(nil, sessionIdentifier)
+		if err != nil {
+			s.internalServerError(w, r, err)
+			return
+		}
+
+		err = s.database.UserSessionLoadUser(nil, userSession)
+		if err != nil {
+			s.internalServerError(w, r, err)
+			return
+		}
+
+		targetAcrLevel := client.DefaultAcrLevel
+
+		hasValidUserSession := loginManager.HasValidUserSession(r.Context(), userSession, nil)
+		if !hasValidUserSession {
+			err = s.saveAuthContext(w, r, &authContext)
+			if err != nil {
+				s.internalServerError(w, r, err)
+				return
+			}
+			http.Redirect(w, r, lib.GetBaseUrl()+"/auth/pwd", http.StatusFound)
+			return
+		}
+
+		if !userSession.User.Enabled {
+			lib.LogAudit(constants.AuditUserDisabled, map[string]interface{}{
+				"userId": userSession.UserId,
+			})
+			renderError("Your account is disabled.")
+			return
+		}
+
+		mustPerformOTPAuth := loginManager.MustPerformOTPAuth(r.Context(), client, userSession, targetAcrLevel)
+		if mustPerformOTPAuth {
+			authContext.UserId = userSession.User.Id
+			err = s.saveAuthContext(w, r, &authContext)
+			if err != nil {
+				s.internalServerError(w, r, err)
+				return
+			}
+			http.Redirect(w, r, lib.GetBaseUrl()+"/auth/otp", http.StatusFound)
+			return
+		}
+
+		// no further authentication is needed
+
+		authContext.UserId = userSession.User.Id
+		err = authContext.SetAcrLevel(targetAcrLevel, userSession)
+		if err != nil {
+			s.internalServerError(w, r, err)
+			return
+		}
+		authContext.AuthMethods = userSession.AuthMethods
+		authContext.AuthTime = userSession.AuthTime
+		authContext.AuthCompleted = true
+
+		_, err = s.bumpUserSession(w, r, sessionIdentifier, client.Id)
+		if err != nil {
+			s.internalServerError(w, r, err)
+			return
+		}
+
+		err = s.saveAuthContext(w, r, &authContext)
+		if err != nil {
+			s.internalServerError(w, r, err)
+			return
+		}
+
+		http.Redirect(w, r, lib.GetBaseUrl()+"/auth/consent", http.StatusFound)
+	}
+}
+
+func (s *Server) approveDeviceCode(w http.ResponseWriter, r *http.Request, deviceCodeId int64, code *entities.Code) error {
+
+	deviceCode, err := s.database.GetDeviceCodeById(nil, deviceCodeId)
+	if err != nil {
+		return err
+	}
+	if deviceCode == nil {
+		return errors.WithStack(errors.New("device code not found"))
+	}
+	if !isDeviceCodePending(deviceCode) {
+		return s.renderDeviceCodeNotPending(w, r)
+	}
+
+	deviceCode.State = enums.DeviceCodeStateApproved.String()
+	deviceCode.CodeId = sql.NullInt64{Int64: code.Id, Valid: true}
+	err = s.database.UpdateDeviceCode(nil, deviceCode)
+	if err != nil {
+		return err
+	}
+
+	lib.LogAudit(constants.AuditApprovedDeviceCode, map[string]interface{}{
+		"userId":       code.UserId,
+		"deviceCodeId": deviceCode.Id,
+	})
+
+	bind := map[string]interface{}{
+		"title":   "Device authorized",
+		"message": "You may now return to your device.",
+		"success": true,
+	}
+	return s.renderTemplate(w, r, "/layouts/no_menu_layout.html", "/device_result.html", bind)
+}
+
+func (s *Server) denyDeviceCode(w http.ResponseWriter, r *http.Request, deviceCodeId int64, description string) error {
+
+	deviceCode, err := s.database.GetDeviceCodeById(nil, deviceCodeId)
+	if err != nil {
+		return err
+	}
+	if deviceCode == nil {
+		return errors.WithStack(errors.New("device code not found"))
+	}
+	if !isDeviceCodePending(deviceCode) {
+		return s.renderDeviceCodeNotPending(w, r)
+	}
+
+	deviceCode.State = enums.DeviceCodeStateDenied.String()
+	err = s.database.UpdateDeviceCode(nil, deviceCode)
+	if err != nil {
+		return err
+	}
+
+	lib.LogAudit(constants.AuditDeniedDeviceCode, map[string]interface{}{
+		"deviceCodeId": deviceCode.Id,
+	})
+
+	err = s.clearAuthContext(w, r)
+	if err != nil {
+		return err
+	}
+
+	bind := map[string]interface{}{
+		"title":   "Device not authorized",
+		"message": description + ".",
+		"success": false,
+	}
+	return s.renderTemplate(w, r, "/layouts/no_menu_layout.html", "/device_result.html", bind)
+}
+
+// isDeviceCodePending tells if the device code can still be approved or denied. The code
+// may have expired, or may have been used by another browser, while the user was on the consent page.
+func isDeviceCodePending(deviceCode *entities.DeviceCode) bool {
+	return deviceCode.State == enums.DeviceCodeStatePending.String() &&
+		!time.Now().UTC().After(deviceCode.ExpiresAt)
+}
+
+func (s *Server) renderDeviceCodeNotPending(w http.ResponseWriter, r *http.Request) error {
+
+	err := s.clearAuthContext(w, r)
+	if err != nil {
+		return err
+	}
+
+	bind := map[string]interface{}{
+		"title":   "Device not authorized",
+		"message": "The code is invalid or has expired.",
+		"success": false,
+	}
+	return s.renderTemplate(w, r, "/layouts/no_menu_layout.html", "/device_result.html", bind)
+}
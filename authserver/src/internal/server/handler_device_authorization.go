@@ -0,0 +1,74 @@
+package server
+
+import (
+	"encoding/json"
+	"math"
+	"net/http"
+	"net/url"
+	"time"
+
+	core_authorize "github.com/leodip/goiabada/internal/core/authorize"
+	core_validators "github.com/leodip/goiabada/internal/core/validators"
+	"github.com/leodip/goiabada/internal/dtos"
+	"github.com/leodip/goiabada/internal/lib"
+)
+
+func (s *Server) handleDeviceAuthorizationPost(deviceCodeIssuer deviceCodeIssuer, tokenValidator tokenValidator,
+	authorizeValidator authorizeValidator) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		r.ParseForm()
+
+		clientCredentials, err := s.getClientCredentials(r)
+		if err != nil {
+			s.jsonError(w, r, err)
+			return
+		}
+
+		input := core_validators.ValidateDeviceAuthorizationRequestInput{
+			ClientId:            clientCredentials.ClientId,
+			ClientSecret:        clientCredentials.ClientSecret,
+			ClientSecretBasic:   clientCredentials.ClientSecretBasic,
+			ClientAssertionType: clientCredentials.ClientAssertionType,
+			ClientAssertion:     clientCredentials.ClientAssertion,
+			ClientCertificate:   clientCredentials.ClientCertificate,
+		}
+		scope := r.PostForm.Get("scope")
+
+		client, err := tokenValidator.ValidateDeviceAuthorizationRequest(r.Context(), &input)
+		if err != nil {
+			s.jsonError(w, r, err)
+			return
+		}
+
+		err = authorizeValidator.ValidateScopes(r.Context(), scope)
+		if err != nil {
+			s.jsonError(w, r, err)
+			return
+		}
+
+		deviceCode, err := deviceCodeIssuer.CreateDeviceCode(r.Context(), &core_authorize.CreateDeviceCodeInput{
+			Client: client,
+			Scope:  scope,
+		})
+		if err != nil {
+			s.internalServerError(w, r, err)
+			return
+		}
+
+		verificationURI := lib.GetBaseUrl() + "/device"
+		resp := dtos.DeviceAuthorizationResponse{
+			DeviceCode:              deviceCode.DeviceCode,
+			UserCode:                deviceCode.UserCode,
+			VerificationURI:         verificationURI,
+			VerificationURIComplete: verificationURI + "?user_code=" + url.QueryEscape(deviceCode.UserCode),
+			ExpiresIn:               int64(math.Round(time.Until(deviceCode.ExpiresAt).Seconds())),
+			Interval:                deviceCode.IntervalInSeconds,
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "no-store")
+		w.Header().Set("Pragma", "no-cache")
+		json.NewEncoder(w).Encode(resp)
+	}
+}
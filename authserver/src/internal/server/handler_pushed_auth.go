@@ -0,0 +1,141 @@
+package server
+
+import (
+	"encoding/json"
+	"math"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/go-chi/chi/v5/middleware"
+	core_authorize "github.com/leodip/goiabada/internal/core/authorize"
+	core_validators "github.com/leodip/goiabada/internal/core/validators"
+	"github.com/leodip/goiabada/internal/customerrors"
+	"github.com/leodip/goiabada/internal/dtos"
+)
+
+func (s *Server) handlePushedAuthPost(pushedAuthRequestIssuer pushedAuthRequestIssuer, tokenValidator tokenValidator,
+	authorizeValidator authorizeValidator) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		requestId := middleware.GetReqID(r.Context())
+
+		r.ParseForm()
+
+		clientCredentials, err := s.getClientCredentials(r)
+		if err != nil {
+			s.jsonError(w, r, err)
+			return
+		}
+
+		input := core_validators.ValidatePushedAuthRequestInput{
+			ClientId:            clientCredentials.ClientId,
+			ClientSecret:        clientCredentials.ClientSecret,
+			ClientSecretBasic:   clientCredentials.ClientSecretBasic,
+			ClientAssertionType: clientCredentials.ClientAssertionType,
+			ClientAssertion:     clientCredentials.ClientAssertion,
+			ClientCertificate:   clientCredentials.ClientCertificate,
+		}
+
+		client, err := tokenValidator.ValidatePushedAuthRequest(r.Context(), &input)
+		if err != nil {
+			s.jsonError(w, r, err)
+			return
+		}
+
+		if len(r.PostForm.Get("request_uri")) > 0 {
+			s.jsonError(w, r, customerrors.NewValidationError("invalid_request", "The request_uri parameter is not allowed in a pushed authorization request."))
+			return
+		}
+
+		params := r.PostForm
+		// the client_id may have been sent in the Authorization header (client_secret_basic)
+		params.Set("client_id", client.ClientIdentifier)
+		if len(params.Get("request")) > 0 {
+			requestObjectParams, err := authorizeValidator.ValidateRequestObject(r.Context(), &core_validators.ValidateRequestObjectInput{
+				ClientId:      client.ClientIdentifier,
+				RequestObject: params.Get("request"),
+			})
+			if err != nil {
+				valError, ok := err.(*customerrors.ValidationError)
+				if ok && len(valError.Code) == 0 {
+					err = customerrors.NewValidationError("invalid_request_object", valError.Description)
+				}
+				s.jsonError(w, r, err)
+				return
+			}
+
+			// parameters inside the request object take precedence over the form parameters
+			params = url.Values{}
+			for name, values := range r.PostForm {
+				params[name] = values
+			}
+			for name, values := range requestObjectParams {
+				params[name] = values
+			}
+			params.Del("request")
+		}
+
+		err = authorizeValidator.ValidateClientAndRedirectURI(r.Context(), &core_validators.ValidateClientAndRedirectURIInput{
+			RequestId:           requestId,
+			ClientId:            client.ClientIdentifier,
+			RedirectURI:         params.Get("redirect_uri"),
+			IsPushedAuthRequest: true,
+		})
+		if err != nil {
+			valError, ok := err.(*customerrors.ValidationError)
+			if ok && len(valError.Code) == 0 {
+				// on the authorize endpoint these errors are shown to the user, here they go back to the client
+				err = customerrors.NewValidationError("invalid_request", valError.Description)
+			}
+			s.jsonError(w, r, err)
+			return
+		}
+
+		err = authorizeValidator.ValidateRequest(r.Context(), &core_validators.ValidateRequestInput{
+			ResponseType:        params.Get("response_type"),
+			CodeChallengeMethod: params.Get("code_challenge_method"),
+			CodeChallenge:       params.Get("code_challenge"),
+			ResponseMode:        params.Get("response_mode"),
+			Prompt:              params.Get("prompt"),
+			MaxAge:              params.Get("max_age"),
+		})
+		if err != nil {
+			s.jsonError(w, r, err)
+			return
+		}
+
+		err = authorizeValidator.ValidateScopes(r.Context(), params.Get("scope"))
+		if err != nil {
+			s.jsonError(w, r, err)
+			return
+		}
+
+		_, err = authorizeValidator.ValidateAuthorizationDetails(r.Context(), params.Get("authorization_details"))
+		if err != nil {
+			s.jsonError(w, r, err)
+			return
+		}
+
+		pushedAuthRequest, err := pushedAuthRequestIssuer.CreatePushedAuthRequest(r.Context(),
+			&core_authorize.CreatePushedAuthRequestInput{
+				Client:     client,
+				Parameters: params,
+			})
+		if err != nil {
+			s.internalServerError(w, r, err)
+			return
+		}
+
+		resp := dtos.PushedAuthResponse{
+			RequestURI: pushedAuthRequest.RequestURI,
+			ExpiresIn:  int64(math.Round(time.Until(pushedAuthRequest.ExpiresAt).Seconds())),
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "no-store")
+		w.Header().Set("Pragma", "no-cache")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(resp)
+	}
+}
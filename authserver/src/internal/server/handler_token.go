@@ -8,22 +8,61 @@ import (
 	core_token "github.com/leodip/goiabada/internal/core/token"
 	core_validators "github.com/leodip/goiabada/internal/core/validators"
 	"github.com/leodip/goiabada/internal/customerrors"
+	"github.com/leodip/goiabada/internal/enums"
 	"github.com/leodip/goiabada/internal/lib"
 )
 
-func (s *Server) handleTokenPost(tokenIssuer tokenIssuer, tokenValidator tokenValidator) http.HandlerFunc {
+func (s *Server) handleTokenPost(tokenIssuer tokenIssuer, tokenValidator tokenValidator, dpopValidator dpopValidator) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 
 		r.ParseForm()
+
+		clientCredentials, err := s.getClientCredentials(r)
+		if err != nil {
+			s.jsonError(w, r, err)
+			return
+		}
+
+		// when a DPoP proof is sent, the issued tokens are bound to its key (RFC 9449)
+		dpopJkt := ""
+		if dpopProofs := r.Header.Values("DPoP"); len(dpopProofs) > 0 {
+			if len(dpopProofs) > 1 {
+				s.jsonError(w, r, customerrors.NewValidationError("invalid_dpop_proof", "Only one DPoP proof is allowed."))
+				return
+			}
+			var err error
+			dpopJkt, err = dpopValidator.ValidateDPoPProof(r.Context(), &core_validators.ValidateDPoPProofInput{
+				Proof:      dpopProofs[0],
+				HttpMethod: http.MethodPost,
+				HttpURI:    lib.GetBaseUrl() + "/auth/token",
+			})
+			if err != nil {
+				s.jsonError(w, r, err)
+				return
+			}
+		}
+
 		input := core_validators.ValidateTokenRequestInput{
-			GrantType:    r.PostForm.Get("grant_type"),
-			Code:         r.PostForm.Get("code"),
-			RedirectURI:  r.PostForm.Get("redirect_uri"),
-			CodeVerifier: r.PostForm.Get("code_verifier"),
-			ClientId:     r.PostForm.Get("client_id"),
-			ClientSecret: r.PostForm.Get("client_secret"),
-			Scope:        r.PostForm.Get("scope"),
-			RefreshToken: r.PostForm.Get("refresh_token"),
+			GrantType:            r.PostForm.Get("grant_type"),
+			Code:                 r.PostForm.Get("code"),
+			RedirectURI:          r.PostForm.Get("redirect_uri"),
+			CodeVerifier:         r.PostForm.Get("code_verifier"),
+			ClientId:             clientCredentials.ClientId,
+			ClientSecret:         clientCredentials.ClientSecret,
+			ClientSecretBasic:    clientCredentials.ClientSecretBasic,
+			ClientAssertionType:  clientCredentials.ClientAssertionType,
+			ClientAssertion:      clientCredentials.ClientAssertion,
+			ClientCertificate:    clientCredentials.ClientCertificate,
+			Scope:                r.PostForm.Get("scope"),
+			RefreshToken:         r.PostForm.Get("refresh_token"),
+			DeviceCode:           r.PostForm.Get("device_code"),
+			AuthReqId:            r.PostForm.Get("auth_req_id"),
+			SubjectToken:         r.PostForm.Get("subject_token"),
+			SubjectTokenType:     r.PostForm.Get("subject_token_type"),
+			RequestedTokenType:   r.PostForm.Get("requested_token_type"),
+			Resources:            r.PostForm["resource"],
+			AuthorizationDetails: r.PostForm.Get("authorization_details"),
+			DPoPJkt:              dpopJkt,
 		}
 
 		validateTokenRequestResult, err := tokenValidator.ValidateTokenRequest(r.Context(), &input)
@@ -32,11 +71,21 @@ func (s *Server) handleTokenPost(tokenIssuer tokenIssuer, tokenValidator tokenVa
 			return
 		}
 
+		// when a client certificate is presented, the issued access tokens are bound to it (RFC 8705)
+		certificateThumbprint := ""
+		if clientCredentials.ClientCertificate != nil {
+			certificateThumbprint = lib.GetCertificateThumbprint(clientCredentials.ClientCertificate)
+		}
+
 		if input.GrantType == "authorization_code" {
 
 			tokenResp, err := tokenIssuer.GenerateTokenResponseForAuthCode(r.Context(),
 				&core_token.GenerateTokenResponseForAuthCodeInput{
-					Code: validateTokenRequestResult.CodeEntity,
+					Code:                  validateTokenRequestResult.CodeEntity,
+					Resource:              validateTokenRequestResult.Resource,
+					AuthorizationDetails:  validateTokenRequestResult.AuthorizationDetails,
+					DPoPJkt:               dpopJkt,
+					CertificateThumbprint: certificateThumbprint,
 				})
 			if err != nil {
 				s.internalServerError(w, r, err)
@@ -62,7 +111,8 @@ func (s *Server) handleTokenPost(tokenIssuer tokenIssuer, tokenValidator tokenVa
 		} else if input.GrantType == "client_credentials" {
 
 			tokenResp, err := tokenIssuer.GenerateTokenResponseForClientCred(r.Context(),
-				validateTokenRequestResult.Client, validateTokenRequestResult.Scope)
+				validateTokenRequestResult.Client, validateTokenRequestResult.Scope,
+				validateTokenRequestResult.AuthorizationDetails, dpopJkt, certificateThumbprint)
 			if err != nil {
 				s.internalServerError(w, r, err)
 				return
@@ -78,6 +128,109 @@ func (s *Server) handleTokenPost(tokenIssuer tokenIssuer, tokenValidator tokenVa
 			json.NewEncoder(w).Encode(tokenResp)
 			return
 
+		} else if input.GrantType == "urn:ietf:params:oauth:grant-type:device_code" {
+
+			tokenResp, err := tokenIssuer.GenerateTokenResponseForAuthCode(r.Context(),
+				&core_token.GenerateTokenResponseForAuthCodeInput{
+					Code:                  validateTokenRequestResult.CodeEntity,
+					Resource:              validateTokenRequestResult.Resource,
+					AuthorizationDetails:  validateTokenRequestResult.AuthorizationDetails,
+					DPoPJkt:               dpopJkt,
+					CertificateThumbprint: certificateThumbprint,
+				})
+			if err != nil {
+				s.internalServerError(w, r, err)
+				return
+			}
+			validateTokenRequestResult.CodeEntity.Used = true
+			err = s.database.UpdateCode(nil, validateTokenRequestResult.CodeEntity)
+			if err != nil {
+				s.internalServerError(w, r, err)
+				return
+			}
+			validateTokenRequestResult.DeviceCode.State = enums.DeviceCodeStateUsed.String()
+			err = s.database.UpdateDeviceCode(nil, validateTokenRequestResult.DeviceCode)
+			if err != nil {
+				s.internalServerError(w, r, err)
+				return
+			}
+
+			lib.LogAudit(constants.AuditTokenIssuedDeviceCodeResponse, map[string]interface{}{
+				"codeId":       validateTokenRequestResult.CodeEntity.Id,
+				"deviceCodeId": validateTokenRequestResult.DeviceCode.Id,
+			})
+
+			w.Header().Set("Content-Type", "application/json")
+			w.Header().Set("Cache-Control", "no-store")
+			w.Header().Set("Pragma", "no-cache")
+			json.NewEncoder(w).Encode(tokenResp)
+			return
+
+		} else if input.GrantType == "urn:openid:params:grant-type:ciba" {
+
+			tokenResp, err := tokenIssuer.GenerateTokenResponseForAuthCode(r.Context(),
+				&core_token.GenerateTokenResponseForAuthCodeInput{
+					Code:                  validateTokenRequestResult.CodeEntity,
+					Resource:              validateTokenRequestResult.Resource,
+					AuthorizationDetails:  validateTokenRequestResult.AuthorizationDetails,
+					DPoPJkt:               dpopJkt,
+					CertificateThumbprint: certificateThumbprint,
+				})
+			if err != nil {
+				s.internalServerError(w, r, err)
+				return
+			}
+			validateTokenRequestResult.CodeEntity.Used = true
+			err = s.database.UpdateCode(nil, validateTokenRequestResult.CodeEntity)
+			if err != nil {
+				s.internalServerError(w, r, err)
+				return
+			}
+			validateTokenRequestResult.BackchannelAuthRequest.State = enums.BackchannelAuthRequestStateUsed.String()
+			err = s.database.UpdateBackchannelAuthRequest(nil, validateTokenRequestResult.BackchannelAuthRequest)
+			if err != nil {
+				s.internalServerError(w, r, err)
+				return
+			}
+
+			lib.LogAudit(constants.AuditTokenIssuedCIBAResponse, map[string]interface{}{
+				"codeId":                   validateTokenRequestResult.CodeEntity.Id,
+				"backchannelAuthRequestId": validateTokenRequestResult.BackchannelAuthRequest.Id,
+			})
+
+			w.Header().Set("Content-Type", "application/json")
+			w.Header().Set("Cache-Control", "no-store")
+			w.Header().Set("Pragma", "no-cache")
+			json.NewEncoder(w).Encode(tokenResp)
+			return
+
+		} else if input.GrantType == "urn:ietf:params:oauth:grant-type:token-exchange" {
+
+			tokenResp, err := tokenIssuer.GenerateTokenResponseForTokenExchange(r.Context(),
+				&core_token.GenerateTokenResponseForTokenExchangeInput{
+					Client:                validateTokenRequestResult.Client,
+					User:                  validateTokenRequestResult.User,
+					Scope:                 validateTokenRequestResult.Scope,
+					SubjectTokenInfo:      validateTokenRequestResult.SubjectTokenInfo,
+					DPoPJkt:               dpopJkt,
+					CertificateThumbprint: certificateThumbprint,
+				})
+			if err != nil {
+				s.internalServerError(w, r, err)
+				return
+			}
+
+			lib.LogAudit(constants.AuditTokenIssuedTokenExchangeResponse, map[string]interface{}{
+				"clientId": validateTokenRequestResult.Client.Id,
+				"userId":   validateTokenRequestResult.User.Id,
+			})
+
+			w.Header().Set("Content-Type", "application/json")
+			w.Header().Set("Cache-Control", "no-store")
+			w.Header().Set("Pragma", "no-cache")
+			json.NewEncoder(w).Encode(tokenResp)
+			return
+
 		} else if input.GrantType == "refresh_token" {
 			refreshToken := validateTokenRequestResult.RefreshToken
 			if refreshToken.Revoked {
@@ -93,10 +246,14 @@ func (s *Server) handleTokenPost(tokenIssuer tokenIssuer, tokenValidator tokenVa
 			}
 
 			input := &core_token.GenerateTokenForRefreshInput{
-				Code:             validateTokenRequestResult.CodeEntity,
-				ScopeRequested:   input.Scope,
-				RefreshToken:     validateTokenRequestResult.RefreshToken,
-				RefreshTokenInfo: validateTokenRequestResult.RefreshTokenInfo,
+				Code:                  validateTokenRequestResult.CodeEntity,
+				ScopeRequested:        input.Scope,
+				Resource:              validateTokenRequestResult.Resource,
+				RefreshToken:          validateTokenRequestResult.RefreshToken,
+				RefreshTokenInfo:      validateTokenRequestResult.RefreshTokenInfo,
+				AuthorizationDetails:  validateTokenRequestResult.AuthorizationDetails,
+				DPoPJkt:               dpopJkt,
+				CertificateThumbprint: certificateThumbprint,
 			}
 
 			tokenResp, err := tokenIssuer.GenerateTokenResponseForRefresh(r.Context(), input)
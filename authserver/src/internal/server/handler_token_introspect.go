@@ -0,0 +1,59 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/leodip/goiabada/internal/constants"
+	core_token "github.com/leodip/goiabada/internal/core/token"
+	core_validators "github.com/leodip/goiabada/internal/core/validators"
+	"github.com/leodip/goiabada/internal/lib"
+)
+
+func (s *Server) handleTokenIntrospectPost(tokenIntrospector tokenIntrospector, tokenValidator tokenValidator) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		r.ParseForm()
+
+		clientCredentials, err := s.getClientCredentials(r)
+		if err != nil {
+			s.jsonError(w, r, err)
+			return
+		}
+
+		input := core_validators.ValidateTokenIntrospectionRequestInput{
+			ClientId:            clientCredentials.ClientId,
+			ClientSecret:        clientCredentials.ClientSecret,
+			ClientSecretBasic:   clientCredentials.ClientSecretBasic,
+			ClientAssertionType: clientCredentials.ClientAssertionType,
+			ClientAssertion:     clientCredentials.ClientAssertion,
+			ClientCertificate:   clientCredentials.ClientCertificate,
+			Token:               r.PostForm.Get("token"),
+		}
+
+		client, err := tokenValidator.ValidateTokenIntrospectionRequest(r.Context(), &input)
+		if err != nil {
+			s.jsonError(w, r, err)
+			return
+		}
+
+		introspectionResp, err := tokenIntrospector.IntrospectToken(r.Context(), &core_token.IntrospectTokenInput{
+			Client: client,
+			Token:  input.Token,
+		})
+		if err != nil {
+			s.internalServerError(w, r, err)
+			return
+		}
+
+		lib.LogAudit(constants.AuditTokenIntrospected, map[string]interface{}{
+			"clientId": client.Id,
+			"active":   introspectionResp.Active,
+		})
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "no-store")
+		w.Header().Set("Pragma", "no-cache")
+		json.NewEncoder(w).Encode(introspectionResp)
+	}
+}
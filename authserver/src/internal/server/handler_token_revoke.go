@@ -0,0 +1,84 @@
+package server
+
+import (
+	"net/http"
+
+	"github.com/leodip/goiabada/internal/constants"
+	core_validators "github.com/leodip/goiabada/internal/core/validators"
+	"github.com/leodip/goiabada/internal/lib"
+)
+
+func (s *Server) handleTokenRevokePost(tokenValidator tokenValidator) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		r.ParseForm()
+
+		clientCredentials, err := s.getClientCredentials(r)
+		if err != nil {
+			s.jsonError(w, r, err)
+			return
+		}
+
+		input := core_validators.ValidateTokenRevocationRequestInput{
+			ClientId:            clientCredentials.ClientId,
+			ClientSecret:        clientCredentials.ClientSecret,
+			ClientSecretBasic:   clientCredentials.ClientSecretBasic,
+			ClientAssertionType: clientCredentials.ClientAssertionType,
+			ClientAssertion:     clientCredentials.ClientAssertion,
+			ClientCertificate:   clientCredentials.ClientCertificate,
+			Token:               r.PostForm.Get("token"),
+			TokenTypeHint:       r.PostForm.Get("token_type_hint"),
+		}
+
+		validateTokenRevocationRequestResult, err := tokenValidator.ValidateTokenRevocationRequest(r.Context(), &input)
+		if err != nil {
+			s.jsonError(w, r, err)
+			return
+		}
+
+		refreshToken := validateTokenRevocationRequestResult.RefreshToken
+		if refreshToken != nil {
+			// revoke the whole chain of refresh tokens, starting from the first one
+			refreshTokens, err := s.database.GetRefreshTokensByFirstRefreshTokenJti(nil, refreshToken.FirstRefreshTokenJti)
+			if err != nil {
+				s.internalServerError(w, r, err)
+				return
+			}
+
+			tx, err := s.database.BeginTransaction()
+			if err != nil {
+				s.internalServerError(w, r, err)
+				return
+			}
+			defer s.database.RollbackTransaction(tx)
+
+			for i := range refreshTokens {
+				if refreshTokens[i].Revoked {
+					continue
+				}
+				refreshTokens[i].Revoked = true
+				err = s.database.UpdateRefreshToken(tx, &refreshTokens[i])
+				if err != nil {
+					s.internalServerError(w, r, err)
+					return
+				}
+			}
+
+			err = s.database.CommitTransaction(tx)
+			if err != nil {
+				s.internalServerError(w, r, err)
+				return
+			}
+
+			lib.LogAudit(constants.AuditRevokedRefreshToken, map[string]interface{}{
+				"clientId":             validateTokenRevocationRequestResult.Client.Id,
+				"refreshTokenJti":      refreshToken.RefreshTokenJti,
+				"firstRefreshTokenJti": refreshToken.FirstRefreshTokenJti,
+			})
+		}
+
+		w.Header().Set("Cache-Control", "no-store")
+		w.Header().Set("Pragma", "no-cache")
+		w.WriteHeader(http.StatusOK)
+	}
+}
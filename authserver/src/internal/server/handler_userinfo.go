@@ -2,20 +2,20 @@ package server
 
 import (
 	"encoding/json"
-	"fmt"
 	"net/http"
-	"strings"
 
 	"github.com/pkg/errors"
 
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/leodip/goiabada/internal/common"
 	"github.com/leodip/goiabada/internal/constants"
+	"github.com/leodip/goiabada/internal/core"
 	"github.com/leodip/goiabada/internal/dtos"
+	"github.com/leodip/goiabada/internal/entities"
 	"github.com/leodip/goiabada/internal/lib"
 )
 
-func (s *Server) handleUserInfoGetPost() http.HandlerFunc {
+func (s *Server) handleUserInfoGetPost(subjectResolver subjectResolver, clientKeysResolver clientKeysResolver) http.HandlerFunc {
 
 	return func(w http.ResponseWriter, r *http.Request) {
 
@@ -59,7 +59,8 @@ func (s *Server) handleUserInfoGetPost() http.HandlerFunc {
 			return
 		}
 
-		user, err := s.database.GetUserBySubject(nil, sub)
+		// the sub can be pairwise, in which case it maps to the user through the sector identifier
+		user, err := subjectResolver.GetUserBySubject(sub)
 		if err != nil {
 			s.internalServerError(w, r, err)
 			return
@@ -102,44 +103,23 @@ func (s *Server) handleUserInfoGetPost() http.HandlerFunc {
 		}
 
 		claims := make(jwt.MapClaims)
-		claims["sub"] = user.Subject
-
-		addClaimIfNotEmpty := func(claims jwt.MapClaims, claimName string, claimValue string) {
-			if len(strings.TrimSpace(claimValue)) > 0 {
-				claims[claimName] = claimValue
+		claims["sub"] = sub
+
+		// the claims requested individually for the userinfo response, with the claims parameter
+		userInfoClaimsRequest := map[string]*dtos.ClaimRequest{}
+		if jwtToken.Claims["userinfo_claims"] != nil {
+			b, err := json.Marshal(jwtToken.Claims["userinfo_claims"])
+			if err == nil {
+				err = json.Unmarshal(b, &userInfoClaimsRequest)
 			}
-		}
-
-		if jwtToken.HasScope("profile") {
-			addClaimIfNotEmpty(claims, "name", user.GetFullName())
-			addClaimIfNotEmpty(claims, "given_name", user.GivenName)
-			addClaimIfNotEmpty(claims, "middle_name", user.MiddleName)
-			addClaimIfNotEmpty(claims, "family_name", user.FamilyName)
-			addClaimIfNotEmpty(claims, "nickname", user.Nickname)
-			addClaimIfNotEmpty(claims, "preferred_username", user.Username)
-			claims["profile"] = fmt.Sprintf("%v/account/profile", lib.GetBaseUrl())
-			addClaimIfNotEmpty(claims, "website", user.Website)
-			addClaimIfNotEmpty(claims, "gender", user.Gender)
-			if user.BirthDate.Valid {
-				claims["birthdate"] = user.BirthDate.Time.Format("2006-01-02")
+			if err != nil {
+				s.internalServerError(w, r, errors.Wrap(err, "unable to parse the userinfo_claims claim of the access token"))
+				return
 			}
-			addClaimIfNotEmpty(claims, "zoneinfo", user.ZoneInfo)
-			addClaimIfNotEmpty(claims, "locale", user.Locale)
-			claims["updated_at"] = user.UpdatedAt.Time.UTC().Unix()
-		}
-
-		if jwtToken.HasScope("email") {
-			addClaimIfNotEmpty(claims, "email", user.Email)
-			claims["email_verified"] = user.EmailVerified
 		}
 
-		if jwtToken.HasScope("address") && user.HasAddress() {
-			claims["address"] = user.GetAddressClaim()
-		}
-
-		if jwtToken.HasScope("phone") {
-			addClaimIfNotEmpty(claims, "phone_number", user.PhoneNumber)
-			claims["phone_number_verified"] = user.PhoneNumberVerified
+		for claimName, claimValue := range core.GetUserClaims(user, jwtToken.GetStringClaim("scope"), userInfoClaimsRequest) {
+			claims[claimName] = claimValue
 		}
 
 		if jwtToken.HasScope("groups") {
@@ -174,6 +154,52 @@ func (s *Server) handleUserInfoGetPost() http.HandlerFunc {
 			}
 		}
 
+		// the client can require the response to be a signed JWT, optionally nested in an encrypted one. The
+		// client_id claim of the access token identifies the client.
+		var client *entities.Client
+		clientIdentifier := jwtToken.GetStringClaim("client_id")
+		if len(clientIdentifier) > 0 {
+			client, err = s.database.GetClientByClientIdentifier(nil, clientIdentifier)
+			if err != nil {
+				s.internalServerError(w, r, err)
+				return
+			}
+		}
+
+		if client != nil {
+			signingAlgorithm := core.GetUserInfoSigningAlgorithm(client)
+			if len(signingAlgorithm) > 0 {
+				settings := r.Context().Value(common.ContextKeySettings).(*entities.Settings)
+				claims["iss"] = settings.Issuer
+				claims["aud"] = client.ClientIdentifier
+
+				signingKey, err := core.GetCurrentSigningKey(s.database, signingAlgorithm)
+				if err != nil {
+					s.internalServerError(w, r, err)
+					return
+				}
+				userInfo, err := signingKey.NewToken(claims).SignedString(signingKey.PrivateKey)
+				if err != nil {
+					s.internalServerError(w, r, errors.Wrap(err, "unable to sign the userinfo response"))
+					return
+				}
+
+				alg, enc := core.GetUserInfoEncryption(client)
+				if len(alg) > 0 {
+					userInfo, err = clientKeysResolver.EncryptToClient(r.Context(), client, userInfo, alg, enc)
+					if err != nil {
+						s.internalServerError(w, r, err)
+						return
+					}
+				}
+
+				w.Header().Set("Content-Type", "application/jwt")
+				w.WriteHeader(http.StatusOK)
+				w.Write([]byte(userInfo))
+				return
+			}
+		}
+
 		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusOK)
 		json.NewEncoder(w).Encode(claims)
@@ -3,48 +3,114 @@ package server
 import (
 	"encoding/json"
 	"net/http"
+	"slices"
 
 	"github.com/leodip/goiabada/internal/common"
+	"github.com/leodip/goiabada/internal/core"
+	core_validators "github.com/leodip/goiabada/internal/core/validators"
 	"github.com/leodip/goiabada/internal/entities"
+	"github.com/leodip/goiabada/internal/enums"
 	"github.com/leodip/goiabada/internal/lib"
 )
 
 func (s *Server) handleWellKnownOIDCConfigGet() http.HandlerFunc {
 
 	type oidcConfig struct {
-		Issuer                            string   `json:"issuer"`
-		AuthorizationEndpoint             string   `json:"authorization_endpoint"`
-		TokenEndpoint                     string   `json:"token_endpoint"`
-		UserInfoEndpoint                  string   `json:"userinfo_endpoint"`
-		EndSessionEndpoint                string   `json:"end_session_endpoint"`
-		JWKsURI                           string   `json:"jwks_uri"`
-		GrantTypesSupported               []string `json:"grant_types_supported"`
-		ResponseTypesSupported            []string `json:"response_types_supported"`
-		ACRValuesSupported                []string `json:"acr_values_supported"`
-		SubjectTypesSupported             []string `json:"subject_types_supported"`
-		IdTokenSigningAlgValuesSupported  []string `json:"id_token_signing_alg_values_supported"`
-		ScopesSupported                   []string `json:"scopes_supported"`
-		ClaimsSupported                   []string `json:"claims_supported"`
-		TokenEndpointAuthMethodsSupported []string `json:"token_endpoint_auth_methods_supported"`
-		CodeChallengeMethodsSupported     []string `json:"code_challenge_methods_supported"`
+		Issuer                                     string   `json:"issuer"`
+		AuthorizationEndpoint                      string   `json:"authorization_endpoint"`
+		TokenEndpoint                              string   `json:"token_endpoint"`
+		UserInfoEndpoint                           string   `json:"userinfo_endpoint"`
+		EndSessionEndpoint                         string   `json:"end_session_endpoint"`
+		CheckSessionIframe                         string   `json:"check_session_iframe"`
+		JWKsURI                                    string   `json:"jwks_uri"`
+		GrantTypesSupported                        []string `json:"grant_types_supported"`
+		ResponseTypesSupported                     []string `json:"response_types_supported"`
+		ResponseModesSupported                     []string `json:"response_modes_supported"`
+		AuthorizationSigningAlgValuesSupported     []string `json:"authorization_signing_alg_values_supported"`
+		ACRValuesSupported                         []string `json:"acr_values_supported"`
+		SubjectTypesSupported                      []string `json:"subject_types_supported"`
+		IdTokenSigningAlgValuesSupported           []string `json:"id_token_signing_alg_values_supported"`
+		IdTokenEncryptionAlgValuesSupported        []string `json:"id_token_encryption_alg_values_supported"`
+		IdTokenEncryptionEncValuesSupported        []string `json:"id_token_encryption_enc_values_supported"`
+		UserInfoSigningAlgValuesSupported          []string `json:"userinfo_signing_alg_values_supported"`
+		UserInfoEncryptionAlgValuesSupported       []string `json:"userinfo_encryption_alg_values_supported"`
+		UserInfoEncryptionEncValuesSupported       []string `json:"userinfo_encryption_enc_values_supported"`
+		ScopesSupported                            []string `json:"scopes_supported"`
+		ClaimsSupported                            []string `json:"claims_supported"`
+		ClaimsParameterSupported                   bool     `json:"claims_parameter_supported"`
+		TokenEndpointAuthMethodsSupported          []string `json:"token_endpoint_auth_methods_supported"`
+		TokenEndpointAuthSigningAlgValuesSupported []string `json:"token_endpoint_auth_signing_alg_values_supported"`
+		CodeChallengeMethodsSupported              []string `json:"code_challenge_methods_supported"`
+		IntrospectionEndpoint                      string   `json:"introspection_endpoint"`
+		IntrospectionEndpointAuthMethodsSupported  []string `json:"introspection_endpoint_auth_methods_supported"`
+		RevocationEndpoint                         string   `json:"revocation_endpoint"`
+		RevocationEndpointAuthMethodsSupported     []string `json:"revocation_endpoint_auth_methods_supported"`
+		DeviceAuthorizationEndpoint                string   `json:"device_authorization_endpoint"`
+		BackchannelAuthenticationEndpoint          string   `json:"backchannel_authentication_endpoint"`
+		BackchannelTokenDeliveryModesSupported     []string `json:"backchannel_token_delivery_modes_supported"`
+		BackchannelUserCodeParameterSupported      bool     `json:"backchannel_user_code_parameter_supported"`
+		PushedAuthorizationRequestEndpoint         string   `json:"pushed_authorization_request_endpoint"`
+		RegistrationEndpoint                       string   `json:"registration_endpoint"`
+		RequirePushedAuthorizationRequests         bool     `json:"require_pushed_authorization_requests"`
+		RequestParameterSupported                  bool     `json:"request_parameter_supported"`
+		RequestURIParameterSupported               bool     `json:"request_uri_parameter_supported"`
+		RequestObjectSigningAlgValuesSupported     []string `json:"request_object_signing_alg_values_supported"`
+		DPoPSigningAlgValuesSupported              []string `json:"dpop_signing_alg_values_supported"`
+		TLSClientCertificateBoundAccessTokens      bool     `json:"tls_client_certificate_bound_access_tokens"`
+		BackChannelLogoutSupported                 bool     `json:"backchannel_logout_supported"`
+		BackChannelLogoutSessionSupported          bool     `json:"backchannel_logout_session_supported"`
+		FrontChannelLogoutSupported                bool     `json:"frontchannel_logout_supported"`
+		FrontChannelLogoutSessionSupported         bool     `json:"frontchannel_logout_session_supported"`
+		PromptValuesSupported                      []string `json:"prompt_values_supported"`
+		AuthorizationDetailsTypesSupported         []string `json:"authorization_details_types_supported"`
 	}
 
 	return func(w http.ResponseWriter, r *http.Request) {
 
 		settings := r.Context().Value(common.ContextKeySettings).(*entities.Settings)
 
+		signingAlgorithms, err := core.GetActiveSigningAlgorithms(s.database)
+		if err != nil {
+			s.internalServerError(w, r, err)
+			return
+		}
+
+		authorizationDetailTypes, err := s.database.GetAllAuthorizationDetailTypes(nil)
+		if err != nil {
+			s.internalServerError(w, r, err)
+			return
+		}
+		authorizationDetailsTypesSupported := []string{}
+		for _, authorizationDetailType := range authorizationDetailTypes {
+			authorizationDetailsTypesSupported = append(authorizationDetailsTypesSupported, authorizationDetailType.Type)
+		}
+
+		clientAuthMethods := []string{"client_secret_basic", "client_secret_post", "client_secret_jwt", "private_key_jwt"}
+		if lib.IsMTLSEnabled() {
+			if lib.IsClientCertificateCAConfigured() {
+				clientAuthMethods = append(clientAuthMethods, "tls_client_auth")
+			}
+			clientAuthMethods = append(clientAuthMethods, "self_signed_tls_client_auth")
+		}
+
 		config := oidcConfig{
-			Issuer:                           settings.Issuer,
-			AuthorizationEndpoint:            lib.GetBaseUrl() + "/auth/authorize",
-			TokenEndpoint:                    lib.GetBaseUrl() + "/auth/token",
-			UserInfoEndpoint:                 lib.GetBaseUrl() + "/userinfo",
-			EndSessionEndpoint:               lib.GetBaseUrl() + "/auth/logout",
-			JWKsURI:                          lib.GetBaseUrl() + "/certs",
-			GrantTypesSupported:              []string{"authorization_code", "refresh_token", "client_credentials"},
-			ResponseTypesSupported:           []string{"code"},
-			ACRValuesSupported:               []string{"urn:goiabada:pwd", "urn:goiabada:pwd:otp_ifpossible", "urn:goiabada:pwd:otp_mandatory"},
-			SubjectTypesSupported:            []string{"public"},
-			IdTokenSigningAlgValuesSupported: []string{"RS256"},
+			Issuer:                               settings.Issuer,
+			AuthorizationEndpoint:                lib.GetBaseUrl() + "/auth/authorize",
+			TokenEndpoint:                        lib.GetBaseUrl() + "/auth/token",
+			UserInfoEndpoint:                     lib.GetBaseUrl() + "/userinfo",
+			EndSessionEndpoint:                   lib.GetBaseUrl() + "/auth/logout",
+			CheckSessionIframe:                   lib.GetBaseUrl() + "/auth/check-session-iframe",
+			JWKsURI:                              lib.GetBaseUrl() + "/certs",
+			GrantTypesSupported:                  []string{"authorization_code", "refresh_token", "client_credentials", "urn:ietf:params:oauth:grant-type:device_code", "urn:ietf:params:oauth:grant-type:token-exchange", "urn:openid:params:grant-type:ciba"},
+			ResponseTypesSupported:               []string{"code"},
+			ACRValuesSupported:                   []string{"urn:goiabada:pwd", "urn:goiabada:pwd:otp_ifpossible", "urn:goiabada:pwd:otp_mandatory"},
+			SubjectTypesSupported:                []string{enums.SubjectTypePublic.String(), enums.SubjectTypePairwise.String()},
+			IdTokenSigningAlgValuesSupported:     signingAlgorithms,
+			IdTokenEncryptionAlgValuesSupported:  lib.JWEAlgorithms,
+			IdTokenEncryptionEncValuesSupported:  lib.JWEEncryptionMethods,
+			UserInfoSigningAlgValuesSupported:    signingAlgorithms,
+			UserInfoEncryptionAlgValuesSupported: lib.JWEAlgorithms,
+			UserInfoEncryptionEncValuesSupported: lib.JWEEncryptionMethods,
 			ScopesSupported: []string{
 				"openid", "profile", "email", "address", "phone", "groups", "attributes", "offline_access"},
 			ClaimsSupported: []string{
@@ -57,8 +123,35 @@ func (s *Server) handleWellKnownOIDCConfigGet() http.HandlerFunc {
 				"groups",     // groups
 				"attributes", // attributes
 			},
-			TokenEndpointAuthMethodsSupported: []string{"client_secret_post"},
-			CodeChallengeMethodsSupported:     []string{"S256"},
+			ClaimsParameterSupported:                   true,
+			TokenEndpointAuthMethodsSupported:          clientAuthMethods,
+			TokenEndpointAuthSigningAlgValuesSupported: core_validators.TokenEndpointAuthSigningAlgValuesSupported,
+			CodeChallengeMethodsSupported:              []string{"S256"},
+			IntrospectionEndpoint:                      lib.GetBaseUrl() + "/auth/introspect",
+			IntrospectionEndpointAuthMethodsSupported:  clientAuthMethods,
+			RevocationEndpoint:                         lib.GetBaseUrl() + "/auth/revoke",
+			RevocationEndpointAuthMethodsSupported:     append(slices.Clone(clientAuthMethods), "none"),
+			DeviceAuthorizationEndpoint:                lib.GetBaseUrl() + "/auth/device_authorization",
+			BackchannelAuthenticationEndpoint:          lib.GetBaseUrl() + "/auth/bc-authorize",
+			BackchannelTokenDeliveryModesSupported:     []string{"poll"},
+			BackchannelUserCodeParameterSupported:      false,
+			PushedAuthorizationRequestEndpoint:         lib.GetBaseUrl() + "/auth/par",
+			RegistrationEndpoint:                       lib.GetBaseUrl() + "/connect/register",
+			RequirePushedAuthorizationRequests:         false,
+			RequestParameterSupported:                  true,
+			// request_uri only accepts references obtained from the PAR endpoint
+			RequestURIParameterSupported:           false,
+			RequestObjectSigningAlgValuesSupported: core_validators.RequestObjectSigningAlgValuesSupported,
+			DPoPSigningAlgValuesSupported:          core_validators.DPoPSigningAlgValuesSupported,
+			TLSClientCertificateBoundAccessTokens:  lib.IsMTLSEnabled(),
+			BackChannelLogoutSupported:             true,
+			BackChannelLogoutSessionSupported:      true,
+			FrontChannelLogoutSupported:            true,
+			FrontChannelLogoutSessionSupported:     true,
+			PromptValuesSupported:                  core_validators.PromptValuesSupported,
+			AuthorizationDetailsTypesSupported:     authorizationDetailsTypesSupported,
+			ResponseModesSupported:                 core_validators.ResponseModesSupported,
+			AuthorizationSigningAlgValuesSupported: []string{lib.DefaultSigningAlgorithm},
 		}
 
 		w.Header().Set("Content-Type", "application/json")
@@ -2,6 +2,7 @@ package server
 
 import (
 	"bytes"
+	"crypto/x509"
 	"encoding/json"
 	"fmt"
 	"html/template"
@@ -65,6 +66,7 @@ func (s *Server) includeLeftPanelImage(templateName string) bool {
 		"/account_register_activation.html",
 		"/account_register_activation_result.html",
 		"/logout_consent.html",
+		"/device.html",
 	}
 
 	return slices.Contains(templates, templateName)
@@ -524,3 +526,99 @@ func (s *Server) getRandomStaticFile(path string) (string, error) {
 	filename := randomFile.Name()
 	return filepath.Join("/static", path, filename), nil
 }
+
+type clientCredentials struct {
+	ClientId            string
+	ClientSecret        string
+	ClientSecretBasic   bool
+	ClientAssertionType string
+	ClientAssertion     string
+	ClientCertificate   *x509.Certificate
+}
+
+// getClientCredentials reads the credentials of the client from the request body, or from the
+// Authorization header when the client uses HTTP Basic authentication (client_secret_basic).
+// The TLS client certificate, if any, is included as well (RFC 8705).
+// The request must be parsed (r.ParseForm) before calling this.
+func (s *Server) getClientCredentials(r *http.Request) (*clientCredentials, error) {
+
+	clientCertificate, err := lib.GetClientCertificate(r)
+	if err != nil {
+		slog.Warn(fmt.Sprintf("unable to read the client certificate: %+v", err))
+		return nil, customerrors.NewValidationError("invalid_request", "The client certificate could not be parsed.")
+	}
+
+	credentials := &clientCredentials{
+		ClientId:            r.PostForm.Get("client_id"),
+		ClientSecret:        r.PostForm.Get("client_secret"),
+		ClientAssertionType: r.PostForm.Get("client_assertion_type"),
+		ClientAssertion:     r.PostForm.Get("client_assertion"),
+		ClientCertificate:   clientCertificate,
+	}
+
+	authHeader := r.Header.Get("Authorization")
+	if len(authHeader) < 6 || !strings.EqualFold(authHeader[:6], "Basic ") {
+		return credentials, nil
+	}
+
+	if r.PostForm.Has("client_secret") || r.PostForm.Has("client_assertion") || r.PostForm.Has("client_assertion_type") {
+		return nil, customerrors.NewValidationError("invalid_request", "The client must use only one authentication method. Please send the client credentials either in the Authorization header or in the request body, but not both.")
+	}
+
+	username, password, ok := r.BasicAuth()
+	if !ok {
+		return nil, customerrors.NewValidationError("invalid_client", "The Authorization header is invalid. The client credentials could not be decoded.")
+	}
+
+	// the client identifier and secret are form-urlencoded before being base64 encoded (RFC 6749, section 2.3.1)
+	clientId, err := url.QueryUnescape(username)
+	if err != nil {
+		return nil, customerrors.NewValidationError("invalid_client", "The Authorization header is invalid. The client credentials could not be decoded.")
+	}
+	clientSecret, err := url.QueryUnescape(password)
+	if err != nil {
+		return nil, customerrors.NewValidationError("invalid_client", "The Authorization header is invalid. The client credentials could not be decoded.")
+	}
+
+	if len(credentials.ClientId) > 0 && credentials.ClientId != clientId {
+		return nil, customerrors.NewValidationError("invalid_request", "The client_id parameter does not match the client identifier in the Authorization header.")
+	}
+
+	credentials.ClientId = clientId
+	credentials.ClientSecret = clientSecret
+	credentials.ClientSecretBasic = true
+	return credentials, nil
+}
+
+// setBrowserStateCookie stores the OP browser state of the user session. The cookie is not HttpOnly,
+// because it's read by the check session iframe, that is embedded in the pages of the clients.
+func (s *Server) setBrowserStateCookie(w http.ResponseWriter, sessionIdentifier string) error {
+	browserState, err := lib.GetBrowserState(sessionIdentifier)
+	if err != nil {
+		return err
+	}
+
+	cookie := &http.Cookie{
+		Name:     common.BrowserStateCookieName,
+		Value:    browserState,
+		Path:     "/",
+		SameSite: http.SameSiteLaxMode,
+	}
+	if strings.HasPrefix(lib.GetBaseUrl(), "https://") {
+		// the iframe runs in a third-party context
+		cookie.Secure = true
+		cookie.SameSite = http.SameSiteNoneMode
+	}
+	http.SetCookie(w, cookie)
+	return nil
+}
+
+func (s *Server) clearBrowserStateCookie(w http.ResponseWriter) {
+	cookie := &http.Cookie{
+		Name:    common.BrowserStateCookieName,
+		Expires: time.Now().AddDate(0, 0, -1),
+		MaxAge:  -1,
+		Path:    "/",
+	}
+	http.SetCookie(w, cookie)
+}
@@ -2,6 +2,7 @@ package server
 
 import (
 	"context"
+	"net/url"
 
 	"github.com/leodip/goiabada/internal/core"
 	core_authorize "github.com/leodip/goiabada/internal/core/authorize"
@@ -19,29 +20,63 @@ type otpSecretGenerator interface {
 
 type tokenIssuer interface {
 	GenerateTokenResponseForAuthCode(ctx context.Context, input *core_token.GenerateTokenResponseForAuthCodeInput) (*dtos.TokenResponse, error)
-	GenerateTokenResponseForClientCred(ctx context.Context, client *entities.Client, scope string) (*dtos.TokenResponse, error)
+	GenerateTokenResponseForClientCred(ctx context.Context, client *entities.Client, scope string, authorizationDetails dtos.AuthorizationDetails, dpopJkt string, certificateThumbprint string) (*dtos.TokenResponse, error)
 	GenerateTokenResponseForRefresh(ctx context.Context, input *core_token.GenerateTokenForRefreshInput) (*dtos.TokenResponse, error)
+	GenerateTokenResponseForTokenExchange(ctx context.Context, input *core_token.GenerateTokenResponseForTokenExchangeInput) (*dtos.TokenResponse, error)
 }
 
 type authorizeValidator interface {
 	ValidateScopes(ctx context.Context, scope string) error
 	ValidateClientAndRedirectURI(ctx context.Context, input *core_validators.ValidateClientAndRedirectURIInput) error
 	ValidateRequest(ctx context.Context, input *core_validators.ValidateRequestInput) error
+	ValidateIdTokenHint(ctx context.Context, input *core_validators.ValidateIdTokenHintInput) (string, error)
+	ValidateResources(ctx context.Context, input *core_validators.ValidateResourcesInput) error
+	ValidateClaimsRequest(ctx context.Context, input *core_validators.ValidateClaimsRequestInput) (*dtos.ClaimsRequest, error)
+	ValidateAuthorizationDetails(ctx context.Context, authorizationDetails string) (dtos.AuthorizationDetails, error)
+	ValidateRequestURI(ctx context.Context, input *core_validators.ValidateRequestURIInput) (*entities.PushedAuthRequest, error)
+	ValidateRequestObject(ctx context.Context, input *core_validators.ValidateRequestObjectInput) (url.Values, error)
+}
+
+type dpopValidator interface {
+	ValidateDPoPProof(ctx context.Context, input *core_validators.ValidateDPoPProofInput) (string, error)
 }
 
 type codeIssuer interface {
 	CreateAuthCode(ctx context.Context, input *core_authorize.CreateCodeInput) (*entities.Code, error)
 }
 
+type deviceCodeIssuer interface {
+	CreateDeviceCode(ctx context.Context, input *core_authorize.CreateDeviceCodeInput) (*entities.DeviceCode, error)
+}
+
+type backchannelAuthRequestIssuer interface {
+	CreateBackchannelAuthRequest(ctx context.Context, input *core_authorize.CreateBackchannelAuthRequestInput) (*entities.BackchannelAuthRequest, error)
+}
+
+type pushedAuthRequestIssuer interface {
+	CreatePushedAuthRequest(ctx context.Context, input *core_authorize.CreatePushedAuthRequestInput) (*entities.PushedAuthRequest, error)
+}
+
 type loginManager interface {
 	HasValidUserSession(ctx context.Context, userSession *entities.UserSession, requestedMaxAgeInSeconds *int) bool
 
+	MustAuthenticate(ctx context.Context, input *core_authorize.MustAuthenticateInput) bool
+
 	MustPerformOTPAuth(ctx context.Context, client *entities.Client, userSession *entities.UserSession,
 		targetAcrLevel enums.AcrLevel) bool
 }
 
 type tokenValidator interface {
 	ValidateTokenRequest(ctx context.Context, input *core_validators.ValidateTokenRequestInput) (*core_validators.ValidateTokenRequestResult, error)
+	ValidateDeviceAuthorizationRequest(ctx context.Context, input *core_validators.ValidateDeviceAuthorizationRequestInput) (*entities.Client, error)
+	ValidateBackchannelAuthRequest(ctx context.Context, input *core_validators.ValidateBackchannelAuthRequestInput) (*core_validators.ValidateBackchannelAuthRequestResult, error)
+	ValidatePushedAuthRequest(ctx context.Context, input *core_validators.ValidatePushedAuthRequestInput) (*entities.Client, error)
+	ValidateTokenIntrospectionRequest(ctx context.Context, input *core_validators.ValidateTokenIntrospectionRequestInput) (*entities.Client, error)
+	ValidateTokenRevocationRequest(ctx context.Context, input *core_validators.ValidateTokenRevocationRequestInput) (*core_validators.ValidateTokenRevocationRequestResult, error)
+}
+
+type tokenIntrospector interface {
+	IntrospectToken(ctx context.Context, input *core_token.IntrospectTokenInput) (*dtos.TokenIntrospectionResponse, error)
 }
 
 type profileValidator interface {
@@ -58,6 +93,10 @@ type emailSender interface {
 	SendEmail(ctx context.Context, input *core_senders.SendEmailInput) error
 }
 
+type backChannelLogoutSender interface {
+	SendLogoutTokens(ctx context.Context, userSession *entities.UserSession) error
+}
+
 type addressValidator interface {
 	ValidateAddress(ctx context.Context, input *core_validators.ValidateAddressInput) error
 }
@@ -85,3 +124,23 @@ type inputSanitizer interface {
 type userCreator interface {
 	CreateUser(ctx context.Context, input *core.CreateUserInput) (*entities.User, error)
 }
+
+type clientRegistrationValidator interface {
+	ValidateClientMetadata(metadata *dtos.ClientMetadata) error
+}
+
+type clientRegistrar interface {
+	RegisterClient(ctx context.Context, metadata *dtos.ClientMetadata) (*dtos.ClientRegistrationResponse, error)
+	UpdateClientRegistration(ctx context.Context, client *entities.Client, metadata *dtos.ClientMetadata) (*dtos.ClientRegistrationResponse, error)
+	GetClientRegistration(ctx context.Context, client *entities.Client) (*dtos.ClientRegistrationResponse, error)
+}
+
+type subjectResolver interface {
+	GetSubject(user *entities.User, client *entities.Client) (string, error)
+	GetUserBySubject(subject string) (*entities.User, error)
+	ValidatePairwiseSettings(ctx context.Context, sectorIdentifierURI string, redirectURIs []string) error
+}
+
+type clientKeysResolver interface {
+	EncryptToClient(ctx context.Context, client *entities.Client, signedJWT string, alg string, enc string) (string, error)
+}
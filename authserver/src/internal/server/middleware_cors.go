@@ -15,7 +15,7 @@ func MiddlewareCors(database data.Database) func(next http.Handler) http.Handler
 			if r.URL.Path == "/.well-known/openid-configuration" || r.URL.Path == "/certs" {
 				// always allow the discovery URL
 				return true
-			} else if r.URL.Path == "/auth/token" || r.URL.Path == "/auth/logout" || r.URL.Path == "/userinfo" {
+			} else if r.URL.Path == "/auth/token" || r.URL.Path == "/auth/revoke" || r.URL.Path == "/auth/logout" || r.URL.Path == "/userinfo" {
 				// allow when the web origin of the request matches a web origin in the database
 				webOrigins, err := database.GetAllWebOrigins(nil)
 				if err != nil {
@@ -30,7 +30,7 @@ func MiddlewareCors(database data.Database) func(next http.Handler) http.Handler
 			}
 			return false
 		},
-		AllowedHeaders: []string{"Accept", "Authorization", "Content-Type", "X-CSRF-Token"},
+		AllowedHeaders: []string{"Accept", "Authorization", "Content-Type", "X-CSRF-Token", "DPoP"},
 		AllowedMethods: []string{"GET", "POST", "OPTIONS"},
 	})
 }
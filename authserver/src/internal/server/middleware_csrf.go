@@ -16,7 +16,13 @@ func MiddlewareSkipCsrf() func(next http.Handler) http.Handler {
 			if strings.HasPrefix(r.URL.Path, "/static") ||
 				strings.HasPrefix(r.URL.Path, "/userinfo") ||
 				strings.HasPrefix(r.URL.Path, "/auth/token") ||
-				strings.HasPrefix(r.URL.Path, "/auth/callback") {
+				strings.HasPrefix(r.URL.Path, "/auth/introspect") ||
+				strings.HasPrefix(r.URL.Path, "/auth/revoke") ||
+				strings.HasPrefix(r.URL.Path, "/auth/device_authorization") ||
+				strings.HasPrefix(r.URL.Path, "/auth/par") ||
+				strings.HasPrefix(r.URL.Path, "/auth/bc-authorize") ||
+				strings.HasPrefix(r.URL.Path, "/auth/callback") ||
+				strings.HasPrefix(r.URL.Path, "/connect/register") {
 				skip = true
 			}
 			if skip {
@@ -5,10 +5,12 @@ import (
 	"fmt"
 	"github.com/gorilla/sessions"
 	"net/http"
+	"strings"
 
 	"github.com/leodip/goiabada/internal/common"
 	"github.com/leodip/goiabada/internal/constants"
 	core_token "github.com/leodip/goiabada/internal/core/token"
+	core_validators "github.com/leodip/goiabada/internal/core/validators"
 	"github.com/leodip/goiabada/internal/dtos"
 	"github.com/leodip/goiabada/internal/lib"
 )
@@ -42,13 +44,36 @@ func MiddlewareJwtSessionToContext(next http.Handler, sessionStore sessions.Stor
 }
 
 func MiddlewareJwtAuthorizationHeaderToContext(next http.Handler, sessionStore sessions.Store,
-	tokenParser *core_token.TokenParser) http.HandlerFunc {
+	tokenParser *core_token.TokenParser, dpopValidator *core_validators.DPoPValidator) http.HandlerFunc {
 
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		ctx := r.Context()
 
 		const BEARER_SCHEMA = "Bearer "
+		const DPOP_SCHEMA = "DPoP "
 		authHeader := r.Header.Get("Authorization")
+
+		if strings.HasPrefix(authHeader, DPOP_SCHEMA) {
+			// DPoP-bound access token (RFC 9449): the proof must be signed with the key the token is bound to
+			tokenStr := authHeader[len(DPOP_SCHEMA):]
+
+			token, err := tokenParser.ParseToken(ctx, tokenStr, true)
+			if err == nil && len(token.GetConfirmationClaim("jkt")) > 0 && len(r.Header.Values("DPoP")) == 1 {
+				jkt, err := dpopValidator.ValidateDPoPProof(ctx, &core_validators.ValidateDPoPProofInput{
+					Proof:       r.Header.Get("DPoP"),
+					HttpMethod:  r.Method,
+					HttpURI:     lib.GetBaseUrl() + r.URL.Path,
+					AccessToken: tokenStr,
+				})
+				if err == nil && jkt == token.GetConfirmationClaim("jkt") && matchesClientCertificate(r, token) {
+					ctx = context.WithValue(ctx, common.ContextKeyJwtInfo, *token)
+				}
+			}
+
+			next.ServeHTTP(w, r.WithContext(ctx))
+			return
+		}
+
 		if len(authHeader) < len(BEARER_SCHEMA) {
 			next.ServeHTTP(w, r.WithContext(ctx))
 			return
@@ -56,7 +81,8 @@ func MiddlewareJwtAuthorizationHeaderToContext(next http.Handler, sessionStore s
 		tokenStr := authHeader[len(BEARER_SCHEMA):]
 
 		token, err := tokenParser.ParseToken(ctx, tokenStr, true)
-		if err == nil {
+		// a DPoP-bound access token can't be used as a bearer token
+		if err == nil && len(token.GetConfirmationClaim("jkt")) == 0 && matchesClientCertificate(r, token) {
 			ctx = context.WithValue(ctx, common.ContextKeyJwtInfo, *token)
 		}
 
@@ -64,6 +90,20 @@ func MiddlewareJwtAuthorizationHeaderToContext(next http.Handler, sessionStore s
 	})
 }
 
+// matchesClientCertificate checks that a certificate-bound access token (RFC 8705) is presented
+// together with the client certificate it is bound to. Tokens that are not bound always match.
+func matchesClientCertificate(r *http.Request, token *dtos.JwtToken) bool {
+	certificateThumbprint := token.GetConfirmationClaim("x5t#S256")
+	if len(certificateThumbprint) == 0 {
+		return true
+	}
+	clientCertificate, err := lib.GetClientCertificate(r)
+	if err != nil || clientCertificate == nil {
+		return false
+	}
+	return lib.GetCertificateThumbprint(clientCertificate) == certificateThumbprint
+}
+
 func MiddlewareRequiresScope(next http.Handler, server *Server, clientIdentifier string,
 	scopesAnyOf []string) http.HandlerFunc {
 
@@ -16,10 +16,12 @@ import (
 
 func (s *Server) initRoutes() {
 
-	authorizeValidator := core_validators.NewAuthorizeValidator(s.database)
+	clientKeysResolver := core.NewClientKeysResolver()
+	subjectResolver := core.NewSubjectResolver(s.database)
+	authorizeValidator := core_validators.NewAuthorizeValidator(s.database, clientKeysResolver, subjectResolver)
 	tokenParser := core_token.NewTokenParser(s.database)
 	permissionChecker := core.NewPermissionChecker(s.database)
-	tokenValidator := core_validators.NewTokenValidator(s.database, tokenParser, permissionChecker)
+	tokenValidator := core_validators.NewTokenValidator(s.database, tokenParser, permissionChecker, clientKeysResolver, subjectResolver)
 	profileValidator := core_validators.NewProfileValidator(s.database)
 	emailValidator := core_validators.NewEmailValidator(s.database)
 	addressValidator := core_validators.NewAddressValidator(s.database)
@@ -29,12 +31,19 @@ func (s *Server) initRoutes() {
 	inputSanitizer := core.NewInputSanitizer()
 
 	codeIssuer := core_authorize.NewCodeIssuer(s.database)
+	deviceCodeIssuer := core_authorize.NewDeviceCodeIssuer(s.database)
+	backchannelAuthRequestIssuer := core_authorize.NewBackchannelAuthRequestIssuer(s.database)
+	pushedAuthRequestIssuer := core_authorize.NewPushedAuthRequestIssuer(s.database)
 	loginManager := core_authorize.NewLoginManager(codeIssuer)
 	otpSecretGenerator := core.NewOTPSecretGenerator()
-	tokenIssuer := core_token.NewTokenIssuer(s.database, tokenParser)
+	tokenIssuer := core_token.NewTokenIssuer(s.database, tokenParser, subjectResolver, clientKeysResolver)
+	tokenIntrospector := core_token.NewTokenIntrospector(s.database, tokenParser)
 	emailSender := core_senders.NewEmailSender(s.database)
 	smsSender := core_senders.NewSMSSender(s.database)
+	backChannelLogoutSender := core_senders.NewBackChannelLogoutSender(s.database, subjectResolver)
 	userCreator := core.NewUserCreator(s.database)
+	clientRegistrationValidator := core_validators.NewClientRegistrationValidator()
+	clientRegistrar := core.NewClientRegistrar(s.database, inputSanitizer)
 
 	s.router.NotFound(s.handleNotFoundGet())
 	s.router.Get("/", s.handleIndexGet())
@@ -45,8 +54,10 @@ func (s *Server) initRoutes() {
 	s.router.Post("/reset-password", s.handleResetPasswordPost(passwordValidator))
 	s.router.Get("/.well-known/openid-configuration", s.handleWellKnownOIDCConfigGet())
 	s.router.Get("/certs", s.handleCertsGet())
-	s.router.With(s.jwtAuthorizationHeaderToContext).Get("/userinfo", s.handleUserInfoGetPost())
-	s.router.With(s.jwtAuthorizationHeaderToContext).Post("/userinfo", s.handleUserInfoGetPost())
+	s.router.With(s.jwtAuthorizationHeaderToContext).Get("/userinfo", s.handleUserInfoGetPost(subjectResolver, clientKeysResolver))
+	s.router.With(s.jwtAuthorizationHeaderToContext).Post("/userinfo", s.handleUserInfoGetPost(subjectResolver, clientKeysResolver))
+	s.router.With(s.jwtSessionToContext).Get("/device", s.handleDeviceGet())
+	s.router.With(s.jwtSessionToContext).Post("/device", s.handleDevicePost(loginManager))
 	s.router.Get("/health", s.handleHealthCheckGet())
 	s.router.Get("/test", s.handleRequestTestGet())
 
@@ -58,11 +69,22 @@ func (s *Server) initRoutes() {
 		r.Post("/otp", s.handleAuthOtpPost())
 		r.Get("/consent", s.handleConsentGet(codeIssuer, permissionChecker))
 		r.Post("/consent", s.handleConsentPost(codeIssuer))
-		r.Post("/token", s.handleTokenPost(tokenIssuer, tokenValidator))
+		r.Post("/token", s.handleTokenPost(tokenIssuer, tokenValidator, s.dpopValidator))
+		r.Post("/introspect", s.handleTokenIntrospectPost(tokenIntrospector, tokenValidator))
+		r.Post("/revoke", s.handleTokenRevokePost(tokenValidator))
+		r.Post("/device_authorization", s.handleDeviceAuthorizationPost(deviceCodeIssuer, tokenValidator, authorizeValidator))
+		r.Post("/bc-authorize", s.handleBackchannelAuthorizePost(backchannelAuthRequestIssuer, tokenValidator, authorizeValidator))
+		r.Post("/par", s.handlePushedAuthPost(pushedAuthRequestIssuer, tokenValidator, authorizeValidator))
 		r.Post("/callback", s.handleAuthCallbackPost(tokenIssuer, tokenValidator))
-		r.Get("/logout", s.handleAccountLogoutGet())
-		r.Post("/logout", s.handleAccountLogoutPost())
-		r.Post("/logout", s.handleAccountLogoutPost())
+		r.Get("/check-session-iframe", s.handleCheckSessionIframeGet())
+		r.Get("/logout", s.handleAccountLogoutGet(subjectResolver))
+		r.Post("/logout", s.handleAccountLogoutPost(backChannelLogoutSender))
+	})
+	s.router.Route("/connect", func(r chi.Router) {
+		r.Post("/register", s.handleClientRegistrationPost(clientRegistrationValidator, clientRegistrar, subjectResolver))
+		r.Get("/register/{clientId}", s.handleClientRegistrationGet(clientRegistrar))
+		r.Put("/register/{clientId}", s.handleClientRegistrationPut(clientRegistrationValidator, clientRegistrar, subjectResolver))
+		r.Delete("/register/{clientId}", s.handleClientRegistrationDelete())
 	})
 	s.router.Route("/account", func(r chi.Router) {
 		r.With(s.jwtSessionToContext).With(s.requiresAccountScope).Get("/", func(w http.ResponseWriter, r *http.Request) {
@@ -87,8 +109,10 @@ func (s *Server) initRoutes() {
 		r.With(s.jwtSessionToContext).With(s.requiresAccountScope).Post("/otp", s.handleAccountOtpPost())
 		r.With(s.jwtSessionToContext).With(s.requiresAccountScope).Get("/manage-consents", s.handleAccountManageConsentsGet())
 		r.With(s.jwtSessionToContext).With(s.requiresAccountScope).Post("/manage-consents", s.handleAccountManageConsentsRevokePost())
+		r.With(s.jwtSessionToContext).With(s.requiresAccountScope).Get("/authentication-requests", s.handleAccountAuthenticationRequestsGet(loginManager))
+		r.With(s.jwtSessionToContext).With(s.requiresAccountScope).Post("/authentication-requests", s.handleAccountAuthenticationRequestsPost(codeIssuer, loginManager))
 		r.With(s.jwtSessionToContext).With(s.requiresAccountScope).Get("/sessions", s.handleAccountSessionsGet())
-		r.With(s.jwtSessionToContext).With(s.requiresAccountScope).Post("/sessions", s.handleAccountSessionsEndSesssionPost())
+		r.With(s.jwtSessionToContext).With(s.requiresAccountScope).Post("/sessions", s.handleAccountSessionsEndSesssionPost(backChannelLogoutSender))
 		r.Get("/register", s.handleAccountRegisterGet())
 		r.Post("/register", s.handleAccountRegisterPost(userCreator, emailValidator, passwordValidator, emailSender))
 		r.Get("/activate", s.handleAccountActivateGet(userCreator, emailSender))
@@ -102,17 +126,21 @@ func (s *Server) initRoutes() {
 		r.Get("/clients/{clientId}/settings", s.handleAdminClientSettingsGet())
 		r.Post("/clients/{clientId}/settings", s.handleAdminClientSettingsPost(identifierValidator, inputSanitizer))
 		r.Get("/clients/{clientId}/tokens", s.handleAdminClientTokensGet())
-		r.Post("/clients/{clientId}/tokens", s.handleAdminClientTokensPost())
+		r.Post("/clients/{clientId}/tokens", s.handleAdminClientTokensPost(subjectResolver))
 		r.Get("/clients/{clientId}/authentication", s.handleAdminClientAuthenticationGet())
 		r.Post("/clients/{clientId}/authentication", s.handleAdminClientAuthenticationPost())
+		r.Get("/clients/{clientId}/keys", s.handleAdminClientKeysGet())
+		r.Post("/clients/{clientId}/keys", s.handleAdminClientKeysPost())
+		r.Get("/clients/{clientId}/logout", s.handleAdminClientLogoutGet())
+		r.Post("/clients/{clientId}/logout", s.handleAdminClientLogoutPost())
 		r.Get("/clients/{clientId}/oauth2-flows", s.handleAdminClientOAuth2Get())
 		r.Post("/clients/{clientId}/oauth2-flows", s.handleAdminClientOAuth2Post())
 		r.Get("/clients/{clientId}/redirect-uris", s.handleAdminClientRedirectURIsGet())
-		r.Post("/clients/{clientId}/redirect-uris", s.handleAdminClientRedirectURIsPost())
+		r.Post("/clients/{clientId}/redirect-uris", s.handleAdminClientRedirectURIsPost(subjectResolver))
 		r.Get("/clients/{clientId}/web-origins", s.handleAdminClientWebOriginsGet())
 		r.Post("/clients/{clientId}/web-origins", s.handleAdminClientWebOriginsPost())
 		r.Get("/clients/{clientId}/user-sessions", s.handleAdminClientUserSessionsGet())
-		r.Post("/clients/{clientId}/user-sessions/delete", s.handleAdminClientUserSessionsPost())
+		r.Post("/clients/{clientId}/user-sessions/delete", s.handleAdminClientUserSessionsPost(backChannelLogoutSender))
 		r.Get("/clients/{clientId}/permissions", s.handleAdminClientPermissionsGet())
 		r.Post("/clients/{clientId}/permissions", s.handleAdminClientPermissionsPost())
 		r.Get("/clients/generate-new-secret", s.handleAdminClientGenerateNewSecretGet())
@@ -135,6 +163,12 @@ func (s *Server) initRoutes() {
 		r.Get("/resources/{resourceId}/groups-with-permission", s.handleAdminResourceGroupsWithPermissionGet())
 		r.Post("/resources/{resourceId}/groups-with-permission/add/{groupId}/{permissionId}", s.handleAdminResourceGroupsWithPermissionAddPermissionPost())
 		r.Post("/resources/{resourceId}/groups-with-permission/remove/{groupId}/{permissionId}", s.handleAdminResourceGroupsWithPermissionRemovePermissionPost())
+		r.Get("/resources/{resourceId}/authorization-details", s.handleAdminResourceAuthorizationDetailsGet())
+		r.Get("/resources/{resourceId}/authorization-details/add", s.handleAdminResourceAuthorizationDetailsAddGet())
+		r.Post("/resources/{resourceId}/authorization-details/add", s.handleAdminResourceAuthorizationDetailsAddPost(identifierValidator, inputSanitizer))
+		r.Get("/resources/{resourceId}/authorization-details/edit/{authorizationDetailTypeId}", s.handleAdminResourceAuthorizationDetailsEditGet())
+		r.Post("/resources/{resourceId}/authorization-details/edit/{authorizationDetailTypeId}", s.handleAdminResourceAuthorizationDetailsEditPost(identifierValidator, inputSanitizer))
+		r.Post("/resources/{resourceId}/authorization-details/remove/{authorizationDetailTypeId}", s.handleAdminResourceAuthorizationDetailsRemovePost())
 		r.Get("/resources/{resourceId}/delete", s.handleAdminResourceDeleteGet())
 		r.Post("/resources/{resourceId}/delete", s.handleAdminResourceDeletePost())
 		r.Get("/resources/new", s.handleAdminResourceNewGet())
@@ -177,7 +211,7 @@ func (s *Server) initRoutes() {
 		r.Get("/users/{userId}/consents", s.handleAdminUserConsentsGet())
 		r.Post("/users/{userId}/consents", s.handleAdminUserConsentsPost())
 		r.Get("/users/{userId}/sessions", s.handleAdminUserSessionsGet())
-		r.Post("/users/{userId}/sessions", s.handleAdminUserSessionsPost())
+		r.Post("/users/{userId}/sessions", s.handleAdminUserSessionsPost(backChannelLogoutSender))
 		r.Get("/users/{userId}/attributes", s.handleAdminUserAttributesGet())
 		r.Get("/users/{userId}/attributes/add", s.handleAdminUserAttributesAddGet())
 		r.Post("/users/{userId}/attributes/add", s.handleAdminUserAttributesAddPost(identifierValidator, inputSanitizer))
@@ -204,6 +238,9 @@ func (s *Server) initRoutes() {
 		r.Get("/settings/keys", s.handleAdminSettingsKeysGet())
 		r.Post("/settings/keys/rotate", s.handleAdminSettingsKeysRotatePost())
 		r.Post("/settings/keys/revoke", s.handleAdminSettingsKeysRevokePost())
+		r.Get("/settings/client-registration", s.handleAdminSettingsClientRegistrationGet())
+		r.Post("/settings/client-registration", s.handleAdminSettingsClientRegistrationPost(inputSanitizer))
+		r.Post("/settings/client-registration/delete", s.handleAdminSettingsClientRegistrationDeletePost())
 		r.Get("/settings/email", s.handleAdminSettingsEmailGet())
 		r.Post("/settings/email", s.handleAdminSettingsEmailPost(emailValidator, inputSanitizer))
 		r.Get("/settings/email/send-test-email", s.handleAdminSettingsEmailSendTestGet())
@@ -218,7 +255,7 @@ func (s *Server) jwtSessionToContext(handler http.Handler) http.Handler {
 }
 
 func (s *Server) jwtAuthorizationHeaderToContext(handler http.Handler) http.Handler {
-	return MiddlewareJwtAuthorizationHeaderToContext(handler, s.sessionStore, s.tokenParser)
+	return MiddlewareJwtAuthorizationHeaderToContext(handler, s.sessionStore, s.tokenParser, s.dpopValidator)
 }
 
 func (s *Server) requiresAdminScope(handler http.Handler) http.Handler {
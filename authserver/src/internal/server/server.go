@@ -1,6 +1,7 @@
 package server
 
 import (
+	"crypto/tls"
 	"fmt"
 	"io/fs"
 	"log"
@@ -17,6 +18,7 @@ import (
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
 	core_token "github.com/leodip/goiabada/internal/core/token"
+	core_validators "github.com/leodip/goiabada/internal/core/validators"
 	"github.com/leodip/goiabada/internal/data"
 	"github.com/leodip/goiabada/internal/entities"
 	"github.com/leodip/goiabada/internal/lib"
@@ -30,6 +32,8 @@ type Server struct {
 	sessionStore sessions.Store
 	tokenParser  *core_token.TokenParser
 
+	dpopValidator *core_validators.DPoPValidator
+
 	staticFS   fs.FS
 	templateFS fs.FS
 }
@@ -41,6 +45,8 @@ func NewServer(router *chi.Mux, database data.Database, sessionStore sessions.St
 		database:     database,
 		sessionStore: sessionStore,
 		tokenParser:  core_token.NewTokenParser(database),
+
+		dpopValidator: core_validators.NewDPoPValidator(database),
 	}
 
 	if envVar := viper.GetString("StaticDir"); len(envVar) == 0 {
@@ -98,6 +104,19 @@ func (s *Server) Start(settings *entities.Settings) {
 			slog.Warn(fmt.Sprintf("https is enabled but the base url '%v' is not using https. Please review your configuration.", lib.GetBaseUrl()))
 		}
 		slog.Info(fmt.Sprintf("listening on host:port %v:%v (https)", host, port))
+		if lib.IsMTLSEnabled() {
+			// client certificates are requested but not verified in the handshake, so that clients
+			// without one still connect; they are checked during client authentication (RFC 8705)
+			slog.Info("mTLS client authentication enabled")
+			httpServer := &http.Server{
+				Addr:    fmt.Sprintf("%v:%v", host, port),
+				Handler: s.router,
+				TLSConfig: &tls.Config{
+					ClientAuth: tls.RequestClientCert,
+				},
+			}
+			log.Fatal(httpServer.ListenAndServeTLS(certFile, keyFile))
+		}
 		log.Fatal(http.ListenAndServeTLS(fmt.Sprintf("%v:%v", host, port), certFile, keyFile, s.router))
 	} else {
 		// non-TLS mode